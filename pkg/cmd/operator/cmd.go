@@ -1,19 +1,85 @@
 package operator
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
 
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/eventbudget"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/informerresync"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 )
 
 func NewOperator() *cobra.Command {
-	cmd := controllercmd.
-		NewControllerCommandConfig("kube-controller-manager-operator", version.Get(), operator.RunOperator).
-		NewCommand()
+	ctx, restart := context.WithCancel(context.Background())
+	resyncPeriods := informerresync.DefaultPeriods()
+	eventBudget := eventbudget.DefaultBudget()
+	wait := &leaseWait{name: leadership.LockName}
+	cmdConfig := controllercmd.NewControllerCommandConfig("kube-controller-manager-operator", version.Get(), wait.wrap(operator.NewRunOperator(&resyncPeriods, &eventBudget, restart))).
+		WithTopologyDetector(newRetryingTopologyDetector())
+	cmd := cmdConfig.NewCommandWithContext(ctx)
 	cmd.Use = "operator"
 	cmd.Short = "Start the Cluster kube-controller-manager Operator"
 
+	leaderElectionConfig := &leaderElectionConfigFile{restart: restart}
+	leaderElectionConfig.AddFlags(cmd.Flags())
+	leaderElectionOverride := &leaderElectionOverride{restart: restart}
+	leaderElectionDisable := &leaderElectionDisable{}
+	leaderElectionFinalizer := &leaderElectionFinalizer{}
+	leaderElectionFinalizer.AddFlags(cmd.Flags())
+	unixSocket := &unixSocketServer{}
+	unixSocket.AddFlags(cmd.Flags())
+	resyncPeriods.AddFlags(cmd.Flags())
+	eventBudget.AddFlags(cmd.Flags())
+	lockNamespace := &lockNamespaceGuard{restart: restart}
+	lockNamespace.AddFlags(cmd.Flags())
+	run := cmd.Run
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if err := leaderElectionConfig.apply(ctx, cmdConfig); err != nil {
+			klog.Fatal(err)
+		}
+		if err := leaderElectionOverride.apply(ctx, cmd.Flags(), cmdConfig); err != nil {
+			klog.Fatal(err)
+		}
+		if err := leaderElectionDisable.apply(ctx, cmd.Flags(), cmdConfig); err != nil {
+			klog.Fatal(err)
+		}
+		// last, whatever the timings were composed from
+		if err := leaderElectionFinalizer.apply(cmdConfig); err != nil {
+			klog.Fatal(err)
+		}
+		if err := unixSocket.apply(cmdConfig); err != nil {
+			klog.Fatal(err)
+		}
+		if err := resyncPeriods.Validate(); err != nil {
+			klog.Fatal(err)
+		}
+		if err := eventBudget.Validate(); err != nil {
+			klog.Fatal(err)
+		}
+		socketCtx, stopSocket := context.WithCancel(ctx)
+		socketStopped, err := unixSocket.start(socketCtx)
+		if err != nil {
+			klog.Fatal(err)
+		}
+		defer func() {
+			stopSocket()
+			<-socketStopped
+		}()
+		if !cmdConfig.DisableLeaderElection {
+			if err := lockNamespace.apply(ctx, cmd.Flags()); err != nil {
+				klog.Fatal(err)
+			}
+			if err := wait.apply(ctx, cmd.Flags(), cmdConfig); err != nil {
+				klog.Fatal(err)
+			}
+		}
+		run(cmd, args)
+	}
+
 	return cmd
 }
@@ -0,0 +1,75 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// disableLeaderElectionEnv disables the leader election of the operator when set to true, e.g. to run it locally
+	// against a cluster or as the only replica of a hosted control plane. It takes precedence over the file of
+	// --leader-election-config and the override.
+	disableLeaderElectionEnv = "DISABLE_LEADER_ELECTION"
+	// operatorDeploymentName is the Deployment of the operator in the operator namespace.
+	operatorDeploymentName = "kube-controller-manager-operator"
+)
+
+// leaderElectionDisable guards running the operator without leader election. Without a lease every replica runs the
+// controllers, which then fight over every object they write. The leader election can only be disabled while the
+// operator Deployment runs at most one replica, a local operator requires the Deployment to be scaled down.
+type leaderElectionDisable struct {
+	deployments appsv1client.DeploymentsGetter
+	getenv      func(string) string
+}
+
+// apply disables the leader election on cmdConfig when disableLeaderElectionEnv is set and checks the replicas of the
+// operator Deployment when the leader election is disabled by any means.
+func (d *leaderElectionDisable) apply(ctx context.Context, flags *pflag.FlagSet, cmdConfig *controllercmd.ControllerCommandConfig) error {
+	if d.getenv == nil {
+		d.getenv = os.Getenv
+	}
+	if value := d.getenv(disableLeaderElectionEnv); len(value) > 0 {
+		disable, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s=%q: %w", disableLeaderElectionEnv, value, err)
+		}
+		if disable {
+			cmdConfig.DisableLeaderElection = true
+		}
+	}
+	if !cmdConfig.DisableLeaderElection {
+		return nil
+	}
+
+	if d.deployments == nil {
+		kubeClient, err := kubeClientOf(flags)
+		if err != nil {
+			return err
+		}
+		d.deployments = kubeClient.AppsV1()
+	}
+	deployment, err := d.deployments.Deployments(operatorclient.OperatorNamespace).Get(ctx, operatorDeploymentName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("unable to check the replicas of the operator before disabling the leader election: %w", err)
+	// the API server defaults the replicas to 1
+	case deployment.Spec.Replicas != nil && *deployment.Spec.Replicas > 1:
+		return fmt.Errorf("the leader election cannot be disabled, deployment %s/%s runs %d replicas", operatorclient.OperatorNamespace, operatorDeploymentName, *deployment.Spec.Replicas)
+	}
+
+	klog.Warningf("LEADER ELECTION IS DISABLED. The operator runs its controllers without holding the lease, no other replica of it must run.")
+	return nil
+}
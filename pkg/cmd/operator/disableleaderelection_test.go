@@ -0,0 +1,88 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
+)
+
+func operatorDeployment(replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: operatorDeploymentName},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(replicas)},
+	}
+}
+
+func TestLeaderElectionDisable(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		// disabled is the leader election disabled by the config file or the override
+		disabled         bool
+		objects          []runtime.Object
+		expectedDisabled bool
+		expectedErr      string
+	}{
+		{name: "enabled", objects: []runtime.Object{operatorDeployment(3)}},
+		{name: "env false", env: "false", objects: []runtime.Object{operatorDeployment(3)}},
+		{name: "env true with one replica", env: "true", objects: []runtime.Object{operatorDeployment(1)}, expectedDisabled: true},
+		{name: "env true with the operator scaled down", env: "true", objects: []runtime.Object{operatorDeployment(0)}, expectedDisabled: true},
+		{name: "env true without the operator deployment", env: "1", expectedDisabled: true},
+		{
+			name:             "env true with multiple replicas",
+			env:              "true",
+			objects:          []runtime.Object{operatorDeployment(2)},
+			expectedDisabled: true,
+			expectedErr:      "the leader election cannot be disabled, deployment openshift-kube-controller-manager-operator/kube-controller-manager-operator runs 2 replicas",
+		},
+		{
+			name:             "config with multiple replicas",
+			disabled:         true,
+			objects:          []runtime.Object{operatorDeployment(2)},
+			expectedDisabled: true,
+			expectedErr:      "the leader election cannot be disabled, deployment openshift-kube-controller-manager-operator/kube-controller-manager-operator runs 2 replicas",
+		},
+		{
+			name:        "invalid env",
+			env:         "yes",
+			expectedErr: `DISABLE_LEADER_ELECTION="yes": strconv.ParseBool: parsing "yes": invalid syntax`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &leaderElectionDisable{
+				deployments: fake.NewSimpleClientset(test.objects...).AppsV1(),
+				getenv: func(name string) string {
+					if name == disableLeaderElectionEnv {
+						return test.env
+					}
+					return ""
+				},
+			}
+			cmdConfig := controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+			cmdConfig.DisableLeaderElection = test.disabled
+
+			err := d.apply(context.Background(), nil, cmdConfig)
+			switch {
+			case len(test.expectedErr) > 0 && (err == nil || err.Error() != test.expectedErr):
+				t.Fatalf("expected %q, got %v", test.expectedErr, err)
+			case len(test.expectedErr) == 0 && err != nil:
+				t.Fatal(err)
+			}
+			if cmdConfig.DisableLeaderElection != test.expectedDisabled {
+				t.Errorf("expected the leader election disabled %v, got %v", test.expectedDisabled, cmdConfig.DisableLeaderElection)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// leaderElectionConfigFile overrides the leader election timings of the operator with a configv1.LeaderElection
+// fragment read from a file, e.g. a mounted configmap. The elector cannot change its timings while it runs, a changed
+// file gracefully releases the lease and restarts the operator, which acquires the lease with the new timings.
+// Invalid content is rejected, the operator keeps running with the previous timings.
+type leaderElectionConfigFile struct {
+	path string
+
+	// current is the leader election config the operator runs with
+	current configv1.LeaderElection
+	// restart gracefully shuts the operator down, releasing the lease
+	restart func()
+}
+
+func (f *leaderElectionConfigFile) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.path, "leader-election-config", f.path, "Optional file with a config.openshift.io/v1 LeaderElection overriding the leader election timings of the operator. Changes restart the operator after releasing the lease.")
+}
+
+// apply sets the leader election config of the file on cmdConfig and starts watching the file for changes until ctx
+// is done.
+func (f *leaderElectionConfigFile) apply(ctx context.Context, cmdConfig *controllercmd.ControllerCommandConfig) error {
+	if len(f.path) == 0 {
+		return nil
+	}
+	content, err := compat.ReadFile(ctx, f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if config, err := leadership.ParseConfig(content); err != nil {
+		klog.Errorf("Ignoring the leader election config of %s: %v", f.path, err)
+	} else {
+		f.current = config
+	}
+	klog.Infof("Leader election of the operator: %s", effective(f.current))
+
+	cmdConfig.DisableLeaderElection = f.current.Disable
+	cmdConfig.LeaseDuration = f.current.LeaseDuration
+	cmdConfig.RenewDeadline = f.current.RenewDeadline
+	cmdConfig.RetryPeriod = f.current.RetryPeriod
+
+	observer, err := fileobserver.NewObserver(10 * time.Second)
+	if err != nil {
+		return err
+	}
+	observer.AddReactor(f.react, map[string][]byte{f.path: content}, f.path)
+	go observer.Run(ctx.Done())
+	return nil
+}
+
+// react restarts the operator when the file changed the leader election config. The file observer calls it without a
+// context, the file was just read by the observer itself.
+func (f *leaderElectionConfigFile) react(file string, action fileobserver.ActionType) error {
+	var content []byte
+	if action != fileobserver.FileDeleted {
+		var err error
+		if content, err = os.ReadFile(file); err != nil {
+			return err
+		}
+	}
+	config, err := leadership.ParseConfig(content)
+	if err != nil {
+		klog.Errorf("Ignoring the change of the leader election config, %s: %v", action.String(file), err)
+		return nil
+	}
+	if config == f.current {
+		return nil
+	}
+
+	klog.Infof("Restarting to change the leader election of the operator from %s to %s", effective(f.current), effective(config))
+	f.current = config
+	f.restart()
+	return nil
+}
+
+// effective describes the leader election config after defaulting. Without timings controllercmd uses the SNO
+// timings on a single replica topology instead. The lock is named for the defaulting not to read the namespace from
+// the service account, only the timings are used.
+func effective(config configv1.LeaderElection) string {
+	if config.Disable {
+		return "disabled"
+	}
+	defaulted := leaderelectionconverter.LeaderElectionDefaulting(config, operatorclient.OperatorNamespace, leadership.LockName)
+	return fmt.Sprintf("leaseDuration=%s renewDeadline=%s retryPeriod=%s", defaulted.LeaseDuration.Duration, defaulted.RenewDeadline.Duration, defaulted.RetryPeriod.Duration)
+}
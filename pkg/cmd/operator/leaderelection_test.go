@@ -0,0 +1,143 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
+)
+
+func writeLeaderElectionConfig(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaderElectionConfigFileOverridesAtStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader-election.yaml")
+	writeLeaderElectionConfig(t, path, "leaseDuration: 137s\nrenewDeadline: 107s\nretryPeriod: 26s\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmdConfig := controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+	f := &leaderElectionConfigFile{path: path, restart: func() { t.Error("unexpected restart") }}
+	if err := f.apply(ctx, cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if cmdConfig.LeaseDuration.Duration != 137*time.Second || cmdConfig.RenewDeadline.Duration != 107*time.Second || cmdConfig.RetryPeriod.Duration != 26*time.Second {
+		t.Errorf("expected the timings of the file, got %v %v %v", cmdConfig.LeaseDuration, cmdConfig.RenewDeadline, cmdConfig.RetryPeriod)
+	}
+
+	// invalid content at startup leaves the defaults
+	writeLeaderElectionConfig(t, path, "leaseDuration: 10s\nrenewDeadline: 20s\n")
+	cmdConfig = controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+	f = &leaderElectionConfigFile{path: path, restart: func() { t.Error("unexpected restart") }}
+	if err := f.apply(ctx, cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if cmdConfig.LeaseDuration.Duration != 0 || cmdConfig.RenewDeadline.Duration != 0 || cmdConfig.RetryPeriod.Duration != 0 {
+		t.Errorf("expected the defaults, got %v %v %v", cmdConfig.LeaseDuration, cmdConfig.RenewDeadline, cmdConfig.RetryPeriod)
+	}
+}
+
+func TestLeaderElectionConfigFileChanges(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		action          fileobserver.ActionType
+		expectedRestart bool
+		expectedCurrent configv1.LeaderElection
+	}{
+		{
+			name:            "changed timings",
+			content:         "leaseDuration: 90s\nrenewDeadline: 60s\nretryPeriod: 15s\n",
+			expectedRestart: true,
+			expectedCurrent: configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 90 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 60 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 15 * time.Second},
+			},
+		},
+		{
+			name:            "deleted",
+			action:          fileobserver.FileDeleted,
+			expectedRestart: true,
+		},
+		{
+			name:    "unchanged timings",
+			content: "# reformatted\nretryPeriod: 10s\nrenewDeadline: 40s\nleaseDuration: 1m\n",
+		},
+		{
+			name:    "renew deadline beyond the lease",
+			content: "leaseDuration: 60s\nrenewDeadline: 60s\nretryPeriod: 10s\n",
+		},
+		{
+			name:    "retry period beyond the renew deadline",
+			content: "leaseDuration: 60s\nrenewDeadline: 40s\nretryPeriod: 35s\n",
+		},
+		{
+			name:    "unknown field",
+			content: "leaseDurationSeconds: 60\n",
+		},
+		{
+			name:    "lease name",
+			content: "name: other-lock\n",
+		},
+		{
+			name:    "not yaml",
+			content: "leaseDuration: [",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "leader-election.yaml")
+			writeLeaderElectionConfig(t, path, test.content)
+			if test.action == fileobserver.FileDeleted {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			previous := configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 60 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 40 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 10 * time.Second},
+			}
+			var restarted bool
+			f := &leaderElectionConfigFile{path: path, current: previous, restart: func() { restarted = true }}
+			if err := f.react(path, test.action); err != nil {
+				t.Fatal(err)
+			}
+
+			if restarted != test.expectedRestart {
+				t.Errorf("expected restart %v, got %v", test.expectedRestart, restarted)
+			}
+			expectedCurrent := previous
+			if test.expectedRestart {
+				expectedCurrent = test.expectedCurrent
+			}
+			if f.current != expectedCurrent {
+				t.Errorf("expected the config %s, got %s", effective(expectedCurrent), effective(f.current))
+			}
+		})
+	}
+}
+
+func TestEffectiveLeaderElection(t *testing.T) {
+	if actual := effective(configv1.LeaderElection{RetryPeriod: metav1.Duration{Duration: 10 * time.Second}}); actual != "leaseDuration=2m17s renewDeadline=1m47s retryPeriod=10s" {
+		t.Errorf("expected the defaults with the given retry period, got %q", actual)
+	}
+	if actual := effective(configv1.LeaderElection{Disable: true}); actual != "disabled" {
+		t.Errorf("expected disabled, got %q", actual)
+	}
+}
@@ -0,0 +1,47 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+)
+
+// leaderElectionFinalizer checks the leader election config on cmdConfig once the file of --leader-election-config,
+// the override and the environment are applied, right before controllercmd builds the elector from it. The timings
+// are clamped with a warning, or rejected with --leader-election-strict. The single replica timings controllercmd
+// uses without timings satisfy the checks.
+type leaderElectionFinalizer struct {
+	strict bool
+}
+
+func (f *leaderElectionFinalizer) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&f.strict, "leader-election-strict", f.strict, "Fail instead of clamping leader election timings that do not leave 30s of clock skew between the lease duration and the renew deadline or retry less than twice before the deadline.")
+}
+
+func (f *leaderElectionFinalizer) apply(cmdConfig *controllercmd.ControllerCommandConfig) error {
+	config := configv1.LeaderElection{
+		Disable:       cmdConfig.DisableLeaderElection,
+		LeaseDuration: cmdConfig.LeaseDuration,
+		RenewDeadline: cmdConfig.RenewDeadline,
+		RetryPeriod:   cmdConfig.RetryPeriod,
+	}
+	finalized, clamped, err := leadership.FinalizeConfig(config, f.strict)
+	if err != nil {
+		return fmt.Errorf("invalid leader election of the operator: %w", err)
+	}
+	if len(clamped) == 0 {
+		return nil
+	}
+	klog.Warningf("Clamping the leader election of the operator: %s", strings.Join(clamped, ", "))
+	cmdConfig.LeaseDuration = finalized.LeaseDuration
+	cmdConfig.RenewDeadline = finalized.RenewDeadline
+	cmdConfig.RetryPeriod = finalized.RetryPeriod
+	return nil
+}
@@ -0,0 +1,52 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
+)
+
+func TestLeaderElectionFinalizer(t *testing.T) {
+	withTimings := func(leaseDuration, renewDeadline, retryPeriod time.Duration) *controllercmd.ControllerCommandConfig {
+		cmdConfig := controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+		cmdConfig.LeaseDuration = metav1.Duration{Duration: leaseDuration}
+		cmdConfig.RenewDeadline = metav1.Duration{Duration: renewDeadline}
+		cmdConfig.RetryPeriod = metav1.Duration{Duration: retryPeriod}
+		return cmdConfig
+	}
+
+	// no timings leave controllercmd to pick the defaults or the single replica timings
+	cmdConfig := withTimings(0, 0, 0)
+	if err := (&leaderElectionFinalizer{strict: true}).apply(cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if cmdConfig.LeaseDuration.Duration != 0 || cmdConfig.RenewDeadline.Duration != 0 || cmdConfig.RetryPeriod.Duration != 0 {
+		t.Errorf("expected no timings, got %v %v %v", cmdConfig.LeaseDuration, cmdConfig.RenewDeadline, cmdConfig.RetryPeriod)
+	}
+
+	// the single replica lease duration with a longer renew deadline leaves 10s of clock skew
+	cmdConfig = withTimings(270*time.Second, 260*time.Second, 60*time.Second)
+	if err := (&leaderElectionFinalizer{}).apply(cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if cmdConfig.LeaseDuration.Duration != 290*time.Second || cmdConfig.RenewDeadline.Duration != 260*time.Second || cmdConfig.RetryPeriod.Duration != 60*time.Second {
+		t.Errorf("expected the lease duration to be clamped, got %v %v %v", cmdConfig.LeaseDuration, cmdConfig.RenewDeadline, cmdConfig.RetryPeriod)
+	}
+
+	cmdConfig = withTimings(270*time.Second, 260*time.Second, 60*time.Second)
+	if err := (&leaderElectionFinalizer{strict: true}).apply(cmdConfig); err == nil {
+		t.Errorf("expected the timings to be rejected")
+	}
+
+	cmdConfig = withTimings(270*time.Second, 260*time.Second, 60*time.Second)
+	cmdConfig.DisableLeaderElection = true
+	if err := (&leaderElectionFinalizer{strict: true}).apply(cmdConfig); err != nil {
+		t.Errorf("expected a disabled leader election not to be checked, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+var (
+	leaderElectionAcquisitionsMetric = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      "openshift",
+		Subsystem:      "kube_controller_manager_operator",
+		Name:           "leader_election_acquisitions_total",
+		Help:           "Acquisitions of the operator lease by this replica",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"lock_namespace", "lock_name", "identity"})
+
+	// the holder of a lost lease exits before it is scraped again, the transitions recorded in the lease are read by
+	// the next holder instead
+	leaderElectionTransitionsMetric = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      "openshift",
+		Subsystem:      "kube_controller_manager_operator",
+		Name:           "leader_election_lease_transitions",
+		Help:           "Changes of the holder of the operator lease recorded in the lease when this replica acquired it. An increase without a rollout of the operator is a lost lease.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"lock_namespace", "lock_name", "identity"})
+
+	leaderElectionRenewDurationMetric = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      "openshift",
+		Subsystem:      "kube_controller_manager_operator",
+		Name:           "leader_election_renew_duration_seconds",
+		Help:           "Round trip latency of the updates of the operator lease, which renew it once it is acquired",
+		Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"lock_namespace", "lock_name", "identity"})
+)
+
+func init() {
+	legacyregistry.MustRegister(leaderElectionAcquisitionsMetric)
+	legacyregistry.MustRegister(leaderElectionTransitionsMetric)
+	legacyregistry.MustRegister(leaderElectionRenewDurationMetric)
+}
+
+// recordAcquisition counts the acquisition of the lease by identity and records the transitions of the lease.
+func (w *leaseWait) recordAcquisition(ctx context.Context) {
+	leaderElectionAcquisitionsMetric.WithLabelValues(w.namespace, w.name, w.identity).Inc()
+	lease, err := w.leases.Leases(w.namespace).Get(ctx, w.name, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).Infof("Unable to read the transitions of lease %s/%s: %v", w.namespace, w.name, err)
+		return
+	}
+	if lease.Spec.LeaseTransitions != nil {
+		leaderElectionTransitionsMetric.WithLabelValues(w.namespace, w.name, w.identity).Set(float64(*lease.Spec.LeaseTransitions))
+	}
+}
+
+// leaseRenewLatency observes the latency of the lease updates of the elector, next observes all requests. client-go
+// reports the latency with a URL without namespace and name, the elector is the only steady writer of leases of the
+// operator process.
+type leaseRenewLatency struct {
+	next      clientmetrics.LatencyMetric
+	namespace string
+	name      string
+	identity  string
+}
+
+func (l *leaseRenewLatency) Observe(ctx context.Context, verb string, u url.URL, latency time.Duration) {
+	if verb == http.MethodPut && strings.HasSuffix(u.Path, "/apis/coordination.k8s.io/v1/namespaces/{namespace}/leases/{name}") {
+		leaderElectionRenewDurationMetric.WithLabelValues(l.namespace, l.name, l.identity).Observe(latency.Seconds())
+	}
+	l.next.Observe(ctx, verb, u, latency)
+}
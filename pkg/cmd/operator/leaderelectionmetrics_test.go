@@ -0,0 +1,69 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+)
+
+func TestAcquisitionIsRecorded(t *testing.T) {
+	lease := heldLease("operator-1", leaseWaitStart)
+	lease.Spec.LeaseTransitions = ptr.To[int32](7)
+	w, _, _, _ := newLeaseWait(fake.NewSimpleClientset(lease))
+	w.identity = "operator-1"
+	acquisitions := leaderElectionAcquisitionsMetric.WithLabelValues(w.namespace, w.name, w.identity)
+	before, _ := testutil.GetCounterMetricValue(acquisitions)
+
+	started := false
+	start := w.wrap(func(ctx context.Context, cc *controllercmd.ControllerContext) error {
+		started = true
+		return nil
+	})
+	if err := start(context.TODO(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if !started {
+		t.Error("expected the operator to be started")
+	}
+	if after, _ := testutil.GetCounterMetricValue(acquisitions); after != before+1 {
+		t.Errorf("expected an acquisition, got %v", after-before)
+	}
+	if transitions, _ := testutil.GetGaugeMetricValue(leaderElectionTransitionsMetric.WithLabelValues(w.namespace, w.name, w.identity)); transitions != 7 {
+		t.Errorf("expected the transitions of the lease, got %v", transitions)
+	}
+}
+
+type fakeLatency struct {
+	observed int
+}
+
+func (f *fakeLatency) Observe(ctx context.Context, verb string, u url.URL, latency time.Duration) {
+	f.observed++
+}
+
+func TestLeaseRenewLatency(t *testing.T) {
+	next := &fakeLatency{}
+	latency := &leaseRenewLatency{next: next, namespace: "openshift-kube-controller-manager-operator", name: "kube-controller-manager-operator-lock", identity: "operator-1"}
+	renewals := leaderElectionRenewDurationMetric.WithLabelValues(latency.namespace, latency.name, latency.identity)
+	before, _ := testutil.GetHistogramMetricCount(renewals)
+
+	leaseURL := url.URL{Scheme: "https", Host: "api:6443", Path: "/apis/coordination.k8s.io/v1/namespaces/{namespace}/leases/{name}"}
+	latency.Observe(context.TODO(), "PUT", leaseURL, 300*time.Millisecond)
+	// the reads of the elector and other requests are not renewals
+	latency.Observe(context.TODO(), "GET", leaseURL, time.Second)
+	latency.Observe(context.TODO(), "PUT", url.URL{Path: "/api/v1/namespaces/{namespace}/configmaps/{name}"}, time.Second)
+
+	if after, _ := testutil.GetHistogramMetricCount(renewals); after != before+1 {
+		t.Errorf("expected a renewal, got %d", after-before)
+	}
+	if next.observed != 3 {
+		t.Errorf("expected all requests to be passed on, got %d", next.observed)
+	}
+}
@@ -0,0 +1,136 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+)
+
+// leaderElectionOverridePollPeriod is how often the override is checked for changes while the elector runs.
+const leaderElectionOverridePollPeriod = 30 * time.Second
+
+// leaderElectionOverride overrides the leader election timings of the operator with leadership.OverrideField of the
+// unsupportedConfigOverrides of kubecontrollermanager/cluster. It takes precedence over the file of
+// --leader-election-config. The override is read before the elector starts and checked with a slow cadence while it
+// runs, a changed override gracefully releases the lease and restarts the operator. An invalid override is ignored,
+// the operator reports it with TargetConfigControllerDegraded once it leads.
+type leaderElectionOverride struct {
+	operators  dynamic.ResourceInterface
+	pollPeriod time.Duration
+	// restart gracefully shuts the operator down, releasing the lease
+	restart func()
+
+	// current is the override the operator runs with, nil without one
+	current *configv1.LeaderElection
+	// ignored is the last reason an override was ignored for, it is logged once
+	ignored string
+}
+
+// apply sets the leader election config of the override on cmdConfig and starts checking the override for changes.
+func (o *leaderElectionOverride) apply(ctx context.Context, flags *pflag.FlagSet, cmdConfig *controllercmd.ControllerCommandConfig) error {
+	if o.operators == nil {
+		clientConfig, err := clientConfigOf(flags)
+		if err != nil {
+			return err
+		}
+		dynamicClient, err := dynamic.NewForConfig(clientConfig)
+		if err != nil {
+			return err
+		}
+		o.operators = dynamicClient.Resource(operatorv1.GroupVersion.WithResource("kubecontrollermanagers"))
+	}
+	if o.pollPeriod == 0 {
+		o.pollPeriod = leaderElectionOverridePollPeriod
+	}
+
+	override, err := o.read(ctx)
+	if err != nil {
+		// the check continues while the elector runs and restarts the operator once the override can be read
+		klog.Warningf("Unable to read the leader election override of the operator: %v", err)
+	}
+	o.current = override
+	if override != nil {
+		klog.Infof("Leader election of the operator overridden by unsupportedConfigOverrides.%s: %s", leadership.OverrideField, effective(*override))
+		cmdConfig.DisableLeaderElection = override.Disable
+		cmdConfig.LeaseDuration = override.LeaseDuration
+		cmdConfig.RenewDeadline = override.RenewDeadline
+		cmdConfig.RetryPeriod = override.RetryPeriod
+	}
+	go o.watch(ctx)
+	return nil
+}
+
+// read returns the valid override, nil without one. An invalid override is ignored.
+func (o *leaderElectionOverride) read(ctx context.Context) (*configv1.LeaderElection, error) {
+	operator, err := o.operators.Get(ctx, "cluster", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	overrides, found, err := unstructured.NestedFieldNoCopy(operator.Object, "spec", "unsupportedConfigOverrides")
+	if err != nil || !found || overrides == nil {
+		return nil, err
+	}
+	content, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+	override, err := leadership.ConfigOverride(content)
+	if err != nil {
+		if err.Error() != o.ignored {
+			o.ignored = err.Error()
+			klog.Errorf("Ignoring the leader election override of the operator: %v", err)
+		}
+		return o.current, nil
+	}
+	o.ignored = ""
+	return override, nil
+}
+
+// watch restarts the operator when the override changes.
+func (o *leaderElectionOverride) watch(ctx context.Context) {
+	_ = wait.PollUntilContextCancel(ctx, o.pollPeriod, false, func(ctx context.Context) (bool, error) {
+		override, err := o.read(ctx)
+		if err != nil {
+			klog.V(2).Infof("Unable to read the leader election override of the operator: %v", err)
+			return false, nil
+		}
+		if equalLeaderElection(override, o.current) {
+			return false, nil
+		}
+		klog.Infof("Restarting to change the leader election override of the operator from %s to %s", describeOverride(o.current), describeOverride(override))
+		o.restart()
+		return true, nil
+	})
+}
+
+func equalLeaderElection(a, b *configv1.LeaderElection) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// describeOverride describes an override, or its absence.
+func describeOverride(override *configv1.LeaderElection) string {
+	if override == nil {
+		return "none"
+	}
+	return effective(*override)
+}
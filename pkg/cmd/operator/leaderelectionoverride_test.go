@@ -0,0 +1,87 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
+)
+
+func kubeControllerManager(overrides map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operator.openshift.io/v1",
+		"kind":       "KubeControllerManager",
+		"metadata":   map[string]interface{}{"name": "cluster"},
+		"spec":       map[string]interface{}{"unsupportedConfigOverrides": overrides},
+	}}
+}
+
+func TestLeaderElectionOverride(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), kubeControllerManager(map[string]interface{}{
+		"operatorLeaderElection": map[string]interface{}{"leaseDuration": "270s", "renewDeadline": "240s", "retryPeriod": "60s"},
+	}))
+	operators := client.Resource(operatorv1.GroupVersion.WithResource("kubecontrollermanagers"))
+	restarted := make(chan struct{})
+	o := &leaderElectionOverride{operators: operators, pollPeriod: 10 * time.Millisecond, restart: func() { close(restarted) }}
+
+	cmdConfig := controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+	cmdConfig.RetryPeriod = metav1.Duration{Duration: 5 * time.Second}
+	if err := o.apply(ctx, nil, cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	// the override takes precedence over the file
+	if cmdConfig.LeaseDuration.Duration != 270*time.Second || cmdConfig.RenewDeadline.Duration != 240*time.Second || cmdConfig.RetryPeriod.Duration != 60*time.Second {
+		t.Errorf("expected the timings of the override, got %v %v %v", cmdConfig.LeaseDuration, cmdConfig.RenewDeadline, cmdConfig.RetryPeriod)
+	}
+
+	// an invalid change is ignored
+	if _, err := operators.Update(ctx, kubeControllerManager(map[string]interface{}{
+		"operatorLeaderElection": map[string]interface{}{"leaseDuration": "100s", "renewDeadline": "240s"},
+	}), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-restarted:
+		t.Fatal("unexpected restart for an invalid override")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// removing the override restarts with the timings of the file
+	if _, err := operators.Update(ctx, kubeControllerManager(nil), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-restarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a restart")
+	}
+}
+
+func TestInvalidLeaderElectionOverrideAtStartup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), kubeControllerManager(map[string]interface{}{
+		"operatorLeaderElection": map[string]interface{}{"retryPeriod": "120s"},
+	}))
+	o := &leaderElectionOverride{operators: client.Resource(operatorv1.GroupVersion.WithResource("kubecontrollermanagers")), restart: func() { t.Error("unexpected restart") }}
+
+	cmdConfig := controllercmd.NewControllerCommandConfig("test", version.Get(), operator.RunOperator)
+	if err := o.apply(ctx, nil, cmdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if o.current != nil || cmdConfig.RetryPeriod.Duration != 0 {
+		t.Errorf("expected the defaults, got %v %v", o.current, cmdConfig.RetryPeriod)
+	}
+}
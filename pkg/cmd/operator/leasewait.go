@@ -0,0 +1,228 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+)
+
+const (
+	// leaseWaitTolerance is how much longer than the worst case the wait may take before it is reported as a problem
+	leaseWaitTolerance = 1.2
+)
+
+// leaseWaitProgress are the fractions of the worst case wait the progress of the wait is logged at.
+var leaseWaitProgress = []float64{0.25, 0.5, 0.75}
+
+var leaseWaitMetric = metrics.NewGauge(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "leader_election_wait_seconds",
+	Help:           "Seconds from the start of the leader election of the operator to the acquisition of the operator lease",
+	StabilityLevel: metrics.ALPHA,
+})
+
+func init() {
+	legacyregistry.MustRegister(leaseWaitMetric)
+}
+
+// leader is the holder of a lease.
+type leader struct {
+	identity      string
+	renewTime     time.Time
+	leaseDuration time.Duration
+}
+
+// getCurrentLeader returns the holder of the lease, nil when the lease does not exist or is released.
+func getCurrentLeader(ctx context.Context, leases coordinationv1client.LeasesGetter, namespace, name string) (*leader, error) {
+	lease, err := leases.Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lease.Spec.HolderIdentity == nil || len(*lease.Spec.HolderIdentity) == 0 {
+		return nil, nil
+	}
+	current := &leader{identity: *lease.Spec.HolderIdentity}
+	if lease.Spec.RenewTime != nil {
+		current.renewTime = lease.Spec.RenewTime.Time
+	}
+	if lease.Spec.LeaseDurationSeconds != nil {
+		current.leaseDuration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return current, nil
+}
+
+// leaseWait explains the wait for the operator lease at startup. A restarted operator cannot acquire the lease before
+// the lease of the previous holder expired, which is up to its lease duration after its last renewal, and notices it
+// with its next retry. A wait of leaseDuration+retryPeriod is normal after a non-graceful restart, the progress is
+// logged so it is not mistaken for a hang. A wait exceeding the worst case is a problem and reported with an event.
+type leaseWait struct {
+	leases   coordinationv1client.LeasesGetter
+	recorder events.Recorder
+	clock    clock.Clock
+	logf     func(format string, args ...interface{})
+
+	namespace string
+	name      string
+	// identity names this replica in the metrics, it is the pod name the identity of the elector starts with
+	identity      string
+	leaseDuration time.Duration
+	retryPeriod   time.Duration
+
+	lock     sync.Mutex
+	started  time.Time
+	acquired chan struct{}
+}
+
+// apply configures the wait for the lease of controllercmd with the effective timings of cmdConfig and starts timing it.
+func (w *leaseWait) apply(ctx context.Context, flags *pflag.FlagSet, cmdConfig *controllercmd.ControllerCommandConfig) error {
+	namespace, err := lockNamespaceOf(ctx, flags)
+	if err != nil {
+		klog.Warningf("Unable to determine the namespace of the leader election lock, not timing the wait for it: %v", err)
+		return nil
+	}
+	kubeClient, err := kubeClientOf(flags)
+	if err != nil {
+		return err
+	}
+	// without timings controllercmd uses the SNO timings on a single replica topology, whose lease duration is read
+	// from the lease of the holder. The namespace is passed on, without it the defaulting reads it from the service
+	// account again, without a context.
+	defaulted := leaderelectionconverter.LeaderElectionDefaulting(configv1.LeaderElection{
+		LeaseDuration: cmdConfig.LeaseDuration,
+		RenewDeadline: cmdConfig.RenewDeadline,
+		RetryPeriod:   cmdConfig.RetryPeriod,
+	}, namespace, w.name)
+	w.leases = kubeClient.CoordinationV1()
+	w.recorder = events.NewRecorder(kubeClient.CoreV1().Events(namespace), "kube-controller-manager-operator", &corev1.ObjectReference{APIVersion: "coordination.k8s.io/v1", Kind: "Lease", Namespace: namespace, Name: w.name})
+	w.clock = clock.RealClock{}
+	w.logf = klog.Infof
+	w.namespace = namespace
+	if w.identity, err = leadership.Identity(); err != nil {
+		return err
+	}
+	w.leaseDuration = defaulted.LeaseDuration.Duration
+	w.retryPeriod = defaulted.RetryPeriod.Duration
+	// the elector has not made a request yet
+	clientmetrics.RequestLatency = &leaseRenewLatency{next: clientmetrics.RequestLatency, namespace: w.namespace, name: w.name, identity: w.identity}
+	w.start(ctx)
+	return nil
+}
+
+// start starts timing the wait. The worst case uses the lease duration of the current holder, which is what the lease
+// expires with, or leaseDuration without one. The returned channel is closed once the progress is not logged anymore.
+func (w *leaseWait) start(ctx context.Context) <-chan struct{} {
+	w.lock.Lock()
+	w.started = w.clock.Now()
+	w.acquired = make(chan struct{})
+	acquired := w.acquired
+	w.lock.Unlock()
+
+	leaseDuration := w.leaseDuration
+	current, err := getCurrentLeader(ctx, w.leases, w.namespace, w.name)
+	if err != nil {
+		klog.V(2).Infof("Unable to read lease %s/%s: %v", w.namespace, w.name, err)
+	}
+	if current != nil && current.leaseDuration > 0 {
+		leaseDuration = current.leaseDuration
+	}
+	worstCase := leaseDuration + w.retryPeriod
+	w.logf("Waiting for lease %s/%s, at most %s: %s", w.namespace, w.name, worstCase, w.describe(current, leaseDuration))
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.report(ctx, acquired, worstCase, leaseDuration)
+	}()
+	return stopped
+}
+
+// report logs the progress of the wait until the lease is acquired.
+func (w *leaseWait) report(ctx context.Context, acquired <-chan struct{}, worstCase, leaseDuration time.Duration) {
+	deadlines := make([]float64, 0, len(leaseWaitProgress)+1)
+	deadlines = append(deadlines, leaseWaitProgress...)
+	deadlines = append(deadlines, leaseWaitTolerance)
+	for _, fraction := range deadlines {
+		deadline := time.Duration(fraction * float64(worstCase))
+		select {
+		case <-ctx.Done():
+			return
+		case <-acquired:
+			return
+		case <-w.clock.After(deadline - w.clock.Since(w.started)):
+		}
+
+		current, err := getCurrentLeader(ctx, w.leases, w.namespace, w.name)
+		if err != nil {
+			klog.V(2).Infof("Unable to read lease %s/%s: %v", w.namespace, w.name, err)
+		}
+		if fraction < 1 {
+			w.logf("Still waiting for lease %s/%s after %s, %d%% of the worst case %s: %s", w.namespace, w.name, deadline, int(fraction*100), worstCase, w.describe(current, leaseDuration))
+			continue
+		}
+		klog.Warningf("Waiting for lease %s/%s for %s, longer than the worst case %s: %s", w.namespace, w.name, deadline, worstCase, w.describe(current, leaseDuration))
+		w.recorder.Warningf("LeaderElectionWaitExceeded", "The operator is waiting for lease %s/%s for %s, longer than the worst case %s: %s", w.namespace, w.name, deadline, worstCase, w.describe(current, leaseDuration))
+	}
+}
+
+// describe explains when the lease can be acquired.
+func (w *leaseWait) describe(current *leader, leaseDuration time.Duration) string {
+	if current == nil {
+		return fmt.Sprintf("the lease is not held, it is acquired with the next retry within %s", w.retryPeriod)
+	}
+	if current.renewTime.IsZero() {
+		return fmt.Sprintf("held by %s, which never renewed it", current.identity)
+	}
+	expiry := current.renewTime.Add(leaseDuration)
+	if remaining := expiry.Sub(w.clock.Now()); remaining > 0 {
+		return fmt.Sprintf("held by %s, last renewed %s ago, it expires in %s (last renewal + leaseDuration %s) and is acquired with the next retry within %s after", current.identity, w.clock.Since(current.renewTime).Round(time.Second), remaining.Round(time.Second), leaseDuration, w.retryPeriod)
+	}
+	return fmt.Sprintf("held by %s, last renewed %s ago, it expired %s ago and is acquired with the next retry within %s", current.identity, w.clock.Since(current.renewTime).Round(time.Second), w.clock.Since(expiry).Round(time.Second), w.retryPeriod)
+}
+
+// done records the wait once the lease is acquired. It is a no-op without a started wait.
+func (w *leaseWait) done() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.acquired == nil {
+		return
+	}
+	waited := w.clock.Since(w.started)
+	close(w.acquired)
+	w.acquired = nil
+	leaseWaitMetric.Set(waited.Seconds())
+	w.logf("Acquired lease %s/%s after %s", w.namespace, w.name, waited.Round(time.Second))
+}
+
+// wrap records the wait and the acquisition when start is called, which controllercmd does once the lease is
+// acquired. Without leader election neither is recorded.
+func (w *leaseWait) wrap(start controllercmd.StartFunc) controllercmd.StartFunc {
+	return func(ctx context.Context, cc *controllercmd.ControllerContext) error {
+		if w.leases != nil {
+			w.done()
+			w.recordAcquisition(ctx)
+		}
+		return start(ctx, cc)
+	}
+}
@@ -0,0 +1,146 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+var leaseWaitStart = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+func heldLease(holder string, renewed time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-controller-manager-operator", Name: "kube-controller-manager-operator-lock"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(holder),
+			LeaseDurationSeconds: ptr.To[int32](137),
+			RenewTime:            &metav1.MicroTime{Time: renewed},
+		},
+	}
+}
+
+func newLeaseWait(client *fake.Clientset) (*leaseWait, *clocktesting.FakeClock, events.InMemoryRecorder, chan string) {
+	fakeClock := clocktesting.NewFakeClock(leaseWaitStart)
+	recorder := events.NewInMemoryRecorder("test")
+	lines := make(chan string, 10)
+	return &leaseWait{
+		leases:        client.CoordinationV1(),
+		recorder:      recorder,
+		clock:         fakeClock,
+		logf:          func(format string, args ...interface{}) { lines <- fmt.Sprintf(format, args...) },
+		namespace:     "openshift-kube-controller-manager-operator",
+		name:          "kube-controller-manager-operator-lock",
+		leaseDuration: 60 * time.Second,
+		retryPeriod:   26 * time.Second,
+	}, fakeClock, recorder, lines
+}
+
+func expectLine(t *testing.T, lines chan string, expected string) {
+	t.Helper()
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, expected) {
+			t.Errorf("expected a line containing %q, got %q", expected, line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a line containing %q", expected)
+	}
+}
+
+// step advances the clock once the wait is waiting for it.
+func step(t *testing.T, fakeClock *clocktesting.FakeClock, d time.Duration) {
+	t.Helper()
+	if err := eventually(func() bool { return fakeClock.HasWaiters() }); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock.Step(d)
+}
+
+func eventually(condition func() bool) error {
+	for i := 0; i < 500; i++ {
+		if condition() {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("timed out")
+}
+
+func TestLeaseWaitForExpiringLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the previous operator was killed 100s ago, the lease expires 37s after the start
+	w, fakeClock, recorder, lines := newLeaseWait(fake.NewSimpleClientset(heldLease("old-operator", leaseWaitStart.Add(-100*time.Second))))
+
+	stopped := w.start(ctx)
+	// the lease duration of the holder is used, not the one configured
+	expectLine(t, lines, "at most 2m43s: held by old-operator, last renewed 1m40s ago, it expires in 37s (last renewal + leaseDuration 2m17s)")
+
+	step(t, fakeClock, 41*time.Second)
+	expectLine(t, lines, "after 40.75s, 25% of the worst case 2m43s: held by old-operator, last renewed 2m21s ago, it expired 4s ago and is acquired with the next retry within 26s")
+
+	step(t, fakeClock, 9*time.Second)
+	w.done()
+	expectLine(t, lines, "Acquired lease openshift-kube-controller-manager-operator/kube-controller-manager-operator-lock after 50s")
+	if value, err := testutil.GetGaugeMetricValue(leaseWaitMetric); err != nil || value != 50 {
+		t.Errorf("expected a wait of 50s, got %v %v", value, err)
+	}
+
+	// the progress is not logged after the acquisition
+	<-stopped
+	if len(lines) > 0 || len(recorder.Events()) > 0 {
+		t.Errorf("unexpected lines or events after the acquisition: %d, %v", len(lines), recorder.Events())
+	}
+}
+
+func TestLeaseWaitForReleasedLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, _, _, lines := newLeaseWait(fake.NewSimpleClientset())
+
+	w.start(ctx)
+	expectLine(t, lines, "at most 1m26s: the lease is not held, it is acquired with the next retry within 26s")
+	w.done()
+	expectLine(t, lines, "after 0s")
+}
+
+func TestLeaseWaitExceedingTheWorstCase(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the previous operator keeps renewing the lease, e.g. a second operator deployment
+	client := fake.NewSimpleClientset(heldLease("other-operator", leaseWaitStart))
+	w, fakeClock, recorder, lines := newLeaseWait(client)
+
+	stopped := w.start(ctx)
+	expectLine(t, lines, "held by other-operator, last renewed 0s ago, it expires in 2m17s")
+	elapsed := time.Duration(0)
+	for _, progress := range []string{"25%", "50%", "75%"} {
+		step(t, fakeClock, 41*time.Second)
+		elapsed += 41 * time.Second
+		expectLine(t, lines, progress)
+		if _, err := client.CoordinationV1().Leases(w.namespace).Update(ctx, heldLease("other-operator", leaseWaitStart.Add(elapsed)), metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 120% of the worst case
+	step(t, fakeClock, 196*time.Second-elapsed)
+	<-stopped
+	if len(recorder.Events()) != 1 {
+		t.Fatalf("expected a single event once the wait exceeded the worst case, got %v", recorder.Events())
+	}
+	if event := recorder.Events()[0]; event.Reason != "LeaderElectionWaitExceeded" || !strings.Contains(event.Message, "held by other-operator, last renewed 1m13s ago") {
+		t.Errorf("unexpected event %#v", event)
+	}
+}
@@ -0,0 +1,196 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/config/client"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
+)
+
+// lockNamespaceRetryPeriod is the slow cadence the lock namespace is checked with. A terminating namespace takes
+// minutes to go away, retrying faster only floods the log.
+const lockNamespaceRetryPeriod = 30 * time.Second
+
+// lockNamespaceGuard keeps the operator from looping on the lock of a terminating namespace. The API server rejects
+// the lease updates with conflict and forbidden errors that do not name the cause, and the elector of controllercmd
+// retries them every few seconds without ever acquiring the lease. The guard checks the namespace before the elector
+// starts and while it runs:
+//   - A terminating or deleted namespace is explained in the log and with one event, the operator waits for it to be
+//     recreated with the slow cadence, or holds the lock in the fallback namespace if one is set.
+//   - A namespace terminating while the operator runs restarts the operator, which releases the lease.
+//   - Running on the fallback namespace, a recreated namespace restarts the operator to hold the lock in it again.
+type lockNamespaceGuard struct {
+	fallbackNamespace string
+
+	namespaces  corev1client.NamespacesGetter
+	recorder    events.Recorder
+	retryPeriod time.Duration
+	// restart gracefully shuts the operator down, releasing the lease
+	restart func()
+}
+
+func (g *lockNamespaceGuard) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&g.fallbackNamespace, "leader-election-fallback-namespace", g.fallbackNamespace, "Optional namespace to hold the leader election lock in while its namespace is terminating. The operator moves the lock back once the namespace is recreated.")
+}
+
+// apply waits until the lock can be held and sets the namespace to hold it in on flags. The lock namespace is the
+// namespace of the operator, from the --namespace flag or the service account.
+func (g *lockNamespaceGuard) apply(ctx context.Context, flags *pflag.FlagSet) error {
+	lockNamespace, err := lockNamespaceOf(ctx, flags)
+	if err != nil {
+		klog.Warningf("Unable to determine the namespace of the leader election lock, not checking it: %v", err)
+		return nil
+	}
+	if g.namespaces == nil {
+		kubeClient, err := kubeClientOf(flags)
+		if err != nil {
+			return err
+		}
+		g.namespaces = kubeClient.CoreV1()
+		// events of cluster scoped objects go to the default namespace, the lock namespace cannot take them
+		g.recorder = events.NewRecorder(kubeClient.CoreV1().Events(metav1.NamespaceDefault), "kube-controller-manager-operator", &corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: lockNamespace})
+	}
+	if g.retryPeriod == 0 {
+		g.retryPeriod = lockNamespaceRetryPeriod
+	}
+
+	namespace, err := g.resolve(ctx, lockNamespace)
+	if err != nil {
+		return err
+	}
+	if namespace != lockNamespace {
+		if err := flags.Set("namespace", namespace); err != nil {
+			return err
+		}
+	}
+	go g.watch(ctx, lockNamespace, namespace)
+	return nil
+}
+
+// lockNamespaceOf returns the namespace of the leader election lock, the --namespace flag or the namespace of the
+// service account.
+func lockNamespaceOf(ctx context.Context, flags *pflag.FlagSet) (string, error) {
+	if namespace := flags.Lookup("namespace").Value.String(); len(namespace) > 0 {
+		return namespace, nil
+	}
+	namespace, err := compat.ServiceAccountNamespace(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	if len(namespace) == 0 {
+		return "", fmt.Errorf("no --namespace and no service account namespace")
+	}
+	return namespace, nil
+}
+
+// kubeClientOf returns a client of the cluster of the --kubeconfig flag, the in-cluster config without.
+func kubeClientOf(flags *pflag.FlagSet) (kubernetes.Interface, error) {
+	clientConfig, err := clientConfigOf(flags)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(clientConfig)
+}
+
+// clientConfigOf returns the config of the --kubeconfig flag, the in-cluster config without.
+func clientConfigOf(flags *pflag.FlagSet) (*rest.Config, error) {
+	return client.GetKubeConfigOrInClusterConfig(flags.Lookup("kubeconfig").Value.String(), nil)
+}
+
+// unavailable describes why the lock cannot be held in namespace, it is empty when it can.
+func (g *lockNamespaceGuard) unavailable(ctx context.Context, namespace string) (string, error) {
+	ns, err := g.namespaces.Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return fmt.Sprintf("namespace %s does not exist", namespace), nil
+	case err != nil:
+		return "", err
+	case ns.Status.Phase == corev1.NamespaceTerminating || ns.DeletionTimestamp != nil:
+		return fmt.Sprintf("namespace %s is terminating", namespace), nil
+	}
+	return "", nil
+}
+
+// report explains why the lock cannot be held in lockNamespace.
+func (g *lockNamespaceGuard) report(lockNamespace, reason string) {
+	fallback := "waiting for it to be recreated"
+	if len(g.fallbackNamespace) > 0 {
+		fallback = fmt.Sprintf("holding the lock in namespace %s until it is recreated", g.fallbackNamespace)
+	}
+	klog.Errorf("The leader election lock of the operator cannot be held in namespace %s: %s. Updates of the lock fail with conflict or forbidden errors until the namespace is recreated, %s.", lockNamespace, reason, fallback)
+	g.recorder.Warningf("LeaderElectionNamespaceUnavailable", "The leader election lock cannot be held: %s, %s", reason, fallback)
+}
+
+// resolve returns the namespace to hold the lock in, lockNamespace unless it is unavailable and the fallback namespace
+// is available. Without a fallback namespace it waits for lockNamespace to become available.
+func (g *lockNamespaceGuard) resolve(ctx context.Context, lockNamespace string) (string, error) {
+	reported := false
+	for {
+		reason, err := g.unavailable(ctx, lockNamespace)
+		if err != nil {
+			// the elector reports the errors of the API server itself
+			klog.Warningf("Unable to check the leader election namespace %s: %v", lockNamespace, err)
+			return lockNamespace, nil
+		}
+		if len(reason) == 0 {
+			if reported {
+				klog.Infof("The leader election namespace %s is recreated, resuming", lockNamespace)
+			}
+			return lockNamespace, nil
+		}
+		if !reported {
+			g.report(lockNamespace, reason)
+			reported = true
+		}
+
+		if len(g.fallbackNamespace) > 0 {
+			fallbackReason, err := g.unavailable(ctx, g.fallbackNamespace)
+			if err == nil && len(fallbackReason) == 0 {
+				return g.fallbackNamespace, nil
+			}
+			klog.Errorf("The leader election fallback namespace cannot be used either: %s%v", fallbackReason, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(g.retryPeriod):
+		}
+	}
+}
+
+// watch restarts the operator when the lock is held in lockNamespace and it becomes unavailable, or when the lock is
+// held in the fallback namespace and lockNamespace is available again.
+func (g *lockNamespaceGuard) watch(ctx context.Context, lockNamespace, namespace string) {
+	_ = wait.PollUntilContextCancel(ctx, g.retryPeriod, false, func(ctx context.Context) (bool, error) {
+		reason, err := g.unavailable(ctx, lockNamespace)
+		if err != nil {
+			klog.V(2).Infof("Unable to check the leader election namespace %s: %v", lockNamespace, err)
+			return false, nil
+		}
+		switch {
+		case namespace == lockNamespace && len(reason) > 0:
+			g.report(lockNamespace, reason)
+		case namespace != lockNamespace && len(reason) == 0:
+			klog.Infof("The leader election namespace %s is recreated, restarting to move the lock back from namespace %s", lockNamespace, namespace)
+		default:
+			return false, nil
+		}
+		g.restart()
+		return true, nil
+	})
+}
@@ -0,0 +1,133 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func namespace(name string, phase corev1.NamespacePhase) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.NamespaceStatus{Phase: phase}}
+}
+
+func newGuard(client *fake.Clientset, fallbackNamespace string, restarted chan struct{}) (*lockNamespaceGuard, events.InMemoryRecorder, *pflag.FlagSet) {
+	recorder := events.NewInMemoryRecorder("test")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("namespace", "openshift-kube-controller-manager-operator", "")
+	flags.String("kubeconfig", "", "")
+	return &lockNamespaceGuard{
+		fallbackNamespace: fallbackNamespace,
+		namespaces:        client.CoreV1(),
+		recorder:          recorder,
+		retryPeriod:       10 * time.Millisecond,
+		restart:           func() { close(restarted) },
+	}, recorder, flags
+}
+
+func TestLockNamespaceFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset(
+		namespace("openshift-kube-controller-manager-operator", corev1.NamespaceTerminating),
+		namespace("openshift-kube-controller-manager", corev1.NamespaceActive),
+	)
+	restarted := make(chan struct{})
+	g, recorder, flags := newGuard(client, "openshift-kube-controller-manager", restarted)
+
+	if err := g.apply(ctx, flags); err != nil {
+		t.Fatal(err)
+	}
+	if lockNamespace := flags.Lookup("namespace").Value.String(); lockNamespace != "openshift-kube-controller-manager" {
+		t.Errorf("expected the lock in the fallback namespace, got %s", lockNamespace)
+	}
+	if count := len(recorder.Events()); count != 1 || recorder.Events()[0].Reason != "LeaderElectionNamespaceUnavailable" {
+		t.Errorf("expected a single LeaderElectionNamespaceUnavailable event, got %v", recorder.Events())
+	}
+
+	// still terminating, the operator keeps running on the fallback namespace
+	select {
+	case <-restarted:
+		t.Fatal("unexpected restart while the namespace terminates")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// deleted and recreated
+	if err := client.CoreV1().Namespaces().Delete(ctx, "openshift-kube-controller-manager-operator", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, namespace("openshift-kube-controller-manager-operator", corev1.NamespaceActive), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-restarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected a restart to move the lock back")
+	}
+}
+
+func TestLockNamespaceWaitsForRecreation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset(namespace("openshift-kube-controller-manager-operator", corev1.NamespaceActive))
+	restarted := make(chan struct{})
+	g, recorder, flags := newGuard(client, "", restarted)
+
+	if err := g.apply(ctx, flags); err != nil {
+		t.Fatal(err)
+	}
+	if count := len(recorder.Events()); count != 0 {
+		t.Errorf("expected no events, got %v", recorder.Events())
+	}
+
+	// terminating while the operator runs
+	if _, err := client.CoreV1().Namespaces().Update(ctx, namespace("openshift-kube-controller-manager-operator", corev1.NamespaceTerminating), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-restarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected a restart to release the lease")
+	}
+	if count := len(recorder.Events()); count != 1 {
+		t.Errorf("expected a single event, got %v", recorder.Events())
+	}
+
+	// the restarted operator waits for the namespace to be recreated
+	resolved := make(chan string)
+	go func() {
+		namespace, err := g.resolve(ctx, "openshift-kube-controller-manager-operator")
+		if err != nil {
+			t.Error(err)
+		}
+		resolved <- namespace
+	}()
+	select {
+	case namespace := <-resolved:
+		t.Fatalf("unexpected lock namespace %s while the namespace terminates", namespace)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := client.CoreV1().Namespaces().Delete(ctx, "openshift-kube-controller-manager-operator", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, namespace("openshift-kube-controller-manager-operator", corev1.NamespaceActive), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case namespace := <-resolved:
+		if namespace != "openshift-kube-controller-manager-operator" {
+			t.Errorf("expected the lock in its namespace, got %s", namespace)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the operator to resume once the namespace is recreated")
+	}
+	if count := len(recorder.Events()); count != 2 {
+		t.Errorf("expected one event per unavailability, got %v", recorder.Events())
+	}
+}
@@ -0,0 +1,76 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+)
+
+// topologyDetectionBackoff retries reading infrastructures/cluster for about a minute before the operator starts
+// with the HA leader election timings.
+var topologyDetectionBackoff = wait.Backoff{
+	Duration: 2 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      30 * time.Second,
+}
+
+// retryingTopologyDetector detects the control plane topology the leader election timings of the operator follow.
+// Unless the timings are set explicitly, controllercmd relaxes them with LeaderElectionSNOConfig on a SingleReplica
+// topology. Its own detector reads infrastructures/cluster once and falls back to the HA timings on any error, e.g.
+// while the apiserver is not reachable yet at the start of a single node cluster, and the operator then renews the
+// lease with the HA cadence until its next restart. This detector retries with backoff instead, it only falls back
+// when the backoff is exhausted.
+type retryingTopologyDetector struct {
+	// infrastructures returns the client to read infrastructures/cluster with
+	infrastructures func(*rest.Config) (configv1client.InfrastructuresGetter, error)
+	backoff         wait.Backoff
+}
+
+var _ controllercmd.TopologyDetector = &retryingTopologyDetector{}
+
+func newRetryingTopologyDetector() *retryingTopologyDetector {
+	return &retryingTopologyDetector{
+		infrastructures: func(clientConfig *rest.Config) (configv1client.InfrastructuresGetter, error) {
+			return configv1client.NewForConfig(clientConfig)
+		},
+		backoff: topologyDetectionBackoff,
+	}
+}
+
+func (d *retryingTopologyDetector) DetectTopology(ctx context.Context, clientConfig *rest.Config) (configv1.TopologyMode, error) {
+	client, err := d.infrastructures(clientConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var topology configv1.TopologyMode
+	var lastErr error
+	err = wait.ExponentialBackoffWithContext(ctx, d.backoff, func(ctx context.Context) (bool, error) {
+		infrastructure, err := client.Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
+		if err != nil {
+			klog.V(2).Infof("Unable to read the control plane topology, retrying: %v", err)
+			lastErr = err
+			return false, nil
+		}
+		topology = infrastructure.Status.ControlPlaneTopology
+		return true, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			return "", lastErr
+		}
+		return "", err
+	}
+	klog.Infof("Control plane topology of the leader election of the operator: %s", topology)
+	return topology, nil
+}
@@ -0,0 +1,64 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+)
+
+func TestRetryingTopologyDetector(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		topology configv1.TopologyMode
+		// failures is the number of reads failing before infrastructures/cluster is readable
+		failures int
+		expected configv1.TopologyMode
+		errors   bool
+	}{
+		{name: "single replica", topology: configv1.SingleReplicaTopologyMode, expected: configv1.SingleReplicaTopologyMode},
+		{name: "highly available", topology: configv1.HighlyAvailableTopologyMode, expected: configv1.HighlyAvailableTopologyMode},
+		{name: "external", topology: configv1.ExternalTopologyMode, expected: configv1.ExternalTopologyMode},
+		{name: "single replica readable after retries", topology: configv1.SingleReplicaTopologyMode, failures: 2, expected: configv1.SingleReplicaTopologyMode},
+		{name: "not readable", topology: configv1.SingleReplicaTopologyMode, failures: 10, errors: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := configfake.NewSimpleClientset(&configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.InfrastructureStatus{ControlPlaneTopology: test.topology},
+			})
+			failures := test.failures
+			client.PrependReactor("get", "infrastructures", func(clienttesting.Action) (bool, runtime.Object, error) {
+				if failures == 0 {
+					return false, nil, nil
+				}
+				failures--
+				return true, nil, fmt.Errorf("connection refused")
+			})
+			detector := &retryingTopologyDetector{
+				infrastructures: func(*rest.Config) (configv1client.InfrastructuresGetter, error) {
+					return client.ConfigV1(), nil
+				},
+				backoff: wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 5},
+			}
+
+			topology, err := detector.DetectTopology(context.Background(), &rest.Config{})
+			if test.errors != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if topology != test.expected {
+				t.Errorf("expected topology %q, got %q", test.expected, topology)
+			}
+		})
+	}
+}
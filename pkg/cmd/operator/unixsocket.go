@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/server/routes"
+	"k8s.io/component-base/logs"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/prometheus/slis"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+)
+
+const (
+	// unixSocketAdditional serves on the unix socket next to the TCP listener.
+	unixSocketAdditional = "additional"
+	// unixSocketExclusive serves on the unix socket only, the TCP listener is disabled.
+	unixSocketExclusive = "exclusive"
+)
+
+// unixSocketServer serves the content of the secure TCP listener of the operator, the metrics, the health checks and
+// the debug endpoints, on a unix socket. Environments that forbid additional TCP listeners on the masters scrape the
+// operator with a node local agent through it. The socket is only accessible to the user of the operator, its file
+// permissions take the place of the authentication and authorization of the TCP listener.
+type unixSocketServer struct {
+	path string
+	mode string
+}
+
+func (s *unixSocketServer) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&s.path, "listen-unix-socket", s.path, "Optional path of a unix socket serving the metrics, health checks and debug endpoints of the operator.")
+	flags.StringVar(&s.mode, "listen-unix-socket-mode", unixSocketAdditional, fmt.Sprintf("Whether the unix socket is served %q to the TCP listener or %q, disabling the TCP listener.", unixSocketAdditional, unixSocketExclusive))
+}
+
+// apply disables the TCP listener of cmdConfig when the socket is served exclusively.
+func (s *unixSocketServer) apply(cmdConfig *controllercmd.ControllerCommandConfig) error {
+	switch s.mode {
+	case unixSocketAdditional:
+	case unixSocketExclusive:
+		if len(s.path) == 0 {
+			return fmt.Errorf("--listen-unix-socket-mode=%s requires --listen-unix-socket", unixSocketExclusive)
+		}
+		cmdConfig.DisableServing = true
+	default:
+		return fmt.Errorf("--listen-unix-socket-mode must be %q or %q, got %q", unixSocketAdditional, unixSocketExclusive, s.mode)
+	}
+	return nil
+}
+
+// start serves the socket until ctx is done. The returned channel is closed once the socket is removed again.
+func (s *unixSocketServer) start(ctx context.Context) (<-chan struct{}, error) {
+	stopped := make(chan struct{})
+	if len(s.path) == 0 {
+		close(stopped)
+		return stopped, nil
+	}
+
+	listener, err := listenUnix(s.path)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: newUnixSocketHandler(), ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		<-ctx.Done()
+		// closing the listener removes the socket
+		if err := server.Close(); err != nil {
+			klog.Warningf("Failed to close the unix socket %s: %v", s.path, err)
+		}
+	}()
+	go func() {
+		defer close(stopped)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("Failed to serve on the unix socket %s: %v", s.path, err)
+		}
+	}()
+	klog.Infof("Serving on the unix socket %s", s.path)
+	return stopped, nil
+}
+
+// listenUnix listens on the socket at path with 0600 permissions. The socket a previous operator left behind when it
+// was killed is taken over, a socket another process still serves on and files that are no socket are not.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a unix socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("the unix socket %s is in use by another process", path)
+		}
+		klog.Infof("Removing the stale unix socket %s", path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// newUnixSocketHandler installs the endpoints the generic API server of controllercmd installs next to the API. Unlike
+// on the TCP listener, where profiling.Annotation gates them, the pprof endpoints are always served, the socket is
+// accessible to its owner only.
+func newUnixSocketHandler() http.Handler {
+	m := mux.NewPathRecorderMux("unix-socket")
+	routes.Profiling{}.Install(m)
+	routes.DebugFlags{}.Install(m, "v", routes.StringFlagPutHandler(logs.GlogSetter))
+	// unlike on the TCP listener, the leadership header is set from the start, before the replica leads
+	m.Handle("/metrics", leadership.WithHeader(legacyregistry.HandlerWithReset()))
+	// slis installs its handler only once per process, on the TCP listener when there is one
+	m.Handle("/metrics/slis", metrics.HandlerWithReset(slis.Registry, metrics.HandlerOpts{}))
+
+	checks := []healthz.HealthChecker{healthz.PingHealthz, healthz.LogHealthz}
+	healthz.InstallHandler(m, checks...)
+	healthz.InstallLivezHandler(m, checks...)
+	healthz.InstallReadyzHandler(m, checks...)
+	return m
+}
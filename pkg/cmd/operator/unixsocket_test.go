@@ -0,0 +1,155 @@
+package operator
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
+)
+
+// unixSocketClient returns a client that sends every request to the socket at path.
+func unixSocketClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func metricNames(t *testing.T, content string) []string {
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestUnixSocketServesTheContentOfTheTCPListener(t *testing.T) {
+	// the generic API server controllercmd serves on the TCP listener, without the authentication and authorization
+	config := genericapiserver.NewConfig(serializer.NewCodecFactory(runtime.NewScheme()))
+	config.LoopbackClientConfig = &rest.Config{}
+	config.ExternalAddress = "127.0.0.1:8443"
+	server, err := config.Complete(nil).New("test", genericapiserver.NewEmptyDelegate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// installs the health checks, which pass once the post start hooks ran
+	server.PrepareRun()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	server.RunPostStartHooks(stopCh)
+	tcpListener := httptest.NewServer(server.Handler)
+	defer tcpListener.Close()
+
+	path := filepath.Join(t.TempDir(), "operator.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped, err := (&unixSocketServer{path: path, mode: unixSocketAdditional}).start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the permissions 0600, got %v", info.Mode().Perm())
+	}
+
+	socketClient := unixSocketClient(path)
+	for _, endpoint := range []string{"/healthz", "/livez", "/readyz/ping", "/debug/pprof/", "/debug/flags/v", "/metrics"} {
+		tcpStatus, tcpBody := get(t, tcpListener.Client(), tcpListener.URL+endpoint)
+		socketStatus, socketBody := get(t, socketClient, "http://operator"+endpoint)
+		if socketStatus != tcpStatus {
+			t.Errorf("%s: expected the status %d of the TCP listener, got %d", endpoint, tcpStatus, socketStatus)
+			continue
+		}
+		switch endpoint {
+		case "/metrics":
+			// the values differ between the two scrapes, the metrics do not
+			if tcpNames, socketNames := metricNames(t, tcpBody), metricNames(t, socketBody); strings.Join(tcpNames, ",") != strings.Join(socketNames, ",") {
+				t.Errorf("%s: expected the metrics %v of the TCP listener, got %v", endpoint, tcpNames, socketNames)
+			}
+		case "/debug/pprof/":
+			if !strings.Contains(socketBody, "goroutine") {
+				t.Errorf("%s: expected the profiles, got %q", endpoint, socketBody)
+			}
+		default:
+			if socketBody != tcpBody {
+				t.Errorf("%s: expected %q like the TCP listener, got %q", endpoint, tcpBody, socketBody)
+			}
+		}
+	}
+
+	cancel()
+	<-stopped
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed on shutdown, got %v", err)
+	}
+}
+
+func TestUnixSocketTakeover(t *testing.T) {
+	dir := t.TempDir()
+
+	// a socket left behind by a killed operator
+	stale := filepath.Join(dir, "stale.sock")
+	listener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := (&unixSocketServer{path: stale}).start(ctx); err != nil {
+		t.Errorf("expected the stale socket to be taken over, got %v", err)
+	}
+
+	// a socket in use
+	if _, err := (&unixSocketServer{path: stale}).start(ctx); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected the socket in use to be refused, got %v", err)
+	}
+
+	// not a socket
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&unixSocketServer{path: file}).start(ctx); err == nil {
+		t.Errorf("expected a regular file to be refused")
+	}
+	if content, err := os.ReadFile(file); err != nil || string(content) != "keep" {
+		t.Errorf("expected the file to be kept, got %q, %v", content, err)
+	}
+}
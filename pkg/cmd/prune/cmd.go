@@ -0,0 +1,49 @@
+package prune
+
+import (
+	"github.com/davecgh/go-spew/spew"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/staticpod/prune"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/revisiondiskusagecontroller"
+)
+
+// NewPrune wraps the library-go prune command and reports the size of the revision directories that are left on
+// the node through the termination message of the pruner pod.
+func NewPrune() *cobra.Command {
+	o := prune.NewPruneOptions()
+	terminationMessagePath := "/dev/termination-log"
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Prune static pod installer revisions",
+		Run: func(cmd *cobra.Command, args []string) {
+			klog.V(1).Info(cmd.Flags())
+			klog.V(1).Info(spew.Sdump(o))
+
+			if err := o.Validate(); err != nil {
+				klog.Fatal(err)
+			}
+			if err := o.Run(); err != nil {
+				klog.Fatal(err)
+			}
+
+			// the revisions are pruned at this point, a missing report must not fail the pod
+			report, err := revisiondiskusagecontroller.NewDiskUsageReport(o.ResourceDir, o.StaticPodName)
+			if err != nil {
+				klog.Warningf("Unable to measure the retained revision directories: %v", err)
+				return
+			}
+			if err := report.Write(terminationMessagePath); err != nil {
+				klog.Warningf("Unable to write the revision disk usage report to %s: %v", terminationMessagePath, err)
+			}
+		},
+	}
+
+	o.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&terminationMessagePath, "termination-message-path", terminationMessagePath, "file the disk usage of the retained revisions is reported to")
+
+	return cmd
+}
@@ -90,10 +90,8 @@ func (o *Options) Run(ctx context.Context) error {
 	}
 
 	certRotationController, err := certrotationcontroller.NewCertRotationControllerOnlyWhenExpired(
-		v1helpers.CachedSecretGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
-		v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
+		certrotationcontroller.StandaloneClusters(kubeClient, kubeInformersForNamespaces),
 		operatorClient,
-		kubeInformersForNamespaces,
 		o.controllerContext.EventRecorder,
 		// this is weird, but when we turn down rotation in CI, we go fast enough that kubelets and kas are racing to observe the new signer before the signer is used.
 		// we need to establish some kind of delay or back pressure to prevent the rollout.  This ensures we don't trigger kas restart
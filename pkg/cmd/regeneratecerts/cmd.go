@@ -0,0 +1,124 @@
+package regeneratecerts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/config/client"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const operatorDeployment = "kube-controller-manager-operator"
+
+// rotatedSecrets are the secrets of the operator namespace the cert rotation controller issues, in the order they
+// depend on each other. The csr-signer is signed by the csr-signer-signer CA and must be issued after it.
+var rotatedSecrets = []string{"csr-signer-signer", "csr-signer"}
+
+type options struct {
+	kubeconfig string
+	force      bool
+	timeout    time.Duration
+	interval   time.Duration
+}
+
+// NewRegenerateCertificatesCommand makes the operator issue the certificates it rotates again, e.g. after the cluster
+// was shut down for longer than they are valid. The operator has to run, nothing recreates them otherwise.
+func NewRegenerateCertificatesCommand(ctx context.Context) *cobra.Command {
+	o := &options{timeout: 5 * time.Minute, interval: 2 * time.Second}
+
+	cmd := &cobra.Command{
+		Use:   "regenerate-certificates",
+		Short: "Regenerate the certificates the operator rotates, for disaster recovery",
+		Run: func(cmd *cobra.Command, args []string) {
+			clientConfig, err := client.GetKubeConfigOrInClusterConfig(o.kubeconfig, nil)
+			if err != nil {
+				klog.Fatal(err)
+			}
+			kubeClient, err := kubernetes.NewForConfig(clientConfig)
+			if err != nil {
+				klog.Fatal(err)
+			}
+			if err := o.run(ctx, kubeClient, os.Stdout); err != nil {
+				klog.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&o.kubeconfig, "kubeconfig", o.kubeconfig, "kubeconfig file of the cluster, the in-cluster config without")
+	cmd.Flags().BoolVar(&o.force, "force", o.force, "expire the certificates even though the operator does not run, it regenerates them once it does")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", o.timeout, "how long to wait for the operator to regenerate each certificate")
+
+	return cmd
+}
+
+func (o *options) run(ctx context.Context, kubeClient kubernetes.Interface, out io.Writer) error {
+	if err := o.checkOperator(ctx, kubeClient, out); err != nil {
+		return err
+	}
+	secrets := kubeClient.CoreV1().Secrets(operatorclient.OperatorNamespace)
+	for _, name := range rotatedSecrets {
+		var previous []byte
+		secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(out, "Secret %s -n %s does not exist, waiting for the operator to create it\n", name, operatorclient.OperatorNamespace)
+		} else if err != nil {
+			return err
+		} else {
+			previous = secret.Data["tls.crt"]
+			// the rotator issues a new certificate for a secret without an expiry, even while the rotation is paused
+			delete(secret.Annotations, certrotation.CertificateNotBeforeAnnotation)
+			delete(secret.Annotations, certrotation.CertificateNotAfterAnnotation)
+			if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Expired secret %s -n %s, waiting for the operator to regenerate it\n", name, operatorclient.OperatorNamespace)
+		}
+
+		var notAfter string
+		if err := wait.PollUntilContextTimeout(ctx, o.interval, o.timeout, true, func(ctx context.Context) (bool, error) {
+			current, err := secrets.Get(ctx, name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			notAfter = current.Annotations[certrotation.CertificateNotAfterAnnotation]
+			return len(notAfter) > 0 && !bytes.Equal(current.Data["tls.crt"], previous), nil
+		}); err != nil {
+			return fmt.Errorf("secret %s -n %s was not regenerated: %w", name, operatorclient.OperatorNamespace, err)
+		}
+		fmt.Fprintf(out, "Regenerated secret %s -n %s, valid until %s\n", name, operatorclient.OperatorNamespace, notAfter)
+	}
+	fmt.Fprintf(out, "The client certificate kube-controller-manager-client-cert-key is issued by the kube-apiserver operator, regenerate it there\n")
+	return nil
+}
+
+// checkOperator refuses to expire the certificates while the operator does not run, unless forced.
+func (o *options) checkOperator(ctx context.Context, kubeClient kubernetes.Interface, out io.Writer) error {
+	deployment, err := kubeClient.AppsV1().Deployments(operatorclient.OperatorNamespace).Get(ctx, operatorDeployment, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && deployment.Status.AvailableReplicas > 0 {
+		return nil
+	}
+	if !o.force {
+		return fmt.Errorf("deployment %s -n %s is not available, nothing would regenerate the certificates. Start it or pass --force", operatorDeployment, operatorclient.OperatorNamespace)
+	}
+	fmt.Fprintf(out, "Deployment %s -n %s is not available, the certificates are regenerated once it is\n", operatorDeployment, operatorclient.OperatorNamespace)
+	return nil
+}
@@ -0,0 +1,148 @@
+package regeneratecerts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func rotatedSecret(name, certificate string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorclient.OperatorNamespace,
+			Name:      name,
+			Annotations: map[string]string{
+				certrotation.CertificateNotBeforeAnnotation: "2024-01-01T00:00:00Z",
+				certrotation.CertificateNotAfterAnnotation:  "2024-01-31T00:00:00Z",
+			},
+		},
+		Data: map[string][]byte{"tls.crt": []byte(certificate)},
+	}
+}
+
+func operatorDeploymentWith(availableReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: operatorDeployment},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: availableReplicas},
+	}
+}
+
+// fakeRotator issues a new certificate for every secret without an expiry until ctx is done, like the cert rotation
+// controller does, and returns the names of the secrets in the order it issued them.
+func fakeRotator(ctx context.Context, client *fake.Clientset) func() []string {
+	var lock sync.Mutex
+	var issued []string
+	go func() {
+		for ctx.Err() == nil {
+			secrets, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).List(ctx, metav1.ListOptions{})
+			if err == nil {
+				for _, secret := range secrets.Items {
+					if _, ok := secret.Annotations[certrotation.CertificateNotAfterAnnotation]; ok {
+						continue
+					}
+					secret.Annotations[certrotation.CertificateNotBeforeAnnotation] = "2025-01-01T00:00:00Z"
+					secret.Annotations[certrotation.CertificateNotAfterAnnotation] = "2025-01-31T00:00:00Z"
+					secret.Data["tls.crt"] = []byte(fmt.Sprintf("new %s", secret.Name))
+					if _, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).Update(ctx, &secret, metav1.UpdateOptions{}); err == nil {
+						lock.Lock()
+						issued = append(issued, secret.Name)
+						lock.Unlock()
+					}
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	return func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), issued...)
+	}
+}
+
+func TestRegenerateCertificates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset(
+		operatorDeploymentWith(1),
+		rotatedSecret("csr-signer-signer", "old csr-signer-signer"),
+		rotatedSecret("csr-signer", "old csr-signer"),
+	)
+	issued := fakeRotator(ctx, client)
+
+	out := &bytes.Buffer{}
+	o := &options{timeout: 5 * time.Second, interval: 10 * time.Millisecond}
+	if err := o.run(ctx, client, out); err != nil {
+		t.Fatal(err)
+	}
+
+	if order := issued(); strings.Join(order, ",") != "csr-signer-signer,csr-signer" {
+		t.Errorf("expected the CA to be regenerated before the csr-signer, got %v", order)
+	}
+	for _, name := range rotatedSecrets {
+		secret, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(secret.Data["tls.crt"]) != "new "+name {
+			t.Errorf("expected secret %s to be regenerated, got %q", name, secret.Data["tls.crt"])
+		}
+	}
+	expected := `Expired secret csr-signer-signer -n openshift-kube-controller-manager-operator, waiting for the operator to regenerate it
+Regenerated secret csr-signer-signer -n openshift-kube-controller-manager-operator, valid until 2025-01-31T00:00:00Z
+Expired secret csr-signer -n openshift-kube-controller-manager-operator, waiting for the operator to regenerate it
+Regenerated secret csr-signer -n openshift-kube-controller-manager-operator, valid until 2025-01-31T00:00:00Z
+The client certificate kube-controller-manager-client-cert-key is issued by the kube-apiserver operator, regenerate it there
+`
+	if out.String() != expected {
+		t.Errorf("expected the output\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRegenerateCertificatesWithoutOperator(t *testing.T) {
+	for _, deployment := range []*appsv1.Deployment{nil, operatorDeploymentWith(0)} {
+		objects := []runtime.Object{rotatedSecret("csr-signer-signer", "old csr-signer-signer"), rotatedSecret("csr-signer", "old csr-signer")}
+		if deployment != nil {
+			objects = append(objects, deployment)
+		}
+		client := fake.NewSimpleClientset(objects...)
+		o := &options{timeout: 100 * time.Millisecond, interval: 10 * time.Millisecond}
+		if err := o.run(context.TODO(), client, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "pass --force") {
+			t.Errorf("expected to refuse without the operator, got %v", err)
+		}
+		secret, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).Get(context.TODO(), "csr-signer-signer", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := secret.Annotations[certrotation.CertificateNotAfterAnnotation]; !ok {
+			t.Error("expected the secrets to be left alone")
+		}
+
+		// forced, the secrets are expired and regenerated once the operator runs
+		o.force = true
+		if err := o.run(context.TODO(), client, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "secret csr-signer-signer -n openshift-kube-controller-manager-operator was not regenerated") {
+			t.Errorf("expected to time out without the operator, got %v", err)
+		}
+		secret, err = client.CoreV1().Secrets(operatorclient.OperatorNamespace).Get(context.TODO(), "csr-signer-signer", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := secret.Annotations[certrotation.CertificateNotAfterAnnotation]; ok {
+			t.Error("expected the CA to be expired")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// cloudConfigFileName and cloudCAFileName are the copies of --cloud-provider-config-file and --cloud-ca-file next
+	// to --config-output-file, i.e. in the config directory the bootstrap pod mounts.
+	cloudConfigFileName = "cloud.conf"
+	cloudCAFileName     = "cloud-ca-bundle.pem"
+
+	// cloudConfigKey is the key of the cloud config in the cloud-config configmap, as synced from the managed
+	// kube-cloud-config.
+	cloudConfigKey = "cloud.conf"
+)
+
+// setCloudFiles sets the names of the cloud config and the cloud CA bundle in the config directory of the bootstrap pod
+// when they were given.
+func (r *renderOpts) setCloudFiles(renderConfig *TemplateData) {
+	if len(r.cloudProviderConfigFile) > 0 {
+		renderConfig.CloudConfigFileName = cloudConfigFileName
+	}
+	if len(r.cloudCAFile) > 0 {
+		renderConfig.CloudCAFileName = cloudCAFileName
+	}
+}
+
+// writeCloudFiles copies the cloud config and the cloud CA bundle into the config directory of the bootstrap pod and
+// writes the cloud-config and trusted-ca-bundle configmaps the operator adopts once it runs. The cloud config
+// observer and the trusted CA injection replace their content then.
+func (r *renderOpts) writeCloudFiles() error {
+	configDir := filepath.Dir(r.generic.ConfigOutputFile)
+	manifestDir := filepath.Join(r.generic.AssetOutputDir, "manifests")
+
+	if len(r.cloudProviderConfigFile) > 0 {
+		cloudConfig, err := os.ReadFile(r.cloudProviderConfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to read --cloud-provider-config-file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, cloudConfigFileName), cloudConfig, 0644); err != nil {
+			return err
+		}
+		configMap := newConfigMap("cloud-config", nil, map[string]string{cloudConfigKey: string(cloudConfig)})
+		if err := writeManifest(filepath.Join(manifestDir, "configmap-cloud-config.yaml"), configMap); err != nil {
+			return err
+		}
+	}
+
+	if len(r.cloudCAFile) > 0 {
+		cloudCA, err := os.ReadFile(r.cloudCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read --cloud-ca-file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, cloudCAFileName), cloudCA, 0644); err != nil {
+			return err
+		}
+		// the same configmap as assets/kube-controller-manager/trusted-ca-cm.yaml, with the cloud CA until the
+		// trusted CA bundle of the cluster is injected
+		configMap := newConfigMap("trusted-ca-bundle",
+			map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"},
+			map[string]string{"ca-bundle.crt": string(cloudCA)},
+		)
+		if err := writeManifest(filepath.Join(manifestDir, "configmap-trusted-ca-bundle.yaml"), configMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newConfigMap(name string, labels, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: name, Labels: labels},
+		Data:       data,
+	}
+}
+
+func writeManifest(path string, object interface{}) error {
+	content, err := yaml.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
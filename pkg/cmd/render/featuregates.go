@@ -0,0 +1,75 @@
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/configobservercontroller"
+)
+
+// readFeatureGateManifest returns the feature gates of the FeatureGate CR in filename, as written by the installer. The
+// gates of payloadVersion in its status are used when the status has them, otherwise the gates of its feature set.
+func readFeatureGateManifest(filename, payloadVersion string) (featuregates.FeatureGateAccess, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	featureGate := &configv1.FeatureGate{}
+	if err := yaml.Unmarshal(content, featureGate); err != nil {
+		return nil, fmt.Errorf("unable to decode %q: %w", filename, err)
+	}
+	if featureGate.Kind != "FeatureGate" {
+		return nil, fmt.Errorf("%q is a %q, not a FeatureGate", filename, featureGate.Kind)
+	}
+
+	for _, details := range featureGate.Status.FeatureGates {
+		if details.Version == payloadVersion {
+			return featuregates.NewHardcodedFeatureGateAccessFromFeatureGate(featureGate, payloadVersion)
+		}
+	}
+
+	featureSet, ok := configv1.FeatureSets[featureGate.Spec.FeatureSet]
+	if !ok {
+		return nil, fmt.Errorf("%q selects the unknown feature set %q", filename, featureGate.Spec.FeatureSet)
+	}
+	gates := map[configv1.FeatureGateName]bool{}
+	for _, description := range featureSet.Enabled {
+		gates[description.FeatureGateAttributes.Name] = true
+	}
+	for _, description := range featureSet.Disabled {
+		gates[description.FeatureGateAttributes.Name] = false
+	}
+	if custom := featureGate.Spec.CustomNoUpgrade; featureGate.Spec.FeatureSet == configv1.CustomNoUpgrade && custom != nil {
+		for _, name := range custom.Enabled {
+			gates[name] = true
+		}
+		for _, name := range custom.Disabled {
+			gates[name] = false
+		}
+	}
+	var enabled, disabled []configv1.FeatureGateName
+	for name, on := range gates {
+		if on {
+			enabled = append(enabled, name)
+		} else {
+			disabled = append(disabled, name)
+		}
+	}
+	return featuregates.NewHardcodedFeatureGateAccess(enabled, disabled), nil
+}
+
+// knownFeatureGate returns whether the kube-controller-manager knows name. The operator does not pass the gates that
+// are only used within OpenShift, see configobservercontroller.OpenShiftOnlyFeatureGates.
+func knownFeatureGate(name configv1.FeatureGateName) bool {
+	if configobservercontroller.OpenShiftOnlyFeatureGates.Has(name) {
+		klog.Warningf("Dropping the feature gate %s unknown to the kube-controller-manager", name)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,460 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/configobservercontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/effectiveconfig"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
+)
+
+// fromClusterNamespaces are the namespaces the operator has informers for.
+var fromClusterNamespaces = []string{
+	"",
+	operatorclient.GlobalUserSpecifiedConfigNamespace,
+	operatorclient.GlobalMachineSpecifiedConfigNamespace,
+	operatorclient.OperatorNamespace,
+	operatorclient.TargetNamespace,
+	"kube-system",
+	"openshift-infra",
+}
+
+// mustGather holds the resources of a must-gather the operator reads.
+type mustGather struct {
+	kubeObjects   []runtime.Object
+	configObjects []runtime.Object
+
+	operator           *unstructured.Unstructured
+	clusterVersion     *configv1.ClusterVersion
+	featureGate        *configv1.FeatureGate
+	operatorDeployment *appsv1.Deployment
+}
+
+// loadMustGather reads every yaml file below dir. Files that are not kubernetes objects, like the ones the gather
+// scripts write next to the resources, and unknown kinds are skipped.
+func loadMustGather(dir string) (*mustGather, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := configv1.Install(scheme); err != nil {
+		return nil, err
+	}
+
+	m := &mustGather{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			klog.V(2).Infof("Skipping %s: %v", path, err)
+			return nil
+		}
+		obj, _, err := unstructured.UnstructuredJSONScheme.Decode(jsonData, nil, nil)
+		if err != nil {
+			klog.V(2).Infof("Skipping %s: %v", path, err)
+			return nil
+		}
+		if list, ok := obj.(*unstructured.UnstructuredList); ok {
+			for i := range list.Items {
+				if err := m.add(scheme, &list.Items[i]); err != nil {
+					return fmt.Errorf("%s: %v", path, err)
+				}
+			}
+			return nil
+		}
+		if err := m.add(scheme, obj.(*unstructured.Unstructured)); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		return nil
+	})
+	return m, err
+}
+
+func (m *mustGather) add(scheme *runtime.Scheme, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	if gvk == operatorv1.GroupVersion.WithKind("KubeControllerManager") {
+		if obj.GetName() == "cluster" {
+			m.operator = obj
+		}
+		return nil
+	}
+	if !scheme.Recognizes(gvk) {
+		return nil
+	}
+
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return err
+	}
+	switch o := typed.(type) {
+	case *configv1.ClusterVersion:
+		m.clusterVersion = o
+	case *configv1.FeatureGate:
+		m.featureGate = o
+	case *appsv1.Deployment:
+		if o.Namespace == operatorclient.OperatorNamespace && o.Name == "kube-controller-manager-operator" {
+			m.operatorDeployment = o
+		}
+	}
+	if gvk.Group == configv1.GroupName {
+		m.configObjects = append(m.configObjects, typed)
+	} else {
+		m.kubeObjects = append(m.kubeObjects, typed)
+	}
+	return nil
+}
+
+// operatorImages returns the images the operator deployment passes to the operator.
+func (m *mustGather) operatorImages() map[string]string {
+	images := map[string]string{}
+	if m.operatorDeployment == nil {
+		return images
+	}
+	for _, container := range m.operatorDeployment.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			images[env.Name] = env.Value
+		}
+	}
+	return images
+}
+
+// renderOperatorClient keeps the operator in memory. The library-go fake does not implement spec updates through the
+// generic operator client the config observer uses, and it does not serialize the observed config the way the API
+// server round trip does.
+type renderOperatorClient struct {
+	v1helpers.StaticPodOperatorClient
+	objectMeta *metav1.ObjectMeta
+}
+
+func (c renderOperatorClient) GetObjectMeta() (*metav1.ObjectMeta, error) {
+	return c.objectMeta.DeepCopy(), nil
+}
+
+func (c renderOperatorClient) UpdateOperatorSpec(ctx context.Context, resourceVersion string, in *operatorv1.OperatorSpec) (*operatorv1.OperatorSpec, string, error) {
+	spec, _, _, err := c.GetStaticPodOperatorState()
+	if err != nil {
+		return nil, "", err
+	}
+	spec = spec.DeepCopy()
+	spec.OperatorSpec = *in.DeepCopy()
+	if spec.ObservedConfig.Object != nil {
+		raw, err := json.Marshal(spec.ObservedConfig.Object)
+		if err != nil {
+			return nil, "", err
+		}
+		spec.ObservedConfig = runtime.RawExtension{Raw: raw}
+	}
+	updated, resourceVersion, err := c.UpdateStaticPodOperatorSpec(ctx, resourceVersion, spec)
+	if err != nil {
+		return nil, "", err
+	}
+	return &updated.OperatorSpec, resourceVersion, nil
+}
+
+// renderFromCluster runs the config observers and the target config controller of the operator against the
+// resources of a must-gather and writes the configmaps and secrets of the revision they would produce to
+// outputDir. Errors of single observers or inputs are written to out, rendering continues without them.
+func renderFromCluster(ctx context.Context, mustGatherDir, outputDir string, out io.Writer) error {
+	m, err := loadMustGather(mustGatherDir)
+	if err != nil {
+		return err
+	}
+	if m.operator == nil {
+		return fmt.Errorf("no kubecontrollermanager.operator.openshift.io/cluster found in %s", mustGatherDir)
+	}
+	kcm := &operatorv1.KubeControllerManager{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m.operator.Object, kcm); err != nil {
+		return err
+	}
+
+	recorder := events.NewInMemoryRecorder("render")
+	operatorClient := renderOperatorClient{
+		StaticPodOperatorClient: v1helpers.NewFakeStaticPodOperatorClient(&kcm.Spec.StaticPodOperatorSpec, &kcm.Status.StaticPodOperatorStatus, nil, nil),
+		objectMeta:              &kcm.ObjectMeta,
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operatorIndexer.Add(m.operator); err != nil {
+		return err
+	}
+	operatorLister := cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource())
+
+	kubeClient := fake.NewSimpleClientset(m.kubeObjects...)
+	kubeInformersForNamespaces := v1helpers.NewKubeInformersForNamespaces(kubeClient, fromClusterNamespaces...)
+
+	// the config informers are never started, their caches are filled from the must-gather directly
+	configInformers := configinformers.NewSharedInformerFactory(nil, 0)
+	for _, obj := range m.configObjects {
+		var informer cache.SharedIndexInformer
+		switch obj.(type) {
+		case *configv1.APIServer:
+			informer = configInformers.Config().V1().APIServers().Informer()
+		case *configv1.FeatureGate:
+			informer = configInformers.Config().V1().FeatureGates().Informer()
+		case *configv1.Infrastructure:
+			informer = configInformers.Config().V1().Infrastructures().Informer()
+		case *configv1.Network:
+			informer = configInformers.Config().V1().Networks().Informer()
+		case *configv1.Node:
+			informer = configInformers.Config().V1().Nodes().Informer()
+		case *configv1.Proxy:
+			informer = configInformers.Config().V1().Proxies().Informer()
+		default:
+			continue
+		}
+		if err := informer.GetIndexer().Add(obj); err != nil {
+			return err
+		}
+	}
+
+	featureGateAccessor := featuregates.NewHardcodedFeatureGateAccess(nil, nil)
+	if m.featureGate == nil {
+		fmt.Fprintf(out, "feature-gates: no featuregate.config.openshift.io/cluster found, rendering without feature gates\n")
+	} else {
+		desiredVersion := ""
+		if m.clusterVersion != nil {
+			desiredVersion = m.clusterVersion.Status.Desired.Version
+		}
+		if accessor, err := featuregates.NewHardcodedFeatureGateAccessFromFeatureGate(m.featureGate, desiredVersion); err != nil {
+			fmt.Fprintf(out, "feature-gates: %v, rendering without feature gates\n", err)
+		} else {
+			featureGateAccessor = accessor
+		}
+	}
+
+	resourceSyncController, err := resourcesynccontroller.NewResourceSyncController(
+		operatorClient,
+		kubeInformersForNamespaces,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		recorder,
+	)
+	if err != nil {
+		return err
+	}
+	payloadVersion := ""
+	if m.clusterVersion != nil {
+		payloadVersion = m.clusterVersion.Status.Desired.Version
+	}
+	configObserver, err := configobservercontroller.NewConfigObserver(
+		operatorClient,
+		configInformers,
+		kubeInformersForNamespaces,
+		resourceSyncController,
+		featureGateAccessor,
+		payloadVersion,
+		// the rendered config is for the target operand, not the ones running on the nodes
+		"",
+		// a must-gather has no discovery, its objects are read in the version they were gathered in
+		nil,
+		recorder,
+	)
+	if err != nil {
+		return err
+	}
+	images := m.operatorImages()
+	targetConfigController := targetconfigcontroller.NewTargetConfigController(
+		images["IMAGE"],
+		images["OPERATOR_IMAGE"],
+		images["CLUSTER_POLICY_CONTROLLER_IMAGE"],
+		images["TOOLS_IMAGE"],
+		kubeInformersForNamespaces,
+		operatorClient,
+		operatorLister,
+		kubeClient,
+		configInformers.Config().V1().Infrastructures(),
+		recorder,
+	)
+
+	kubeInformersForNamespaces.Start(ctx.Done())
+	for _, namespace := range fromClusterNamespaces {
+		kubeInformersForNamespaces.InformersFor(namespace).WaitForCacheSync(ctx.Done())
+	}
+
+	// the config observation errors are reported per observer below
+	_ = configObserver.Sync(ctx, factory.NewSyncContext("ConfigObserver", recorder))
+	observerErrors := configObserver.ObserverErrors()
+	observerNames := make([]string, 0, len(observerErrors))
+	for name := range observerErrors {
+		observerNames = append(observerNames, name)
+	}
+	sort.Strings(observerNames)
+	for _, name := range observerNames {
+		for _, err := range observerErrors[name] {
+			fmt.Fprintf(out, "observer %s: %v\n", name, err)
+		}
+	}
+
+	if err := waitForInformers(ctx, kubeClient, kubeInformersForNamespaces); err != nil {
+		return err
+	}
+	if err := resourceSyncController.Sync(ctx, factory.NewSyncContext("ResourceSyncController", recorder)); err != nil {
+		fmt.Fprintf(out, "resource sync: %v\n", err)
+	}
+
+	// the target config controller reads some of what it writes itself, the second pass sees all of it like the
+	// operator does on its next sync
+	var targetConfigErr error
+	for i := 0; i < 2; i++ {
+		if err := waitForInformers(ctx, kubeClient, kubeInformersForNamespaces); err != nil {
+			return err
+		}
+		targetConfigErr = targetConfigController.Sync(ctx, factory.NewSyncContext("TargetConfigController", recorder))
+	}
+	if targetConfigErr != nil && targetConfigErr != factory.SyntheticRequeueError {
+		fmt.Fprintf(out, "target config: %v\n", targetConfigErr)
+	}
+
+	if err := writeRevision(ctx, kubeClient, outputDir, out); err != nil {
+		return err
+	}
+	return writeEffectiveConfig(ctx, kubeClient, outputDir, out)
+}
+
+// waitForInformers waits until the informers caught up with what the controllers wrote to the fake client.
+func waitForInformers(ctx context.Context, kubeClient kubernetes.Interface, kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces) error {
+	return wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+		configMaps, err := kubeClient.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, configMap := range configMaps.Items {
+			cached, err := kubeInformersForNamespaces.ConfigMapLister().ConfigMaps(configMap.Namespace).Get(configMap.Name)
+			if err != nil || cached.ResourceVersion != configMap.ResourceVersion {
+				return false, nil
+			}
+		}
+		secrets, err := kubeClient.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, secret := range secrets.Items {
+			cached, err := kubeInformersForNamespaces.SecretLister().Secrets(secret.Namespace).Get(secret.Name)
+			if err != nil || cached.ResourceVersion != secret.ResourceVersion {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// writeRevision writes the revisioned configmaps and secrets in the layout of a static pod resource directory.
+func writeRevision(ctx context.Context, kubeClient kubernetes.Interface, outputDir string, out io.Writer) error {
+	for _, resource := range operator.DeploymentConfigMaps {
+		configMap, err := kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(ctx, resource.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if !resource.Optional {
+				fmt.Fprintf(out, "revision: missing configmap/%s\n", resource.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		files := map[string][]byte{}
+		for key, value := range configMap.Data {
+			files[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			files[key] = value
+		}
+		if err := writeFiles(filepath.Join(outputDir, "configmaps", resource.Name), files); err != nil {
+			return err
+		}
+	}
+	for _, resource := range operator.DeploymentSecrets {
+		secret, err := kubeClient.CoreV1().Secrets(operatorclient.TargetNamespace).Get(ctx, resource.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if !resource.Optional {
+				fmt.Fprintf(out, "revision: missing secret/%s\n", resource.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeFiles(filepath.Join(outputDir, "secrets", resource.Name), secret.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEffectiveConfig writes the rendered config like the operator serves the config of its latest revision, the
+// schema violations are reported to out.
+func writeEffectiveConfig(ctx context.Context, kubeClient kubernetes.Interface, outputDir string, out io.Writer) error {
+	configMap, err := kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(ctx, "config", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	config, err := effectiveconfig.New(0, []byte(configMap.Data["config.yaml"]))
+	if err != nil {
+		return fmt.Errorf("configmap/%s: %v", configMap.Name, err)
+	}
+	for _, violation := range config.Violations {
+		fmt.Fprintf(out, "effective config: %s\n", violation)
+	}
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "effective-config.json"), append(content, '\n'), 0644)
+}
+
+func writeFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRenderFromCluster(t *testing.T) {
+	outputDir := t.TempDir()
+	out := &bytes.Buffer{}
+	if err := renderFromCluster(context.TODO(), filepath.Join("testdata", "must-gather"), outputDir, out); err != nil {
+		t.Fatal(err)
+	}
+
+	// the must-gather lacks the config-7 revision and the secrets, they are reported instead of failing the render
+	expectedReport := `observer node-lifecycle: configmap "config-7" not found
+revision: missing secret/service-account-private-key
+revision: missing secret/localhost-recovery-client-token
+`
+	if out.String() != expectedReport {
+		t.Errorf("unexpected report: %s", cmp.Diff(expectedReport, out.String()))
+	}
+
+	var files []string
+	if err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			r, err := filepath.Rel(outputDir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, r)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectedFiles := []string{
+		"configmaps/cluster-policy-controller-config/config.yaml",
+		"configmaps/config/config.yaml",
+		"configmaps/controller-manager-kubeconfig/kubeconfig",
+		"configmaps/kube-controller-cert-syncer-kubeconfig/kubeconfig",
+		"configmaps/kube-controller-manager-pod/forceRedeploymentReason",
+		"configmaps/kube-controller-manager-pod/pod.yaml",
+		"configmaps/kube-controller-manager-pod/version",
+		"configmaps/recycler-config/recycler-pod.yaml",
+		"configmaps/service-ca/service-ca.crt",
+		"configmaps/serviceaccount-ca/ca-bundle.crt",
+		"effective-config.json",
+	}
+	sort.Strings(files)
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected and rendered files differ: %s", cmp.Diff(expectedFiles, files))
+	}
+
+	// {filename: {field: value}}
+	expectedContents := map[string]map[string]interface{}{
+		"configmaps/config/config.yaml": {
+			"extendedArguments.cluster-name": []interface{}{"test-x7k2p"},
+			"extendedArguments.cluster-cidr": []interface{}{"10.128.0.0/14"},
+		},
+		"configmaps/kube-controller-manager-pod/pod.yaml": {
+			"spec.containers[0].image": "quay.io/openshift/hyperkube:test",
+			"spec.containers[1].image": "quay.io/openshift/cluster-policy-controller:test",
+		},
+	}
+	for f, fields := range expectedContents {
+		data, err := os.ReadFile(filepath.Join(outputDir, f))
+		if err != nil {
+			t.Errorf("error reading file %s: %v", f, err)
+			continue
+		}
+		dataJSON, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			t.Errorf("error converting file %s: %v", f, err)
+			continue
+		}
+		obj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, dataJSON)
+		if err != nil {
+			t.Errorf("error decoding %s: %v", f, err)
+			continue
+		}
+		for field, expectedValue := range fields {
+			actualValue, err := readPath(obj.(*unstructured.Unstructured).Object, field)
+			if err != nil {
+				t.Errorf("error reading field %s: %v", field, err)
+				continue
+			}
+			if !reflect.DeepEqual(actualValue, expectedValue) {
+				t.Errorf("%s: expected %s %v, got %v", f, field, expectedValue, actualValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package render
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+const (
+	ipv4 = "ipv4"
+	ipv6 = "ipv6"
+)
+
+// networkArguments are the extended arguments of the kube-controller-manager derived from the cluster Network CR.
+type networkArguments struct {
+	clusterCIDRs          []string
+	serviceClusterIPRange []string
+	// nodeCIDRMaskSizes are the hostPrefix of the clusterNetwork entries by IP family, a family is missing when its
+	// entries have none or disagree.
+	nodeCIDRMaskSizes map[string]int
+	// allocateNodeCIDRs is whether the node IPAM of the kube-controller-manager can allocate the node CIDRs, i.e.
+	// there is at most one clusterNetwork entry per IP family and each has a hostPrefix.
+	allocateNodeCIDRs bool
+}
+
+// parseNetwork reads the cluster Network CR as written by the installer.
+func parseNetwork(data []byte) (*networkArguments, error) {
+	network := &configv1.Network{}
+	if err := yaml.Unmarshal(data, network); err != nil {
+		return nil, err
+	}
+	if network.Kind != "Network" {
+		return nil, fmt.Errorf("expected a Network, got %q", network.Kind)
+	}
+	if len(network.Spec.ClusterNetwork) == 0 {
+		return nil, fmt.Errorf("spec.clusterNetwork is empty")
+	}
+
+	config := &networkArguments{
+		serviceClusterIPRange: network.Spec.ServiceNetwork,
+		nodeCIDRMaskSizes:     map[string]int{},
+	}
+	entries := map[string]int{}
+	hostPrefixes := map[string]sets.Set[uint32]{}
+	for i, entry := range network.Spec.ClusterNetwork {
+		_, cidr, err := net.ParseCIDR(entry.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("spec.clusterNetwork[%d].cidr: %w", i, err)
+		}
+		ones, bits := cidr.Mask.Size()
+		if entry.HostPrefix != 0 && (int(entry.HostPrefix) < ones || int(entry.HostPrefix) > bits) {
+			return nil, fmt.Errorf("spec.clusterNetwork[%d].hostPrefix %d does not fit the CIDR %s, it must be between %d and %d", i, entry.HostPrefix, entry.CIDR, ones, bits)
+		}
+
+		family := ipv4
+		if cidr.IP.To4() == nil {
+			family = ipv6
+		}
+		config.clusterCIDRs = append(config.clusterCIDRs, entry.CIDR)
+		entries[family]++
+		if hostPrefixes[family] == nil {
+			hostPrefixes[family] = sets.New[uint32]()
+		}
+		hostPrefixes[family].Insert(entry.HostPrefix)
+	}
+
+	config.allocateNodeCIDRs = true
+	for family, prefixes := range hostPrefixes {
+		if prefixes.Len() == 1 && !prefixes.Has(0) {
+			config.nodeCIDRMaskSizes[family] = int(prefixes.UnsortedList()[0])
+		}
+		if entries[family] > 1 || prefixes.Has(0) {
+			config.allocateNodeCIDRs = false
+		}
+	}
+	return config, nil
+}
+
+// setNetworkConfig sets the CIDRs, the node CIDR mask sizes and whether to allocate node CIDRs from
+// --cluster-config-file and --network-config-file, the explicit flags win over them. The CIDRs the
+// kube-controller-manager would reject are rejected, the bootstrap would fail much later otherwise.
+func (r *renderOpts) setNetworkConfig(renderConfig *TemplateData) error {
+	if len(r.clusterConfigFile) > 0 {
+		clusterConfigFileData, err := os.ReadFile(r.clusterConfigFile)
+		if err != nil {
+			return err
+		}
+		err = discoverRestrictedCIDRs(clusterConfigFileData, renderConfig)
+		if err != nil {
+			return fmt.Errorf("unable to parse restricted CIDRs from config: %v", err)
+		}
+	}
+
+	maskSizes := map[string]int{}
+	if len(r.networkConfigFile) > 0 {
+		data, err := os.ReadFile(r.networkConfigFile)
+		if err != nil {
+			return err
+		}
+		network, err := parseNetwork(data)
+		if err != nil {
+			return fmt.Errorf("unable to parse the network config %q: %v", r.networkConfigFile, err)
+		}
+		renderConfig.ClusterCIDR = network.clusterCIDRs
+		renderConfig.ServiceClusterIPRange = network.serviceClusterIPRange
+		renderConfig.AllocateNodeCIDRs = strconv.FormatBool(network.allocateNodeCIDRs)
+		maskSizes = network.nodeCIDRMaskSizes
+	}
+
+	if len(r.clusterCIDRs) > 0 {
+		renderConfig.ClusterCIDR = r.clusterCIDRs
+	}
+	if len(r.serviceClusterIPRange) > 0 {
+		renderConfig.ServiceClusterIPRange = r.serviceClusterIPRange
+	}
+	if r.flags != nil && r.flags.Changed("allocate-node-cidrs") {
+		renderConfig.AllocateNodeCIDRs = strconv.FormatBool(r.allocateNodeCIDRs)
+	}
+	clusterFamilies, err := familiesOf(renderConfig.ClusterCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid cluster CIDR: %v", err)
+	}
+	serviceFamilies, err := familiesOf(renderConfig.ServiceClusterIPRange)
+	if err != nil {
+		return fmt.Errorf("invalid service cluster IP range: %v", err)
+	}
+	// the kube-controller-manager validates the service CIDRs on start, the cluster CIDRs once it allocates node CIDRs
+	if err := validateDualStack("service-cluster-ip-range", renderConfig.ServiceClusterIPRange, serviceFamilies); err != nil {
+		return err
+	}
+	if renderConfig.AllocateNodeCIDRs == "true" {
+		if err := validateDualStack("cluster-cidr", renderConfig.ClusterCIDR, clusterFamilies); err != nil {
+			return err
+		}
+	}
+
+	dualStack := sets.New(clusterFamilies...).Len() > 1
+	if r.nodeCIDRMaskSize > 0 {
+		if r.nodeCIDRMaskSizeIPv4 > 0 || r.nodeCIDRMaskSizeIPv6 > 0 {
+			return fmt.Errorf("--node-cidr-mask-size is not allowed with --node-cidr-mask-size-ipv4 and --node-cidr-mask-size-ipv6")
+		}
+		if dualStack {
+			return fmt.Errorf("--node-cidr-mask-size is not allowed for the dual-stack cluster CIDRs %s", strings.Join(renderConfig.ClusterCIDR, ","))
+		}
+		maskSizes = map[string]int{clusterFamilyOr(clusterFamilies, ipv4): r.nodeCIDRMaskSize}
+	}
+	if r.nodeCIDRMaskSizeIPv4 > 0 {
+		maskSizes[ipv4] = r.nodeCIDRMaskSizeIPv4
+	}
+	if r.nodeCIDRMaskSizeIPv6 > 0 {
+		maskSizes[ipv6] = r.nodeCIDRMaskSizeIPv6
+	}
+
+	renderConfig.NodeCIDRMaskSizes = map[string]string{}
+	for family, size := range maskSizes {
+		switch {
+		case dualStack:
+			// the kube-controller-manager rejects node-cidr-mask-size for dual-stack CIDRs
+			renderConfig.NodeCIDRMaskSizes["node-cidr-mask-size-"+family] = strconv.Itoa(size)
+		case family == clusterFamilyOr(clusterFamilies, family):
+			renderConfig.NodeCIDRMaskSizes["node-cidr-mask-size"] = strconv.Itoa(size)
+		}
+	}
+	return nil
+}
+
+// familiesOf returns the IP family of every CIDR of cidrs.
+func familiesOf(cidrs []string) ([]string, error) {
+	var families []string
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		if ip.To4() != nil {
+			families = append(families, ipv4)
+		} else {
+			families = append(families, ipv6)
+		}
+	}
+	return families, nil
+}
+
+// clusterFamilyOr returns the family of single-stack cluster CIDRs, defaultFamily without cluster CIDRs.
+func clusterFamilyOr(families []string, defaultFamily string) string {
+	if len(families) == 0 {
+		return defaultFamily
+	}
+	return families[0]
+}
+
+// validateDualStack rejects the CIDRs of the argument name the kube-controller-manager rejects, i.e. more than two or
+// two of the same IP family.
+func validateDualStack(name string, cidrs, families []string) error {
+	switch {
+	case len(cidrs) > 2:
+		return fmt.Errorf("--%s accepts at most one CIDR per IP family, got %s", name, strings.Join(cidrs, ","))
+	case len(cidrs) == 2 && families[0] == families[1]:
+		return fmt.Errorf("--%s accepts at most one CIDR per IP family, got two %s CIDRs %s", name, families[0], strings.Join(cidrs, ","))
+	}
+	return nil
+}
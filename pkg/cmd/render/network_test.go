@@ -0,0 +1,306 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+func network(clusterNetwork string) string {
+	return `
+apiVersion: config.openshift.io/v1
+kind: Network
+metadata:
+  name: cluster
+spec:
+  clusterNetwork:` + clusterNetwork + `
+  serviceNetwork:
+    - 172.30.0.0/16
+`
+}
+
+func TestSetNetworkConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		clusterNetwork string
+		args           []string
+		expected       TemplateData
+		expectedErr    string
+	}{
+		{
+			name: "ipv4",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "23"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "ipv6",
+			clusterNetwork: `
+    - cidr: fd01::/48
+      hostPrefix: 64`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"fd01::/48"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "64"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "dual-stack",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23
+    - cidr: fd01::/48
+      hostPrefix: 64`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14", "fd01::/48"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "multiple-ipv4",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23
+    - cidr: 10.132.0.0/14
+      hostPrefix: 23`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14", "10.132.0.0/14"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "23"},
+				AllocateNodeCIDRs:     "false",
+			},
+		},
+		{
+			name: "mixed-host-prefixes",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23
+    - cidr: 10.132.0.0/14
+      hostPrefix: 24
+    - cidr: fd01::/48
+      hostPrefix: 64`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14", "10.132.0.0/14", "fd01::/48"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size-ipv6": "64"},
+				AllocateNodeCIDRs:     "false",
+			},
+		},
+		{
+			name: "no-host-prefix",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14`,
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{},
+				AllocateNodeCIDRs:     "false",
+			},
+		},
+		{
+			name: "explicit-flags",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23`,
+			args: []string{"--cluster-cidr=10.0.0.0/8", "--service-cluster-ip-range=192.168.0.0/16", "--node-cidr-mask-size=24", "--allocate-node-cidrs=false"},
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.0.0.0/8"},
+				ServiceClusterIPRange: []string{"192.168.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "24"},
+				AllocateNodeCIDRs:     "false",
+			},
+		},
+		{
+			name: "host-prefix-shorter-than-cidr",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 12`,
+			expectedErr: `spec.clusterNetwork[0].hostPrefix 12 does not fit the CIDR 10.128.0.0/14, it must be between 14 and 32`,
+		},
+		{
+			name: "host-prefix-longer-than-address",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23
+    - cidr: fd01::/48
+      hostPrefix: 129`,
+			expectedErr: `spec.clusterNetwork[1].hostPrefix 129 does not fit the CIDR fd01::/48, it must be between 48 and 128`,
+		},
+		{
+			name: "mask-size-for-dual-stack",
+			clusterNetwork: `
+    - cidr: 10.128.0.0/14
+      hostPrefix: 23
+    - cidr: fd01::/48
+      hostPrefix: 64`,
+			args:        []string{"--node-cidr-mask-size=24"},
+			expectedErr: `--node-cidr-mask-size is not allowed for the dual-stack cluster CIDRs 10.128.0.0/14,fd01::/48`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			networkConfigFile := filepath.Join(t.TempDir(), "cluster-network-02-config.yml")
+			if err := os.WriteFile(networkConfigFile, []byte(network(test.clusterNetwork)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			r := &renderOpts{flags: pflag.NewFlagSet(test.name, pflag.ContinueOnError)}
+			r.AddFlags(r.flags)
+			if err := r.flags.Parse(append(test.args, "--network-config-file="+networkConfigFile)); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := TemplateData{}
+			err := r.setNetworkConfig(&actual)
+			if len(test.expectedErr) > 0 {
+				if err == nil {
+					t.Fatalf("expected error %q, got none", test.expectedErr)
+				}
+				if expected := `unable to parse the network config "` + networkConfigFile + `": ` + test.expectedErr; err.Error() != expected && err.Error() != test.expectedErr {
+					t.Fatalf("expected error %q, got %q", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected network config: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNetworkFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    TemplateData
+		expectedErr string
+	}{
+		{
+			name: "ipv4",
+			args: []string{"--cluster-cidr=10.128.0.0/14", "--service-cluster-ip-range=172.30.0.0/16", "--node-cidr-mask-size=23", "--allocate-node-cidrs"},
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "23"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "ipv6",
+			args: []string{"--cluster-cidr=fd01::/48", "--service-cluster-ip-range=fd02::/112", "--node-cidr-mask-size=64", "--allocate-node-cidrs"},
+			expected: TemplateData{
+				ClusterCIDR:           []string{"fd01::/48"},
+				ServiceClusterIPRange: []string{"fd02::/112"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size": "64"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "ipv6-family-mask-size",
+			args: []string{"--cluster-cidr=fd01::/48", "--node-cidr-mask-size-ipv6=64"},
+			expected: TemplateData{
+				ClusterCIDR:       []string{"fd01::/48"},
+				NodeCIDRMaskSizes: map[string]string{"node-cidr-mask-size": "64"},
+			},
+		},
+		{
+			name: "dual-stack-comma-separated",
+			args: []string{"--cluster-cidr=10.128.0.0/14,fd01::/48", "--service-cluster-ip-range=172.30.0.0/16,fd02::/112", "--node-cidr-mask-size-ipv4=23", "--node-cidr-mask-size-ipv6=64", "--allocate-node-cidrs"},
+			expected: TemplateData{
+				ClusterCIDR:           []string{"10.128.0.0/14", "fd01::/48"},
+				ServiceClusterIPRange: []string{"172.30.0.0/16", "fd02::/112"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "dual-stack-repeated",
+			args: []string{"--cluster-cidr=fd01::/48", "--cluster-cidr=10.128.0.0/14", "--service-cluster-ip-range=fd02::/112", "--service-cluster-ip-range=172.30.0.0/16", "--node-cidr-mask-size-ipv4=23", "--node-cidr-mask-size-ipv6=64", "--allocate-node-cidrs"},
+			expected: TemplateData{
+				ClusterCIDR:           []string{"fd01::/48", "10.128.0.0/14"},
+				ServiceClusterIPRange: []string{"fd02::/112", "172.30.0.0/16"},
+				NodeCIDRMaskSizes:     map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"},
+				AllocateNodeCIDRs:     "true",
+			},
+		},
+		{
+			name: "two-ipv4-cluster-cidrs-without-allocation",
+			args: []string{"--cluster-cidr=10.128.0.0/14,10.132.0.0/14", "--allocate-node-cidrs=false"},
+			expected: TemplateData{
+				ClusterCIDR:       []string{"10.128.0.0/14", "10.132.0.0/14"},
+				NodeCIDRMaskSizes: map[string]string{},
+				AllocateNodeCIDRs: "false",
+			},
+		},
+		{
+			name:        "two-ipv4-cluster-cidrs",
+			args:        []string{"--cluster-cidr=10.128.0.0/14,10.132.0.0/14", "--allocate-node-cidrs"},
+			expectedErr: "--cluster-cidr accepts at most one CIDR per IP family, got two ipv4 CIDRs 10.128.0.0/14,10.132.0.0/14",
+		},
+		{
+			name:        "three-cluster-cidrs",
+			args:        []string{"--cluster-cidr=10.128.0.0/14,fd01::/48,10.132.0.0/14", "--allocate-node-cidrs"},
+			expectedErr: "--cluster-cidr accepts at most one CIDR per IP family, got 10.128.0.0/14,fd01::/48,10.132.0.0/14",
+		},
+		{
+			name:        "two-ipv6-service-cidrs",
+			args:        []string{"--service-cluster-ip-range=fd02::/112,fd03::/112"},
+			expectedErr: "--service-cluster-ip-range accepts at most one CIDR per IP family, got two ipv6 CIDRs fd02::/112,fd03::/112",
+		},
+		{
+			name:        "three-service-cidrs",
+			args:        []string{"--service-cluster-ip-range=172.30.0.0/16,fd02::/112,172.31.0.0/16"},
+			expectedErr: "--service-cluster-ip-range accepts at most one CIDR per IP family, got 172.30.0.0/16,fd02::/112,172.31.0.0/16",
+		},
+		{
+			name:        "invalid-cluster-cidr",
+			args:        []string{"--cluster-cidr=10.128.0.0"},
+			expectedErr: "invalid cluster CIDR: invalid CIDR address: 10.128.0.0",
+		},
+		{
+			name:        "mask-size-with-family-mask-size",
+			args:        []string{"--cluster-cidr=10.128.0.0/14", "--node-cidr-mask-size=23", "--node-cidr-mask-size-ipv4=24"},
+			expectedErr: "--node-cidr-mask-size is not allowed with --node-cidr-mask-size-ipv4 and --node-cidr-mask-size-ipv6",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &renderOpts{flags: pflag.NewFlagSet(test.name, pflag.ContinueOnError)}
+			r.AddFlags(r.flags)
+			if err := r.flags.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := TemplateData{}
+			err := r.setNetworkConfig(&actual)
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Fatalf("expected error %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected network config: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+)
+
+const (
+	outputJSON = "json"
+
+	// outputVersion is increased on incompatible changes of Output.
+	outputVersion = 1
+)
+
+// Output is the document --output=json prints. Tooling records it for provenance, its fields must stay compatible.
+type Output struct {
+	Version int `json:"version"`
+	// Assets are the written files sorted by path.
+	Assets []Asset `json:"assets"`
+	// Parameters are the values of all flags, defaulted ones included.
+	Parameters map[string]string `json:"parameters"`
+}
+
+// Asset is a written file.
+type Asset struct {
+	Path string `json:"path"`
+	// Kind and Name are read from a YAML or JSON object and empty for other files.
+	Kind   string `json:"kind,omitempty"`
+	Name   string `json:"name,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+// writeOutput prints the Output of the files written to out.
+func (r *renderOpts) writeOutput() error {
+	var paths []string
+	if len(r.fromClusterDir) > 0 {
+		paths = []string{r.generic.AssetOutputDir}
+	} else {
+		paths = []string{
+			filepath.Join(r.generic.AssetOutputDir, "bootstrap-manifests"),
+			filepath.Join(r.generic.AssetOutputDir, "manifests"),
+			r.generic.ConfigOutputFile,
+			r.clusterPolicyControllerConfigOutputFile,
+		}
+		if len(r.cloudProviderConfigFile) > 0 {
+			paths = append(paths, filepath.Join(filepath.Dir(r.generic.ConfigOutputFile), cloudConfigFileName))
+		}
+		if len(r.cloudCAFile) > 0 {
+			paths = append(paths, filepath.Join(filepath.Dir(r.generic.ConfigOutputFile), cloudCAFileName))
+		}
+	}
+	assets, err := readAssets(paths...)
+	if err != nil {
+		return err
+	}
+
+	output := Output{Version: outputVersion, Assets: assets, Parameters: map[string]string{}}
+	r.flags.VisitAll(func(flag *pflag.Flag) {
+		output.Parameters[flag.Name] = flag.Value.String()
+	})
+	encoder := json.NewEncoder(r.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// readAssets describes the files at paths, directories are walked.
+func readAssets(paths ...string) ([]Asset, error) {
+	assets := []Asset{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(content)
+			asset := Asset{Path: path, SHA256: hex.EncodeToString(sum[:])}
+			object := struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}{}
+			if err := yaml.Unmarshal(content, &object); err == nil {
+				asset.Kind, asset.Name = object.Kind, object.Metadata.Name
+			}
+			assets = append(assets, asset)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Path < assets[j].Path })
+	return assets, nil
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	outputFormatYAMLFiles = "yaml-files"
+	outputFormatJSONFiles = "json-files"
+	outputFormatStream    = "stream"
+)
+
+// outputFormats are the values of --output-format.
+var outputFormats = sets.New(outputFormatYAMLFiles, outputFormatJSONFiles, outputFormatStream)
+
+// manifestDirs are the directories of --asset-output-dir the manifests are rendered into, in the order they are
+// streamed in.
+func (r *renderOpts) manifestDirs() []string {
+	return []string{
+		filepath.Join(r.generic.AssetOutputDir, "bootstrap-manifests"),
+		filepath.Join(r.generic.AssetOutputDir, "manifests"),
+	}
+}
+
+// writeOutputFormat converts the rendered manifests to JSON files or prints them to out as one stream, according to
+// --output-format.
+func (r *renderOpts) writeOutputFormat() error {
+	switch r.outputFormat {
+	case outputFormatJSONFiles:
+		for _, dir := range r.manifestDirs() {
+			if err := convertToJSONFiles(dir); err != nil {
+				return err
+			}
+		}
+	case outputFormatStream:
+		return r.writeStream()
+	}
+	return nil
+}
+
+// convertToJSONFiles replaces every YAML file in dir by an indented JSON file of the same name.
+func convertToJSONFiles(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		jsonContent, err := yaml.YAMLToJSON(content)
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to JSON: %v", filename, err)
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, jsonContent, "", "  "); err != nil {
+			return fmt.Errorf("failed to convert %q to JSON: %v", filename, err)
+		}
+		indented.WriteByte('\n')
+		if err := os.WriteFile(strings.TrimSuffix(filename, ext)+".json", indented.Bytes(), 0644); err != nil {
+			return err
+		}
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStream prints the rendered manifests to out as one YAML stream. The manifests are ordered by directory and
+// file name, each is preceded by a comment with its path in --asset-output-dir, so that renders of different releases
+// can be diffed.
+func (r *renderOpts) writeStream() error {
+	for _, dir := range r.manifestDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			filename := filepath.Join(dir, entry.Name())
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			source, err := filepath.Rel(r.generic.AssetOutputDir, filename)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(r.out, "---\n# Source: %s\n%s\n", filepath.ToSlash(source), bytes.TrimRight(content, "\n")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
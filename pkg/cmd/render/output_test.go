@@ -0,0 +1,210 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderOutput(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         []string
+		expectedOutput bool
+	}{
+		{name: "no output flag"},
+		{name: "json", output: []string{"--output=json"}, expectedOutput: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-output")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			args := setOutputFlags([]string{
+				"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+				"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+				"--rendered-manifest-files=" + filepath.Join("testdata", "rendered", "default-fg"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			}, outputDir)
+			var runErr error
+			c := NewRenderCommand(func(err error) error {
+				runErr = err
+				return err
+			})
+			var stdout bytes.Buffer
+			c.SetOut(&stdout)
+			c.SetArgs(append(args, test.output...))
+			if err := c.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if runErr != nil {
+				t.Fatal(runErr)
+			}
+
+			var files []string
+			if err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					files = append(files, path)
+				}
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(files)
+			if len(files) != 14 {
+				t.Errorf("expected the 14 files of the happy path to be written, got %v", files)
+			}
+
+			if !test.expectedOutput {
+				if stdout.Len() > 0 {
+					t.Errorf("expected no output, got %s", stdout.String())
+				}
+				return
+			}
+
+			var output Output
+			decoder := json.NewDecoder(&stdout)
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&output); err != nil {
+				t.Fatalf("invalid output: %v", err)
+			}
+			if output.Version != 1 {
+				t.Errorf("expected version 1, got %d", output.Version)
+			}
+
+			var paths []string
+			sha256Pattern := regexp.MustCompile(`^[0-9a-f]{64}$`)
+			for _, asset := range output.Assets {
+				paths = append(paths, asset.Path)
+				content, err := os.ReadFile(asset.Path)
+				if err != nil {
+					t.Errorf("%s: %v", asset.Path, err)
+					continue
+				}
+				sum := sha256.Sum256(content)
+				if !sha256Pattern.MatchString(asset.SHA256) || asset.SHA256 != hex.EncodeToString(sum[:]) {
+					t.Errorf("%s: expected sha256 %x, got %q", asset.Path, sum, asset.SHA256)
+				}
+			}
+			if !reflect.DeepEqual(paths, files) {
+				t.Errorf("expected an asset per written file: %s", cmp.Diff(files, paths))
+			}
+
+			expectedKinds := map[string]Asset{
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml":   {Kind: "Pod", Name: "bootstrap-kube-controller-manager"},
+				"manifests/manifests/00_openshift-kube-controller-manager-ns.yaml": {Kind: "Namespace", Name: "openshift-kube-controller-manager"},
+				"configs/config.yaml": {Kind: "KubeControllerManagerConfig"},
+			}
+			for _, asset := range output.Assets {
+				rel, err := filepath.Rel(outputDir, asset.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if expected, ok := expectedKinds[rel]; ok && (asset.Kind != expected.Kind || asset.Name != expected.Name) {
+					t.Errorf("%s: expected %s/%s, got %s/%s", rel, expected.Kind, expected.Name, asset.Kind, asset.Name)
+				}
+			}
+
+			for flag, expected := range map[string]string{
+				"output":           "json",
+				"payload-version":  "test",
+				"asset-output-dir": filepath.Join(outputDir, "manifests"),
+				"from-cluster":     "",
+			} {
+				if value, ok := output.Parameters[flag]; !ok || value != expected {
+					t.Errorf("expected parameter %s=%q, got %q", flag, expected, value)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderOutputFormat pins the stream of the happy path, run with -update after an intended change.
+func TestRenderOutputFormat(t *testing.T) {
+	for _, format := range []string{"yaml-files", "json-files", "stream"} {
+		t.Run(format, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-" + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			args := setOutputFlags([]string{
+				"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+				"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+				"--rendered-manifest-files=" + filepath.Join("testdata", "rendered", "default-fg"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+				"--output-format=" + format,
+			}, outputDir)
+			var runErr error
+			c := NewRenderCommand(func(err error) error {
+				runErr = err
+				return err
+			})
+			var stdout bytes.Buffer
+			c.SetOut(&stdout)
+			c.SetArgs(args)
+			if err := c.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if runErr != nil {
+				t.Fatal(runErr)
+			}
+
+			expectedExt := ".yaml"
+			if format == "json-files" {
+				expectedExt = ".json"
+			}
+			var manifests int
+			for _, dir := range []string{"bootstrap-manifests", "manifests"} {
+				entries, err := os.ReadDir(filepath.Join(outputDir, "manifests", dir))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, entry := range entries {
+					manifests++
+					if ext := filepath.Ext(entry.Name()); ext != expectedExt {
+						t.Errorf("%s/%s: expected a %s file", dir, entry.Name(), expectedExt)
+						continue
+					}
+					content, err := os.ReadFile(filepath.Join(outputDir, "manifests", dir, entry.Name()))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if expectedExt == ".json" && !json.Valid(content) {
+						t.Errorf("%s/%s: invalid JSON", dir, entry.Name())
+					}
+				}
+			}
+			if manifests != 12 {
+				t.Errorf("expected the 12 manifests of the happy path, got %d", manifests)
+			}
+
+			if format != "stream" {
+				if stdout.Len() > 0 {
+					t.Errorf("expected no output, got %s", stdout.String())
+				}
+				return
+			}
+			compareGolden(t, filepath.Join("testdata", "output-formats", "stream.yaml"), stdout.Bytes())
+		})
+	}
+}
@@ -1,15 +1,23 @@
 package render
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"text/template"
 
 	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
 	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
 	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/kcmfeatures"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
 	genericrender "github.com/openshift/library-go/pkg/operator/render"
 	genericrenderoptions "github.com/openshift/library-go/pkg/operator/render/options"
@@ -17,6 +25,8 @@ import (
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
@@ -29,6 +39,36 @@ type renderOpts struct {
 	clusterPolicyControllerConfigOutputFile string
 	clusterPolicyControllerImage            string
 	disablePhase2                           bool
+	// clusterProfile is the name of the ClusterProfile of the bootstrap manifests.
+	clusterProfile string
+	// networkConfigFile is the cluster Network CR the CIDRs and node CIDR mask sizes are derived from. The explicit
+	// clusterCIDRs, serviceClusterIPRange, node CIDR mask sizes and allocateNodeCIDRs win over it.
+	networkConfigFile     string
+	clusterCIDRs          []string
+	serviceClusterIPRange []string
+	nodeCIDRMaskSize      int
+	nodeCIDRMaskSizeIPv4  int
+	nodeCIDRMaskSizeIPv6  int
+	allocateNodeCIDRs     bool
+	// cloudProviderConfigFile and cloudCAFile are the cloud config and the CA bundle of the cloud endpoints the
+	// bootstrap pod needs before the cluster-config-operator publishes them.
+	cloudProviderConfigFile string
+	cloudCAFile             string
+	// featureGateManifest is the FeatureGate CR written by the installer, it takes precedence over a FeatureGate in
+	// --rendered-manifest-files.
+	featureGateManifest string
+
+	// fromClusterDir is a must-gather to render the next revision of instead of the bootstrap manifests.
+	fromClusterDir string
+
+	// output is empty or outputJSON to describe the written files on out, see Output.
+	output string
+	// outputFormat is one of outputFormats, how the manifests are written.
+	outputFormat string
+	// flags are the parameters recorded in the Output.
+	flags *pflag.FlagSet
+	// out receives the Output, errOut the diagnostics of --from-cluster when output is set.
+	out, errOut io.Writer
 
 	// errHandler is used to handle errors in the command run.
 	// It is used by unit tests to change the behavior of the command on error.
@@ -49,22 +89,29 @@ func NewRenderCommand(errHandler func(error) error) *cobra.Command {
 	}
 
 	renderOpts := &renderOpts{
-		manifest:   *genericrenderoptions.NewManifestOptions("kube-controller-manager", "openshift/origin-hyperkube:latest"),
-		generic:    *genericrenderoptions.NewGenericOptions(),
-		errHandler: errHandler,
+		manifest:       *genericrenderoptions.NewManifestOptions("kube-controller-manager", "openshift/origin-hyperkube:latest"),
+		generic:        *genericrenderoptions.NewGenericOptions(),
+		clusterProfile: highAvailabilityProfile,
+		outputFormat:   outputFormatYAMLFiles,
+		errHandler:     errHandler,
 	}
 	cmd := &cobra.Command{
 		Use:   "render",
 		Short: "Render kubernetes controller manager bootstrap manifests, secrets and configMaps",
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := renderOpts.errHandler(renderOpts.expandRenderedManifestFiles()); err != nil {
+				return
+			}
 			if err := renderOpts.errHandler(renderOpts.Validate()); err != nil {
 				return
 			}
+			renderOpts.flags = cmd.Flags()
+			renderOpts.out, renderOpts.errOut = cmd.OutOrStdout(), cmd.ErrOrStderr()
 			if err := renderOpts.errHandler(renderOpts.Complete()); err != nil {
 				return
 			}
 
-			if err := renderOpts.errHandler(renderOpts.Run()); err != nil {
+			if err := renderOpts.errHandler(renderOpts.Run(cmd.Context())); err != nil {
 				return
 			}
 		},
@@ -78,10 +125,25 @@ func NewRenderCommand(errHandler func(error) error) *cobra.Command {
 func (r *renderOpts) AddFlags(fs *pflag.FlagSet) {
 	r.manifest.AddFlags(fs, "controller manager")
 	r.generic.AddFlags(fs, kubecontrolplanev1.GroupVersion.WithKind("KubeControllerManagerConfig"))
+	fs.Lookup("rendered-manifest-files").Usage = "Files, directories or globs of yaml or json manifests that will be created via cluster-bootstrapping. The manifests among them are not rendered again."
 
 	fs.StringVar(&r.clusterConfigFile, "cluster-config-file", r.clusterConfigFile, "Openshift Cluster API Config file.")
+	fs.StringVar(&r.networkConfigFile, "network-config-file", r.networkConfigFile, "Path to the cluster Network CR yaml. The cluster CIDRs, service cluster IP ranges, node CIDR mask sizes and whether to allocate node CIDRs are derived from it, it takes precedence over --cluster-config-file.")
+	fs.StringSliceVar(&r.clusterCIDRs, "cluster-cidr", r.clusterCIDRs, "CIDRs of the pods, one per IP family, repeated or comma-separated. Overrides --network-config-file.")
+	fs.StringSliceVar(&r.serviceClusterIPRange, "service-cluster-ip-range", r.serviceClusterIPRange, "CIDRs of the services, one per IP family, repeated or comma-separated. Overrides --network-config-file.")
+	fs.IntVar(&r.nodeCIDRMaskSize, "node-cidr-mask-size", r.nodeCIDRMaskSize, "Mask size of the node CIDRs of single-stack cluster CIDRs, overrides the hostPrefix of --network-config-file.")
+	fs.IntVar(&r.nodeCIDRMaskSizeIPv4, "node-cidr-mask-size-ipv4", r.nodeCIDRMaskSizeIPv4, "Mask size of the IPv4 node CIDRs, overrides the hostPrefix of --network-config-file.")
+	fs.IntVar(&r.nodeCIDRMaskSizeIPv6, "node-cidr-mask-size-ipv6", r.nodeCIDRMaskSizeIPv6, "Mask size of the IPv6 node CIDRs, overrides the hostPrefix of --network-config-file.")
+	fs.BoolVar(&r.allocateNodeCIDRs, "allocate-node-cidrs", r.allocateNodeCIDRs, "Whether the kube-controller-manager allocates the node CIDRs, overrides --network-config-file.")
 	fs.StringVar(&r.clusterPolicyControllerImage, "cluster-policy-controller-image", r.clusterPolicyControllerImage, "Image to use for the cluster-policy-controller.")
+	fs.StringVar(&r.clusterProfile, "cluster-profile", r.clusterProfile, fmt.Sprintf("Topology the bootstrap manifests are rendered for, one of %s.", strings.Join(sets.List(sets.KeySet(clusterProfiles)), ", ")))
+	fs.StringVar(&r.cloudProviderConfigFile, "cloud-provider-config-file", r.cloudProviderConfigFile, "Path to the cloud provider config the bootstrap kube-controller-manager is started with.")
+	fs.StringVar(&r.cloudCAFile, "cloud-ca-file", r.cloudCAFile, "Path to the CA bundle of the cloud endpoints the bootstrap kube-controller-manager trusts in addition to the system trust.")
+	fs.StringVar(&r.featureGateManifest, "featuregate-manifest", r.featureGateManifest, "Path to the FeatureGate CR yaml. The feature gates of its feature set are rendered into the bootstrap config instead of the ones of --rendered-manifest-files.")
 	fs.StringVar(&r.clusterPolicyControllerConfigOutputFile, "cpc-config-output-file", r.clusterPolicyControllerConfigOutputFile, "Output path for the Openshift Cluster API Config yaml file.")
+	fs.StringVar(&r.output, "output", r.output, "Output format, \"json\" prints a JSON document describing the written files and the parameters to stdout.")
+	fs.StringVar(&r.outputFormat, "output-format", r.outputFormat, fmt.Sprintf("Format of the rendered manifests, one of %s. \"json-files\" writes them as JSON instead of YAML files, \"stream\" also prints them to stdout as one YAML stream in a stable order.", strings.Join(sets.List(outputFormats), ", ")))
+	fs.StringVar(&r.fromClusterDir, "from-cluster", r.fromClusterDir, "Path to a must-gather of a running cluster. Renders the configmaps and secrets of the revision the operator would create for that cluster into --asset-output-dir instead of the bootstrap manifests.")
 
 	// TODO: remove when the installer has stopped using it
 	fs.BoolVar(&r.disablePhase2, "disable-phase-2", r.disablePhase2, "Disable rendering of the phase 2 daemonset and dependencies.")
@@ -89,24 +151,116 @@ func (r *renderOpts) AddFlags(fs *pflag.FlagSet) {
 	fs.MarkDeprecated("disable-phase-2", "Only used temporarily to synchronize roll out of the phase 2 removal. Does nothing anymore.")
 }
 
-// Validate verifies the inputs.
+// Validate verifies the inputs. It reports every invalid input at once and reads every input file, so that nothing is
+// written when any of them would fail the render.
 func (r *renderOpts) Validate() error {
-	if err := r.manifest.Validate(); err != nil {
-		return err
+	var errs []error
+	if len(r.output) > 0 && r.output != outputJSON {
+		errs = append(errs, fmt.Errorf("--output must be %q, got %q", outputJSON, r.output))
+	}
+	if !outputFormats.Has(r.outputFormat) {
+		errs = append(errs, fmt.Errorf("unknown --output-format %q, must be one of %s", r.outputFormat, strings.Join(sets.List(outputFormats), ", ")))
+	}
+	if r.outputFormat == outputFormatStream && r.output == outputJSON {
+		errs = append(errs, fmt.Errorf("--output-format=%s and --output=%s both print to stdout", outputFormatStream, outputJSON))
+	}
+	if _, ok := clusterProfiles[r.clusterProfile]; !ok {
+		errs = append(errs, fmt.Errorf("unknown --cluster-profile %q, must be one of %s", r.clusterProfile, strings.Join(sets.List(sets.KeySet(clusterProfiles)), ", ")))
+	}
+	if len(r.fromClusterDir) > 0 {
+		if r.outputFormat != outputFormatYAMLFiles {
+			errs = append(errs, fmt.Errorf("--output-format=%s is not supported with --from-cluster", r.outputFormat))
+		}
+		if len(r.generic.AssetOutputDir) == 0 {
+			errs = append(errs, errors.New("missing required flag: --asset-output-dir"))
+		}
+		return utilerrors.NewAggregate(errs)
 	}
+	errs = append(errs, r.manifest.Validate())
 	if err := r.generic.Validate(); err != nil {
-		return err
+		errs = append(errs, err)
+	} else if _, err := r.featureGates(); err != nil {
+		// the rendered manifests are read by the generic validation already
+		errs = append(errs, err)
 	}
-
 	if len(r.clusterPolicyControllerConfigOutputFile) == 0 {
-		return errors.New("missing required flag: --cpc-config-output-file")
+		errs = append(errs, errors.New("missing required flag: --cpc-config-output-file"))
+	}
+
+	errs = append(errs, r.setNetworkConfig(&TemplateData{}))
+	if len(r.cloudProviderConfigFile) > 0 {
+		if _, err := os.Stat(r.cloudProviderConfigFile); err != nil {
+			errs = append(errs, fmt.Errorf("--cloud-provider-config-file: %v", err))
+		}
+	}
+	if len(r.cloudCAFile) > 0 {
+		if _, err := os.Stat(r.cloudCAFile); err != nil {
+			errs = append(errs, fmt.Errorf("--cloud-ca-file: %v", err))
+		}
+	}
+	for _, filename := range r.generic.AdditionalConfigOverrideFiles {
+		errs = append(errs, validateConfigOverrideFile(filename))
+	}
+	if len(r.generic.TemplatesDir) > 0 {
+		for _, name := range []string{
+			filepath.Join("config", "bootstrap-config-overrides.yaml"),
+			filepath.Join("config", "bootstrap-cluster-policy-controller-config-overrides.yaml"),
+		} {
+			if _, err := os.Stat(filepath.Join(r.generic.TemplatesDir, name)); err != nil {
+				errs = append(errs, fmt.Errorf("--templates-input-dir: %v", err))
+			}
+		}
+	}
+	if len(r.generic.AssetInputDir) > 0 {
+		if _, err := r.readBootstrapSecretsKubeconfig(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s/kubeconfig: %v", r.manifest.SecretsHostPath, err))
+		}
 	}
 
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateConfigOverrideFile verifies that the --config-override-files filename is a template of YAML. It is executed
+// without data, the values of the render are not known yet.
+func validateConfigOverrideFile(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("--config-override-files: %v", err)
+	}
+	tmpl, err := template.New(filename).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("--config-override-files: %v", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, TemplateData{}); err != nil {
+		return fmt.Errorf("--config-override-files: %v", err)
+	}
+	if _, err := yaml.YAMLToJSON(rendered.Bytes()); err != nil {
+		return fmt.Errorf("--config-override-files %q is not YAML: %v", filename, err)
+	}
 	return nil
 }
 
+// featureGates returns the feature gates of --featuregate-manifest, or of --rendered-manifest-files without it.
+func (r *renderOpts) featureGates() (featuregates.FeatureGateAccess, error) {
+	var featureGates featuregates.FeatureGateAccess
+	var err error
+	if len(r.featureGateManifest) > 0 {
+		featureGates, err = readFeatureGateManifest(r.featureGateManifest, r.generic.PayloadVersion)
+	} else {
+		featureGates, err = r.generic.FeatureGates()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting FeatureGates: %v", err)
+	}
+	return featureGates, nil
+}
+
 // Complete fills in missing values before command execution.
 func (r *renderOpts) Complete() error {
+	if len(r.fromClusterDir) > 0 {
+		return nil
+	}
 	if err := r.manifest.Complete(); err != nil {
 		return err
 	}
@@ -119,14 +273,66 @@ func (r *renderOpts) Complete() error {
 type TemplateData struct {
 	genericrenderoptions.TemplateData
 
-	// FeatureGates is list of featuregates to apply
+	// FeatureGates is list of featuregates to apply, ClusterPolicyControllerFeatureGates has the gates used within
+	// OpenShift the kube-controller-manager does not know too
 	FeatureGates                          []string
+	ClusterPolicyControllerFeatureGates   []string
 	ExtendedArguments                     string
 	ClusterPolicyControllerImage          string
 	ClusterPolicyControllerConfigFileName string
 	ClusterPolicyControllerFileConfig     genericrenderoptions.FileConfig
 	ClusterCIDR                           []string
 	ServiceClusterIPRange                 []string
+	// NodeCIDRMaskSizes are the node-cidr-mask-size extended arguments by name.
+	NodeCIDRMaskSizes map[string]string
+	// AllocateNodeCIDRs is the allocate-node-cidrs extended argument, the default config applies when empty.
+	AllocateNodeCIDRs string
+	// CloudConfigFileName and CloudCAFileName are the cloud config and the cloud CA bundle in the config directory,
+	// empty when not given.
+	CloudConfigFileName string
+	CloudCAFileName     string
+	Profile             ClusterProfile
+}
+
+const (
+	highAvailabilityProfile = "self-managed-high-availability"
+	singleNodeProfile       = "single-node"
+)
+
+// ClusterProfile are the values of the bootstrap manifests that differ by topology.
+type ClusterProfile struct {
+	// LeaseDuration, RenewDeadline and RetryPeriod are set in the config when not empty, the defaults of the
+	// config apply otherwise.
+	LeaseDuration, RenewDeadline, RetryPeriod string
+	StartupProbeTimeoutSeconds                int
+	ProbeTimeoutSeconds                       int
+	CPURequest, MemoryRequest                 string
+}
+
+// clusterProfiles are the values of --cluster-profile. A single node runs with the SNO leader election timings the
+// operator uses once the cluster is up, its API server is restarted on the same node, so the probes wait longer. It
+// has fewer cores to share, so the CPU request is smaller.
+var clusterProfiles = map[string]ClusterProfile{
+	highAvailabilityProfile: {
+		StartupProbeTimeoutSeconds: 3,
+		ProbeTimeoutSeconds:        10,
+		CPURequest:                 "60m",
+		MemoryRequest:              "200Mi",
+	},
+	singleNodeProfile: singleNodeClusterProfile(),
+}
+
+func singleNodeClusterProfile() ClusterProfile {
+	leaderElection := leaderelectionconverter.LeaderElectionSNOConfig(configv1.LeaderElection{})
+	return ClusterProfile{
+		LeaseDuration:              leaderElection.LeaseDuration.Duration.String(),
+		RenewDeadline:              leaderElection.RenewDeadline.Duration.String(),
+		RetryPeriod:                leaderElection.RetryPeriod.Duration.String(),
+		StartupProbeTimeoutSeconds: 10,
+		ProbeTimeoutSeconds:        30,
+		CPURequest:                 "30m",
+		MemoryRequest:              "200Mi",
+	}
 }
 
 func setFeatureGatesFromAccessor(renderConfig *TemplateData, featureGates featuregates.FeatureGateAccess) error {
@@ -135,14 +341,20 @@ func setFeatureGatesFromAccessor(renderConfig *TemplateData, featureGates featur
 		return fmt.Errorf("unable to get FeatureGates: %w", err)
 	}
 	allGates := []string{}
+	kubeControllerManagerGates := []string{}
 	for _, featureGateName := range currFeatureGates.KnownFeatures() {
-		if currFeatureGates.Enabled(featureGateName) {
-			allGates = append(allGates, fmt.Sprintf("%v=true", featureGateName))
-		} else {
-			allGates = append(allGates, fmt.Sprintf("%v=false", featureGateName))
+		if !knownFeatureGate(featureGateName) {
+			continue
+		}
+		gate := fmt.Sprintf("%v=%v", featureGateName, currFeatureGates.Enabled(featureGateName))
+		allGates = append(allGates, gate)
+		// the kube-controller-manager fails to start with a gate removed upstream, see kcmfeatures.NewObserveFeatureGatesFunc
+		if !kcmfeatures.Removed(string(featureGateName)) {
+			kubeControllerManagerGates = append(kubeControllerManagerGates, gate)
 		}
 	}
-	renderConfig.FeatureGates = allGates
+	renderConfig.FeatureGates = kubeControllerManagerGates
+	renderConfig.ClusterPolicyControllerFeatureGates = allGates
 	return nil
 }
 
@@ -228,22 +440,38 @@ func discoverRestrictedCIDRsFromNetwork(clusterConfigFileData []byte, renderConf
 }
 
 // Run contains the logic of the render command.
-func (r *renderOpts) Run() error {
-	renderConfig := TemplateData{}
-	if len(r.clusterConfigFile) > 0 {
-		clusterConfigFileData, err := ioutil.ReadFile(r.clusterConfigFile)
-		if err != nil {
-			return err
-		}
-		err = discoverRestrictedCIDRs(clusterConfigFileData, &renderConfig)
-		if err != nil {
-			return fmt.Errorf("unable to parse restricted CIDRs from config: %v", err)
+func (r *renderOpts) Run(ctx context.Context) error {
+	if err := r.render(ctx); err != nil {
+		return err
+	}
+	if err := r.writeOutputFormat(); err != nil {
+		return err
+	}
+	if r.output != outputJSON {
+		return nil
+	}
+	return r.writeOutput()
+}
+
+func (r *renderOpts) render(ctx context.Context) error {
+	if len(r.fromClusterDir) > 0 {
+		// stdout is the JSON document alone
+		diagnostics := r.out
+		if r.output == outputJSON {
+			diagnostics = r.errOut
 		}
+		return renderFromCluster(ctx, r.fromClusterDir, r.generic.AssetOutputDir, diagnostics)
+	}
+
+	renderConfig := TemplateData{Profile: clusterProfiles[r.clusterProfile]}
+	if err := r.setNetworkConfig(&renderConfig); err != nil {
+		return err
 	}
+	r.setCloudFiles(&renderConfig)
 
-	featureGates, err := r.generic.FeatureGates()
+	featureGates, err := r.featureGates()
 	if err != nil {
-		return fmt.Errorf("error getting FeatureGates: %v", err)
+		return err
 	}
 	if err := setFeatureGatesFromAccessor(&renderConfig, featureGates); err != nil {
 		return err
@@ -299,8 +527,18 @@ func (r *renderOpts) Run() error {
 	if err := genericrender.WriteFiles(&r.generic, &renderConfig.FileConfig, renderConfig); err != nil {
 		return err
 	}
+	if err := r.writeCloudFiles(); err != nil {
+		return err
+	}
+	renderedManifests, err := r.generic.ReadInputManifests()
+	if err != nil {
+		return err
+	}
+	if err := skipRenderedManifests(filepath.Join(r.generic.AssetOutputDir, "manifests"), renderedManifests); err != nil {
+		return err
+	}
 
-	if err := ioutil.WriteFile(
+	if err := os.WriteFile(
 		r.clusterPolicyControllerConfigOutputFile,
 		renderConfig.ClusterPolicyControllerFileConfig.BootstrapConfig,
 		0644,
@@ -312,11 +550,11 @@ func (r *renderOpts) Run() error {
 }
 
 func (r *renderOpts) readBootstrapSecretsKubeconfig() ([]byte, error) {
-	return ioutil.ReadFile(filepath.Join(r.generic.AssetInputDir, "..", "auth", "kubeconfig"))
+	return os.ReadFile(filepath.Join(r.generic.AssetInputDir, "..", "auth", "kubeconfig"))
 }
 
 func mustReadTemplateFile(fname string) genericrenderoptions.Template {
-	bs, err := ioutil.ReadFile(fname)
+	bs, err := os.ReadFile(fname)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to load %q: %v", fname, err))
 	}
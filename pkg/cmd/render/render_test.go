@@ -1,6 +1,7 @@
 package render
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path"
@@ -18,8 +19,11 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
 var (
 	expectedClusterCIDR = []string{"10.128.0.0/14"}
 	expectedServiceCIDR = []string{"172.30.0.0/16"}
@@ -144,6 +148,60 @@ func TestRenderCommand(t *testing.T) {
 			},
 			expectedErr: "missing required flag: --asset-input-dir",
 		},
+		{
+			name: "unknown-output",
+			args: []string{
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir,
+				"--payload-version=test",
+				"--output=yaml",
+			},
+			expectedErr: `--output must be "json", got "yaml"`,
+		},
+		{
+			name: "unknown-output-format",
+			args: []string{
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir,
+				"--payload-version=test",
+				"--output-format=xml-files",
+			},
+			expectedErr: `unknown --output-format "xml-files", must be one of json-files, stream, yaml-files`,
+		},
+		{
+			name: "stream-with-json-output",
+			args: []string{
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir,
+				"--payload-version=test",
+				"--output-format=stream",
+				"--output=json",
+			},
+			expectedErr: `--output-format=stream and --output=json both print to stdout`,
+		},
+		{
+			name: "unknown-cluster-profile",
+			args: []string{
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir,
+				"--payload-version=test",
+				"--cluster-profile=hypershift",
+			},
+			expectedErr: `unknown --cluster-profile "hypershift", must be one of self-managed-high-availability, single-node`,
+		},
+		{
+			name: "unknown-feature-set",
+			args: []string{
+				"--asset-input-dir=" + assetsInputDir,
+				"--templates-input-dir=" + templateDir,
+				"--featuregate-manifest=" + filepath.Join("testdata", "featuregate-manifests", "unknown.yaml"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			},
+			expectedErr: `error getting FeatureGates: "testdata/featuregate-manifests/unknown.yaml" selects the unknown feature set "Unknown"`,
+		},
 		{
 			name: "happy-path",
 			args: []string{
@@ -190,8 +248,75 @@ func TestRenderCommand(t *testing.T) {
 						"--controllers=-tokencleaner",
 						"--controllers=-ttl",
 						"--enable-dynamic-provisioning=true",
-						"--feature-gates=Bar=false",
-						"--feature-gates=Foo=true",
+						"--feature-gates=MemoryQoS=false",
+						"--feature-gates=NodeSwap=true",
+						"--flex-volume-plugin-dir=/etc/kubernetes/kubelet-plugins/volume/exec",
+						"--kube-api-burst=300",
+						"--kube-api-qps=150",
+						"--leader-elect-renew-deadline=12s",
+						"--leader-elect-resource-lock=leases",
+						"--leader-elect-retry-period=3s",
+						"--leader-elect=true",
+						"--pv-recycler-pod-template-filepath-hostpath=",
+						"--pv-recycler-pod-template-filepath-nfs=",
+						"--root-ca-file=/etc/kubernetes/secrets/kube-apiserver-complete-server-ca-bundle.crt",
+						"--secure-port=10257",
+						"--service-account-private-key-file=/etc/kubernetes/secrets/service-account.key",
+						"--use-service-account-credentials=true",
+					},
+				},
+			},
+		},
+		{
+			name: "network-config-file",
+			args: []string{
+				"--asset-input-dir=" + assetsInputDir,
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir,
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+				"--network-config-file=" + filepath.Join("testdata", "network", "cluster-network-02-config.yml"),
+			},
+			expectedFiles: []string{
+				"configs/config.yaml",
+				"configs/cpc-config.yaml",
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml",
+				"manifests/manifests/0000_00_namespace-openshift-infra.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrole.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-ns.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-operator-ns.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/secret-csr-signer-signer.yaml",
+				"manifests/manifests/secret-initial-kube-controller-manager-service-account-private-key.yaml",
+			},
+			expectedContents: map[string]map[string]interface{}{
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml": {
+					"spec.containers[0].args": []interface{}{
+						"--openshift-config=/etc/kubernetes/config/kube-controller-manager-config.yaml",
+						"--kubeconfig=/etc/kubernetes/secrets/kubeconfig",
+						"--v=2",
+						"--allocate-node-cidrs=true",
+						"--authentication-kubeconfig=/etc/kubernetes/secrets/kubeconfig",
+						"--authorization-kubeconfig=/etc/kubernetes/secrets/kubeconfig",
+						"--cert-dir=/var/run/kubernetes",
+						"--cluster-cidr=10.128.0.0/14,fd01::/48",
+						"--cluster-signing-cert-file=/etc/kubernetes/secrets/kubelet-signer.crt",
+						"--cluster-signing-duration=720h",
+						"--cluster-signing-key-file=/etc/kubernetes/secrets/kubelet-signer.key",
+						"--configure-cloud-routes=false",
+						"--controllers=*",
+						"--controllers=-bootstrapsigner",
+						"--controllers=-tokencleaner",
+						"--controllers=-ttl",
+						"--enable-dynamic-provisioning=true",
+						"--feature-gates=MemoryQoS=false",
+						"--feature-gates=NodeSwap=true",
 						"--flex-volume-plugin-dir=/etc/kubernetes/kubelet-plugins/volume/exec",
 						"--kube-api-burst=300",
 						"--kube-api-qps=150",
@@ -199,11 +324,14 @@ func TestRenderCommand(t *testing.T) {
 						"--leader-elect-resource-lock=leases",
 						"--leader-elect-retry-period=3s",
 						"--leader-elect=true",
+						"--node-cidr-mask-size-ipv4=23",
+						"--node-cidr-mask-size-ipv6=64",
 						"--pv-recycler-pod-template-filepath-hostpath=",
 						"--pv-recycler-pod-template-filepath-nfs=",
 						"--root-ca-file=/etc/kubernetes/secrets/kube-apiserver-complete-server-ca-bundle.crt",
 						"--secure-port=10257",
 						"--service-account-private-key-file=/etc/kubernetes/secrets/service-account.key",
+						"--service-cluster-ip-range=172.30.0.0/16,fd02::/112",
 						"--use-service-account-credentials=true",
 					},
 				},
@@ -269,8 +397,8 @@ func TestRenderCommand(t *testing.T) {
 						"--controllers=-tokencleaner",
 						"--controllers=-ttl",
 						"--enable-dynamic-provisioning=true",
-						"--feature-gates=Bar=false",
-						"--feature-gates=Foo=true",
+						"--feature-gates=MemoryQoS=false",
+						"--feature-gates=NodeSwap=true",
 						"--flex-volume-plugin-dir=/etc/kubernetes/kubelet-plugins/volume/exec",
 						"--kube-api-burst=300",
 						"--kube-api-qps=150",
@@ -334,8 +462,8 @@ func TestRenderCommand(t *testing.T) {
 						"--controllers=-tokencleaner",
 						"--controllers=-ttl",
 						"--enable-dynamic-provisioning=true",
-						"--feature-gates=AwesomeNewFeature=true",
-						"--feature-gates=BadFailingFeature=false",
+						"--feature-gates=CloudDualStackNodeIPs=true",
+						"--feature-gates=DynamicResourceAllocation=false",
 						"--flex-volume-plugin-dir=/etc/kubernetes/kubelet-plugins/volume/exec",
 						"--kube-api-burst=300",
 						"--kube-api-qps=150",
@@ -353,6 +481,88 @@ func TestRenderCommand(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "rendered-manifests-skipped",
+			args: []string{
+				"--asset-input-dir=" + assetsInputDir,
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir + "," + filepath.Join("testdata", "rendered", "shared", "*.yaml"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			},
+			expectedFiles: []string{
+				"configs/config.yaml",
+				"configs/cpc-config.yaml",
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-ns.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-operator-ns.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/secret-csr-signer-signer.yaml",
+				"manifests/manifests/secret-initial-kube-controller-manager-service-account-private-key.yaml",
+			},
+		},
+		{
+			name: "rendered-manifests-conflict",
+			args: []string{
+				"--asset-input-dir=" + assetsInputDir,
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + defaultFGDir + "," + filepath.Join("testdata", "rendered", "conflicting", "*.yaml"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			},
+			expectedErr: `(?s)00_namespace-security-allocation-controller-clusterrole.yaml conflicts with "testdata/rendered/conflicting/namespace-security-allocation-controller-clusterrole.yaml" rendered already: .*\+.*"patch"`,
+			expectedFiles: []string{
+				"configs/config.yaml",
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml",
+				"manifests/manifests/0000_00_namespace-openshift-infra.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrole.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-ns.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-operator-ns.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/secret-csr-signer-signer.yaml",
+				"manifests/manifests/secret-initial-kube-controller-manager-service-account-private-key.yaml",
+			},
+		},
+		{
+			name: "rendered-manifests-glob-without-matches",
+			args: []string{
+				"--asset-input-dir=" + assetsInputDir,
+				"--templates-input-dir=" + templateDir,
+				"--rendered-manifest-files=" + filepath.Join(defaultFGDir, "*.yaml") + "," + filepath.Join("testdata", "rendered", "missing", "*.yaml"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			},
+			expectedFiles: []string{
+				"configs/config.yaml",
+				"configs/cpc-config.yaml",
+				"manifests/bootstrap-manifests/kube-controller-manager-pod.yaml",
+				"manifests/manifests/0000_00_namespace-openshift-infra.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrole.yaml",
+				"manifests/manifests/00_namespace-security-allocation-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-ns.yaml",
+				"manifests/manifests/00_openshift-kube-controller-manager-operator-ns.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
+				"manifests/manifests/00_podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
+				"manifests/manifests/secret-csr-signer-signer.yaml",
+				"manifests/manifests/secret-initial-kube-controller-manager-service-account-private-key.yaml",
+			},
+		},
 		{
 			name: "mismatched-fg",
 			args: []string{
@@ -451,6 +661,255 @@ func TestRenderCommand(t *testing.T) {
 	}
 }
 
+// TestRenderClusterProfiles pins the bootstrap pod and config of every cluster profile, run with -update after an
+// intended change.
+func TestRenderClusterProfiles(t *testing.T) {
+	for _, profile := range []string{"self-managed-high-availability", "single-node"} {
+		t.Run(profile, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-" + profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			_, err = runRender(setOutputFlags([]string{
+				"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+				"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+				"--rendered-manifest-files=" + filepath.Join("testdata", "rendered", "default-fg"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+				"--cluster-profile=" + profile,
+			}, outputDir)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for golden, rendered := range map[string]string{
+				"kube-controller-manager-pod.yaml": filepath.Join(outputDir, "manifests", "bootstrap-manifests", "kube-controller-manager-pod.yaml"),
+				"config.yaml":                      filepath.Join(outputDir, "configs", "config.yaml"),
+			} {
+				actual, err := os.ReadFile(rendered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				compareGolden(t, filepath.Join("testdata", "cluster-profiles", profile, golden), actual)
+			}
+		})
+	}
+}
+
+// TestRenderFeatureGateManifest pins the feature gates rendered from the FeatureGate CR of every feature set, run with
+// -update after an intended change or a bump of the feature sets of openshift/api.
+func TestRenderFeatureGateManifest(t *testing.T) {
+	for _, featureSet := range []string{"default", "techpreview"} {
+		t.Run(featureSet, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-" + featureSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			_, err = runRender(setOutputFlags([]string{
+				"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+				"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+				"--featuregate-manifest=" + filepath.Join("testdata", "featuregate-manifests", featureSet+".yaml"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+			}, outputDir)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for golden, rendered := range map[string]string{
+				"kube-controller-manager-pod.yaml": filepath.Join(outputDir, "manifests", "bootstrap-manifests", "kube-controller-manager-pod.yaml"),
+				"config.yaml":                      filepath.Join(outputDir, "configs", "config.yaml"),
+			} {
+				actual, err := os.ReadFile(rendered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				compareGolden(t, filepath.Join("testdata", "feature-sets", featureSet, golden), actual)
+			}
+		})
+	}
+}
+
+// TestRenderCloudFiles pins the bootstrap pod, config and configmaps rendered with the cloud config and CA bundle of
+// every platform, run with -update after an intended change.
+func TestRenderCloudFiles(t *testing.T) {
+	for _, platform := range []string{"azure", "vsphere"} {
+		t.Run(platform, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-" + platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			input := filepath.Join("testdata", "cloud", platform, "input")
+			_, err = runRender(setOutputFlags([]string{
+				"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+				"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+				"--rendered-manifest-files=" + filepath.Join("testdata", "rendered", "default-fg"),
+				"--asset-output-dir=",
+				"--config-output-file=",
+				"--cpc-config-output-file=",
+				"--payload-version=test",
+				"--cloud-provider-config-file=" + filepath.Join(input, "cloud.conf"),
+				"--cloud-ca-file=" + filepath.Join(input, "ca-bundle.pem"),
+			}, outputDir)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for golden, rendered := range map[string]string{
+				"kube-controller-manager-pod.yaml": filepath.Join(outputDir, "manifests", "bootstrap-manifests", "kube-controller-manager-pod.yaml"),
+				"config.yaml":                      filepath.Join(outputDir, "configs", "config.yaml"),
+				"configmap-cloud-config.yaml":      filepath.Join(outputDir, "manifests", "manifests", "configmap-cloud-config.yaml"),
+				"configmap-trusted-ca-bundle.yaml": filepath.Join(outputDir, "manifests", "manifests", "configmap-trusted-ca-bundle.yaml"),
+			} {
+				actual, err := os.ReadFile(rendered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				compareGolden(t, filepath.Join("testdata", "cloud", platform, golden), actual)
+			}
+
+			for copied, source := range map[string]string{
+				"cloud.conf":          "cloud.conf",
+				"cloud-ca-bundle.pem": "ca-bundle.pem",
+			} {
+				actual, err := os.ReadFile(filepath.Join(outputDir, "configs", copied))
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected, err := os.ReadFile(filepath.Join(input, source))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(string(expected), string(actual)); len(diff) > 0 {
+					t.Errorf("%s differs from %s: %s", copied, source, diff)
+				}
+			}
+		})
+	}
+}
+
+// TestValidate verifies that all invalid inputs are reported at once and nothing is written then.
+func TestValidate(t *testing.T) {
+	validArgs := []string{
+		"--asset-input-dir=" + filepath.Join("testdata", "tls"),
+		"--templates-input-dir=" + filepath.Join("..", "..", "..", "bindata", "bootkube"),
+		"--rendered-manifest-files=" + filepath.Join("testdata", "rendered", "default-fg"),
+		"--asset-output-dir=",
+		"--config-output-file=",
+		"--cpc-config-output-file=",
+		"--payload-version=test",
+	}
+	tests := []struct {
+		name           string
+		args           []string
+		expectedErrors []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "cidr-image-and-override-file",
+			args: []string{
+				"--cluster-cidr=10.128.0.0",
+				"--manifest-image=",
+				"--config-override-files=" + filepath.Join("testdata", "configs", "missing.yaml"),
+			},
+			expectedErrors: []string{
+				"missing required flag: --manifest-image",
+				"invalid cluster CIDR: invalid CIDR address: 10.128.0.0",
+				"--config-override-files: open testdata/configs/missing.yaml: no such file or directory",
+			},
+		},
+		{
+			name: "files-and-conflicting-flags",
+			args: []string{
+				"--output=json",
+				"--output-format=stream",
+				"--node-cidr-mask-size=23",
+				"--node-cidr-mask-size-ipv4=24",
+				"--cloud-ca-file=" + filepath.Join("testdata", "cloud", "missing.pem"),
+				"--featuregate-manifest=" + filepath.Join("testdata", "featuregate-manifests", "unknown.yaml"),
+				"--config-override-files=" + filepath.Join("testdata", "configs", "unparsable-overrides.yaml"),
+			},
+			expectedErrors: []string{
+				"--output-format=stream and --output=json both print to stdout",
+				`error getting FeatureGates: "testdata/featuregate-manifests/unknown.yaml" selects the unknown feature set "Unknown"`,
+				"--node-cidr-mask-size is not allowed with --node-cidr-mask-size-ipv4 and --node-cidr-mask-size-ipv6",
+				"--cloud-ca-file: stat testdata/cloud/missing.pem: no such file or directory",
+				`--config-override-files "testdata/configs/unparsable-overrides.yaml" is not YAML: .*`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			teardown, outputDir, err := setupAssetOutputDir("render-validate")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer teardown()
+
+			_, err = runRender(setOutputFlags(append(validArgs, test.args...), outputDir)...)
+			var actualErrors []string
+			if aggregate, ok := err.(utilerrors.Aggregate); ok {
+				for _, err := range aggregate.Errors() {
+					actualErrors = append(actualErrors, err.Error())
+				}
+			} else if err != nil {
+				actualErrors = []string{err.Error()}
+			}
+			if len(actualErrors) != len(test.expectedErrors) {
+				t.Fatalf("expected the errors %q, got %q", test.expectedErrors, actualErrors)
+			}
+			for i := range test.expectedErrors {
+				if !regexp.MustCompile("^" + test.expectedErrors[i] + "$").MatchString(actualErrors[i]) {
+					t.Errorf("expected the error %q, got %q", test.expectedErrors[i], actualErrors[i])
+				}
+			}
+
+			if len(test.expectedErrors) == 0 {
+				return
+			}
+			if err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					t.Errorf("expected nothing to be written, got %s", path)
+				}
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func compareGolden(t *testing.T, golden string, actual []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(expected), string(actual)); len(diff) > 0 {
+		t.Errorf("%s differs from the golden file, run with -update after an intended change: %s", golden, diff)
+	}
+}
+
 func readPath(obj map[string]interface{}, path string) (interface{}, error) {
 	if strings.Contains(path, "[") {
 		nestedPath := strings.Split(path, "[")
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/klog/v2"
+
+	genericrenderoptions "github.com/openshift/library-go/pkg/operator/render/options"
+)
+
+// expandRenderedManifestFiles expands the globs of --rendered-manifest-files. A glob matching no file is dropped, the
+// other operators may not have rendered anything into it.
+func (r *renderOpts) expandRenderedManifestFiles() error {
+	var files []string
+	for _, pattern := range r.generic.RenderedManifestInputFilenames {
+		if !strings.ContainsAny(pattern, `*?[`) {
+			files = append(files, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("--rendered-manifest-files %q: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			klog.Warningf("--rendered-manifest-files %q matches no files", pattern)
+		}
+		files = append(files, matches...)
+	}
+	r.generic.RenderedManifestInputFilenames = files
+	return nil
+}
+
+// skipRenderedManifests removes the manifests in dir another operator has rendered already, i.e. that are of the same
+// group, kind, namespace and name as one of rendered. A manifest that differs from the one rendered already in more
+// than its metadata and status is a conflict, the cluster would end up with either depending on the order they are
+// created in.
+func skipRenderedManifests(dir string, rendered genericrenderoptions.RenderedManifests) error {
+	if len(rendered) == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		manifest := genericrenderoptions.RenderedManifest{OriginalFilename: filename, Content: content}
+		obj, err := manifest.GetDecodedObj()
+		if err != nil {
+			return err
+		}
+		existing, err := findRenderedManifest(rendered, obj)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+
+		existingObj, err := existing.GetDecodedObj()
+		if err != nil {
+			return err
+		}
+		ours, err := withoutMetadata(obj)
+		if err != nil {
+			return err
+		}
+		theirs, err := withoutMetadata(existingObj)
+		if err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(ours, theirs) {
+			return fmt.Errorf("%s conflicts with %q rendered already: %s", entry.Name(), existing.OriginalFilename, diff.ObjectDiff(theirs, ours))
+		}
+
+		klog.Infof("Skipping %s, %q is rendered already", entry.Name(), existing.OriginalFilename)
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findRenderedManifest returns the manifest of rendered that is of the group, kind, namespace and name of obj, nil if
+// there is none.
+func findRenderedManifest(rendered genericrenderoptions.RenderedManifests, obj runtime.Object) (*genericrenderoptions.RenderedManifest, error) {
+	objMetadata, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rendered {
+		renderedObj, err := rendered[i].GetDecodedObj()
+		if err != nil {
+			return nil, err
+		}
+		if renderedObj.GetObjectKind().GroupVersionKind().GroupKind() != obj.GetObjectKind().GroupVersionKind().GroupKind() {
+			continue
+		}
+		renderedMetadata, err := meta.Accessor(renderedObj)
+		if err != nil {
+			return nil, err
+		}
+		if renderedMetadata.GetNamespace() == objMetadata.GetNamespace() && renderedMetadata.GetName() == objMetadata.GetName() {
+			return &rendered[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// withoutMetadata returns the content of obj that is compared for conflicts, the versions of a group may differ.
+func withoutMetadata(obj runtime.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	content = runtime.DeepCopyJSON(content)
+	delete(content, "apiVersion")
+	delete(content, "metadata")
+	delete(content, "status")
+	return content, nil
+}
@@ -0,0 +1,76 @@
+package upgradecheck
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/config/client"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/upgradecheck"
+)
+
+type options struct {
+	kubeconfig string
+	window     time.Duration
+}
+
+// NewUpgradeCheckCommand checks whether kube-controller-manager is ready to upgrade the cluster. It prints a report of
+// every criterion and exits with 1 when one failed, for use in pipelines.
+func NewUpgradeCheckCommand(ctx context.Context) *cobra.Command {
+	o := &options{window: upgradecheck.DefaultWindow}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-check",
+		Short: "Check whether kube-controller-manager is ready to upgrade",
+		Run: func(cmd *cobra.Command, args []string) {
+			passed, err := o.run(ctx, os.Stdout)
+			if err != nil {
+				klog.Fatal(err)
+			}
+			if !passed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&o.kubeconfig, "kubeconfig", o.kubeconfig, "kubeconfig file of the cluster, the in-cluster config without")
+	cmd.Flags().DurationVar(&o.window, "window", o.window, "duration of the upgrade, no certificate may expire within it")
+
+	return cmd
+}
+
+func (o *options) run(ctx context.Context, out io.Writer) (bool, error) {
+	clientConfig, err := client.GetKubeConfigOrInClusterConfig(o.kubeconfig, nil)
+	if err != nil {
+		return false, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return false, err
+	}
+	operatorClient, err := operatorv1client.NewForConfig(clientConfig)
+	if err != nil {
+		return false, err
+	}
+
+	state, err := upgradecheck.Gather(ctx, upgradecheck.Clients{
+		KubeControllerManagers: operatorClient,
+		Secrets:                kubeClient.CoreV1(),
+		Leases:                 kubeClient.CoordinationV1(),
+	})
+	if err != nil {
+		return false, err
+	}
+	report := upgradecheck.Check(state, time.Now(), o.window)
+	if err := report.Write(out); err != nil {
+		return false, err
+	}
+	return report.Passed(), nil
+}
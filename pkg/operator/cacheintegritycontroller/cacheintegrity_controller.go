@@ -0,0 +1,199 @@
+package cacheintegritycontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const controllerName = "CacheIntegrityController"
+
+// revisionStatusPrefix names the configmaps the revision controller derives the latest revision from
+const revisionStatusPrefix = "revision-status-"
+
+var divergencesMetric = metrics.NewCounterVec(&metrics.CounterOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "cache_divergences_total",
+	Help:           "Objects of the operand namespace the informer cache of the operator disagreed on with the API server, by resource and by the check that found it",
+	StabilityLevel: metrics.ALPHA,
+}, []string{"resource", "check"})
+
+func init() {
+	legacyregistry.MustRegister(divergencesMetric)
+}
+
+// CacheIntegrityController compares the informer cache with the API server for the objects a revision is made of:
+// the revision status configmaps and the configmaps and secrets copied into every revision. A watch gap can leave the
+// cache without an object or at an old version of it until the informer relists, the revision controller then creates
+// revisions from stale content.
+//
+// An object the cache and the API server disagree on is checked again with the next sync. It is a divergence when the
+// live object did not change in between and the cache still disagrees, the cache is only allowed to lag behind
+// changes. Divergences are counted in openshift_kube_controller_manager_operator_cache_divergences_total and force a
+// relist. The shared informers cannot relist on their own, relist restarts the operator after releasing the lease.
+type CacheIntegrityController struct {
+	kubeClient      kubernetes.Interface
+	configMapLister corev1listers.ConfigMapNamespaceLister
+	secretLister    corev1listers.SecretNamespaceLister
+	configMaps      []string
+	secrets         []string
+	relist          func()
+
+	// suspects are the live resource versions of the objects the cache disagreed on in the previous sync, by
+	// resource/name. A deleted object has an empty resource version.
+	suspects map[string]string
+}
+
+func NewCacheIntegrityController(
+	kubeClient kubernetes.Interface,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMaps, secrets []string,
+	relist func(),
+	eventRecorder events.Recorder,
+) factory.Controller {
+	informers := kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1()
+	c := &CacheIntegrityController{
+		kubeClient:      kubeClient,
+		configMapLister: informers.ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+		secretLister:    informers.Secrets().Lister().Secrets(operatorclient.TargetNamespace),
+		configMaps:      configMaps,
+		secrets:         secrets,
+		relist:          relist,
+		suspects:        map[string]string{},
+	}
+
+	// no informers, the checks are spot checks of the cache at a slow cadence
+	return factory.New().ResyncEvery(5*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("cache-integrity-controller"))
+}
+
+func (c *CacheIntegrityController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	cached, err := c.cachedVersions()
+	if err != nil {
+		return err
+	}
+	live, err := c.liveVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	suspects := map[string]string{}
+	var divergences []string
+	for key := range union(cached, live) {
+		cachedVersion, liveVersion := cached[key], live[key]
+		if cachedVersion == liveVersion {
+			continue
+		}
+		if previous, ok := c.suspects[key]; ok && previous == liveVersion {
+			divergencesMetric.WithLabelValues(strings.SplitN(key, "/", 2)[0], "spot-check").Inc()
+			divergences = append(divergences, fmt.Sprintf("%s is at resourceVersion %q in the cache but %q in the API server", key, cachedVersion, liveVersion))
+			continue
+		}
+		suspects[key] = liveVersion
+	}
+	c.suspects = suspects
+	if len(divergences) == 0 {
+		return nil
+	}
+
+	sort.Strings(divergences)
+	klog.Errorf("The informer cache of namespace %s diverged from the API server: %s", operatorclient.TargetNamespace, strings.Join(divergences, "; "))
+	if c.relist == nil {
+		syncCtx.Recorder().Warningf("CacheDivergence", "The informer cache diverged from the API server: %s", strings.Join(divergences, "; "))
+		return nil
+	}
+	syncCtx.Recorder().Warningf("CacheDivergence", "The informer cache diverged from the API server, restarting to relist: %s", strings.Join(divergences, "; "))
+	c.relist()
+	return nil
+}
+
+// cachedVersions returns the resource versions of the checked objects in the cache by resource/name.
+func (c *CacheIntegrityController) cachedVersions() (map[string]string, error) {
+	versions := map[string]string{}
+	configMaps, err := c.configMapLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, configMap := range configMaps {
+		if c.checked(configMap.Name) {
+			versions["configmaps/"+configMap.Name] = configMap.ResourceVersion
+		}
+	}
+	for _, name := range c.secrets {
+		secret, err := c.secretLister.Get(name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions["secrets/"+name] = secret.ResourceVersion
+	}
+	return versions, nil
+}
+
+// liveVersions returns the resource versions of the checked objects in the API server by resource/name.
+func (c *CacheIntegrityController) liveVersions(ctx context.Context) (map[string]string, error) {
+	versions := map[string]string{}
+	configMaps, err := c.kubeClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, configMap := range configMaps.Items {
+		if c.checked(configMap.Name) {
+			versions["configmaps/"+configMap.Name] = configMap.ResourceVersion
+		}
+	}
+	for _, name := range c.secrets {
+		secret, err := c.kubeClient.CoreV1().Secrets(operatorclient.TargetNamespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions["secrets/"+name] = secret.ResourceVersion
+	}
+	return versions, nil
+}
+
+// checked is true for the revision status configmaps and the configmaps copied into the revisions.
+func (c *CacheIntegrityController) checked(configMap string) bool {
+	if strings.HasPrefix(configMap, revisionStatusPrefix) {
+		return true
+	}
+	for _, name := range c.configMaps {
+		if configMap == name {
+			return true
+		}
+	}
+	return false
+}
+
+func union(a, b map[string]string) map[string]bool {
+	keys := map[string]bool{}
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
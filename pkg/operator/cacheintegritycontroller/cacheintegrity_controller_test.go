@@ -0,0 +1,174 @@
+package cacheintegritycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func configMap(name, resourceVersion string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: name, ResourceVersion: resourceVersion},
+		Data:       data,
+	}
+}
+
+func revisionStatus(revision, resourceVersion string) *corev1.ConfigMap {
+	status := configMap("revision-status-"+revision, resourceVersion, map[string]string{"revision": revision})
+	status.Annotations = map[string]string{"operator.openshift.io/revision-ready": "true"}
+	status.UID = types.UID("uid-" + revision)
+	return status
+}
+
+// newInformers returns informers whose cache holds cached, they are not started.
+func newInformers(t *testing.T, client *fake.Clientset, cached ...runtime.Object) v1helpers.KubeInformersForNamespaces {
+	informers := v1helpers.NewKubeInformersForNamespaces(client, operatorclient.TargetNamespace)
+	for _, obj := range cached {
+		var err error
+		switch obj.(type) {
+		case *corev1.ConfigMap:
+			err = informers.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer().GetIndexer().Add(obj)
+		case *corev1.Secret:
+			err = informers.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Informer().GetIndexer().Add(obj)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return informers
+}
+
+func TestLiveGetPreventsRecreatingARevision(t *testing.T) {
+	source := configMap("config", "10", map[string]string{"config.yaml": "new"})
+	live := []runtime.Object{
+		source,
+		revisionStatus("1", "11"), configMap("config-1", "12", map[string]string{"config.yaml": "old"}),
+		revisionStatus("2", "13"), configMap("config-2", "14", map[string]string{"config.yaml": "new"}),
+	}
+	client := fake.NewSimpleClientset(live...)
+	// a watch gap lost revision 2
+	informers := newInformers(t, client, live[:3]...)
+	liveCreateClient := NewLiveCreateClient(client, operatorclient.TargetNamespace)
+
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 1},
+		nil,
+		nil,
+	)
+	controller := revisioncontroller.NewRevisionController(
+		operatorclient.TargetNamespace,
+		[]revisioncontroller.RevisionResource{{Name: "config"}},
+		nil,
+		informers.InformersFor(operatorclient.TargetNamespace),
+		revisioncontroller.StaticPodLatestRevisionClient{StaticPodOperatorClient: operatorClient},
+		v1helpers.CachedConfigMapGetter(liveCreateClient.CoreV1(), informers),
+		v1helpers.CachedSecretGetter(liveCreateClient.CoreV1(), informers),
+		events.NewInMemoryRecorder("test"),
+	)
+	before, _ := testutil.GetCounterMetricValue(divergencesMetric.WithLabelValues("configmaps", "create"))
+	if err := controller.Sync(context.TODO(), factory.NewSyncContext("RevisionController", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" && action.GetVerb() != "list" && action.GetVerb() != "watch" {
+			t.Errorf("expected revision 2 not to be written again, got %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+	if after, _ := testutil.GetCounterMetricValue(divergencesMetric.WithLabelValues("configmaps", "create")); after != before+1 {
+		t.Errorf("expected the divergence to be counted, got %v", after-before)
+	}
+}
+
+func TestLiveCreateClientCreatesMissingObjects(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	liveCreateClient := NewLiveCreateClient(client, operatorclient.TargetNamespace)
+	if _, err := liveCreateClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Create(context.TODO(), configMap("config-3", "", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := liveCreateClient.CoreV1().Secrets(operatorclient.TargetNamespace).Create(context.TODO(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "serving-cert-3"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(context.TODO(), "config-3", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the configmap to be created, got %v", err)
+	}
+}
+
+func TestSpotCheckRelistsOnDivergence(t *testing.T) {
+	live := []runtime.Object{
+		revisionStatus("1", "11"),
+		revisionStatus("2", "13"),
+		configMap("config", "20", nil),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "service-account-private-key", ResourceVersion: "30"}},
+		// not checked
+		configMap("unrelated", "40", nil),
+	}
+	client := fake.NewSimpleClientset(live...)
+	// the cache missed revision 2 and is at an old version of the secret
+	informers := newInformers(t, client,
+		revisionStatus("1", "11"),
+		configMap("config", "19", nil),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "service-account-private-key", ResourceVersion: "29"}},
+	)
+	relisted := 0
+	recorder := events.NewInMemoryRecorder("test")
+	controller := NewCacheIntegrityController(client, informers, []string{"config"}, []string{"service-account-private-key"}, func() { relisted++ }, recorder)
+	sync := func() {
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, recorder)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the cache may lag behind, the first disagreement is a suspect
+	sync()
+	if relisted != 0 || len(recorder.Events()) != 0 {
+		t.Fatalf("expected no relist on the first disagreement, got %d relists and %v", relisted, recorder.Events())
+	}
+
+	// the cache caught up with the configmap, which changed meanwhile, the others did not change
+	if err := informers.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer().GetIndexer().Update(configMap("config", "21", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Update(context.TODO(), configMap("config", "21", nil), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := testutil.GetCounterMetricValue(divergencesMetric.WithLabelValues("configmaps", "spot-check"))
+	sync()
+	if relisted != 1 {
+		t.Errorf("expected a relist, got %d", relisted)
+	}
+	if after, _ := testutil.GetCounterMetricValue(divergencesMetric.WithLabelValues("configmaps", "spot-check")); after != before+1 {
+		t.Errorf("expected a configmap divergence, got %v", after-before)
+	}
+	events := recorder.Events()
+	if len(events) != 1 || events[0].Reason != "CacheDivergence" {
+		t.Fatalf("expected a CacheDivergence event, got %v", events)
+	}
+	for _, expected := range []string{
+		`configmaps/revision-status-2 is at resourceVersion "" in the cache but "13" in the API server`,
+		`secrets/service-account-private-key is at resourceVersion "29" in the cache but "30" in the API server`,
+	} {
+		if !strings.Contains(events[0].Message, expected) {
+			t.Errorf("expected the event to contain %q, got %q", expected, events[0].Message)
+		}
+	}
+	if strings.Contains(events[0].Message, "configmaps/config ") || strings.Contains(events[0].Message, "unrelated") {
+		t.Errorf("expected only the diverged objects, got %q", events[0].Message)
+	}
+}
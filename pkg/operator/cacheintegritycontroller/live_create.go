@@ -0,0 +1,86 @@
+package cacheintegritycontroller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// NewLiveCreateClient returns kubeClient with a live GET before every create of a configmap or secret in namespace. The
+// revision controller reads through the informer cache and creates what it does not find there, an object the cache
+// missed is returned with an AlreadyExists error instead of being created. Unlike the error of the API server, which
+// comes without the object, the live object lets the revision controller see that the revision exists already.
+func NewLiveCreateClient(kubeClient kubernetes.Interface, namespace string) kubernetes.Interface {
+	return &liveCreateClient{Interface: kubeClient, namespace: namespace}
+}
+
+type liveCreateClient struct {
+	kubernetes.Interface
+	namespace string
+}
+
+func (c *liveCreateClient) CoreV1() corev1client.CoreV1Interface {
+	return &liveCreateCoreV1{CoreV1Interface: c.Interface.CoreV1(), namespace: c.namespace}
+}
+
+type liveCreateCoreV1 struct {
+	corev1client.CoreV1Interface
+	namespace string
+}
+
+func (c *liveCreateCoreV1) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	if namespace != c.namespace {
+		return c.CoreV1Interface.ConfigMaps(namespace)
+	}
+	return &liveCreateConfigMaps{ConfigMapInterface: c.CoreV1Interface.ConfigMaps(namespace)}
+}
+
+func (c *liveCreateCoreV1) Secrets(namespace string) corev1client.SecretInterface {
+	if namespace != c.namespace {
+		return c.CoreV1Interface.Secrets(namespace)
+	}
+	return &liveCreateSecrets{SecretInterface: c.CoreV1Interface.Secrets(namespace)}
+}
+
+type liveCreateConfigMaps struct {
+	corev1client.ConfigMapInterface
+}
+
+func (c *liveCreateConfigMaps) Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	if len(configMap.Name) > 0 {
+		existing, err := c.ConfigMapInterface.Get(ctx, configMap.Name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			divergencesMetric.WithLabelValues("configmaps", "create").Inc()
+			klog.Warningf("Not creating configmap/%s -n %s, it exists with resourceVersion %s but the cache missed it", configMap.Name, configMap.Namespace, existing.ResourceVersion)
+			return existing, apierrors.NewAlreadyExists(corev1.Resource("configmaps"), configMap.Name)
+		case !apierrors.IsNotFound(err):
+			return nil, err
+		}
+	}
+	return c.ConfigMapInterface.Create(ctx, configMap, opts)
+}
+
+type liveCreateSecrets struct {
+	corev1client.SecretInterface
+}
+
+func (c *liveCreateSecrets) Create(ctx context.Context, secret *corev1.Secret, opts metav1.CreateOptions) (*corev1.Secret, error) {
+	if len(secret.Name) > 0 {
+		existing, err := c.SecretInterface.Get(ctx, secret.Name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			divergencesMetric.WithLabelValues("secrets", "create").Inc()
+			klog.Warningf("Not creating secret/%s -n %s, it exists with resourceVersion %s but the cache missed it", secret.Name, secret.Namespace, existing.ResourceVersion)
+			return existing, apierrors.NewAlreadyExists(corev1.Resource("secrets"), secret.Name)
+		case !apierrors.IsNotFound(err):
+			return nil, err
+		}
+	}
+	return c.SecretInterface.Create(ctx, secret, opts)
+}
@@ -2,59 +2,123 @@ package certrotationcontroller
 
 import (
 	"context"
+	"sync"
 	"time"
 
-	"k8s.io/klog/v2"
-
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
+	configv1 "github.com/openshift/api/config/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/library-go/pkg/operator/certrotation"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
 	"github.com/openshift/library-go/pkg/controller/factory"
 )
 
 // defaultRotationDay is the default rotation base for all cert rotation operations.
 const defaultRotationDay = 24 * time.Hour
 
+func init() {
+	// the signers, the service account token signing keys and the bundles publishing them in a standalone cluster
+	relatedobjects.Register(
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.OperatorNamespace, Name: "csr-signer-signer"},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.OperatorNamespace, Name: "csr-signer"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-signer-ca"},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.OperatorNamespace, Name: "next-service-account-private-key"},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "initial-service-account-private-key"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "sa-token-signing-certs"},
+	)
+}
+
 type CertRotationController struct {
-	certRotators []factory.Controller
+	// newCertRotators builds the rotators with the given periods. They are built again every time the rotation
+	// resumes or the periods change, a stopped controller cannot run again.
+	newCertRotators func(periods rotationPeriods) []factory.Controller
+	// defaultPeriods apply without the rotation period overrides, periods are the ones of the running rotators
+	defaultPeriods rotationPeriods
+	periods        rotationPeriods
+
+	operatorClient v1helpers.StaticPodOperatorClient
+	// operatorLister reads PauseAnnotation and the rotation periods, the rotation cannot be paused or its periods set
+	// without it
+	operatorLister cache.GenericLister
+	// clusterVersionLister tells whether the cluster upgrades, the rotation is deferred meanwhile
+	clusterVersionLister configv1listers.ClusterVersionLister
+	secretLister         corev1listers.SecretNamespaceLister
+	// secretsClient writes the secrets of the source cluster for a forced rotation
+	secretsClient corev1client.SecretsGetter
+	// configMapLister reads the csr-controller-signer-ca bundle in the source cluster
+	configMapLister corev1listers.ConfigMapNamespaceLister
+	// safetyMargin is how long before their expiry the certificates are rotated despite a pause
+	safetyMargin time.Duration
+	clock        clock.PassiveClock
+
+	lock    sync.Mutex
+	runCtx  context.Context
+	workers int
+	// stop stops the running rotators, it is nil while they do not run
+	stop context.CancelFunc
+	// pausedUntil is the end of the current pause, zero while the certificates rotate
+	pausedUntil time.Time
+	// deferred is whether the rotators are stopped until an upgrade settles
+	deferred bool
+	// ignored and ignoredDeferralRatio are the errors of the last ignored values of PauseAnnotation and
+	// UpgradeDeferralRatioOverrideField, they are reported once
+	ignored              string
+	ignoredDeferralRatio string
+
+	controller factory.Controller
 }
 
+// NewCertRotationController rotates the csr-signer and its signer. The rotation can be paused with PauseAnnotation and
+// its periods set with the rotation period fields of the unsupportedConfigOverrides of the KubeControllerManager CR read
+// through operatorLister. The rotation is deferred while the cluster upgrades unless a certificate nears its expiry. Every sync also exports the expiry of the certificates in certExpiryMetric and starts a rotation forced with
+// ForceRotationAnnotation.
 func NewCertRotationController(
-	secretsGetter corev1client.SecretsGetter,
-	configMapsGetter corev1client.ConfigMapsGetter,
+	clusters Clusters,
 	operatorClient v1helpers.StaticPodOperatorClient,
-	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	operatorLister cache.GenericLister,
+	clusterVersionInformer configv1informers.ClusterVersionInformer,
 	eventRecorder events.Recorder,
 	day time.Duration,
 ) (*CertRotationController, error) {
-	return newCertRotationController(
-		secretsGetter,
-		configMapsGetter,
+	ret, err := newCertRotationController(
+		clusters,
 		operatorClient,
-		kubeInformersForNamespaces,
 		eventRecorder,
 		day,
 		false,
 	)
+	if err != nil {
+		return nil, err
+	}
+	ret.operatorLister = operatorLister
+	ret.clusterVersionLister = clusterVersionInformer.Lister()
+	ret.controller = factory.New().WithInformers(
+		operatorClient.Informer(),
+		clusterVersionInformer.Informer(),
+		clusters.Source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer(),
+	).ResyncEvery(time.Minute).WithSync(ret.sync).ToController("CertRotationPauseController", eventRecorder.WithComponentSuffix("cert-rotation-pause-controller"))
+	return ret, nil
 }
 
 func NewCertRotationControllerOnlyWhenExpired(
-	secretsGetter corev1client.SecretsGetter,
-	configMapsGetter corev1client.ConfigMapsGetter,
+	clusters Clusters,
 	operatorClient v1helpers.StaticPodOperatorClient,
-	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
 	eventRecorder events.Recorder,
 	day time.Duration,
 ) (*CertRotationController, error) {
 	return newCertRotationController(
-		secretsGetter,
-		configMapsGetter,
+		clusters,
 		operatorClient,
-		kubeInformersForNamespaces,
 		eventRecorder,
 		day,
 		true,
@@ -62,16 +126,12 @@ func NewCertRotationControllerOnlyWhenExpired(
 }
 
 func newCertRotationController(
-	secretsGetter corev1client.SecretsGetter,
-	configMapsGetter corev1client.ConfigMapsGetter,
+	clusters Clusters,
 	operatorClient v1helpers.StaticPodOperatorClient,
-	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
 	eventRecorder events.Recorder,
 	day time.Duration,
 	refreshOnlyWhenExpired bool,
 ) (*CertRotationController, error) {
-	ret := &CertRotationController{}
-
 	rotationDay := defaultRotationDay
 	if day != time.Duration(0) {
 		rotationDay = day
@@ -79,57 +139,81 @@ func newCertRotationController(
 		klog.Warningf("Certificate rotation base set to %q", rotationDay)
 	}
 
-	certRotator := certrotation.NewCertRotationController(
-		"CSRSigningCert",
-		certrotation.RotatedSigningCASecret{
-			Namespace: operatorclient.OperatorNamespace,
-			// this is not a typo, this is the signer of the signer
-			Name:                   "csr-signer-signer",
-			JiraComponent:          "kube-controller-manager",
-			Validity:               60 * rotationDay,
-			Refresh:                30 * rotationDay,
-			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			Informer:               kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
-			Lister:                 kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister(),
-			Client:                 secretsGetter,
-			EventRecorder:          eventRecorder,
-		},
-		certrotation.CABundleConfigMap{
-			Namespace:     operatorclient.OperatorNamespace,
-			Name:          "csr-controller-signer-ca",
-			JiraComponent: "kube-controller-manager",
-			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps(),
-			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
-			Client:        configMapsGetter,
-			EventRecorder: eventRecorder,
-		},
-		certrotation.RotatedSelfSignedCertKeySecret{
-			Namespace:              operatorclient.OperatorNamespace,
-			Name:                   "csr-signer",
-			JiraComponent:          "kube-controller-manager",
-			Validity:               30 * rotationDay,
-			Refresh:                15 * rotationDay,
-			RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
-			CertCreator: &certrotation.SignerRotation{
-				SignerName: "kube-csr-signer",
-			},
-			Informer:      kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
-			Lister:        kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister(),
-			Client:        secretsGetter,
-			EventRecorder: eventRecorder,
-		},
-		eventRecorder,
-		&certrotation.StaticPodConditionStatusReporter{OperatorClient: operatorClient},
-	)
+	source := clusters.Source
+	secretsGetter := v1helpers.CachedSecretGetter(source.KubeClient.CoreV1(), source.KubeInformersForNamespaces)
+	configMapsGetter := v1helpers.CachedConfigMapGetter(source.KubeClient.CoreV1(), source.KubeInformersForNamespaces)
 
-	ret.certRotators = append(ret.certRotators, certRotator)
+	newCertRotator := func(periods rotationPeriods) factory.Controller {
+		return certrotation.NewCertRotationController(
+			"CSRSigningCert",
+			certrotation.RotatedSigningCASecret{
+				Namespace: operatorclient.OperatorNamespace,
+				// this is not a typo, this is the signer of the signer
+				Name:                   "csr-signer-signer",
+				JiraComponent:          "kube-controller-manager",
+				Validity:               periods.caValidity,
+				Refresh:                periods.caRefresh,
+				RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
+				Informer:               source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
+				Lister:                 source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister(),
+				Client:                 secretsGetter,
+				EventRecorder:          source.recorder(eventRecorder),
+			},
+			certrotation.CABundleConfigMap{
+				Namespace:     operatorclient.OperatorNamespace,
+				Name:          "csr-controller-signer-ca",
+				JiraComponent: "kube-controller-manager",
+				Informer:      source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps(),
+				Lister:        source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps().Lister(),
+				Client:        configMapsGetter,
+				EventRecorder: source.recorder(eventRecorder),
+			},
+			certrotation.RotatedSelfSignedCertKeySecret{
+				Namespace:              operatorclient.OperatorNamespace,
+				Name:                   "csr-signer",
+				JiraComponent:          "kube-controller-manager",
+				Validity:               periods.signerValidity,
+				Refresh:                periods.signerRefresh,
+				RefreshOnlyWhenExpired: refreshOnlyWhenExpired,
+				CertCreator: &certrotation.SignerRotation{
+					SignerName: "kube-csr-signer",
+				},
+				Informer:      source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets(),
+				Lister:        source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister(),
+				Client:        secretsGetter,
+				EventRecorder: source.recorder(eventRecorder),
+			},
+			eventRecorder,
+			&certrotation.StaticPodConditionStatusReporter{OperatorClient: operatorClient},
+		)
+	}
 
-	return ret, nil
+	return &CertRotationController{
+		newCertRotators: func(periods rotationPeriods) []factory.Controller {
+			return []factory.Controller{newCertRotator(periods)}
+		},
+		defaultPeriods:  defaultRotationPeriods(rotationDay),
+		operatorClient:  operatorClient,
+		secretLister:    source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.OperatorNamespace),
+		secretsClient:   source.KubeClient.CoreV1(),
+		configMapLister: source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.OperatorNamespace),
+		safetyMargin:    pauseSafetyMarginDays * rotationDay,
+		clock:           clock.RealClock{},
+	}, nil
 }
 
 func (c *CertRotationController) Run(ctx context.Context, workers int) {
 	syncCtx := context.WithValue(ctx, certrotation.RunOnceContextKey, false)
-	for _, certRotator := range c.certRotators {
-		go certRotator.Run(syncCtx, workers)
+	if c.operatorLister == nil {
+		for _, certRotator := range c.newCertRotators(c.defaultPeriods) {
+			go certRotator.Run(syncCtx, workers)
+		}
+		return
 	}
+
+	c.lock.Lock()
+	c.runCtx, c.workers = syncCtx, workers
+	c.lock.Unlock()
+	// the rotators are started by the first sync unless the rotation is paused
+	c.controller.Run(ctx, 1)
 }
@@ -0,0 +1,122 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// Cluster is a cluster the controllers of this package read from or write to.
+type Cluster struct {
+	// Name identifies the cluster in the events of the writes to it. It is empty in a standalone cluster.
+	Name                       string
+	KubeClient                 kubernetes.Interface
+	KubeInformersForNamespaces v1helpers.KubeInformersForNamespaces
+}
+
+// Clusters routes the writes of the controllers. The signing keys and certs are secrets in the Source cluster, where
+// the kube-controller-manager and the kube-apiserver run. The sa-token-signing-certs configmap the service account
+// tokens are verified with is written to the Destination cluster. In a standalone cluster both are the same cluster, in
+// a hosted control plane the source is the management cluster and the destination the guest cluster.
+//
+// The csr-controller-signer-ca configmap stays in the source cluster in either case. The target config controller
+// combines csr-controller-ca from it and csr-signer-ca in the operator namespace of the source cluster, and the
+// kube-apiserver verifying the client certificates signed by the csr-signer runs there too.
+type Clusters struct {
+	Source      Cluster
+	Destination Cluster
+
+	// SATokenSigningCertsNamespace receives the sa-token-signing-certs configmap in the destination cluster.
+	SATokenSigningCertsNamespace string
+}
+
+// StandaloneClusters reads and writes everything with kubeClient, like a standalone cluster always did.
+func StandaloneClusters(kubeClient kubernetes.Interface, kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces) Clusters {
+	cluster := Cluster{KubeClient: kubeClient, KubeInformersForNamespaces: kubeInformersForNamespaces}
+	return Clusters{
+		Source:                       cluster,
+		Destination:                  cluster,
+		SATokenSigningCertsNamespace: operatorclient.GlobalMachineSpecifiedConfigNamespace,
+	}
+}
+
+// SplitClusters keeps the secrets and csr-controller-signer-ca in the management cluster and writes
+// sa-token-signing-certs to the guest cluster of destinationClient. The informers of the guest cluster still have to be started.
+func SplitClusters(kubeClient kubernetes.Interface, kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces, destinationClient kubernetes.Interface) Clusters {
+	clusters := StandaloneClusters(kubeClient, kubeInformersForNamespaces)
+	clusters.Source.Name = "management"
+	clusters.Destination = Cluster{
+		Name:                       "guest",
+		KubeClient:                 destinationClient,
+		KubeInformersForNamespaces: v1helpers.NewKubeInformersForNamespaces(destinationClient, clusters.SATokenSigningCertsNamespace),
+	}
+	return clusters
+}
+
+func (c Clusters) split() bool {
+	return c.Source.Name != c.Destination.Name
+}
+
+// Validate checks that both clients of split clusters reach their cluster and that the namespace written in the
+// destination cluster exists. Otherwise the controllers would only fail on their first write, long after the operator
+// reported it started.
+func (c Clusters) Validate(ctx context.Context) error {
+	if !c.split() {
+		return nil
+	}
+	if _, err := c.Source.KubeClient.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("%s cluster: %w", c.Source.Name, err)
+	}
+	if _, err := c.Destination.KubeClient.CoreV1().Namespaces().Get(ctx, c.SATokenSigningCertsNamespace, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("%s cluster: %w", c.Destination.Name, err)
+	}
+	return nil
+}
+
+// recorder names the cluster in the events, the events of both clusters are recorded in the same place.
+func (c Cluster) recorder(recorder events.Recorder) events.Recorder {
+	if len(c.Name) == 0 {
+		return recorder
+	}
+	return &clusterRecorder{Recorder: recorder, cluster: c.Name}
+}
+
+type clusterRecorder struct {
+	events.Recorder
+	cluster string
+}
+
+func (r *clusterRecorder) Event(reason, message string) {
+	r.Recorder.Event(reason, fmt.Sprintf("%s (%s cluster)", message, r.cluster))
+}
+
+func (r *clusterRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *clusterRecorder) Warning(reason, message string) {
+	r.Recorder.Warning(reason, fmt.Sprintf("%s (%s cluster)", message, r.cluster))
+}
+
+func (r *clusterRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *clusterRecorder) ForComponent(componentName string) events.Recorder {
+	return &clusterRecorder{Recorder: r.Recorder.ForComponent(componentName), cluster: r.cluster}
+}
+
+func (r *clusterRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &clusterRecorder{Recorder: r.Recorder.WithComponentSuffix(componentNameSuffix), cluster: r.cluster}
+}
+
+func (r *clusterRecorder) WithContext(ctx context.Context) events.Recorder {
+	return &clusterRecorder{Recorder: r.Recorder.WithContext(ctx), cluster: r.cluster}
+}
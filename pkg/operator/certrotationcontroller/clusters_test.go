@@ -0,0 +1,151 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func newTestSATokenSignerController(clusters Clusters) *SATokenSignerController {
+	return &SATokenSignerController{
+		secretClient:               clusters.Source.KubeClient.CoreV1(),
+		configMapClient:            clusters.Destination.KubeClient.CoreV1(),
+		endpointClient:             clusters.Source.KubeClient.CoreV1(),
+		podClient:                  clusters.Source.KubeClient.CoreV1(),
+		operatorClient:             v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil),
+		operatorLister:             cache.NewGenericLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}), operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		clusters:                   clusters,
+		clock:                      clock.RealClock{},
+		confirmedBootstrapNodeGone: true,
+	}
+}
+
+func TestSATokenSignerWritesToClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters func(source, destination *fake.Clientset) Clusters
+		// a standalone cluster writes both clientsets' objects to the source clientset
+		configMapInDestination bool
+		secretEventSuffix      string
+		configMapEventSuffix   string
+	}{
+		{
+			name: "standalone",
+			clusters: func(source, _ *fake.Clientset) Clusters {
+				return StandaloneClusters(source, v1helpers.NewKubeInformersForNamespaces(source))
+			},
+		},
+		{
+			name: "split",
+			clusters: func(source, destination *fake.Clientset) Clusters {
+				return SplitClusters(source, v1helpers.NewKubeInformersForNamespaces(source), destination)
+			},
+			configMapInDestination: true,
+			secretEventSuffix:      " (management cluster)",
+			configMapEventSuffix:   " (guest cluster)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, destination := fake.NewSimpleClientset(), fake.NewSimpleClientset()
+			c := newTestSATokenSignerController(test.clusters(source, destination))
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.syncWorker(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := source.CoreV1().Secrets(operatorclient.OperatorNamespace).Get(context.TODO(), "next-service-account-private-key", metav1.GetOptions{}); err != nil {
+				t.Errorf("expected the signing key in the source cluster: %v", err)
+			}
+			if _, err := destination.CoreV1().Secrets(operatorclient.OperatorNamespace).Get(context.TODO(), "next-service-account-private-key", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected no signing key in the destination cluster, got %v", err)
+			}
+			withConfigMap, withoutConfigMap := source, destination
+			if test.configMapInDestination {
+				withConfigMap, withoutConfigMap = destination, source
+			}
+			if _, err := withConfigMap.CoreV1().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(context.TODO(), "sa-token-signing-certs", metav1.GetOptions{}); err != nil {
+				t.Errorf("expected the public keys: %v", err)
+			}
+			if _, err := withoutConfigMap.CoreV1().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(context.TODO(), "sa-token-signing-certs", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected the public keys in a single cluster, got %v", err)
+			}
+
+			expectedSuffixes := map[string]string{"SecretCreated": test.secretEventSuffix, "ConfigMapCreated": test.configMapEventSuffix}
+			for _, event := range recorder.Events() {
+				suffix, ok := expectedSuffixes[event.Reason]
+				if !ok {
+					continue
+				}
+				delete(expectedSuffixes, event.Reason)
+				// the events of a standalone cluster are unchanged
+				if !strings.HasSuffix(event.Message, suffix) || len(suffix) == 0 && strings.HasSuffix(event.Message, " cluster)") {
+					t.Errorf("%s: expected the message to end with %q, got %q", event.Reason, suffix, event.Message)
+				}
+			}
+			if len(expectedSuffixes) != 0 {
+				t.Errorf("missing events %v", expectedSuffixes)
+			}
+		})
+	}
+}
+
+func TestSplitClustersValidate(t *testing.T) {
+	source := fake.NewSimpleClientset()
+	// the operator namespace does not exist in a guest cluster, nothing is written there
+	destination := fake.NewSimpleClientset()
+	clusters := SplitClusters(source, v1helpers.NewKubeInformersForNamespaces(source), destination)
+	if err := clusters.Validate(context.TODO()); err == nil || !strings.HasPrefix(err.Error(), "guest cluster: ") {
+		t.Errorf("expected the missing %s namespace of the guest cluster to be reported, got %v", operatorclient.GlobalMachineSpecifiedConfigNamespace, err)
+	}
+
+	if _, err := destination.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: operatorclient.GlobalMachineSpecifiedConfigNamespace}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusters.Validate(context.TODO()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// a standalone cluster is validated by the operator reaching it at all
+	if err := StandaloneClusters(source, v1helpers.NewKubeInformersForNamespaces(source)).Validate(context.TODO()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCSRSignerCAStaysInTheSourceCluster(t *testing.T) {
+	ctx := context.WithValue(context.TODO(), certrotation.RunOnceContextKey, true)
+	source, destination := fake.NewSimpleClientset(), fake.NewSimpleClientset()
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+	c, err := NewCertRotationControllerOnlyWhenExpired(SplitClusters(source, v1helpers.NewKubeInformersForNamespaces(source, operatorclient.OperatorNamespace), destination), operatorClient, events.NewInMemoryRecorder("test"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rotator := range c.newCertRotators(c.defaultPeriods) {
+		if err := rotator.Sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the target config controller combines csr-controller-ca from it in the source cluster
+	if _, err := source.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(ctx, "csr-controller-signer-ca", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the signer CA bundle in the source cluster: %v", err)
+	}
+	if actions := destination.Actions(); len(actions) != 0 {
+		t.Errorf("expected nothing to be written to the destination cluster, got %v", actions)
+	}
+}
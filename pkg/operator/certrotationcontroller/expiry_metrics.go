@@ -0,0 +1,79 @@
+package certrotationcontroller
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// certExpiryMetric is the expiry of the signers as found in their secrets and of the soonest expiring certificate of
+// the bundle clients verify them with. A rotation that is stuck shows as an expiry that comes closer.
+var certExpiryMetric = metrics.NewGaugeVec(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "signer_cert_expiry_timestamp_seconds",
+	Help:           "Unix time the csr-signer, its signer csr-signer-signer or the soonest expiring certificate of the csr-controller-signer-ca bundle expires at, by name",
+	StabilityLevel: metrics.ALPHA,
+}, []string{"name"})
+
+func init() {
+	legacyregistry.MustRegister(certExpiryMetric)
+}
+
+// recordCertExpiry sets certExpiryMetric from the certificates the secrets and the bundle contain, not from their
+// annotations. A certificate that is missing or cannot be parsed removes its series, an expiry that is not known must
+// not look like a valid one.
+func (c *CertRotationController) recordCertExpiry() {
+	for _, name := range pausedSecrets {
+		var certPEM []byte
+		secret, err := c.secretLister.Get(name)
+		if err == nil {
+			certPEM = secret.Data["tls.crt"]
+		}
+		c.setCertExpiry(name, certPEM, err)
+	}
+	var bundlePEM []byte
+	configMap, err := c.configMapLister.Get("csr-controller-signer-ca")
+	if err == nil {
+		bundlePEM = []byte(configMap.Data["ca-bundle.crt"])
+	}
+	c.setCertExpiry("csr-controller-signer-ca", bundlePEM, err)
+}
+
+func (c *CertRotationController) setCertExpiry(name string, certPEM []byte, err error) {
+	var expiry time.Time
+	if err == nil {
+		expiry, err = soonestExpiry(certPEM)
+	}
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Warningf("Unknown expiry of %s: %v", name, err)
+		}
+		certExpiryMetric.DeleteLabelValues(name)
+		return
+	}
+	certExpiryMetric.WithLabelValues(name).Set(float64(expiry.Unix()))
+}
+
+// soonestExpiry returns the earliest NotAfter of the PEM encoded certificates.
+func soonestExpiry(certPEM []byte) (time.Time, error) {
+	if len(certPEM) == 0 {
+		return time.Time{}, fmt.Errorf("no certificate")
+	}
+	certificates, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return time.Time{}, err
+	}
+	soonest := certificates[0].NotAfter
+	for _, certificate := range certificates[1:] {
+		if certificate.NotAfter.Before(soonest) {
+			soonest = certificate.NotAfter
+		}
+	}
+	return soonest, nil
+}
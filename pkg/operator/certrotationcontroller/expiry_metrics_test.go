@@ -0,0 +1,115 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// certificate returns a PEM encoded self-signed certificate and its NotAfter.
+func certificate(t *testing.T, name string, lifetime time.Duration) ([]byte, time.Time) {
+	t.Helper()
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, lifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, _, err := ca.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, ca.Certs[0].NotAfter
+}
+
+func TestRecordCertExpiry(t *testing.T) {
+	caPEM, caNotAfter := certificate(t, "csr-signer-signer", 60*24*time.Hour)
+	signerPEM, signerNotAfter := certificate(t, "kube-csr-signer", 30*24*time.Hour)
+	previousCAPEM, previousCANotAfter := certificate(t, "previous-csr-signer-signer", 10*24*time.Hour)
+
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for name, certPEM := range map[string][]byte{"csr-signer-signer": caPEM, "csr-signer": signerPEM} {
+		if err := secrets.Add(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: name},
+			Data:       map[string][]byte{"tls.crt": certPEM},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-signer-ca"},
+		Data:       map[string]string{"ca-bundle.crt": string(bytes.Join([][]byte{caPEM, previousCAPEM}, nil))},
+	}
+	if err := configMaps.Add(bundle); err != nil {
+		t.Fatal(err)
+	}
+	controller := &CertRotationController{
+		secretLister:    corev1listers.NewSecretLister(secrets).Secrets(operatorclient.OperatorNamespace),
+		configMapLister: corev1listers.NewConfigMapLister(configMaps).ConfigMaps(operatorclient.OperatorNamespace),
+	}
+	expectExpiry := func(name string, expected time.Time) {
+		t.Helper()
+		value, err := testutil.GetGaugeMetricValue(certExpiryMetric.WithLabelValues(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != float64(expected.Unix()) {
+			t.Errorf("expected %s to expire at %d, got %v", name, expected.Unix(), value)
+		}
+	}
+
+	controller.recordCertExpiry()
+	expectExpiry("csr-signer-signer", caNotAfter)
+	expectExpiry("csr-signer", signerNotAfter)
+	// the bundle reports its soonest expiring certificate
+	expectExpiry("csr-controller-signer-ca", previousCANotAfter)
+
+	// a secret that cannot be parsed or is gone has no expiry
+	if err := secrets.Update(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "csr-signer"},
+		Data:       map[string][]byte{"tls.crt": []byte("garbage")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configMaps.Delete(bundle); err != nil {
+		t.Fatal(err)
+	}
+	controller.recordCertExpiry()
+	expectExpiry("csr-signer-signer", caNotAfter)
+	if names := expirySeries(t); len(names) != 1 || names[0] != "csr-signer-signer" {
+		t.Errorf("expected only the expiry of csr-signer-signer, got %v", names)
+	}
+}
+
+// expirySeries returns the names certExpiryMetric has a series for.
+func expirySeries(t *testing.T) []string {
+	t.Helper()
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, family := range families {
+		if family.GetName() != "openshift_kube_controller_manager_operator_signer_cert_expiry_timestamp_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" {
+					names = append(names, label.GetValue())
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,99 @@
+package certrotationcontroller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// ForceRotationAnnotation on the csr-signer secret in the operator namespace rotates the csr-signer-signer CA and
+	// the csr-signer right away every time its value changes, e.g. after the signing keys were exposed. The old CA
+	// stays in the csr-controller-signer-ca bundle until it expires, the certificates the kubelets hold remain valid.
+	ForceRotationAnnotation = "certificates.openshift.io/force-rotation"
+
+	// forceRotationHandledAnnotation records on either secret the value of ForceRotationAnnotation it was rotated for.
+	forceRotationHandledAnnotation = "certificates.openshift.io/force-rotation-handled"
+)
+
+// forceRotation rotates the csr-signer-signer CA and then the csr-signer for a new value of ForceRotationAnnotation.
+// Both are rotated by the rotators, the expiry annotations of a secret are removed to make them issue a new
+// certificate. The csr-signer is only reissued once the new CA exists, it would be signed by the old one otherwise.
+// A certificate without an expiry also overrides a pause of the rotation.
+func (c *CertRotationController) forceRotation(ctx context.Context, recorder events.Recorder) error {
+	signer, err := c.secretLister.Get("csr-signer")
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	value, ok := signer.Annotations[ForceRotationAnnotation]
+	if !ok || signer.Annotations[forceRotationHandledAnnotation] == value {
+		return nil
+	}
+	// the steps depend on each other's writes, which the informer may not have seen yet
+	signer, err = c.secretsClient.Secrets(operatorclient.OperatorNamespace).Get(ctx, "csr-signer", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if value, ok = signer.Annotations[ForceRotationAnnotation]; !ok || signer.Annotations[forceRotationHandledAnnotation] == value {
+		return nil
+	}
+	ca, err := c.secretsClient.Secrets(operatorclient.OperatorNamespace).Get(ctx, "csr-signer-signer", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if ca.Annotations[forceRotationHandledAnnotation] != value {
+		if err := c.markRotated(ctx, ca, value, true); err != nil {
+			return err
+		}
+		recorder.Eventf("CSRSignerForcedRotation", "Rotating secrets csr-signer-signer and csr-signer -n %s for %s=%q", operatorclient.OperatorNamespace, ForceRotationAnnotation, value)
+		return nil
+	}
+	if _, ok := ca.Annotations[certrotation.CertificateNotAfterAnnotation]; !ok {
+		// the rotator did not issue the new CA yet
+		return nil
+	}
+	return c.markRotated(ctx, signer, value, !signedBy(signer, ca))
+}
+
+// signedBy returns whether the certificate of secret is signed by the one of ca.
+func signedBy(secret, ca *corev1.Secret) bool {
+	certificates, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return false
+	}
+	caCertificates, err := cert.ParseCertsPEM(ca.Data["tls.crt"])
+	if err != nil {
+		return false
+	}
+	return certificates[0].CheckSignatureFrom(caCertificates[0]) == nil
+}
+
+// markRotated marks a secret read from the API server rotated for value. With expire it also removes its expiry
+// annotations, which makes its rotator issue a new certificate.
+func (c *CertRotationController) markRotated(ctx context.Context, secret *corev1.Secret, value string, expire bool) error {
+	if expire {
+		delete(secret.Annotations, certrotation.CertificateNotBeforeAnnotation)
+		delete(secret.Annotations, certrotation.CertificateNotAfterAnnotation)
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[forceRotationHandledAnnotation] = value
+	_, err := c.secretsClient.Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
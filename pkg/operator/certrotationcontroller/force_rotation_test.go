@@ -0,0 +1,146 @@
+package certrotationcontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/cert"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestForceRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset()
+	informers := v1helpers.NewKubeInformersForNamespaces(client, operatorclient.OperatorNamespace)
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+	recorder := events.NewInMemoryRecorder("test")
+	controller, err := newCertRotationController(StandaloneClusters(client, informers), operatorClient, recorder, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotators := controller.newCertRotators(controller.defaultPeriods)
+	informers.Start(ctx.Done())
+	// caughtUp returns whether the informers saw the last writes, the rotators would rotate twice otherwise. The fake
+	// client sets no resource versions.
+	caughtUp := func() bool {
+		secrets, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false
+		}
+		for _, secret := range secrets.Items {
+			if cached, err := controller.secretLister.Get(secret.Name); err != nil || !equality.Semantic.DeepEqual(cached.Annotations, secret.Annotations) || !equality.Semantic.DeepEqual(cached.Data, secret.Data) {
+				return false
+			}
+		}
+		configMaps, err := client.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false
+		}
+		for _, configMap := range configMaps.Items {
+			if cached, err := controller.configMapLister.Get(configMap.Name); err != nil || !equality.Semantic.DeepEqual(cached.Data, configMap.Data) {
+				return false
+			}
+		}
+		return true
+	}
+	get := func(name string) *corev1.Secret {
+		t.Helper()
+		secret, err := controller.secretLister.Get(name)
+		if err != nil {
+			return nil
+		}
+		return secret
+	}
+	bundle := func() []byte {
+		configMap, err := controller.configMapLister.Get("csr-controller-signer-ca")
+		if err != nil {
+			return nil
+		}
+		return []byte(configMap.Data["ca-bundle.crt"])
+	}
+
+	// sync runs the forced rotation and the rotators until done returns true, the informers may lag behind the writes
+	sync := func(done func() bool) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+			if !caughtUp() {
+				return false, nil
+			}
+			if err := controller.forceRotation(ctx, recorder); err != nil {
+				return false, nil
+			}
+			for _, rotator := range rotators {
+				if err := rotator.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+					return false, nil
+				}
+			}
+			return done(), nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sync(func() bool { return get("csr-signer-signer") != nil && get("csr-signer") != nil && len(bundle()) > 0 })
+	oldCA, oldSigner := get("csr-signer-signer"), get("csr-signer")
+
+	forced := oldSigner.DeepCopy()
+	forced.Annotations[ForceRotationAnnotation] = "incident-1"
+	if _, err := client.CoreV1().Secrets(operatorclient.OperatorNamespace).Update(ctx, forced, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	sync(func() bool {
+		ca, signer := get("csr-signer-signer"), get("csr-signer")
+		return signer.Annotations[forceRotationHandledAnnotation] == "incident-1" &&
+			len(signer.Annotations[certrotation.CertificateNotAfterAnnotation]) > 0 && signedBy(signer, ca) &&
+			bytes.Contains(bundle(), bytes.TrimSpace(ca.Data["tls.crt"]))
+	})
+	newCA, newSigner := get("csr-signer-signer"), get("csr-signer")
+
+	if bytes.Equal(oldCA.Data["tls.key"], newCA.Data["tls.key"]) {
+		t.Error("expected a new key of the csr-signer-signer CA")
+	}
+	if bytes.Equal(oldSigner.Data["tls.key"], newSigner.Data["tls.key"]) {
+		t.Error("expected a new key of the csr-signer")
+	}
+	if !signedBy(newSigner, newCA) {
+		t.Error("expected the csr-signer to be signed by the new CA")
+	}
+	// the bundle keeps the old CA, the certificates it issued remain trusted
+	certificates, err := cert.ParseCertsPEM(bundle())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certificates) != 2 || !bytes.Contains(bundle(), bytes.TrimSpace(oldCA.Data["tls.crt"])) || !bytes.Contains(bundle(), bytes.TrimSpace(newCA.Data["tls.crt"])) {
+		t.Errorf("expected the bundle to contain the old and the new CA, got %d certificates", len(certificates))
+	}
+
+	// the same value does not rotate again
+	var syncs int
+	sync(func() bool { syncs++; return syncs == 5 })
+	if !bytes.Equal(get("csr-signer-signer").Data["tls.key"], newCA.Data["tls.key"]) || !bytes.Equal(get("csr-signer").Data["tls.key"], newSigner.Data["tls.key"]) {
+		t.Error("expected no further rotation for the same value")
+	}
+	var forcedEvents int
+	for _, event := range recorder.Events() {
+		if event.Reason == "CSRSignerForcedRotation" {
+			forcedEvents++
+		}
+	}
+	if forcedEvents != 1 {
+		t.Errorf("expected one CSRSignerForcedRotation event, got %d", forcedEvents)
+	}
+}
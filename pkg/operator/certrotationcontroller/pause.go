@@ -0,0 +1,238 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// PauseAnnotation on the KubeControllerManager CR pauses the rotation of the csr-signer and its signer until the
+	// RFC3339 timestamp it is set to, e.g. during a maintenance window that must not see a new signer rolled out.
+	PauseAnnotation = "operator.openshift.io/pause-cert-rotation-until"
+
+	pauseConditionType = "CertRotationPauseUpgradeable"
+
+	// pauseSafetyMarginDays is how many rotation days before their expiry the certificates rotate despite a pause.
+	pauseSafetyMarginDays = 7
+)
+
+// pausedSecrets are the certificates the rotation of which is paused.
+var pausedSecrets = []string{"csr-signer-signer", "csr-signer"}
+
+// sync pauses or resumes the rotators. A pause makes the operator not upgradeable, the next release would find the
+// rotation paused by an annotation it may not know. The rotators are stopped while paused, a certificate expiring
+// within the safety margin resumes them before the end of the pause. They are also stopped while the cluster upgrades,
+// see upgradeInProgress, unless a certificate is urgent to rotate.
+func (c *CertRotationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	c.recordCertExpiry()
+	if err := c.forceRotation(ctx, syncCtx.Recorder()); err != nil {
+		return err
+	}
+
+	until, err := c.readPause(syncCtx.Recorder())
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   pauseConditionType,
+		Status: operatorv1.ConditionTrue,
+		Reason: "AsExpected",
+	}
+	pause := false
+	if !until.IsZero() {
+		expiring, err := c.expiringCertificate()
+		if err != nil {
+			return err
+		}
+		if len(expiring) == 0 {
+			pause = true
+			condition.Status = operatorv1.ConditionFalse
+			condition.Reason = "CertRotationPaused"
+			condition.Message = fmt.Sprintf("The rotation of secrets %s -n %s is paused by %s until %s. Remove the annotation to resume it before upgrading.", strings.Join(pausedSecrets, ", "), operatorclient.OperatorNamespace, PauseAnnotation, until.Format(time.RFC3339))
+		} else {
+			condition.Reason = "CertRotationPauseOverridden"
+			condition.Message = fmt.Sprintf("The certificates rotate despite %s until %s: %s", PauseAnnotation, until.Format(time.RFC3339), expiring)
+		}
+	}
+
+	var upgrade string
+	resumeReason := condition.Message
+	if !pause {
+		if upgrade, err = c.upgradeInProgress(); err != nil {
+			return err
+		}
+	}
+	if len(upgrade) > 0 {
+		ratio, err := c.readUpgradeDeferralRatio(syncCtx.Recorder())
+		if err != nil {
+			return err
+		}
+		urgent, err := c.urgentCertificate(ratio)
+		if err != nil {
+			return err
+		}
+		if len(urgent) > 0 {
+			upgrade, resumeReason = "", urgent
+		}
+	}
+
+	periods, periodsErr := c.readRotationPeriods()
+
+	c.lock.Lock()
+	if periodsErr == nil {
+		c.setPeriods(syncCtx.Recorder(), periods)
+	}
+	switch {
+	case pause:
+		c.pauseRotators(syncCtx.Recorder(), until)
+	case len(upgrade) > 0:
+		c.deferRotators(syncCtx.Recorder(), upgrade)
+	default:
+		c.resumeRotators(syncCtx.Recorder(), resumeReason)
+	}
+	c.lock.Unlock()
+	if pause {
+		// resume at the end of the pause without waiting for the resync
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), until.Sub(c.clock.Now()))
+	}
+
+	_, _, err = v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition), v1helpers.UpdateStaticPodConditionFn(rotationPeriodsCondition(periodsErr)))
+	return err
+}
+
+// readPause returns the end of the pause, zero without a pause. A malformed or expired value is ignored, which is
+// reported once per value with a warning event.
+func (c *CertRotationController) readPause(recorder events.Recorder) (time.Time, error) {
+	obj, err := c.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	operator, err := meta.Accessor(obj)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value, ok := operator.GetAnnotations()[PauseAnnotation]
+	if !ok {
+		c.ignored = ""
+		return time.Time{}, nil
+	}
+	until, err := parsePause(value, c.clock.Now())
+	if err != nil {
+		if c.ignored != err.Error() {
+			c.ignored = err.Error()
+			klog.Warningf("Ignoring %v", err)
+			recorder.Warningf("CertRotationPauseIgnored", "Ignoring %v", err)
+		}
+		return time.Time{}, nil
+	}
+	c.ignored = ""
+	return until, nil
+}
+
+// parsePause parses the end of a pause, which must be in the future.
+func parsePause(value string, now time.Time) (time.Time, error) {
+	path := validation.AnnotationPath(PauseAnnotation)
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &validation.Error{Path: path, Value: value, Accepted: "an RFC3339 timestamp"}
+	}
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("%s: %q is in the past", path, value)
+	}
+	return until, nil
+}
+
+// expiringCertificate describes the first paused certificate that must not wait for the end of a pause, empty when
+// none. A certificate that does not exist or the expiry of which is unknown must not wait either.
+func (c *CertRotationController) expiringCertificate() (string, error) {
+	for _, name := range pausedSecrets {
+		secret, err := c.secretLister.Get(name)
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("secret %s -n %s does not exist", name, operatorclient.OperatorNamespace), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		notAfter, err := time.Parse(time.RFC3339, secret.Annotations[certrotation.CertificateNotAfterAnnotation])
+		if err != nil {
+			return fmt.Sprintf("the expiry of secret %s -n %s is unknown", name, operatorclient.OperatorNamespace), nil
+		}
+		if remaining := notAfter.Sub(c.clock.Now()); remaining < c.safetyMargin {
+			return fmt.Sprintf("secret %s -n %s expires at %s, within the safety margin of %s", name, operatorclient.OperatorNamespace, notAfter.Format(time.RFC3339), c.safetyMargin), nil
+		}
+	}
+	return "", nil
+}
+
+// pauseRotators stops the rotators, c.lock is held.
+func (c *CertRotationController) pauseRotators(recorder events.Recorder, until time.Time) {
+	if c.pausedUntil.Equal(until) {
+		return
+	}
+	c.pausedUntil, c.deferred = until, false
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+	recorder.Eventf("CertRotationPaused", "The rotation of secrets %s -n %s is paused until %s", strings.Join(pausedSecrets, ", "), operatorclient.OperatorNamespace, until.Format(time.RFC3339))
+}
+
+// resumeRotators starts new rotators unless they run, c.lock is held.
+func (c *CertRotationController) resumeRotators(recorder events.Recorder, reason string) {
+	if c.stop != nil {
+		return
+	}
+	if !c.pausedUntil.IsZero() || c.deferred {
+		switch {
+		case len(reason) > 0:
+		case c.deferred:
+			reason = "the upgrade settled"
+		default:
+			reason = "the pause ended"
+		}
+		recorder.Eventf("CertRotationResumed", "The rotation of secrets %s -n %s resumed: %s", strings.Join(pausedSecrets, ", "), operatorclient.OperatorNamespace, reason)
+	}
+	c.pausedUntil, c.deferred = time.Time{}, false
+	if c.periods == (rotationPeriods{}) {
+		c.periods = c.defaultPeriods
+	}
+	ctx, cancel := context.WithCancel(c.runCtx)
+	c.stop = cancel
+	for _, certRotator := range c.newCertRotators(c.periods) {
+		go certRotator.Run(ctx, c.workers)
+	}
+}
+
+// setPeriods stops the running rotators when the periods change, they are resumed with the new ones. c.lock is held.
+func (c *CertRotationController) setPeriods(recorder events.Recorder, periods rotationPeriods) {
+	if c.periods == periods {
+		return
+	}
+	if c.periods != (rotationPeriods{}) {
+		recorder.Eventf("CertRotationPeriodsChanged", "The csr-signer-signer CA is valid for %s and refreshed after %s, the csr-signer is valid for %s and refreshed after %s", periods.caValidity, periods.caRefresh, periods.signerValidity, periods.signerRefresh)
+	}
+	c.periods = periods
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+}
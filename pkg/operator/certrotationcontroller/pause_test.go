@@ -0,0 +1,274 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var pauseStart = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+// fakeRotator sends the context it runs with to runs and runs until it is cancelled.
+type fakeRotator struct {
+	runs chan context.Context
+}
+
+func (r fakeRotator) Run(ctx context.Context, workers int) {
+	r.runs <- ctx
+	<-ctx.Done()
+}
+
+func (r fakeRotator) Sync(ctx context.Context, syncCtx factory.SyncContext) error { return nil }
+
+func (r fakeRotator) Name() string { return "fake" }
+
+type pauseTest struct {
+	t               *testing.T
+	controller      *CertRotationController
+	operatorClient  v1helpers.StaticPodOperatorClient
+	operators       cache.Indexer
+	secrets         cache.Indexer
+	clusterVersions cache.Indexer
+	clock           *clocktesting.FakeClock
+	recorder        events.InMemoryRecorder
+	runs            chan context.Context
+}
+
+// newPauseTest returns a controller the certificates of which expire after expiry.
+func newPauseTest(t *testing.T, ctx context.Context, expiry time.Duration) *pauseTest {
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, name := range pausedSecrets {
+		if err := secrets.Add(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   operatorclient.OperatorNamespace,
+			Name:        name,
+			Annotations: map[string]string{certrotation.CertificateNotAfterAnnotation: pauseStart.Add(expiry).Format(time.RFC3339)},
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operators := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	clusterVersions := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	test := &pauseTest{
+		t: t,
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		operators:       operators,
+		secrets:         secrets,
+		clusterVersions: clusterVersions,
+		clock:           clocktesting.NewFakeClock(pauseStart),
+		recorder:        events.NewInMemoryRecorder("test"),
+		runs:            make(chan context.Context, 10),
+	}
+	test.setPause("")
+	test.controller = &CertRotationController{
+		newCertRotators:      func(rotationPeriods) []factory.Controller { return []factory.Controller{fakeRotator{runs: test.runs}} },
+		defaultPeriods:       defaultRotationPeriods(defaultRotationDay),
+		operatorClient:       test.operatorClient,
+		operatorLister:       cache.NewGenericLister(operators, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		clusterVersionLister: configv1listers.NewClusterVersionLister(clusterVersions),
+		secretLister:         corev1listers.NewSecretLister(secrets).Secrets(operatorclient.OperatorNamespace),
+		configMapLister:      corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})).ConfigMaps(operatorclient.OperatorNamespace),
+		safetyMargin:         pauseSafetyMarginDays * defaultRotationDay,
+		clock:                test.clock,
+		runCtx:               ctx,
+		workers:              1,
+	}
+	return test
+}
+
+// setPause sets PauseAnnotation to value, an empty value removes it.
+func (p *pauseTest) setPause(value string) {
+	operator := &unstructured.Unstructured{}
+	operator.SetName("cluster")
+	if len(value) > 0 {
+		operator.SetAnnotations(map[string]string{PauseAnnotation: value})
+	}
+	if err := p.operators.Update(operator); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *pauseTest) sync() {
+	p.t.Helper()
+	if err := p.controller.sync(context.TODO(), factory.NewSyncContext("CertRotationPauseController", p.recorder)); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// started returns the context of the next rotator started.
+func (p *pauseTest) started() context.Context {
+	p.t.Helper()
+	select {
+	case ctx := <-p.runs:
+		return ctx
+	case <-time.After(5 * time.Second):
+		p.t.Fatal("expected the rotator to be started")
+		return nil
+	}
+}
+
+func (p *pauseTest) expectNotStarted() {
+	p.t.Helper()
+	select {
+	case <-p.runs:
+		p.t.Fatal("expected no rotator to be started")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (p *pauseTest) expectCondition(status operatorv1.ConditionStatus, reason, message string) {
+	p.t.Helper()
+	_, operatorStatus, _, err := p.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, pauseConditionType)
+	if condition == nil || condition.Status != status || condition.Reason != reason || !strings.Contains(condition.Message, message) {
+		p.t.Errorf("expected %s to be %s with reason %s and a message containing %q, got %#v", pauseConditionType, status, reason, message, condition)
+	}
+}
+
+func (p *pauseTest) expectEvents(reasons ...string) {
+	p.t.Helper()
+	var got []string
+	for _, event := range p.recorder.Events() {
+		got = append(got, event.Reason)
+	}
+	if strings.Join(got, ",") != strings.Join(reasons, ",") {
+		p.t.Errorf("expected events %v, got %v", reasons, p.recorder.Events())
+	}
+}
+
+func TestPauseStopsTheRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 20*24*time.Hour)
+
+	p.sync()
+	running := p.started()
+	p.expectCondition(operatorv1.ConditionTrue, "AsExpected", "")
+
+	p.setPause(pauseStart.Add(3 * 24 * time.Hour).Format(time.RFC3339))
+	p.sync()
+	if running.Err() == nil {
+		t.Error("expected the rotator to be stopped")
+	}
+	p.expectCondition(operatorv1.ConditionFalse, "CertRotationPaused", "The rotation of secrets csr-signer-signer, csr-signer -n openshift-kube-controller-manager-operator is paused by operator.openshift.io/pause-cert-rotation-until until 2024-05-04T08:00:00Z")
+	// a resync does not restart it
+	p.sync()
+	p.expectNotStarted()
+
+	p.setPause("")
+	p.sync()
+	p.started()
+	p.expectCondition(operatorv1.ConditionTrue, "AsExpected", "")
+	p.expectEvents("CertRotationPaused", "CertRotationResumed")
+}
+
+func TestPauseEndsBeforeACertificateExpires(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 9*24*time.Hour)
+	p.setPause(pauseStart.Add(10 * 24 * time.Hour).Format(time.RFC3339))
+
+	p.sync()
+	p.expectNotStarted()
+	p.expectCondition(operatorv1.ConditionFalse, "CertRotationPaused", "")
+
+	// the certificates expire in less than the safety margin of 7 days
+	p.clock.Step(3 * 24 * time.Hour)
+	p.sync()
+	p.started()
+	p.expectCondition(operatorv1.ConditionTrue, "CertRotationPauseOverridden", "secret csr-signer-signer -n openshift-kube-controller-manager-operator expires at 2024-05-10T08:00:00Z, within the safety margin of 168h0m0s")
+	p.expectEvents("CertRotationPaused", "CertRotationResumed")
+}
+
+func TestPauseEndsWithItsWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 20*24*time.Hour)
+	p.setPause(pauseStart.Add(time.Hour).Format(time.RFC3339))
+
+	p.sync()
+	p.expectNotStarted()
+
+	p.clock.Step(time.Hour)
+	p.sync()
+	p.started()
+	p.expectCondition(operatorv1.ConditionTrue, "AsExpected", "")
+	p.expectEvents("CertRotationPaused", "CertRotationPauseIgnored", "CertRotationResumed")
+	if message := p.recorder.Events()[1].Message; !strings.Contains(message, "is in the past") {
+		t.Errorf("expected the expired pause to be reported, got %q", message)
+	}
+}
+
+func TestMalformedPauseIsIgnored(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 20*24*time.Hour)
+	p.setPause("tomorrow")
+
+	p.sync()
+	p.started()
+	p.sync()
+	p.expectCondition(operatorv1.ConditionTrue, "AsExpected", "")
+	// once per value
+	p.expectEvents("CertRotationPauseIgnored")
+	if message := p.recorder.Events()[0].Message; message != `Ignoring metadata.annotations[operator.openshift.io/pause-cert-rotation-until]: "tomorrow" must be an RFC3339 timestamp` {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	// another malformed value is reported again
+	p.setPause("2024-05-04")
+	p.sync()
+	p.expectNotStarted()
+	p.expectEvents("CertRotationPauseIgnored", "CertRotationPauseIgnored")
+}
+
+func TestParsePause(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		value         string
+		expected      time.Time
+		expectedError string
+	}{
+		{value: "2024-05-04T08:00:00Z", expected: time.Date(2024, 5, 4, 8, 0, 0, 0, time.UTC)},
+		{value: "2024-05-04T10:00:00+02:00", expected: time.Date(2024, 5, 4, 8, 0, 0, 0, time.UTC)},
+		{value: "tomorrow", expectedError: `metadata.annotations[operator.openshift.io/pause-cert-rotation-until]: "tomorrow" must be an RFC3339 timestamp`},
+		{value: "", expectedError: `metadata.annotations[operator.openshift.io/pause-cert-rotation-until]: "" must be an RFC3339 timestamp`},
+		{value: "2024-05-01T08:00:00Z", expectedError: `metadata.annotations[operator.openshift.io/pause-cert-rotation-until]: "2024-05-01T08:00:00Z" is in the past`},
+		{value: "2024-04-01T08:00:00Z", expectedError: `metadata.annotations[operator.openshift.io/pause-cert-rotation-until]: "2024-04-01T08:00:00Z" is in the past`},
+	} {
+		until, err := parsePause(test.value, now)
+		if len(test.expectedError) > 0 {
+			if err == nil || err.Error() != test.expectedError {
+				t.Errorf("%q: expected %q, got %v", test.value, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil || !until.Equal(test.expected) {
+			t.Errorf("%q: expected %s, got %s, %v", test.value, test.expected, until, err)
+		}
+	}
+}
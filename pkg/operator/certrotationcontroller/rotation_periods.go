@@ -0,0 +1,129 @@
+package certrotationcontroller
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// CSRSignerCAValidityOverrideField, CSRSignerCARefreshOverrideField, CSRSignerValidityOverrideField and
+	// CSRSignerRefreshOverrideField of the unsupportedConfigOverrides set how long the csr-signer-signer CA and the
+	// csr-signer are valid and after how long they are rotated, e.g. 2160h. They apply to the next rotation, a
+	// certificate is only rotated right away when it is already older than its new refresh period. Without them the
+	// CA is valid for 60 days and rotated after 30, the csr-signer is valid for 30 days and rotated after 15.
+	CSRSignerCAValidityOverrideField = "csrSignerCAValidity"
+	CSRSignerCARefreshOverrideField  = "csrSignerCARefresh"
+	CSRSignerValidityOverrideField   = "csrSignerValidity"
+	CSRSignerRefreshOverrideField    = "csrSignerRefresh"
+
+	rotationPeriodsConditionType = "CertRotationPeriodsDegraded"
+
+	// minRotationPeriod keeps the rotation from issuing a certificate every resync.
+	minRotationPeriod = time.Hour
+)
+
+func init() {
+	overrides.Register(CSRSignerCAValidityOverrideField, CSRSignerCARefreshOverrideField, CSRSignerValidityOverrideField, CSRSignerRefreshOverrideField)
+}
+
+// rotationPeriods are the validity and refresh periods of the csr-signer-signer CA and the csr-signer.
+type rotationPeriods struct {
+	caValidity, caRefresh         time.Duration
+	signerValidity, signerRefresh time.Duration
+}
+
+func defaultRotationPeriods(rotationDay time.Duration) rotationPeriods {
+	return rotationPeriods{
+		caValidity:     60 * rotationDay,
+		caRefresh:      30 * rotationDay,
+		signerValidity: 30 * rotationDay,
+		signerRefresh:  15 * rotationDay,
+	}
+}
+
+// parseRotationPeriods overrides the defaults with the override fields that are set. A refresh period must be shorter than
+// its validity, the rotation would issue a certificate every resync otherwise. The csr-signer must not outlive the CA
+// it is signed by.
+func parseRotationPeriods(unsupportedConfigOverrides []byte, defaults rotationPeriods) (rotationPeriods, error) {
+	periods := defaults
+	var errs []error
+	for field, period := range map[string]*time.Duration{
+		CSRSignerCAValidityOverrideField: &periods.caValidity,
+		CSRSignerCARefreshOverrideField:  &periods.caRefresh,
+		CSRSignerValidityOverrideField:   &periods.signerValidity,
+		CSRSignerRefreshOverrideField:    &periods.signerRefresh,
+	} {
+		value, ok, err := overrides.String(unsupportedConfigOverrides, field)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		duration, err := validation.DurationBetween(overrides.Path(field), value, minRotationPeriod, 0)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		*period = duration
+	}
+	if len(errs) > 0 {
+		return defaults, utilerrors.NewAggregate(validation.Sorted(errs))
+	}
+
+	if periods.caRefresh >= periods.caValidity {
+		errs = append(errs, fmt.Errorf("the csr-signer-signer CA refresh period %s must be shorter than its validity %s", periods.caRefresh, periods.caValidity))
+	}
+	if periods.signerRefresh >= periods.signerValidity {
+		errs = append(errs, fmt.Errorf("the csr-signer refresh period %s must be shorter than its validity %s", periods.signerRefresh, periods.signerValidity))
+	}
+	if periods.signerValidity > periods.caValidity {
+		errs = append(errs, fmt.Errorf("the csr-signer validity %s must not be longer than the csr-signer-signer CA validity %s", periods.signerValidity, periods.caValidity))
+	}
+	if len(errs) > 0 {
+		return defaults, utilerrors.NewAggregate(errs)
+	}
+	return periods, nil
+}
+
+// readRotationPeriods returns the rotation periods set on the KubeControllerManager CR.
+func (c *CertRotationController) readRotationPeriods() (rotationPeriods, error) {
+	obj, err := c.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return c.defaultPeriods, nil
+	}
+	if err != nil {
+		return c.defaultPeriods, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(obj)
+	if err != nil {
+		return c.defaultPeriods, err
+	}
+	return parseRotationPeriods(unsupportedConfigOverrides, c.defaultPeriods)
+}
+
+// rotationPeriodsCondition reports invalid rotation periods, the rotation keeps the periods it runs with meanwhile.
+func rotationPeriodsCondition(err error) operatorv1.OperatorCondition {
+	if err == nil {
+		return operatorv1.OperatorCondition{
+			Type:   rotationPeriodsConditionType,
+			Status: operatorv1.ConditionFalse,
+			Reason: "AsExpected",
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    rotationPeriodsConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "InvalidRotationPeriods",
+		Message: fmt.Sprintf("The csr-signer keeps rotating with its previous periods: %v", err),
+	}
+}
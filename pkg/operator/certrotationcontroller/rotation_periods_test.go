@@ -0,0 +1,251 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestParseRotationPeriods(t *testing.T) {
+	defaults := defaultRotationPeriods(defaultRotationDay)
+	tests := []struct {
+		name          string
+		overrides     map[string]interface{}
+		expected      rotationPeriods
+		expectedError string
+	}{
+		{
+			name:     "defaults",
+			expected: defaults,
+		},
+		{
+			name: "longer",
+			overrides: map[string]interface{}{
+				CSRSignerCAValidityOverrideField: "8760h",
+				CSRSignerCARefreshOverrideField:  "4380h",
+				CSRSignerValidityOverrideField:   "4380h",
+				CSRSignerRefreshOverrideField:    "2190h",
+			},
+			expected: rotationPeriods{caValidity: 8760 * time.Hour, caRefresh: 4380 * time.Hour, signerValidity: 4380 * time.Hour, signerRefresh: 2190 * time.Hour},
+		},
+		{
+			name: "shorter csr-signer",
+			overrides: map[string]interface{}{
+				CSRSignerValidityOverrideField: "168h",
+				CSRSignerRefreshOverrideField:  "72h",
+			},
+			expected: rotationPeriods{caValidity: defaults.caValidity, caRefresh: defaults.caRefresh, signerValidity: 168 * time.Hour, signerRefresh: 72 * time.Hour},
+		},
+		{
+			name:          "refresh not shorter than the validity",
+			overrides:     map[string]interface{}{CSRSignerRefreshOverrideField: "720h"},
+			expected:      defaults,
+			expectedError: "the csr-signer refresh period 720h0m0s must be shorter than its validity 720h0m0s",
+		},
+		{
+			name:          "CA refresh longer than the validity",
+			overrides:     map[string]interface{}{CSRSignerCAValidityOverrideField: "720h", CSRSignerCARefreshOverrideField: "1000h", CSRSignerValidityOverrideField: "500h"},
+			expected:      defaults,
+			expectedError: "the csr-signer-signer CA refresh period 1000h0m0s must be shorter than its validity 720h0m0s",
+		},
+		{
+			name:          "csr-signer outliving the CA",
+			overrides:     map[string]interface{}{CSRSignerValidityOverrideField: "2000h"},
+			expected:      defaults,
+			expectedError: "the csr-signer validity 2000h0m0s must not be longer than the csr-signer-signer CA validity 1440h0m0s",
+		},
+		{
+			name:          "too short",
+			overrides:     map[string]interface{}{CSRSignerRefreshOverrideField: "30m"},
+			expected:      defaults,
+			expectedError: `spec.unsupportedConfigOverrides.csrSignerRefresh: "30m" must be a duration of at least 1h0m0s`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unsupportedConfigOverrides, err := json.Marshal(test.overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+			periods, err := parseRotationPeriods(unsupportedConfigOverrides, defaults)
+			switch {
+			case len(test.expectedError) == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(test.expectedError) > 0 && (err == nil || err.Error() != test.expectedError):
+				t.Errorf("expected %q, got %v", test.expectedError, err)
+			}
+			if periods != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, periods)
+			}
+		})
+	}
+}
+
+// newOperator returns the KubeControllerManager CR with the given annotations and unsupportedConfigOverrides.
+func newOperator(t *testing.T, annotations map[string]string, unsupportedConfigOverrides map[string]interface{}) *unstructured.Unstructured {
+	t.Helper()
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	operator.SetAnnotations(annotations)
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedField(operator.Object, runtime.DeepCopyJSONValue(unsupportedConfigOverrides), "spec", "unsupportedConfigOverrides"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return operator
+}
+
+// setAnnotations replaces the annotations of the KubeControllerManager CR.
+func (p *pauseTest) setAnnotations(annotations map[string]string) {
+	if err := p.operators.Update(newOperator(p.t, annotations, nil)); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// setOverrides replaces the unsupportedConfigOverrides of the KubeControllerManager CR.
+func (p *pauseTest) setOverrides(unsupportedConfigOverrides map[string]interface{}) {
+	if err := p.operators.Update(newOperator(p.t, nil, unsupportedConfigOverrides)); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func TestRotationPeriodsRestartTheRotators(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	test := newPauseTest(t, ctx, 30*defaultRotationDay)
+	started := make(chan rotationPeriods, 10)
+	test.controller.newCertRotators = func(periods rotationPeriods) []factory.Controller {
+		started <- periods
+		return []factory.Controller{fakeRotator{runs: test.runs}}
+	}
+	expectStarted := func(expected rotationPeriods) context.Context {
+		t.Helper()
+		rotatorCtx := test.started()
+		if periods := <-started; periods != expected {
+			t.Errorf("expected the rotators to start with %+v, got %+v", expected, periods)
+		}
+		return rotatorCtx
+	}
+	expectCondition := func(status operatorv1.ConditionStatus) {
+		t.Helper()
+		_, operatorStatus, _, err := test.operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, rotationPeriodsConditionType); condition == nil || condition.Status != status {
+			t.Errorf("expected %s to be %s, got %#v", rotationPeriodsConditionType, status, condition)
+		}
+	}
+
+	test.sync()
+	first := expectStarted(defaultRotationPeriods(defaultRotationDay))
+	expectCondition(operatorv1.ConditionFalse)
+
+	// new periods restart the rotators with them
+	test.setOverrides(map[string]interface{}{CSRSignerValidityOverrideField: "168h", CSRSignerRefreshOverrideField: "72h"})
+	test.sync()
+	shorter := defaultRotationPeriods(defaultRotationDay)
+	shorter.signerValidity, shorter.signerRefresh = 168*time.Hour, 72*time.Hour
+	second := expectStarted(shorter)
+	if first.Err() == nil {
+		t.Error("expected the rotators of the previous periods to be stopped")
+	}
+	test.sync()
+	test.expectNotStarted()
+
+	// invalid periods keep the running rotators
+	test.setOverrides(map[string]interface{}{CSRSignerValidityOverrideField: "168h", CSRSignerRefreshOverrideField: "168h"})
+	test.sync()
+	test.expectNotStarted()
+	if second.Err() != nil {
+		t.Error("expected the rotators to keep running with invalid periods")
+	}
+	expectCondition(operatorv1.ConditionTrue)
+
+	// removing the overrides restores the defaults and clears the condition
+	test.setOverrides(nil)
+	test.sync()
+	expectStarted(defaultRotationPeriods(defaultRotationDay))
+	expectCondition(operatorv1.ConditionFalse)
+	test.expectEvents("CertRotationPeriodsChanged", "CertRotationPeriodsChanged")
+}
+
+func TestCertRotatorPeriods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset()
+	informers := v1helpers.NewKubeInformersForNamespaces(client, operatorclient.OperatorNamespace)
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+	controller, err := newCertRotationController(StandaloneClusters(client, informers), operatorClient, events.NewInMemoryRecorder("test"), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	periods := rotationPeriods{caValidity: 8760 * time.Hour, caRefresh: 4380 * time.Hour, signerValidity: 168 * time.Hour, signerRefresh: 72 * time.Hour}
+	rotators := controller.newCertRotators(periods)
+	informers.Start(ctx.Done())
+	syncRotators := func() {
+		t.Helper()
+		for _, rotator := range rotators {
+			if err := rotator.Sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	secretLister := informers.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.OperatorNamespace)
+	// validity waits for the rotators' secret in the informer and returns its validity and expiry
+	validity := func(name string) (time.Duration, string) {
+		t.Helper()
+		var notBefore, notAfter time.Time
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			secret, err := secretLister.Get(name)
+			if err != nil {
+				return false, nil
+			}
+			if notBefore, err = time.Parse(time.RFC3339, secret.Annotations[certrotation.CertificateNotBeforeAnnotation]); err != nil {
+				return false, err
+			}
+			notAfter, err = time.Parse(time.RFC3339, secret.Annotations[certrotation.CertificateNotAfterAnnotation])
+			return err == nil, err
+		}); err != nil {
+			t.Fatalf("secret %s: %v", name, err)
+		}
+		return notAfter.Sub(notBefore), notAfter.Format(time.RFC3339)
+	}
+	// the certificates are backdated by a second or so, their validity is at least the configured one
+	expectValidity := func(name string, expected time.Duration) string {
+		t.Helper()
+		actual, notAfter := validity(name)
+		if actual < expected || actual > expected+time.Minute {
+			t.Errorf("expected secret %s to be valid for %s, got %s", name, expected, actual)
+		}
+		return notAfter
+	}
+
+	syncRotators()
+	caNotAfter := expectValidity("csr-signer-signer", periods.caValidity)
+	signerNotAfter := expectValidity("csr-signer", periods.signerValidity)
+
+	// other periods apply to the next rotation, the current certificates are younger than the refresh periods
+	rotators = controller.newCertRotators(rotationPeriods{caValidity: 4380 * time.Hour, caRefresh: 2190 * time.Hour, signerValidity: 336 * time.Hour, signerRefresh: 168 * time.Hour})
+	syncRotators()
+	if _, notAfter := validity("csr-signer-signer"); notAfter != caNotAfter {
+		t.Errorf("expected the csr-signer-signer CA not to be rotated, its expiry changed from %s to %s", caNotAfter, notAfter)
+	}
+	if _, notAfter := validity("csr-signer"); notAfter != signerNotAfter {
+		t.Errorf("expected the csr-signer not to be rotated, its expiry changed from %s to %s", signerNotAfter, notAfter)
+	}
+}
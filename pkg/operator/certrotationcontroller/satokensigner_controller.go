@@ -7,16 +7,19 @@ import (
 	"time"
 
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/inputgeneration"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/encryption/crypto"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -35,28 +38,45 @@ type SATokenSignerController struct {
 	endpointClient  corev1client.EndpointsGetter
 	podClient       corev1client.PodsGetter
 
+	// operatorLister reads the rotation annotations of the KubeControllerManager CR
+	operatorLister cache.GenericLister
+
+	// the signing keys are secrets in the source cluster, the public keys are published in the destination cluster
+	clusters Clusters
+	clock    clock.PassiveClock
+
 	confirmedBootstrapNodeGone bool
+	// ignoredOverlap is the error of the last ignored value of ServiceAccountKeyOverlapOverrideField, it is reported
+	// once
+	ignoredOverlap string
 }
 
+// NewSATokenSignerController maintains the service account token signing key. A new key is published and trusted before
+// kube-controller-manager signs with it, ServiceAccountKeyRotationAnnotation on the KubeControllerManager CR read through
+// operatorLister rotates it.
 func NewSATokenSignerController(
 	operatorClient v1helpers.StaticPodOperatorClient,
-	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
-	kubeClient kubernetes.Interface,
+	operatorLister cache.GenericLister,
+	clusters Clusters,
 	eventRecorder events.Recorder,
 ) factory.Controller {
+	source, destination := clusters.Source, clusters.Destination
 	c := &SATokenSignerController{
 		operatorClient:  operatorClient,
-		secretClient:    v1helpers.CachedSecretGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
-		configMapClient: v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
-		endpointClient:  kubeClient.CoreV1(),
-		podClient:       kubeClient.CoreV1(),
+		secretClient:    v1helpers.CachedSecretGetter(source.KubeClient.CoreV1(), source.KubeInformersForNamespaces),
+		configMapClient: v1helpers.CachedConfigMapGetter(destination.KubeClient.CoreV1(), destination.KubeInformersForNamespaces),
+		endpointClient:  source.KubeClient.CoreV1(),
+		podClient:       source.KubeClient.CoreV1(),
+		operatorLister:  operatorLister,
+		clusters:        clusters,
+		clock:           clock.RealClock{},
 	}
 
 	return factory.New().WithInformers(
-		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().Secrets().Informer(),
-		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer(),
-		kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer(),
-		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Informer(),
+		source.KubeInformersForNamespaces.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().Secrets().Informer(),
+		destination.KubeInformersForNamespaces.InformersFor(clusters.SATokenSigningCertsNamespace).Core().V1().ConfigMaps().Informer(),
+		source.KubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer(),
+		source.KubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Informer(),
 		operatorClient.Informer(),
 	).ResyncEvery(time.Minute).WithSync(c.sync).ToController("SATokenSignerController", eventRecorder)
 }
@@ -151,9 +171,11 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 			return err
 		}
 		// at this point we have not-found condition, sync the original
-		_, _, err = resourceapply.SyncSecret(ctx, c.secretClient, syncCtx.Recorder(),
-			operatorclient.GlobalUserSpecifiedConfigNamespace, "initial-service-account-private-key",
-			operatorclient.TargetNamespace, "service-account-private-key", []metav1.OwnerReference{})
+		return c.syncServiceAccountKey(ctx, syncCtx, operatorclient.GlobalUserSpecifiedConfigNamespace, "initial-service-account-private-key")
+	}
+
+	rotation, overlap, err := c.readKeyRotation(syncCtx.Recorder())
+	if err != nil {
 		return err
 	}
 
@@ -168,6 +190,9 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 		if err != nil {
 			klog.Errorf("key pair is invalid: %v", err)
 			needNewSATokenSigningKey = true
+		} else if len(rotation) > 0 && saTokenSigner.Annotations[saTokenRotationAnnotation] != rotation {
+			syncCtx.Recorder().Eventf("SATokenSignerRotationStarted", "Rotating the service account token signing key for %s=%q", ServiceAccountKeyRotationAnnotation, rotation)
+			needNewSATokenSigningKey = true
 		}
 	}
 
@@ -177,10 +202,18 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 			return err
 		}
 
+		annotations := map[string]string{
+			saTokenReadyTimeAnnotation: c.clock.Now().Add(5 * time.Minute).Format(time.RFC3339),
+			// a new key is not promoted yet
+			saTokenPromotedAnnotation + "-": "",
+		}
+		if len(rotation) > 0 {
+			annotations[saTokenRotationAnnotation] = rotation
+		}
 		saTokenSigner = &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: operatorclient.OperatorNamespace, Name: "next-service-account-private-key",
-				Annotations: map[string]string{saTokenReadyTimeAnnotation: time.Now().Add(5 * time.Minute).Format(time.RFC3339)},
+				Annotations: annotations,
 			},
 			Data: map[string][]byte{
 				"service-account.key": privKeyPEM,
@@ -188,7 +221,7 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 			},
 		}
 
-		saTokenSigner, _, err = resourceapply.ApplySecret(ctx, c.secretClient, syncCtx.Recorder(), saTokenSigner)
+		saTokenSigner, _, err = resourceapply.ApplySecret(ctx, c.secretClient, c.clusters.Source.recorder(syncCtx.Recorder()), saTokenSigner)
 		if err != nil {
 			return err
 		}
@@ -196,13 +229,13 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), 5*time.Minute+10*time.Second)
 	}
 
-	saTokenSigningCerts, err := c.configMapClient.ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(ctx, "sa-token-signing-certs", metav1.GetOptions{})
+	saTokenSigningCerts, err := c.configMapClient.ConfigMaps(c.clusters.SATokenSigningCertsNamespace).Get(ctx, "sa-token-signing-certs", metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
 	if errors.IsNotFound(err) {
 		saTokenSigningCerts = &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "sa-token-signing-certs"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: c.clusters.SATokenSigningCertsNamespace, Name: "sa-token-signing-certs"},
 			Data:       map[string]string{},
 		}
 	}
@@ -214,8 +247,8 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 		}
 	}
 	if !hasThisPublicKey {
-		saTokenSigningCerts.Data[fmt.Sprintf("service-account-%03d.pub", len(saTokenSigningCerts.Data)+1)] = currPublicKey
-		saTokenSigningCerts, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), saTokenSigningCerts)
+		saTokenSigningCerts.Data[nextPublicKeyName(saTokenSigningCerts.Data)] = currPublicKey
+		saTokenSigningCerts, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, c.clusters.Destination.recorder(syncCtx.Recorder()), saTokenSigningCerts)
 		if err != nil {
 			return err
 		}
@@ -233,17 +266,52 @@ func (c *SATokenSignerController) syncWorker(ctx context.Context, syncCtx factor
 	if err != nil {
 		readyToPromote = true
 	}
-	if time.Now().After(promotionTime) {
+	if c.clock.Now().After(promotionTime) {
 		readyToPromote = true
 	}
 
 	// if we're past our promotion time, go ahead and synchronize over
 	if readyToPromote {
-		_, _, err := resourceapply.SyncSecret(ctx, c.secretClient, syncCtx.Recorder(),
-			operatorclient.OperatorNamespace, "next-service-account-private-key",
-			operatorclient.TargetNamespace, "service-account-private-key", []metav1.OwnerReference{})
+		if err := c.syncServiceAccountKey(ctx, syncCtx, operatorclient.OperatorNamespace, "next-service-account-private-key"); err != nil {
+			return err
+		}
+		if saTokenSigner, err = c.recordPromotion(ctx, syncCtx, saTokenSigner); err != nil {
+			return err
+		}
+		if saTokenSigningCerts, err = c.retirePublicKeys(ctx, syncCtx, saTokenSigner, saTokenSigningCerts, overlap); err != nil {
+			return err
+		}
+	}
+
+	return c.updateRotationCondition(ctx, saTokenSigner, saTokenSigningCerts, overlap)
+}
+
+// syncServiceAccountKey copies the key in sourceNamespace/sourceName to service-account-private-key. A changed key
+// stamps the revision inputs with a new input generation, so no revision pairs it with inputs of an older change, see
+// inputgeneration.
+func (c *SATokenSignerController) syncServiceAccountKey(ctx context.Context, syncCtx factory.SyncContext, sourceNamespace, sourceName string) error {
+	client := inputgeneration.NewStamper(
+		&cachedSecretsCoreV1{CoreV1Interface: c.clusters.Source.KubeClient.CoreV1(), secrets: c.secretClient},
+		operatorclient.TargetNamespace,
+		inputgeneration.NewGeneration(),
+		targetconfigcontroller.RevisionConfigMaps,
+		targetconfigcontroller.RevisionSecrets,
+	)
+	_, _, err := resourceapply.SyncSecret(ctx, client, c.clusters.Source.recorder(syncCtx.Recorder()),
+		sourceNamespace, sourceName,
+		operatorclient.TargetNamespace, "service-account-private-key", []metav1.OwnerReference{})
+	if err != nil {
 		return err
 	}
+	return client.Finish(ctx)
+}
 
-	return nil
+// cachedSecretsCoreV1 reads and writes the secrets through secrets, the other resources through CoreV1Interface.
+type cachedSecretsCoreV1 struct {
+	corev1client.CoreV1Interface
+	secrets corev1client.SecretsGetter
+}
+
+func (c *cachedSecretsCoreV1) Secrets(namespace string) corev1client.SecretInterface {
+	return c.secrets.Secrets(namespace)
 }
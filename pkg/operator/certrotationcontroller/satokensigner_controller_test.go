@@ -0,0 +1,98 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/inputgeneration"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
+)
+
+func TestSATokenSignerPromotionIsNotTorn(t *testing.T) {
+	ctx := context.TODO()
+	// the revision inputs were written by one sync of the target config controller
+	var objects []runtime.Object
+	for _, name := range targetconfigcontroller.RevisionConfigMaps {
+		objects = append(objects, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: name, Annotations: map[string]string{inputgeneration.Annotation: "1"}}})
+	}
+	objects = append(objects, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "serving-cert", Annotations: map[string]string{inputgeneration.Annotation: "1"}}})
+	client := fake.NewSimpleClientset(objects...)
+
+	// the revision controller reads the same objects through its own client
+	revisionClient := &fake.Clientset{}
+	revisionClient.AddReactor("*", "*", clienttesting.ObjectReaction(client.Tracker()))
+	fencedClient := inputgeneration.NewFencedClient(revisionClient, operatorclient.TargetNamespace, targetconfigcontroller.RevisionConfigMaps, targetconfigcontroller.RevisionSecrets, time.Minute)
+	createRevision := func(revision string) error {
+		_, err := fencedClient.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "revision-status-" + revision}}, metav1.CreateOptions{})
+		return err
+	}
+
+	fakeClock := clocktesting.NewFakeClock(pauseStart)
+	c := newTestSATokenSignerController(StandaloneClusters(client, v1helpers.NewKubeInformersForNamespaces(client)))
+	c.clock = fakeClock
+	recorder := events.NewInMemoryRecorder("test")
+	if err := c.syncWorker(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the revision controller tries to create a revision after the key was promoted, before the other inputs are
+	// stamped
+	var tornErr error
+	checked := false
+	client.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if !checked && action.GetNamespace() == operatorclient.TargetNamespace {
+			checked = true
+			tornErr = createRevision("2")
+		}
+		return false, nil, nil
+	})
+	fakeClock.Step(6 * time.Minute)
+	if err := c.syncWorker(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+	if !checked {
+		t.Fatal("expected the other inputs to be stamped after the key was promoted")
+	}
+	if !apierrors.IsConflict(tornErr) {
+		t.Errorf("expected no revision of the promoted key with the inputs of the previous change, got %v", tornErr)
+	}
+
+	if err := createRevision("2"); err != nil {
+		t.Fatalf("expected the revision once the inputs agree, got %v", err)
+	}
+	configMaps, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var revisions []string
+	for _, configMap := range configMaps.Items {
+		if strings.HasPrefix(configMap.Name, "revision-status-") {
+			revisions = append(revisions, configMap.Name)
+		}
+	}
+	if len(revisions) != 1 {
+		t.Errorf("expected a single revision, got %v", revisions)
+	}
+	secret, err := client.CoreV1().Secrets(operatorclient.TargetNamespace).Get(ctx, "service-account-private-key", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generation := secret.Annotations[inputgeneration.Annotation]; len(generation) == 0 || generation == "1" {
+		t.Errorf("expected the promoted key to carry a new input generation, got %q", generation)
+	}
+}
@@ -0,0 +1,184 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// ServiceAccountKeyRotationAnnotation on the KubeControllerManager CR rotates the service account token signing key
+	// every time its value changes, e.g. after the key was exposed. The public key of the new key is published next to
+	// the previous ones, kube-controller-manager signs with the new key once the kube-apiserver had time to trust it.
+	ServiceAccountKeyRotationAnnotation = "operator.openshift.io/rotate-service-account-signing-key"
+
+	// ServiceAccountKeyOverlapOverrideField of the unsupportedConfigOverrides sets how long the public keys of the
+	// previous signing keys remain published after a rotated key signs tokens, e.g. 720h. Tokens signed with a retired
+	// key no longer validate, including the tokens of legacy service account token secrets which never expire. It
+	// defaults to a year, the longest the kube-apiserver extends the expiry of a bound token.
+	ServiceAccountKeyOverlapOverrideField = "serviceAccountSigningKeyOverlap"
+
+	defaultServiceAccountKeyOverlap = 365 * 24 * time.Hour
+	// minServiceAccountKeyOverlap leaves the new revision of the kube-controller-manager time to roll out
+	minServiceAccountKeyOverlap = time.Hour
+
+	// saTokenRotationAnnotation records on next-service-account-private-key the value of
+	// ServiceAccountKeyRotationAnnotation the key was generated for, saTokenPromotedAnnotation when it was copied to
+	// service-account-private-key. Only a key generated for a rotation retires the previous public keys.
+	saTokenRotationAnnotation = "kube-controller-manager.openshift.io/rotation"
+	saTokenPromotedAnnotation = "kube-controller-manager.openshift.io/promoted"
+
+	saTokenRotationConditionType = "SATokenSignerRotationProgressing"
+)
+
+func init() {
+	overrides.Register(ServiceAccountKeyOverlapOverrideField)
+}
+
+// readKeyRotation returns the requested rotation, empty without one, and the overlap window. An invalid overlap is
+// ignored for the default, which is reported once per value.
+func (c *SATokenSignerController) readKeyRotation(recorder events.Recorder) (string, time.Duration, error) {
+	obj, err := c.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return "", defaultServiceAccountKeyOverlap, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	operator, err := meta.Accessor(obj)
+	if err != nil {
+		return "", 0, err
+	}
+	rotation := operator.GetAnnotations()[ServiceAccountKeyRotationAnnotation]
+	unsupportedConfigOverrides, err := overrides.Of(obj)
+	if err != nil {
+		return "", 0, err
+	}
+	value, ok, err := overrides.String(unsupportedConfigOverrides, ServiceAccountKeyOverlapOverrideField)
+	if err == nil && !ok {
+		c.ignoredOverlap = ""
+		return rotation, defaultServiceAccountKeyOverlap, nil
+	}
+	overlap := defaultServiceAccountKeyOverlap
+	if err == nil {
+		overlap, err = validation.DurationBetween(overrides.Path(ServiceAccountKeyOverlapOverrideField), value, minServiceAccountKeyOverlap, 0)
+	}
+	if err != nil {
+		if c.ignoredOverlap != err.Error() {
+			c.ignoredOverlap = err.Error()
+			klog.Warningf("Ignoring %v", err)
+			recorder.Warningf("SATokenSignerOverlapIgnored", "Ignoring %v, the default %s applies", err, defaultServiceAccountKeyOverlap)
+		}
+		return rotation, defaultServiceAccountKeyOverlap, nil
+	}
+	c.ignoredOverlap = ""
+	return rotation, overlap, nil
+}
+
+// nextPublicKeyName returns a key of sa-token-signing-certs following the existing ones, retired keys leave gaps.
+func nextPublicKeyName(publicKeys map[string]string) string {
+	last := 0
+	for name := range publicKeys {
+		var index int
+		if _, err := fmt.Sscanf(name, "service-account-%d.pub", &index); err == nil && index > last {
+			last = index
+		}
+	}
+	return fmt.Sprintf("service-account-%03d.pub", last+1)
+}
+
+// recordPromotion marks the key of a rotation promoted once service-account-private-key holds it, the overlap window
+// starts then.
+func (c *SATokenSignerController) recordPromotion(ctx context.Context, syncCtx factory.SyncContext, saTokenSigner *corev1.Secret) (*corev1.Secret, error) {
+	if _, ok := saTokenSigner.Annotations[saTokenRotationAnnotation]; !ok {
+		return saTokenSigner, nil
+	}
+	if _, ok := saTokenSigner.Annotations[saTokenPromotedAnnotation]; ok {
+		return saTokenSigner, nil
+	}
+	saTokenSigner = saTokenSigner.DeepCopy()
+	saTokenSigner.Annotations[saTokenPromotedAnnotation] = c.clock.Now().Format(time.RFC3339)
+	saTokenSigner, err := c.secretClient.Secrets(operatorclient.OperatorNamespace).Update(ctx, saTokenSigner, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c.clusters.Source.recorder(syncCtx.Recorder()).Eventf("SATokenSignerRotated", "The service account token signing key rotated for %s=%q was promoted to secret service-account-private-key -n %s", ServiceAccountKeyRotationAnnotation, saTokenSigner.Annotations[saTokenRotationAnnotation], operatorclient.TargetNamespace)
+	return saTokenSigner, nil
+}
+
+// retirePublicKeys removes all but the current public key from sa-token-signing-certs once the overlap window after
+// the promotion of a rotated key ended. The previous keys of several rotations within the window are all retired with
+// the last one, later than they had to.
+func (c *SATokenSignerController) retirePublicKeys(ctx context.Context, syncCtx factory.SyncContext, saTokenSigner *corev1.Secret, saTokenSigningCerts *corev1.ConfigMap, overlap time.Duration) (*corev1.ConfigMap, error) {
+	promoted, err := time.Parse(time.RFC3339, saTokenSigner.Annotations[saTokenPromotedAnnotation])
+	if err != nil {
+		return saTokenSigningCerts, nil
+	}
+	if retireAt := promoted.Add(overlap); c.clock.Now().Before(retireAt) {
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), retireAt.Sub(c.clock.Now()))
+		return saTokenSigningCerts, nil
+	}
+
+	currPublicKey := string(saTokenSigner.Data["service-account.pub"])
+	retired := []string{}
+	required := saTokenSigningCerts.DeepCopy()
+	for name, publicKey := range required.Data {
+		if publicKey != currPublicKey {
+			delete(required.Data, name)
+			retired = append(retired, name)
+		}
+	}
+	if len(retired) == 0 {
+		return saTokenSigningCerts, nil
+	}
+	recorder := c.clusters.Destination.recorder(syncCtx.Recorder())
+	saTokenSigningCerts, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, recorder, required)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(retired)
+	recorder.Eventf("SATokenSignerKeysRetired", "Removed the public keys %s of previous service account token signing keys from configmap/sa-token-signing-certs -n %s, %s after the rotation", strings.Join(retired, ", "), required.Namespace, overlap)
+	return saTokenSigningCerts, nil
+}
+
+// updateRotationCondition reports a rotation as progressing until the new key signs tokens and the end of the overlap
+// window in its message until the previous public keys are retired.
+func (c *SATokenSignerController) updateRotationCondition(ctx context.Context, saTokenSigner *corev1.Secret, saTokenSigningCerts *corev1.ConfigMap, overlap time.Duration) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   saTokenRotationConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if rotation, ok := saTokenSigner.Annotations[saTokenRotationAnnotation]; ok {
+		promoted, err := time.Parse(time.RFC3339, saTokenSigner.Annotations[saTokenPromotedAnnotation])
+		switch {
+		case err != nil:
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = "RotatingSigningKey"
+			condition.Message = fmt.Sprintf("The service account token signing key is rotated for %s=%q, kube-controller-manager signs with the new key once the kube-apiserver trusts it after %s", ServiceAccountKeyRotationAnnotation, rotation, saTokenSigner.Annotations[saTokenReadyTimeAnnotation])
+		case len(saTokenSigningCerts.Data) > 1:
+			condition.Reason = "PreviousKeysTrusted"
+			condition.Message = fmt.Sprintf("The service account token signing key was rotated for %s=%q, tokens signed with the previous keys validate until %s", ServiceAccountKeyRotationAnnotation, rotation, promoted.Add(overlap).Format(time.RFC3339))
+		}
+	}
+	_, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(condition))
+	return err
+}
@@ -0,0 +1,225 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/keyutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// signToken returns a service account token signed with the PEM encoded private key, the way kube-controller-manager
+// signs the tokens of legacy service account token secrets.
+func signToken(t *testing.T, keyPEM []byte) string {
+	t.Helper()
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoding := base64.RawURLEncoding
+	signed := encoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encoding.EncodeToString([]byte(`{"iss":"kubernetes/serviceaccount","sub":"system:serviceaccount:default:test"}`))
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + encoding.EncodeToString(signature)
+}
+
+// verifiable returns whether the token is signed by one of the public keys of sa-token-signing-certs, the way the
+// kube-apiserver validates it with all of them.
+func verifiable(t *testing.T, token string, publicKeys *corev1.ConfigMap) bool {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	for _, publicKeyPEM := range publicKeys.Data {
+		keys, err := keyutil.ParsePublicKeysPEM([]byte(publicKeyPEM))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			if rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestSATokenSignerRotation(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+	operators := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	fakeClock := clocktesting.NewFakeClock(pauseStart)
+	c := newTestSATokenSignerController(StandaloneClusters(client, v1helpers.NewKubeInformersForNamespaces(client)))
+	c.operatorLister = cache.NewGenericLister(operators, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource())
+	c.clock = fakeClock
+	recorder := events.NewInMemoryRecorder("test")
+
+	sync := func() {
+		t.Helper()
+		if err := c.syncWorker(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setOperator := func(rotation string, unsupportedConfigOverrides map[string]interface{}) {
+		t.Helper()
+		if err := operators.Update(newOperator(t, map[string]string{ServiceAccountKeyRotationAnnotation: rotation}, unsupportedConfigOverrides)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	signingKey := func() []byte {
+		t.Helper()
+		secret, err := client.CoreV1().Secrets(operatorclient.TargetNamespace).Get(ctx, "service-account-private-key", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret.Data["service-account.key"]
+	}
+	publicKeys := func() *corev1.ConfigMap {
+		t.Helper()
+		configMap, err := client.CoreV1().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(ctx, "sa-token-signing-certs", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return configMap
+	}
+	expectPublicKeys := func(names ...string) {
+		t.Helper()
+		var actual []string
+		for name := range publicKeys().Data {
+			actual = append(actual, name)
+		}
+		sort.Strings(actual)
+		if strings.Join(actual, ",") != strings.Join(names, ",") {
+			t.Errorf("expected the public keys %v, got %v", names, actual)
+		}
+	}
+	expectVerifiable := func(token string, expected bool) {
+		t.Helper()
+		if actual := verifiable(t, token, publicKeys()); actual != expected {
+			t.Errorf("expected the token to be verifiable %v, got %v", expected, actual)
+		}
+	}
+	expectCondition := func(status operatorv1.ConditionStatus, reason, message string) {
+		t.Helper()
+		_, operatorStatus, _, err := c.operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, saTokenRotationConditionType)
+		if condition == nil || condition.Status != status || condition.Reason != reason || !strings.Contains(condition.Message, message) {
+			t.Errorf("expected %s to be %s with reason %s and a message containing %q, got %#v", saTokenRotationConditionType, status, reason, message, condition)
+		}
+	}
+
+	// the first key is trusted before it signs tokens
+	sync()
+	fakeClock.Step(6 * time.Minute)
+	sync()
+	firstToken := signToken(t, signingKey())
+	expectVerifiable(firstToken, true)
+	expectPublicKeys("service-account-001.pub")
+	expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+
+	setOperator("1", nil)
+	sync()
+	expectPublicKeys("service-account-001.pub", "service-account-002.pub")
+	expectCondition(operatorv1.ConditionTrue, "RotatingSigningKey", `rotated for operator.openshift.io/rotate-service-account-signing-key="1"`)
+	if token := signToken(t, signingKey()); token != firstToken {
+		t.Error("expected the first key to sign until the new one is trusted")
+	}
+
+	fakeClock.Step(6 * time.Minute)
+	sync()
+	secondToken := signToken(t, signingKey())
+	if secondToken == firstToken {
+		t.Fatal("expected the new key to sign")
+	}
+	expectVerifiable(firstToken, true)
+	expectVerifiable(secondToken, true)
+	expectCondition(operatorv1.ConditionFalse, "PreviousKeysTrusted", "tokens signed with the previous keys validate until 2025-05-01T08:12:00Z")
+	// a handled rotation does not rotate again
+	sync()
+	if token := signToken(t, signingKey()); token != secondToken {
+		t.Error("expected the rotation to happen once")
+	}
+
+	// the overlap window is a year by default
+	fakeClock.Step(defaultServiceAccountKeyOverlap - time.Minute)
+	sync()
+	expectVerifiable(firstToken, true)
+	fakeClock.Step(time.Minute)
+	sync()
+	expectVerifiable(firstToken, false)
+	expectVerifiable(secondToken, true)
+	expectPublicKeys("service-account-002.pub")
+	expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+
+	// a shorter overlap window, the new public key does not replace the one of the current key
+	setOperator("2", map[string]interface{}{ServiceAccountKeyOverlapOverrideField: "48h"})
+	sync()
+	fakeClock.Step(6 * time.Minute)
+	sync()
+	thirdToken := signToken(t, signingKey())
+	expectPublicKeys("service-account-002.pub", "service-account-003.pub")
+	fakeClock.Step(47 * time.Hour)
+	sync()
+	expectVerifiable(secondToken, true)
+	fakeClock.Step(time.Hour)
+	sync()
+	expectVerifiable(secondToken, false)
+	expectVerifiable(thirdToken, true)
+	expectPublicKeys("service-account-003.pub")
+
+	var reasons []string
+	for _, event := range recorder.Events() {
+		if strings.HasPrefix(event.Reason, "SATokenSigner") {
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	expected := "SATokenSignerRotationStarted,SATokenSignerRotated,SATokenSignerKeysRetired,SATokenSignerRotationStarted,SATokenSignerRotated,SATokenSignerKeysRetired"
+	if strings.Join(reasons, ",") != expected {
+		t.Errorf("expected the events %s, got %v", expected, reasons)
+	}
+}
+
+func TestNextPublicKeyName(t *testing.T) {
+	for _, test := range []struct {
+		publicKeys map[string]string
+		expected   string
+	}{
+		{expected: "service-account-001.pub"},
+		{publicKeys: map[string]string{"service-account-001.pub": "a", "service-account-002.pub": "b"}, expected: "service-account-003.pub"},
+		// retired keys leave gaps, the current key must not be overwritten
+		{publicKeys: map[string]string{"service-account-003.pub": "c"}, expected: "service-account-004.pub"},
+		{publicKeys: map[string]string{"custom.pub": "d"}, expected: "service-account-001.pub"},
+	} {
+		if actual := nextPublicKeyName(test.publicKeys); actual != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.publicKeys, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package certrotationcontroller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	clusteroperatorhelpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// UpgradeDeferralRatioOverrideField of the unsupportedConfigOverrides sets the ratio of their lifetime the
+	// certificates must have left for their rotation to be deferred during an upgrade, e.g. 0.5. A rotation lands a new
+	// kube-controller-manager revision, which would compete with the rollouts of the upgrade. A certificate with less
+	// left or within the safety margin of its expiry rotates regardless, 1 never defers a rotation.
+	UpgradeDeferralRatioOverrideField = "certRotationUpgradeDeferralRatio"
+
+	defaultUpgradeDeferralRatio = 0.2
+)
+
+func init() {
+	overrides.Register(UpgradeDeferralRatioOverrideField)
+}
+
+// upgradeInProgress describes the upgrade or the rollout the rotation waits for, empty when none: the cluster version
+// progressing towards a new release or a revision of the kube-controller-manager that is not on all nodes yet.
+func (c *CertRotationController) upgradeInProgress() (string, error) {
+	clusterVersion, err := c.clusterVersionLister.Get("version")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil && clusteroperatorhelpers.IsStatusConditionTrue(clusterVersion.Status.Conditions, configv1.OperatorProgressing) {
+		return fmt.Sprintf("the cluster upgrades to %s", clusterVersion.Status.Desired.Version), nil
+	}
+
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return "", err
+	}
+	for _, node := range status.NodeStatuses {
+		if node.TargetRevision > 0 {
+			return fmt.Sprintf("node %s is updated to revision %d", node.NodeName, node.TargetRevision), nil
+		}
+		if node.CurrentRevision != status.LatestAvailableRevision {
+			return fmt.Sprintf("revision %d is rolled out to node %s", status.LatestAvailableRevision, node.NodeName), nil
+		}
+	}
+	return "", nil
+}
+
+// readUpgradeDeferralRatio returns the ratio set by UpgradeDeferralRatioOverrideField, the default when it is not set.
+// An invalid value is ignored, which is reported once per value.
+func (c *CertRotationController) readUpgradeDeferralRatio(recorder events.Recorder) (float64, error) {
+	obj, err := c.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return defaultUpgradeDeferralRatio, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(obj)
+	if err != nil {
+		return 0, err
+	}
+	value, ok, err := overrides.String(unsupportedConfigOverrides, UpgradeDeferralRatioOverrideField)
+	if err == nil && !ok {
+		c.ignoredDeferralRatio = ""
+		return defaultUpgradeDeferralRatio, nil
+	}
+	ratio := defaultUpgradeDeferralRatio
+	if err == nil {
+		ratio, err = validation.FloatRatio(overrides.Path(UpgradeDeferralRatioOverrideField), value)
+	}
+	if err != nil {
+		if c.ignoredDeferralRatio != err.Error() {
+			c.ignoredDeferralRatio = err.Error()
+			klog.Warningf("Ignoring %v", err)
+			recorder.Warningf("CertRotationUpgradeDeferralRatioIgnored", "Ignoring %v, the default %v applies", err, defaultUpgradeDeferralRatio)
+		}
+		return defaultUpgradeDeferralRatio, nil
+	}
+	c.ignoredDeferralRatio = ""
+	return ratio, nil
+}
+
+// urgentCertificate describes the first paused certificate the rotation of which must not be deferred, empty when
+// none: one that must not wait for the end of a pause either or that has no more than ratio of its lifetime left.
+func (c *CertRotationController) urgentCertificate(ratio float64) (string, error) {
+	expiring, err := c.expiringCertificate()
+	if err != nil || len(expiring) > 0 {
+		return expiring, err
+	}
+	for _, name := range pausedSecrets {
+		secret, err := c.secretLister.Get(name)
+		if err != nil {
+			return "", err
+		}
+		notBefore, err := time.Parse(time.RFC3339, secret.Annotations[certrotation.CertificateNotBeforeAnnotation])
+		if err != nil {
+			return fmt.Sprintf("the lifetime of secret %s -n %s is unknown", name, operatorclient.OperatorNamespace), nil
+		}
+		notAfter, err := time.Parse(time.RFC3339, secret.Annotations[certrotation.CertificateNotAfterAnnotation])
+		if err != nil {
+			return fmt.Sprintf("the expiry of secret %s -n %s is unknown", name, operatorclient.OperatorNamespace), nil
+		}
+		lifetime, remaining := notAfter.Sub(notBefore), notAfter.Sub(c.clock.Now())
+		if float64(remaining) <= ratio*float64(lifetime) {
+			return fmt.Sprintf("secret %s -n %s expires at %s, it has no more than %.0f%% of its lifetime left", name, operatorclient.OperatorNamespace, notAfter.Format(time.RFC3339), ratio*100), nil
+		}
+	}
+	return "", nil
+}
+
+// deferRotators stops the rotators until the upgrade settles, c.lock is held.
+func (c *CertRotationController) deferRotators(recorder events.Recorder, upgrade string) {
+	c.pausedUntil = time.Time{}
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+	if c.deferred {
+		return
+	}
+	c.deferred = true
+	recorder.Eventf("CertRotationDeferred", "The rotation of secrets %s -n %s is deferred while %s", strings.Join(pausedSecrets, ", "), operatorclient.OperatorNamespace, upgrade)
+}
@@ -0,0 +1,166 @@
+package certrotationcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// setLifetime sets the validity of the paused certificates.
+func (p *pauseTest) setLifetime(notBefore, notAfter time.Time) {
+	for _, name := range pausedSecrets {
+		if err := p.secrets.Update(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorclient.OperatorNamespace,
+			Name:      name,
+			Annotations: map[string]string{
+				certrotation.CertificateNotBeforeAnnotation: notBefore.Format(time.RFC3339),
+				certrotation.CertificateNotAfterAnnotation:  notAfter.Format(time.RFC3339),
+			},
+		}}); err != nil {
+			p.t.Fatal(err)
+		}
+	}
+}
+
+// setUpgrading sets whether the cluster version progresses towards 4.16.1.
+func (p *pauseTest) setUpgrading(upgrading bool) {
+	status := configv1.ConditionFalse
+	if upgrading {
+		status = configv1.ConditionTrue
+	}
+	if err := p.clusterVersions.Update(&configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Desired:    configv1.Release{Version: "4.16.1"},
+			Conditions: []configv1.ClusterOperatorStatusCondition{{Type: configv1.OperatorProgressing, Status: status}},
+		},
+	}); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *pauseTest) expectEventMessage(reason, message string) {
+	p.t.Helper()
+	for _, event := range p.recorder.Events() {
+		if event.Reason == reason && strings.Contains(event.Message, message) {
+			return
+		}
+	}
+	p.t.Errorf("expected a %s event containing %q, got %v", reason, message, p.recorder.Events())
+}
+
+func TestUpgradeDefersTheRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 0)
+	// two thirds of the lifetime are left
+	p.setLifetime(pauseStart.Add(-10*24*time.Hour), pauseStart.Add(20*24*time.Hour))
+	p.setUpgrading(false)
+
+	p.sync()
+	running := p.started()
+
+	p.setUpgrading(true)
+	p.sync()
+	if running.Err() == nil {
+		t.Error("expected the rotator to be stopped")
+	}
+	// a resync does not restart it
+	p.sync()
+	p.expectNotStarted()
+	// the rotation stays upgradeable, a deferral ends with the upgrade
+	p.expectCondition(operatorv1.ConditionTrue, "AsExpected", "")
+
+	p.setUpgrading(false)
+	p.sync()
+	p.started()
+	p.expectEvents("CertRotationDeferred", "CertRotationResumed")
+	p.expectEventMessage("CertRotationDeferred", "is deferred while the cluster upgrades to 4.16.1")
+	p.expectEventMessage("CertRotationResumed", "resumed: the upgrade settled")
+}
+
+func TestUpgradeDoesNotDeferUrgentRotations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 0)
+	// half of the lifetime of 100 days is left
+	p.setLifetime(pauseStart.Add(-50*24*time.Hour), pauseStart.Add(50*24*time.Hour))
+	p.setUpgrading(true)
+
+	p.sync()
+	p.expectNotStarted()
+
+	// 19 days are left, less than the default of 20% and more than the safety margin
+	p.clock.Step(31 * 24 * time.Hour)
+	p.sync()
+	p.started()
+	p.expectEvents("CertRotationDeferred", "CertRotationResumed")
+	p.expectEventMessage("CertRotationResumed", "resumed: secret csr-signer-signer -n openshift-kube-controller-manager-operator expires at 2024-06-20T08:00:00Z, it has no more than 20% of its lifetime left")
+
+	// certificates without a known lifetime are never deferred either
+	p = newPauseTest(t, ctx, 50*24*time.Hour)
+	p.setUpgrading(true)
+	p.sync()
+	p.started()
+	p.expectEvents()
+}
+
+func TestUpgradeDeferralRatio(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 0)
+	// 40% of the lifetime is left
+	p.setLifetime(pauseStart.Add(-60*24*time.Hour), pauseStart.Add(40*24*time.Hour))
+	p.setUpgrading(true)
+
+	p.setOverrides(map[string]interface{}{UpgradeDeferralRatioOverrideField: 0.5})
+	p.sync()
+	p.started()
+
+	p.setOverrides(map[string]interface{}{UpgradeDeferralRatioOverrideField: "half"})
+	p.sync()
+	p.expectNotStarted()
+	p.expectEvents("CertRotationUpgradeDeferralRatioIgnored", "CertRotationDeferred")
+	p.expectEventMessage("CertRotationUpgradeDeferralRatioIgnored", `spec.unsupportedConfigOverrides.certRotationUpgradeDeferralRatio: "half" must be a ratio between 0 and 1, the default 0.2 applies`)
+}
+
+func TestRevisionRolloutDefersTheRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPauseTest(t, ctx, 0)
+	p.setLifetime(pauseStart.Add(-10*24*time.Hour), pauseStart.Add(20*24*time.Hour))
+	setNodeStatuses := func(latest int32, nodes ...operatorv1.NodeStatus) {
+		t.Helper()
+		if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, p.operatorClient, func(status *operatorv1.StaticPodOperatorStatus) error {
+			status.LatestAvailableRevision, status.NodeStatuses = latest, nodes
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setNodeStatuses(3, operatorv1.NodeStatus{NodeName: "master-0", CurrentRevision: 2, TargetRevision: 3}, operatorv1.NodeStatus{NodeName: "master-1", CurrentRevision: 2})
+	p.sync()
+	p.expectNotStarted()
+	p.expectEventMessage("CertRotationDeferred", "is deferred while node master-0 is updated to revision 3")
+
+	setNodeStatuses(3, operatorv1.NodeStatus{NodeName: "master-0", CurrentRevision: 3}, operatorv1.NodeStatus{NodeName: "master-1", CurrentRevision: 2})
+	p.sync()
+	p.expectNotStarted()
+
+	setNodeStatuses(3, operatorv1.NodeStatus{NodeName: "master-0", CurrentRevision: 3}, operatorv1.NodeStatus{NodeName: "master-1", CurrentRevision: 3})
+	p.sync()
+	p.started()
+	p.expectEvents("CertRotationDeferred", "CertRotationResumed")
+}
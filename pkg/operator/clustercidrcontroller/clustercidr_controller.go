@@ -0,0 +1,199 @@
+package clustercidrcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	controllerName = "ClusterCIDRConsistencyController"
+	conditionType  = "ClusterCIDRConsistencyDegraded"
+
+	// DefaultGracePeriod is how long a node may run with other cluster CIDRs than the Network config, which covers a
+	// regular rollout of the observed change.
+	DefaultGracePeriod = 30 * time.Minute
+)
+
+var staleNodesMetric = metrics.NewGauge(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager",
+	Name:           "stale_cluster_cidr_nodes",
+	Help:           "Number of nodes whose current kube-controller-manager revision allocates node CIDRs from other cluster networks than network.config/cluster for longer than the grace period",
+	StabilityLevel: metrics.ALPHA,
+})
+
+func init() {
+	legacyregistry.MustRegister(staleNodesMetric)
+}
+
+// ClusterCIDRConsistencyController compares the --cluster-cidr the current revision of every node runs with the cluster
+// networks of network.config/cluster. After an unsupported change of the cluster network that never rolled out, or
+// rolled out only partially, kube-controller-manager keeps allocating node CIDRs from the old range. A node that
+// diverges for longer than the grace period is reported. This is detection only, the config observer and the
+// installer remain the only way the change reaches the nodes.
+type ClusterCIDRConsistencyController struct {
+	operatorClient  v1helpers.StaticPodOperatorClient
+	networkLister   configlisters.NetworkLister
+	configMapLister corev1listers.ConfigMapNamespaceLister
+
+	gracePeriod time.Duration
+	now         func() time.Time
+
+	lock sync.Mutex
+	// divergedSince is when a node was first seen running other cluster CIDRs than the Network config
+	divergedSince map[string]time.Time
+}
+
+func NewClusterCIDRConsistencyController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configInformers configinformers.SharedInformerFactory,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &ClusterCIDRConsistencyController{
+		operatorClient:  operatorClient,
+		networkLister:   configInformers.Config().V1().Networks().Lister(),
+		configMapLister: kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+		gracePeriod:     DefaultGracePeriod,
+		now:             time.Now,
+		divergedSince:   map[string]time.Time{},
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		configInformers.Config().V1().Networks().Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("cluster-cidr-consistency-controller"))
+}
+
+func (c *ClusterCIDRConsistencyController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	network, err := c.networkLister.Get("cluster")
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	live := sets.New[string]()
+	if network != nil {
+		for _, clusterNetwork := range network.Status.ClusterNetwork {
+			live.Insert(clusterNetwork.CIDR)
+		}
+	}
+
+	// the nodes beyond the grace period, grouped by the cluster CIDRs they run
+	stale := map[string][]string{}
+	var staleNodes int
+	c.lock.Lock()
+	diverged := sets.New[string]()
+	for _, nodeStatus := range status.NodeStatuses {
+		if live.Len() == 0 || nodeStatus.CurrentRevision == 0 {
+			continue
+		}
+		running, err := c.clusterCIDRsOf(nodeStatus.CurrentRevision)
+		if err != nil {
+			klog.V(2).Infof("Skipping node %s: %v", nodeStatus.NodeName, err)
+			continue
+		}
+		if running.Equal(live) {
+			continue
+		}
+
+		diverged.Insert(nodeStatus.NodeName)
+		since, ok := c.divergedSince[nodeStatus.NodeName]
+		if !ok {
+			since = c.now()
+			c.divergedSince[nodeStatus.NodeName] = since
+		}
+		if c.now().Sub(since) < c.gracePeriod {
+			continue
+		}
+		key := strings.Join(sets.List(running), ",")
+		stale[key] = append(stale[key], fmt.Sprintf("%s (revision %d)", nodeStatus.NodeName, nodeStatus.CurrentRevision))
+		staleNodes++
+	}
+	for node := range c.divergedSince {
+		if !diverged.Has(node) {
+			delete(c.divergedSince, node)
+		}
+	}
+	c.lock.Unlock()
+	staleNodesMetric.Set(float64(staleNodes))
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if staleNodes > 0 {
+		running := make([]string, 0, len(stale))
+		for key := range stale {
+			running = append(running, key)
+		}
+		sort.Strings(running)
+		var lines []string
+		for _, key := range running {
+			cidrs := key
+			if len(cidrs) == 0 {
+				cidrs = "no cluster CIDR"
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", strings.Join(stale[key], ", "), cidrs))
+		}
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "StaleClusterCIDR"
+		condition.Message = fmt.Sprintf("For longer than %s, kube-controller-manager allocates node CIDRs from other cluster networks than network.config/cluster, which has %s:\n%s",
+			c.gracePeriod, strings.Join(sets.List(live), ","), strings.Join(lines, "\n"))
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// clusterCIDRsOf returns the --cluster-cidr of the kube-controller-manager config of revision.
+func (c *ClusterCIDRConsistencyController) clusterCIDRsOf(revision int32) (sets.Set[string], error) {
+	configMap, err := c.configMapLister.Get(fmt.Sprintf("config-%d", revision))
+	if err != nil {
+		return nil, err
+	}
+	config := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(configMap.Data["config.yaml"]), &config); err != nil {
+		return nil, fmt.Errorf("configmap/%s: %v", configMap.Name, err)
+	}
+	values, _, err := unstructured.NestedStringSlice(config, "extendedArguments", "cluster-cidr")
+	if err != nil {
+		return nil, fmt.Errorf("configmap/%s: %v", configMap.Name, err)
+	}
+	cidrs := sets.New[string]()
+	for _, value := range values {
+		for _, cidr := range strings.Split(value, ",") {
+			if cidr = strings.TrimSpace(cidr); len(cidr) > 0 {
+				cidrs.Insert(cidr)
+			}
+		}
+	}
+	return cidrs, nil
+}
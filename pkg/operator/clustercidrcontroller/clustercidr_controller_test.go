@@ -0,0 +1,129 @@
+package clustercidrcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestClusterCIDRConsistency(t *testing.T) {
+	tests := []struct {
+		name            string
+		revisions       map[string]int32
+		after           time.Duration
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+		expectedStale   float64
+	}{
+		{
+			name:           "matching",
+			revisions:      map[string]int32{"master-0": 4, "master-1": 4, "master-2": 4},
+			after:          time.Hour,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:           "mid-rollout",
+			revisions:      map[string]int32{"master-0": 4, "master-1": 3, "master-2": 3},
+			after:          10 * time.Minute,
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:           "stale",
+			revisions:      map[string]int32{"master-0": 4, "master-1": 3, "master-2": 2},
+			after:          31 * time.Minute,
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedMessage: "For longer than 30m0s, kube-controller-manager allocates node CIDRs from other cluster networks than network.config/cluster, which has 10.132.0.0/14,fd01::/48:\n" +
+				"master-2 (revision 2): no cluster CIDR\n" +
+				"master-1 (revision 3): 10.128.0.0/14",
+			expectedStale: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for revision, config := range map[int]string{
+				2: `{"extendedArguments":{}}`,
+				3: `{"extendedArguments":{"cluster-cidr":["10.128.0.0/14"]}}`,
+				4: `{"extendedArguments":{"cluster-cidr":["fd01::/48,10.132.0.0/14"]}}`,
+			} {
+				if err := configMapIndexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: fmt.Sprintf("config-%d", revision)},
+					Data:       map[string]string{"config.yaml": config},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			networkIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := networkIndexer.Add(&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.NetworkStatus{ClusterNetwork: []configv1.ClusterNetworkEntry{
+					{CIDR: "10.132.0.0/14", HostPrefix: 23},
+					{CIDR: "fd01::/48", HostPrefix: 64},
+				}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			var nodeStatuses []operatorv1.NodeStatus
+			for _, node := range []string{"master-0", "master-1", "master-2"} {
+				nodeStatuses = append(nodeStatuses, operatorv1.NodeStatus{NodeName: node, CurrentRevision: test.revisions[node]})
+			}
+			now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+			c := &ClusterCIDRConsistencyController{
+				operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+					&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+					&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 4, NodeStatuses: nodeStatuses},
+					nil,
+					nil,
+				),
+				networkLister:   configlisters.NewNetworkLister(networkIndexer),
+				configMapLister: corev1listers.NewConfigMapLister(configMapIndexer).ConfigMaps(operatorclient.TargetNamespace),
+				gracePeriod:     DefaultGracePeriod,
+				now:             func() time.Time { return now },
+				divergedSince:   map[string]time.Time{},
+			}
+			syncCtx := factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))
+
+			// the first sync starts the grace period of the diverged nodes
+			for _, after := range []time.Duration{0, test.after} {
+				now = now.Add(after)
+				if err := c.sync(context.TODO(), syncCtx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+			if condition == nil || condition.Status != test.expectedStatus || condition.Message != test.expectedMessage {
+				t.Errorf("expected the condition %s %q, got %#v", test.expectedStatus, test.expectedMessage, condition)
+			}
+			stale, err := testutil.GetGaugeMetricValue(staleNodesMetric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stale != test.expectedStale {
+				t.Errorf("expected %v stale nodes, got %v", test.expectedStale, stale)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package compat holds the context aware replacements of the file and client calls the operator used to make without
+// a context: reads of the service account files that honor the cancellation of the operator, and the timeout of the
+// API calls made from callbacks that library-go invokes without a context.
+package compat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CallTimeout bounds an API call made without a caller context, e.g. from an installer pod mutation function. The
+// client has no timeout of its own, a stuck connection would block the caller forever.
+const CallTimeout = 30 * time.Second
+
+// serviceAccountDir is where the service account of the pod is mounted, a variable for the tests.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// WithCallTimeout returns a context for one API call, ctx bounded by CallTimeout.
+func WithCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, CallTimeout)
+}
+
+// ReadFile reads the file name like os.ReadFile, but returns the error of ctx as soon as it is done. A read blocked on
+// a stale mount is abandoned, its result is dropped.
+func ReadFile(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		content []byte
+		err     error
+	}
+	read := make(chan result, 1)
+	go func() {
+		content, err := os.ReadFile(name)
+		read <- result{content: content, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-read:
+		return r.content, r.err
+	}
+}
+
+// ServiceAccountToken returns the token of the service account of the pod. It is read on every call, it is rotated.
+func ServiceAccountToken(ctx context.Context) ([]byte, error) {
+	return ReadFile(ctx, filepath.Join(serviceAccountDir, "token"))
+}
+
+// ServiceAccountNamespace returns the namespace of the pod from its service account, fallback when the service
+// account is not mounted, e.g. when the operator runs outside of a cluster. Other errors are returned as they are.
+func ServiceAccountNamespace(ctx context.Context, fallback string) (string, error) {
+	content, err := ReadFile(ctx, filepath.Join(serviceAccountDir, "namespace"))
+	switch {
+	case os.IsNotExist(err):
+		return fallback, nil
+	case err != nil:
+		return "", err
+	}
+	if namespace := strings.TrimSpace(string(content)); len(namespace) > 0 {
+		return namespace, nil
+	}
+	return fallback, nil
+}
@@ -0,0 +1,115 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ReadFile(context.Background(), path); err != nil || string(content) != "content" {
+		t.Errorf("expected the content, got %q, %v", content, err)
+	}
+	if _, err := ReadFile(context.Background(), filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadFile(ctx, path); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled read, got %v", err)
+	}
+}
+
+func TestReadFileTimeout(t *testing.T) {
+	// opening a fifo blocks until it has a writer, like a read from a stale mount
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0644); err != nil {
+		t.Skipf("unable to create a fifo: %v", err)
+	}
+	defer func() {
+		// unblock the abandoned read
+		if writer, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			writer.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := ReadFile(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the read to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the read to be abandoned at the deadline, it took %s", elapsed)
+	}
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	ctx, cancel := WithCallTimeout(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > CallTimeout {
+		t.Errorf("expected a deadline within %s, got %v (%v)", CallTimeout, deadline, ok)
+	}
+}
+
+func TestServiceAccountNamespace(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		content       *string
+		directory     bool
+		expected      string
+		expectedError bool
+	}{
+		{name: "namespace", content: stringPtr("openshift-kube-controller-manager-operator\n"), expected: "openshift-kube-controller-manager-operator"},
+		{name: "not mounted", expected: "fallback"},
+		{name: "empty", content: stringPtr(" \n"), expected: "fallback"},
+		{name: "unreadable", directory: true, expectedError: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			switch {
+			case test.content != nil:
+				if err := os.WriteFile(filepath.Join(dir, "namespace"), []byte(*test.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			case test.directory:
+				if err := os.Mkdir(filepath.Join(dir, "namespace"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			defer func(previous string) { serviceAccountDir = previous }(serviceAccountDir)
+			serviceAccountDir = dir
+
+			actual, err := ServiceAccountNamespace(context.Background(), "fallback")
+			if test.expectedError != (err != nil) {
+				t.Fatalf("expected error: %v, got %v", test.expectedError, err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestServiceAccountNamespaceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// a cancelled read is not taken for a missing service account
+	if _, err := ServiceAccountNamespace(ctx, "fallback"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled read, got %v", err)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
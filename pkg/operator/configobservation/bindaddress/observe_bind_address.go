@@ -0,0 +1,109 @@
+package bindaddress
+
+import (
+	"fmt"
+	"net"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/configobserver/network"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// PolicyOverrideField of the unsupportedConfigOverrides selects where the secure port of kube-controller-manager
+	// is reachable from. Without it --bind-address is left to the kube-controller-manager default.
+	PolicyOverrideField = "securePortBindAddressPolicy"
+
+	// PolicyAll binds the secure port to all addresses, the Service and the PodDisruptionBudget guard reach it
+	// through the node IP.
+	PolicyAll = "All"
+	// PolicyNodeLocal binds the secure port to loopback. Only clients on the node, like the kubelet probes, can
+	// reach it.
+	PolicyNodeLocal = "NodeLocal"
+)
+
+var bindAddressPath = []string{"extendedArguments", "bind-address"}
+
+func init() {
+	overrides.Register(PolicyOverrideField)
+}
+
+// NewObserveBindAddressFunc renders --bind-address from the policy set on the operator. The address family follows
+// the first service network, matching the address the kube-apiserver and the probes use to reach the node.
+func NewObserveBindAddressFunc(operator overrides.SpecGetter) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		listers := genericListers.(configobservation.Listers)
+
+		previouslyObservedConfig := map[string]interface{}{}
+		if current, _, _ := unstructured.NestedStringSlice(existingConfig, bindAddressPath...); len(current) > 0 {
+			if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, bindAddressPath...); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		policy, ok, err := overrides.String(unsupportedConfigOverrides, PolicyOverrideField)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !ok {
+			return map[string]interface{}{}, nil
+		}
+		if _, err := validation.EnumOf(overrides.Path(PolicyOverrideField), policy, PolicyAll, PolicyNodeLocal); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+
+		if policy == PolicyNodeLocal {
+			infrastructure, err := listers.InfrastructureLister().Get("cluster")
+			if err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+			// the guard pods of the PodDisruptionBudget probe the secure port on the node IP from the pod network
+			if topology := infrastructure.Status.ControlPlaneTopology; topology != configv1.SingleReplicaTopologyMode {
+				return previouslyObservedConfig, []error{fmt.Errorf("%s: %q requires the %q control plane topology, the PodDisruptionBudget guard of a %q control plane cannot reach a node local secure port", overrides.Path(PolicyOverrideField), PolicyNodeLocal, configv1.SingleReplicaTopologyMode, topology)}
+			}
+		}
+
+		serviceCIDRs, err := network.GetServiceCIDRs(listers.NetworkLister, recorder)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if len(serviceCIDRs) == 0 {
+			return previouslyObservedConfig, []error{fmt.Errorf("no service network to derive the address family of --bind-address from")}
+		}
+		ip, _, err := net.ParseCIDR(serviceCIDRs[0])
+		if err != nil {
+			return previouslyObservedConfig, []error{fmt.Errorf("service network %q: %w", serviceCIDRs[0], err)}
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{bindAddress(policy, ip.To4() == nil)}, bindAddressPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		return observedConfig, nil
+	}
+}
+
+// bindAddress returns the --bind-address of policy for the given address family.
+func bindAddress(policy string, ipv6 bool) string {
+	switch {
+	case policy == PolicyNodeLocal && ipv6:
+		return "::1"
+	case policy == PolicyNodeLocal:
+		return "127.0.0.1"
+	case ipv6:
+		return "::"
+	default:
+		return "0.0.0.0"
+	}
+}
@@ -0,0 +1,142 @@
+package bindaddress
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func bindAddressConfig(address string) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"bind-address": []interface{}{address},
+		},
+	}
+}
+
+func TestObserveBindAddress(t *testing.T) {
+	tests := []struct {
+		name          string
+		overrides     map[string]interface{}
+		serviceCIDR   string
+		topology      configv1.TopologyMode
+		input         map[string]interface{}
+		expected      map[string]interface{}
+		expectErrors  bool
+		expectedError string
+	}{
+		{
+			name:        "no policy",
+			serviceCIDR: "172.30.0.0/16",
+			topology:    configv1.HighlyAvailableTopologyMode,
+			input:       map[string]interface{}{},
+			expected:    map[string]interface{}{},
+		},
+		{
+			name:        "all ipv4",
+			overrides:   map[string]interface{}{PolicyOverrideField: PolicyAll},
+			serviceCIDR: "172.30.0.0/16",
+			topology:    configv1.HighlyAvailableTopologyMode,
+			input:       map[string]interface{}{},
+			expected:    bindAddressConfig("0.0.0.0"),
+		},
+		{
+			name:        "all ipv6",
+			overrides:   map[string]interface{}{PolicyOverrideField: PolicyAll},
+			serviceCIDR: "fd02::/112",
+			topology:    configv1.HighlyAvailableTopologyMode,
+			input:       map[string]interface{}{},
+			expected:    bindAddressConfig("::"),
+		},
+		{
+			name:        "node local ipv4",
+			overrides:   map[string]interface{}{PolicyOverrideField: PolicyNodeLocal},
+			serviceCIDR: "172.30.0.0/16",
+			topology:    configv1.SingleReplicaTopologyMode,
+			input:       bindAddressConfig("0.0.0.0"),
+			expected:    bindAddressConfig("127.0.0.1"),
+		},
+		{
+			name:        "node local ipv6",
+			overrides:   map[string]interface{}{PolicyOverrideField: PolicyNodeLocal},
+			serviceCIDR: "fd02::/112",
+			topology:    configv1.SingleReplicaTopologyMode,
+			input:       map[string]interface{}{},
+			expected:    bindAddressConfig("::1"),
+		},
+		{
+			name:         "node local with a guarded control plane is rejected",
+			overrides:    map[string]interface{}{PolicyOverrideField: PolicyNodeLocal},
+			serviceCIDR:  "172.30.0.0/16",
+			topology:     configv1.HighlyAvailableTopologyMode,
+			input:        bindAddressConfig("0.0.0.0"),
+			expected:     bindAddressConfig("0.0.0.0"),
+			expectErrors: true,
+		},
+		{
+			name:          "unknown policy",
+			overrides:     map[string]interface{}{PolicyOverrideField: "PodNetwork"},
+			serviceCIDR:   "172.30.0.0/16",
+			topology:      configv1.SingleReplicaTopologyMode,
+			input:         bindAddressConfig("127.0.0.1"),
+			expected:      bindAddressConfig("127.0.0.1"),
+			expectErrors:  true,
+			expectedError: `spec.unsupportedConfigOverrides.securePortBindAddressPolicy: "PodNetwork" must be one of "All", "NodeLocal"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infrastructureIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := infrastructureIndexer.Add(&configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.InfrastructureStatus{ControlPlaneTopology: test.topology},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			networkIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := networkIndexer.Add(&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.NetworkStatus{ServiceNetwork: []string{test.serviceCIDR}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{
+				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infrastructureIndexer),
+				NetworkLister:         configlistersv1.NewNetworkLister(networkIndexer),
+			}
+
+			observe := NewObserveBindAddressFunc(fakeOperator(test.overrides))
+			result, errs := observe(listers, events.NewInMemoryRecorder("test"), test.input)
+			if test.expectErrors != (len(errs) > 0) {
+				t.Fatalf("expected errors: %v, got %v", test.expectErrors, errs)
+			}
+			if len(test.expectedError) > 0 && (len(errs) != 1 || errs[0].Error() != test.expectedError) {
+				t.Errorf("expected %q, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cloud
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// currentRelease is the OpenShift minor release this operator ships with. It is used whenever the payload version
+// cannot be parsed (e.g. development builds reporting 0.0.1-snapshot) and must always have an entry in
+// csiMigrationReleases.
+const currentRelease = "4.16"
+
+// csiMigrationRelease describes the state of the in-tree to CSI volume migration for a single OpenShift minor
+// release.
+type csiMigrationRelease struct {
+	// externalVolumePlugins are the cloud providers (as returned by cloudprovider.GetPlatformName) whose in-tree
+	// volume plugin must still be kept alive in kube-controller-manager through --external-cloud-volume-plugin
+	// once the cloud provider itself has moved out of tree. Drop a provider from the set in the release where its
+	// in-tree plugin is removed from kube-controller-manager.
+	externalVolumePlugins sets.Set[string]
+	// featureGates are the "<name>=<enabled>" CSI migration feature gates kube-controller-manager must run with on a
+	// cloud provider, whatever the FeatureGate CR says. They are locked to these values in the kubelet of the release,
+	// a FeatureGate CR carried over from an older release may still disable them. Drop them in the release that
+	// removes them from kube-controller-manager.
+	featureGates map[string][]string
+}
+
+// csiMigrationReleases must be kept in sync with the kube-controller-manager and kubelet configuration shipped in
+// every supported release, otherwise volume attach/detach ends up being served by different plugins on either side.
+// Add an entry for every new release; TestCSIMigrationReleasesCoverCurrentRelease enforces that at least the
+// release we are building is present.
+var csiMigrationReleases = map[string]csiMigrationRelease{
+	// Kubernetes 1.27 removed the in-tree Azure Disk plugin, the in-tree Azure File plugin remains. CSIMigrationvSphere
+	// is GA and locked to true.
+	"4.14": {
+		externalVolumePlugins: sets.New("azure", "gce", "vsphere"),
+		featureGates:          map[string][]string{"vsphere": {"CSIMigrationvSphere=true"}},
+	},
+	// Kubernetes 1.28 removed the in-tree GCE PD plugin.
+	"4.15": {
+		externalVolumePlugins: sets.New("azure", "vsphere"),
+		featureGates:          map[string][]string{"vsphere": {"CSIMigrationvSphere=true"}},
+	},
+	// Kubernetes 1.29 removed CSIMigrationvSphere, the migration is always on. kcmfeatures drops the gate.
+	"4.16": {
+		externalVolumePlugins: sets.New("azure", "vsphere"),
+	},
+}
+
+// csiMigrationForVersion returns the CSI migration entry for the given payload version. Unparseable versions and
+// releases newer than the table use currentRelease, releases older than the table use the oldest known entry.
+func csiMigrationForVersion(version string) (string, csiMigrationRelease) {
+	minor, ok := release.Minor(version)
+	if !ok {
+		return currentRelease, csiMigrationReleases[currentRelease]
+	}
+	if entry, ok := csiMigrationReleases[minor]; ok {
+		return minor, entry
+	}
+
+	known := make([]string, 0, len(csiMigrationReleases))
+	for r := range csiMigrationReleases {
+		known = append(known, r)
+	}
+	sort.Slice(known, func(i, j int) bool { return release.Compare(known[i], known[j]) < 0 })
+	if release.Compare(minor, known[0]) < 0 {
+		return known[0], csiMigrationReleases[known[0]]
+	}
+	return currentRelease, csiMigrationReleases[currentRelease]
+}
@@ -0,0 +1,199 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+var allPlatforms = []configv1.PlatformType{
+	configv1.AWSPlatformType,
+	configv1.AzurePlatformType,
+	configv1.BareMetalPlatformType,
+	configv1.GCPPlatformType,
+	configv1.LibvirtPlatformType,
+	configv1.OpenStackPlatformType,
+	configv1.NonePlatformType,
+	configv1.VSpherePlatformType,
+	configv1.OvirtPlatformType,
+	configv1.IBMCloudPlatformType,
+	configv1.KubevirtPlatformType,
+	configv1.EquinixMetalPlatformType,
+	configv1.PowerVSPlatformType,
+	configv1.AlibabaCloudPlatformType,
+	configv1.NutanixPlatformType,
+	configv1.ExternalPlatformType,
+}
+
+func newInfrastructureListers(t *testing.T, platform configv1.PlatformType) configobservation.Listers {
+	infraIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := infraIndexer.Add(&configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{Type: platform},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return configobservation.Listers{
+		InfrastructureLister_: configlistersv1.NewInfrastructureLister(infraIndexer),
+	}
+}
+
+func TestCSIMigrationReleasesPerPlatform(t *testing.T) {
+	externalCloudProvider := featuregates.NewHardcodedFeatureGateAccess(
+		[]configv1.FeatureGateName{configv1.FeatureGateExternalCloudProvider},
+		[]configv1.FeatureGateName{},
+	)
+	// the --external-cloud-volume-plugin of every release by platform, none on the other platforms
+	expectedVolumePlugins := map[string]map[configv1.PlatformType]string{
+		"4.14": {configv1.AzurePlatformType: "azure", configv1.GCPPlatformType: "gce", configv1.VSpherePlatformType: "vsphere"},
+		"4.15": {configv1.AzurePlatformType: "azure", configv1.VSpherePlatformType: "vsphere"},
+		"4.16": {configv1.AzurePlatformType: "azure", configv1.VSpherePlatformType: "vsphere"},
+	}
+	if len(expectedVolumePlugins) != len(csiMigrationReleases) {
+		t.Fatalf("expected the releases %v, csiMigrationReleases has %d", sets.List(sets.KeySet(expectedVolumePlugins)), len(csiMigrationReleases))
+	}
+
+	for release, platforms := range expectedVolumePlugins {
+		for _, platform := range allPlatforms {
+			t.Run(release+"/"+string(platform), func(t *testing.T) {
+				recorder := events.NewInMemoryRecorder("cloud")
+				result, errs := NewObserveCloudVolumePluginFunc(externalCloudProvider, release+".0")(newInfrastructureListers(t, platform), recorder, map[string]interface{}{})
+				if len(errs) > 0 {
+					t.Fatal(errs)
+				}
+
+				expected := map[string]interface{}{}
+				if plugin, ok := platforms[platform]; ok {
+					expected = map[string]interface{}{
+						"extendedArguments": map[string]interface{}{
+							"external-cloud-volume-plugin": []interface{}{plugin},
+						},
+					}
+				}
+				if !reflect.DeepEqual(expected, result) {
+					t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(expected), toYAML(result))
+				}
+			})
+		}
+	}
+}
+
+func TestCSIMigrationFeatureGatesPerPlatform(t *testing.T) {
+	// the feature gates of the FeatureGate CR, CSIMigrationvSphere is disabled by one carried over from 4.13
+	observeFeatureGates := func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{
+			"extendedArguments": map[string]interface{}{
+				"feature-gates": []interface{}{"CSIMigrationvSphere=false", "RotateKubeletServerCertificate=true"},
+			},
+		}, nil
+	}
+	// the feature gates of every release by platform, the ones of the FeatureGate CR on the other platforms
+	expectedFeatureGates := map[string]map[configv1.PlatformType][]interface{}{
+		"4.14": {configv1.VSpherePlatformType: {"CSIMigrationvSphere=true", "RotateKubeletServerCertificate=true"}},
+		"4.15": {configv1.VSpherePlatformType: {"CSIMigrationvSphere=true", "RotateKubeletServerCertificate=true"}},
+		"4.16": {},
+	}
+	if len(expectedFeatureGates) != len(csiMigrationReleases) {
+		t.Fatalf("expected the releases %v, csiMigrationReleases has %d", sets.List(sets.KeySet(expectedFeatureGates)), len(csiMigrationReleases))
+	}
+
+	for release, platforms := range expectedFeatureGates {
+		for _, platform := range allPlatforms {
+			t.Run(release+"/"+string(platform), func(t *testing.T) {
+				observe := NewObserveCSIMigrationFeatureGatesFunc(observeFeatureGates, release+".0", []string{"extendedArguments", "feature-gates"})
+				result, errs := observe(newInfrastructureListers(t, platform), events.NewInMemoryRecorder("cloud"), map[string]interface{}{})
+				if len(errs) > 0 {
+					t.Fatal(errs)
+				}
+
+				expected, ok := platforms[platform]
+				if !ok {
+					expected = []interface{}{"CSIMigrationvSphere=false", "RotateKubeletServerCertificate=true"}
+				}
+				if actual := result["extendedArguments"].(map[string]interface{})["feature-gates"]; !reflect.DeepEqual(expected, actual) {
+					t.Errorf("expected the feature gates %v, got %v", expected, actual)
+				}
+			})
+		}
+	}
+}
+
+func TestCSIMigrationFeatureGatesAppended(t *testing.T) {
+	noFeatureGates := func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{}, nil
+	}
+	observe := NewObserveCSIMigrationFeatureGatesFunc(noFeatureGates, "4.15.2", []string{"extendedArguments", "feature-gates"})
+	result, errs := observe(newInfrastructureListers(t, configv1.VSpherePlatformType), events.NewInMemoryRecorder("cloud"), map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expected := map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"feature-gates": []interface{}{"CSIMigrationvSphere=true"},
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(expected), toYAML(result))
+	}
+}
+
+func TestCSIMigrationForVersion(t *testing.T) {
+	tests := []struct {
+		version         string
+		expectedRelease string
+	}{
+		{version: "4.15.3", expectedRelease: "4.15"},
+		{version: "4.16.0-0.nightly-2024-01-10-120000", expectedRelease: "4.16"},
+		{version: "4.14.0-rc.1", expectedRelease: "4.14"},
+		{version: "0.0.1-snapshot", expectedRelease: currentRelease},
+		{version: "", expectedRelease: currentRelease},
+		{version: "garbage", expectedRelease: currentRelease},
+		{version: "4.1.0", expectedRelease: "4.14"},
+		{version: "5.0.0", expectedRelease: currentRelease},
+	}
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			release, _ := csiMigrationForVersion(test.version)
+			if release != test.expectedRelease {
+				t.Errorf("expected release %q, got %q", test.expectedRelease, release)
+			}
+		})
+	}
+}
+
+// TestCSIMigrationReleasesCoverCurrentRelease fails the build once the repository moves to a new release branch
+// without adding the corresponding entry to csiMigrationReleases.
+func TestCSIMigrationReleasesCoverCurrentRelease(t *testing.T) {
+	ciConfig, err := os.ReadFile(filepath.Join("..", "..", "..", "..", ".ci-operator.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	match := regexp.MustCompile(`openshift-(\d+\.\d+)`).FindSubmatch(ciConfig)
+	if match == nil {
+		t.Fatalf("unable to determine the current release from .ci-operator.yaml")
+	}
+	release := string(match[1])
+
+	if release != currentRelease {
+		t.Errorf("currentRelease is %q, but the repository builds release %q", currentRelease, release)
+	}
+	if _, ok := csiMigrationReleases[release]; !ok {
+		t.Errorf("csiMigrationReleases is missing an entry for release %q", release)
+	}
+}
@@ -0,0 +1,136 @@
+package cloud
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/configobserver/cloudprovider"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// cloudConfigFileFormat is the file of a key of the cloud-config configmap of a revision.
+	cloudConfigFileFormat = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/%s"
+	// managedCloudConfig is the cloud config normalized by the cloud config sync, it wins over the one of the user.
+	managedCloudConfig    = "kube-cloud-config"
+	managedCloudConfigKey = "cloud.conf"
+)
+
+var (
+	cloudProviderPath = []string{"extendedArguments", "cloud-provider"}
+	cloudConfigPath   = []string{"extendedArguments", "cloud-config"}
+
+	// cloudConfigProviders are the in-tree cloud providers reading a cloud config.
+	cloudConfigProviders = sets.New("aws", "azure", "gce", "vsphere")
+)
+
+// NewObserveCloudProviderFunc returns an observer rendering --cloud-provider and --cloud-config, and syncing the
+// cloud-config configmap of the revisions.
+func NewObserveCloudProviderFunc(featureGateAccessor featuregates.FeatureGateAccess) configobserver.ObserveConfigFunc {
+	return (&cloudProvider{
+		featureGateAccessor: featureGateAccessor,
+	}).ObserveCloudProvider
+}
+
+type cloudProvider struct {
+	featureGateAccessor featuregates.FeatureGateAccess
+}
+
+// ObserveCloudProvider sets --cloud-provider=external without a --cloud-config and the cloud-config configmap once
+// the platform runs an external cloud controller manager, according to the feature gates and the Infrastructure
+// status. Otherwise it sets the in-tree cloud provider of the platform and its cloud config. Both arguments and the
+// configmap are switched in one observation, a revision with only some of them would break the node lifecycle. While
+// the feature gates or the platform are unknown the previously observed arguments are kept.
+func (o *cloudProvider) ObserveCloudProvider(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+	defer func() {
+		ret = configobserver.Pruned(ret, cloudProviderPath, cloudConfigPath)
+	}()
+
+	if !o.featureGateAccessor.AreInitialFeatureGatesObserved() {
+		return existingConfig, nil
+	}
+
+	listers := genericListers.(configobservation.Listers)
+	infrastructure, err := listers.InfrastructureLister().Get("cluster")
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ObserveCloudProvider", "Required infrastructures.%s/cluster not found", configv1.GroupName)
+		return existingConfig, nil
+	}
+	if err != nil {
+		return existingConfig, append(errs, err)
+	}
+	if infrastructure.Status.PlatformStatus == nil {
+		return existingConfig, append(errs, fmt.Errorf("infrastructures.%s/cluster: status.platformStatus not found", configv1.GroupName))
+	}
+
+	external, err := cloudprovider.IsCloudProviderExternal(o.featureGateAccessor, infrastructure.Status.PlatformStatus)
+	if err != nil {
+		recorder.Warningf("ObserveCloudProvider", "Keeping the cloud provider: %v", err)
+		return existingConfig, append(errs, err)
+	}
+
+	observedConfig := map[string]interface{}{}
+	cloudConfigSource := resourcesynccontroller.ResourceLocation{}
+	provider := cloudprovider.GetPlatformName(infrastructure.Status.PlatformStatus.Type, recorder)
+	switch {
+	case external:
+		provider = "external"
+	case cloudConfigProviders.Has(provider):
+		var cloudConfigKey string
+		cloudConfigSource, cloudConfigKey, err = cloudConfigLocation(listers, infrastructure)
+		if err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if len(cloudConfigSource.Name) > 0 {
+			if err := unstructured.SetNestedStringSlice(observedConfig, []string{fmt.Sprintf(cloudConfigFileFormat, cloudConfigKey)}, cloudConfigPath...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+	}
+	if len(provider) > 0 {
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{provider}, cloudProviderPath...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+
+	if err := listers.ResourceSyncer().SyncConfigMap(
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "cloud-config"},
+		cloudConfigSource,
+	); err != nil {
+		return existingConfig, append(errs, err)
+	}
+
+	previous := configobserver.Pruned(existingConfig, cloudProviderPath, cloudConfigPath)
+	if !equality.Semantic.DeepEqual(previous, observedConfig) {
+		recorder.Eventf("ObserveCloudProviderChanges", "cloud provider changed from %v to %v", previous, observedConfig)
+	}
+
+	return observedConfig, errs
+}
+
+// cloudConfigLocation returns the configmap of the cloud config and its key. The cloud config normalized by the cloud
+// config sync wins over the one of the user, there is none when neither is set.
+func cloudConfigLocation(listers configobservation.Listers, infrastructure *configv1.Infrastructure) (resourcesynccontroller.ResourceLocation, string, error) {
+	_, err := listers.ConfigMapLister().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(managedCloudConfig)
+	if err == nil {
+		return resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: managedCloudConfig}, managedCloudConfigKey, nil
+	}
+	if !errors.IsNotFound(err) {
+		return resourcesynccontroller.ResourceLocation{}, "", err
+	}
+	if len(infrastructure.Spec.CloudConfig.Name) == 0 {
+		return resourcesynccontroller.ResourceLocation{}, "", nil
+	}
+	return resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: infrastructure.Spec.CloudConfig.Name}, infrastructure.Spec.CloudConfig.Key, nil
+}
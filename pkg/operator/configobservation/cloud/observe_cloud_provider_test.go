@@ -0,0 +1,230 @@
+package cloud
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeResourceSyncer struct {
+	configMaps map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation
+}
+
+func (f *fakeResourceSyncer) SyncConfigMap(destination, source resourcesynccontroller.ResourceLocation) error {
+	f.configMaps[destination] = source
+	return nil
+}
+
+func (f *fakeResourceSyncer) SyncSecret(destination, source resourcesynccontroller.ResourceLocation) error {
+	return nil
+}
+
+func cloudProviderConfig(provider, cloudConfig string) map[string]interface{} {
+	extendedArguments := map[string]interface{}{"cloud-provider": []interface{}{provider}}
+	if len(cloudConfig) > 0 {
+		extendedArguments["cloud-config"] = []interface{}{cloudConfig}
+	}
+	return map[string]interface{}{"extendedArguments": extendedArguments}
+}
+
+func TestObserveCloudProvider(t *testing.T) {
+	externalGates := []configv1.FeatureGateName{
+		configv1.FeatureGateExternalCloudProvider,
+		configv1.FeatureGateExternalCloudProviderAzure,
+		configv1.FeatureGateExternalCloudProviderGCP,
+	}
+	enabled := featuregates.NewHardcodedFeatureGateAccess(externalGates, nil)
+	disabled := featuregates.NewHardcodedFeatureGateAccess(nil, externalGates)
+	notObserved := featuregates.NewHardcodedFeatureGateAccessForTesting(nil, externalGates, make(chan struct{}), nil)
+	unreadable := featuregates.NewHardcodedFeatureGateAccessForTesting(nil, externalGates, closedChannel(), fmt.Errorf("no feature gates"))
+
+	userCloudConfig := resourcesynccontroller.ResourceLocation{Namespace: "openshift-config", Name: "cloud-provider-config"}
+	managedCloudConfig := resourcesynccontroller.ResourceLocation{Namespace: "openshift-config-managed", Name: "kube-cloud-config"}
+	const (
+		userCloudConfigFile    = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/config"
+		managedCloudConfigFile = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/cloud.conf"
+	)
+
+	tests := []struct {
+		name                string
+		platform            configv1.PlatformType
+		noInfrastructure    bool
+		managedCloudConfig  bool
+		featureGateAccessor featuregates.FeatureGateAccess
+		input, expected     map[string]interface{}
+		expectedSource      *resourcesynccontroller.ResourceLocation
+		expectErrors        bool
+	}{
+		{
+			name:                "AWS in-tree to external",
+			platform:            configv1.AWSPlatformType,
+			featureGateAccessor: enabled,
+			input:               cloudProviderConfig("aws", userCloudConfigFile),
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			// the external cloud provider of AWS is GA and does not depend on the feature gates
+			name:                "AWS external stays external",
+			platform:            configv1.AWSPlatformType,
+			featureGateAccessor: disabled,
+			input:               cloudProviderConfig("external", ""),
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "Azure in-tree to external",
+			platform:            configv1.AzurePlatformType,
+			featureGateAccessor: enabled,
+			input:               cloudProviderConfig("azure", userCloudConfigFile),
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "Azure external to in-tree",
+			platform:            configv1.AzurePlatformType,
+			featureGateAccessor: disabled,
+			input:               cloudProviderConfig("external", ""),
+			expected:            cloudProviderConfig("azure", userCloudConfigFile),
+			expectedSource:      &userCloudConfig,
+		},
+		{
+			name:                "GCP in-tree to external",
+			platform:            configv1.GCPPlatformType,
+			featureGateAccessor: enabled,
+			input:               cloudProviderConfig("gce", managedCloudConfigFile),
+			managedCloudConfig:  true,
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "GCP external to in-tree with the managed cloud config",
+			platform:            configv1.GCPPlatformType,
+			featureGateAccessor: disabled,
+			input:               cloudProviderConfig("external", ""),
+			managedCloudConfig:  true,
+			expected:            cloudProviderConfig("gce", managedCloudConfigFile),
+			expectedSource:      &managedCloudConfig,
+		},
+		{
+			name:                "vSphere in-tree to external",
+			platform:            configv1.VSpherePlatformType,
+			featureGateAccessor: enabled,
+			input:               cloudProviderConfig("vsphere", userCloudConfigFile),
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			// the external cloud provider of vSphere is GA and does not depend on the feature gates
+			name:                "vSphere external stays external",
+			platform:            configv1.VSpherePlatformType,
+			featureGateAccessor: disabled,
+			input:               cloudProviderConfig("external", ""),
+			expected:            cloudProviderConfig("external", ""),
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "None with external feature gates",
+			platform:            configv1.NonePlatformType,
+			featureGateAccessor: enabled,
+			input:               map[string]interface{}{},
+			expected:            map[string]interface{}{},
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "None without external feature gates",
+			platform:            configv1.NonePlatformType,
+			featureGateAccessor: disabled,
+			input:               map[string]interface{}{},
+			expected:            map[string]interface{}{},
+			expectedSource:      &resourcesynccontroller.ResourceLocation{},
+		},
+		{
+			name:                "feature gates not observed keep the cloud provider",
+			platform:            configv1.AzurePlatformType,
+			featureGateAccessor: notObserved,
+			input:               cloudProviderConfig("azure", userCloudConfigFile),
+			expected:            cloudProviderConfig("azure", userCloudConfigFile),
+		},
+		{
+			name:                "unreadable feature gates keep the cloud provider",
+			platform:            configv1.AzurePlatformType,
+			featureGateAccessor: unreadable,
+			input:               cloudProviderConfig("external", ""),
+			expected:            cloudProviderConfig("external", ""),
+			expectErrors:        true,
+		},
+		{
+			name:                "missing infrastructure keeps the cloud provider",
+			noInfrastructure:    true,
+			featureGateAccessor: enabled,
+			input:               cloudProviderConfig("gce", userCloudConfigFile),
+			expected:            cloudProviderConfig("gce", userCloudConfigFile),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infrastructureIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if !test.noInfrastructure {
+				if err := infrastructureIndexer.Add(&configv1.Infrastructure{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Spec:       configv1.InfrastructureSpec{CloudConfig: configv1.ConfigMapFileReference{Name: "cloud-provider-config", Key: "config"}},
+					Status: configv1.InfrastructureStatus{
+						Platform:       test.platform,
+						PlatformStatus: &configv1.PlatformStatus{Type: test.platform},
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if test.managedCloudConfig {
+				if err := configMapIndexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config-managed", Name: "kube-cloud-config"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			syncer := &fakeResourceSyncer{configMaps: map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation{}}
+			listers := configobservation.Listers{
+				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infrastructureIndexer),
+				ConfigMapLister_:      corelistersv1.NewConfigMapLister(configMapIndexer),
+				ResourceSync:          syncer,
+			}
+
+			observe := NewObserveCloudProviderFunc(test.featureGateAccessor)
+			result, errs := observe(listers, events.NewInMemoryRecorder("cloud"), test.input)
+			if test.expectErrors != (len(errs) > 0) {
+				t.Fatalf("expected errors: %v, got %v", test.expectErrors, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+
+			source, synced := syncer.configMaps[resourcesynccontroller.ResourceLocation{Namespace: "openshift-kube-controller-manager", Name: "cloud-config"}]
+			switch {
+			case test.expectedSource == nil && synced:
+				t.Errorf("expected the cloud-config sync to be kept, got %v", source)
+			case test.expectedSource != nil && (!synced || source != *test.expectedSource):
+				t.Errorf("expected the cloud-config to be synced from %v, got %v", *test.expectedSource, source)
+			}
+		})
+	}
+}
+
+func closedChannel() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
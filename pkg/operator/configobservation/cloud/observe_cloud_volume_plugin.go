@@ -11,19 +11,23 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 )
 
-func NewObserveCloudVolumePluginFunc(featureGateAccessor featuregates.FeatureGateAccess) configobserver.ObserveConfigFunc {
+// NewObserveCloudVolumePluginFunc returns an observer rendering --external-cloud-volume-plugin according to the CSI
+// migration state of the release identified by payloadVersion, see csiMigrationReleases.
+func NewObserveCloudVolumePluginFunc(featureGateAccessor featuregates.FeatureGateAccess, payloadVersion string) configobserver.ObserveConfigFunc {
 	return (&cloudVolumePlugin{
 		featureGateAccessor: featureGateAccessor,
+		payloadVersion:      payloadVersion,
 	}).ObserveCloudVolumePlugin
 }
 
 type cloudVolumePlugin struct {
 	featureGateAccessor featuregates.FeatureGateAccess
+	payloadVersion      string
 }
 
 // ObserveCloudVOlumePlugin fills in the extendedArguments.external-cloud-volume-plugin with the value of the current
-// platform type, only when the cluster is running an external cloud provider and there is a supported in-tree volume
-// plugin.
+// platform type, only when the cluster is running an external cloud provider and the in-tree volume plugin of that
+// platform has not completed its CSI migration in the current release.
 func (o *cloudVolumePlugin) ObserveCloudVolumePlugin(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
 	volumePluginPath := []string{"extendedArguments", "external-cloud-volume-plugin"}
 	defer func() {
@@ -51,17 +55,13 @@ func (o *cloudVolumePlugin) ObserveCloudVolumePlugin(genericListers configobserv
 	observedConfig := map[string]interface{}{}
 	cloudProvider := cloudprovider.GetPlatformName(infrastructure.Status.PlatformStatus.Type, recorder)
 
-	switch cloudProvider {
-	case "aws":
-		// CSI migration GA'd more than 1 release ago, so we can safely remove the in-tree plugin.
-		// Do nothing on these platforms.
-	default:
-		// If the cloud provider is external, we should set the option, else leave it empty.
-		if external && len(cloudProvider) > 0 {
-			if err := unstructured.SetNestedStringSlice(observedConfig, []string{cloudProvider}, volumePluginPath...); err != nil {
-				recorder.Warningf("ObserveCloudVolumePlugin", "Failed setting cloudVolumePlugin: %v", err)
-				return existingConfig, append(errs, err)
-			}
+	_, migration := csiMigrationForVersion(o.payloadVersion)
+	// If the cloud provider is external and its CSI migration is not complete yet, we should set the option,
+	// else leave it empty.
+	if external && migration.externalVolumePlugins.Has(cloudProvider) {
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{cloudProvider}, volumePluginPath...); err != nil {
+			recorder.Warningf("ObserveCloudVolumePlugin", "Failed setting cloudVolumePlugin: %v", err)
+			return existingConfig, append(errs, err)
 		}
 	}
 
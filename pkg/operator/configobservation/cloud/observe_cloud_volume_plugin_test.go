@@ -222,7 +222,8 @@ func TestObserveCloudVolumePlugin(t *testing.T) {
 				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infraIndexer),
 			}
 
-			result, errs := NewObserveCloudVolumePluginFunc(featureGates)(listers, events.NewInMemoryRecorder("cloud"), test.input)
+			// the in-tree plugins of azure, gce and vsphere are all kept in 4.14, see TestCSIMigrationReleasesPerPlatform
+			result, errs := NewObserveCloudVolumePluginFunc(featureGates, "4.14.0")(listers, events.NewInMemoryRecorder("cloud"), test.input)
 			if len(errs) > 0 && !test.expectedError {
 				t.Fatal(errs)
 			} else if len(errs) == 0 {
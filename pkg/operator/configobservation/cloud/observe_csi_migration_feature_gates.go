@@ -0,0 +1,74 @@
+package cloud
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/configobserver/cloudprovider"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// NewObserveCSIMigrationFeatureGatesFunc wraps observeFeatureGates, which writes the feature gates of the FeatureGate
+// CR into configPath, and sets the CSI migration feature gates the release identified by payloadVersion requires on
+// the platform of the cluster, see csiMigrationReleases. The gates are kept consistent with the kubelet this way.
+func NewObserveCSIMigrationFeatureGatesFunc(observeFeatureGates configobserver.ObserveConfigFunc, payloadVersion string, configPath []string) configobserver.ObserveConfigFunc {
+	return (&csiMigrationFeatureGates{
+		observeFeatureGates: observeFeatureGates,
+		payloadVersion:      payloadVersion,
+		configPath:          configPath,
+	}).ObserveCSIMigrationFeatureGates
+}
+
+type csiMigrationFeatureGates struct {
+	observeFeatureGates configobserver.ObserveConfigFunc
+	payloadVersion      string
+	configPath          []string
+}
+
+// ObserveCSIMigrationFeatureGates replaces the "<name>=<enabled>" entries of the observed feature gates named by the
+// CSI migration gates of the platform and appends the missing ones.
+func (o *csiMigrationFeatureGates) ObserveCSIMigrationFeatureGates(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	observedConfig, errs := o.observeFeatureGates(genericListers, recorder, existingConfig)
+
+	listers := genericListers.(configobservation.Listers)
+	infrastructure, err := listers.InfrastructureLister().Get("cluster")
+	if err != nil {
+		return observedConfig, append(errs, err)
+	}
+	if infrastructure.Status.PlatformStatus == nil {
+		return observedConfig, errs
+	}
+	_, migration := csiMigrationForVersion(o.payloadVersion)
+	required := migration.featureGates[cloudprovider.GetPlatformName(infrastructure.Status.PlatformStatus.Type, recorder)]
+	if len(required) == 0 {
+		return observedConfig, errs
+	}
+
+	gates, _, err := unstructured.NestedStringSlice(observedConfig, o.configPath...)
+	if err != nil {
+		return observedConfig, append(errs, err)
+	}
+	for _, gate := range required {
+		name, _, _ := strings.Cut(gate, "=")
+		found := false
+		for i := range gates {
+			if existing, _, _ := strings.Cut(gates[i], "="); existing == name {
+				gates[i] = gate
+				found = true
+			}
+		}
+		if !found {
+			gates = append(gates, gate)
+		}
+	}
+	if observedConfig == nil {
+		observedConfig = map[string]interface{}{}
+	}
+	if err := unstructured.SetNestedStringSlice(observedConfig, gates, o.configPath...); err != nil {
+		errs = append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,84 @@
+package configobservercontroller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+// durationArguments are the extendedArguments of kube-controller-manager that take a duration. Their observed values
+// are rendered like time.Duration.String(), so the same duration is always the same string no matter which observer
+// produced it and how: "90s", "1m30s" and "90000ms" all become "1m30s". Otherwise the string comparison of the
+// observed config misses changes and spuriously creates revisions.
+var durationArguments = []string{
+	"attach-detach-reconcile-sync-period",
+	"cluster-signing-duration",
+	"horizontal-pod-autoscaler-cpu-initialization-period",
+	"horizontal-pod-autoscaler-downscale-stabilization",
+	"horizontal-pod-autoscaler-initial-readiness-delay",
+	"horizontal-pod-autoscaler-sync-period",
+	"leader-elect-lease-duration",
+	"leader-elect-renew-deadline",
+	"leader-elect-retry-period",
+	"min-resync-period",
+	"namespace-sync-period",
+	"node-monitor-grace-period",
+	"node-monitor-period",
+	"node-startup-grace-period",
+	"pvclaimbinder-sync-period",
+	"resource-quota-sync-period",
+	"route-reconciliation-period",
+}
+
+// withCanonicalDurations renders the duration arguments observer returns in canonical form. A value that is no
+// duration or negative is an error of observer, the previously observed value is kept.
+func withCanonicalDurations(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+		for _, argument := range durationArguments {
+			path := []string{"extendedArguments", argument}
+			values, found, err := unstructured.NestedStringSlice(observedConfig, path...)
+			if err != nil || !found {
+				continue
+			}
+
+			canonical, err := canonicalDurations(strings.Join(path, "."), values)
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("observer %s: %w", name, err))
+				previous, found, _ := unstructured.NestedStringSlice(existingConfig, path...)
+				if !found {
+					unstructured.RemoveNestedField(observedConfig, path...)
+					continue
+				}
+				canonical = previous
+			case reflect.DeepEqual(canonical, values):
+				continue
+			}
+			if err := unstructured.SetNestedStringSlice(observedConfig, canonical, path...); err != nil {
+				errs = append(errs, fmt.Errorf("observer %s: extendedArguments.%s: %w", name, argument, err))
+			}
+		}
+		return observedConfig, errs
+	}
+}
+
+func canonicalDurations(path string, values []string) ([]string, error) {
+	canonical := make([]string, 0, len(values))
+	for _, value := range values {
+		// none of the duration arguments accepts a negative duration
+		duration, err := validation.DurationBetween(path, value, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		canonical = append(canonical, duration.String())
+	}
+	return canonical, nil
+}
@@ -0,0 +1,103 @@
+package configobservercontroller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+func observing(values map[string]string) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig := map[string]interface{}{}
+		for argument, value := range values {
+			if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, "extendedArguments", argument); err != nil {
+				return nil, []error{err}
+			}
+		}
+		return observedConfig, nil
+	}
+}
+
+func TestDurationArgumentsAreCanonical(t *testing.T) {
+	for _, format := range []string{"90s", "1m30s", "90000ms"} {
+		values := map[string]string{}
+		for _, argument := range durationArguments {
+			values[argument] = format
+		}
+		observedConfig, errs := withCanonicalDurations("test", observing(values))(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+		if len(errs) > 0 {
+			t.Fatalf("%s: unexpected errors %v", format, errs)
+		}
+		for _, argument := range durationArguments {
+			actual, _, _ := unstructured.NestedStringSlice(observedConfig, "extendedArguments", argument)
+			if !reflect.DeepEqual(actual, []string{"1m30s"}) {
+				t.Errorf("%s: expected %s to be [1m30s], got %v", format, argument, actual)
+			}
+		}
+	}
+}
+
+func TestDurationArgumentsKeepValuesOfOtherArguments(t *testing.T) {
+	observedConfig, errs := withCanonicalDurations("test", observing(map[string]string{"cluster-name": "90s", "node-monitor-grace-period": "2m"}))(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	expected := map[string]interface{}{"extendedArguments": map[string]interface{}{
+		"cluster-name":              []interface{}{"90s"},
+		"node-monitor-grace-period": []interface{}{"2m0s"},
+	}}
+	if !reflect.DeepEqual(observedConfig, expected) {
+		t.Errorf("expected %v, got %v", expected, observedConfig)
+	}
+}
+
+func TestInvalidDurationIsAnErrorOfTheObserver(t *testing.T) {
+	timer := newObserverTimer(time.Minute)
+	observe := timer.timed("invalid-duration", observing(map[string]string{"node-monitor-grace-period": "forty seconds"}))
+
+	existingConfig := map[string]interface{}{"extendedArguments": map[string]interface{}{"node-monitor-grace-period": []interface{}{"40s"}}}
+	observedConfig, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), existingConfig)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "observer invalid-duration: extendedArguments.node-monitor-grace-period: ") {
+		t.Errorf("expected the invalid duration to be reported, got %v", errs)
+	}
+	if !reflect.DeepEqual(timer.observerErrors()["invalid-duration"], errs) {
+		t.Errorf("expected the error to be attributed to the observer, got %v", timer.observerErrors())
+	}
+	if actual, _, _ := unstructured.NestedStringSlice(observedConfig, "extendedArguments", "node-monitor-grace-period"); !reflect.DeepEqual(actual, []string{"40s"}) {
+		t.Errorf("expected the previous value to be kept, got %v", actual)
+	}
+
+	// without a previous value the argument is left to the default config
+	observedConfig, _ = observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+	if _, found, _ := unstructured.NestedFieldNoCopy(observedConfig, "extendedArguments", "node-monitor-grace-period"); found {
+		t.Errorf("expected the invalid value to be dropped, got %v", observedConfig)
+	}
+}
+
+func TestNegativeDurationIsRejected(t *testing.T) {
+	observe := newObserverTimer(time.Minute).timed("latency-profile", observing(map[string]string{"node-monitor-grace-period": "-40s"}))
+	_, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+	expected := `observer latency-profile: extendedArguments.node-monitor-grace-period: "-40s" must be a duration of at least 0s`
+	if len(errs) != 1 || errs[0].Error() != expected {
+		t.Errorf("expected %q, got %v", expected, errs)
+	}
+}
+
+func TestObserverErrorsAreSorted(t *testing.T) {
+	observe := newObserverTimer(time.Minute).timed("test", func(configobserver.Listers, events.Recorder, map[string]interface{}) (map[string]interface{}, []error) {
+		return nil, []error{fmt.Errorf("b"), fmt.Errorf("a"), fmt.Errorf("b")}
+	})
+	_, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+	if len(errs) != 2 || errs[0].Error() != "a" || errs[1].Error() != "b" {
+		t.Errorf("expected the errors a and b, got %v", errs)
+	}
+}
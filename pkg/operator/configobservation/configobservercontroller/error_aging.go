@@ -0,0 +1,177 @@
+package configobservercontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	// repeatedWarningInterval is how often an identical observer warning is recorded again.
+	repeatedWarningInterval = 6 * time.Hour
+	// agingRefreshInterval bounds how often the "seen N times" suffix of an unchanged error is written.
+	agingRefreshInterval = 10 * time.Minute
+
+	agingSuffixPrefix = " (since "
+)
+
+// dedupRecorder drops warnings identical to one recorded within the interval. A source object that stays
+// misconfigured makes its observer warn on every resync. Normal events pass through.
+type dedupRecorder struct {
+	events.Recorder
+	state *dedupState
+}
+
+type dedupState struct {
+	interval time.Duration
+	now      func() time.Time
+
+	lock     sync.Mutex
+	recorded map[string]time.Time
+}
+
+func newDedupRecorder(delegate events.Recorder, interval time.Duration, now func() time.Time) events.Recorder {
+	return &dedupRecorder{Recorder: delegate, state: &dedupState{interval: interval, now: now, recorded: map[string]time.Time{}}}
+}
+
+func (r *dedupRecorder) Warning(reason, message string) {
+	if r.state.shouldRecord(r.ComponentName(), reason, message) {
+		r.Recorder.Warning(reason, message)
+	}
+}
+
+func (r *dedupRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *dedupRecorder) ForComponent(componentName string) events.Recorder {
+	return &dedupRecorder{Recorder: r.Recorder.ForComponent(componentName), state: r.state}
+}
+
+func (r *dedupRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &dedupRecorder{Recorder: r.Recorder.WithComponentSuffix(componentNameSuffix), state: r.state}
+}
+
+func (r *dedupRecorder) WithContext(ctx context.Context) events.Recorder {
+	return &dedupRecorder{Recorder: r.Recorder.WithContext(ctx), state: r.state}
+}
+
+func (s *dedupState) shouldRecord(component, reason, message string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	for key, recordedAt := range s.recorded {
+		if now.Sub(recordedAt) >= s.interval {
+			delete(s.recorded, key)
+		}
+	}
+	key := component + "\x00" + reason + "\x00" + message
+	if _, ok := s.recorded[key]; ok {
+		return false
+	}
+	s.recorded[key] = now
+	return true
+}
+
+// agingOperatorClient appends "since <timestamp>, seen N times" to the ConfigObservationDegraded message while the
+// error stays the same. The counter is written at most once per refresh interval, in between a status update that
+// only carries the unchanged error is not sent at all. A different error text starts over.
+type agingOperatorClient struct {
+	v1helpers.OperatorClient
+
+	conditionType   string
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	lock sync.Mutex
+	// message is the error without the suffix, since and count describe how long and how often it was seen
+	message   string
+	since     time.Time
+	count     int
+	writtenAt time.Time
+}
+
+func newAgingOperatorClient(delegate v1helpers.OperatorClient, refreshInterval time.Duration, now func() time.Time) *agingOperatorClient {
+	return &agingOperatorClient{
+		OperatorClient:  delegate,
+		conditionType:   condition.ConfigObservationDegradedConditionType,
+		refreshInterval: refreshInterval,
+		now:             now,
+	}
+}
+
+func (c *agingOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, in *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
+	_, current, _, err := c.GetOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	in = in.DeepCopy()
+	c.age(current.Conditions, in.Conditions)
+	if equality.Semantic.DeepEqual(current, in) {
+		return current, nil
+	}
+	return c.OperatorClient.UpdateOperatorStatus(ctx, resourceVersion, in)
+}
+
+func (c *agingOperatorClient) age(current, updated []operatorv1.OperatorCondition) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	updatedCondition := v1helpers.FindOperatorCondition(updated, c.conditionType)
+	if updatedCondition == nil || updatedCondition.Status != operatorv1.ConditionTrue {
+		c.message = ""
+		return
+	}
+
+	now := c.now()
+	message := stripAging(updatedCondition.Message)
+	currentCondition := v1helpers.FindOperatorCondition(current, c.conditionType)
+	if message != c.message {
+		c.message, c.since, c.count, c.writtenAt = message, now, 0, time.Time{}
+		// after a restart the aging continues from what was written before
+		if currentCondition != nil && currentCondition.Status == operatorv1.ConditionTrue && stripAging(currentCondition.Message) == message {
+			if since, count, ok := parseAging(currentCondition.Message); ok {
+				c.since, c.count, c.writtenAt = since, count, now
+			}
+		}
+	}
+	c.count++
+
+	if currentCondition != nil && stripAging(currentCondition.Message) == message && now.Sub(c.writtenAt) < c.refreshInterval {
+		updatedCondition.Message = currentCondition.Message
+		return
+	}
+	updatedCondition.Message = fmt.Sprintf("%s%s%s, seen %d times)", message, agingSuffixPrefix, c.since.UTC().Format(time.RFC3339), c.count)
+	c.writtenAt = now
+}
+
+func parseAging(message string) (time.Time, int, bool) {
+	suffix := strings.TrimPrefix(message, stripAging(message))
+	var sinceText string
+	var count int
+	if _, err := fmt.Sscanf(suffix, agingSuffixPrefix+"%s seen %d times)", &sinceText, &count); err != nil {
+		return time.Time{}, 0, false
+	}
+	since, err := time.Parse(time.RFC3339, strings.TrimSuffix(sinceText, ","))
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return since, count, true
+}
+
+func stripAging(message string) string {
+	if i := strings.LastIndex(message, agingSuffixPrefix); i >= 0 && strings.HasSuffix(message, " times)") {
+		return message[:i]
+	}
+	return message
+}
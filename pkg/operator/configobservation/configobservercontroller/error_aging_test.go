@@ -0,0 +1,114 @@
+package configobservercontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+// countingOperatorClient counts the status writes that reach the API.
+type countingOperatorClient struct {
+	v1helpers.StaticPodOperatorClient
+	statusUpdates int
+}
+
+func (c *countingOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, in *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
+	c.statusUpdates++
+	return c.StaticPodOperatorClient.UpdateOperatorStatus(ctx, resourceVersion, in)
+}
+
+func TestRepeatedObserverErrorsAreCoalesced(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+
+	errorText := "configmap openshift-config/cloud-provider-config: invalid cloud.conf"
+	failing := func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		recorder.Warningf("ObserveCloudConfig", "%s", errorText)
+		return map[string]interface{}{}, []error{fmt.Errorf("%s", errorText)}
+	}
+
+	delegate := &countingOperatorClient{StaticPodOperatorClient: v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)}
+	inMemory := events.NewInMemoryRecorder("test")
+	recorder := newDedupRecorder(inMemory, repeatedWarningInterval, clock)
+	controller := configobserver.NewConfigObserver(newAgingOperatorClient(delegate, agingRefreshInterval, clock), recorder, configobservation.Listers{}, nil, failing)
+
+	observationDegraded := func() operatorv1.OperatorCondition {
+		_, status, _, err := delegate.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *v1helpers.FindOperatorCondition(status.Conditions, condition.ConfigObservationDegradedConditionType)
+	}
+	syncCtx := factory.NewSyncContext("test", recorder)
+	sync := func() {
+		if err := controller.Sync(context.TODO(), syncCtx); err == nil {
+			t.Fatal("expected the observer error to be returned")
+		}
+		now = now.Add(time.Minute)
+	}
+
+	// 100 resyncs, one per minute
+	sync()
+	firstTransition := observationDegraded().LastTransitionTime
+	for i := 1; i < 100; i++ {
+		sync()
+		if lastTransition := observationDegraded().LastTransitionTime; lastTransition != firstTransition {
+			t.Fatalf("sync %d: lastTransitionTime changed from %v to %v", i, firstTransition, lastTransition)
+		}
+	}
+
+	warnings := func() int {
+		var count int
+		for _, event := range inMemory.Events() {
+			if event.Reason == "ObserveCloudConfig" {
+				count++
+			}
+		}
+		return count
+	}
+	if warnings := warnings(); warnings != 1 {
+		t.Errorf("expected a single warning, got %d", warnings)
+	}
+	// written on the first sync and then once every 10 minutes
+	if delegate.statusUpdates != 10 {
+		t.Errorf("expected 10 status updates, got %d", delegate.statusUpdates)
+	}
+	if expected := errorText + " (since 2024-03-01T12:00:00Z, seen 91 times)"; observationDegraded().Message != expected {
+		t.Errorf("expected message %q, got %q", expected, observationDegraded().Message)
+	}
+
+	// a restarted operator continues counting from the written condition
+	controller = configobserver.NewConfigObserver(newAgingOperatorClient(delegate, agingRefreshInterval, clock), recorder, configobservation.Listers{}, nil, failing)
+	for i := 0; i < 11; i++ {
+		sync()
+	}
+	if expected := errorText + " (since 2024-03-01T12:00:00Z, seen 102 times)"; observationDegraded().Message != expected {
+		t.Errorf("expected message %q, got %q", expected, observationDegraded().Message)
+	}
+
+	// a different error starts over
+	warningsBefore := warnings()
+	errorText = "configmap openshift-config/cloud-provider-config: missing cloud.conf"
+	changedAt := now
+	sync()
+	if expected := fmt.Sprintf("%s (since %s, seen 1 times)", errorText, changedAt.Format(time.RFC3339)); observationDegraded().Message != expected {
+		t.Errorf("expected message %q, got %q", expected, observationDegraded().Message)
+	}
+	if lastTransition := observationDegraded().LastTransitionTime; lastTransition != firstTransition {
+		t.Errorf("expected lastTransitionTime to stay %v, got %v", firstTransition, lastTransition)
+	}
+	if recorded := warnings() - warningsBefore; recorded != 1 {
+		t.Errorf("expected the changed error to be recorded once, got %d warnings", recorded)
+	}
+}
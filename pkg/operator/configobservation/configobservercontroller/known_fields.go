@@ -0,0 +1,27 @@
+package configobservercontroller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+// withKnownFields drops the fields observer returns that are not in configobservation.ObservedConfigFields. Every
+// dropped field is an error of observer naming the closest known field, a misspelled field would otherwise land in the
+// observed config and be dropped silently when the operand configs are rendered.
+func withKnownFields(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+		for _, unknown := range validation.UnknownFields(observedConfig, configobservation.ObservedConfigFields) {
+			unstructured.RemoveNestedField(observedConfig, unknown.Path...)
+			errs = append(errs, fmt.Errorf("observer %s: %w", name, unknown))
+		}
+		return observedConfig, errs
+	}
+}
@@ -0,0 +1,50 @@
+package configobservercontroller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+func TestWithKnownFields(t *testing.T) {
+	observer := func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{
+			"extendedArguments": map[string]interface{}{"cluster-cidr": []interface{}{"10.128.0.0/14"}},
+			"extendedArgument":  map[string]interface{}{"service-cluster-ip-range": []interface{}{"172.30.0.0/16"}},
+			"featureGates":      []interface{}{"OpenShiftPodSecurityAdmission=true"},
+			"servingInfo":       map[string]interface{}{"minTLSVersion": "VersionTLS12", "cipherSuite": []interface{}{"TLS_AES_128_GCM_SHA256"}},
+			"targetconfigcontroller": map[string]interface{}{
+				"proxy": map[string]interface{}{"HTTPS_PROXY": "https://proxy.example.com"},
+			},
+		}, nil
+	}
+
+	config, errs := withKnownFields("test", observer)(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+
+	expectedConfig := map[string]interface{}{
+		"extendedArguments": map[string]interface{}{"cluster-cidr": []interface{}{"10.128.0.0/14"}},
+		"featureGates":      []interface{}{"OpenShiftPodSecurityAdmission=true"},
+		"servingInfo":       map[string]interface{}{"minTLSVersion": "VersionTLS12"},
+		"targetconfigcontroller": map[string]interface{}{
+			"proxy": map[string]interface{}{"HTTPS_PROXY": "https://proxy.example.com"},
+		},
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Errorf("expected the unknown fields to be dropped, got %v", config)
+	}
+	var actualErrs []string
+	for _, err := range errs {
+		actualErrs = append(actualErrs, err.Error())
+	}
+	expectedErrs := []string{
+		"observer test: extendedArgument: is not a known field, did you mean extendedArguments?",
+		"observer test: servingInfo.cipherSuite: is not a known field, did you mean cipherSuites?",
+	}
+	if !reflect.DeepEqual(actualErrs, expectedErrs) {
+		t.Errorf("expected %q, got %q", expectedErrs, actualErrs)
+	}
+}
@@ -1,6 +1,8 @@
 package configobservercontroller
 
 import (
+	"time"
+
 	configv1 "github.com/openshift/api/config/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
@@ -10,33 +12,60 @@ import (
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	libgoapiserver "github.com/openshift/library-go/pkg/operator/configobserver/apiserver"
-	"github.com/openshift/library-go/pkg/operator/configobserver/cloudprovider"
 	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
 	nodeobserver "github.com/openshift/library-go/pkg/operator/configobserver/node"
-	"github.com/openshift/library-go/pkg/operator/configobserver/proxy"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/bindaddress"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/cloud"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/clustername"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/csrsigning"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/kcmfeatures"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/leaderelection"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/network"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/node"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/podgc"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/proxy"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/requestheader"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/serviceca"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/workloadprofile"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
 )
 
-// openShiftOnlyFeatureGates are feature gate names that are only used within
+// OpenShiftOnlyFeatureGates are feature gate names that are only used within
 // OpenShift. Passing these to KCM causes it to log an error on startup.
 // This list is passed to the feature gate config observer as a blacklist,
-// excluding them from the feature gate output passed to KCM.
-var openShiftOnlyFeatureGates = sets.New[configv1.FeatureGateName](
+// excluding them from the feature gate output passed to KCM. render drops
+// them from the bootstrap config likewise.
+var OpenShiftOnlyFeatureGates = sets.New[configv1.FeatureGateName](
 	libgocloudprovider.ExternalCloudProviderFeature,
 )
 
+func init() {
+	// the cluster config the observers read
+	for _, resource := range []string{"apiservers", "featuregates", "infrastructures", "networks", "nodes", "proxies"} {
+		relatedobjects.Register(configv1.ObjectReference{Group: configv1.GroupName, Resource: resource, Name: "cluster"})
+	}
+	relatedobjects.Register(
+		// the cloud config of the user and the one normalized by the cloud config sync, see cloud.NewObserveCloudProviderFunc
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "cloud-provider-config"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-cloud-config"},
+	)
+}
+
 type ConfigObserver struct {
 	factory.Controller
+
+	timer *observerTimer
+}
+
+// ObserverErrors returns the errors every observer reported in its last run, keyed by the observer name.
+func (c *ConfigObserver) ObserverErrors() map[string][]error {
+	return c.timer.observerErrors()
 }
 
 func NewConfigObserver(
@@ -45,6 +74,9 @@ func NewConfigObserver(
 	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
 	resourceSyncer resourcesynccontroller.ResourceSyncer,
 	featureGateAccessor featuregates.FeatureGateAccess,
+	payloadVersion string,
+	operandImagePullSpec string,
+	sourceVersions *SourceVersions,
 	eventRecorder events.Recorder,
 ) (*ConfigObserver, error) {
 
@@ -70,6 +102,21 @@ func NewConfigObserver(
 	for _, ns := range interestingNamespaces {
 		informers = append(informers, kubeInformersForNamespaces.InformersFor(ns).Core().V1().ConfigMaps().Informer())
 	}
+	informers = append(informers,
+		// the front-proxy client certificate is the only secret of openshift-config-managed the observers read
+		namedInformer{
+			Informer: kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Informer(),
+			filter:   factory.NamesFilter(requestheader.FrontProxyClientCertSecretName),
+		},
+		// the csr-signer caps the cluster signing duration
+		kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer(),
+	)
+	if len(operandImagePullSpec) > 0 {
+		informers = append(informers,
+			kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Informer(),
+			configinformers.Config().V1().ClusterOperators().Informer(),
+		)
+	}
 
 	extremeProfileSuppressor, err := nodeobserver.NewSuppressConfigUpdateForExtremeProfilesFunc(
 		operatorClient.(v1helpers.StaticPodOperatorClient),
@@ -87,10 +134,22 @@ func NewConfigObserver(
 		node.LatencyConfigs,
 	)
 
+	timer := newObserverTimer(defaultObserverDeadline)
+	timer.sources = sourceVersions
+	// without the operand image there are no running operands to compare with, e.g. when rendering
+	if len(operandImagePullSpec) > 0 {
+		timer.guard = &versionSkewGuard{
+			podLister:             kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Lister().Pods(operatorclient.TargetNamespace),
+			clusterOperatorLister: configinformers.Config().V1().ClusterOperators().Lister(),
+			operandImagePullSpec:  operandImagePullSpec,
+		}
+	}
 	c := &ConfigObserver{
+		timer: timer,
 		Controller: configobserver.NewConfigObserver(
-			operatorClient,
-			eventRecorder,
+			// an error that persists for days must neither spam events nor churn the condition
+			newAgingOperatorClient(operatorClient, agingRefreshInterval, time.Now),
+			newDedupRecorder(eventRecorder, repeatedWarningInterval, time.Now),
 			configobservation.Listers{
 				FeatureGateLister_:    configinformers.Config().V1().FeatureGates().Lister(),
 				InfrastructureLister_: configinformers.Config().V1().Infrastructures().Lister(),
@@ -101,11 +160,14 @@ func NewConfigObserver(
 
 				ResourceSync:     resourceSyncer,
 				ConfigMapLister_: kubeInformersForNamespaces.ConfigMapLister(),
+				SecretLister_:    kubeInformersForNamespaces.SecretLister(),
 				PreRunCachesSynced: append(configMapPreRunCacheSynced,
 					operatorClient.Informer().HasSynced,
 
 					kubeInformersForNamespaces.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
 					kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer().HasSynced,
+					kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().Secrets().Informer().HasSynced,
+					kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer().HasSynced,
 
 					configinformers.Config().V1().FeatureGates().Informer().HasSynced,
 					configinformers.Config().V1().Infrastructures().Informer().HasSynced,
@@ -115,32 +177,35 @@ func NewConfigObserver(
 				),
 			},
 			informers,
-			cloudprovider.NewCloudProviderObserver(
-				"openshift-kube-controller-manager",
-				false,
-				[]string{"extendedArguments", "cloud-provider"},
-				[]string{"extendedArguments", "cloud-config"},
-				featureGateAccessor,
-			),
+			timer.timed("cloud-provider", cloud.NewObserveCloudProviderFunc(featureGateAccessor)),
 
 			// this is picked up by the kube-controller-manager container
-			featuregates.NewObserveFeatureFlagsFunc(
-				nil,
-				openShiftOnlyFeatureGates,
+			timer.timed("feature-gates", kcmfeatures.NewObserveFeatureGatesFunc(
+				cloud.NewObserveCSIMigrationFeatureGatesFunc(
+					featuregates.NewObserveFeatureFlagsFunc(
+						nil,
+						OpenShiftOnlyFeatureGates,
+						[]string{"extendedArguments", "feature-gates"},
+						featureGateAccessor,
+					),
+					payloadVersion,
+					[]string{"extendedArguments", "feature-gates"},
+				),
 				[]string{"extendedArguments", "feature-gates"},
-				featureGateAccessor,
-			),
+			)),
 
 			// this is picked up by the cluster-policy-controller container
-			featuregates.NewObserveFeatureFlagsFunc(
+			timer.timed("cluster-policy-controller-feature-gates", featuregates.NewObserveFeatureFlagsFunc(
 				nil,
 				nil,
 				[]string{"featureGates"},
 				featureGateAccessor,
-			),
-			network.ObserveClusterCIDRs,
-			network.ObserveServiceClusterIPRanges,
-			nodeobserver.NewLatencyProfileObserver(
+			)),
+			timer.timed("cluster-cidrs", network.ObserveClusterCIDRs),
+			timer.timed("service-cluster-ip-ranges", network.ObserveServiceClusterIPRanges),
+			timer.timed("node-cidr-mask-sizes", network.ObserveNodeCIDRMaskSizes),
+			timer.timed("node-cidr-allocation", network.NewObserveNodeCIDRAllocationFunc(operatorClient)),
+			timer.timed("node-lifecycle", node.NewObserveNodeLifecycleFunc(operatorClient, nodeobserver.NewLatencyProfileObserver(
 				node.LatencyConfigs,
 				[]nodeobserver.ShouldSuppressConfigUpdatesFunc{
 					// for multiple suppressor(s) being called in this observer
@@ -150,14 +215,31 @@ func NewConfigObserver(
 					extremeProfileSuppressor,
 					differentConfigProfileSuppressor,
 				},
-			),
-			proxy.NewProxyObserveFunc([]string{"targetconfigcontroller", "proxy"}),
-			serviceca.ObserveServiceCA,
-			clustername.ObserveInfraID,
-			libgoapiserver.ObserveTLSSecurityProfile,
-			cloud.NewObserveCloudVolumePluginFunc(featureGateAccessor),
+			))),
+			timer.timed("proxy", proxy.NewObserveProxyFunc([]string{"targetconfigcontroller", "proxy"})),
+			timer.timed("service-ca", serviceca.ObserveServiceCA),
+			timer.timed("infra-id", clustername.ObserveInfraID),
+			timer.timed("leader-election", leaderelection.ObserveLeaderElection),
+			timer.timed("requestheader-allowed-names", requestheader.ObserveRequestHeaderAllowedNames),
+			timer.timed("bind-address", bindaddress.NewObserveBindAddressFunc(operatorClient)),
+			timer.timed("terminated-pod-gc-threshold", podgc.NewObserveTerminatedPodGCThresholdFunc(operatorClient)),
+			timer.timed("cluster-signing-duration", csrsigning.NewObserveClusterSigningDurationFunc(operatorClient)),
+			timer.timed("controller-workload-profile", workloadprofile.NewObserveWorkloadProfileFunc(operatorClient)),
+			timer.timed("tls-security-profile", libgoapiserver.ObserveTLSSecurityProfile),
+			timer.timed("cloud-volume-plugin", cloud.NewObserveCloudVolumePluginFunc(featureGateAccessor, payloadVersion)),
 		),
 	}
 
 	return c, nil
 }
+
+// namedInformer passes on the events of the objects the filter accepts only, like the filtered informers of a
+// controller factory.
+type namedInformer struct {
+	factory.Informer
+	filter factory.EventFilterFunc
+}
+
+func (i namedInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	return i.Informer.AddEventHandler(cache.FilteringResourceEventHandler{FilterFunc: i.filter, Handler: handler})
+}
@@ -0,0 +1,197 @@
+package configobservercontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
+)
+
+// SourceVersionsAnnotation on the KubeControllerManager CR lists the version of every config.openshift.io resource the
+// observers read, e.g. "infrastructures=v1,networks=v1".
+const SourceVersionsAnnotation = "operator.openshift.io/config-source-versions"
+
+// supportedSourceVersions are the versions of the config.openshift.io resources the observers can read, preferred
+// first. The listers of the observers are of these versions.
+var supportedSourceVersions = map[string][]string{
+	"apiservers":      {"v1"},
+	"infrastructures": {"v1"},
+	"networks":        {"v1"},
+	"nodes":           {"v1"},
+	"proxies":         {"v1"},
+}
+
+// observerSources are the config.openshift.io resources each observer reads. The feature gates are read through the
+// feature gate accessor, which is not pinned to a version of the lister.
+var observerSources = map[string][]string{
+	"cloud-provider":            {"infrastructures"},
+	"feature-gates":             {"infrastructures"},
+	"cluster-cidrs":             {"networks"},
+	"service-cluster-ip-ranges": {"networks"},
+	"node-cidr-mask-sizes":      {"networks"},
+	"node-cidr-allocation":      {"networks"},
+	"node-lifecycle":            {"nodes"},
+	"proxy":                     {"proxies", "networks", "infrastructures"},
+	"infra-id":                  {"infrastructures"},
+	"leader-election":           {"infrastructures"},
+	"bind-address":              {"infrastructures"},
+	"tls-security-profile":      {"apiservers"},
+	"cloud-volume-plugin":       {"infrastructures"},
+}
+
+// SourceVersions negotiates the versions the observers read their config.openshift.io resources with. A lister of a
+// version the server stopped serving returns stale or no objects without an error, so an observer fails instead when
+// none of the versions it supports is served. The versions are discovered once and again after an observer failed.
+type SourceVersions struct {
+	discovery              discovery.ServerResourcesInterface
+	kubeControllerManagers operatorv1client.KubeControllerManagersGetter
+	supported              map[string][]string
+
+	lock sync.Mutex
+	// versions are the negotiated versions by resource
+	versions map[string]string
+	// reported is the value of SourceVersionsAnnotation last set
+	reported string
+}
+
+func NewSourceVersions(discovery discovery.ServerResourcesInterface, kubeControllerManagers operatorv1client.KubeControllerManagersGetter) *SourceVersions {
+	return &SourceVersions{
+		discovery:              discovery,
+		kubeControllerManagers: kubeControllerManagers,
+		supported:              supportedSourceVersions,
+		versions:               map[string]string{},
+	}
+}
+
+// versioned wraps the observer name so that it fails when a resource it reads is not served in a supported version.
+// It returns its previous result then, like an observer missing its deadline. Nothing is wrapped for a nil
+// SourceVersions, e.g. when rendering from a must-gather without discovery.
+func (s *SourceVersions) versioned(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	resources := observerSources[name]
+	if s == nil || len(resources) == 0 {
+		return observer
+	}
+	var lock sync.Mutex
+	var last map[string]interface{}
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		if err := s.negotiate(resources); err != nil {
+			lock.Lock()
+			previous := last
+			lock.Unlock()
+			if previous == nil {
+				// nothing to fall back to, dropping the observed fields would be worse than a stale lister
+				observedConfig, errs := observer(listers, recorder, existingConfig)
+				return observedConfig, append(errs, err)
+			}
+			return previous, []error{err}
+		}
+
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+		if len(errs) > 0 {
+			// the served versions may have changed since they were discovered
+			s.invalidate(resources)
+		}
+		lock.Lock()
+		last = observedConfig
+		lock.Unlock()
+		return observedConfig, errs
+	}
+}
+
+// negotiate discovers the versions of resources that are not known yet and reports the versions in use.
+func (s *SourceVersions) negotiate(resources []string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var errs []error
+	for _, resource := range resources {
+		if _, ok := s.versions[resource]; ok {
+			continue
+		}
+		version, err := s.discover(resource)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.versions[resource] = version
+	}
+	s.report()
+	return utilerrors.NewAggregate(errs)
+}
+
+// discover returns the most preferred supported version resource is served in.
+func (s *SourceVersions) discover(resource string) (string, error) {
+	supported := s.supported[resource]
+	for _, version := range supported {
+		groupVersion := configv1.GroupName + "/" + version
+		served, err := s.discovery.ServerResourcesForGroupVersion(groupVersion)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to discover %s: %w", groupVersion, err)
+		}
+		for _, apiResource := range served.APIResources {
+			if apiResource.Name == resource {
+				return version, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s.%s is not served in a supported version %s", resource, configv1.GroupName, strings.Join(supported, ", "))
+}
+
+func (s *SourceVersions) invalidate(resources []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, resource := range resources {
+		delete(s.versions, resource)
+	}
+}
+
+// report sets SourceVersionsAnnotation to the negotiated versions when they changed. A failure is retried with the
+// next negotiation.
+func (s *SourceVersions) report() {
+	versions := make([]string, 0, len(s.versions))
+	for resource, version := range s.versions {
+		versions = append(versions, resource+"="+version)
+	}
+	sort.Strings(versions)
+	value := strings.Join(versions, ",")
+	if value == s.reported {
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{SourceVersionsAnnotation: value},
+		},
+	})
+	if err != nil {
+		klog.Warningf("Unable to report the config source versions: %v", err)
+		return
+	}
+	// the observers are called without a context
+	ctx, cancel := compat.WithCallTimeout(context.Background())
+	defer cancel()
+	if _, err := s.kubeControllerManagers.KubeControllerManagers().Patch(ctx, "cluster", types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Warningf("Unable to set %s=%s: %v", SourceVersionsAnnotation, value, err)
+		return
+	}
+	s.reported = value
+}
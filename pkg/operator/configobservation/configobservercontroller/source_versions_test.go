@@ -0,0 +1,164 @@
+package configobservercontroller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeKubeControllerManagers struct {
+	operatorv1client.KubeControllerManagerInterface
+	patches []string
+}
+
+func (f *fakeKubeControllerManagers) KubeControllerManagers() operatorv1client.KubeControllerManagerInterface {
+	return f
+}
+
+func (f *fakeKubeControllerManagers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*operatorv1.KubeControllerManager, error) {
+	f.patches = append(f.patches, string(data))
+	return &operatorv1.KubeControllerManager{}, nil
+}
+
+// discoveryOf returns a discovery serving infrastructures in versions.
+func discoveryOf(versions ...string) *fakediscovery.FakeDiscovery {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	for _, version := range versions {
+		discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{
+			GroupVersion: "config.openshift.io/" + version,
+			APIResources: []metav1.APIResource{{Name: "infrastructures", Kind: "Infrastructure"}},
+		})
+	}
+	return discovery
+}
+
+func TestSourceVersionsNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		supported       []string
+		served          []string
+		expectedVersion string
+		expectedErr     string
+	}{
+		{name: "old only", supported: []string{"v2", "v1"}, served: []string{"v1"}, expectedVersion: "v1"},
+		{name: "new only", supported: []string{"v2", "v1"}, served: []string{"v2"}, expectedVersion: "v2"},
+		{name: "both", supported: []string{"v2", "v1"}, served: []string{"v1", "v2"}, expectedVersion: "v2"},
+		{name: "new only without support", supported: []string{"v1"}, served: []string{"v2"}, expectedErr: "infrastructures.config.openshift.io is not served in a supported version v1"},
+		{name: "none", supported: []string{"v2", "v1"}, expectedErr: "infrastructures.config.openshift.io is not served in a supported version v2, v1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kubeControllerManagers := &fakeKubeControllerManagers{}
+			s := NewSourceVersions(discoveryOf(test.served...), kubeControllerManagers)
+			s.supported = map[string][]string{"infrastructures": test.supported}
+
+			observe := s.versioned("infra-id", func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+				return map[string]interface{}{"infra": "id"}, nil
+			})
+			observedConfig, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+			if !reflect.DeepEqual(observedConfig, map[string]interface{}{"infra": "id"}) {
+				t.Errorf("unexpected observed config %v", observedConfig)
+			}
+
+			if len(test.expectedErr) > 0 {
+				if len(errs) != 1 || errs[0].Error() != test.expectedErr {
+					t.Fatalf("expected error %q, got %v", test.expectedErr, errs)
+				}
+				if len(kubeControllerManagers.patches) != 0 {
+					t.Errorf("expected no versions to be reported, got %v", kubeControllerManagers.patches)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			expectedPatch := `{"metadata":{"annotations":{"operator.openshift.io/config-source-versions":"infrastructures=` + test.expectedVersion + `"}}}`
+			if !reflect.DeepEqual(kubeControllerManagers.patches, []string{expectedPatch}) {
+				t.Errorf("expected the patch %s, got %v", expectedPatch, kubeControllerManagers.patches)
+			}
+		})
+	}
+}
+
+func TestSourceVersionsRefresh(t *testing.T) {
+	discovery := discoveryOf("v1")
+	kubeControllerManagers := &fakeKubeControllerManagers{}
+	s := NewSourceVersions(discovery, kubeControllerManagers)
+
+	var observerErrs []error
+	calls := 0
+	observe := s.versioned("infra-id", func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		calls++
+		return callConfig(calls), observerErrs
+	})
+	listers := configobservation.Listers{}
+	recorder := events.NewInMemoryRecorder("test")
+	discoveries := func() int {
+		return len(discovery.Actions())
+	}
+
+	// the version is discovered once
+	observe(listers, recorder, nil)
+	observe(listers, recorder, nil)
+	if discoveries() != 1 {
+		t.Errorf("expected a single discovery, got %d", discoveries())
+	}
+
+	// a failing observer makes the next call discover again
+	observerErrs = []error{errors.New("infrastructure not found")}
+	observe(listers, recorder, nil)
+	observerErrs = nil
+	if config, errs := observe(listers, recorder, nil); !reflect.DeepEqual(config, callConfig(4)) || len(errs) > 0 {
+		t.Errorf("unexpected result %v, %v", config, errs)
+	}
+	if discoveries() != 2 {
+		t.Errorf("expected the version to be discovered again, got %d discoveries", discoveries())
+	}
+	if len(kubeControllerManagers.patches) != 1 {
+		t.Errorf("expected the unchanged version to be reported once, got %v", kubeControllerManagers.patches)
+	}
+
+	// the server stopped serving v1, the observer fails with its previous result
+	discovery.Resources = discoveryOf("v2").Resources
+	observerErrs = []error{errors.New("infrastructure not found")}
+	observe(listers, recorder, nil)
+	observerErrs = nil
+	config, errs := observe(listers, recorder, nil)
+	if !reflect.DeepEqual(config, callConfig(5)) {
+		t.Errorf("expected the previous result, got %v", config)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "is not served in a supported version v1") {
+		t.Errorf("expected the observer to fail, got %v", errs)
+	}
+	if calls != 5 {
+		t.Errorf("expected the observer not to run without a supported version, it ran %d times", calls)
+	}
+	expectedPatch := `{"metadata":{"annotations":{"operator.openshift.io/config-source-versions":""}}}`
+	if last := kubeControllerManagers.patches[len(kubeControllerManagers.patches)-1]; last != expectedPatch {
+		t.Errorf("expected the patch %s, got %s", expectedPatch, last)
+	}
+}
+
+func TestSourceVersionsNil(t *testing.T) {
+	var s *SourceVersions
+	observe := s.versioned("infra-id", func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return callConfig(1), nil
+	})
+	if config, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil); !reflect.DeepEqual(config, callConfig(1)) || len(errs) > 0 {
+		t.Errorf("unexpected result %v, %v", config, errs)
+	}
+}
@@ -0,0 +1,183 @@
+package configobservercontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+// defaultObserverDeadline is how long a single observer may take before its previous result is used for the current
+// sync. The observers run one after another, so a slow one delays all the others.
+const defaultObserverDeadline = 5 * time.Second
+
+var observerDurationMetric = metrics.NewHistogramVec(&metrics.HistogramOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "config_observer_duration_seconds",
+	Help:           "Time spent in a single config observer",
+	Buckets:        []float64{0.001, 0.01, 0.1, 0.5, 1, 2, 5, 10, 30},
+	StabilityLevel: metrics.ALPHA,
+}, []string{"observer"})
+
+func init() {
+	legacyregistry.MustRegister(observerDurationMetric)
+}
+
+// observerTimer measures the observers of one config observer controller and logs the slowest ones once every
+// observer ran.
+type observerTimer struct {
+	deadline time.Duration
+	// guard withholds the flags the running operands do not know yet, nil when there is nothing to guard
+	guard *versionSkewGuard
+	// sources fails the observers reading a resource that is not served in a supported version, nil without discovery
+	sources *SourceVersions
+
+	lock      sync.Mutex
+	observers int
+	pass      map[string]time.Duration
+	// errs are the errors of the last result of every observer
+	errs map[string][]error
+}
+
+func newObserverTimer(deadline time.Duration) *observerTimer {
+	return &observerTimer{deadline: deadline, pass: map[string]time.Duration{}, errs: map[string][]error{}}
+}
+
+// timed wraps observer so that its duration is measured and that it returns its previous result when it takes
+// longer than the deadline. The slow call keeps running in the background and its result is used by the next sync.
+func (t *observerTimer) timed(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	t.lock.Lock()
+	t.observers++
+	t.lock.Unlock()
+
+	// every observer passes here, so this is also where the fields it observed are checked against the config types,
+	// the durations it observed are made comparable, the versions of its sources are negotiated, the flags it observed
+	// are checked against the running operands and its errors are ordered for the condition message
+	o := &timedObserver{name: name, observe: withSortedErrors(t.guard.guarded(name, t.sources.versioned(name, withCanonicalDurations(name, withKnownFields(name, observer))))), timer: t}
+	return o.observeConfig
+}
+
+// withSortedErrors orders the errors of observer, see validation.Sorted.
+func withSortedErrors(observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+		return observedConfig, validation.Sorted(errs)
+	}
+}
+
+func (t *observerTimer) record(name string, duration time.Duration, errs []error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.errs[name] = errs
+	t.pass[name] = duration
+	if len(t.pass) < t.observers {
+		return
+	}
+
+	var total time.Duration
+	names := make([]string, 0, len(t.pass))
+	for name, duration := range t.pass {
+		total += duration
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return t.pass[names[i]] > t.pass[names[j]] })
+	if len(names) > 3 {
+		names = names[:3]
+	}
+	slowest := make([]string, 0, len(names))
+	for _, name := range names {
+		slowest = append(slowest, fmt.Sprintf("%s=%s", name, t.pass[name]))
+	}
+	klog.V(2).Infof("Config observation took %s, slowest observers: %s", total, strings.Join(slowest, ", "))
+	t.pass = map[string]time.Duration{}
+}
+
+// observerErrors returns the errors of the last result of every observer that reported any.
+func (t *observerTimer) observerErrors() map[string][]error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	errs := map[string][]error{}
+	for name, observerErrs := range t.errs {
+		if len(observerErrs) > 0 {
+			errs[name] = observerErrs
+		}
+	}
+	return errs
+}
+
+type observation struct {
+	config map[string]interface{}
+	errs   []error
+}
+
+type timedObserver struct {
+	name    string
+	observe configobserver.ObserveConfigFunc
+	timer   *observerTimer
+
+	lock sync.Mutex
+	// inFlight is closed once the running call finished, nil when there is none
+	inFlight chan struct{}
+	last     *observation
+}
+
+func (o *timedObserver) observeConfig(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	start := time.Now()
+
+	o.lock.Lock()
+	if o.inFlight == nil {
+		done := make(chan struct{})
+		o.inFlight = done
+		go func() {
+			config, errs := o.observe(listers, recorder, existingConfig)
+			observerDurationMetric.WithLabelValues(o.name).Observe(time.Since(start).Seconds())
+
+			o.lock.Lock()
+			o.last = &observation{config: config, errs: errs}
+			o.inFlight = nil
+			o.lock.Unlock()
+			close(done)
+		}()
+	}
+	done, previous := o.inFlight, o.last
+	o.lock.Unlock()
+
+	if previous == nil {
+		// nothing to fall back to, dropping the observed fields would be worse than waiting
+		<-done
+		return o.result(start)
+	}
+
+	deadline := time.NewTimer(o.timer.deadline)
+	defer deadline.Stop()
+	select {
+	case <-done:
+		return o.result(start)
+	case <-deadline.C:
+		o.timer.record(o.name, time.Since(start), previous.errs)
+		recorder.Warningf("ConfigObserverSlow", "Config observer %s did not finish within %s, reusing its previous result", o.name, o.timer.deadline)
+		return previous.config, previous.errs
+	}
+}
+
+func (o *timedObserver) result(start time.Time) (map[string]interface{}, []error) {
+	o.lock.Lock()
+	last := o.last
+	o.lock.Unlock()
+
+	o.timer.record(o.name, time.Since(start), last.errs)
+	return last.config, last.errs
+}
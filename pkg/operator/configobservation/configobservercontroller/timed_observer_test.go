@@ -0,0 +1,117 @@
+package configobservercontroller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+func TestTimedObserverReusesPreviousResultWhenSlow(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	slow := func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		calls++
+		if calls > 1 {
+			<-release
+		}
+		return callConfig(calls), nil
+	}
+
+	observedBefore, err := testutil.GetHistogramMetricCount(observerDurationMetric.WithLabelValues("slow-test-observer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := newObserverTimer(50 * time.Millisecond)
+	observe := timer.timed("slow-test-observer", slow)
+	listers := configobservation.Listers{}
+	recorder := events.NewInMemoryRecorder("test")
+
+	// the first call has nothing to fall back to and must not be cut short
+	if config, _ := observe(listers, recorder, nil); !reflect.DeepEqual(config, callConfig(1)) {
+		t.Fatalf("unexpected first result %v", config)
+	}
+
+	// the second call exceeds the deadline and reuses the first result
+	if config, _ := observe(listers, recorder, nil); !reflect.DeepEqual(config, callConfig(1)) {
+		t.Fatalf("expected the previous result to be reused, got %v", config)
+	}
+	var warned bool
+	for _, event := range recorder.Events() {
+		if event.Reason == "ConfigObserverSlow" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a ConfigObserverSlow warning")
+	}
+
+	// the slow call finishes in the background, the next sync picks up its result without starting another call
+	close(release)
+	if !waitFor(func() bool {
+		count, err := testutil.GetHistogramMetricCount(observerDurationMetric.WithLabelValues("slow-test-observer"))
+		return err == nil && count == observedBefore+2
+	}) {
+		t.Fatal("expected two observed durations")
+	}
+	if config, _ := observe(listers, recorder, nil); !reflect.DeepEqual(config, callConfig(3)) {
+		t.Fatalf("expected a fresh result, got %v", config)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls of the observer, got %d", calls)
+	}
+}
+
+func TestTimedObserverWaitsForInFlightCall(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	slow := func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		calls++
+		if calls == 2 {
+			<-release
+		}
+		return callConfig(calls), nil
+	}
+
+	timer := newObserverTimer(20 * time.Millisecond)
+	observe := timer.timed("in-flight-test-observer", slow)
+	listers := configobservation.Listers{}
+	recorder := events.NewInMemoryRecorder("test")
+
+	observe(listers, recorder, nil)
+	// times out, call 2 stays in flight
+	observe(listers, recorder, nil)
+	// still in flight: no new call is started and the previous result is reused again
+	if config, _ := observe(listers, recorder, nil); !reflect.DeepEqual(config, callConfig(1)) {
+		t.Fatalf("expected the previous result to be reused, got %v", config)
+	}
+	close(release)
+	if !waitFor(func() bool {
+		config, _ := observe(listers, recorder, nil)
+		return reflect.DeepEqual(config, callConfig(2)) || reflect.DeepEqual(config, callConfig(3))
+	}) {
+		t.Fatal("expected the in-flight result to be picked up")
+	}
+}
+
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// callConfig is the observed config of the given call of a test observer.
+func callConfig(call int) map[string]interface{} {
+	return map[string]interface{}{"extendedArguments": map[string]interface{}{"call": call}}
+}
@@ -0,0 +1,131 @@
+package configobservercontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// flagsIntroducedIn are the extendedArguments of kube-controller-manager the observers may render, that older
+// kube-controller-manager releases reject at startup, keyed by the MAJOR.MINOR release that introduced them. Add a
+// flag here when an observer starts rendering a flag that is younger than the oldest release an upgrade starts from.
+var flagsIntroducedIn = map[string]string{
+	"concurrent-horizontal-pod-autoscaler-syncs":   "1.26",
+	"legacy-service-account-token-clean-up-period": "1.28",
+}
+
+// versionSkewGuard withholds the flags of flagsIntroducedIn from the observed config while an operand pod still runs a
+// kube-controller-manager release older than the flag. During an upgrade the nodes at an old revision run the old
+// image until the installer reaches them, a flag only the new image knows crashloops them if they restart.
+type versionSkewGuard struct {
+	podLister corev1listers.PodNamespaceLister
+	// clusterOperatorLister reads the operand version the operator reported, the version all operands ran the last
+	// time they ran the same image
+	clusterOperatorLister configlistersv1.ClusterOperatorLister
+	// operandImagePullSpec is the image the operator renders, as set in the environment of the operator
+	operandImagePullSpec string
+}
+
+// guarded wraps observer so that the flags too new for the oldest running operand are withheld. Every withheld flag
+// is an error of observer until the operand image is updated. A nil guard leaves observer unchanged.
+func (g *versionSkewGuard) guarded(name string, observer configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	if g == nil {
+		return observer
+	}
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := observer(listers, recorder, existingConfig)
+		extendedArguments, _, _ := unstructured.NestedMap(observedConfig, "extendedArguments")
+		var gated []string
+		for argument := range extendedArguments {
+			if _, ok := flagsIntroducedIn[argument]; ok {
+				gated = append(gated, argument)
+			}
+		}
+		if len(gated) == 0 {
+			return observedConfig, errs
+		}
+		sort.Strings(gated)
+
+		node, kubeRelease, err := g.oldestOperand()
+		if err != nil {
+			return observedConfig, append(errs, fmt.Errorf("observer %s: failed to determine the operand version: %w", name, err))
+		}
+		if len(node) == 0 {
+			return observedConfig, errs
+		}
+		for _, argument := range gated {
+			introducedIn := flagsIntroducedIn[argument]
+			if len(kubeRelease) > 0 && release.Compare(introducedIn, kubeRelease) <= 0 {
+				continue
+			}
+			running := "a kube-controller-manager of unknown version"
+			if len(kubeRelease) > 0 {
+				running = "kube-controller-manager " + kubeRelease
+			}
+			unstructured.RemoveNestedField(observedConfig, "extendedArguments", argument)
+			errs = append(errs, fmt.Errorf("observer %s: deferring extendedArguments.%s introduced in kube-controller-manager %s until the operand image is updated, node %s runs %s", name, argument, introducedIn, node, running))
+		}
+		return observedConfig, errs
+	}
+}
+
+// oldestOperand returns the first node whose operand does not run the rendered image and the MAJOR.MINOR release of
+// its kube-controller-manager. An empty node means every operand runs the rendered image, the release the flags are
+// written for. The operand version of the cluster operator is only updated once all operands run the rendered image,
+// until then it is the version of the operands not updated yet. An empty release means the operand version is not
+// known, which is treated as older than every release.
+func (g *versionSkewGuard) oldestOperand() (string, string, error) {
+	pods, err := g.podLister.List(labels.SelectorFromSet(labels.Set{"app": "kube-controller-manager"}))
+	if err != nil {
+		return "", "", err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Spec.NodeName < pods[j].Spec.NodeName })
+
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if container.Name != "kube-controller-manager" || container.Image == g.operandImagePullSpec {
+				continue
+			}
+			kubeRelease, err := g.reportedRelease()
+			if err != nil {
+				return "", "", err
+			}
+			return pod.Spec.NodeName, kubeRelease, nil
+		}
+	}
+	return "", "", nil
+}
+
+// reportedRelease returns the MAJOR.MINOR release of the kube-controller-manager operand version in the status of the
+// cluster operator, empty when none is reported yet.
+func (g *versionSkewGuard) reportedRelease() (string, error) {
+	clusterOperator, err := g.clusterOperatorLister.Get("kube-controller-manager")
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, version := range clusterOperator.Status.Versions {
+		if version.Name != "kube-controller-manager" {
+			continue
+		}
+		// the operand versions of other products, like the OpenShift release of the payload, are not
+		// kube-controller-manager releases
+		if kubeRelease, ok := release.Minor(version.Version); ok && strings.HasPrefix(kubeRelease, "1.") {
+			return kubeRelease, nil
+		}
+	}
+	return "", nil
+}
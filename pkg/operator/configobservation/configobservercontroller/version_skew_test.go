@@ -0,0 +1,117 @@
+package configobservercontroller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+const currentOperandImage = "quay.io/openshift/origin-hyperkube@sha256:new"
+
+func TestVersionSkewGuard(t *testing.T) {
+	tests := []struct {
+		name            string
+		images          map[string]string
+		reportedVersion string
+		expectedFlag    bool
+		expectedErrors  []string
+	}{
+		{
+			name:         "aligned",
+			images:       map[string]string{"master-0": currentOperandImage, "master-1": currentOperandImage},
+			expectedFlag: true,
+		},
+		{
+			name:            "skewed",
+			images:          map[string]string{"master-0": currentOperandImage, "master-1": "quay.io/openshift/origin-hyperkube@sha256:old"},
+			reportedVersion: "1.27.4",
+			expectedErrors:  []string{"observer test: deferring extendedArguments.legacy-service-account-token-clean-up-period introduced in kube-controller-manager 1.28 until the operand image is updated, node master-1 runs kube-controller-manager 1.27"},
+		},
+		{
+			name:            "skewed, but new enough",
+			images:          map[string]string{"master-0": currentOperandImage, "master-1": "quay.io/openshift/origin-hyperkube@sha256:old"},
+			reportedVersion: "1.28.2",
+			expectedFlag:    true,
+		},
+		{
+			name:           "skewed to an unknown version",
+			images:         map[string]string{"master-0": "quay.io/openshift/origin-hyperkube@sha256:old", "master-1": currentOperandImage},
+			expectedErrors: []string{"node master-0 runs a kube-controller-manager of unknown version"},
+		},
+		{
+			name:            "skewed to the version of another product",
+			images:          map[string]string{"master-0": "quay.io/openshift/origin-hyperkube@sha256:old"},
+			reportedVersion: "4.15.3",
+			expectedErrors:  []string{"node master-0 runs a kube-controller-manager of unknown version"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for node, image := range test.images {
+				if err := indexer.Add(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager-" + node, Namespace: "openshift-kube-controller-manager", Labels: map[string]string{"app": "kube-controller-manager"}},
+					Spec: corev1.PodSpec{
+						NodeName: node,
+						Containers: []corev1.Container{
+							{Name: "kube-controller-manager", Image: image},
+							{Name: "cluster-policy-controller", Image: "quay.io/openshift/origin-cluster-policy-controller:v1.20.0"},
+						},
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clusterOperators := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if len(test.reportedVersion) > 0 {
+				if err := clusterOperators.Add(&configv1.ClusterOperator{
+					ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager"},
+					Status: configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{
+						{Name: "operator", Version: "4.16.0"},
+						{Name: "kube-controller-manager", Version: test.reportedVersion},
+					}},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			guard := &versionSkewGuard{
+				podLister:             corev1listers.NewPodLister(indexer).Pods("openshift-kube-controller-manager"),
+				clusterOperatorLister: configlistersv1.NewClusterOperatorLister(clusterOperators),
+				operandImagePullSpec:  currentOperandImage,
+			}
+
+			observe := guard.guarded("test", observing(map[string]string{
+				"legacy-service-account-token-clean-up-period": "8760h0m0s",
+				"node-monitor-grace-period":                    "40s",
+			}))
+			observedConfig, errs := observe(configobservation.Listers{}, events.NewInMemoryRecorder("test"), nil)
+
+			if _, found, _ := unstructured.NestedStringSlice(observedConfig, "extendedArguments", "legacy-service-account-token-clean-up-period"); found != test.expectedFlag {
+				t.Errorf("expected the gated flag to be rendered %v, got %v", test.expectedFlag, found)
+			}
+			if _, found, _ := unstructured.NestedStringSlice(observedConfig, "extendedArguments", "node-monitor-grace-period"); !found {
+				t.Errorf("expected the ungated flag to be rendered")
+			}
+			if len(errs) != len(test.expectedErrors) {
+				t.Fatalf("expected %d errors, got %v", len(test.expectedErrors), errs)
+			}
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), test.expectedErrors[i]) {
+					t.Errorf("expected an error containing %q, got %q", test.expectedErrors[i], err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package csrsigning
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+// DurationOverrideField of the unsupportedConfigOverrides sets the validity of the certificates the
+// kube-controller-manager signs for CSRs, e.g. 24h. Without it --cluster-signing-duration is the 720h of the default
+// config.
+const DurationOverrideField = "clusterSigningDuration"
+
+// minDuration is the shortest accepted signing duration, the kubelets renew their certificates at 70-90% of their
+// validity and shorter ones would make them renew all the time.
+const minDuration = time.Hour
+
+var durationPath = []string{"extendedArguments", "cluster-signing-duration"}
+
+func init() {
+	overrides.Register(DurationOverrideField)
+}
+
+// NewObserveClusterSigningDurationFunc renders --cluster-signing-duration from DurationOverrideField. The duration
+// must not exceed the validity of the csr-signer CA, the certificates would outlive the CA they were signed with. An
+// invalid value or an unreadable csr-signer keeps the previously observed duration.
+func NewObserveClusterSigningDurationFunc(operator overrides.SpecGetter) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		listers := genericListers.(configobservation.Listers)
+
+		previouslyObservedConfig := map[string]interface{}{}
+		current, _, _ := unstructured.NestedStringSlice(existingConfig, durationPath...)
+		if len(current) > 0 {
+			if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, durationPath...); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		value, ok, err := overrides.String(unsupportedConfigOverrides, DurationOverrideField)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !ok {
+			if len(current) > 0 {
+				recorder.Eventf("ObserveClusterSigningDuration", "Cluster signing duration changed from %s to the default", current[0])
+			}
+			return map[string]interface{}{}, nil
+		}
+
+		maxDuration, err := signerValidity(listers)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		duration, err := validation.DurationBetween(overrides.Path(DurationOverrideField), value, minDuration, maxDuration)
+		if err != nil {
+			recorder.Warningf("ObserveClusterSigningDuration", "Keeping the previous cluster signing duration: %v", err)
+			return previouslyObservedConfig, []error{err}
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{duration.String()}, durationPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		switch {
+		case len(current) == 0:
+			recorder.Eventf("ObserveClusterSigningDuration", "Cluster signing duration changed from the default to %s", duration)
+		case current[0] != duration.String():
+			recorder.Eventf("ObserveClusterSigningDuration", "Cluster signing duration changed from %s to %s", current[0], duration)
+		}
+		return observedConfig, nil
+	}
+}
+
+// signerValidity returns the validity of the csr-signer CA the kube-controller-manager signs with.
+func signerValidity(listers configobservation.Listers) (time.Duration, error) {
+	secret, err := listers.SecretLister().Secrets(operatorclient.OperatorNamespace).Get("csr-signer")
+	if err != nil {
+		return 0, fmt.Errorf("unable to check the cluster signing duration against the csr-signer: %w", err)
+	}
+	certs, err := crypto.CertsFromPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return 0, fmt.Errorf("unable to check the cluster signing duration against the csr-signer: %w", err)
+	}
+	return certs[0].NotAfter.Sub(certs[0].NotBefore), nil
+}
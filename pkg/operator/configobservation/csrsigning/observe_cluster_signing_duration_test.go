@@ -0,0 +1,196 @@
+package csrsigning
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func durationConfig(duration string) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"cluster-signing-duration": []interface{}{duration},
+		},
+	}
+}
+
+// signerListers returns listers with a csr-signer CA valid for lifetime, none without a lifetime.
+func signerListers(t *testing.T, lifetime time.Duration) configobservation.Listers {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if lifetime > 0 {
+		ca, err := crypto.MakeSelfSignedCAConfigForDuration("kube-csr-signer", lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPEM, keyPEM, err := ca.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Add(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-kube-controller-manager-operator", Name: "csr-signer"},
+			Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return configobservation.Listers{SecretLister_: corelistersv1.NewSecretLister(indexer)}
+}
+
+func TestObserveClusterSigningDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		overrides      map[string]interface{}
+		signerLifetime time.Duration
+		input          map[string]interface{}
+		expected       map[string]interface{}
+		expectedError  string
+		expectedEvent  string
+	}{
+		{
+			name:           "no override",
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          map[string]interface{}{},
+			expected:       map[string]interface{}{},
+		},
+		{
+			name:           "override removed",
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          durationConfig("24h0m0s"),
+			expected:       map[string]interface{}{},
+			expectedEvent:  "Cluster signing duration changed from 24h0m0s to the default",
+		},
+		{
+			name:           "shorter",
+			overrides:      map[string]interface{}{DurationOverrideField: "24h"},
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          map[string]interface{}{},
+			expected:       durationConfig("24h0m0s"),
+			expectedEvent:  "Cluster signing duration changed from the default to 24h0m0s",
+		},
+		{
+			name:           "changed",
+			overrides:      map[string]interface{}{DurationOverrideField: "90m"},
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          durationConfig("24h0m0s"),
+			expected:       durationConfig("1h30m0s"),
+			expectedEvent:  "Cluster signing duration changed from 24h0m0s to 1h30m0s",
+		},
+		{
+			name:           "unchanged",
+			overrides:      map[string]interface{}{DurationOverrideField: "24h"},
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          durationConfig("24h0m0s"),
+			expected:       durationConfig("24h0m0s"),
+		},
+		{
+			name:           "longer with a longer lived signer",
+			overrides:      map[string]interface{}{DurationOverrideField: "2160h"},
+			signerLifetime: 365 * 24 * time.Hour,
+			input:          map[string]interface{}{},
+			expected:       durationConfig("2160h0m0s"),
+			expectedEvent:  "Cluster signing duration changed from the default to 2160h0m0s",
+		},
+		{
+			name:           "too short",
+			overrides:      map[string]interface{}{DurationOverrideField: "59m"},
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          durationConfig("24h0m0s"),
+			expected:       durationConfig("24h0m0s"),
+			expectedError:  `spec.unsupportedConfigOverrides.clusterSigningDuration: "59m" must be a duration between 1h0m0s and 720h0m1s`,
+			expectedEvent:  `Keeping the previous cluster signing duration: spec.unsupportedConfigOverrides.clusterSigningDuration: "59m" must be a duration between 1h0m0s and 720h0m1s`,
+		},
+		{
+			name:           "not a duration",
+			overrides:      map[string]interface{}{DurationOverrideField: "30d"},
+			signerLifetime: 30 * 24 * time.Hour,
+			input:          map[string]interface{}{},
+			expected:       map[string]interface{}{},
+			expectedError:  `spec.unsupportedConfigOverrides.clusterSigningDuration: "30d" must be a duration between 1h0m0s and 720h0m1s`,
+			expectedEvent:  `Keeping the previous cluster signing duration: spec.unsupportedConfigOverrides.clusterSigningDuration: "30d" must be a duration between 1h0m0s and 720h0m1s`,
+		},
+		{
+			name:          "no signer",
+			overrides:     map[string]interface{}{DurationOverrideField: "24h"},
+			input:         durationConfig("48h0m0s"),
+			expected:      durationConfig("48h0m0s"),
+			expectedError: `unable to check the cluster signing duration against the csr-signer: secret "csr-signer" not found`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := events.NewInMemoryRecorder("test")
+			observe := NewObserveClusterSigningDurationFunc(fakeOperator(test.overrides))
+			result, errs := observe(signerListers(t, test.signerLifetime), recorder, test.input)
+			if len(test.expectedError) == 0 && len(errs) > 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+			if len(test.expectedError) > 0 && (len(errs) != 1 || errs[0].Error() != test.expectedError) {
+				t.Errorf("expected %q, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+
+			var expectedMessages, messages []string
+			if len(test.expectedEvent) > 0 {
+				expectedMessages = []string{test.expectedEvent}
+			}
+			for _, event := range recorder.Events() {
+				messages = append(messages, event.Message)
+			}
+			if !reflect.DeepEqual(expectedMessages, messages) {
+				t.Errorf("expected the events %q, got %q", expectedMessages, messages)
+			}
+		})
+	}
+}
+
+func TestObserveClusterSigningDurationCappedBySigner(t *testing.T) {
+	for _, signerLifetime := range []time.Duration{12 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour} {
+		listers := signerListers(t, signerLifetime)
+		validity, err := signerValidity(listers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for duration, accepted := range map[time.Duration]bool{
+			validity:                true,
+			validity + time.Second:  false,
+			validity + 24*time.Hour: false,
+			validity - 24*time.Hour: validity-24*time.Hour >= time.Hour,
+			signerLifetime / 2:      true,
+			signerLifetime * 2:      false,
+		} {
+			observe := NewObserveClusterSigningDurationFunc(fakeOperator{DurationOverrideField: duration.String()})
+			result, errs := observe(listers, events.NewInMemoryRecorder("test"), map[string]interface{}{})
+			if accepted != (len(errs) == 0) {
+				t.Errorf("signer valid for %s: expected %s to be accepted: %v, got %v", validity, duration, accepted, errs)
+			}
+			if accepted && !reflect.DeepEqual(durationConfig(duration.String()), result) {
+				t.Errorf("signer valid for %s: expected %s, got %v", validity, duration, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package configobservation
+
+import (
+	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
+	openshiftcontrolplanev1 "github.com/openshift/api/openshiftcontrolplane/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+// targetConfigControllerConfig is the part of the observed config the target config controller reads itself instead
+// of passing it on to an operand.
+type targetConfigControllerConfig struct {
+	TargetConfigController struct {
+		Proxy map[string]string `json:"proxy"`
+	} `json:"targetconfigcontroller"`
+}
+
+// ObservedConfigFields are the fields an observer may set. The observed config is merged into the config of
+// kube-controller-manager and into the config of cluster-policy-controller, and each merge drops the fields its config
+// type does not have. A field neither has is a typo that does nothing.
+var ObservedConfigFields = validation.FieldsOf(
+	kubecontrolplanev1.KubeControllerManagerConfig{},
+	openshiftcontrolplanev1.OpenShiftControllerManagerConfig{},
+	targetConfigControllerConfig{},
+)
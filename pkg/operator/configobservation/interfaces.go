@@ -18,6 +18,7 @@ type Listers struct {
 	NodeLister_           configlistersv1.NodeLister
 	ProxyLister_          configlistersv1.ProxyLister
 	ConfigMapLister_      corev1listers.ConfigMapLister
+	SecretLister_         corev1listers.SecretLister
 	APIServerLister_      configlistersv1.APIServerLister
 
 	ResourceSync       resourcesynccontroller.ResourceSyncer
@@ -52,6 +53,10 @@ func (l Listers) ConfigMapLister() corev1listers.ConfigMapLister {
 	return l.ConfigMapLister_
 }
 
+func (l Listers) SecretLister() corev1listers.SecretLister {
+	return l.SecretLister_
+}
+
 func (l Listers) APIServerLister() configlistersv1.APIServerLister {
 	return l.APIServerLister_
 }
@@ -0,0 +1,62 @@
+package kcmfeatures
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// NewObserveFeatureGatesFunc wraps observeFeatureGates, which writes the feature gates of the FeatureGate CR into
+// configPath, and drops the gates removed from the kube-controller-manager. It fails to start with them, e.g. with a
+// gate removed upstream that is still listed by the FeatureGate CR after an upgrade.
+func NewObserveFeatureGatesFunc(observeFeatureGates configobserver.ObserveConfigFunc, configPath []string) configobserver.ObserveConfigFunc {
+	return (&featureGates{
+		observeFeatureGates: observeFeatureGates,
+		configPath:          configPath,
+		dropped:             sets.New[string](),
+	}).ObserveFeatureGates
+}
+
+type featureGates struct {
+	observeFeatureGates configobserver.ObserveConfigFunc
+	configPath          []string
+	// dropped are the gates dropped by the last observation, a warning is recorded when they change
+	dropped sets.Set[string]
+}
+
+// ObserveFeatureGates drops the "<name>=<enabled>" entries of the observed feature gates whose name is Removed and
+// records a warning event naming them.
+func (o *featureGates) ObserveFeatureGates(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	observedConfig, errs := o.observeFeatureGates(genericListers, recorder, existingConfig)
+	gates, found, err := unstructured.NestedStringSlice(observedConfig, o.configPath...)
+	if err != nil {
+		return observedConfig, append(errs, err)
+	}
+	if !found {
+		return observedConfig, errs
+	}
+
+	kept := []string{}
+	dropped := sets.New[string]()
+	for _, gate := range gates {
+		name, _, _ := strings.Cut(gate, "=")
+		if Removed(name) {
+			dropped.Insert(name)
+			continue
+		}
+		kept = append(kept, gate)
+	}
+	if dropped.Len() > 0 && !dropped.Equal(o.dropped) {
+		recorder.Warningf("ObserveFeatureGatesDropped", "Dropping the feature gates removed from the kube-controller-manager %s: %s", bundledRelease, strings.Join(sets.List(dropped), ", "))
+	}
+	o.dropped = dropped
+
+	if err := unstructured.SetNestedStringSlice(observedConfig, kept, o.configPath...); err != nil {
+		errs = append(errs, err)
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,82 @@
+package kcmfeatures
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestObserveFeatureGates(t *testing.T) {
+	featureGateAccessor := featuregates.NewHardcodedFeatureGateAccess(
+		[]configv1.FeatureGateName{"NodeSwap", "OpenShiftPodSecurityAdmission", "ContextualLogging", "KMSv1", "DownwardAPIHugePages"},
+		[]configv1.FeatureGateName{"MemoryQoS", "AddedUpstream", "SeccompDefault"},
+	)
+	configPath := []string{"extendedArguments", "feature-gates"}
+	observe := NewObserveFeatureGatesFunc(featuregates.NewObserveFeatureFlagsFunc(nil, nil, configPath, featureGateAccessor), configPath)
+
+	expected := map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"feature-gates": []interface{}{"AddedUpstream=false", "ContextualLogging=true", "KMSv1=true", "MemoryQoS=false", "NodeSwap=true", "OpenShiftPodSecurityAdmission=true"},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		recorder := events.NewInMemoryRecorder("test")
+		result, errs := observe(nil, recorder, map[string]interface{}{})
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("expected %v, got %v", expected, result)
+		}
+
+		var warnings []string
+		for _, event := range recorder.Events() {
+			if event.Reason == "ObserveFeatureGatesDropped" {
+				warnings = append(warnings, event.Message)
+			}
+		}
+		// the dropped gates are only recorded when they change
+		expectedWarnings := []string{"Dropping the feature gates removed from the kube-controller-manager 1.29: DownwardAPIHugePages, SeccompDefault"}
+		if i > 0 {
+			expectedWarnings = nil
+		}
+		if !reflect.DeepEqual(expectedWarnings, warnings) {
+			t.Errorf("observation %d: expected the warnings %q, got %q", i, expectedWarnings, warnings)
+		}
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	for name, removed := range map[string]bool{
+		// removed upstream
+		"DownwardAPIHugePages": true,
+		"EphemeralContainers":  true,
+		// k8s.io/kubernetes/pkg/features
+		"NodeSwap": false,
+		// k8s.io/apiserver/pkg/features
+		"KMSv1": false,
+		// k8s.io/component-base
+		"ContextualLogging": false,
+		// used within OpenShift only
+		"OpenShiftPodSecurityAdmission": false,
+		"ExternalCloudProvider":         false,
+		// not known to this operator yet
+		"AddedUpstream": false,
+	} {
+		if Removed(name) != removed {
+			t.Errorf("expected Removed(%q) to be %v", name, removed)
+		}
+	}
+}
+
+func TestRemovedIn(t *testing.T) {
+	if removedIn("DownwardAPIHugePages", "1.28") {
+		t.Error("expected DownwardAPIHugePages to be accepted by kube-controller-manager 1.28")
+	}
+	if !removedIn("DownwardAPIHugePages", "1.30") {
+		t.Error("expected DownwardAPIHugePages to be removed from kube-controller-manager 1.30")
+	}
+}
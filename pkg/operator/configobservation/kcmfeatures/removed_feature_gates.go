@@ -0,0 +1,54 @@
+package kcmfeatures
+
+import (
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// bundledRelease is the Kubernetes minor release of the kube-controller-manager shipped with this operator, the one of
+// the vendored k8s.io modules.
+const bundledRelease = "1.29"
+
+// removedFeatureGates are the feature gates removed upstream and the first kube-controller-manager release failing to
+// start with them. The FeatureGate CR of an older release may still list them after an upgrade. Every other gate is
+// passed on: the gates of OpenShift and the ones added upstream are not known to this operator, but accepted by the
+// kube-controller-manager of the payload. Add the gates removed upstream when bumping bundledRelease,
+// TestRemovedFeatureGatesAreNotBundled enforces that they are gone from the bundled release.
+var removedFeatureGates = map[string]string{
+	"CSIInlineVolume":                    "1.27",
+	"CSIMigration":                       "1.27",
+	"DaemonSetUpdateSurge":               "1.27",
+	"EphemeralContainers":                "1.27",
+	"ExpandCSIVolumes":                   "1.27",
+	"ExpandInUsePersistentVolumes":       "1.27",
+	"ExpandPersistentVolumes":            "1.27",
+	"IdentifyPodOS":                      "1.27",
+	"LocalStorageCapacityIsolation":      "1.27",
+	"NetworkPolicyEndPort":               "1.27",
+	"StatefulSetMinReadySeconds":         "1.27",
+	"DelegateFSGroupToCSIDriver":         "1.28",
+	"DevicePlugins":                      "1.28",
+	"EndpointSliceTerminatingCondition":  "1.28",
+	"KubeletCredentialProviders":         "1.28",
+	"MixedProtocolLBService":             "1.28",
+	"ServiceInternalTrafficPolicy":       "1.28",
+	"ServiceIPStaticSubrange":            "1.28",
+	"WindowsHostProcessContainers":       "1.28",
+	"CSIMigrationvSphere":                "1.29",
+	"DownwardAPIHugePages":               "1.29",
+	"GRPCContainerProbe":                 "1.29",
+	"JobMutableNodeSchedulingDirectives": "1.29",
+	"OpenAPIV3":                          "1.29",
+	"SeccompDefault":                     "1.29",
+	"TopologyManager":                    "1.29",
+}
+
+// removedIn returns whether the kube-controller-manager of kubeRelease no longer accepts the feature gate name.
+func removedIn(name, kubeRelease string) bool {
+	removed, ok := removedFeatureGates[name]
+	return ok && release.Compare(removed, kubeRelease) <= 0
+}
+
+// Removed returns whether the kube-controller-manager of the payload no longer accepts the feature gate name.
+func Removed(name string) bool {
+	return removedIn(name, bundledRelease)
+}
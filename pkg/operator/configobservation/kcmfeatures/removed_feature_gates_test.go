@@ -0,0 +1,114 @@
+package kcmfeatures
+
+import (
+	"bufio"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	_ "k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/component-base/featuregate"
+	logsapi "k8s.io/component-base/logs/api/v1"
+	metricsfeatures "k8s.io/component-base/metrics/features"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// readKubeFeatureGates returns the release and the gates of testdata/kube-feature-gates.txt.
+func readKubeFeatureGates(t *testing.T) (string, sets.Set[string]) {
+	file, err := os.Open("testdata/kube-feature-gates.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var kubeRelease string
+	gates := sets.New[string]()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(line) == 0 || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "release "):
+			kubeRelease = strings.TrimPrefix(line, "release ")
+		default:
+			gates.Insert(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return kubeRelease, gates
+}
+
+// vendoredFeatureGates returns the gates of the vendored registries the kube-controller-manager registers: the generic
+// apiserver gates and the component-base logging and metrics gates.
+func vendoredFeatureGates(t *testing.T) sets.Set[string] {
+	componentBase := featuregate.NewFeatureGate()
+	if err := logsapi.AddFeatureGates(componentBase); err != nil {
+		t.Fatal(err)
+	}
+	if err := metricsfeatures.AddFeatureGates(componentBase); err != nil {
+		t.Fatal(err)
+	}
+	gates := sets.New[string]()
+	for _, gate := range []featuregate.MutableFeatureGate{utilfeature.DefaultMutableFeatureGate, componentBase} {
+		for name := range gate.GetAll() {
+			gates.Insert(string(name))
+		}
+	}
+	return gates
+}
+
+// TestBundledReleaseIsVendored fails when the vendored Kubernetes modules are bumped without bumping bundledRelease
+// and reviewing removedFeatureGates and testdata/kube-feature-gates.txt.
+func TestBundledReleaseIsVendored(t *testing.T) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("no build info")
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != "k8s.io/component-base" {
+			continue
+		}
+		// the staging modules of Kubernetes 1.x are tagged v0.x
+		parts := strings.SplitN(strings.TrimPrefix(dep.Version, "v0."), ".", 2)
+		if len(parts) != 2 || !strings.HasPrefix(dep.Version, "v0.") {
+			t.Fatalf("unexpected version %s of %s", dep.Version, dep.Path)
+		}
+		vendored := "1." + parts[0]
+		if vendored != bundledRelease {
+			t.Errorf("bundledRelease is %s, but Kubernetes %s is vendored: add the feature gates removed up to it to removedFeatureGates, regenerate testdata/kube-feature-gates.txt and bump bundledRelease", bundledRelease, vendored)
+		}
+		if kubeRelease, _ := readKubeFeatureGates(t); kubeRelease != bundledRelease {
+			t.Errorf("testdata/kube-feature-gates.txt lists the gates of %s, regenerate it for %s", kubeRelease, bundledRelease)
+		}
+		return
+	}
+	t.Fatal("k8s.io/component-base is not a dependency")
+}
+
+func TestRemovedFeatureGatesAreNotBundled(t *testing.T) {
+	_, kubeGates := readKubeFeatureGates(t)
+	vendored := vendoredFeatureGates(t)
+	for name, removed := range removedFeatureGates {
+		if _, ok := release.Minor(removed); !ok {
+			t.Errorf("%s: %q is not a minor release", name, removed)
+		}
+		if release.Compare(removed, bundledRelease) > 0 {
+			continue
+		}
+		if kubeGates.Has(name) || vendored.Has(name) {
+			t.Errorf("%s is listed as removed in %s, but kube-controller-manager %s still registers it", name, removed, bundledRelease)
+		}
+	}
+	// the bundled gates are passed on
+	for name := range kubeGates.Union(vendored) {
+		if Removed(name) {
+			t.Errorf("%s is registered by kube-controller-manager %s, but dropped", name, bundledRelease)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package leaderelection
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+var (
+	leaseDurationPath = []string{"extendedArguments", "leader-elect-lease-duration"}
+	renewDeadlinePath = []string{"extendedArguments", "leader-elect-renew-deadline"}
+	retryPeriodPath   = []string{"extendedArguments", "leader-elect-retry-period"}
+)
+
+// ObserveLeaderElection relaxes the leader election timings of kube-controller-manager on a SingleReplica control
+// plane to the ones of LeaderElectionSNOConfig. There is no other instance to hand the lease over to, renewing it
+// every few seconds only costs API requests. Other topologies keep the timings of the default config.
+// unsupportedConfigOverrides of the flags still take precedence.
+func ObserveLeaderElection(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	listers := genericListers.(configobservation.Listers)
+
+	previouslyObservedConfig := map[string]interface{}{}
+	for _, path := range [][]string{leaseDurationPath, renewDeadlinePath, retryPeriodPath} {
+		if current, _, _ := unstructured.NestedStringSlice(existingConfig, path...); len(current) > 0 {
+			if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, path...); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+	}
+
+	infrastructure, err := listers.InfrastructureLister().Get("cluster")
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ObserveLeaderElection", "Required infrastructures.%s/cluster not found", configv1.GroupName)
+		return previouslyObservedConfig, nil
+	}
+	if err != nil {
+		return previouslyObservedConfig, []error{err}
+	}
+	if infrastructure.Status.ControlPlaneTopology != configv1.SingleReplicaTopologyMode {
+		return map[string]interface{}{}, nil
+	}
+
+	sno := leaderelectionconverter.LeaderElectionSNOConfig(configv1.LeaderElection{})
+	observedConfig := map[string]interface{}{}
+	for _, argument := range []struct {
+		path  []string
+		value string
+	}{
+		{path: leaseDurationPath, value: sno.LeaseDuration.Duration.String()},
+		{path: renewDeadlinePath, value: sno.RenewDeadline.Duration.String()},
+		{path: retryPeriodPath, value: sno.RetryPeriod.Duration.String()},
+	} {
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{argument.value}, argument.path...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+	}
+	return observedConfig, nil
+}
@@ -0,0 +1,88 @@
+package leaderelection
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+func TestObserveLeaderElection(t *testing.T) {
+	snoConfig := map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"leader-elect-lease-duration": []interface{}{"4m30s"},
+			"leader-elect-renew-deadline": []interface{}{"4m0s"},
+			"leader-elect-retry-period":   []interface{}{"1m0s"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		topology configv1.TopologyMode
+		// missing has no infrastructures/cluster
+		missing  bool
+		input    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "single replica",
+			topology: configv1.SingleReplicaTopologyMode,
+			input:    map[string]interface{}{},
+			expected: snoConfig,
+		},
+		{
+			name:     "highly available",
+			topology: configv1.HighlyAvailableTopologyMode,
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "external",
+			topology: configv1.ExternalTopologyMode,
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "highly available after single replica",
+			topology: configv1.HighlyAvailableTopologyMode,
+			input:    snoConfig,
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "infrastructure not found keeps the previous timings",
+			missing:  true,
+			input:    snoConfig,
+			expected: snoConfig,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if !test.missing {
+				if err := indexer.Add(&configv1.Infrastructure{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Status:     configv1.InfrastructureStatus{ControlPlaneTopology: test.topology},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := configobservation.Listers{
+				InfrastructureLister_: configlistersv1.NewInfrastructureLister(indexer),
+			}
+			result, errs := ObserveLeaderElection(listers, events.NewInMemoryRecorder("leaderelection"), test.input)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package network
 
 import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/operator/configobserver"
 	"github.com/openshift/library-go/pkg/operator/configobserver/network"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -44,6 +51,10 @@ func ObserveClusterCIDRs(genericListers configobserver.Listers, recorder events.
 	return observedConfig, errs
 }
 
+// ObserveServiceClusterIPRanges observes the service networks of the cluster Network into --service-cluster-ip-range,
+// one CIDR per IP family in the order of the status. The service networks of the kube-apiserver can gain a second IP
+// family, but neither lose one nor change the primary family. Service networks doing so or invalid ones keep the
+// previously observed range and are an error of the observer.
 func ObserveServiceClusterIPRanges(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
 	listers := genericListers.(configobservation.Listers)
 
@@ -51,7 +62,8 @@ func ObserveServiceClusterIPRanges(genericListers configobserver.Listers, record
 	serviceClusterIPRangePath := []string{"extendedArguments", "service-cluster-ip-range"}
 
 	previouslyObservedConfig := map[string]interface{}{}
-	if currentServiceClusterIPRanges, _, _ := unstructured.NestedStringSlice(existingConfig, serviceClusterIPRangePath...); len(currentServiceClusterIPRanges) > 0 {
+	currentServiceClusterIPRanges, _, _ := unstructured.NestedStringSlice(existingConfig, serviceClusterIPRangePath...)
+	if len(currentServiceClusterIPRanges) > 0 {
 		if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, currentServiceClusterIPRanges, serviceClusterIPRangePath...); err != nil {
 			errs = append(errs, err)
 		}
@@ -63,11 +75,158 @@ func ObserveServiceClusterIPRanges(genericListers configobserver.Listers, record
 		errs = append(errs, err)
 		return previouslyObservedConfig, errs
 	}
-	serviceClusterIPRange := strings.Join(serviceCIDRs, ",")
+	if len(serviceCIDRs) == 0 {
+		// the Network is not found, GetServiceCIDRs recorded a warning
+		return previouslyObservedConfig, errs
+	}
+
+	var currentServiceCIDRs []string
+	if len(currentServiceClusterIPRanges) > 0 {
+		currentServiceCIDRs = strings.Split(currentServiceClusterIPRanges[0], ",")
+	}
+	if err := validateServiceCIDRs(currentServiceCIDRs, serviceCIDRs); err != nil {
+		recorder.Warningf("ObserveServiceClusterIPRangesFailed", "Keeping the service-cluster-ip-range %s: %v", strings.Join(currentServiceCIDRs, ","), err)
+		errs = append(errs, err)
+		return previouslyObservedConfig, errs
+	}
 
+	serviceClusterIPRange := strings.Join(serviceCIDRs, ",")
 	if err := unstructured.SetNestedStringSlice(observedConfig, []string{serviceClusterIPRange}, serviceClusterIPRangePath...); err != nil {
 		errs = append(errs, err)
 	}
+	if len(currentServiceCIDRs) > 0 && serviceClusterIPRange != currentServiceClusterIPRanges[0] {
+		recorder.Eventf("ObserveServiceClusterIPRanges", "service-cluster-ip-range changed from %s to %s", currentServiceClusterIPRanges[0], serviceClusterIPRange)
+	}
 
 	return observedConfig, errs
 }
+
+// validateServiceCIDRs accepts at most one service CIDR per IP family. Once observed, the IP families of the current
+// service CIDRs must be kept and the first one must stay first.
+func validateServiceCIDRs(current, observed []string) error {
+	observedFamilies, err := ipFamilies(observed)
+	if err != nil {
+		return fmt.Errorf("networks.%s/cluster: status.serviceNetwork: %v", configv1.GroupName, err)
+	}
+	if len(observed) > 2 || (len(observed) == 2 && observedFamilies[0] == observedFamilies[1]) {
+		return fmt.Errorf("networks.%s/cluster: status.serviceNetwork %s must have at most one CIDR per IP family", configv1.GroupName, strings.Join(observed, ","))
+	}
+
+	// an invalid current range is replaced
+	currentFamilies, err := ipFamilies(current)
+	if err != nil || len(currentFamilies) == 0 {
+		return nil
+	}
+	if currentFamilies[0] != observedFamilies[0] {
+		return fmt.Errorf("networks.%s/cluster: status.serviceNetwork %s changes the primary IP family from %s", configv1.GroupName, strings.Join(observed, ","), currentFamilies[0])
+	}
+	for _, family := range currentFamilies {
+		if !slices.Contains(observedFamilies, family) {
+			return fmt.Errorf("networks.%s/cluster: status.serviceNetwork %s removes the %s service network", configv1.GroupName, strings.Join(observed, ","), family)
+		}
+	}
+	return nil
+}
+
+// ipFamilies returns the IP family of every CIDR of cidrs.
+func ipFamilies(cidrs []string) ([]string, error) {
+	var families []string
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		if ip.To4() != nil {
+			families = append(families, "IPv4")
+		} else {
+			families = append(families, "IPv6")
+		}
+	}
+	return families, nil
+}
+
+// nodeCIDRMaskSizeArguments are the extendedArguments of the node CIDR mask sizes, of single-stack and of each IP
+// family of dual-stack cluster networks.
+var nodeCIDRMaskSizeArguments = []string{"node-cidr-mask-size", "node-cidr-mask-size-ipv4", "node-cidr-mask-size-ipv6"}
+
+// ObserveNodeCIDRMaskSizes observes the hostPrefix of the cluster networks into the node CIDR mask sizes. Single-stack
+// cluster networks set --node-cidr-mask-size, dual-stack ones --node-cidr-mask-size-ipv4 and
+// --node-cidr-mask-size-ipv6, the kube-controller-manager rejects the former for them. An IP family whose cluster
+// networks have no or different host prefixes keeps the default of the kube-controller-manager. An invalid host prefix
+// keeps the previously observed mask sizes and is an error of the observer.
+func ObserveNodeCIDRMaskSizes(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	listers := genericListers.(configobservation.Listers)
+
+	var errs []error
+	previouslyObservedConfig := map[string]interface{}{}
+	for _, argument := range nodeCIDRMaskSizeArguments {
+		path := []string{"extendedArguments", argument}
+		if currentMaskSize, _, _ := unstructured.NestedStringSlice(existingConfig, path...); len(currentMaskSize) > 0 {
+			if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, currentMaskSize, path...); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	networkConfig, err := listers.NetworkLister.Get("cluster")
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ObserveNodeCIDRMaskSizesFailed", "Required networks.%s/cluster not found", configv1.GroupName)
+		return previouslyObservedConfig, errs
+	}
+	if err != nil {
+		errs = append(errs, err)
+		return previouslyObservedConfig, errs
+	}
+
+	var families []string
+	hostPrefixes := map[string]sets.Set[uint32]{}
+	for i, clusterNetwork := range networkConfig.Status.ClusterNetwork {
+		family, err := validateHostPrefix(clusterNetwork)
+		if err != nil {
+			err = fmt.Errorf("networks.%s/cluster: status.clusterNetwork[%d]: %v", configv1.GroupName, i, err)
+			recorder.Warningf("ObserveNodeCIDRMaskSizesFailed", "Keeping the node CIDR mask sizes: %v", err)
+			errs = append(errs, err)
+			return previouslyObservedConfig, errs
+		}
+		if hostPrefixes[family] == nil {
+			families = append(families, family)
+			hostPrefixes[family] = sets.New[uint32]()
+		}
+		hostPrefixes[family].Insert(clusterNetwork.HostPrefix)
+	}
+
+	observedConfig := map[string]interface{}{}
+	for _, family := range families {
+		if hostPrefixes[family].Len() != 1 || hostPrefixes[family].Has(0) {
+			continue
+		}
+		argument := "node-cidr-mask-size"
+		if len(families) > 1 {
+			argument = "node-cidr-mask-size-" + strings.ToLower(family)
+		}
+		maskSize := strconv.Itoa(int(hostPrefixes[family].UnsortedList()[0]))
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{maskSize}, "extendedArguments", argument); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return observedConfig, errs
+}
+
+// validateHostPrefix returns the IP family of the cluster network. A host prefix must be longer than the prefix of the
+// CIDR and shorter than 31 for IPv4 and 127 for IPv6, a node CIDR must have room for pods.
+func validateHostPrefix(clusterNetwork configv1.ClusterNetworkEntry) (string, error) {
+	families, err := ipFamilies([]string{clusterNetwork.CIDR})
+	if err != nil {
+		return "", err
+	}
+	if clusterNetwork.HostPrefix == 0 {
+		return families[0], nil
+	}
+	_, cidr, _ := net.ParseCIDR(clusterNetwork.CIDR)
+	ones, bits := cidr.Mask.Size()
+	if int(clusterNetwork.HostPrefix) <= ones || int(clusterNetwork.HostPrefix) >= bits-1 {
+		return "", fmt.Errorf("hostPrefix %d must be longer than the prefix of %s and shorter than %d", clusterNetwork.HostPrefix, clusterNetwork.CIDR, bits-1)
+	}
+	return families[0], nil
+}
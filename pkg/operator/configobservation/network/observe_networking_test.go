@@ -93,27 +93,177 @@ func TestObserveClusterCIDRs(t *testing.T) {
 	}
 }
 
+func serviceClusterIPRangeConfig(serviceClusterIPRange string) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"service-cluster-ip-range": []interface{}{serviceClusterIPRange},
+		},
+	}
+}
+
 func TestObserveServiceClusterIPRanges(t *testing.T) {
-	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
-	if err := indexer.Add(&configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.NetworkStatus{ServiceNetwork: []string{"serviceCIDRv4", "serviceCIDRv6"}}}); err != nil {
-		t.Fatal(err.Error())
+	tests := []struct {
+		name            string
+		serviceNetwork  []string
+		input, expected map[string]interface{}
+		expectedError   bool
+	}{
+		{
+			name:           "dual-stack",
+			serviceNetwork: []string{"172.30.0.0/16", "fd02::/112"},
+			input:          map[string]interface{}{},
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16,fd02::/112"),
+		},
+		{
+			name:           "unchanged",
+			serviceNetwork: []string{"fd02::/112", "172.30.0.0/16"},
+			input:          serviceClusterIPRangeConfig("fd02::/112,172.30.0.0/16"),
+			expected:       serviceClusterIPRangeConfig("fd02::/112,172.30.0.0/16"),
+		},
+		{
+			name:           "single to dual-stack",
+			serviceNetwork: []string{"172.30.0.0/16", "fd02::/112"},
+			input:          serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16,fd02::/112"),
+		},
+		{
+			name:           "dual-stack to single keeps the previous range",
+			serviceNetwork: []string{"172.30.0.0/16"},
+			input:          serviceClusterIPRangeConfig("172.30.0.0/16,fd02::/112"),
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16,fd02::/112"),
+			expectedError:  true,
+		},
+		{
+			name:           "primary family change keeps the previous range",
+			serviceNetwork: []string{"fd02::/112", "172.30.0.0/16"},
+			input:          serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expectedError:  true,
+		},
+		{
+			name:           "two CIDRs of one family keep the previous range",
+			serviceNetwork: []string{"172.30.0.0/16", "172.31.0.0/16"},
+			input:          serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expectedError:  true,
+		},
+		{
+			name:           "invalid CIDR keeps the previous range",
+			serviceNetwork: []string{"172.30.0.0/16", "serviceCIDRv6"},
+			input:          serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expected:       serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expectedError:  true,
+		},
+		{
+			name:          "none, existing config",
+			input:         serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expected:      serviceClusterIPRangeConfig("172.30.0.0/16"),
+			expectedError: true,
+		},
 	}
-	listers := configobservation.Listers{
-		NetworkLister: configlistersv1.NewNetworkLister(indexer),
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.NetworkStatus{ServiceNetwork: test.serviceNetwork}}); err != nil {
+				t.Fatal(err.Error())
+			}
+			listers := configobservation.Listers{
+				NetworkLister: configlistersv1.NewNetworkLister(indexer),
+			}
+			result, errs := ObserveServiceClusterIPRanges(listers, events.NewInMemoryRecorder("network"), test.input)
+			if test.expectedError != (len(errs) > 0) {
+				t.Fatalf("expected error: %v, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(test.expected), toYAML(result))
+			}
+		})
 	}
-	result, errs := ObserveServiceClusterIPRanges(listers, events.NewInMemoryRecorder("network"), map[string]interface{}{})
-	if len(errs) > 0 {
-		t.Fatal(errs)
+}
+
+func nodeCIDRMaskSizeConfig(maskSizes map[string]string) map[string]interface{} {
+	extendedArguments := map[string]interface{}{}
+	for argument, maskSize := range maskSizes {
+		extendedArguments[argument] = []interface{}{maskSize}
 	}
-	expected := map[string]interface{}{
-		"extendedArguments": map[string]interface{}{
-			"service-cluster-ip-range": []interface{}{
-				"serviceCIDRv4,serviceCIDRv6",
-			},
+	return map[string]interface{}{"extendedArguments": extendedArguments}
+}
+
+func TestObserveNodeCIDRMaskSizes(t *testing.T) {
+	tests := []struct {
+		name            string
+		clusterNetwork  []configv1.ClusterNetworkEntry
+		input, expected map[string]interface{}
+		expectedError   bool
+	}{
+		{
+			name:           "ipv4",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size": "23"}),
+		},
+		{
+			name:           "ipv6",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "fd01::/48", HostPrefix: 64}},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size": "64"}),
+		},
+		{
+			name:           "dual-stack with equal masks",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 24}, {CIDR: "fd01::/16", HostPrefix: 24}},
+			input:          nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size": "24"}),
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size-ipv4": "24", "node-cidr-mask-size-ipv6": "24"}),
+		},
+		{
+			name:           "dual-stack with different masks",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "fd01::/48", HostPrefix: 64}, {CIDR: "10.128.0.0/14", HostPrefix: 23}},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"}),
+		},
+		{
+			name:           "different host prefixes of one family keep the default",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}, {CIDR: "10.132.0.0/14", HostPrefix: 24}, {CIDR: "fd01::/48", HostPrefix: 64}},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size-ipv6": "64"}),
+		},
+		{
+			name:           "no host prefix",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14"}},
+			input:          map[string]interface{}{},
+			expected:       map[string]interface{}{},
+		},
+		{
+			name:           "host prefix of the CIDR prefix keeps the previous mask sizes",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 14}},
+			input:          nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size": "23"}),
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size": "23"}),
+			expectedError:  true,
+		},
+		{
+			name:           "host prefix without room for pods keeps the previous mask sizes",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}, {CIDR: "fd01::/48", HostPrefix: 127}},
+			input:          nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"}),
+			expected:       nodeCIDRMaskSizeConfig(map[string]string{"node-cidr-mask-size-ipv4": "23", "node-cidr-mask-size-ipv6": "64"}),
+			expectedError:  true,
 		},
 	}
-	if !reflect.DeepEqual(expected, result) {
-		t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(expected), toYAML(result))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.NetworkStatus{ClusterNetwork: test.clusterNetwork}}); err != nil {
+				t.Fatal(err.Error())
+			}
+			listers := configobservation.Listers{
+				NetworkLister: configlistersv1.NewNetworkLister(indexer),
+			}
+			result, errs := ObserveNodeCIDRMaskSizes(listers, events.NewInMemoryRecorder("network"), test.input)
+			if test.expectedError != (len(errs) > 0) {
+				t.Fatalf("expected error: %v, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(test.expected), toYAML(result))
+			}
+		})
 	}
 }
 
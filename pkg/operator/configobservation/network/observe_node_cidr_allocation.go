@@ -0,0 +1,152 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// NodeCIDRAllocationOverrideField of the unsupportedConfigOverrides selects the node CIDR allocation of a network
+	// plugin the operator does not know, one of the NodeCIDRAllocation values. It is ignored for the known network
+	// plugins. Without it an unknown network plugin keeps NodeCIDRAllocationNone.
+	NodeCIDRAllocationOverrideField = "nodeCIDRAllocation"
+
+	// NodeCIDRAllocationNone leaves the pod CIDRs of the nodes to the network plugin, --allocate-node-cidrs=false and
+	// --configure-cloud-routes=false.
+	NodeCIDRAllocationNone = "None"
+	// NodeCIDRAllocationNodeCIDRs allocates the pod CIDRs of the nodes from the cluster networks,
+	// --allocate-node-cidrs=true and --configure-cloud-routes=false.
+	NodeCIDRAllocationNodeCIDRs = "NodeCIDRs"
+	// NodeCIDRAllocationNodeCIDRsAndCloudRoutes allocates the pod CIDRs of the nodes and routes them through the cloud,
+	// --allocate-node-cidrs=true and --configure-cloud-routes=true.
+	NodeCIDRAllocationNodeCIDRsAndCloudRoutes = "NodeCIDRsAndCloudRoutes"
+)
+
+var (
+	allocateNodeCIDRsPath    = []string{"extendedArguments", "allocate-node-cidrs"}
+	configureCloudRoutesPath = []string{"extendedArguments", "configure-cloud-routes"}
+
+	// knownNetworkTypes are the node CIDR allocations of the network plugins the operator knows. They allocate the
+	// node subnets themselves, a second allocation by the kube-controller-manager would hand out the CIDRs twice.
+	knownNetworkTypes = map[string]string{
+		string(operatorv1.NetworkTypeOVNKubernetes): NodeCIDRAllocationNone,
+		string(operatorv1.NetworkTypeOpenShiftSDN):  NodeCIDRAllocationNone,
+		"Kuryr": NodeCIDRAllocationNone,
+	}
+)
+
+func init() {
+	overrides.Register(NodeCIDRAllocationOverrideField)
+}
+
+// NewObserveNodeCIDRAllocationFunc renders --allocate-node-cidrs and --configure-cloud-routes from the network plugin
+// in the status of the cluster Network, or from NodeCIDRAllocationOverrideField for network plugins the operator does
+// not know. While the network plugin is unknown, or the allocation is invalid, the previously observed arguments are kept.
+func NewObserveNodeCIDRAllocationFunc(operator overrides.SpecGetter) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		listers := genericListers.(configobservation.Listers)
+
+		previouslyObservedConfig := map[string]interface{}{}
+		for _, path := range [][]string{allocateNodeCIDRsPath, configureCloudRoutesPath} {
+			if current, _, _ := unstructured.NestedStringSlice(existingConfig, path...); len(current) > 0 {
+				if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, path...); err != nil {
+					return previouslyObservedConfig, []error{err}
+				}
+			}
+		}
+
+		networkConfig, err := listers.NetworkLister.Get("cluster")
+		if errors.IsNotFound(err) {
+			recorder.Warningf("ObserveNodeCIDRAllocationFailed", "Required networks.%s/cluster not found", configv1.GroupName)
+			return previouslyObservedConfig, nil
+		}
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		networkType := networkConfig.Status.NetworkType
+		if len(networkType) == 0 {
+			// the network operator has not reported the network plugin yet
+			return previouslyObservedConfig, nil
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		override, overridden, err := overrides.String(unsupportedConfigOverrides, NodeCIDRAllocationOverrideField)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+
+		allocation, known := knownNetworkTypes[networkType]
+		switch {
+		case known && overridden:
+			recorder.Warningf("ObserveNodeCIDRAllocation", "Ignoring %s, the node CIDR allocation of the %s network plugin is %s", overrides.Path(NodeCIDRAllocationOverrideField), networkType, allocation)
+		case !known && overridden:
+			allocation, err = validation.EnumOf(overrides.Path(NodeCIDRAllocationOverrideField), override, NodeCIDRAllocationNone, NodeCIDRAllocationNodeCIDRs, NodeCIDRAllocationNodeCIDRsAndCloudRoutes)
+			if err != nil {
+				recorder.Warningf("ObserveNodeCIDRAllocation", "Keeping the previous node CIDR allocation: %v", err)
+				return previouslyObservedConfig, []error{err}
+			}
+		case !known:
+			allocation = NodeCIDRAllocationNone
+			recorder.Warningf("ObserveNodeCIDRAllocation", "The node CIDR allocation of the %s network plugin is unknown, using %s, set %s to change it", networkType, allocation, overrides.Path(NodeCIDRAllocationOverrideField))
+		}
+
+		allocateNodeCIDRs := allocation != NodeCIDRAllocationNone
+		if allocateNodeCIDRs {
+			if err := validateNodeCIDRAllocation(networkConfig.Status.ClusterNetwork); err != nil {
+				recorder.Warningf("ObserveNodeCIDRAllocation", "Keeping the previous node CIDR allocation: %v", err)
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatBool(allocateNodeCIDRs)}, allocateNodeCIDRsPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.FormatBool(allocation == NodeCIDRAllocationNodeCIDRsAndCloudRoutes)}, configureCloudRoutesPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !equality.Semantic.DeepEqual(previouslyObservedConfig, observedConfig) {
+			recorder.Eventf("ObserveNodeCIDRAllocation", "Node CIDR allocation of the %s network plugin changed to %s", networkType, allocation)
+		}
+		return observedConfig, nil
+	}
+}
+
+// validateNodeCIDRAllocation checks the cluster networks the node IPAM of the kube-controller-manager allocates from,
+// it does not start with more than one cluster network per IP family or without a node CIDR mask size.
+func validateNodeCIDRAllocation(clusterNetworks []configv1.ClusterNetworkEntry) error {
+	if len(clusterNetworks) == 0 {
+		return fmt.Errorf("networks.%s/cluster: allocating node CIDRs requires a status.clusterNetwork", configv1.GroupName)
+	}
+	seen := map[string]bool{}
+	for i, clusterNetwork := range clusterNetworks {
+		family, err := validateHostPrefix(clusterNetwork)
+		if err != nil {
+			return fmt.Errorf("networks.%s/cluster: status.clusterNetwork[%d]: %v", configv1.GroupName, i, err)
+		}
+		if clusterNetwork.HostPrefix == 0 {
+			return fmt.Errorf("networks.%s/cluster: status.clusterNetwork[%d]: allocating node CIDRs requires a hostPrefix", configv1.GroupName, i)
+		}
+		if seen[family] {
+			return fmt.Errorf("networks.%s/cluster: status.clusterNetwork[%d]: allocating node CIDRs requires at most one %s cluster network", configv1.GroupName, i, family)
+		}
+		seen[family] = true
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package network
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func nodeCIDRAllocationConfig(allocateNodeCIDRs, configureCloudRoutes string) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"allocate-node-cidrs":    []interface{}{allocateNodeCIDRs},
+			"configure-cloud-routes": []interface{}{configureCloudRoutes},
+		},
+	}
+}
+
+func TestObserveNodeCIDRAllocation(t *testing.T) {
+	singleStack := []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}}
+	dualStack := []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}, {CIDR: "fd01::/48", HostPrefix: 64}}
+
+	tests := []struct {
+		name            string
+		networkType     string
+		clusterNetwork  []configv1.ClusterNetworkEntry
+		overrides       map[string]interface{}
+		input, expected map[string]interface{}
+		expectedError   bool
+	}{
+		{
+			name:           "OVNKubernetes",
+			networkType:    "OVNKubernetes",
+			clusterNetwork: singleStack,
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "OpenShiftSDN",
+			networkType:    "OpenShiftSDN",
+			clusterNetwork: singleStack,
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "Kuryr",
+			networkType:    "Kuryr",
+			clusterNetwork: singleStack,
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "known plugin ignores the override",
+			networkType:    "OVNKubernetes",
+			clusterNetwork: singleStack,
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNodeCIDRs},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "unknown plugin without the override",
+			networkType:    "Calico",
+			clusterNetwork: singleStack,
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "unknown plugin allocating node CIDRs",
+			networkType:    "Calico",
+			clusterNetwork: dualStack,
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNodeCIDRs},
+			input:          nodeCIDRAllocationConfig("false", "false"),
+			expected:       nodeCIDRAllocationConfig("true", "false"),
+		},
+		{
+			name:           "unknown plugin allocating node CIDRs with cloud routes",
+			networkType:    "Calico",
+			clusterNetwork: singleStack,
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNodeCIDRsAndCloudRoutes},
+			input:          map[string]interface{}{},
+			expected:       nodeCIDRAllocationConfig("true", "true"),
+		},
+		{
+			name:           "unknown plugin allocating none",
+			networkType:    "Cilium",
+			clusterNetwork: singleStack,
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNone},
+			input:          nodeCIDRAllocationConfig("true", "false"),
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+		},
+		{
+			name:           "invalid override keeps the previous allocation",
+			networkType:    "Calico",
+			clusterNetwork: singleStack,
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: "true"},
+			input:          nodeCIDRAllocationConfig("true", "false"),
+			expected:       nodeCIDRAllocationConfig("true", "false"),
+			expectedError:  true,
+		},
+		{
+			name:           "two cluster networks of one family keep the previous allocation",
+			networkType:    "Calico",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}, {CIDR: "10.132.0.0/14", HostPrefix: 23}},
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNodeCIDRs},
+			input:          nodeCIDRAllocationConfig("false", "false"),
+			expected:       nodeCIDRAllocationConfig("false", "false"),
+			expectedError:  true,
+		},
+		{
+			name:           "cluster network without a host prefix keeps the previous allocation",
+			networkType:    "Calico",
+			clusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14"}},
+			overrides:      map[string]interface{}{NodeCIDRAllocationOverrideField: NodeCIDRAllocationNodeCIDRs},
+			input:          map[string]interface{}{},
+			expected:       map[string]interface{}{},
+			expectedError:  true,
+		},
+		{
+			name:           "unreported plugin keeps the previous allocation",
+			clusterNetwork: singleStack,
+			input:          nodeCIDRAllocationConfig("true", "false"),
+			expected:       nodeCIDRAllocationConfig("true", "false"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.NetworkStatus{NetworkType: test.networkType, ClusterNetwork: test.clusterNetwork},
+			}); err != nil {
+				t.Fatal(err.Error())
+			}
+			listers := configobservation.Listers{
+				NetworkLister: configlistersv1.NewNetworkLister(indexer),
+			}
+			observe := NewObserveNodeCIDRAllocationFunc(fakeOperator(test.overrides))
+			result, errs := observe(listers, events.NewInMemoryRecorder("network"), test.input)
+			if test.expectedError != (len(errs) > 0) {
+				t.Fatalf("expected error: %v, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("\n===== observed config expected:\n%v\n===== observed config actual:\n%v", toYAML(test.expected), toYAML(result))
+			}
+		})
+	}
+}
+
+// TestObserveNodeCIDRAllocationKeepsTheDefaultConfig checks that the allocation of the known network plugins is the
+// one of the default config, observing it does not change the config of the revisions.
+func TestObserveNodeCIDRAllocationKeepsTheDefaultConfig(t *testing.T) {
+	defaultConfig := bindata.MustAsset("assets/config/defaultconfig.yaml")
+	expected, err := resourcemerge.MergeProcessConfig(nil, defaultConfig, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for networkType := range knownNetworkTypes {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		if err := indexer.Add(&configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.NetworkStatus{NetworkType: networkType}}); err != nil {
+			t.Fatal(err.Error())
+		}
+		observe := NewObserveNodeCIDRAllocationFunc(fakeOperator(nil))
+		observed, errs := observe(configobservation.Listers{NetworkLister: configlistersv1.NewNetworkLister(indexer)}, events.NewInMemoryRecorder("network"), map[string]interface{}{})
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		observedConfig, err := json.Marshal(observed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged, err := resourcemerge.MergeProcessConfig(nil, defaultConfig, observedConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(expected) != string(merged) {
+			t.Errorf("%s: expected the default config\n%s\ngot\n%s", networkType, expected, merged)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package node
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// MonitorGracePeriodOverrideField, StartupGracePeriodOverrideField and EvictionRateOverrideField of the
+	// unsupportedConfigOverrides set --node-monitor-grace-period, --node-startup-grace-period and --node-eviction-rate,
+	// e.g. 2m, 5m and 0.05. The monitor grace period wins over the one of the worker latency profile.
+	MonitorGracePeriodOverrideField = "nodeMonitorGracePeriod"
+	StartupGracePeriodOverrideField = "nodeStartupGracePeriod"
+	EvictionRateOverrideField       = "nodeEvictionRate"
+
+	// nodeStatusUpdateFrequency is the default nodeStatusUpdateFrequency of the kubelet. A node is not ready once it
+	// missed the updates of a monitor grace period, which must allow for minNodeStatusUpdates of them like the default
+	// grace period of 40s does.
+	nodeStatusUpdateFrequency = 10 * time.Second
+	minNodeStatusUpdates      = 4
+)
+
+// lifecycleArguments are the node lifecycle extendedArguments and the override fields they are read from, parse
+// returns the value rendered into the argument.
+var lifecycleArguments = []struct {
+	field string
+	path  []string
+	parse func(path, value string) (string, error)
+}{
+	{
+		field: MonitorGracePeriodOverrideField,
+		path:  []string{"extendedArguments", "node-monitor-grace-period"},
+		parse: parseMonitorGracePeriod,
+	},
+	{
+		field: StartupGracePeriodOverrideField,
+		path:  []string{"extendedArguments", "node-startup-grace-period"},
+		parse: func(path, value string) (string, error) {
+			duration, err := validation.DurationBetween(path, value, 0, 0)
+			return duration.String(), err
+		},
+	},
+	{
+		field: EvictionRateOverrideField,
+		path:  []string{"extendedArguments", "node-eviction-rate"},
+		parse: func(path, value string) (string, error) {
+			rate, err := validation.FloatAtLeast(path, value, 0)
+			return strconv.FormatFloat(rate, 'g', -1, 64), err
+		},
+	},
+}
+
+func init() {
+	overrides.Register(MonitorGracePeriodOverrideField, StartupGracePeriodOverrideField, EvictionRateOverrideField)
+}
+
+// NewObserveNodeLifecycleFunc renders the node lifecycle timings from the unsupportedConfigOverrides of the operator
+// over the config latencyProfile observes. It wraps the latency profile observer, two observers of the same argument
+// would make the observed config depend on their order. An invalid override keeps the previously observed value of
+// its argument, records a warning event and is an error of the observer.
+func NewObserveNodeLifecycleFunc(operator overrides.SpecGetter, latencyProfile configobserver.ObserveConfigFunc) configobserver.ObserveConfigFunc {
+	return func(listers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		observedConfig, errs := latencyProfile(listers, recorder, existingConfig)
+		if observedConfig == nil {
+			observedConfig = map[string]interface{}{}
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			for _, argument := range lifecycleArguments {
+				if err := keepPrevious(observedConfig, existingConfig, argument.path); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return observedConfig, append(errs, err)
+		}
+
+		for _, argument := range lifecycleArguments {
+			rendered, ok, err := parseOverride(unsupportedConfigOverrides, argument.field, argument.parse)
+			if err != nil {
+				recorder.Warningf("ObserveNodeLifecycle", "Keeping the previous %s: %v", argument.path[len(argument.path)-1], err)
+				errs = append(errs, err)
+				if err := keepPrevious(observedConfig, existingConfig, argument.path); err != nil {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if err := unstructured.SetNestedStringSlice(observedConfig, []string{rendered}, argument.path...); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return observedConfig, errs
+	}
+}
+
+// parseOverride returns the rendered value of field, false when it is not set.
+func parseOverride(unsupportedConfigOverrides []byte, field string, parse func(path, value string) (string, error)) (string, bool, error) {
+	value, ok, err := overrides.String(unsupportedConfigOverrides, field)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	rendered, err := parse(overrides.Path(field), value)
+	return rendered, true, err
+}
+
+// parseMonitorGracePeriod accepts a multiple of nodeStatusUpdateFrequency of at least minNodeStatusUpdates of them.
+func parseMonitorGracePeriod(path, value string) (string, error) {
+	duration, err := validation.DurationBetween(path, value, minNodeStatusUpdates*nodeStatusUpdateFrequency, 0)
+	if err != nil {
+		return "", err
+	}
+	if duration%nodeStatusUpdateFrequency != 0 {
+		return "", &validation.Error{Path: path, Value: value, Accepted: "a multiple of the node status update frequency " + nodeStatusUpdateFrequency.String()}
+	}
+	return duration.String(), nil
+}
+
+// keepPrevious sets path of observedConfig to its value in existingConfig, if any.
+func keepPrevious(observedConfig, existingConfig map[string]interface{}, path []string) error {
+	previous, found, _ := unstructured.NestedStringSlice(existingConfig, path...)
+	if !found {
+		return nil
+	}
+	return unstructured.SetNestedStringSlice(observedConfig, previous, path...)
+}
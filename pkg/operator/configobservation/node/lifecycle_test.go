@@ -0,0 +1,141 @@
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func lifecycleConfig(arguments map[string]string) map[string]interface{} {
+	extendedArguments := map[string]interface{}{}
+	for name, value := range arguments {
+		extendedArguments[name] = []interface{}{value}
+	}
+	return map[string]interface{}{"extendedArguments": extendedArguments}
+}
+
+func TestObserveNodeLifecycle(t *testing.T) {
+	tests := []struct {
+		name           string
+		overrides      map[string]interface{}
+		latencyProfile map[string]string
+		input          map[string]interface{}
+		expected       map[string]interface{}
+		expectedErrors []string
+	}{
+		{
+			name:           "latency profile",
+			latencyProfile: map[string]string{"node-monitor-grace-period": "2m0s"},
+			input:          map[string]interface{}{},
+			expected:       lifecycleConfig(map[string]string{"node-monitor-grace-period": "2m0s"}),
+		},
+		{
+			name: "overrides win over the latency profile",
+			overrides: map[string]interface{}{
+				MonitorGracePeriodOverrideField: "3m",
+				StartupGracePeriodOverrideField: "300s",
+				EvictionRateOverrideField:       "0.050",
+			},
+			latencyProfile: map[string]string{"node-monitor-grace-period": "40s"},
+			input:          map[string]interface{}{},
+			expected: lifecycleConfig(map[string]string{
+				"node-monitor-grace-period": "3m0s",
+				"node-startup-grace-period": "5m0s",
+				"node-eviction-rate":        "0.05",
+			}),
+		},
+		{
+			name:           "overrides removed",
+			latencyProfile: map[string]string{"node-monitor-grace-period": "40s"},
+			input: lifecycleConfig(map[string]string{
+				"node-monitor-grace-period": "3m0s",
+				"node-startup-grace-period": "5m0s",
+				"node-eviction-rate":        "0.05",
+			}),
+			expected: lifecycleConfig(map[string]string{"node-monitor-grace-period": "40s"}),
+		},
+		{
+			name:           "zero eviction rate",
+			overrides:      map[string]interface{}{EvictionRateOverrideField: "0"},
+			latencyProfile: map[string]string{"node-monitor-grace-period": "40s"},
+			input:          map[string]interface{}{},
+			expected:       lifecycleConfig(map[string]string{"node-monitor-grace-period": "40s", "node-eviction-rate": "0"}),
+		},
+		{
+			name: "invalid overrides keep the previous values",
+			overrides: map[string]interface{}{
+				MonitorGracePeriodOverrideField: "45s",
+				StartupGracePeriodOverrideField: "-1m",
+				EvictionRateOverrideField:       "fast",
+			},
+			latencyProfile: map[string]string{"node-monitor-grace-period": "40s"},
+			input: lifecycleConfig(map[string]string{
+				"node-monitor-grace-period": "3m0s",
+				"node-startup-grace-period": "5m0s",
+				"node-eviction-rate":        "0.05",
+			}),
+			expected: lifecycleConfig(map[string]string{
+				"node-monitor-grace-period": "3m0s",
+				"node-startup-grace-period": "5m0s",
+				"node-eviction-rate":        "0.05",
+			}),
+			expectedErrors: []string{
+				`spec.unsupportedConfigOverrides.nodeMonitorGracePeriod: "45s" must be a multiple of the node status update frequency 10s`,
+				`spec.unsupportedConfigOverrides.nodeStartupGracePeriod: "-1m" must be a duration of at least 0s`,
+				`spec.unsupportedConfigOverrides.nodeEvictionRate: "fast" must be a number of at least 0`,
+			},
+		},
+		{
+			name:           "invalid override without a previous value keeps the latency profile",
+			overrides:      map[string]interface{}{MonitorGracePeriodOverrideField: "30s"},
+			latencyProfile: map[string]string{"node-monitor-grace-period": "40s"},
+			input:          map[string]interface{}{},
+			expected:       lifecycleConfig(map[string]string{"node-monitor-grace-period": "40s"}),
+			expectedErrors: []string{
+				`spec.unsupportedConfigOverrides.nodeMonitorGracePeriod: "30s" must be a duration of at least 40s`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var latencyProfile configobserver.ObserveConfigFunc = func(configobserver.Listers, events.Recorder, map[string]interface{}) (map[string]interface{}, []error) {
+				return lifecycleConfig(test.latencyProfile), nil
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			observe := NewObserveNodeLifecycleFunc(fakeOperator(test.overrides), latencyProfile)
+			result, errs := observe(configobservation.Listers{}, recorder, test.input)
+
+			var actualErrors []string
+			for _, err := range errs {
+				actualErrors = append(actualErrors, err.Error())
+			}
+			if !reflect.DeepEqual(test.expectedErrors, actualErrors) {
+				t.Errorf("expected the errors %q, got %q", test.expectedErrors, actualErrors)
+			}
+			if len(recorder.Events()) != len(test.expectedErrors) {
+				t.Errorf("expected an event per error, got %v", recorder.Events())
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
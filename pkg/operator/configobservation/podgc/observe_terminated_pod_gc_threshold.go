@@ -0,0 +1,66 @@
+package podgc
+
+import (
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+// ThresholdOverrideField of the unsupportedConfigOverrides sets the number of terminated pods the pod garbage
+// collector of kube-controller-manager keeps, e.g. 1000. Without it --terminated-pod-gc-threshold is left to the
+// kube-controller-manager default of 12500.
+const ThresholdOverrideField = "terminatedPodGCThreshold"
+
+var thresholdPath = []string{"extendedArguments", "terminated-pod-gc-threshold"}
+
+func init() {
+	overrides.Register(ThresholdOverrideField)
+}
+
+// NewObserveTerminatedPodGCThresholdFunc renders --terminated-pod-gc-threshold from ThresholdOverrideField. An invalid
+// value keeps the previously observed threshold, the kube-controller-manager parses it as an int32 and would not start
+// with anything else than a number.
+func NewObserveTerminatedPodGCThresholdFunc(operator overrides.SpecGetter) configobserver.ObserveConfigFunc {
+	return func(_ configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		previouslyObservedConfig := map[string]interface{}{}
+		if current, _, _ := unstructured.NestedStringSlice(existingConfig, thresholdPath...); len(current) > 0 {
+			if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, thresholdPath...); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		value, ok, err := overrides.String(unsupportedConfigOverrides, ThresholdOverrideField)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !ok {
+			return map[string]interface{}{}, nil
+		}
+		threshold, err := validation.IntBetween(overrides.Path(ThresholdOverrideField), value, 1, math.MaxInt32)
+		if err != nil {
+			recorder.Warningf("ObserveTerminatedPodGCThreshold", "Keeping the previous terminated pod GC threshold: %v", err)
+			return previouslyObservedConfig, []error{err}
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedStringSlice(observedConfig, []string{strconv.Itoa(threshold)}, thresholdPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !equality.Semantic.DeepEqual(previouslyObservedConfig, observedConfig) {
+			recorder.Eventf("ObserveTerminatedPodGCThreshold", "Terminated pod GC threshold changed to %d", threshold)
+		}
+		return observedConfig, nil
+	}
+}
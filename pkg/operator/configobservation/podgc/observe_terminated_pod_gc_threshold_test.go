@@ -0,0 +1,140 @@
+package podgc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func thresholdConfig(threshold string) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"terminated-pod-gc-threshold": []interface{}{threshold},
+		},
+	}
+}
+
+func TestObserveTerminatedPodGCThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		overrides     map[string]interface{}
+		input         map[string]interface{}
+		expected      map[string]interface{}
+		expectedError string
+		expectedEvent string
+	}{
+		{
+			name:     "no override",
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "override removed",
+			input:    thresholdConfig("1000"),
+			expected: map[string]interface{}{},
+		},
+		{
+			name:          "threshold",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "1000"},
+			input:         map[string]interface{}{},
+			expected:      thresholdConfig("1000"),
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:          "number",
+			overrides:     map[string]interface{}{ThresholdOverrideField: 1000},
+			input:         map[string]interface{}{},
+			expected:      thresholdConfig("1000"),
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:      "unchanged threshold",
+			overrides: map[string]interface{}{ThresholdOverrideField: "1000"},
+			input:     thresholdConfig("1000"),
+			expected:  thresholdConfig("1000"),
+		},
+		{
+			name:          "normalized threshold",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "+0500"},
+			input:         thresholdConfig("1000"),
+			expected:      thresholdConfig("500"),
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:          "zero",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "0"},
+			input:         thresholdConfig("1000"),
+			expected:      thresholdConfig("1000"),
+			expectedError: `spec.unsupportedConfigOverrides.terminatedPodGCThreshold: "0" must be an integer between 1 and 2147483647`,
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:          "negative",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "-1"},
+			input:         map[string]interface{}{},
+			expected:      map[string]interface{}{},
+			expectedError: `spec.unsupportedConfigOverrides.terminatedPodGCThreshold: "-1" must be an integer between 1 and 2147483647`,
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:          "not a number",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "12.5k"},
+			input:         thresholdConfig("1000"),
+			expected:      thresholdConfig("1000"),
+			expectedError: `spec.unsupportedConfigOverrides.terminatedPodGCThreshold: "12.5k" must be an integer between 1 and 2147483647`,
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+		{
+			name:          "beyond int32",
+			overrides:     map[string]interface{}{ThresholdOverrideField: "2147483648"},
+			input:         thresholdConfig("1000"),
+			expected:      thresholdConfig("1000"),
+			expectedError: `spec.unsupportedConfigOverrides.terminatedPodGCThreshold: "2147483648" must be an integer between 1 and 2147483647`,
+			expectedEvent: "ObserveTerminatedPodGCThreshold",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := events.NewInMemoryRecorder("test")
+			observe := NewObserveTerminatedPodGCThresholdFunc(fakeOperator(test.overrides))
+			result, errs := observe(configobservation.Listers{}, recorder, test.input)
+			if len(test.expectedError) == 0 && len(errs) > 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+			if len(test.expectedError) > 0 && (len(errs) != 1 || errs[0].Error() != test.expectedError) {
+				t.Errorf("expected %q, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+
+			var expectedReasons, reasons []string
+			if len(test.expectedEvent) > 0 {
+				expectedReasons = []string{test.expectedEvent}
+			}
+			for _, event := range recorder.Events() {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(expectedReasons, reasons) {
+				t.Errorf("expected the events %v, got %v", expectedReasons, reasons)
+			}
+		})
+	}
+}
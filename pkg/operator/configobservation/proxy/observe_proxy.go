@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/configobserver/network"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+// NewObserveProxyFunc observes the status of proxy.config.openshift.io/cluster into configPath as the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment of the operands. Without an HTTP or HTTPS proxy nothing is observed.
+//
+// NO_PROXY is completed with the cluster and service networks and the hosts of the kube-apiserver, the
+// kube-controller-manager must reach them directly even if the no proxy list of the admin misses them. When they cannot
+// be read the previously observed environment is kept.
+func NewObserveProxyFunc(configPath []string) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		listers := genericListers.(configobservation.Listers)
+
+		previouslyObservedConfig := map[string]interface{}{}
+		previousProxy, _, _ := unstructured.NestedStringMap(existingConfig, configPath...)
+		if len(previousProxy) > 0 {
+			if err := unstructured.SetNestedStringMap(previouslyObservedConfig, previousProxy, configPath...); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+
+		proxy, err := listers.ProxyLister().Get("cluster")
+		if errors.IsNotFound(err) {
+			recorder.Warningf("ObserveProxyConfig", "proxy.%s/cluster not found", configv1.GroupName)
+			return map[string]interface{}{}, nil
+		}
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if len(proxy.Status.HTTPProxy) == 0 && len(proxy.Status.HTTPSProxy) == 0 {
+			return map[string]interface{}{}, nil
+		}
+
+		exempted, err := requiredNoProxy(listers, recorder)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		proxyEnv := map[string]string{"NO_PROXY": mergeNoProxy(proxy.Status.NoProxy, exempted)}
+		if len(proxy.Status.HTTPProxy) > 0 {
+			proxyEnv["HTTP_PROXY"] = proxy.Status.HTTPProxy
+		}
+		if len(proxy.Status.HTTPSProxy) > 0 {
+			proxyEnv["HTTPS_PROXY"] = proxy.Status.HTTPSProxy
+		}
+
+		observedConfig := map[string]interface{}{}
+		if err := unstructured.SetNestedStringMap(observedConfig, proxyEnv, configPath...); err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !reflect.DeepEqual(previousProxy, proxyEnv) {
+			recorder.Eventf("ObserveProxyConfig", "proxy changed to %q", proxyEnv)
+		}
+		return observedConfig, nil
+	}
+}
+
+// requiredNoProxy returns the cluster networks, the service networks and the hosts of the kube-apiserver URLs.
+func requiredNoProxy(listers configobservation.Listers, recorder events.Recorder) ([]string, error) {
+	clusterCIDRs, err := network.GetClusterCIDRs(listers.NetworkLister, recorder)
+	if err != nil {
+		return nil, err
+	}
+	serviceCIDRs, err := network.GetServiceCIDRs(listers.NetworkLister, recorder)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterCIDRs) == 0 || len(serviceCIDRs) == 0 {
+		return nil, fmt.Errorf("networks.%s/cluster not found, the cluster and service networks cannot be exempted from the proxy", configv1.GroupName)
+	}
+
+	infrastructure, err := listers.InfrastructureLister().Get("cluster")
+	if err != nil {
+		return nil, err
+	}
+	var apiServerHosts []string
+	for _, apiServerURL := range []string{infrastructure.Status.APIServerInternalURL, infrastructure.Status.APIServerURL} {
+		if len(apiServerURL) == 0 {
+			continue
+		}
+		parsed, err := url.Parse(apiServerURL)
+		if err != nil {
+			return nil, fmt.Errorf("infrastructures.%s/cluster: %w", configv1.GroupName, err)
+		}
+		apiServerHosts = append(apiServerHosts, parsed.Hostname())
+	}
+
+	return append(append(clusterCIDRs, serviceCIDRs...), apiServerHosts...), nil
+}
+
+// mergeNoProxy appends the entries of required noProxy misses to it.
+func mergeNoProxy(noProxy string, required []string) string {
+	var entries []string
+	seen := sets.New[string]()
+	for _, entry := range append(strings.Split(noProxy, ","), required...) {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 || seen.Has(entry) {
+			continue
+		}
+		seen.Insert(entry)
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+)
+
+func proxyConfig(env map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"targetconfigcontroller": map[string]interface{}{
+			"proxy": env,
+		},
+	}
+}
+
+func TestObserveProxy(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxy        *configv1.ProxyStatus
+		network      *configv1.NetworkStatus
+		input        map[string]interface{}
+		expected     map[string]interface{}
+		expectErrors bool
+	}{
+		{
+			name:     "no proxy",
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "proxy without http and https proxy",
+			proxy:    &configv1.ProxyStatus{NoProxy: ".example.com"},
+			input:    proxyConfig(map[string]interface{}{"HTTP_PROXY": "http://proxy.example.com:3128"}),
+			expected: map[string]interface{}{},
+		},
+		{
+			name:  "no proxy is completed",
+			proxy: &configv1.ProxyStatus{HTTPProxy: "http://proxy.example.com:3128", HTTPSProxy: "https://proxy.example.com:3129"},
+			input: map[string]interface{}{},
+			expected: proxyConfig(map[string]interface{}{
+				"HTTP_PROXY":  "http://proxy.example.com:3128",
+				"HTTPS_PROXY": "https://proxy.example.com:3129",
+				"NO_PROXY":    "10.128.0.0/14,fd01::/48,172.30.0.0/16,api-int.cluster.example.com,api.cluster.example.com",
+			}),
+		},
+		{
+			name:  "no proxy entries are kept in order without duplicates",
+			proxy: &configv1.ProxyStatus{HTTPSProxy: "https://proxy.example.com:3129", NoProxy: "172.30.0.0/16, .example.com,,169.254.169.254,.example.com"},
+			input: map[string]interface{}{},
+			expected: proxyConfig(map[string]interface{}{
+				"HTTPS_PROXY": "https://proxy.example.com:3129",
+				"NO_PROXY":    "172.30.0.0/16,.example.com,169.254.169.254,10.128.0.0/14,fd01::/48,api-int.cluster.example.com,api.cluster.example.com",
+			}),
+		},
+		{
+			name:         "unknown networks keep the previous proxy",
+			proxy:        &configv1.ProxyStatus{HTTPProxy: "http://other-proxy.example.com:3128"},
+			network:      &configv1.NetworkStatus{},
+			input:        proxyConfig(map[string]interface{}{"HTTP_PROXY": "http://proxy.example.com:3128", "NO_PROXY": "172.30.0.0/16"}),
+			expected:     proxyConfig(map[string]interface{}{"HTTP_PROXY": "http://proxy.example.com:3128", "NO_PROXY": "172.30.0.0/16"}),
+			expectErrors: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxyIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if test.proxy != nil {
+				if err := proxyIndexer.Add(&configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: *test.proxy}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			network := &configv1.NetworkStatus{
+				ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14"}, {CIDR: "fd01::/48"}},
+				ServiceNetwork: []string{"172.30.0.0/16"},
+			}
+			if test.network != nil {
+				network = test.network
+			}
+			networkIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := networkIndexer.Add(&configv1.Network{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: *network}); err != nil {
+				t.Fatal(err)
+			}
+			infrastructureIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := infrastructureIndexer.Add(&configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.InfrastructureStatus{
+					APIServerURL:         "https://api.cluster.example.com:6443",
+					APIServerInternalURL: "https://api-int.cluster.example.com:6443",
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			listers := configobservation.Listers{
+				ProxyLister_:          configlistersv1.NewProxyLister(proxyIndexer),
+				NetworkLister:         configlistersv1.NewNetworkLister(networkIndexer),
+				InfrastructureLister_: configlistersv1.NewInfrastructureLister(infrastructureIndexer),
+			}
+
+			observe := NewObserveProxyFunc([]string{"targetconfigcontroller", "proxy"})
+			result, errs := observe(listers, events.NewInMemoryRecorder("test"), test.input)
+			if test.expectErrors != (len(errs) > 0) {
+				t.Fatalf("expected errors: %v, got %v", test.expectErrors, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
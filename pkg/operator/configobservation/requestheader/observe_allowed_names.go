@@ -0,0 +1,73 @@
+package requestheader
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// FrontProxyClientCertSecretName is the client certificate kube-apiserver uses when it proxies requests to
+	// aggregated apiservers. Its CN is the only name kube-controller-manager has to accept request headers from. The
+	// kube-apiserver-operator publishes it in openshift-config-managed, the copy in openshift-kube-apiserver follows.
+	FrontProxyClientCertSecretName = "aggregator-client"
+)
+
+var allowedNamesPath = []string{"extendedArguments", "requestheader-allowed-names"}
+
+// ObserveRequestHeaderAllowedNames sets --requestheader-allowed-names to the CN of the current front-proxy client
+// certificate, so that the flag follows the certificate when it is rotated instead of going stale.
+func ObserveRequestHeaderAllowedNames(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+	listers := genericListers.(configobservation.Listers)
+	errs := []error{}
+	prevObservedConfig := map[string]interface{}{}
+
+	currentAllowedNames, _, err := unstructured.NestedStringSlice(existingConfig, allowedNamesPath...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(currentAllowedNames) > 0 {
+		if err := unstructured.SetNestedStringSlice(prevObservedConfig, currentAllowedNames, allowedNamesPath...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	secret, err := listers.SecretLister().Secrets(operatorclient.GlobalMachineSpecifiedConfigNamespace).Get(FrontProxyClientCertSecretName)
+	if errors.IsNotFound(err) {
+		// without the certificate there is nothing to compare against, keep what we rendered before
+		return prevObservedConfig, errs
+	}
+	if err != nil {
+		return prevObservedConfig, append(errs, err)
+	}
+	certs, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return prevObservedConfig, append(errs, fmt.Errorf("secret/%s -n %s: %v", FrontProxyClientCertSecretName, operatorclient.GlobalMachineSpecifiedConfigNamespace, err))
+	}
+	commonName := certs[0].Subject.CommonName
+	if len(commonName) == 0 {
+		return prevObservedConfig, append(errs, fmt.Errorf("secret/%s -n %s: certificate has no common name", FrontProxyClientCertSecretName, operatorclient.GlobalMachineSpecifiedConfigNamespace))
+	}
+
+	observedConfig := map[string]interface{}{}
+	if err := unstructured.SetNestedStringSlice(observedConfig, []string{commonName}, allowedNamesPath...); err != nil {
+		return prevObservedConfig, append(errs, err)
+	}
+	if !equality.Semantic.DeepEqual(prevObservedConfig, observedConfig) {
+		if len(currentAllowedNames) > 0 {
+			recorder.Warningf("RequestHeaderAllowedNamesMismatch", "--requestheader-allowed-names=%v does not match the front-proxy client certificate CN %q, updating it", currentAllowedNames, commonName)
+		} else {
+			recorder.Eventf("ObserveRequestHeaderAllowedNames", "--requestheader-allowed-names set to %q", commonName)
+		}
+	}
+	return observedConfig, errs
+}
@@ -0,0 +1,118 @@
+package requestheader
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func frontProxyClientCert(t *testing.T, commonName string) *corev1.Secret {
+	ca, err := crypto.MakeSelfSignedCAConfig(commonName, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := ca.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: FrontProxyClientCertSecretName},
+		Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+	}
+}
+
+func allowedNames(names ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"extendedArguments": map[string]interface{}{
+			"requestheader-allowed-names": names,
+		},
+	}
+}
+
+func TestObserveRequestHeaderAllowedNames(t *testing.T) {
+	tests := []struct {
+		name           string
+		secret         *corev1.Secret
+		input          map[string]interface{}
+		expected       map[string]interface{}
+		expectedEvents []string
+		expectErrors   bool
+	}{
+		{
+			name:           "initial CN",
+			secret:         frontProxyClientCert(t, "system:openshift-aggregator"),
+			input:          map[string]interface{}{},
+			expected:       allowedNames("system:openshift-aggregator"),
+			expectedEvents: []string{"ObserveRequestHeaderAllowedNames"},
+		},
+		{
+			name:           "unchanged CN",
+			secret:         frontProxyClientCert(t, "system:openshift-aggregator"),
+			input:          allowedNames("system:openshift-aggregator"),
+			expected:       allowedNames("system:openshift-aggregator"),
+			expectedEvents: []string{},
+		},
+		{
+			name:           "CN change after rotation is propagated",
+			secret:         frontProxyClientCert(t, "system:openshift-aggregator-2"),
+			input:          allowedNames("system:openshift-aggregator"),
+			expected:       allowedNames("system:openshift-aggregator-2"),
+			expectedEvents: []string{"RequestHeaderAllowedNamesMismatch"},
+		},
+		{
+			name:           "missing certificate keeps the current names",
+			input:          allowedNames("system:openshift-aggregator"),
+			expected:       allowedNames("system:openshift-aggregator"),
+			expectedEvents: []string{},
+		},
+		{
+			name: "unparseable certificate keeps the current names",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: FrontProxyClientCertSecretName},
+				Data:       map[string][]byte{"tls.crt": []byte("garbage")},
+			},
+			input:          allowedNames("system:openshift-aggregator"),
+			expected:       allowedNames("system:openshift-aggregator"),
+			expectedEvents: []string{},
+			expectErrors:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if test.secret != nil {
+				if err := indexer.Add(test.secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := configobservation.Listers{
+				SecretLister_: corev1listers.NewSecretLister(indexer),
+			}
+			recorder := events.NewInMemoryRecorder("requestheader")
+			result, errs := ObserveRequestHeaderAllowedNames(listers, recorder, test.input)
+			if test.expectErrors != (len(errs) > 0) {
+				t.Errorf("expected errors: %v, got %v", test.expectErrors, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+			reasons := []string{}
+			for _, event := range recorder.Events() {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(test.expectedEvents, reasons) {
+				t.Errorf("expected events %v, got %v", test.expectedEvents, reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Fields is the tree of the JSON field names of a config type. A nil subtree is a field whose content is not checked:
+// a value, a list, a map with arbitrary keys or a type with its own JSON decoding.
+type Fields map[string]Fields
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// FieldsOf returns the union of the JSON fields of the given structs. A field that is a struct in one and not checked
+// in another is not checked.
+func FieldsOf(structs ...interface{}) Fields {
+	fields := Fields{}
+	for _, s := range structs {
+		fields = mergeFields(fields, fieldsOf(reflect.TypeOf(s), map[reflect.Type]bool{}))
+	}
+	return fields
+}
+
+func fieldsOf(t reflect.Type, visiting map[reflect.Type]bool) Fields {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// a recursive type is checked up to its first recursion
+	if t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(unmarshalerType) || visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	fields := Fields{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !field.IsExported():
+		case field.Anonymous && len(name) == 0:
+			fields = mergeFields(fields, fieldsOf(field.Type, visiting))
+		case len(name) == 0:
+			fields = mergeFields(fields, Fields{field.Name: fieldsOf(field.Type, visiting)})
+		default:
+			fields = mergeFields(fields, Fields{name: fieldsOf(field.Type, visiting)})
+		}
+	}
+	return fields
+}
+
+func mergeFields(a, b Fields) Fields {
+	if a == nil || b == nil {
+		return nil
+	}
+	merged := Fields{}
+	for name, fields := range a {
+		merged[name] = fields
+	}
+	for name, fields := range b {
+		if existing, ok := merged[name]; ok {
+			merged[name] = mergeFields(existing, fields)
+			continue
+		}
+		merged[name] = fields
+	}
+	return merged
+}
+
+// UnknownFieldError is a field of a config its type does not have. The decoding of the config drops it silently.
+type UnknownFieldError struct {
+	// Path are the names of the field and of its parents, e.g. [extendedArgument]
+	Path []string
+	// Suggestion is the known field closest to the unknown one on the same level, empty when none is close
+	Suggestion string
+}
+
+func (e *UnknownFieldError) Error() string {
+	if len(e.Suggestion) == 0 {
+		return fmt.Sprintf("%s: is not a known field", strings.Join(e.Path, "."))
+	}
+	return fmt.Sprintf("%s: is not a known field, did you mean %s?", strings.Join(e.Path, "."), e.Suggestion)
+}
+
+// UnknownFields returns the fields of config that are not in known, ordered by their paths. The fields below an
+// unknown field are not reported.
+func UnknownFields(config map[string]interface{}, known Fields) []*UnknownFieldError {
+	return unknownFields(nil, config, known)
+}
+
+func unknownFields(path []string, config map[string]interface{}, known Fields) []*UnknownFieldError {
+	if known == nil {
+		return nil
+	}
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unknown []*UnknownFieldError
+	for _, name := range names {
+		fieldPath := append(append([]string{}, path...), name)
+		fields, ok := known[name]
+		if !ok {
+			unknown = append(unknown, &UnknownFieldError{Path: fieldPath, Suggestion: closestField(name, known)})
+			continue
+		}
+		if value, ok := config[name].(map[string]interface{}); ok {
+			unknown = append(unknown, unknownFields(fieldPath, value, fields)...)
+		}
+	}
+	return unknown
+}
+
+// closestField returns the known field with the smallest edit distance to name, when at most a third of the known
+// name has to be edited. Ties go to the first name in lexical order.
+func closestField(name string, known Fields) string {
+	candidates := make([]string, 0, len(known))
+	for candidate := range known {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+
+	closest, closestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance*3 > len(candidate) {
+			continue
+		}
+		if closestDistance < 0 || distance < closestDistance {
+			closest, closestDistance = candidate, distance
+		}
+	}
+	return closest
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
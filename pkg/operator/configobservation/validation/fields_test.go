@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testServing struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type testConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	ServingInfo       *testServing        `json:"servingInfo"`
+	ExtendedArguments map[string][]string `json:"extendedArguments"`
+	SyncPeriod        metav1.Duration     `json:"syncPeriod"`
+	Next              *testConfig         `json:"next,omitempty"`
+	Ignored           string              `json:"-"`
+}
+
+type testOtherConfig struct {
+	ServingInfo struct {
+		MinTLSVersion string `json:"minTLSVersion"`
+	} `json:"servingInfo"`
+	FeatureGates []string `json:"featureGates"`
+}
+
+func TestFieldsOf(t *testing.T) {
+	expected := Fields{
+		"apiVersion": nil,
+		"kind":       nil,
+		"servingInfo": Fields{
+			"certFile":      nil,
+			"keyFile":       nil,
+			"minTLSVersion": nil,
+		},
+		"extendedArguments": nil,
+		"syncPeriod":        nil,
+		"next":              nil,
+		"featureGates":      nil,
+	}
+	if actual := FieldsOf(testConfig{}, testOtherConfig{}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	known := FieldsOf(testConfig{}, testOtherConfig{})
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "known fields",
+			config: map[string]interface{}{
+				"extendedArguments": map[string]interface{}{"any-flag": []interface{}{"1"}},
+				"servingInfo":       map[string]interface{}{"certFile": "tls.crt", "minTLSVersion": "VersionTLS12"},
+			},
+		},
+		{
+			name: "typos",
+			config: map[string]interface{}{
+				"extendedArgument": map[string]interface{}{"cluster-cidr": []interface{}{"10.128.0.0/14"}},
+				"ServingInfo":      map[string]interface{}{"certFile": "tls.crt"},
+				"servingInfo":      map[string]interface{}{"certfile": "tls.crt", "minTlsVersion": "VersionTLS12", "keyFiles": "tls.key"},
+			},
+			expected: []string{
+				"ServingInfo: is not a known field, did you mean servingInfo?",
+				"extendedArgument: is not a known field, did you mean extendedArguments?",
+				"servingInfo.certfile: is not a known field, did you mean certFile?",
+				"servingInfo.keyFiles: is not a known field, did you mean keyFile?",
+				"servingInfo.minTlsVersion: is not a known field, did you mean minTLSVersion?",
+			},
+		},
+		{
+			name:     "nothing close",
+			config:   map[string]interface{}{"proxy": map[string]interface{}{}},
+			expected: []string{"proxy: is not a known field"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual []string
+			for _, unknown := range UnknownFields(test.config, known) {
+				actual = append(actual, unknown.Error())
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
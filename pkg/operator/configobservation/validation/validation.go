@@ -0,0 +1,130 @@
+// Package validation checks the values the config observers read from the cluster config, the
+// unsupportedConfigOverrides and annotations. The errors name the JSON path of the value and the accepted values in
+// the same format for every observer, e.g.
+//
+//	extendedArguments.node-monitor-grace-period: "-5s" must be a duration of at least 0s
+//
+// None of the validators panics on bad input.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+// Error is a value outside of the accepted ones.
+type Error struct {
+	// Path is the JSON path of the value, e.g. extendedArguments.cluster-signing-duration
+	Path string
+	// Value is the invalid value as it was read
+	Value string
+	// Accepted describes the accepted values, e.g. "a duration between 1s and 1m0s"
+	Accepted string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %q must be %s", e.Path, e.Value, e.Accepted)
+}
+
+// AnnotationPath is the JSON path of an annotation of the object metadata.
+func AnnotationPath(annotation string) string {
+	return fmt.Sprintf("metadata.annotations[%s]", annotation)
+}
+
+// DurationBetween parses value as a duration between min and max, inclusive. A max of 0 is no upper bound.
+func DurationBetween(path, value string, min, max time.Duration) (time.Duration, error) {
+	accepted := fmt.Sprintf("a duration between %s and %s", min, max)
+	if max == 0 {
+		accepted = fmt.Sprintf("a duration of at least %s", min)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < min || (max != 0 && duration > max) {
+		return 0, &Error{Path: path, Value: value, Accepted: accepted}
+	}
+	return duration, nil
+}
+
+// IntBetween parses value as an integer between min and max, inclusive. A max of math.MaxInt is no upper bound.
+func IntBetween(path, value string, min, max int) (int, error) {
+	accepted := fmt.Sprintf("an integer between %d and %d", min, max)
+	if max == math.MaxInt {
+		accepted = fmt.Sprintf("an integer of at least %d", min)
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return 0, &Error{Path: path, Value: value, Accepted: accepted}
+	}
+	return number, nil
+}
+
+// FloatRatio parses value as a ratio between 0 and 1, inclusive.
+func FloatRatio(path, value string) (float64, error) {
+	ratio, err := strconv.ParseFloat(value, 64)
+	// NaN fails both comparisons
+	if err != nil || !(ratio >= 0 && ratio <= 1) {
+		return 0, &Error{Path: path, Value: value, Accepted: "a ratio between 0 and 1"}
+	}
+	return ratio, nil
+}
+
+// FloatAtLeast parses value as a finite number of at least min.
+func FloatAtLeast(path, value string, min float64) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	// NaN fails the comparison
+	if err != nil || !(number >= min) || math.IsInf(number, 1) {
+		return 0, &Error{Path: path, Value: value, Accepted: fmt.Sprintf("a number of at least %v", min)}
+	}
+	return number, nil
+}
+
+// EnumOf returns value when it is one of allowed.
+func EnumOf(path, value string, allowed ...string) (string, error) {
+	for _, a := range allowed {
+		if value == a {
+			return value, nil
+		}
+	}
+	quoted := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		quoted = append(quoted, strconv.Quote(a))
+	}
+	return "", &Error{Path: path, Value: value, Accepted: "one of " + strings.Join(quoted, ", ")}
+}
+
+// LeaderElection checks the timings of a client-go leader elector, which refuses to start with a renew deadline not
+// shorter than the lease duration or not longer than leaderelection.JitterFactor retry periods. The paths are the ones
+// of the renew deadline and of the retry period.
+func LeaderElection(renewDeadlinePath, retryPeriodPath string, leaseDuration, renewDeadline, retryPeriod time.Duration) error {
+	if renewDeadline >= leaseDuration {
+		return &Error{Path: renewDeadlinePath, Value: renewDeadline.String(), Accepted: fmt.Sprintf("shorter than the lease duration %s", leaseDuration)}
+	}
+	if renewDeadline <= time.Duration(leaderelection.JitterFactor*float64(retryPeriod)) {
+		return &Error{Path: retryPeriodPath, Value: retryPeriod.String(), Accepted: fmt.Sprintf("shorter than the renew deadline %s divided by %v", renewDeadline, leaderelection.JitterFactor)}
+	}
+	return nil
+}
+
+// Sorted orders errs by their messages and drops the duplicates. The condition message aggregating the errors does
+// not change when an observer reports them in a different order.
+func Sorted(errs []error) []error {
+	if len(errs) < 2 {
+		return errs
+	}
+	sorted := make([]error, 0, len(errs))
+	seen := map[string]bool{}
+	for _, err := range errs {
+		if err == nil || seen[err.Error()] {
+			continue
+		}
+		seen[err.Error()] = true
+		sorted = append(sorted, err)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Error() < sorted[j].Error() })
+	return sorted
+}
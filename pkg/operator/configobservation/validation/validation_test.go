@@ -0,0 +1,145 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestDurationBetween(t *testing.T) {
+	tests := []struct {
+		value       string
+		min, max    time.Duration
+		expected    time.Duration
+		expectedErr string
+	}{
+		{value: "90s", min: time.Second, max: time.Hour, expected: 90 * time.Second},
+		{value: "1s", min: time.Second, max: time.Hour, expected: time.Second},
+		{value: "1h", min: time.Second, max: time.Hour, expected: time.Hour},
+		{value: "0s", min: time.Second, max: time.Hour, expectedErr: `a.b: "0s" must be a duration between 1s and 1h0m0s`},
+		{value: "2h", min: time.Second, max: time.Hour, expectedErr: `a.b: "2h" must be a duration between 1s and 1h0m0s`},
+		{value: "720h", expected: 720 * time.Hour},
+		{value: "-1s", expectedErr: `a.b: "-1s" must be a duration of at least 0s`},
+		{value: "forty seconds", expectedErr: `a.b: "forty seconds" must be a duration of at least 0s`},
+		{value: "", expectedErr: `a.b: "" must be a duration of at least 0s`},
+	}
+	for _, test := range tests {
+		actual, err := DurationBetween("a.b", test.value, test.min, test.max)
+		if errorString(err) != test.expectedErr || actual != test.expected {
+			t.Errorf("%q: expected %v %q, got %v %v", test.value, test.expected, test.expectedErr, actual, err)
+		}
+	}
+}
+
+func TestIntBetween(t *testing.T) {
+	tests := []struct {
+		value       string
+		min, max    int
+		expected    int
+		expectedErr string
+	}{
+		{value: "3", min: 1, max: 5, expected: 3},
+		{value: "5", min: 1, max: 5, expected: 5},
+		{value: "6", min: 1, max: 5, expectedErr: `a.b: "6" must be an integer between 1 and 5`},
+		{value: "0", min: 1, max: math.MaxInt, expectedErr: `a.b: "0" must be an integer of at least 1`},
+		{value: "1.5", min: 1, max: math.MaxInt, expectedErr: `a.b: "1.5" must be an integer of at least 1`},
+		{value: "99999999999999999999", min: 1, max: math.MaxInt, expectedErr: `a.b: "99999999999999999999" must be an integer of at least 1`},
+	}
+	for _, test := range tests {
+		actual, err := IntBetween("a.b", test.value, test.min, test.max)
+		if errorString(err) != test.expectedErr || actual != test.expected {
+			t.Errorf("%q: expected %v %q, got %v %v", test.value, test.expected, test.expectedErr, actual, err)
+		}
+	}
+}
+
+func TestFloatRatio(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    float64
+		expectedErr string
+	}{
+		{value: "0", expected: 0},
+		{value: "0.55", expected: 0.55},
+		{value: "1", expected: 1},
+		{value: "1.01", expectedErr: `a.b: "1.01" must be a ratio between 0 and 1`},
+		{value: "-0.1", expectedErr: `a.b: "-0.1" must be a ratio between 0 and 1`},
+		{value: "NaN", expectedErr: `a.b: "NaN" must be a ratio between 0 and 1`},
+		{value: "55%", expectedErr: `a.b: "55%" must be a ratio between 0 and 1`},
+	}
+	for _, test := range tests {
+		actual, err := FloatRatio("a.b", test.value)
+		if errorString(err) != test.expectedErr || actual != test.expected {
+			t.Errorf("%q: expected %v %q, got %v %v", test.value, test.expected, test.expectedErr, actual, err)
+		}
+	}
+}
+
+func TestFloatAtLeast(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    float64
+		expectedErr string
+	}{
+		{value: "0", expected: 0},
+		{value: "0.05", expected: 0.05},
+		{value: "12", expected: 12},
+		{value: "-0.1", expectedErr: `a.b: "-0.1" must be a number of at least 0`},
+		{value: "NaN", expectedErr: `a.b: "NaN" must be a number of at least 0`},
+		{value: "+Inf", expectedErr: `a.b: "+Inf" must be a number of at least 0`},
+		{value: "1/s", expectedErr: `a.b: "1/s" must be a number of at least 0`},
+	}
+	for _, test := range tests {
+		actual, err := FloatAtLeast("a.b", test.value, 0)
+		if errorString(err) != test.expectedErr || actual != test.expected {
+			t.Errorf("%q: expected %v %q, got %v %v", test.value, test.expected, test.expectedErr, actual, err)
+		}
+	}
+}
+
+func TestEnumOf(t *testing.T) {
+	if actual, err := EnumOf(AnnotationPath("a"), "B", "A", "B"); err != nil || actual != "B" {
+		t.Errorf("expected B, got %q %v", actual, err)
+	}
+	expectedErr := `metadata.annotations[a]: "b" must be one of "A", "B"`
+	if actual, err := EnumOf(AnnotationPath("a"), "b", "A", "B"); errorString(err) != expectedErr || actual != "" {
+		t.Errorf("expected %q, got %q %v", expectedErr, actual, err)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	tests := []struct {
+		leaseDuration, renewDeadline, retryPeriod time.Duration
+		expectedErr                               string
+	}{
+		{leaseDuration: 137 * time.Second, renewDeadline: 107 * time.Second, retryPeriod: 26 * time.Second},
+		{leaseDuration: 15 * time.Second, renewDeadline: 15 * time.Second, retryPeriod: 2 * time.Second, expectedErr: `renew: "15s" must be shorter than the lease duration 15s`},
+		{leaseDuration: 15 * time.Second, renewDeadline: 12 * time.Second, retryPeriod: 10 * time.Second, expectedErr: `retry: "10s" must be shorter than the renew deadline 12s divided by 1.2`},
+		{leaseDuration: 15 * time.Second, renewDeadline: 12 * time.Second, retryPeriod: 20 * time.Second, expectedErr: `retry: "20s" must be shorter than the renew deadline 12s divided by 1.2`},
+	}
+	for _, test := range tests {
+		err := LeaderElection("renew", "retry", test.leaseDuration, test.renewDeadline, test.retryPeriod)
+		if errorString(err) != test.expectedErr {
+			t.Errorf("%v/%v/%v: expected %q, got %v", test.leaseDuration, test.renewDeadline, test.retryPeriod, test.expectedErr, err)
+		}
+	}
+}
+
+func TestSorted(t *testing.T) {
+	a, b, c := fmt.Errorf("a"), fmt.Errorf("b"), fmt.Errorf("c")
+	if actual := Sorted([]error{c, nil, a, b, fmt.Errorf("a")}); !reflect.DeepEqual(actual, []error{a, b, c}) {
+		t.Errorf("expected [a b c], got %v", actual)
+	}
+	if actual := Sorted(nil); actual != nil {
+		t.Errorf("expected no errors, got %v", actual)
+	}
+}
@@ -0,0 +1,96 @@
+package workloadprofile
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// ProfileOverrideField of the unsupportedConfigOverrides selects the controller workload profile, which sizes the
+	// concurrency of the workload controllers and the client rate limits of kube-controller-manager to the cluster.
+	// Without it the Default profile applies. The unsupportedConfigOverrides of a single argument win over the profile.
+	ProfileOverrideField = "controllerWorkloadProfile"
+
+	// ProfileDefault keeps the kube-controller-manager defaults and the ones of the default config.
+	ProfileDefault = "Default"
+	// ProfileLarge is meant for clusters of about 250 nodes and more.
+	ProfileLarge = "Large"
+	// ProfileExtraLarge is meant for clusters of about 1000 nodes and more.
+	ProfileExtraLarge = "ExtraLarge"
+)
+
+// profileArguments are the extendedArguments of the profiles. Every profile other than Default sets all of them, so
+// switching profiles replaces the whole set in one observation.
+var profileArguments = map[string]map[string]string{
+	ProfileDefault: {},
+	ProfileLarge: {
+		"concurrent-deployment-syncs": "10",
+		"concurrent-replicaset-syncs": "10",
+		"concurrent-gc-syncs":         "30",
+		"kube-api-qps":                "300",
+		"kube-api-burst":              "600",
+	},
+	ProfileExtraLarge: {
+		"concurrent-deployment-syncs": "20",
+		"concurrent-replicaset-syncs": "20",
+		"concurrent-gc-syncs":         "50",
+		"kube-api-qps":                "600",
+		"kube-api-burst":              "1200",
+	},
+}
+
+// argumentNames are the extendedArguments any profile sets.
+var argumentNames = []string{"concurrent-deployment-syncs", "concurrent-replicaset-syncs", "concurrent-gc-syncs", "kube-api-qps", "kube-api-burst"}
+
+func init() {
+	overrides.Register(ProfileOverrideField)
+}
+
+// NewObserveWorkloadProfileFunc renders the arguments of the profile set by ProfileOverrideField. An invalid profile
+// keeps the previously observed arguments.
+func NewObserveWorkloadProfileFunc(operator overrides.SpecGetter) configobserver.ObserveConfigFunc {
+	return func(_ configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		previouslyObservedConfig := map[string]interface{}{}
+		for _, name := range argumentNames {
+			path := []string{"extendedArguments", name}
+			if current, _, _ := unstructured.NestedStringSlice(existingConfig, path...); len(current) > 0 {
+				if err := unstructured.SetNestedStringSlice(previouslyObservedConfig, current, path...); err != nil {
+					return previouslyObservedConfig, []error{err}
+				}
+			}
+		}
+
+		unsupportedConfigOverrides, err := overrides.OfSpec(operator)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		profile, ok, err := overrides.String(unsupportedConfigOverrides, ProfileOverrideField)
+		if err != nil {
+			return previouslyObservedConfig, []error{err}
+		}
+		if !ok {
+			profile = ProfileDefault
+		}
+		if _, err := validation.EnumOf(overrides.Path(ProfileOverrideField), profile, ProfileDefault, ProfileLarge, ProfileExtraLarge); err != nil {
+			recorder.Warningf("ObserveControllerWorkloadProfile", "Keeping the previous controller workload profile: %v", err)
+			return previouslyObservedConfig, []error{err}
+		}
+
+		observedConfig := map[string]interface{}{}
+		for name, value := range profileArguments[profile] {
+			if err := unstructured.SetNestedStringSlice(observedConfig, []string{value}, "extendedArguments", name); err != nil {
+				return previouslyObservedConfig, []error{err}
+			}
+		}
+		if !equality.Semantic.DeepEqual(previouslyObservedConfig, observedConfig) {
+			recorder.Eventf("ObserveControllerWorkloadProfile", "Controller workload profile changed to %s", profile)
+		}
+		return observedConfig, nil
+	}
+}
@@ -0,0 +1,183 @@
+package workloadprofile
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+)
+
+type fakeOperator map[string]interface{}
+
+func (f fakeOperator) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	unsupportedConfigOverrides, err := json.Marshal(map[string]interface{}(f))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &operatorv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: unsupportedConfigOverrides}}, &operatorv1.OperatorStatus{}, "", nil
+}
+
+func profileConfig(arguments map[string]string) map[string]interface{} {
+	if len(arguments) == 0 {
+		return map[string]interface{}{}
+	}
+	extendedArguments := map[string]interface{}{}
+	for name, value := range arguments {
+		extendedArguments[name] = []interface{}{value}
+	}
+	return map[string]interface{}{"extendedArguments": extendedArguments}
+}
+
+var (
+	largeArguments = map[string]string{
+		"concurrent-deployment-syncs": "10",
+		"concurrent-replicaset-syncs": "10",
+		"concurrent-gc-syncs":         "30",
+		"kube-api-qps":                "300",
+		"kube-api-burst":              "600",
+	}
+	extraLargeArguments = map[string]string{
+		"concurrent-deployment-syncs": "20",
+		"concurrent-replicaset-syncs": "20",
+		"concurrent-gc-syncs":         "50",
+		"kube-api-qps":                "600",
+		"kube-api-burst":              "1200",
+	}
+)
+
+func TestObserveWorkloadProfile(t *testing.T) {
+	tests := []struct {
+		name            string
+		overrides       map[string]interface{}
+		input, expected map[string]interface{}
+		expectedError   bool
+	}{
+		{
+			name:     "no override",
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:      "Default",
+			overrides: map[string]interface{}{ProfileOverrideField: ProfileDefault},
+			input:     map[string]interface{}{},
+			expected:  map[string]interface{}{},
+		},
+		{
+			name:      "Large",
+			overrides: map[string]interface{}{ProfileOverrideField: ProfileLarge},
+			input:     map[string]interface{}{},
+			expected:  profileConfig(largeArguments),
+		},
+		{
+			name:      "ExtraLarge",
+			overrides: map[string]interface{}{ProfileOverrideField: ProfileExtraLarge},
+			input:     profileConfig(largeArguments),
+			expected:  profileConfig(extraLargeArguments),
+		},
+		{
+			name:     "override removed",
+			input:    profileConfig(extraLargeArguments),
+			expected: map[string]interface{}{},
+		},
+		{
+			name:          "invalid profile keeps the previous arguments",
+			overrides:     map[string]interface{}{ProfileOverrideField: "large"},
+			input:         profileConfig(largeArguments),
+			expected:      profileConfig(largeArguments),
+			expectedError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			observe := NewObserveWorkloadProfileFunc(fakeOperator(test.overrides))
+			result, errs := observe(nil, events.NewInMemoryRecorder("test"), test.input)
+			if test.expectedError != (len(errs) > 0) {
+				t.Fatalf("expected error: %v, got %v", test.expectedError, errs)
+			}
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+// TestObserveWorkloadProfileSwitch checks that a switch of profiles changes the observed config once, which rolls out
+// one revision.
+func TestObserveWorkloadProfileSwitch(t *testing.T) {
+	operator := fakeOperator{ProfileOverrideField: ProfileLarge}
+	observe := NewObserveWorkloadProfileFunc(operator)
+	recorder := events.NewInMemoryRecorder("test")
+
+	config, _ := observe(nil, recorder, map[string]interface{}{})
+	operator[ProfileOverrideField] = ProfileExtraLarge
+	var changes int
+	for i := 0; i < 3; i++ {
+		observed, errs := observe(nil, recorder, config)
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		if !reflect.DeepEqual(config, observed) {
+			changes++
+		}
+		config = observed
+	}
+	if changes != 1 {
+		t.Errorf("expected one change of the observed config, got %d", changes)
+	}
+	if !reflect.DeepEqual(profileConfig(extraLargeArguments), config) {
+		t.Errorf("expected %v, got %v", profileConfig(extraLargeArguments), config)
+	}
+	if events := recorder.Events(); len(events) != 2 {
+		t.Errorf("expected an event per profile, got %v", events)
+	}
+}
+
+// TestWorkloadProfileOverridePrecedence checks that the unsupportedConfigOverrides of an argument win over the profile,
+// merged like the config of the revisions.
+func TestWorkloadProfileOverridePrecedence(t *testing.T) {
+	observe := NewObserveWorkloadProfileFunc(fakeOperator{ProfileOverrideField: ProfileLarge})
+	observed, errs := observe(nil, events.NewInMemoryRecorder("test"), map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	observedConfig, err := json.Marshal(observed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := resourcemerge.MergeProcessConfig(nil,
+		bindata.MustAsset("assets/config/defaultconfig.yaml"),
+		observedConfig,
+		[]byte(`{"extendedArguments":{"kube-api-qps":["450"],"concurrent-gc-syncs":["40"]}}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		ExtendedArguments map[string][]string `json:"extendedArguments"`
+	}{}
+	if err := yaml.Unmarshal(merged, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{}
+	for name, value := range largeArguments {
+		expected[name] = value
+	}
+	expected["kube-api-qps"] = "450"
+	expected["concurrent-gc-syncs"] = "40"
+	for name, value := range expected {
+		if actual := config.ExtendedArguments[name]; !reflect.DeepEqual([]string{value}, actual) {
+			t.Errorf("%s: expected %q, got %q", name, value, actual)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package controlplanenodes tells the control plane nodes apart by their role labels. Clusters are moving from
+// node-role.kubernetes.io/master to node-role.kubernetes.io/control-plane, a control plane node may carry either
+// label or both.
+package controlplanenodes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ControlPlaneLabel is the role label of control plane nodes, preferred over MasterLabel.
+	ControlPlaneLabel = "node-role.kubernetes.io/control-plane"
+	// MasterLabel is the deprecated role label of control plane nodes.
+	MasterLabel = "node-role.kubernetes.io/master"
+)
+
+// Convention is the role label the control plane nodes of a cluster carry.
+type Convention string
+
+const (
+	// ConventionControlPlane clusters label every control plane node with ControlPlaneLabel, some may carry
+	// MasterLabel as well.
+	ConventionControlPlane Convention = "control-plane"
+	// ConventionMaster clusters label every control plane node with MasterLabel only.
+	ConventionMaster Convention = "master"
+	// ConventionMixed clusters label some control plane nodes with MasterLabel only and others with
+	// ControlPlaneLabel only, e.g. while new nodes replace the old ones.
+	ConventionMixed Convention = "mixed"
+	// ConventionNone clusters have no node with a role label of the control plane.
+	ConventionNone Convention = "none"
+)
+
+// IsControlPlane returns whether node has either role label of the control plane.
+func IsControlPlane(node *corev1.Node) bool {
+	_, controlPlane := node.Labels[ControlPlaneLabel]
+	_, master := node.Labels[MasterLabel]
+	return controlPlane || master
+}
+
+// Select returns the control plane nodes in order of their names. A node carrying both labels is returned once.
+func Select(nodes []*corev1.Node) []*corev1.Node {
+	var selected []*corev1.Node
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if !IsControlPlane(node) || seen[node.Name] {
+			continue
+		}
+		seen[node.Name] = true
+		selected = append(selected, node)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+// ConventionOf returns the role label convention of the control plane nodes and the nodes that carry MasterLabel only.
+func ConventionOf(nodes []*corev1.Node) (Convention, []string) {
+	var masterOnly, controlPlane []string
+	for _, node := range Select(nodes) {
+		if _, ok := node.Labels[ControlPlaneLabel]; ok {
+			controlPlane = append(controlPlane, node.Name)
+		} else {
+			masterOnly = append(masterOnly, node.Name)
+		}
+	}
+	switch {
+	case len(masterOnly) == 0 && len(controlPlane) == 0:
+		return ConventionNone, nil
+	case len(masterOnly) == 0:
+		return ConventionControlPlane, nil
+	case len(controlPlane) == 0:
+		return ConventionMaster, masterOnly
+	default:
+		return ConventionMixed, masterOnly
+	}
+}
+
+// LogConvention logs the role label convention of the control plane nodes. The node statuses and the guard pods of
+// the static pod controllers only follow MasterLabel, control plane nodes without it get no kube-controller-manager.
+func LogConvention(ctx context.Context, nodes corev1client.NodesGetter) error {
+	list, err := nodes.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the nodes: %w", err)
+	}
+	all := make([]*corev1.Node, 0, len(list.Items))
+	for i := range list.Items {
+		all = append(all, &list.Items[i])
+	}
+
+	convention, masterOnly := ConventionOf(all)
+	klog.Infof("Control plane nodes are labelled with the %s convention", convention)
+	if convention == ConventionMixed {
+		klog.Infof("Control plane nodes labelled %s only: %s", MasterLabel, strings.Join(masterOnly, ", "))
+	}
+	var unmanaged []string
+	for _, node := range Select(all) {
+		if _, ok := node.Labels[MasterLabel]; !ok {
+			unmanaged = append(unmanaged, node.Name)
+		}
+	}
+	if len(unmanaged) > 0 {
+		klog.Warningf("Control plane nodes %s are not labelled %s, kube-controller-manager is not rolled out to them", strings.Join(unmanaged, ", "), MasterLabel)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package controlplanenodes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name string, labels ...string) *corev1.Node {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+	for _, label := range labels {
+		n.Labels[label] = ""
+	}
+	return n
+}
+
+func TestConventionOf(t *testing.T) {
+	tests := []struct {
+		name               string
+		nodes              []*corev1.Node
+		expectedSelected   []string
+		expectedConvention Convention
+		expectedMasterOnly []string
+	}{
+		{
+			name:               "master only",
+			nodes:              []*corev1.Node{node("master-1", MasterLabel), node("master-0", MasterLabel), node("worker-0")},
+			expectedSelected:   []string{"master-0", "master-1"},
+			expectedConvention: ConventionMaster,
+			expectedMasterOnly: []string{"master-0", "master-1"},
+		},
+		{
+			name:               "control-plane only",
+			nodes:              []*corev1.Node{node("cp-0", ControlPlaneLabel), node("cp-1", ControlPlaneLabel), node("worker-0", "node-role.kubernetes.io/worker")},
+			expectedSelected:   []string{"cp-0", "cp-1"},
+			expectedConvention: ConventionControlPlane,
+		},
+		{
+			name:               "both labels on every node",
+			nodes:              []*corev1.Node{node("master-0", MasterLabel, ControlPlaneLabel), node("master-1", MasterLabel, ControlPlaneLabel)},
+			expectedSelected:   []string{"master-0", "master-1"},
+			expectedConvention: ConventionControlPlane,
+		},
+		{
+			name:               "mixed",
+			nodes:              []*corev1.Node{node("master-0", MasterLabel), node("master-1", MasterLabel, ControlPlaneLabel), node("cp-2", ControlPlaneLabel)},
+			expectedSelected:   []string{"cp-2", "master-0", "master-1"},
+			expectedConvention: ConventionMixed,
+			expectedMasterOnly: []string{"master-0"},
+		},
+		{
+			name:               "duplicate node",
+			nodes:              []*corev1.Node{node("master-0", MasterLabel, ControlPlaneLabel), node("master-0", MasterLabel, ControlPlaneLabel)},
+			expectedSelected:   []string{"master-0"},
+			expectedConvention: ConventionControlPlane,
+		},
+		{
+			name:               "none",
+			nodes:              []*corev1.Node{node("worker-0")},
+			expectedConvention: ConventionNone,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var selected []string
+			for _, n := range Select(test.nodes) {
+				selected = append(selected, n.Name)
+			}
+			if !reflect.DeepEqual(selected, test.expectedSelected) {
+				t.Errorf("expected the nodes %v, got %v", test.expectedSelected, selected)
+			}
+			convention, masterOnly := ConventionOf(test.nodes)
+			if convention != test.expectedConvention {
+				t.Errorf("expected the %s convention, got %s", test.expectedConvention, convention)
+			}
+			if !reflect.DeepEqual(masterOnly, test.expectedMasterOnly) {
+				t.Errorf("expected the master only nodes %v, got %v", test.expectedMasterOnly, masterOnly)
+			}
+		})
+	}
+}
+
+func TestLogConvention(t *testing.T) {
+	client := fake.NewSimpleClientset(node("master-0", MasterLabel), node("cp-1", ControlPlaneLabel))
+	if err := LogConvention(context.Background(), client.CoreV1()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package degradeddamping
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	// DefaultHoldDuration is how long the cause of a Degraded condition has to be gone before the condition is
+	// cleared.
+	DefaultHoldDuration = 2 * time.Minute
+
+	// SkipHoldOverrideField of the unsupportedConfigOverrides clears recovering Degraded conditions right away when it
+	// is true, any other value keeps the hold. It is meant for an admin who fixed the cause and does not want to wait
+	// for the hold.
+	SkipHoldOverrideField = "skipDegradedHold"
+
+	recoveringMessagePrefix = "recovering, clearing at "
+)
+
+func init() {
+	overrides.Register(SkipHoldOverrideField)
+}
+
+// pendingClear is a Degraded condition that is held True although its controller reported it False.
+type pendingClear struct {
+	condition operatorv1.OperatorCondition
+	message   string
+	clearAt   time.Time
+}
+
+// OperatorClient damps flapping Degraded conditions. A *Degraded condition becomes True as soon as a controller
+// sets it, but goes back to False only once the controllers kept reporting it False for the hold duration. Until
+// then it stays True with a message that says when it is going to clear. Other conditions and fields are passed
+// through.
+type OperatorClient struct {
+	v1helpers.StaticPodOperatorClient
+
+	operatorLister cache.GenericLister
+	hold           time.Duration
+	now            func() time.Time
+
+	lock    sync.Mutex
+	pending map[string]pendingClear
+}
+
+var _ v1helpers.StaticPodOperatorClient = &OperatorClient{}
+
+func NewOperatorClient(delegate v1helpers.StaticPodOperatorClient, operatorLister cache.GenericLister, hold time.Duration) *OperatorClient {
+	return &OperatorClient{
+		StaticPodOperatorClient: delegate,
+		operatorLister:          operatorLister,
+		hold:                    hold,
+		now:                     time.Now,
+		pending:                 map[string]pendingClear{},
+	}
+}
+
+// Run clears held conditions once their hold ran out, even when no controller updates them again.
+func (c *OperatorClient) Run(ctx context.Context) {
+	wait.UntilWithContext(ctx, c.clearExpired, 10*time.Second)
+}
+
+func (c *OperatorClient) UpdateStaticPodOperatorStatus(ctx context.Context, resourceVersion string, in *operatorv1.StaticPodOperatorStatus) (*operatorv1.StaticPodOperatorStatus, error) {
+	_, current, _, err := c.GetStaticPodOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	in = in.DeepCopy()
+	c.damp(current.Conditions, in.Conditions)
+	return c.StaticPodOperatorClient.UpdateStaticPodOperatorStatus(ctx, resourceVersion, in)
+}
+
+func (c *OperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, in *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
+	_, current, _, err := c.GetOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	in = in.DeepCopy()
+	c.damp(current.Conditions, in.Conditions)
+	return c.StaticPodOperatorClient.UpdateOperatorStatus(ctx, resourceVersion, in)
+}
+
+// damp replaces the Degraded conditions in updated that are about to go from True to False with the current True
+// condition until the hold ran out.
+func (c *OperatorClient) damp(current, updated []operatorv1.OperatorCondition) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	skipHold := c.skipHold()
+	now := c.now()
+	for i, condition := range updated {
+		if !strings.HasSuffix(condition.Type, operatorv1.OperatorStatusTypeDegraded) {
+			continue
+		}
+		previous := v1helpers.FindOperatorCondition(current, condition.Type)
+		if previous != nil && equality.Semantic.DeepEqual(condition, *previous) {
+			// not touched by this update, a held condition stays held
+			continue
+		}
+		if condition.Status != operatorv1.ConditionFalse || previous == nil || previous.Status != operatorv1.ConditionTrue || skipHold {
+			delete(c.pending, condition.Type)
+			continue
+		}
+
+		pending, ok := c.pending[condition.Type]
+		if !ok {
+			pending = pendingClear{message: stripRecovering(previous.Message), clearAt: now.Add(c.hold)}
+		}
+		if !now.Before(pending.clearAt) {
+			delete(c.pending, condition.Type)
+			continue
+		}
+		pending.condition = condition
+		c.pending[condition.Type] = pending
+
+		held := *previous
+		held.Message = fmt.Sprintf("%s%s: %s", recoveringMessagePrefix, pending.clearAt.UTC().Format(time.RFC3339), pending.message)
+		updated[i] = held
+	}
+}
+
+func (c *OperatorClient) clearExpired(ctx context.Context) {
+	c.lock.Lock()
+	skipHold := c.skipHold()
+	now := c.now()
+	var expired []operatorv1.OperatorCondition
+	for _, pending := range c.pending {
+		if skipHold || !now.Before(pending.clearAt) {
+			expired = append(expired, pending.condition)
+		}
+	}
+	c.lock.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	updateFuncs := make([]v1helpers.UpdateStaticPodStatusFunc, 0, len(expired))
+	for _, condition := range expired {
+		updateFuncs = append(updateFuncs, v1helpers.UpdateStaticPodConditionFn(condition))
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c, updateFuncs...); err != nil {
+		klog.Warningf("Unable to clear recovered Degraded conditions: %v", err)
+	}
+}
+
+func (c *OperatorClient) skipHold() bool {
+	if c.operatorLister == nil {
+		return false
+	}
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return false
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return false
+	}
+	skip, err := overrides.Bool(unsupportedConfigOverrides, SkipHoldOverrideField)
+	return err == nil && skip
+}
+
+func stripRecovering(message string) string {
+	if !strings.HasPrefix(message, recoveringMessagePrefix) {
+		return message
+	}
+	if _, original, ok := strings.Cut(message, ": "); ok {
+		return original
+	}
+	return message
+}
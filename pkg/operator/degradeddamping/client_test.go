@@ -0,0 +1,128 @@
+package degradeddamping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func newTestClient(unsupportedConfigOverrides map[string]interface{}) (*OperatorClient, *time.Time) {
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedMap(operator.Object, unsupportedConfigOverrides, "spec", "unsupportedConfigOverrides"); err != nil {
+			panic(err)
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(operator); err != nil {
+		panic(err)
+	}
+
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	c := NewOperatorClient(
+		v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil),
+		cache.NewGenericLister(indexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		DefaultHoldDuration,
+	)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func setDegraded(t *testing.T, c *OperatorClient, status operatorv1.ConditionStatus, message string) operatorv1.OperatorCondition {
+	t.Helper()
+	if _, _, err := v1helpers.UpdateStaticPodStatus(context.TODO(), c, v1helpers.UpdateStaticPodConditionFn(operatorv1.OperatorCondition{
+		Type:    "InstallerControllerDegraded",
+		Status:  status,
+		Reason:  string(status),
+		Message: message,
+	})); err != nil {
+		t.Fatal(err)
+	}
+	return degraded(t, c)
+}
+
+func degraded(t *testing.T, c *OperatorClient) operatorv1.OperatorCondition {
+	t.Helper()
+	_, status, _, err := c.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *v1helpers.FindOperatorCondition(status.Conditions, "InstallerControllerDegraded")
+}
+
+func TestFlappingDegradedIsHeld(t *testing.T) {
+	c, now := newTestClient(nil)
+
+	trueSince := setDegraded(t, c, operatorv1.ConditionTrue, "installer pod failed").LastTransitionTime
+	// the cause flaps within the hold, the condition has to stay in the same True period
+	for i, step := range []struct {
+		status  operatorv1.ConditionStatus
+		message string
+	}{
+		{operatorv1.ConditionFalse, ""},
+		{operatorv1.ConditionTrue, "installer pod failed"},
+		{operatorv1.ConditionFalse, ""},
+	} {
+		*now = now.Add(20 * time.Second)
+		condition := setDegraded(t, c, step.status, step.message)
+		if condition.Status != operatorv1.ConditionTrue || condition.LastTransitionTime != trueSince {
+			t.Fatalf("step %d: expected the condition to stay True since %v, got %#v", i, trueSince, condition)
+		}
+	}
+	if message := degraded(t, c).Message; message != "recovering, clearing at 2024-01-02T03:03:00Z: installer pod failed" {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	// the cause stays away, controllers keep reporting False and other conditions change meanwhile
+	*now = now.Add(time.Minute)
+	if _, _, err := v1helpers.UpdateStaticPodStatus(context.TODO(), c, v1helpers.UpdateStaticPodConditionFn(operatorv1.OperatorCondition{Type: "NodeInstallerProgressing", Status: operatorv1.ConditionTrue})); err != nil {
+		t.Fatal(err)
+	}
+	if condition := setDegraded(t, c, operatorv1.ConditionFalse, ""); condition.Status != operatorv1.ConditionTrue {
+		t.Fatalf("expected the condition to be held until 03:03:00, got %#v", condition)
+	}
+
+	*now = now.Add(time.Minute)
+	condition := setDegraded(t, c, operatorv1.ConditionFalse, "")
+	if condition.Status != operatorv1.ConditionFalse || condition.Message != "" {
+		t.Fatalf("expected the condition to clear after the hold, got %#v", condition)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("expected nothing pending, got %v", c.pending)
+	}
+}
+
+func TestHeldDegradedIsClearedWithoutFurtherUpdates(t *testing.T) {
+	c, now := newTestClient(nil)
+
+	setDegraded(t, c, operatorv1.ConditionTrue, "installer pod failed")
+	setDegraded(t, c, operatorv1.ConditionFalse, "")
+
+	*now = now.Add(time.Minute)
+	c.clearExpired(context.TODO())
+	if condition := degraded(t, c); condition.Status != operatorv1.ConditionTrue {
+		t.Fatalf("expected the condition to be held, got %#v", condition)
+	}
+
+	*now = now.Add(time.Minute)
+	c.clearExpired(context.TODO())
+	if condition := degraded(t, c); condition.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the condition to clear after the hold, got %#v", condition)
+	}
+}
+
+func TestSkipHold(t *testing.T) {
+	c, _ := newTestClient(map[string]interface{}{SkipHoldOverrideField: true})
+
+	setDegraded(t, c, operatorv1.ConditionTrue, "installer pod failed")
+	if condition := setDegraded(t, c, operatorv1.ConditionFalse, ""); condition.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected the condition to clear right away, got %#v", condition)
+	}
+}
@@ -0,0 +1,215 @@
+package effectiveconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+)
+
+// Path is where the operator serves the effective config on its secure port. Like every path but /metrics, reading it
+// requires a user authorized for the non-resource URL.
+const Path = "/effective-config"
+
+var schema = mustLoadSchema(bindata.MustAsset("assets/config/schema.yaml"))
+
+func mustLoadSchema(content []byte) *spec.Schema {
+	jsonContent, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		panic(err)
+	}
+	s := &spec.Schema{}
+	if err := json.Unmarshal(jsonContent, s); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// EffectiveConfig is the config kube-controller-manager runs with: the defaults, the observed config and the
+// unsupportedConfigOverrides merged by the target config controller.
+type EffectiveConfig struct {
+	// Revision is the revision the config was read from, unset for a config that is not revisioned yet.
+	Revision int32 `json:"revision,omitempty"`
+	// Config is the merged config.yaml.
+	Config map[string]interface{} `json:"config"`
+	// Violations are the parts of Config that do not match the schema of the KubeControllerManagerConfig.
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a part of the config that does not match the schema.
+type Violation struct {
+	// Path is the field path, like extendedArguments.cluster-cidr.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// New parses and validates the merged config.yaml.
+func New(revision int32, configYAML []byte) (*EffectiveConfig, error) {
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(configYAML, &config); err != nil {
+		return nil, err
+	}
+	return &EffectiveConfig{
+		Revision:   revision,
+		Config:     config,
+		Violations: validate("", config, schema),
+	}, nil
+}
+
+// ForLatestRevision reads the effective config of the latest available revision. The config configmap is not used, it
+// holds what the target config controller computed for the next revision, which may never be rolled out.
+func ForLatestRevision(operatorClient v1helpers.StaticPodOperatorClient, configMapLister corev1listers.ConfigMapNamespaceLister) (*EffectiveConfig, error) {
+	_, status, _, err := operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	if status.LatestAvailableRevision == 0 {
+		return nil, errors.NewServiceUnavailable("no revision is available yet")
+	}
+	configMap, err := configMapLister.Get(fmt.Sprintf("config-%d", status.LatestAvailableRevision))
+	if err != nil {
+		return nil, err
+	}
+	config, err := New(status.LatestAvailableRevision, []byte(configMap.Data["config.yaml"]))
+	if err != nil {
+		return nil, fmt.Errorf("configmap/%s: %v", configMap.Name, err)
+	}
+	return config, nil
+}
+
+// NewHandler serves the effective config of the latest available revision as JSON.
+func NewHandler(operatorClient v1helpers.StaticPodOperatorClient, configMapLister corev1listers.ConfigMapNamespaceLister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, fmt.Sprintf("%s is not supported", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		config, err := ForLatestRevision(operatorClient, configMapLister)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if apiStatus, ok := err.(errors.APIStatus); ok {
+				status = int(apiStatus.Status().Code)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		content, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(append(content, '\n')); err != nil {
+			klog.V(2).Infof("Failed to write the effective config: %v", err)
+		}
+	})
+}
+
+// validate checks value against the subset of the JSON schema the embedded schema uses: type, enum, required,
+// properties, additionalProperties, items and minItems.
+func validate(path string, value interface{}, s *spec.Schema) []Violation {
+	var violations []Violation
+	violation := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if path == "" {
+		path = "."
+	}
+
+	if len(s.Type) > 0 && !s.Type.Contains(typeOf(value)) {
+		violation("must be of type %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+		return violations
+	}
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, enum := range s.Enum {
+			if reflect.DeepEqual(enum, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violation("must be one of %v, got %v", s.Enum, value)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				violations = append(violations, Violation{Path: fieldPath(path, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				violations = append(violations, validate(fieldPath(path, name), value[name], &property)...)
+				continue
+			}
+			switch {
+			case s.AdditionalProperties == nil:
+			case s.AdditionalProperties.Schema != nil:
+				violations = append(violations, validate(fieldPath(path, name), value[name], s.AdditionalProperties.Schema)...)
+			case !s.AdditionalProperties.Allows:
+				violations = append(violations, Violation{Path: fieldPath(path, name), Message: "is not a known field"})
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && int64(len(value)) < *s.MinItems {
+			violation("must have at least %d items, got %d", *s.MinItems, len(value))
+		}
+		if s.Items != nil && s.Items.Schema != nil {
+			for i, item := range value {
+				violations = append(violations, validate(fmt.Sprintf("%s[%d]", path, i), item, s.Items.Schema)...)
+			}
+		}
+	}
+	return violations
+}
+
+func fieldPath(path, name string) string {
+	if path == "." {
+		return name
+	}
+	return path + "." + name
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, int64:
+		return "number"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,129 @@
+package effectiveconfig
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestEffectiveConfigOfTheLatestRevision(t *testing.T) {
+	rolledOut, err := os.ReadFile(filepath.Join("testdata", "config-7.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for name, config := range map[string]string{
+		"config-6": `{"apiVersion":"kubecontrolplane.config.openshift.io/v1","kind":"KubeControllerManagerConfig","extendedArguments":{"cluster-name":["previous"]}}`,
+		"config-7": string(rolledOut),
+		// computed for the next revision, which is not created yet
+		"config": `{"apiVersion":"kubecontrolplane.config.openshift.io/v1","kind":"KubeControllerManagerConfig","extendedArguments":{"cluster-name":["next"]}}`,
+	} {
+		if err := indexer.Add(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: name},
+			Data:       map[string]string{"config.yaml": config},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 7},
+		nil,
+		nil,
+	)
+	server := httptest.NewServer(NewHandler(operatorClient, corev1listers.NewConfigMapLister(indexer).ConfigMaps(operatorclient.TargetNamespace)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON response, got %d %q: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	golden := filepath.Join("testdata", "effective-config-7.json")
+	if *update {
+		if err := os.WriteFile(golden, body, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != string(expected) {
+		t.Errorf("unexpected effective config, run with -update if the change is intended: %s", cmp.Diff(string(expected), string(body)))
+	}
+
+	resp, err = http.Post(server.URL+Path, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST to be refused, got %d", resp.StatusCode)
+	}
+}
+
+func TestSchemaViolations(t *testing.T) {
+	config, err := New(3, []byte(`{
+  "kind": "KubeControllerManagerConfig",
+  "serviceServingCert": {"certFile": "/etc/service-ca.crt", "keyFile": "/etc/service-ca.key"},
+  "projectConfig": "node-role.kubernetes.io/worker=",
+  "extendedArguments": {
+    "cluster-cidr": ["10.128.0.0/14"],
+    "controllers": [],
+    "kube-api-qps": "150",
+    "kube-api-burst": [300]
+  },
+  "unsupported": true
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Violation{
+		{Path: "apiVersion", Message: "is required"},
+		{Path: "extendedArguments.controllers", Message: "must have at least 1 items, got 0"},
+		{Path: "extendedArguments.kube-api-burst[0]", Message: "must be of type string, got number"},
+		{Path: "extendedArguments.kube-api-qps", Message: "must be of type array, got string"},
+		{Path: "projectConfig", Message: "must be of type object, got string"},
+		{Path: "serviceServingCert.keyFile", Message: "is not a known field"},
+		{Path: "unsupported", Message: "is not a known field"},
+	}
+	if !reflect.DeepEqual(config.Violations, expected) {
+		t.Errorf("unexpected violations: %s", cmp.Diff(expected, config.Violations))
+	}
+
+	config, err = New(3, []byte(`{"apiVersion": "v1", "kind": "KubeControllerManagerConfig", "extendedArguments": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Violation{{Path: "apiVersion", Message: "must be one of [kubecontrolplane.config.openshift.io/v1], got v1"}}
+	if !reflect.DeepEqual(config.Violations, expected) {
+		t.Errorf("unexpected violations: %s", cmp.Diff(expected, config.Violations))
+	}
+}
@@ -0,0 +1,183 @@
+// Package eventbudget limits the events the operator records per reason. A crashlooping operand makes the controllers
+// record the same events for hours, which exceeded the events quota of the namespace.
+package eventbudget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// DefaultEvents is how many events of one reason are recorded per DefaultInterval.
+	DefaultEvents = 10
+	// DefaultInterval is the interval the budget of a reason refills in.
+	DefaultInterval = 10 * time.Minute
+
+	// SuppressedReason is the reason of the summary of suppressed events.
+	SuppressedReason = "EventsSuppressed"
+)
+
+// ExemptReasons are always recorded. They report expiring certificates and revisions rolling back, which must not be
+// hidden by a storm of other events.
+var ExemptReasons = sets.New(
+	"CertificateUpdateFailed",
+	"SignerUpdateRequired",
+	"SATokenSignerControllerStuck",
+	"NodeCurrentRevisionChanged",
+	"RevisionThrash",
+	SuppressedReason,
+)
+
+var suppressedEventsMetric = metrics.NewCounterVec(&metrics.CounterOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "suppressed_events_total",
+	Help:           "Number of events not recorded because the budget of their reason was exhausted",
+	StabilityLevel: metrics.ALPHA,
+}, []string{"reason"})
+
+func init() {
+	legacyregistry.MustRegister(suppressedEventsMetric)
+}
+
+// Budget is how many events of one reason are recorded per interval.
+type Budget struct {
+	Events   int
+	Interval time.Duration
+}
+
+func DefaultBudget() Budget {
+	return Budget{Events: DefaultEvents, Interval: DefaultInterval}
+}
+
+func (b *Budget) AddFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&b.Events, "event-budget", b.Events, fmt.Sprintf("Number of events of one reason recorded per --event-budget-interval, 0 records all. The reasons %v are always recorded.", sets.List(ExemptReasons)))
+	flags.DurationVar(&b.Interval, "event-budget-interval", b.Interval, "Interval the event budget of a reason refills in.")
+}
+
+func (b Budget) Validate() error {
+	if b.Events < 0 {
+		return fmt.Errorf("--event-budget must not be negative, got %d", b.Events)
+	}
+	if b.Events > 0 && b.Interval <= 0 {
+		return fmt.Errorf("--event-budget-interval must be positive, got %s", b.Interval)
+	}
+	return nil
+}
+
+// Recorder records the events of a reason while its budget lasts. The budget of a reason is a token bucket shared by
+// all components, it holds Budget.Events tokens and refills continuously over Budget.Interval. The events recorded
+// while it is empty are counted, and the count is recorded as a single SuppressedReason warning before the next event
+// of the reason.
+type Recorder struct {
+	events.Recorder
+	state *state
+}
+
+var _ events.Recorder = &Recorder{}
+
+// NewRecorder wraps delegate, a zero budget records all events.
+func NewRecorder(delegate events.Recorder, budget Budget) events.Recorder {
+	if budget.Events == 0 {
+		return delegate
+	}
+	return &Recorder{Recorder: delegate, state: &state{budget: budget, now: time.Now, buckets: map[string]*bucket{}}}
+}
+
+type state struct {
+	budget Budget
+	now    func() time.Time
+
+	lock    sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// suppressed are the events since the bucket ran empty
+	suppressed int
+}
+
+// take returns whether an event of reason may be recorded and the number of events suppressed before it.
+func (s *state) take(reason string) (bool, int) {
+	if ExemptReasons.Has(reason) {
+		return true, 0
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[reason]
+	if !ok {
+		b = &bucket{tokens: float64(s.budget.Events), updated: now}
+		s.buckets[reason] = b
+	}
+	b.tokens += float64(s.budget.Events) * float64(now.Sub(b.updated)) / float64(s.budget.Interval)
+	if max := float64(s.budget.Events); b.tokens > max {
+		b.tokens = max
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		b.suppressed++
+		suppressedEventsMetric.WithLabelValues(reason).Inc()
+		return false, 0
+	}
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+func (r *Recorder) Event(reason, message string) {
+	if r.record(reason) {
+		r.Recorder.Event(reason, message)
+	}
+}
+
+func (r *Recorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) Warning(reason, message string) {
+	if r.record(reason) {
+		r.Recorder.Warning(reason, message)
+	}
+}
+
+func (r *Recorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) ForComponent(componentName string) events.Recorder {
+	return &Recorder{Recorder: r.Recorder.ForComponent(componentName), state: r.state}
+}
+
+func (r *Recorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &Recorder{Recorder: r.Recorder.WithComponentSuffix(componentNameSuffix), state: r.state}
+}
+
+func (r *Recorder) WithContext(ctx context.Context) events.Recorder {
+	return &Recorder{Recorder: r.Recorder.WithContext(ctx), state: r.state}
+}
+
+// record returns whether the event of reason is within its budget and records the summary of the events suppressed
+// before it.
+func (r *Recorder) record(reason string) bool {
+	ok, suppressed := r.state.take(reason)
+	if suppressed > 0 {
+		r.Recorder.Warningf(SuppressedReason, "%d similar %s events were suppressed, the budget is %d events per %s", suppressed, reason, r.state.budget.Events, r.state.budget.Interval)
+	}
+	return ok
+}
@@ -0,0 +1,96 @@
+package eventbudget
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestRecorderBudget(t *testing.T) {
+	inMemory := events.NewInMemoryRecorder("test")
+	recorder := NewRecorder(inMemory, Budget{Events: 3, Interval: 3 * time.Minute}).(*Recorder)
+	now := time.Now()
+	recorder.state.now = func() time.Time { return now }
+
+	suppressedBefore, err := testutil.GetCounterMetricValue(suppressedEventsMetric.WithLabelValues("OperandCrashLooping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the budget is shared by the components and exhausted after 3 events
+	for i := 0; i < 5; i++ {
+		recorder.WithComponentSuffix(fmt.Sprintf("controller-%d", i%2)).Warningf("OperandCrashLooping", "crashloop %d", i)
+	}
+	// other reasons have a budget of their own, the exempt ones have none
+	recorder.Event("RevisionCreated", "created")
+	for i := 0; i < 5; i++ {
+		recorder.Warning("CertificateUpdateFailed", "expiring")
+	}
+
+	// a minute refills a single event, which is preceded by the summary
+	now = now.Add(time.Minute)
+	recorder.Warning("OperandCrashLooping", "crashloop 5")
+	recorder.Warning("OperandCrashLooping", "crashloop 6")
+
+	var recorded []string
+	for _, event := range inMemory.Events() {
+		recorded = append(recorded, event.Reason+": "+event.Message)
+	}
+	expected := []string{
+		"OperandCrashLooping: crashloop 0",
+		"OperandCrashLooping: crashloop 1",
+		"OperandCrashLooping: crashloop 2",
+		"RevisionCreated: created",
+		"CertificateUpdateFailed: expiring",
+		"CertificateUpdateFailed: expiring",
+		"CertificateUpdateFailed: expiring",
+		"CertificateUpdateFailed: expiring",
+		"CertificateUpdateFailed: expiring",
+		"EventsSuppressed: 2 similar OperandCrashLooping events were suppressed, the budget is 3 events per 3m0s",
+		"OperandCrashLooping: crashloop 5",
+	}
+	if !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected events\n%v\ngot\n%v", expected, recorded)
+	}
+
+	suppressed, err := testutil.GetCounterMetricValue(suppressedEventsMetric.WithLabelValues("OperandCrashLooping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suppressed-suppressedBefore != 3 {
+		t.Errorf("expected 3 suppressed events, got %v", suppressed-suppressedBefore)
+	}
+}
+
+func TestRecorderWithoutBudget(t *testing.T) {
+	inMemory := events.NewInMemoryRecorder("test")
+	if recorder := NewRecorder(inMemory, Budget{}); recorder != events.Recorder(inMemory) {
+		t.Errorf("expected no budget to record all events")
+	}
+}
+
+func TestBudgetValidate(t *testing.T) {
+	tests := []struct {
+		budget      Budget
+		expectedErr string
+	}{
+		{budget: DefaultBudget()},
+		{budget: Budget{}},
+		{budget: Budget{Events: -1, Interval: time.Minute}, expectedErr: "--event-budget must not be negative, got -1"},
+		{budget: Budget{Events: 10}, expectedErr: "--event-budget-interval must be positive, got 0s"},
+	}
+	for _, test := range tests {
+		err := test.budget.Validate()
+		switch {
+		case len(test.expectedErr) == 0 && err != nil:
+			t.Errorf("%v: unexpected error %v", test.budget, err)
+		case len(test.expectedErr) > 0 && (err == nil || err.Error() != test.expectedErr):
+			t.Errorf("%v: expected error %q, got %v", test.budget, test.expectedErr, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package eventcontext
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// Recorder appends the operator version, the latest available revision and the leader to the message of every event,
+// so that events can be attributed to an operator build and revision during upgrade analysis.
+//
+// The suffix only changes when the revision changes, repeated events keep an identical message and are still
+// aggregated by the event correlator.
+type Recorder struct {
+	delegate       events.Recorder
+	operatorClient v1helpers.StaticPodOperatorClient
+	version        string
+	leader         string
+}
+
+var _ events.Recorder = &Recorder{}
+
+// NewRecorder wraps delegate. leader identifies the process holding the operator lease, the operator only runs
+// its controllers while it is the leader.
+func NewRecorder(delegate events.Recorder, operatorClient v1helpers.StaticPodOperatorClient, version, leader string) *Recorder {
+	return &Recorder{
+		delegate:       delegate,
+		operatorClient: operatorClient,
+		version:        version,
+		leader:         leader,
+	}
+}
+
+func (r *Recorder) Event(reason, message string) {
+	r.delegate.Event(reason, message+r.suffix())
+}
+
+func (r *Recorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) Warning(reason, message string) {
+	r.delegate.Warning(reason, message+r.suffix())
+}
+
+func (r *Recorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) ForComponent(componentName string) events.Recorder {
+	return r.wrap(r.delegate.ForComponent(componentName))
+}
+
+func (r *Recorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return r.wrap(r.delegate.WithComponentSuffix(componentNameSuffix))
+}
+
+func (r *Recorder) WithContext(ctx context.Context) events.Recorder {
+	return r.wrap(r.delegate.WithContext(ctx))
+}
+
+func (r *Recorder) ComponentName() string {
+	return r.delegate.ComponentName()
+}
+
+func (r *Recorder) Shutdown() {
+	r.delegate.Shutdown()
+}
+
+func (r *Recorder) wrap(delegate events.Recorder) events.Recorder {
+	return NewRecorder(delegate, r.operatorClient, r.version, r.leader)
+}
+
+// suffix reads the revision from the informer cache, recording an event does not cost an API call.
+func (r *Recorder) suffix() string {
+	revision := "unknown"
+	if _, status, _, err := r.operatorClient.GetStaticPodOperatorState(); err == nil {
+		revision = strconv.Itoa(int(status.LatestAvailableRevision))
+	}
+	return fmt.Sprintf(" (operator %s, revision %s, leader %s)", r.version, revision, r.leader)
+}
@@ -0,0 +1,40 @@
+package eventcontext
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestRecorderAddsContext(t *testing.T) {
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{},
+		&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 7},
+		nil, nil,
+	)
+	inMemory := events.NewInMemoryRecorder("test")
+	recorder := NewRecorder(inMemory, operatorClient, "4.16.0", "kube-controller-manager-operator-5d8f7-xk2lp")
+
+	recorder.Eventf("RevisionCreated", "Created revision %d", 7)
+	recorder.WithComponentSuffix("target-config-controller").Warning("ConfigMissing", "no observedConfig")
+	recorder.Warning("ConfigMissing", "no observedConfig")
+
+	recorded := inMemory.Events()
+	if len(recorded) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(recorded))
+	}
+	for i, expected := range []string{
+		"Created revision 7 (operator 4.16.0, revision 7, leader kube-controller-manager-operator-5d8f7-xk2lp)",
+		"no observedConfig (operator 4.16.0, revision 7, leader kube-controller-manager-operator-5d8f7-xk2lp)",
+		"no observedConfig (operator 4.16.0, revision 7, leader kube-controller-manager-operator-5d8f7-xk2lp)",
+	} {
+		if recorded[i].Message != expected {
+			t.Errorf("event %d: expected message %q, got %q", i, expected, recorded[i].Message)
+		}
+	}
+	if recorded[1].Type != "Warning" {
+		t.Errorf("expected a warning, got %q", recorded[1].Type)
+	}
+}
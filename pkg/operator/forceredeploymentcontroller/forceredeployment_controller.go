@@ -0,0 +1,195 @@
+package forceredeploymentcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+)
+
+const (
+	controllerName = "ForceRedeploymentHistoryController"
+
+	// HistoryConfigMapName is the configmap in the operator namespace that keeps the history.
+	HistoryConfigMapName = "force-redeployment-history"
+	// MaxHistoryEntries bounds the history, the oldest entries are dropped first.
+	MaxHistoryEntries = 10
+
+	historyKey = "history.json"
+	countKey   = "count"
+
+	// podConfigMapPrefix is the prefix of the revisioned copies of kube-controller-manager-pod, which carry the
+	// forceRedeploymentReason the revision was created with
+	podConfigMapPrefix = "kube-controller-manager-pod-"
+)
+
+var forcedRedeploymentsMetric = metrics.NewGauge(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "forced_redeployments",
+	Help:           "Number of distinct spec.forceRedeploymentReason values that created a revision, including the ones dropped from the history",
+	StabilityLevel: metrics.ALPHA,
+})
+
+func init() {
+	legacyregistry.MustRegister(forcedRedeploymentsMetric)
+	relatedobjects.Register(configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: HistoryConfigMapName})
+}
+
+// Entry is a forceRedeploymentReason and the revision it took effect with.
+type Entry struct {
+	Reason   string      `json:"reason"`
+	Revision int         `json:"revision"`
+	Time     metav1.Time `json:"time"`
+}
+
+// ForceRedeploymentHistoryController records every distinct spec.forceRedeploymentReason together with the first
+// revision created for it in the force-redeployment-history configmap. The reason ends up in the revisioned
+// kube-controller-manager-pod configmap, so the history survives both the pruning of the revisions and the reason
+// being reset. Setting a reason identical to the last recorded one does not add an entry.
+type ForceRedeploymentHistoryController struct {
+	configMapClient       corev1client.ConfigMapsGetter
+	targetConfigMapLister corev1listers.ConfigMapNamespaceLister
+}
+
+func NewForceRedeploymentHistoryController(
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient corev1client.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &ForceRedeploymentHistoryController{
+		configMapClient:       configMapClient,
+		targetConfigMapLister: kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+	}
+
+	return factory.New().WithInformers(
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+	).ResyncEvery(10*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("force-redeployment-history-controller"))
+}
+
+func (c *ForceRedeploymentHistoryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	history, count, err := c.history(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastReason string
+	var lastRevision int
+	if len(history) > 0 {
+		lastReason, lastRevision = history[len(history)-1].Reason, history[len(history)-1].Revision
+	}
+	revisions, err := c.revisionsAfter(lastRevision)
+	if err != nil {
+		return err
+	}
+	var added []Entry
+	for _, r := range revisions {
+		reason := r.configMap.Data["forceRedeploymentReason"]
+		if len(reason) == 0 || reason == lastReason {
+			continue
+		}
+		added = append(added, Entry{Reason: reason, Revision: r.number, Time: r.configMap.CreationTimestamp})
+		lastReason = reason
+	}
+	if len(added) == 0 {
+		forcedRedeploymentsMetric.Set(float64(count))
+		return nil
+	}
+
+	history = append(history, added...)
+	if len(history) > MaxHistoryEntries {
+		history = history[len(history)-MaxHistoryEntries:]
+	}
+	count += len(added)
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: HistoryConfigMapName},
+		Data: map[string]string{
+			historyKey: string(historyBytes),
+			countKey:   strconv.Itoa(count),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	forcedRedeploymentsMetric.Set(float64(count))
+	for _, entry := range added {
+		syncCtx.Recorder().Eventf("ForcedRedeployment", "Revision %d was created for forceRedeploymentReason: %s", entry.Revision, entry.Reason)
+	}
+	return nil
+}
+
+// history returns the recorded entries, oldest first, and how many were ever recorded. It is read live, a stale
+// cache would record the last entries again.
+func (c *ForceRedeploymentHistoryController) history(ctx context.Context) ([]Entry, int, error) {
+	configMap, err := c.configMapClient.ConfigMaps(operatorclient.OperatorNamespace).Get(ctx, HistoryConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var history []Entry
+	if err := json.Unmarshal([]byte(configMap.Data[historyKey]), &history); err != nil {
+		return nil, 0, fmt.Errorf("configmap/%s: %s: %v", HistoryConfigMapName, historyKey, err)
+	}
+	count, err := strconv.Atoi(configMap.Data[countKey])
+	if err != nil || count < len(history) {
+		count = len(history)
+	}
+	return history, count, nil
+}
+
+// revisionedPod is a revisioned copy of kube-controller-manager-pod.
+type revisionedPod struct {
+	number    int
+	configMap *corev1.ConfigMap
+}
+
+// revisionsAfter returns the revisioned kube-controller-manager-pod configmaps of the revisions after revision,
+// oldest first.
+func (c *ForceRedeploymentHistoryController) revisionsAfter(revision int) ([]revisionedPod, error) {
+	configMaps, err := c.targetConfigMapLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var after []revisionedPod
+	for _, configMap := range configMaps {
+		if !strings.HasPrefix(configMap.Name, podConfigMapPrefix) {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimPrefix(configMap.Name, podConfigMapPrefix))
+		if err != nil || number <= revision {
+			continue
+		}
+		after = append(after, revisionedPod{number: number, configMap: configMap})
+	}
+	sort.Slice(after, func(i, j int) bool { return after[i].number < after[j].number })
+	return after, nil
+}
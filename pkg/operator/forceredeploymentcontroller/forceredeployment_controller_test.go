@@ -0,0 +1,146 @@
+package forceredeploymentcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var start = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+type testRevisions struct {
+	t       *testing.T
+	indexer cache.Indexer
+}
+
+// add creates the revisioned pod configmap of revision, created revision minutes after start.
+func (r testRevisions) add(revision int, reason string) {
+	if err := r.indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         operatorclient.TargetNamespace,
+			Name:              fmt.Sprintf("kube-controller-manager-pod-%d", revision),
+			CreationTimestamp: metav1.NewTime(start.Add(time.Duration(revision) * time.Minute)),
+		},
+		Data: map[string]string{"forceRedeploymentReason": reason},
+	}); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func newTestController(t *testing.T) (*ForceRedeploymentHistoryController, testRevisions, *fake.Clientset) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "kube-controller-manager-pod"}}); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+	c := &ForceRedeploymentHistoryController{
+		configMapClient:       client.CoreV1(),
+		targetConfigMapLister: corev1listers.NewConfigMapLister(indexer).ConfigMaps(operatorclient.TargetNamespace),
+	}
+	return c, testRevisions{t: t, indexer: indexer}, client
+}
+
+func syncAndRead(t *testing.T, c *ForceRedeploymentHistoryController, client *fake.Clientset) ([]Entry, []string) {
+	recorder := events.NewInMemoryRecorder("test")
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, recorder)); err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, event := range recorder.Events() {
+		if event.Reason == "ForcedRedeployment" {
+			messages = append(messages, event.Message)
+		}
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), HistoryConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, messages
+	}
+	var history []Entry
+	if err := json.Unmarshal([]byte(configMap.Data[historyKey]), &history); err != nil {
+		t.Fatal(err)
+	}
+	return history, messages
+}
+
+func TestForceRedeploymentHistory(t *testing.T) {
+	c, revisions, client := newTestController(t)
+	revisions.add(1, "")
+	revisions.add(2, "rotate the credentials")
+	revisions.add(3, "rotate the credentials")
+
+	history, messages := syncAndRead(t, c, client)
+	expected := []Entry{{Reason: "rotate the credentials", Revision: 2, Time: metav1.NewTime(start.Add(2 * time.Minute))}}
+	if len(history) != 1 || history[0].Reason != expected[0].Reason || history[0].Revision != expected[0].Revision || !history[0].Time.Equal(&expected[0].Time) {
+		t.Fatalf("expected the history %v, got %v", expected, history)
+	}
+	if len(messages) != 1 || messages[0] != "Revision 2 was created for forceRedeploymentReason: rotate the credentials" {
+		t.Errorf("unexpected events %q", messages)
+	}
+
+	// nothing new
+	if _, messages = syncAndRead(t, c, client); len(messages) != 0 {
+		t.Errorf("expected no events, got %q", messages)
+	}
+
+	// the reason is reset and set to the same reason again
+	revisions.add(4, "")
+	revisions.add(5, "rotate the credentials")
+	revisions.add(6, `debug "etcd" latency`)
+	history, messages = syncAndRead(t, c, client)
+	if len(history) != 2 || history[1].Reason != `debug "etcd" latency` || history[1].Revision != 6 {
+		t.Errorf("expected a second entry for revision 6, got %v", history)
+	}
+	if len(messages) != 1 || messages[0] != `Revision 6 was created for forceRedeploymentReason: debug "etcd" latency` {
+		t.Errorf("unexpected events %q", messages)
+	}
+}
+
+func TestForceRedeploymentHistoryIsBounded(t *testing.T) {
+	c, revisions, client := newTestController(t)
+	for revision := 1; revision <= MaxHistoryEntries+5; revision++ {
+		revisions.add(revision, fmt.Sprintf("reason %d", revision))
+	}
+
+	history, messages := syncAndRead(t, c, client)
+	if len(history) != MaxHistoryEntries || history[0].Revision != 6 || history[MaxHistoryEntries-1].Revision != MaxHistoryEntries+5 {
+		t.Errorf("expected the revisions 6 to %d, got %v", MaxHistoryEntries+5, history)
+	}
+	if len(messages) != MaxHistoryEntries+5 {
+		t.Errorf("expected an event for every reason, got %d", len(messages))
+	}
+	count, err := testutil.GetGaugeMetricValue(forcedRedeploymentsMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != MaxHistoryEntries+5 {
+		t.Errorf("expected the count to include the dropped entries, got %v", count)
+	}
+
+	// the pruned revisions are not recorded again
+	for revision := 1; revision <= MaxHistoryEntries+5; revision++ {
+		revisions.indexer.Delete(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: fmt.Sprintf("kube-controller-manager-pod-%d", revision)}})
+	}
+	revisions.add(MaxHistoryEntries+6, "reason 1")
+	history, _ = syncAndRead(t, c, client)
+	if len(history) != MaxHistoryEntries || history[MaxHistoryEntries-1].Revision != MaxHistoryEntries+6 {
+		t.Errorf("expected revision %d to be the last entry, got %v", MaxHistoryEntries+6, history)
+	}
+	if count, _ := testutil.GetGaugeMetricValue(forcedRedeploymentsMetric); count != MaxHistoryEntries+6 {
+		t.Errorf("expected the count %d, got %v", MaxHistoryEntries+6, count)
+	}
+}
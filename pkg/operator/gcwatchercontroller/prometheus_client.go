@@ -5,7 +5,6 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"time"
@@ -17,13 +16,14 @@ import (
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/transport"
 
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
 )
 
 func newPrometheusClient(ctx context.Context, configMapClient corev1client.ConfigMapsGetter) (prometheusv1.API, *http.Transport, error) {
 	host := "thanos-querier.openshift-monitoring.svc"
 
-	saToken, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token")
+	saToken, err := compat.ServiceAccountToken(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error reading service account token: %w", err)
 	}
@@ -0,0 +1,171 @@
+package informerresync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// DefaultPeriod is the resync period the informers of the operator always had.
+	DefaultPeriod = 10 * time.Minute
+	// MinPeriod is the shortest configurable resync period. Every resync delivers an update of every cached object to
+	// every handler, shorter periods cost more CPU than they buy.
+	MinPeriod = time.Minute
+)
+
+// ResyncDisabledNamespaces are the namespaces whose informers never resync. They hold the large configmaps and
+// secrets of the cluster, resyncing them dominated the steady-state CPU of the operator. Every controller watching
+// them is either event driven or resyncs itself, so they do not depend on the informer resync for level triggering:
+//   - TargetConfigController, SATokenSignerController, ConfigObserver, ResourceSyncController and the cert rotation
+//     controllers resync every minute with ResyncEvery and requeue failed syncs.
+//   - WorkqueueSaturationController resyncs with ResyncEvery and GarbageCollectorWatcherController every 5 minutes,
+//     its service-ca handler is only interested in changes.
+//
+// A new controller watching these namespaces has to resync itself if it needs level triggering.
+var ResyncDisabledNamespaces = sets.New(
+	operatorclient.GlobalUserSpecifiedConfigNamespace,
+	operatorclient.GlobalMachineSpecifiedConfigNamespace,
+)
+
+// Periods are the resync periods of the informer groups of the operator.
+type Periods struct {
+	// Kube is the resync period of the namespaced and cluster scoped kube informers, except the ones of
+	// ResyncDisabledNamespaces.
+	Kube time.Duration
+	// Config is the resync period of the config.openshift.io informers.
+	Config time.Duration
+}
+
+func DefaultPeriods() Periods {
+	return Periods{Kube: DefaultPeriod, Config: DefaultPeriod}
+}
+
+func (p *Periods) AddFlags(flags *pflag.FlagSet) {
+	flags.DurationVar(&p.Kube, "kube-informers-resync-period", p.Kube, fmt.Sprintf("Resync period of the kube informers of the operator, at least %s. The informers of the namespaces %v never resync.", MinPeriod, sets.List(ResyncDisabledNamespaces)))
+	flags.DurationVar(&p.Config, "config-informers-resync-period", p.Config, fmt.Sprintf("Resync period of the config.openshift.io informers of the operator, at least %s.", MinPeriod))
+}
+
+func (p Periods) Validate() error {
+	for flag, period := range map[string]time.Duration{
+		"--kube-informers-resync-period":   p.Kube,
+		"--config-informers-resync-period": p.Config,
+	} {
+		if period < MinPeriod {
+			return fmt.Errorf("%s must be at least %s, got %s", flag, MinPeriod, period)
+		}
+	}
+	return nil
+}
+
+// NewKubeInformersForNamespaces is v1helpers.NewKubeInformersForNamespaces with the informers of namespaces resyncing
+// every period, but the ones of ResyncDisabledNamespaces that never resync.
+func NewKubeInformersForNamespaces(kubeClient kubernetes.Interface, period time.Duration, namespaces ...string) v1helpers.KubeInformersForNamespaces {
+	ret := kubeInformersForNamespaces{}
+	for _, namespace := range namespaces {
+		resync := period
+		if ResyncDisabledNamespaces.Has(namespace) {
+			resync = 0
+		}
+		if len(namespace) == 0 {
+			ret[""] = informers.NewSharedInformerFactory(kubeClient, resync)
+			continue
+		}
+		ret[namespace] = informers.NewSharedInformerFactoryWithOptions(kubeClient, resync, informers.WithNamespace(namespace))
+	}
+	return ret
+}
+
+// kubeInformersForNamespaces follows the implementation of v1helpers, which does not allow to choose the resync
+// periods.
+type kubeInformersForNamespaces map[string]informers.SharedInformerFactory
+
+var _ v1helpers.KubeInformersForNamespaces = kubeInformersForNamespaces{}
+
+func (i kubeInformersForNamespaces) Start(stopCh <-chan struct{}) {
+	for _, informer := range i {
+		informer.Start(stopCh)
+	}
+}
+
+func (i kubeInformersForNamespaces) Namespaces() sets.String {
+	return sets.StringKeySet(i)
+}
+
+func (i kubeInformersForNamespaces) InformersFor(namespace string) informers.SharedInformerFactory {
+	return i[namespace]
+}
+
+func (i kubeInformersForNamespaces) factoryFor(namespace string) informers.SharedInformerFactory {
+	informer, ok := i[namespace]
+	if !ok {
+		// coding error
+		panic(fmt.Sprintf("namespace %q is missing", namespace))
+	}
+	return informer
+}
+
+func (i kubeInformersForNamespaces) ConfigMapLister() corev1listers.ConfigMapLister {
+	return configMapLister(i)
+}
+
+func (i kubeInformersForNamespaces) SecretLister() corev1listers.SecretLister {
+	return secretLister(i)
+}
+
+func (i kubeInformersForNamespaces) PodLister() corev1listers.PodLister {
+	return podLister(i)
+}
+
+type configMapLister kubeInformersForNamespaces
+
+func (l configMapLister) List(selector labels.Selector) ([]*corev1.ConfigMap, error) {
+	globalInformer, ok := l[""]
+	if !ok {
+		return nil, fmt.Errorf("combinedLister does not support cross namespace list")
+	}
+	return globalInformer.Core().V1().ConfigMaps().Lister().List(selector)
+}
+
+func (l configMapLister) ConfigMaps(namespace string) corev1listers.ConfigMapNamespaceLister {
+	return kubeInformersForNamespaces(l).factoryFor(namespace).Core().V1().ConfigMaps().Lister().ConfigMaps(namespace)
+}
+
+type secretLister kubeInformersForNamespaces
+
+func (l secretLister) List(selector labels.Selector) ([]*corev1.Secret, error) {
+	globalInformer, ok := l[""]
+	if !ok {
+		return nil, fmt.Errorf("combinedLister does not support cross namespace list")
+	}
+	return globalInformer.Core().V1().Secrets().Lister().List(selector)
+}
+
+func (l secretLister) Secrets(namespace string) corev1listers.SecretNamespaceLister {
+	return kubeInformersForNamespaces(l).factoryFor(namespace).Core().V1().Secrets().Lister().Secrets(namespace)
+}
+
+type podLister kubeInformersForNamespaces
+
+func (l podLister) List(selector labels.Selector) ([]*corev1.Pod, error) {
+	globalInformer, ok := l[""]
+	if !ok {
+		return nil, fmt.Errorf("combinedLister does not support cross namespace list")
+	}
+	return globalInformer.Core().V1().Pods().Lister().List(selector)
+}
+
+func (l podLister) Pods(namespace string) corev1listers.PodNamespaceLister {
+	return kubeInformersForNamespaces(l).factoryFor(namespace).Core().V1().Pods().Lister().Pods(namespace)
+}
@@ -0,0 +1,130 @@
+package informerresync
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		periods     Periods
+		expectedErr string
+	}{
+		{name: "defaults", periods: DefaultPeriods()},
+		{name: "floor", periods: Periods{Kube: MinPeriod, Config: MinPeriod}},
+		{name: "kube below the floor", periods: Periods{Kube: 30 * time.Second, Config: MinPeriod}, expectedErr: "--kube-informers-resync-period must be at least 1m0s, got 30s"},
+		{name: "config disabled", periods: Periods{Kube: MinPeriod, Config: 0}, expectedErr: "--config-informers-resync-period must be at least 1m0s, got 0s"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.periods.Validate()
+			if len(test.expectedErr) == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(test.expectedErr) > 0 && (err == nil || err.Error() != test.expectedErr) {
+				t.Errorf("expected %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+// countResyncs counts the updates without change the informer delivers.
+func countResyncs(informer cache.SharedIndexInformer) *atomic.Int32 {
+	resyncs := &atomic.Int32{}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			if old.(*corev1.ConfigMap).ResourceVersion == new.(*corev1.ConfigMap).ResourceVersion {
+				resyncs.Add(1)
+			}
+		},
+	})
+	return resyncs
+}
+
+func TestResyncDisabledNamespaces(t *testing.T) {
+	namespaces := []string{operatorclient.TargetNamespace, operatorclient.GlobalUserSpecifiedConfigNamespace, operatorclient.GlobalMachineSpecifiedConfigNamespace}
+	client := fake.NewSimpleClientset()
+	for _, namespace := range namespaces {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "config", ResourceVersion: "1"}}
+		if _, err := client.CoreV1().ConfigMaps(namespace).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// below the floor of the flags to keep the test short
+	informers := NewKubeInformersForNamespaces(client, time.Second, namespaces...)
+	resyncs := map[string]*atomic.Int32{}
+	for _, namespace := range namespaces {
+		resyncs[namespace] = countResyncs(informers.InformersFor(namespace).Core().V1().ConfigMaps().Informer())
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informers.Start(stopCh)
+
+	if err := wait.PollUntilContextTimeout(context.TODO(), 100*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+		return resyncs[operatorclient.TargetNamespace].Load() >= 2, nil
+	}); err != nil {
+		t.Fatalf("expected the informers of %s to resync: %v", operatorclient.TargetNamespace, err)
+	}
+	for _, namespace := range sets.List(ResyncDisabledNamespaces) {
+		if count := resyncs[namespace].Load(); count != 0 {
+			t.Errorf("expected the informers of %s not to resync, got %d resyncs", namespace, count)
+		}
+	}
+	if cm, err := informers.ConfigMapLister().ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Get("config"); err != nil || cm.Name != "config" {
+		t.Errorf("expected the lister of %s to serve the configmap, got %v", operatorclient.GlobalUserSpecifiedConfigNamespace, err)
+	}
+}
+
+// TestEventDrivenControllerConverges covers the level triggered controllers on resync-disabled informers: a failed
+// sync is not retried by a resync, but requeued by the controller.
+func TestEventDrivenControllerConverges(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informers := NewKubeInformersForNamespaces(client, DefaultPeriod, operatorclient.GlobalUserSpecifiedConfigNamespace)
+
+	syncs := &atomic.Int32{}
+	converged := make(chan struct{})
+	controller := factory.New().WithInformers(
+		informers.InformersFor(operatorclient.GlobalUserSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer(),
+	).WithSync(func(ctx context.Context, syncCtx factory.SyncContext) error {
+		if syncs.Add(1) < 3 {
+			return fmt.Errorf("transient failure")
+		}
+		select {
+		case <-converged:
+		default:
+			close(converged)
+		}
+		return nil
+	}).ToController("TestController", events.NewInMemoryRecorder("test"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informers.Start(ctx.Done())
+	go controller.Run(ctx, 1)
+
+	// a single event, nothing resyncs the configmap afterwards
+	if _, err := client.CoreV1().ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-converged:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected the controller to converge by requeueing, got %d syncs", syncs.Load())
+	}
+}
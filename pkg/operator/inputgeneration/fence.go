@@ -0,0 +1,144 @@
+package inputgeneration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// DefaultTimeout is how long a revision is deferred at most. A writer that failed half way through a change does not
+// stop the revisions for longer.
+const DefaultTimeout = time.Minute
+
+// revisionStatusPrefix is the name prefix of the configmap the revision controller creates first for a new revision.
+const revisionStatusPrefix = "revision-status-"
+
+// NewFencedClient returns kubeClient failing the creation of a new revision in namespace, i.e. of its revision-status
+// configmap, while the inputs carry different generations. The revision controller retries and creates the revision
+// from the converged inputs. Inputs without a generation are not fenced. When the inputs disagree for longer than
+// timeout, the revision is created anyway.
+func NewFencedClient(kubeClient kubernetes.Interface, namespace string, configMaps, secrets []string, timeout time.Duration) kubernetes.Interface {
+	return &fencedClient{
+		Interface: kubeClient,
+		fence: &fence{
+			client:     kubeClient.CoreV1(),
+			namespace:  namespace,
+			configMaps: configMaps,
+			secrets:    secrets,
+			timeout:    timeout,
+			now:        time.Now,
+		},
+	}
+}
+
+type fence struct {
+	client     corev1client.CoreV1Interface
+	namespace  string
+	configMaps []string
+	secrets    []string
+	timeout    time.Duration
+	now        func() time.Time
+
+	lock sync.Mutex
+	// tornSince is when the inputs were first seen with different generations, zero while they agree
+	tornSince time.Time
+}
+
+// check returns an error while the inputs carry different generations and timeout has not passed since this was seen
+// first.
+func (f *fence) check(ctx context.Context) error {
+	inputs := map[string][]string{}
+	for _, name := range f.configMaps {
+		configMap, err := f.client.ConfigMaps(f.namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return err
+		}
+		if generation, ok := configMap.Annotations[Annotation]; ok {
+			inputs[generation] = append(inputs[generation], "configmap/"+name)
+		}
+	}
+	for _, name := range f.secrets {
+		secret, err := f.client.Secrets(f.namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return err
+		}
+		if generation, ok := secret.Annotations[Annotation]; ok {
+			inputs[generation] = append(inputs[generation], "secret/"+name)
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(inputs) <= 1 {
+		f.tornSince = time.Time{}
+		return nil
+	}
+
+	var generations []string
+	for generation, names := range inputs {
+		generations = append(generations, fmt.Sprintf("%s at %s", strings.Join(names, ","), generation))
+	}
+	sort.Strings(generations)
+	now := f.now()
+	if f.tornSince.IsZero() {
+		f.tornSince = now
+	}
+	if torn := now.Sub(f.tornSince); torn >= f.timeout {
+		// the next torn change is deferred again for up to the timeout
+		f.tornSince = time.Time{}
+		klog.Warningf("Creating a revision in %s although the inputs carry different generations for %s: %s", f.namespace, torn.Round(time.Second), strings.Join(generations, "; "))
+		return nil
+	}
+	return apierrors.NewConflict(corev1.Resource("configmaps"), revisionStatusPrefix+"*", fmt.Errorf("deferring the new revision, the inputs are being updated: %s", strings.Join(generations, "; ")))
+}
+
+type fencedClient struct {
+	kubernetes.Interface
+	fence *fence
+}
+
+func (c *fencedClient) CoreV1() corev1client.CoreV1Interface {
+	return &fencedCoreV1{CoreV1Interface: c.Interface.CoreV1(), fence: c.fence}
+}
+
+type fencedCoreV1 struct {
+	corev1client.CoreV1Interface
+	fence *fence
+}
+
+func (c *fencedCoreV1) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	if namespace != c.fence.namespace {
+		return c.CoreV1Interface.ConfigMaps(namespace)
+	}
+	return &fencedConfigMaps{ConfigMapInterface: c.CoreV1Interface.ConfigMaps(namespace), fence: c.fence}
+}
+
+type fencedConfigMaps struct {
+	corev1client.ConfigMapInterface
+	fence *fence
+}
+
+func (c *fencedConfigMaps) Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	if strings.HasPrefix(configMap.Name, revisionStatusPrefix) {
+		if err := c.fence.check(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return c.ConfigMapInterface.Create(ctx, configMap, opts)
+}
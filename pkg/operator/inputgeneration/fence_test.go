@@ -0,0 +1,129 @@
+package inputgeneration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func revisionStatus(revision string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "target", Name: revisionStatusPrefix + revision}}
+}
+
+func revisions(t *testing.T, kubeClient kubernetes.Interface) []string {
+	configMaps, err := kubeClient.CoreV1().ConfigMaps("target").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, configMap := range configMaps.Items {
+		if strings.HasPrefix(configMap.Name, revisionStatusPrefix) {
+			names = append(names, configMap.Name)
+		}
+	}
+	return names
+}
+
+func TestFenceDefersTornInputs(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset(configMap("config", "1"), configMap("pod", "1"), secret("secret", ""))
+	fencedClient := NewFencedClient(kubeClient, "target", []string{"config", "pod", "missing"}, []string{"secret"}, time.Minute)
+
+	// the writer updated the config, but not yet the pod
+	s := NewStamper(kubeClient.CoreV1(), "target", "2", []string{"config", "pod"}, nil)
+	changed := configMap("config", "1")
+	changed.Data["key"] = "new"
+	if _, err := s.ConfigMaps("target").Update(ctx, changed, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{})
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict while the inputs are torn, got %v", err)
+	}
+	// other configmaps are created
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, configMap("config-2", ""), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Finish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if names := revisions(t, kubeClient); len(names) != 1 || names[0] != "revision-status-2" {
+		t.Errorf("expected a single revision, got %v", names)
+	}
+}
+
+func TestFenceTimeout(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset(configMap("config", "2"), configMap("pod", "1"))
+	fencedClient := NewFencedClient(kubeClient, "target", []string{"config", "pod"}, nil, time.Minute).(*fencedClient)
+	now := time.Now()
+	fencedClient.fence.now = func() time.Time { return now }
+
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict while the inputs are torn, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict within the timeout, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("expected the revision after the timeout, got %v", err)
+	}
+	if names := revisions(t, kubeClient); len(names) != 1 {
+		t.Errorf("expected a single revision, got %v", names)
+	}
+}
+
+func TestFenceTimeoutPerTornChange(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset(configMap("config", "2"), configMap("pod", "1"))
+	fencedClient := NewFencedClient(kubeClient, "target", []string{"config", "pod"}, nil, time.Minute).(*fencedClient)
+	now := time.Now()
+	fencedClient.fence.now = func() time.Time { return now }
+
+	// the first torn change is let through after the timeout
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict while the inputs are torn, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("2"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("expected the revision after the timeout, got %v", err)
+	}
+
+	// a second torn change, without the inputs agreeing in between, is deferred for the full timeout again
+	s := NewStamper(kubeClient.CoreV1(), "target", "3", []string{"config", "pod"}, nil)
+	if _, err := s.ConfigMaps("target").Update(ctx, configMap("config", "2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("3"), metav1.CreateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict for the second torn change, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("3"), metav1.CreateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict within the timeout of the second torn change, got %v", err)
+	}
+
+	if err := s.Finish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fencedClient.CoreV1().ConfigMaps("target").Create(ctx, revisionStatus("3"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if names := revisions(t, kubeClient); len(names) != 2 {
+		t.Errorf("expected two revisions, got %v", names)
+	}
+}
@@ -0,0 +1,140 @@
+// Package inputgeneration fences the revision controller against torn reads of its inputs. A writer stamps every
+// revision input it changes in one logical change with the same generation and, once it is done, the other inputs it
+// owns as well. The revision controller does not create a revision while the stamped inputs carry different
+// generations, i.e. while a change is half written, for at most a timeout.
+package inputgeneration
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Annotation on a revision input is the generation of the logical change that wrote it last.
+const Annotation = "operator.openshift.io/input-generation"
+
+// NewGeneration returns a generation that differs from the ones returned before.
+func NewGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// Stamper is a client stamping generation on the configmaps and secrets of namespace it creates or updates, when they
+// are one of the inputs. Finish stamps the inputs that were not written.
+type Stamper struct {
+	corev1client.CoreV1Interface
+	namespace  string
+	generation string
+	configMaps sets.Set[string]
+	secrets    sets.Set[string]
+
+	stampedConfigMaps sets.Set[string]
+	stampedSecrets    sets.Set[string]
+}
+
+func NewStamper(client corev1client.CoreV1Interface, namespace, generation string, configMaps, secrets []string) *Stamper {
+	return &Stamper{
+		CoreV1Interface:   client,
+		namespace:         namespace,
+		generation:        generation,
+		configMaps:        sets.New(configMaps...),
+		secrets:           sets.New(secrets...),
+		stampedConfigMaps: sets.New[string](),
+		stampedSecrets:    sets.New[string](),
+	}
+}
+
+func (s *Stamper) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	if namespace != s.namespace {
+		return s.CoreV1Interface.ConfigMaps(namespace)
+	}
+	return &stampingConfigMaps{ConfigMapInterface: s.CoreV1Interface.ConfigMaps(namespace), stamper: s}
+}
+
+func (s *Stamper) Secrets(namespace string) corev1client.SecretInterface {
+	if namespace != s.namespace {
+		return s.CoreV1Interface.Secrets(namespace)
+	}
+	return &stampingSecrets{SecretInterface: s.CoreV1Interface.Secrets(namespace), stamper: s}
+}
+
+// Finish stamps the inputs that were not written with the generation of the ones that were. Nothing is stamped when no
+// input was written, the inputs keep the generation of the last change then.
+func (s *Stamper) Finish(ctx context.Context) error {
+	if s.stampedConfigMaps.Len() == 0 && s.stampedSecrets.Len() == 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{Annotation: s.generation},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range sets.List(s.configMaps.Difference(s.stampedConfigMaps)) {
+		_, err := s.CoreV1Interface.ConfigMaps(s.namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, name := range sets.List(s.secrets.Difference(s.stampedSecrets)) {
+		_, err := s.CoreV1Interface.Secrets(s.namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stamper) stamp(meta *metav1.ObjectMeta, inputs, stamped sets.Set[string]) {
+	if !inputs.Has(meta.Name) {
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[Annotation] = s.generation
+	stamped.Insert(meta.Name)
+}
+
+type stampingConfigMaps struct {
+	corev1client.ConfigMapInterface
+	stamper *Stamper
+}
+
+func (c *stampingConfigMaps) Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	configMap = configMap.DeepCopy()
+	c.stamper.stamp(&configMap.ObjectMeta, c.stamper.configMaps, c.stamper.stampedConfigMaps)
+	return c.ConfigMapInterface.Create(ctx, configMap, opts)
+}
+
+func (c *stampingConfigMaps) Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error) {
+	configMap = configMap.DeepCopy()
+	c.stamper.stamp(&configMap.ObjectMeta, c.stamper.configMaps, c.stamper.stampedConfigMaps)
+	return c.ConfigMapInterface.Update(ctx, configMap, opts)
+}
+
+type stampingSecrets struct {
+	corev1client.SecretInterface
+	stamper *Stamper
+}
+
+func (c *stampingSecrets) Create(ctx context.Context, secret *corev1.Secret, opts metav1.CreateOptions) (*corev1.Secret, error) {
+	secret = secret.DeepCopy()
+	c.stamper.stamp(&secret.ObjectMeta, c.stamper.secrets, c.stamper.stampedSecrets)
+	return c.SecretInterface.Create(ctx, secret, opts)
+}
+
+func (c *stampingSecrets) Update(ctx context.Context, secret *corev1.Secret, opts metav1.UpdateOptions) (*corev1.Secret, error) {
+	secret = secret.DeepCopy()
+	c.stamper.stamp(&secret.ObjectMeta, c.stamper.secrets, c.stamper.stampedSecrets)
+	return c.SecretInterface.Update(ctx, secret, opts)
+}
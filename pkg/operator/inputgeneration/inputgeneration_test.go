@@ -0,0 +1,106 @@
+package inputgeneration
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func configMap(name, generation string) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "target", Name: name}, Data: map[string]string{"key": "old"}}
+	if len(generation) > 0 {
+		configMap.Annotations = map[string]string{Annotation: generation}
+	}
+	return configMap
+}
+
+func secret(name, generation string) *corev1.Secret {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "target", Name: name}}
+	if len(generation) > 0 {
+		secret.Annotations = map[string]string{Annotation: generation}
+	}
+	return secret
+}
+
+func TestStamper(t *testing.T) {
+	tests := []struct {
+		name                string
+		write               func(ctx context.Context, s *Stamper) error
+		expectedGenerations map[string]string
+	}{
+		{
+			name: "nothing written",
+			write: func(ctx context.Context, s *Stamper) error {
+				return nil
+			},
+			expectedGenerations: map[string]string{"config": "1", "pod": "1", "other": "", "secret": "1"},
+		},
+		{
+			name: "an input updated",
+			write: func(ctx context.Context, s *Stamper) error {
+				_, err := s.ConfigMaps("target").Update(ctx, configMap("config", "1"), metav1.UpdateOptions{})
+				return err
+			},
+			expectedGenerations: map[string]string{"config": "2", "pod": "2", "other": "", "secret": "2"},
+		},
+		{
+			name: "an input created",
+			write: func(ctx context.Context, s *Stamper) error {
+				_, err := s.ConfigMaps("target").Create(ctx, configMap("created", ""), metav1.CreateOptions{})
+				return err
+			},
+			expectedGenerations: map[string]string{"config": "2", "pod": "2", "other": "", "secret": "2", "created": "2"},
+		},
+		{
+			name: "not an input written",
+			write: func(ctx context.Context, s *Stamper) error {
+				if _, err := s.ConfigMaps("target").Update(ctx, configMap("other", ""), metav1.UpdateOptions{}); err != nil {
+					return err
+				}
+				created, err := s.ConfigMaps("other").Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "config"}}, metav1.CreateOptions{})
+				if err == nil && len(created.Annotations) > 0 {
+					t.Errorf("expected configmap/config -n other not to be stamped, got %v", created.Annotations)
+				}
+				return err
+			},
+			expectedGenerations: map[string]string{"config": "1", "pod": "1", "other": "", "secret": "1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := fake.NewSimpleClientset(configMap("config", "1"), configMap("pod", "1"), configMap("other", ""), secret("secret", "1"))
+			s := NewStamper(kubeClient.CoreV1(), "target", "2", []string{"config", "pod", "created", "optional"}, []string{"secret"})
+
+			if err := test.write(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Finish(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, expected := range test.expectedGenerations {
+				var annotations map[string]string
+				if name == "secret" {
+					found, err := kubeClient.CoreV1().Secrets("target").Get(ctx, name, metav1.GetOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					annotations = found.Annotations
+				} else {
+					found, err := kubeClient.CoreV1().ConfigMaps("target").Get(ctx, name, metav1.GetOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					annotations = found.Annotations
+				}
+				if annotations[Annotation] != expected {
+					t.Errorf("%s: expected generation %q, got %q", name, expected, annotations[Annotation])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package installerconcurrencycontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	controllerName = "InstallerConcurrencyController"
+	conditionType  = "InstallerConcurrencyDegraded"
+
+	// DefaultMaxConcurrentInstallers is how many installer pods may run in the cluster at the same time. The installer
+	// controller rolls out one node at a time, a second installer means two operators or a bug are at work.
+	DefaultMaxConcurrentInstallers = 1
+	// DefaultStaleTimeout is how long an installer or pruner pod may run before it is considered stuck and deleted in
+	// favor of a new installer pod.
+	DefaultStaleTimeout = 30 * time.Minute
+)
+
+// revisionPodSelector selects the installer and pruner pods, both write to the static-pod-resources directory of
+// their node.
+var revisionPodSelector = func() labels.Selector {
+	requirement, err := labels.NewRequirement("app", selection.In, []string{"installer", "pruner"})
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*requirement)
+}()
+
+// InstallerConcurrencyController keeps installer pods of different revisions from writing to the static-pod-resources
+// directory of a node at the same time. Before the installer controller creates an installer pod, HoldInstallerPod
+// lists the live installer and pruner pods: the ones running for longer than the stale timeout are deleted, and the
+// installer pod is held back while its node runs another installer or pruner pod, or the cluster runs the maximum of
+// installer pods. The installer controller waits for a held pod like for a pending one and creates it on a later sync.
+//
+// The pruner pods are created without such a hook, two of them or a pruner next to an installer are not prevented but
+// reported. Nodes running more than one installer or pruner pod set InstallerConcurrencyDegraded.
+type InstallerConcurrencyController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	podsGetter     corev1client.PodsGetter
+	podLister      corev1listers.PodNamespaceLister
+	eventRecorder  events.Recorder
+
+	maxConcurrentInstallers int
+	staleTimeout            time.Duration
+	now                     func() time.Time
+
+	controller factory.Controller
+}
+
+func NewInstallerConcurrencyController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	podsGetter corev1client.PodsGetter,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) *InstallerConcurrencyController {
+	eventRecorder = eventRecorder.WithComponentSuffix("installer-concurrency-controller")
+	c := &InstallerConcurrencyController{
+		operatorClient:          operatorClient,
+		podsGetter:              podsGetter,
+		podLister:               kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Lister().Pods(operatorclient.TargetNamespace),
+		eventRecorder:           eventRecorder,
+		maxConcurrentInstallers: DefaultMaxConcurrentInstallers,
+		staleTimeout:            DefaultStaleTimeout,
+		now:                     time.Now,
+	}
+	c.controller = factory.New().WithInformers(
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder)
+	return c
+}
+
+func (c *InstallerConcurrencyController) Run(ctx context.Context, workers int) {
+	c.controller.Run(ctx, workers)
+}
+
+// HoldInstallerPod is an installergate.HoldFunc. It holds the pod back while other installer or pruner pods prevent
+// it.
+func (c *InstallerConcurrencyController) HoldInstallerPod(pod *corev1.Pod, nodeName string, _ int32) (string, error) {
+	// the installer controller mutates the pod without a context
+	ctx, cancel := compat.WithCallTimeout(context.Background())
+	defer cancel()
+	// the live pods, the informer may not have seen an installer pod another operator created just now
+	pods, err := c.podsGetter.Pods(pod.Namespace).List(ctx, metav1.ListOptions{LabelSelector: revisionPodSelector.String()})
+	if err != nil {
+		return "", err
+	}
+
+	var installers []string
+	for i := range pods.Items {
+		existing := &pods.Items[i]
+		if !active(existing) || existing.Name == pod.Name {
+			continue
+		}
+		if age := c.now().Sub(existing.CreationTimestamp.Time); age > c.staleTimeout {
+			if err := c.podsGetter.Pods(existing.Namespace).Delete(ctx, existing.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("unable to delete the stale pod %s: %v", existing.Name, err)
+			}
+			c.eventRecorder.Warningf("StaleInstallerPodDeleted", "Deleted pod %s on node %s, it was running for %s, longer than %s", existing.Name, existing.Spec.NodeName, age.Round(time.Second), c.staleTimeout)
+			continue
+		}
+		if existing.Spec.NodeName == nodeName {
+			return fmt.Sprintf("node %s still runs pod %s", nodeName, existing.Name), nil
+		}
+		if existing.Labels["app"] == "installer" {
+			installers = append(installers, existing.Name)
+		}
+	}
+	if len(installers) >= c.maxConcurrentInstallers {
+		sort.Strings(installers)
+		return fmt.Sprintf("%d installer pods are running (%s)", len(installers), strings.Join(installers, ", ")), nil
+	}
+	return "", nil
+}
+
+func (c *InstallerConcurrencyController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	pods, err := c.podLister.List(revisionPodSelector)
+	if err != nil {
+		return err
+	}
+	podsOnNode := map[string][]string{}
+	for _, pod := range pods {
+		if active(pod) {
+			podsOnNode[pod.Spec.NodeName] = append(podsOnNode[pod.Spec.NodeName], pod.Name)
+		}
+	}
+	var violations []string
+	for node, names := range podsOnNode {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		violations = append(violations, fmt.Sprintf("node %s runs the pods %s at the same time", node, strings.Join(names, " and ")))
+	}
+	sort.Strings(violations)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(violations) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "ConcurrentInstallerPods"
+		condition.Message = strings.Join(violations, "\n")
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// active is true for a pod that neither terminated nor is being deleted.
+func active(pod *corev1.Pod) bool {
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed && pod.DeletionTimestamp == nil
+}
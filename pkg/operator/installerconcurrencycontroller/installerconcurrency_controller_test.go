@@ -0,0 +1,176 @@
+package installerconcurrencycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var now = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func revisionPod(name, app, node string, phase corev1.PodPhase, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         operatorclient.TargetNamespace,
+			Name:              name,
+			Labels:            map[string]string{"app": app},
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		},
+		Spec:   corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func newTestController(pods ...runtime.Object) (*InstallerConcurrencyController, *fake.Clientset, events.InMemoryRecorder) {
+	recorder := events.NewInMemoryRecorder("test")
+	client := fake.NewSimpleClientset(pods...)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		indexer.Add(pod)
+	}
+	return &InstallerConcurrencyController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		podsGetter:              client.CoreV1(),
+		podLister:               corev1listers.NewPodLister(indexer).Pods(operatorclient.TargetNamespace),
+		eventRecorder:           recorder,
+		maxConcurrentInstallers: DefaultMaxConcurrentInstallers,
+		staleTimeout:            DefaultStaleTimeout,
+		now:                     func() time.Time { return now },
+	}, client, recorder
+}
+
+func installerPodFor(revision, node string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "installer-" + revision + "-" + node}}
+}
+
+func TestHoldInstallerPod(t *testing.T) {
+	tests := []struct {
+		name           string
+		pods           []runtime.Object
+		node           string
+		expectedReason string
+		expectDeleted  string
+	}{
+		{
+			name: "normal operation",
+			pods: []runtime.Object{
+				revisionPod("installer-6-master-0", "installer", "master-0", corev1.PodSucceeded, time.Hour),
+				revisionPod("installer-6-master-1", "installer", "master-1", corev1.PodSucceeded, 50*time.Minute),
+				revisionPod("revision-pruner-6-master-0", "pruner", "master-0", corev1.PodSucceeded, time.Hour),
+				revisionPod("installer-5-master-2", "installer", "master-2", corev1.PodFailed, 2*time.Hour),
+			},
+			node: "master-2",
+		},
+		{
+			name: "the installer pod exists already",
+			pods: []runtime.Object{
+				revisionPod("installer-7-master-2", "installer", "master-2", corev1.PodRunning, time.Minute),
+			},
+			node: "master-2",
+		},
+		{
+			// another operator created an installer pod the informers did not see yet
+			name: "another installer on the node",
+			pods: []runtime.Object{
+				revisionPod("installer-6-master-2", "installer", "master-2", corev1.PodPending, time.Second),
+			},
+			node:           "master-2",
+			expectedReason: "node master-2 still runs pod installer-6-master-2",
+		},
+		{
+			name: "a pruner on the node",
+			pods: []runtime.Object{
+				revisionPod("revision-pruner-6-master-2", "pruner", "master-2", corev1.PodRunning, time.Second),
+			},
+			node:           "master-2",
+			expectedReason: "node master-2 still runs pod revision-pruner-6-master-2",
+		},
+		{
+			name: "an installer on another node",
+			pods: []runtime.Object{
+				revisionPod("installer-7-master-1", "installer", "master-1", corev1.PodRunning, time.Minute),
+				revisionPod("revision-pruner-7-master-0", "pruner", "master-0", corev1.PodRunning, time.Second),
+			},
+			node:           "master-2",
+			expectedReason: "1 installer pods are running (installer-7-master-1)",
+		},
+		{
+			name: "a stale installer on the node",
+			pods: []runtime.Object{
+				revisionPod("installer-6-master-2", "installer", "master-2", corev1.PodRunning, 45*time.Minute),
+			},
+			node:          "master-2",
+			expectDeleted: "installer-6-master-2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, client, recorder := newTestController(test.pods...)
+			reason, err := c.HoldInstallerPod(installerPodFor("7", test.node), test.node, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason != test.expectedReason {
+				t.Fatalf("expected the installer pod to be held for %q, got %q", test.expectedReason, reason)
+			}
+
+			var deleted []string
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "delete" {
+					deleted = append(deleted, action.(clienttesting.DeleteAction).GetName())
+				}
+			}
+			if strings.Join(deleted, ",") != test.expectDeleted {
+				t.Errorf("expected the deleted pods %q, got %q", test.expectDeleted, deleted)
+			}
+			if len(test.expectDeleted) > 0 {
+				if events := recorder.Events(); len(events) != 1 || events[0].Reason != "StaleInstallerPodDeleted" {
+					t.Errorf("expected a StaleInstallerPodDeleted event, got %v", events)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentInstallerPodsAreReported(t *testing.T) {
+	c, _, _ := newTestController(
+		revisionPod("installer-6-master-0", "installer", "master-0", corev1.PodRunning, 2*time.Minute),
+		revisionPod("installer-7-master-0", "installer", "master-0", corev1.PodPending, time.Minute),
+		revisionPod("installer-5-master-1", "installer", "master-1", corev1.PodSucceeded, time.Hour),
+		revisionPod("revision-pruner-7-master-1", "pruner", "master-1", corev1.PodRunning, time.Minute),
+	)
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+	expectedMessage := "node master-0 runs the pods installer-6-master-0 and installer-7-master-0 at the same time"
+	if condition == nil || condition.Status != operatorv1.ConditionTrue || condition.Message != expectedMessage {
+		t.Errorf("expected the condition True %q, got %#v", expectedMessage, condition)
+	}
+}
@@ -0,0 +1,139 @@
+package installergate
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// HoldFunc returns why the installer pod for revision on nodeName must not be created yet, empty when it may be. An
+// error fails the creation of the pod.
+type HoldFunc func(pod *corev1.Pod, nodeName string, revision int32) (string, error)
+
+// Gate holds back installer pods without failing them. The installer controller of library-go cannot defer an
+// installer pod: an error of its installer.InstallerPodMutationFunc is reported as InstallerPodFailed and sets
+// InstallerControllerDegraded. A pod the Gate holds is not created, the installer controller reads it through Client
+// as a pending pod instead and waits for it like for any installer that did not finish yet. Every sync of the
+// installer controller asks the HoldFuncs again, the pod is created on the first one none of them holds it.
+//
+// The held pods are kept in memory, a restarted operator asks the HoldFuncs again.
+type Gate struct {
+	holds []HoldFunc
+
+	lock sync.Mutex
+	// held are the pending pods reported in place of the ones not created
+	held map[types.NamespacedName]*corev1.Pod
+}
+
+func NewGate(holds ...HoldFunc) *Gate {
+	return &Gate{
+		holds: holds,
+		held:  map[types.NamespacedName]*corev1.Pod{},
+	}
+}
+
+// MutateInstallerPod is an installer.InstallerPodMutationFunc. It leaves the pod alone and holds it while one of the
+// HoldFuncs asks to.
+func (g *Gate) MutateInstallerPod(pod *corev1.Pod, nodeName string, _ *operatorv1.StaticPodOperatorSpec, revision int32) error {
+	for _, hold := range g.holds {
+		reason, err := hold(pod, nodeName, revision)
+		if err != nil {
+			return err
+		}
+		if len(reason) > 0 {
+			g.hold(pod, reason)
+			return nil
+		}
+	}
+	g.release(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	return nil
+}
+
+func (g *Gate) hold(pod *corev1.Pod, reason string) {
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if previous, ok := g.held[key]; !ok || previous.Status.Message != reason {
+		klog.Infof("Holding back installer pod %s on node %s: %s", pod.Name, pod.Spec.NodeName, reason)
+	}
+	held := pod.DeepCopy()
+	held.Status = corev1.PodStatus{Phase: corev1.PodPending, Message: reason}
+	g.held[key] = held
+}
+
+func (g *Gate) release(key types.NamespacedName) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if _, ok := g.held[key]; ok {
+		klog.Infof("Releasing installer pod %s", key.Name)
+		delete(g.held, key)
+	}
+}
+
+func (g *Gate) get(key types.NamespacedName) (*corev1.Pod, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	pod, ok := g.held[key]
+	if !ok {
+		return nil, false
+	}
+	return pod.DeepCopy(), true
+}
+
+// Client returns kubeClient with the held pods in place of the ones not created. The installer controller must read
+// and apply its pods through it.
+func (g *Gate) Client(kubeClient kubernetes.Interface) kubernetes.Interface {
+	return &gatedClient{Interface: kubeClient, gate: g}
+}
+
+type gatedClient struct {
+	kubernetes.Interface
+	gate *Gate
+}
+
+func (c *gatedClient) CoreV1() corev1client.CoreV1Interface {
+	return &gatedCoreV1{CoreV1Interface: c.Interface.CoreV1(), gate: c.gate}
+}
+
+type gatedCoreV1 struct {
+	corev1client.CoreV1Interface
+	gate *Gate
+}
+
+func (c *gatedCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return &gatedPods{PodInterface: c.CoreV1Interface.Pods(namespace), gate: c.gate, namespace: namespace}
+}
+
+type gatedPods struct {
+	corev1client.PodInterface
+	gate      *Gate
+	namespace string
+}
+
+// Get returns a held pod only while it does not exist. An installer pod created before it was held, e.g. before a
+// pruner started on its node, is returned as it is.
+func (c *gatedPods) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Pod, error) {
+	pod, err := c.PodInterface.Get(ctx, name, opts)
+	if !apierrors.IsNotFound(err) {
+		return pod, err
+	}
+	if held, ok := c.gate.get(types.NamespacedName{Namespace: c.namespace, Name: name}); ok {
+		return held, nil
+	}
+	return pod, err
+}
+
+// Delete drops a held pod, the installer controller deletes the installer pod of a revision superseded by a newer one.
+func (c *gatedPods) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	c.gate.release(types.NamespacedName{Namespace: c.namespace, Name: name})
+	return c.PodInterface.Delete(ctx, name, opts)
+}
@@ -0,0 +1,132 @@
+package installergate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/installer"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestGateDefersTheInstallerPod(t *testing.T) {
+	ctx := context.TODO()
+	namespace := operatorclient.TargetNamespace
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "kube-controller-manager-pod-1"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "revision-status-1"}},
+	)
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{
+			LatestAvailableRevision: 1,
+			NodeStatuses:            []operatorv1.NodeStatus{{NodeName: "master-0"}},
+		},
+		nil,
+		nil,
+	)
+	reason := "another installer runs"
+	gate := NewGate(func(*corev1.Pod, string, int32) (string, error) { return reason, nil })
+	recorder := events.NewInMemoryRecorder("test")
+	c := installer.NewInstallerController(
+		namespace,
+		"kube-controller-manager-pod",
+		[]revision.RevisionResource{{Name: "kube-controller-manager-pod"}},
+		nil,
+		[]string{"installer"},
+		informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace)),
+		operatorClient,
+		client.CoreV1(),
+		client.CoreV1(),
+		gate.Client(client).CoreV1(),
+		recorder,
+	).WithInstallerPodMutationFn(gate.MutateInstallerPod)
+
+	sync := func() {
+		t.Helper()
+		if err := c.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, condition := range status.Conditions {
+			if strings.HasSuffix(condition.Type, "Degraded") && condition.Status == operatorv1.ConditionTrue {
+				t.Errorf("expected no degraded condition, got %#v", condition)
+			}
+		}
+	}
+	installerPod := func() (*corev1.Pod, error) {
+		return client.CoreV1().Pods(namespace).Get(ctx, "installer-1-master-0", metav1.GetOptions{})
+	}
+
+	// the node is assigned the revision, then its installer pod is held
+	sync()
+	sync()
+	sync()
+	if _, err := installerPod(); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the installer pod to be held, got %v", err)
+	}
+	held, err := gate.Client(client).CoreV1().Pods(namespace).Get(ctx, "installer-1-master-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held.Status.Phase != corev1.PodPending || held.Status.Message != reason {
+		t.Errorf("expected the held pod to be pending with the message %q, got %#v", reason, held.Status)
+	}
+	for _, event := range recorder.Events() {
+		if event.Reason == "InstallerPodFailed" || event.Reason == "PodCreated" {
+			t.Errorf("expected no %s event while the pod is held, got %q", event.Reason, event.Message)
+		}
+	}
+
+	reason = ""
+	sync()
+	if _, err := installerPod(); err != nil {
+		t.Fatalf("expected the installer pod to be created once released, got %v", err)
+	}
+}
+
+func TestGateReturnsExistingPods(t *testing.T) {
+	ctx := context.TODO()
+	existing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "installer-1-master-0"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	client := fake.NewSimpleClientset(existing)
+	gate := NewGate(func(*corev1.Pod, string, int32) (string, error) { return "a pruner runs", nil })
+	pods := gate.Client(client).CoreV1().Pods(operatorclient.TargetNamespace)
+
+	// a pod held after it was created is not hidden
+	if err := gate.MutateInstallerPod(existing.DeepCopy(), "master-0", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	pod, err := pods.Get(ctx, existing.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		t.Errorf("expected the existing pod, got %#v", pod.Status)
+	}
+
+	// a deleted pod is not held anymore
+	if err := pods.Delete(ctx, existing.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pods.Get(ctx, existing.Name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the deleted pod to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package installerhistorycontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+)
+
+const (
+	controllerName = "InstallerHistoryController"
+	conditionType  = "NodeInstallerHistoryDegraded"
+
+	// HistoryConfigMapName is the configmap in the operator namespace that keeps the history.
+	HistoryConfigMapName = "installer-history"
+	// MaxAttemptsPerNode bounds the history of every node, the oldest attempts are dropped first.
+	MaxAttemptsPerNode = 10
+
+	historyKey = "history.json"
+
+	// recentAttempts is the window of the Degraded message, a node that failed repeatedly within it is Degraded
+	recentAttempts   = 5
+	repeatedFailures = 2
+	// maxFailureSummary bounds the summary of a failure, the installer logs are on the node
+	maxFailureSummary = 256
+)
+
+func init() {
+	relatedobjects.Register(configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: HistoryConfigMapName})
+}
+
+// installerPodName matches the names of the installer pods, installer-<revision>-<node> and
+// installer-<revision>-retry-<n>-<node>.
+var installerPodName = regexp.MustCompile(`^installer-(\d+)-`)
+
+// Outcome is how an install attempt ended.
+type Outcome string
+
+const (
+	Succeeded Outcome = "Succeeded"
+	Failed    Outcome = "Failed"
+)
+
+// Attempt is a finished installer pod.
+type Attempt struct {
+	Pod      string      `json:"pod"`
+	Revision int32       `json:"revision"`
+	Start    metav1.Time `json:"start"`
+	End      metav1.Time `json:"end"`
+	Outcome  Outcome     `json:"outcome"`
+	// FailureSummary is the termination message of a failed installer, truncated
+	FailureSummary string `json:"failureSummary,omitempty"`
+}
+
+// Duration is how long the installer ran.
+func (a Attempt) Duration() time.Duration {
+	return a.End.Sub(a.Start.Time)
+}
+
+// History is the install attempts per node, oldest first.
+type History map[string][]Attempt
+
+// InstallerHistoryController records the finished installer pods of every node in the installer-history configmap.
+// The node statuses only carry the latest state of a node and the installer pods are pruned, the configmap keeps the
+// last attempts of every node across revisions and operator restarts. A node whose latest install failed after
+// failing before within the last attempts sets NodeInstallerHistoryDegraded, naming how often it failed.
+type InstallerHistoryController struct {
+	operatorClient  v1helpers.StaticPodOperatorClient
+	configMapClient corev1client.ConfigMapsGetter
+	podLister       corev1listers.PodNamespaceLister
+}
+
+func NewInstallerHistoryController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configMapClient corev1client.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &InstallerHistoryController{
+		operatorClient:  operatorClient,
+		configMapClient: configMapClient,
+		podLister:       kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Lister().Pods(operatorclient.TargetNamespace),
+	}
+
+	return factory.New().WithInformers(
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Informer(),
+	).ResyncEvery(5*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("installer-history-controller"))
+}
+
+func (c *InstallerHistoryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	history, err := c.history(ctx)
+	if err != nil {
+		return err
+	}
+
+	pods, err := c.podLister.List(labels.SelectorFromSet(labels.Set{"app": "installer"}))
+	if err != nil {
+		return err
+	}
+	if added := history.add(pods); added {
+		historyBytes, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		if _, _, err := resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: HistoryConfigMapName},
+			Data:       map[string]string{historyKey: string(historyBytes)},
+		}); err != nil {
+			return err
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if messages := history.repeatedFailures(); len(messages) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "RepeatedInstallFailures"
+		condition.Message = strings.Join(messages, "\n")
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// history returns the recorded attempts. It is read live, a stale cache would record the last attempts again.
+func (c *InstallerHistoryController) history(ctx context.Context) (History, error) {
+	configMap, err := c.configMapClient.ConfigMaps(operatorclient.OperatorNamespace).Get(ctx, HistoryConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return History{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := History{}
+	if err := json.Unmarshal([]byte(configMap.Data[historyKey]), &history); err != nil {
+		return nil, fmt.Errorf("configmap/%s: %s: %v", HistoryConfigMapName, historyKey, err)
+	}
+	return history, nil
+}
+
+// add appends the finished installer pods that are not recorded yet and bounds the history of their nodes. It
+// returns whether it added any.
+func (h History) add(pods []*corev1.Pod) bool {
+	added := map[string]bool{}
+	for _, pod := range pods {
+		attempt, ok := attemptOf(pod)
+		if !ok {
+			continue
+		}
+		node := pod.Spec.NodeName
+		attempts := h[node]
+		if recorded(attempts, attempt) {
+			continue
+		}
+		h[node] = append(attempts, attempt)
+		added[node] = true
+	}
+	for node := range added {
+		attempts := h[node]
+		sort.SliceStable(attempts, func(i, j int) bool { return attempts[i].End.Before(&attempts[j].End) })
+		if len(attempts) > MaxAttemptsPerNode {
+			attempts = attempts[len(attempts)-MaxAttemptsPerNode:]
+		}
+		h[node] = attempts
+	}
+	return len(added) > 0
+}
+
+// recorded is true for an attempt in attempts or older than all of them, which is a pod whose attempt dropped out
+// of the history.
+func recorded(attempts []Attempt, attempt Attempt) bool {
+	for _, a := range attempts {
+		if a.Pod == attempt.Pod && a.Start.Equal(&attempt.Start) {
+			return true
+		}
+	}
+	return len(attempts) >= MaxAttemptsPerNode && attempt.End.Before(&attempts[0].End)
+}
+
+// attemptOf returns the attempt of a finished installer pod.
+func attemptOf(pod *corev1.Pod) (Attempt, bool) {
+	var outcome Outcome
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		outcome = Succeeded
+	case corev1.PodFailed:
+		outcome = Failed
+	default:
+		return Attempt{}, false
+	}
+	match := installerPodName.FindStringSubmatch(pod.Name)
+	if match == nil || len(pod.Spec.NodeName) == 0 {
+		return Attempt{}, false
+	}
+	revision, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		return Attempt{}, false
+	}
+
+	attempt := Attempt{Pod: pod.Name, Revision: int32(revision), Start: pod.CreationTimestamp, End: pod.CreationTimestamp, Outcome: outcome}
+	if pod.Status.StartTime != nil {
+		attempt.Start = *pod.Status.StartTime
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		if terminated.FinishedAt.After(attempt.End.Time) {
+			attempt.End = terminated.FinishedAt
+		}
+		if outcome == Failed && terminated.ExitCode != 0 && len(attempt.FailureSummary) == 0 {
+			attempt.FailureSummary = summarize(terminated)
+		}
+	}
+	if outcome == Failed && len(attempt.FailureSummary) == 0 {
+		attempt.FailureSummary = pod.Status.Message
+	}
+	return attempt, true
+}
+
+func summarize(terminated *corev1.ContainerStateTerminated) string {
+	summary := strings.Join(strings.Fields(terminated.Message), " ")
+	if len(summary) == 0 {
+		summary = fmt.Sprintf("%s, exit code %d", terminated.Reason, terminated.ExitCode)
+	}
+	if len(summary) > maxFailureSummary {
+		summary = summary[:maxFailureSummary] + "..."
+	}
+	return summary
+}
+
+// repeatedFailures describes the nodes whose latest attempt failed and that failed before within the recent attempts.
+func (h History) repeatedFailures() []string {
+	var messages []string
+	for node, attempts := range h {
+		if len(attempts) == 0 || attempts[len(attempts)-1].Outcome != Failed {
+			continue
+		}
+		recent := attempts
+		if len(recent) > recentAttempts {
+			recent = recent[len(recent)-recentAttempts:]
+		}
+		failures := 0
+		for _, attempt := range recent {
+			if attempt.Outcome == Failed {
+				failures++
+			}
+		}
+		if failures < repeatedFailures {
+			continue
+		}
+		last := attempts[len(attempts)-1]
+		messages = append(messages, fmt.Sprintf("node %s has failed %d of the last %d installs, revision %d failed after %s: %s", node, failures, len(recent), last.Revision, last.Duration().Round(time.Second), last.FailureSummary))
+	}
+	sort.Strings(messages)
+	return messages
+}
@@ -0,0 +1,184 @@
+package installerhistorycontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var start = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+type testPods struct {
+	t       *testing.T
+	indexer cache.Indexer
+	// attempts counts the pods, every pod starts a minute after the previous one
+	attempts int
+}
+
+// add creates a finished installer pod of revision on node that ran for 30 seconds. A failed pod terminates with a
+// message.
+func (p *testPods) add(revision int, node string, phase corev1.PodPhase) {
+	p.attempts++
+	created := start.Add(time.Duration(p.attempts) * time.Minute)
+	terminated := &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(created.Add(30 * time.Second))}
+	if phase == corev1.PodFailed {
+		terminated.ExitCode = 1
+		terminated.Reason = "Error"
+		terminated.Message = "timed out waiting for\nthe kube-controller-manager-pod configmap"
+	}
+	if err := p.indexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         operatorclient.TargetNamespace,
+			Name:              fmt.Sprintf("installer-%d-retry-%d-%s", revision, p.attempts, node),
+			Labels:            map[string]string{"app": "installer"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			StartTime:         &metav1.Time{Time: created},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "installer", State: corev1.ContainerState{Terminated: terminated}}},
+		},
+	}); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func newTestController(t *testing.T, client *fake.Clientset) (*InstallerHistoryController, *testPods) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	// a running installer is not recorded
+	if err := indexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "installer-9-master-0", Labels: map[string]string{"app": "installer"}},
+		Spec:       corev1.PodSpec{NodeName: "master-0"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c := &InstallerHistoryController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		configMapClient: client.CoreV1(),
+		podLister:       corev1listers.NewPodLister(indexer).Pods(operatorclient.TargetNamespace),
+	}
+	return c, &testPods{t: t, indexer: indexer}
+}
+
+func syncAndRead(t *testing.T, c *InstallerHistoryController) (History, *operatorv1.OperatorCondition) {
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+	history, err := c.history(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return history, v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+}
+
+func TestHistoryAppendAndBound(t *testing.T) {
+	c, pods := newTestController(t, fake.NewSimpleClientset())
+
+	pods.add(3, "master-0", corev1.PodSucceeded)
+	pods.add(3, "master-1", corev1.PodFailed)
+	history, _ := syncAndRead(t, c)
+	if len(history["master-0"]) != 1 || len(history["master-1"]) != 1 {
+		t.Fatalf("expected an attempt per node, got %#v", history)
+	}
+	expected := Attempt{
+		Pod:            "installer-3-retry-2-master-1",
+		Revision:       3,
+		Start:          metav1.NewTime(start.Add(2 * time.Minute)),
+		End:            metav1.NewTime(start.Add(2*time.Minute + 30*time.Second)),
+		Outcome:        Failed,
+		FailureSummary: "timed out waiting for the kube-controller-manager-pod configmap",
+	}
+	if attempt := history["master-1"][0]; !equality.Semantic.DeepEqual(attempt, expected) {
+		t.Errorf("expected %#v, got %#v", expected, attempt)
+	}
+
+	// syncing again records nothing new
+	history, _ = syncAndRead(t, c)
+	if len(history["master-0"]) != 1 || len(history["master-1"]) != 1 {
+		t.Errorf("expected the attempts to be recorded once, got %#v", history)
+	}
+
+	for revision := 4; revision < 4+MaxAttemptsPerNode+2; revision++ {
+		pods.add(revision, "master-0", corev1.PodSucceeded)
+	}
+	history, _ = syncAndRead(t, c)
+	attempts := history["master-0"]
+	if len(attempts) != MaxAttemptsPerNode {
+		t.Fatalf("expected %d attempts, got %d", MaxAttemptsPerNode, len(attempts))
+	}
+	if attempts[0].Revision != 6 || attempts[len(attempts)-1].Revision != 15 {
+		t.Errorf("expected the revisions 6 to 15, got %d to %d", attempts[0].Revision, attempts[len(attempts)-1].Revision)
+	}
+	// the dropped pods are not recorded again
+	history, _ = syncAndRead(t, c)
+	if attempts := history["master-0"]; len(attempts) != MaxAttemptsPerNode || attempts[0].Revision != 6 {
+		t.Errorf("expected the dropped attempts to stay dropped, got %#v", attempts)
+	}
+}
+
+func TestHistorySurvivesRestart(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c, pods := newTestController(t, client)
+	pods.add(3, "master-0", corev1.PodFailed)
+	syncAndRead(t, c)
+
+	// the restarted operator starts with an empty cache, the pods are pruned
+	restarted, restartedPods := newTestController(t, client)
+	restartedPods.attempts = pods.attempts
+	restartedPods.add(4, "master-0", corev1.PodSucceeded)
+	history, _ := syncAndRead(t, restarted)
+	if attempts := history["master-0"]; len(attempts) != 2 || attempts[0].Revision != 3 || attempts[1].Revision != 4 {
+		t.Errorf("expected the attempts before and after the restart, got %#v", attempts)
+	}
+}
+
+func TestRepeatedFailuresDegrade(t *testing.T) {
+	c, pods := newTestController(t, fake.NewSimpleClientset())
+
+	pods.add(3, "master-0", corev1.PodFailed)
+	if _, condition := syncAndRead(t, c); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected a single failure not to degrade, got %#v", condition)
+	}
+
+	pods.add(3, "master-0", corev1.PodFailed)
+	pods.add(4, "master-0", corev1.PodSucceeded)
+	pods.add(4, "master-0", corev1.PodFailed)
+	pods.add(5, "master-0", corev1.PodFailed)
+	pods.add(5, "master-1", corev1.PodSucceeded)
+	_, condition := syncAndRead(t, c)
+	expectedMessage := "node master-0 has failed 4 of the last 5 installs, revision 5 failed after 30s: timed out waiting for the kube-controller-manager-pod configmap"
+	if condition.Status != operatorv1.ConditionTrue || condition.Reason != "RepeatedInstallFailures" || condition.Message != expectedMessage {
+		t.Errorf("expected the condition True %q, got %#v", expectedMessage, condition)
+	}
+
+	pods.add(5, "master-0", corev1.PodSucceeded)
+	if _, condition := syncAndRead(t, c); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected a successful install to clear the condition, got %#v", condition)
+	}
+}
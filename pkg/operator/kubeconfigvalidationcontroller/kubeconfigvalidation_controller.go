@@ -0,0 +1,306 @@
+package kubeconfigvalidationcontroller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
+	clusteroperatorhelpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	controllerName = "KubeconfigValidationController"
+
+	// validationInterval rate limits the connection tests of a kubeconfig whose content did not change.
+	validationInterval = 10 * time.Minute
+	// connectionTimeout bounds a single connection test.
+	connectionTimeout = 10 * time.Second
+)
+
+// validatedKubeconfigs are the kubeconfig configmaps rendered by the operator for the operand.
+var validatedKubeconfigs = []string{
+	"controller-manager-kubeconfig",
+	"kube-controller-cert-syncer-kubeconfig",
+}
+
+// staticPodDirs are the host directories the installer copies revisioned and unrevisioned resources into.
+// A file referenced by a kubeconfig in one of these can be mapped back to the configmap or secret it comes from.
+var staticPodDirs = []string{
+	"/etc/kubernetes/static-pod-resources/",
+	"/etc/kubernetes/static-pod-certs/",
+}
+
+// KubeconfigValidationController builds a client out of every kubeconfig the operator renders for the operand and
+// performs a cheap authenticated request with it, so that a wrong CA or a stale client certificate is reported
+// before the operand restarts into it.
+type KubeconfigValidationController struct {
+	operatorClient        v1helpers.StaticPodOperatorClient
+	configMapLister       corev1listers.ConfigMapLister
+	secretLister          corev1listers.SecretLister
+	clusterOperatorLister configlisters.ClusterOperatorLister
+
+	// isNodeLocal reports servers only reachable from the operand's node. Replaced in unit tests, which serve from
+	// loopback.
+	isNodeLocal func(host string) bool
+
+	lock sync.Mutex
+	// lastResults caches the result of the last connection test per kubeconfig.
+	lastResults map[string]validationResult
+}
+
+type validationResult struct {
+	contentHash [sha256.Size]byte
+	validatedAt time.Time
+	err         error
+}
+
+func NewKubeconfigValidationController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	configInformers configinformers.SharedInformerFactory,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &KubeconfigValidationController{
+		operatorClient:        operatorClient,
+		configMapLister:       kubeInformersForNamespaces.ConfigMapLister(),
+		secretLister:          kubeInformersForNamespaces.SecretLister(),
+		clusterOperatorLister: configInformers.Config().V1().ClusterOperators().Lister(),
+		isNodeLocal:           isLoopback,
+		lastResults:           map[string]validationResult{},
+	}
+
+	return factory.New().WithInformers(
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Secrets().Informer(),
+		configInformers.Config().V1().ClusterOperators().Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("kubeconfig-validation-controller"))
+}
+
+func (c *KubeconfigValidationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if c.isAPIServerKnownDown() {
+		klog.V(4).Infof("kube-apiserver is not available, skipping kubeconfig validation")
+		return nil
+	}
+
+	var errs []error
+	for _, name := range validatedKubeconfigs {
+		if err := c.validate(ctx, syncCtx.Recorder(), name); err != nil {
+			errs = append(errs, fmt.Errorf("configmap/%s: %w", name, err))
+		}
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   "KubeconfigValidationDegraded",
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(errs) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "KubeconfigInvalid"
+		condition.Message = v1helpers.NewMultiLineAggregate(errs).Error()
+	}
+	_, _, updateErr := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return updateErr
+}
+
+// isAPIServerKnownDown reports whether the kube-apiserver operator says there is no available kube-apiserver.
+// Validating during such an outage would only produce noise.
+func (c *KubeconfigValidationController) isAPIServerKnownDown() bool {
+	kasOperator, err := c.clusterOperatorLister.Get("kube-apiserver")
+	if err != nil {
+		return false
+	}
+	return clusteroperatorhelpers.IsStatusConditionFalse(kasOperator.Status.Conditions, configv1.OperatorAvailable)
+}
+
+func (c *KubeconfigValidationController) validate(ctx context.Context, recorder events.Recorder, name string) error {
+	kubeconfigCM, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(name)
+	if errors.IsNotFound(err) {
+		// not rendered yet, the target config controller reports that
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	restConfig, err := c.toRESTConfig([]byte(kubeconfigCM.Data["kubeconfig"]))
+	if err != nil {
+		return err
+	}
+	if c.isNodeLocal(restConfig.Host) {
+		// the operand talks to a kube-apiserver on its own node, which the operator cannot reach.
+		// Resolving every referenced file above is all we can check.
+		return nil
+	}
+
+	contentHash := hashRESTConfig(restConfig)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if last, ok := c.lastResults[name]; ok && last.contentHash == contentHash && time.Since(last.validatedAt) < validationInterval {
+		return last.err
+	}
+
+	connCtx, cancel := context.WithTimeout(ctx, connectionTimeout)
+	defer cancel()
+	connErr := checkConnection(connCtx, restConfig)
+	if connErr != nil {
+		connErr = fmt.Errorf("connection test against %s failed: %w", restConfig.Host, connErr)
+		if last, ok := c.lastResults[name]; !ok || last.err == nil || last.err.Error() != connErr.Error() {
+			recorder.Warningf("KubeconfigValidationFailed", "configmap/%s: %v", name, connErr)
+		}
+	}
+	c.lastResults[name] = validationResult{contentHash: contentHash, validatedAt: time.Now(), err: connErr}
+	return connErr
+}
+
+// toRESTConfig turns a kubeconfig written for the operand into a rest config usable from the operator, replacing all
+// file references with the content of the configmaps and secrets the installer copies into the static pod directories.
+func (c *KubeconfigValidationController) toRESTConfig(kubeconfig []byte) (*rest.Config, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse kubeconfig: %w", err)
+	}
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found", kubeContext.Cluster)
+	}
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %q not found", kubeContext.AuthInfo)
+	}
+
+	restConfig := &rest.Config{
+		Host: cluster.Server,
+		TLSClientConfig: rest.TLSClientConfig{
+			ServerName: cluster.TLSServerName,
+			CAData:     cluster.CertificateAuthorityData,
+			CertData:   authInfo.ClientCertificateData,
+			KeyData:    authInfo.ClientKeyData,
+		},
+		BearerToken: authInfo.Token,
+		Timeout:     connectionTimeout,
+	}
+	for _, file := range []struct {
+		path   string
+		target *[]byte
+	}{
+		{path: cluster.CertificateAuthority, target: &restConfig.CAData},
+		{path: authInfo.ClientCertificate, target: &restConfig.CertData},
+		{path: authInfo.ClientKey, target: &restConfig.KeyData},
+	} {
+		if len(file.path) == 0 {
+			continue
+		}
+		content, err := c.readStaticPodFile(file.path)
+		if err != nil {
+			return nil, err
+		}
+		*file.target = content
+	}
+	if len(authInfo.TokenFile) > 0 {
+		token, err := c.readStaticPodFile(authInfo.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		restConfig.BearerToken = strings.TrimSpace(string(token))
+	}
+
+	return restConfig, nil
+}
+
+// readStaticPodFile resolves <static pod dir>/{configmaps,secrets}/<name>/<key> to the content of the
+// corresponding object in the target namespace.
+func (c *KubeconfigValidationController) readStaticPodFile(path string) ([]byte, error) {
+	for _, dir := range staticPodDirs {
+		if !strings.HasPrefix(path, dir) {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(path, dir), "/")
+		if len(segments) != 3 {
+			break
+		}
+		kind, name, key := segments[0], segments[1], segments[2]
+		switch kind {
+		case "configmaps":
+			cm, err := c.configMapLister.ConfigMaps(operatorclient.TargetNamespace).Get(name)
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve %s: %w", path, err)
+			}
+			if value, ok := cm.Data[key]; ok && len(value) > 0 {
+				return []byte(value), nil
+			}
+			return nil, fmt.Errorf("unable to resolve %s: configmap/%s has no %q key", path, name, key)
+		case "secrets":
+			secret, err := c.secretLister.Secrets(operatorclient.TargetNamespace).Get(name)
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve %s: %w", path, err)
+			}
+			if value, ok := secret.Data[key]; ok && len(value) > 0 {
+				return value, nil
+			}
+			return nil, fmt.Errorf("unable to resolve %s: secret/%s has no %q key", path, name, key)
+		}
+	}
+	return nil, fmt.Errorf("unable to resolve %s: not a static pod resource", path)
+}
+
+// checkConnection performs an authenticated /version request. Client certificates are verified by the
+// kube-apiserver during the TLS handshake, so a wrong CA, an expired or an untrusted client certificate all fail it.
+func checkConnection(ctx context.Context, config *rest.Config) error {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+func isLoopback(host string) bool {
+	u, err := url.Parse(host)
+	if err != nil {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+func hashRESTConfig(config *rest.Config) [sha256.Size]byte {
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(config.Host), []byte(config.ServerName), config.CAData, config.CertData, config.KeyData, []byte(config.BearerToken),
+	} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
@@ -0,0 +1,317 @@
+package kubeconfigvalidationcontroller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configlisters "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+  - cluster:
+      certificate-authority: /etc/kubernetes/static-pod-resources/configmaps/serviceaccount-ca/ca-bundle.crt
+      server: SERVER
+    name: lb-int
+contexts:
+  - context:
+      cluster: lb-int
+      user: kube-controller-manager
+    name: kube-controller-manager
+current-context: kube-controller-manager
+users:
+  - name: kube-controller-manager
+    user:
+      client-certificate: /etc/kubernetes/static-pod-certs/secrets/kube-controller-manager-client-cert-key/tls.crt
+      client-key: /etc/kubernetes/static-pod-certs/secrets/kube-controller-manager-client-cert-key/tls.key
+`
+
+type testPKI struct {
+	serverCA  *crypto.CA
+	clientCA  *crypto.CA
+	otherCA   *crypto.CA
+	serverURL string
+	// requests counts the requests that made it past the TLS handshake.
+	requests atomic.Int32
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	newCA := func(name string) *crypto.CA {
+		config, err := crypto.MakeSelfSignedCAConfig(name, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	}
+	pki := &testPKI{serverCA: newCA("server-ca"), clientCA: newCA("client-ca"), otherCA: newCA("other-ca")}
+
+	serverCert, err := pki.serverCA.MakeServerCertForDuration(sets.NewString("127.0.0.1"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := serverCert.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(pki.clientCA.Config.Certs[0])
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pki.requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"major":"1","minor":"29"}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	pki.serverURL = server.URL
+
+	return pki
+}
+
+func pemBytes(t *testing.T, config *crypto.TLSCertificateConfig) ([]byte, []byte) {
+	certPEM, keyPEM, err := config.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, keyPEM
+}
+
+func (p *testPKI) clientCert(t *testing.T) ([]byte, []byte) {
+	config, err := p.clientCA.MakeClientCertificateForDuration(&user.DefaultInfo{Name: "system:kube-controller-manager"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pemBytes(t, config)
+}
+
+func (p *testPKI) expiredClientCert(t *testing.T) ([]byte, []byte) {
+	publicKey, privateKey, err := crypto.NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := crypto.NewClientCertificateTemplateForDuration(
+		crypto.UserToSubject(&user.DefaultInfo{Name: "system:kube-controller-manager"}),
+		time.Hour,
+		func() time.Time { return time.Now().Add(-2 * time.Hour) },
+	)
+	cert, err := p.clientCA.SignCertificate(template, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := crypto.EncodeCertificates(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := crypto.EncodeKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, keyPEM
+}
+
+func TestKubeconfigValidation(t *testing.T) {
+	pki := newTestPKI(t)
+	serverCABundle, _ := pemBytes(t, pki.serverCA.Config)
+	otherCABundle, _ := pemBytes(t, pki.otherCA.Config)
+	validCert, validKey := pki.clientCert(t)
+	expiredCert, expiredKey := pki.expiredClientCert(t)
+
+	tests := []struct {
+		name                    string
+		server                  string
+		caBundle                []byte
+		clientCert, clientKey   []byte
+		kubeAPIServerAvailable  configv1.ConditionStatus
+		expectedDegraded        operatorv1.ConditionStatus
+		expectedMessageContains []string
+	}{
+		{
+			name:             "valid kubeconfig",
+			caBundle:         serverCABundle,
+			clientCert:       validCert,
+			clientKey:        validKey,
+			expectedDegraded: operatorv1.ConditionFalse,
+		},
+		{
+			name:                    "server presents a certificate from an unknown CA",
+			caBundle:                otherCABundle,
+			clientCert:              validCert,
+			clientKey:               validKey,
+			expectedDegraded:        operatorv1.ConditionTrue,
+			expectedMessageContains: []string{"configmap/controller-manager-kubeconfig", "certificate signed by unknown authority"},
+		},
+		{
+			name:                    "expired client certificate",
+			caBundle:                serverCABundle,
+			clientCert:              expiredCert,
+			clientKey:               expiredKey,
+			expectedDegraded:        operatorv1.ConditionTrue,
+			expectedMessageContains: []string{"configmap/controller-manager-kubeconfig", "tls: expired certificate"},
+		},
+		{
+			name:                    "missing client certificate secret",
+			caBundle:                serverCABundle,
+			expectedDegraded:        operatorv1.ConditionTrue,
+			expectedMessageContains: []string{"configmap/controller-manager-kubeconfig", "kube-controller-manager-client-cert-key"},
+		},
+		{
+			name:                   "kube-apiserver is known to be down",
+			caBundle:               otherCABundle,
+			clientCert:             validCert,
+			clientKey:              validKey,
+			kubeAPIServerAvailable: configv1.ConditionFalse,
+		},
+		{
+			name:             "loopback servers are not dialed",
+			server:           "https://localhost:6443",
+			caBundle:         otherCABundle,
+			clientCert:       validCert,
+			clientKey:        validKey,
+			expectedDegraded: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := test.server
+			if len(server) == 0 {
+				server = pki.serverURL
+			}
+
+			c, operatorClient, _ := newTestController(pki, server, test.caBundle, test.clientCert, test.clientKey, test.kubeAPIServerAvailable)
+
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := v1helpers.FindOperatorCondition(status.Conditions, "KubeconfigValidationDegraded")
+			if len(test.expectedDegraded) == 0 {
+				if condition != nil {
+					t.Fatalf("expected no condition, got %#v", condition)
+				}
+				return
+			}
+			if condition == nil {
+				t.Fatalf("expected KubeconfigValidationDegraded condition")
+			}
+			if condition.Status != test.expectedDegraded {
+				t.Errorf("expected status %s, got %s: %s", test.expectedDegraded, condition.Status, condition.Message)
+			}
+			for _, expected := range test.expectedMessageContains {
+				if !strings.Contains(condition.Message, expected) {
+					t.Errorf("expected message to contain %q, got %q", expected, condition.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestKubeconfigValidationIsRateLimited(t *testing.T) {
+	pki := newTestPKI(t)
+	serverCABundle, _ := pemBytes(t, pki.serverCA.Config)
+	validCert, validKey := pki.clientCert(t)
+	c, _, secretIndexer := newTestController(pki, pki.serverURL, serverCABundle, validCert, validKey, "")
+	syncCtx := factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))
+
+	for i := 0; i < 3; i++ {
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests := pki.requests.Load(); requests != 1 {
+		t.Fatalf("expected an unchanged kubeconfig to be tested once, got %d requests", requests)
+	}
+
+	rotatedCert, rotatedKey := pki.clientCert(t)
+	secretIndexer.Update(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager-client-cert-key", Namespace: operatorclient.TargetNamespace},
+		Data:       map[string][]byte{"tls.crt": rotatedCert, "tls.key": rotatedKey},
+	})
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if requests := pki.requests.Load(); requests != 2 {
+		t.Fatalf("expected a rotated client certificate to be tested right away, got %d requests", requests)
+	}
+}
+
+func newTestController(pki *testPKI, server string, caBundle, clientCert, clientKey []byte, kubeAPIServerAvailable configv1.ConditionStatus) (*KubeconfigValidationController, v1helpers.StaticPodOperatorClient, cache.Indexer) {
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	configMapIndexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "controller-manager-kubeconfig", Namespace: operatorclient.TargetNamespace},
+		Data:       map[string]string{"kubeconfig": strings.Replace(kubeconfigTemplate, "SERVER", server, 1)},
+	})
+	configMapIndexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "serviceaccount-ca", Namespace: operatorclient.TargetNamespace},
+		Data:       map[string]string{"ca-bundle.crt": string(caBundle)},
+	})
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if clientCert != nil {
+		secretIndexer.Add(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager-client-cert-key", Namespace: operatorclient.TargetNamespace},
+			Data:       map[string][]byte{"tls.crt": clientCert, "tls.key": clientKey},
+		})
+	}
+	clusterOperatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if len(kubeAPIServerAvailable) > 0 {
+		clusterOperatorIndexer.Add(&configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+			Status: configv1.ClusterOperatorStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: kubeAPIServerAvailable},
+			}},
+		})
+	}
+
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+		&operatorv1.StaticPodOperatorStatus{},
+		nil,
+		nil,
+	)
+	c := &KubeconfigValidationController{
+		operatorClient:        operatorClient,
+		configMapLister:       corev1listers.NewConfigMapLister(configMapIndexer),
+		secretLister:          corev1listers.NewSecretLister(secretIndexer),
+		clusterOperatorLister: configlisters.NewClusterOperatorLister(clusterOperatorIndexer),
+		isNodeLocal:           func(host string) bool { return host != pki.serverURL },
+		lastResults:           map[string]validationResult{},
+	}
+	return c, operatorClient, secretIndexer
+}
@@ -0,0 +1,165 @@
+package leadership
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ghodss/yaml"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// OverrideField of the unsupportedConfigOverrides of kubecontrollermanager/cluster overrides the leader election
+// timings of the operator with a configv1.LeaderElection, e.g.
+//
+//	unsupportedConfigOverrides:
+//	  operatorLeaderElection:
+//	    leaseDuration: 270s
+//	    renewDeadline: 240s
+//	    retryPeriod: 60s
+//
+// The timings of the operand are its leader-elect-* extendedArguments.
+const OverrideField = "operatorLeaderElection"
+
+// LockName is the name of the operator lease in the operator namespace.
+const LockName = "kube-controller-manager-operator-lock"
+
+// ClockSkewTolerance is how much longer than the renew deadline the lease duration must be. The other replicas take the
+// lease over once it expires by their clock, the leader must have given it up by then by its own.
+const ClockSkewTolerance = 30 * time.Second
+
+// ParseConfig reads a LeaderElection, no content is the default config. Zero timings are defaulted by
+// LeaderElectionDefaulting, the timings after defaulting are the ones validated.
+func ParseConfig(content []byte) (configv1.LeaderElection, error) {
+	config := configv1.LeaderElection{}
+	if err := unmarshalStrict(content, &config); err != nil {
+		return configv1.LeaderElection{}, err
+	}
+	if len(config.Namespace) > 0 || len(config.Name) > 0 {
+		return configv1.LeaderElection{}, fmt.Errorf("the namespace and name of the lease cannot be changed")
+	}
+
+	// the checks of the elector, which would otherwise fail the operator at startup
+	defaulted := leaderelectionconverter.LeaderElectionDefaulting(config, operatorclient.OperatorNamespace, LockName)
+	if errs := ValidateConfig(defaulted); len(errs) > 0 {
+		return configv1.LeaderElection{}, utilerrors.NewAggregate(errs)
+	}
+	return config, nil
+}
+
+// ValidateConfig checks a defaulted LeaderElection before an elector is built from it. client-go accepts timings
+// that cannot hold a lease, e.g. a lease duration shorter than the renew deadline, and the elector then loses the lease
+// over and over. The renew deadline must be shorter than the lease duration by ClockSkewTolerance at least, and at least
+// two retry periods long, so that one failed renewal is retried before the deadline. A disabled leader election is
+// not checked.
+func ValidateConfig(config configv1.LeaderElection) []error {
+	if config.Disable {
+		return nil
+	}
+
+	var errs []error
+	if len(config.Namespace) == 0 {
+		errs = append(errs, &validation.Error{Path: "namespace", Value: config.Namespace, Accepted: "set"})
+	}
+	if len(config.Name) == 0 {
+		errs = append(errs, &validation.Error{Path: "name", Value: config.Name, Accepted: "set"})
+	}
+	positive := true
+	for _, timing := range []struct {
+		path     string
+		duration time.Duration
+	}{
+		{path: "leaseDuration", duration: config.LeaseDuration.Duration},
+		{path: "renewDeadline", duration: config.RenewDeadline.Duration},
+		{path: "retryPeriod", duration: config.RetryPeriod.Duration},
+	} {
+		if timing.duration <= 0 {
+			errs = append(errs, &validation.Error{Path: timing.path, Value: timing.duration.String(), Accepted: "a positive duration"})
+			positive = false
+		}
+	}
+	if !positive {
+		return errs
+	}
+
+	leaseDuration, renewDeadline, retryPeriod := config.LeaseDuration.Duration, config.RenewDeadline.Duration, config.RetryPeriod.Duration
+	switch {
+	case renewDeadline >= leaseDuration:
+		errs = append(errs, &validation.Error{Path: "renewDeadline", Value: renewDeadline.String(), Accepted: fmt.Sprintf("shorter than the lease duration %s", leaseDuration)})
+	case leaseDuration-renewDeadline < ClockSkewTolerance:
+		errs = append(errs, &validation.Error{Path: "renewDeadline", Value: renewDeadline.String(), Accepted: fmt.Sprintf("at most the lease duration %s minus %s of clock skew", leaseDuration, ClockSkewTolerance)})
+	}
+	if retryPeriod > renewDeadline/2 {
+		errs = append(errs, &validation.Error{Path: "retryPeriod", Value: retryPeriod.String(), Accepted: fmt.Sprintf("at most half of the renew deadline %s", renewDeadline)})
+	}
+	return errs
+}
+
+// FinalizeConfig checks the leader election config the elector is built from, however it was composed from the
+// defaults, the single replica timings and the overrides. Timings failing ValidateConfig are an error when strict,
+// otherwise they are clamped: the lease duration is raised to the renew deadline plus ClockSkewTolerance and the retry
+// period lowered to half the renew deadline. Negative timings cannot be clamped and are always an error. The returned
+// messages describe what was clamped. Zero timings are defaulted by LeaderElectionDefaulting first.
+func FinalizeConfig(config configv1.LeaderElection, strict bool) (configv1.LeaderElection, []string, error) {
+	if config.Disable {
+		return config, nil, nil
+	}
+	defaulted := leaderelectionconverter.LeaderElectionDefaulting(config, operatorclient.OperatorNamespace, LockName)
+	errs := ValidateConfig(defaulted)
+	if len(errs) == 0 {
+		return config, nil, nil
+	}
+	leaseDuration, renewDeadline, retryPeriod := defaulted.LeaseDuration.Duration, defaulted.RenewDeadline.Duration, defaulted.RetryPeriod.Duration
+	if strict || leaseDuration <= 0 || renewDeadline <= 0 || retryPeriod <= 0 {
+		return configv1.LeaderElection{}, nil, utilerrors.NewAggregate(errs)
+	}
+
+	var clamped []string
+	if leaseDuration-renewDeadline < ClockSkewTolerance {
+		defaulted.LeaseDuration.Duration = renewDeadline + ClockSkewTolerance
+		clamped = append(clamped, fmt.Sprintf("leaseDuration raised from %s to %s", leaseDuration, defaulted.LeaseDuration.Duration))
+	}
+	if retryPeriod > renewDeadline/2 {
+		defaulted.RetryPeriod.Duration = renewDeadline / 2
+		clamped = append(clamped, fmt.Sprintf("retryPeriod lowered from %s to %s", retryPeriod, defaulted.RetryPeriod.Duration))
+	}
+	return defaulted, clamped, nil
+}
+
+// unmarshalStrict decodes YAML or JSON content into obj and fails on fields obj does not have.
+func unmarshalStrict(content []byte, obj interface{}) error {
+	data, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(obj)
+}
+
+// ConfigOverride returns the LeaderElection of OverrideField in unsupportedConfigOverrides, nil without one.
+func ConfigOverride(unsupportedConfigOverrides []byte) (*configv1.LeaderElection, error) {
+	if len(unsupportedConfigOverrides) == 0 {
+		return nil, nil
+	}
+	overrides := map[string]json.RawMessage{}
+	if err := yaml.Unmarshal(unsupportedConfigOverrides, &overrides); err != nil {
+		return nil, fmt.Errorf("unsupportedConfigOverrides: %w", err)
+	}
+	content, ok := overrides[OverrideField]
+	if !ok {
+		return nil, nil
+	}
+	config, err := ParseConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("unsupportedConfigOverrides.%s: %w", OverrideField, err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,244 @@
+package leadership
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
+)
+
+func TestConfigOverride(t *testing.T) {
+	tests := []struct {
+		name                  string
+		overrides             string
+		expectedOverride      bool
+		expectedLeaseDuration time.Duration
+		expectedErr           string
+	}{
+		{name: "no overrides"},
+		{name: "no override", overrides: `{"extendedArguments":{"leader-elect-retry-period":["5s"]}}`},
+		{name: "override", overrides: `{"operatorLeaderElection":{"leaseDuration":"270s","renewDeadline":"240s","retryPeriod":"60s"}}`, expectedOverride: true, expectedLeaseDuration: 270 * time.Second},
+		// 0 is the default of 137s
+		{name: "defaulted lease duration", overrides: `{"operatorLeaderElection":{"renewDeadline":"100s"}}`, expectedOverride: true},
+		{
+			name:        "renew deadline within the clock skew of the default lease duration",
+			overrides:   `{"operatorLeaderElection":{"renewDeadline":"120s"}}`,
+			expectedErr: `unsupportedConfigOverrides.operatorLeaderElection: renewDeadline: "2m0s" must be at most the lease duration 2m17s minus 30s of clock skew`,
+		},
+		{
+			name:        "renew deadline of the default lease duration",
+			overrides:   `{"operatorLeaderElection":{"renewDeadline":"137s"}}`,
+			expectedErr: `unsupportedConfigOverrides.operatorLeaderElection: renewDeadline: "2m17s" must be shorter than the lease duration 2m17s`,
+		},
+		{
+			name:        "retry period longer than the renew deadline",
+			overrides:   `{"operatorLeaderElection":{"retryPeriod":"120s"}}`,
+			expectedErr: `unsupportedConfigOverrides.operatorLeaderElection: retryPeriod: "2m0s" must be at most half of the renew deadline 1m47s`,
+		},
+		{
+			name:        "negative and inconsistent",
+			overrides:   `{"operatorLeaderElection":{"leaseDuration":"10s","renewDeadline":"107s","retryPeriod":"-1s"}}`,
+			expectedErr: `unsupportedConfigOverrides.operatorLeaderElection: retryPeriod: "-1s" must be a positive duration`,
+		},
+		{
+			name:        "unknown field",
+			overrides:   `{"operatorLeaderElection":{"leaseDurationSeconds":270}}`,
+			expectedErr: `unsupportedConfigOverrides.operatorLeaderElection: json: unknown field "leaseDurationSeconds"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			override, err := ConfigOverride([]byte(test.overrides))
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Fatalf("expected %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (override != nil) != test.expectedOverride {
+				t.Fatalf("expected an override %v, got %#v", test.expectedOverride, override)
+			}
+			if override != nil && override.LeaseDuration.Duration != test.expectedLeaseDuration {
+				t.Errorf("expected a lease duration of %s, got %v", test.expectedLeaseDuration, override.LeaseDuration)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	config := func(leaseDuration, renewDeadline, retryPeriod time.Duration) configv1.LeaderElection {
+		return configv1.LeaderElection{
+			Namespace:     "openshift-kube-controller-manager-operator",
+			Name:          LockName,
+			LeaseDuration: metav1.Duration{Duration: leaseDuration},
+			RenewDeadline: metav1.Duration{Duration: renewDeadline},
+			RetryPeriod:   metav1.Duration{Duration: retryPeriod},
+		}
+	}
+	tests := []struct {
+		name     string
+		config   configv1.LeaderElection
+		expected []string
+	}{
+		{name: "defaults", config: config(137*time.Second, 107*time.Second, 26*time.Second)},
+		{name: "single replica", config: config(270*time.Second, 240*time.Second, 60*time.Second)},
+		{name: "retry period of half the renew deadline", config: config(50*time.Second, 20*time.Second, 10*time.Second)},
+		{name: "disabled", config: configv1.LeaderElection{Disable: true}},
+		{
+			name:     "no lease",
+			config:   configv1.LeaderElection{LeaseDuration: metav1.Duration{Duration: 137 * time.Second}, RenewDeadline: metav1.Duration{Duration: 107 * time.Second}, RetryPeriod: metav1.Duration{Duration: 26 * time.Second}},
+			expected: []string{`namespace: "" must be set`, `name: "" must be set`},
+		},
+		{
+			name:   "zero and negative timings",
+			config: config(0, -time.Second, 0),
+			expected: []string{
+				`leaseDuration: "0s" must be a positive duration`,
+				`renewDeadline: "-1s" must be a positive duration`,
+				`retryPeriod: "0s" must be a positive duration`,
+			},
+		},
+		{
+			name:     "renew deadline longer than the lease duration",
+			config:   config(10*time.Second, 107*time.Second, 26*time.Second),
+			expected: []string{`renewDeadline: "1m47s" must be shorter than the lease duration 10s`},
+		},
+		{
+			name:     "renew deadline of the lease duration",
+			config:   config(107*time.Second, 107*time.Second, 26*time.Second),
+			expected: []string{`renewDeadline: "1m47s" must be shorter than the lease duration 1m47s`},
+		},
+		{
+			name:     "less than the clock skew between the lease duration and the renew deadline",
+			config:   config(130*time.Second, 107*time.Second, 26*time.Second),
+			expected: []string{`renewDeadline: "1m47s" must be at most the lease duration 2m10s minus 30s of clock skew`},
+		},
+		{
+			name:     "retry period longer than half the renew deadline",
+			config:   config(137*time.Second, 107*time.Second, 60*time.Second),
+			expected: []string{`retryPeriod: "1m0s" must be at most half of the renew deadline 1m47s`},
+		},
+		{
+			name:   "renew deadline and retry period",
+			config: config(10*time.Second, 107*time.Second, 107*time.Second),
+			expected: []string{
+				`renewDeadline: "1m47s" must be shorter than the lease duration 10s`,
+				`retryPeriod: "1m47s" must be at most half of the renew deadline 1m47s`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual []string
+			for _, err := range ValidateConfig(test.config) {
+				actual = append(actual, err.Error())
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFinalizeConfig(t *testing.T) {
+	snoWith := func(override func(config *configv1.LeaderElection)) configv1.LeaderElection {
+		config := leaderelectionconverter.LeaderElectionSNOConfig(configv1.LeaderElection{})
+		override(&config)
+		return config
+	}
+	timings := func(leaseDuration, renewDeadline, retryPeriod time.Duration) configv1.LeaderElection {
+		return configv1.LeaderElection{
+			LeaseDuration: metav1.Duration{Duration: leaseDuration},
+			RenewDeadline: metav1.Duration{Duration: renewDeadline},
+			RetryPeriod:   metav1.Duration{Duration: retryPeriod},
+		}
+	}
+	tests := []struct {
+		name            string
+		config          configv1.LeaderElection
+		strict          bool
+		expected        configv1.LeaderElection
+		expectedClamped []string
+		expectedErr     string
+	}{
+		{name: "defaults", config: configv1.LeaderElection{}, expected: configv1.LeaderElection{}},
+		{name: "disabled", config: configv1.LeaderElection{Disable: true, RenewDeadline: metav1.Duration{Duration: time.Hour}}, expected: configv1.LeaderElection{Disable: true, RenewDeadline: metav1.Duration{Duration: time.Hour}}},
+		{
+			name:     "single replica",
+			config:   snoWith(func(config *configv1.LeaderElection) {}),
+			expected: snoWith(func(config *configv1.LeaderElection) {}),
+		},
+		{
+			name:     "single replica with a longer lease duration",
+			config:   snoWith(func(config *configv1.LeaderElection) { config.LeaseDuration.Duration = 300 * time.Second }),
+			expected: snoWith(func(config *configv1.LeaderElection) { config.LeaseDuration.Duration = 300 * time.Second }),
+		},
+		{
+			name: "single replica with a shorter renew deadline and retry period",
+			config: snoWith(func(config *configv1.LeaderElection) {
+				config.RenewDeadline.Duration, config.RetryPeriod.Duration = 200*time.Second, 50*time.Second
+			}),
+			expected: snoWith(func(config *configv1.LeaderElection) {
+				config.RenewDeadline.Duration, config.RetryPeriod.Duration = 200*time.Second, 50*time.Second
+			}),
+		},
+		{
+			name:            "single replica with a longer renew deadline",
+			config:          snoWith(func(config *configv1.LeaderElection) { config.RenewDeadline.Duration = 260 * time.Second }),
+			expected:        timings(290*time.Second, 260*time.Second, 60*time.Second),
+			expectedClamped: []string{"leaseDuration raised from 4m30s to 4m50s"},
+		},
+		{
+			name: "single replica with a shorter lease duration and a longer retry period",
+			config: snoWith(func(config *configv1.LeaderElection) {
+				config.LeaseDuration.Duration, config.RetryPeriod.Duration = 250*time.Second, 150*time.Second
+			}),
+			expected:        timings(270*time.Second, 240*time.Second, 120*time.Second),
+			expectedClamped: []string{"leaseDuration raised from 4m10s to 4m30s", "retryPeriod lowered from 2m30s to 2m0s"},
+		},
+		{
+			name:        "single replica with a longer renew deadline, strict",
+			config:      snoWith(func(config *configv1.LeaderElection) { config.RenewDeadline.Duration = 260 * time.Second }),
+			strict:      true,
+			expectedErr: `renewDeadline: "4m20s" must be at most the lease duration 4m30s minus 30s of clock skew`,
+		},
+		{
+			name:            "renew deadline only, defaulted lease duration",
+			config:          configv1.LeaderElection{RenewDeadline: metav1.Duration{Duration: 120 * time.Second}},
+			expected:        timings(150*time.Second, 120*time.Second, 26*time.Second),
+			expectedClamped: []string{"leaseDuration raised from 2m17s to 2m30s"},
+		},
+		{
+			name:        "negative retry period",
+			config:      snoWith(func(config *configv1.LeaderElection) { config.RetryPeriod.Duration = -time.Second }),
+			expectedErr: `retryPeriod: "-1s" must be a positive duration`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, clamped, err := FinalizeConfig(test.config, test.strict)
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Fatalf("expected %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(clamped, test.expectedClamped) {
+				t.Errorf("expected %q to be clamped, got %q", test.expectedClamped, clamped)
+			}
+			if actual.LeaseDuration != test.expected.LeaseDuration || actual.RenewDeadline != test.expected.RenewDeadline || actual.RetryPeriod != test.expected.RetryPeriod || actual.Disable != test.expected.Disable {
+				t.Errorf("expected %v %v %v, got %v %v %v", test.expected.LeaseDuration, test.expected.RenewDeadline, test.expected.RetryPeriod, actual.LeaseDuration, actual.RenewDeadline, actual.RetryPeriod)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package leadership
+
+import (
+	"fmt"
+	"os"
+)
+
+// PodNameEnv is set to the name of the operator pod through the downward API.
+const PodNameEnv = "POD_NAME"
+
+// Identity returns the name of the operator pod, from PodNameEnv or the hostname when it is not set. The holder
+// identity controllercmd records in the lease is the hostname followed by a uniquifier.
+func Identity() (string, error) {
+	return identity(os.Getenv, os.Hostname)
+}
+
+func identity(getenv func(string) string, hostname func() (string, error)) (string, error) {
+	if name := getenv(PodNameEnv); len(name) > 0 {
+		return name, nil
+	}
+	name, err := hostname()
+	if err != nil {
+		return "", fmt.Errorf("%s is not set and the hostname is unknown: %w", PodNameEnv, err)
+	}
+	return name, nil
+}
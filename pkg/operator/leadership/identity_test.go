@@ -0,0 +1,44 @@
+package leadership
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	tests := []struct {
+		name             string
+		podName          string
+		hostnameErr      error
+		expectedIdentity string
+		expectedErr      string
+	}{
+		{name: "pod name set", podName: "kube-controller-manager-operator-5d8f9c7b6-x2vqk", expectedIdentity: "kube-controller-manager-operator-5d8f9c7b6-x2vqk"},
+		{name: "pod name set without hostname", podName: "kube-controller-manager-operator-5d8f9c7b6-x2vqk", hostnameErr: errors.New("no hostname"), expectedIdentity: "kube-controller-manager-operator-5d8f9c7b6-x2vqk"},
+		{name: "pod name empty", expectedIdentity: "master-0"},
+		{name: "pod name empty without hostname", hostnameErr: errors.New("no hostname"), expectedErr: "POD_NAME is not set and the hostname is unknown: no hostname"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				if key == PodNameEnv {
+					return test.podName
+				}
+				return ""
+			}
+			hostname := func() (string, error) {
+				return "master-0", test.hostnameErr
+			}
+			identity, err := identity(getenv, hostname)
+			switch {
+			case len(test.expectedErr) > 0 && (err == nil || err.Error() != test.expectedErr):
+				t.Fatalf("expected error %q, got %v", test.expectedErr, err)
+			case len(test.expectedErr) == 0 && err != nil:
+				t.Fatal(err)
+			}
+			if identity != test.expectedIdentity {
+				t.Errorf("expected identity %q, got %q", test.expectedIdentity, identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package leadership
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// Header is set on the metrics responses of the operator to whether the replica holds the operator lease.
+const Header = "X-Operator-Leader"
+
+// isLeaderMetric tells the active replica from the standby ones, which serve their metrics as well. Recording rules
+// keep the metrics of the active replica by joining on it, e.g.
+//
+//	rules:
+//	- record: openshift_kube_controller_manager_operator:workqueue_adds:rate5m
+//	  expr: |
+//	    sum by (name) (
+//	      rate(workqueue_adds_total{namespace="openshift-kube-controller-manager-operator"}[5m])
+//	      * on (namespace, pod) group_left ()
+//	      (openshift_kube_controller_manager_operator_is_leader == 1)
+//	    )
+var isLeaderMetric = metrics.NewGauge(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager_operator",
+	Name:           "is_leader",
+	Help:           "1 while this operator replica holds the operator lease and runs the controllers, 0 while it is standby",
+	StabilityLevel: metrics.ALPHA,
+})
+
+var leading atomic.Bool
+
+func init() {
+	legacyregistry.MustRegister(isLeaderMetric)
+	isLeaderMetric.Set(0)
+}
+
+// Set records whether the replica holds the operator lease. The operator runs its controllers only while it leads,
+// it sets the leadership when they start and clears it when they stop.
+func Set(isLeader bool) {
+	leading.Store(isLeader)
+	if isLeader {
+		isLeaderMetric.Set(1)
+	} else {
+		isLeaderMetric.Set(0)
+	}
+}
+
+// Leading is whether the replica holds the operator lease.
+func Leading() bool {
+	return leading.Load()
+}
+
+// WithHeader sets Header on every response of handler to the leadership at the time of the request.
+func WithHeader(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(Header, strconv.FormatBool(Leading()))
+		handler.ServeHTTP(w, r)
+	})
+}
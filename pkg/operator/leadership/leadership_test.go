@@ -0,0 +1,56 @@
+package leadership
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestLeadershipTransitions(t *testing.T) {
+	server := httptest.NewServer(WithHeader(legacyregistry.HandlerWithReset()))
+	defer server.Close()
+	defer Set(false)
+
+	for _, test := range []struct {
+		leading        bool
+		expectedHeader string
+		expectedValue  float64
+	}{
+		{leading: false, expectedHeader: "false", expectedValue: 0},
+		{leading: true, expectedHeader: "true", expectedValue: 1},
+		// lost the lease
+		{leading: false, expectedHeader: "false", expectedValue: 0},
+	} {
+		Set(test.leading)
+
+		value, err := testutil.GetGaugeMetricValue(isLeaderMetric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != test.expectedValue {
+			t.Errorf("leading %v: expected the gauge %v, got %v", test.leading, test.expectedValue, value)
+		}
+
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if header := resp.Header.Get(Header); header != test.expectedHeader {
+			t.Errorf("leading %v: expected the header %q, got %q", test.leading, test.expectedHeader, header)
+		}
+	}
+
+	expected := `
+# HELP openshift_kube_controller_manager_operator_is_leader [ALPHA] 1 while this operator replica holds the operator lease and runs the controllers, 0 while it is standby
+# TYPE openshift_kube_controller_manager_operator_is_leader gauge
+openshift_kube_controller_manager_operator_is_leader 0
+`
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "openshift_kube_controller_manager_operator_is_leader"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,202 @@
+package networkpolicycontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ghodss/yaml"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/client-go/kubernetes"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+)
+
+const (
+	controllerName = "NetworkPolicyController"
+
+	// DisableOverrideField of the unsupportedConfigOverrides of the KubeControllerManager CR stops the management of
+	// the network policies for clusters with their own policy controller when it is true. The network policies the
+	// operator created are removed. The operator API has no spec field for it.
+	DisableOverrideField = "disableNetworkPolicyManagement"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "cluster-kube-controller-manager-operator"
+)
+
+// assets are the network policies of each namespace. Only the apiserver and DNS egress, the egress of the operator to
+// the metrics it reads and the ingress of the monitoring stack to the metrics ports are allowed.
+var assets = map[string][]string{
+	operatorclient.OperatorNamespace: {
+		"assets/kube-controller-manager/networkpolicies/operator-default-deny.yaml",
+		"assets/kube-controller-manager/networkpolicies/operator-allow-to-apiserver-and-dns.yaml",
+		"assets/kube-controller-manager/networkpolicies/operator-allow-metrics-from-monitoring.yaml",
+		"assets/kube-controller-manager/networkpolicies/operator-allow-to-thanos-querier.yaml",
+		"assets/kube-controller-manager/networkpolicies/operator-allow-to-metrics.yaml",
+	},
+	operatorclient.TargetNamespace: {
+		"assets/kube-controller-manager/networkpolicies/operand-default-deny.yaml",
+		"assets/kube-controller-manager/networkpolicies/operand-allow-to-apiserver-and-dns.yaml",
+		"assets/kube-controller-manager/networkpolicies/operand-allow-metrics-from-monitoring.yaml",
+	},
+}
+
+func init() {
+	overrides.Register(DisableOverrideField)
+	for namespace := range assets {
+		relatedobjects.Register(configv1.ObjectReference{Group: "networking.k8s.io", Resource: "networkpolicies", Namespace: namespace})
+	}
+}
+
+// NetworkPolicyController keeps the network policies of the operator and operand namespaces in the shape of the
+// assets and reverts changes to them. The kube-controller-manager static pods use the host network and are not
+// affected, the guard, installer and pruner pods and the operator are.
+type NetworkPolicyController struct {
+	kubeClient           kubernetes.Interface
+	operatorLister       cache.GenericLister
+	infrastructureLister configv1listers.InfrastructureLister
+	networkPolicyListers map[string]networkingv1listers.NetworkPolicyNamespaceLister
+}
+
+func NewNetworkPolicyController(
+	kubeClient kubernetes.Interface,
+	operatorLister cache.GenericLister,
+	operatorInformer cache.SharedIndexInformer,
+	infrastructureInformer configv1informers.InfrastructureInformer,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &NetworkPolicyController{
+		kubeClient:           kubeClient,
+		operatorLister:       operatorLister,
+		infrastructureLister: infrastructureInformer.Lister(),
+		networkPolicyListers: map[string]networkingv1listers.NetworkPolicyNamespaceLister{},
+	}
+
+	informers := []factory.Informer{operatorInformer, infrastructureInformer.Informer()}
+	for namespace := range assets {
+		networkPolicyInformer := kubeInformersForNamespaces.InformersFor(namespace).Networking().V1().NetworkPolicies()
+		c.networkPolicyListers[namespace] = networkPolicyInformer.Lister().NetworkPolicies(namespace)
+		informers = append(informers, networkPolicyInformer.Informer())
+	}
+
+	return factory.New().WithInformers(informers...).ResyncEvery(10*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("network-policy-controller"))
+}
+
+func (c *NetworkPolicyController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	disabled, err := c.disabled()
+	if err != nil {
+		return err
+	}
+	infrastructure, err := c.infrastructureLister.Get("cluster")
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for namespace, files := range assets {
+		// nothing of an external control plane runs in the operand namespace
+		managed := !disabled && (namespace != operatorclient.TargetNamespace || infrastructure.Status.ControlPlaneTopology != configv1.ExternalTopologyMode)
+		for _, file := range files {
+			required, err := c.read(file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if managed {
+				errs = append(errs, c.apply(ctx, syncCtx.Recorder(), required))
+			} else {
+				errs = append(errs, c.remove(ctx, syncCtx.Recorder(), required))
+			}
+		}
+	}
+	return v1helpers.NewMultiLineAggregate(errs)
+}
+
+func (c *NetworkPolicyController) apply(ctx context.Context, recorder events.Recorder, required *networkingv1.NetworkPolicy) error {
+	existing, err := c.networkPolicyListers[required.Namespace].Get(required.Name)
+	if apierrors.IsNotFound(err) {
+		if _, err := c.kubeClient.NetworkingV1().NetworkPolicies(required.Namespace).Create(ctx, required, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("networkpolicy/%s -n %s: %w", required.Name, required.Namespace, err)
+		}
+		recorder.Eventf("NetworkPolicyCreated", "Created networkpolicy/%s -n %s", required.Name, required.Namespace)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, required.Spec) && existing.Labels[managedByLabel] == managedByValue {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = required.Spec
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	updated.Labels[managedByLabel] = managedByValue
+	if _, err := c.kubeClient.NetworkingV1().NetworkPolicies(required.Namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("networkpolicy/%s -n %s: %w", required.Name, required.Namespace, err)
+	}
+	recorder.Warningf("NetworkPolicyDriftReverted", "Reverted changes to networkpolicy/%s -n %s: %s", required.Name, required.Namespace, diff.ObjectDiff(existing.Spec, required.Spec))
+	return nil
+}
+
+// remove deletes a network policy the operator created, a policy of the same name created by someone else is kept.
+func (c *NetworkPolicyController) remove(ctx context.Context, recorder events.Recorder, required *networkingv1.NetworkPolicy) error {
+	existing, err := c.networkPolicyListers[required.Namespace].Get(required.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Labels[managedByLabel] != managedByValue {
+		return nil
+	}
+	if err := c.kubeClient.NetworkingV1().NetworkPolicies(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("networkpolicy/%s -n %s: %w", required.Name, required.Namespace, err)
+	}
+	recorder.Eventf("NetworkPolicyDeleted", "Deleted networkpolicy/%s -n %s", required.Name, required.Namespace)
+	return nil
+}
+
+func (c *NetworkPolicyController) read(file string) (*networkingv1.NetworkPolicy, error) {
+	data, err := bindata.Asset(file)
+	if err != nil {
+		return nil, err
+	}
+	networkPolicy := &networkingv1.NetworkPolicy{}
+	if err := yaml.Unmarshal(data, networkPolicy); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return networkPolicy, nil
+}
+
+func (c *NetworkPolicyController) disabled() (bool, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return false, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return false, err
+	}
+	return overrides.Bool(unsupportedConfigOverrides, DisableOverrideField)
+}
@@ -0,0 +1,192 @@
+package networkpolicycontroller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func newTestController(t *testing.T, unsupportedConfigOverrides map[string]interface{}, topology configv1.TopologyMode, objects ...runtime.Object) (*NetworkPolicyController, *fake.Clientset) {
+	operator := &unstructured.Unstructured{}
+	operator.SetName("cluster")
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedField(operator.Object, unsupportedConfigOverrides, "spec", "unsupportedConfigOverrides"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operatorIndexer.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	infrastructureIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := infrastructureIndexer.Add(&configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{ControlPlaneTopology: topology},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	networkPolicyIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objects {
+		if err := networkPolicyIndexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := fake.NewSimpleClientset(objects...)
+	lister := networkingv1listers.NewNetworkPolicyLister(networkPolicyIndexer)
+	c := &NetworkPolicyController{
+		kubeClient:           client,
+		operatorLister:       cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		infrastructureLister: configv1listers.NewInfrastructureLister(infrastructureIndexer),
+		networkPolicyListers: map[string]networkingv1listers.NetworkPolicyNamespaceLister{
+			operatorclient.OperatorNamespace: lister.NetworkPolicies(operatorclient.OperatorNamespace),
+			operatorclient.TargetNamespace:   lister.NetworkPolicies(operatorclient.TargetNamespace),
+		},
+	}
+	return c, client
+}
+
+func networkPolicyNames(t *testing.T, client *fake.Clientset, namespace string) []string {
+	list, err := client.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, networkPolicy := range list.Items {
+		names = append(names, networkPolicy.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestNetworkPolicyAssets(t *testing.T) {
+	c, _ := newTestController(t, nil, configv1.HighlyAvailableTopologyMode)
+	for namespace, files := range assets {
+		for _, file := range files {
+			networkPolicy, err := c.read(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if networkPolicy.Namespace != namespace {
+				t.Errorf("%s: expected namespace %q, got %q", file, namespace, networkPolicy.Namespace)
+			}
+			if networkPolicy.Labels[managedByLabel] != managedByValue {
+				t.Errorf("%s: missing the %s label", file, managedByLabel)
+			}
+			if len(networkPolicy.Spec.PolicyTypes) == 0 {
+				t.Errorf("%s: expected explicit policy types", file)
+			}
+		}
+	}
+}
+
+func TestNetworkPoliciesAreAppliedPerNamespace(t *testing.T) {
+	operator := []string{"allow-metrics-from-monitoring", "allow-to-apiserver-and-dns", "allow-to-metrics", "allow-to-thanos-querier", "default-deny"}
+	operand := []string{"allow-metrics-from-monitoring", "allow-to-apiserver-and-dns", "default-deny"}
+	tests := []struct {
+		name     string
+		topology configv1.TopologyMode
+		operator []string
+		operand  []string
+	}{
+		{name: "highly available", topology: configv1.HighlyAvailableTopologyMode, operator: operator, operand: operand},
+		{name: "single replica", topology: configv1.SingleReplicaTopologyMode, operator: operator, operand: operand},
+		{name: "external", topology: configv1.ExternalTopologyMode, operator: operator},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, client := newTestController(t, nil, test.topology)
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+			if actual := networkPolicyNames(t, client, operatorclient.OperatorNamespace); !reflect.DeepEqual(actual, test.operator) {
+				t.Errorf("operator namespace: expected %v, got %v", test.operator, actual)
+			}
+			if actual := networkPolicyNames(t, client, operatorclient.TargetNamespace); !reflect.DeepEqual(actual, test.operand) {
+				t.Errorf("operand namespace: expected %v, got %v", test.operand, actual)
+			}
+		})
+	}
+}
+
+func TestNetworkPolicyDriftIsReverted(t *testing.T) {
+	drifted := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "default-deny", Labels: map[string]string{managedByLabel: managedByValue}},
+		Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}},
+	}
+	c, client := newTestController(t, nil, configv1.HighlyAvailableTopologyMode, drifted)
+	recorder := events.NewInMemoryRecorder("test")
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := client.NetworkingV1().NetworkPolicies(operatorclient.TargetNamespace).Get(context.TODO(), "default-deny", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted.Spec.PolicyTypes) != 2 {
+		t.Errorf("expected ingress and egress to be denied, got %v", reverted.Spec.PolicyTypes)
+	}
+	var reported int
+	for _, event := range recorder.Events() {
+		if event.Reason == "NetworkPolicyDriftReverted" {
+			reported++
+		}
+	}
+	if reported != 1 {
+		t.Errorf("expected 1 NetworkPolicyDriftReverted event, got %d", reported)
+	}
+}
+
+func TestNetworkPolicyManagementCanBeDisabled(t *testing.T) {
+	managed := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "default-deny", Labels: map[string]string{managedByLabel: managedByValue}}}
+	// created by the policy controller of the cluster
+	foreign := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "default-deny"}}
+	c, client := newTestController(t, map[string]interface{}{DisableOverrideField: true}, configv1.HighlyAvailableTopologyMode, managed, foreign)
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.NetworkingV1().NetworkPolicies(operatorclient.TargetNamespace).Get(context.TODO(), "default-deny", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the operator network policy to be removed, got %v", err)
+	}
+	kept, err := client.NetworkingV1().NetworkPolicies(operatorclient.OperatorNamespace).Get(context.TODO(), "default-deny", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the foreign network policy to be kept: %v", err)
+	}
+	if len(kept.Spec.PolicyTypes) != 0 {
+		t.Errorf("expected the foreign network policy to be left alone, got %v", kept.Spec)
+	}
+	if names := networkPolicyNames(t, client, operatorclient.OperatorNamespace); len(names) != 1 {
+		t.Errorf("expected no network policies to be created, got %v", names)
+	}
+}
+
+func TestInvalidDisableOverrideIsAnError(t *testing.T) {
+	c, client := newTestController(t, map[string]interface{}{DisableOverrideField: "sometimes"}, configv1.HighlyAvailableTopologyMode)
+	err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")))
+	if err == nil || err.Error() != `spec.unsupportedConfigOverrides.disableNetworkPolicyManagement: "sometimes" must be true or false` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if names := networkPolicyNames(t, client, operatorclient.OperatorNamespace); len(names) != 0 {
+		t.Errorf("expected the network policies to be left alone, got %v", names)
+	}
+}
@@ -0,0 +1,279 @@
+package orphanedlockcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1listers "k8s.io/client-go/listers/coordination/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+)
+
+const (
+	controllerName = "OrphanedLeaderElectionLockController"
+
+	// DefaultStaleAfter is how long the holder of a lock must not have renewed it before the lock is reported.
+	DefaultStaleAfter = 30 * 24 * time.Hour
+
+	// OrphanedAnnotation is set on a reported lock, its value is the time the lock was last renewed.
+	OrphanedAnnotation = "operator.openshift.io/orphaned-leader-election-lock"
+
+	// DeleteOrphanedOverrideField of the unsupportedConfigOverrides opts in to deleting the reported locks when it is
+	// true.
+	DeleteOrphanedOverrideField = "deleteOrphanedLeaderElectionLocks"
+)
+
+// knownLocks are the locks of the components that currently run in the scanned namespaces. They are never reported,
+// no matter how long ago they were renewed.
+var knownLocks = map[string]sets.Set[string]{
+	operatorclient.OperatorNamespace: sets.New("kube-controller-manager-operator-lock"),
+	operatorclient.TargetNamespace:   sets.New("cluster-policy-controller-lock", "cert-recovery-controller-lock"),
+}
+
+func init() {
+	// the lease of the operator, which is held by the operator process, and the leases of the operand containers
+	for namespace := range knownLocks {
+		relatedobjects.Register(
+			configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: namespace},
+			configv1.ObjectReference{Resource: "configmaps", Namespace: namespace},
+		)
+	}
+	overrides.Register(DeleteOrphanedOverrideField)
+}
+
+// lock is a lease or configmap holding a leader election record.
+type lock struct {
+	kind      string
+	namespace string
+	name      string
+	holder    string
+	renewTime time.Time
+}
+
+func (l lock) String() string {
+	return fmt.Sprintf("%s/%s -n %s", l.kind, l.name, l.namespace)
+}
+
+// OrphanedLockController reports leader election locks left behind by components that no longer run, for example
+// locks whose name changed in an upgrade. Deleting them is left to the admin unless DeleteOrphanedOverrideField is
+// true.
+type OrphanedLockController struct {
+	kubeClient      kubernetes.Interface
+	operatorLister  cache.GenericLister
+	leaseListers    map[string]coordinationv1listers.LeaseNamespaceLister
+	configMapLister corev1listers.ConfigMapLister
+
+	staleAfter time.Duration
+	now        func() time.Time
+}
+
+func NewOrphanedLockController(
+	kubeClient kubernetes.Interface,
+	operatorLister cache.GenericLister,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &OrphanedLockController{
+		kubeClient:      kubeClient,
+		operatorLister:  operatorLister,
+		leaseListers:    map[string]coordinationv1listers.LeaseNamespaceLister{},
+		configMapLister: kubeInformersForNamespaces.ConfigMapLister(),
+		staleAfter:      DefaultStaleAfter,
+		now:             time.Now,
+	}
+
+	var informers []factory.Informer
+	for namespace := range knownLocks {
+		leaseInformer := kubeInformersForNamespaces.InformersFor(namespace).Coordination().V1().Leases()
+		c.leaseListers[namespace] = leaseInformer.Lister().Leases(namespace)
+		informers = append(informers,
+			leaseInformer.Informer(),
+			kubeInformersForNamespaces.InformersFor(namespace).Core().V1().ConfigMaps().Informer(),
+		)
+	}
+
+	return factory.New().WithFilteredEventsInformers(
+		// the known locks are renewed every few seconds, only unknown locks can become orphaned
+		isUnknownLock,
+		informers...,
+	).ResyncEvery(time.Hour).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("orphaned-lock-controller"))
+}
+
+func isUnknownLock(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if cm, ok := obj.(*corev1.ConfigMap); ok {
+		if _, isLock := cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey]; !isLock {
+			return false
+		}
+	}
+	return !knownLocks[objMeta.GetNamespace()].Has(objMeta.GetName())
+}
+
+func (c *OrphanedLockController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	deleteOrphaned, err := c.deleteOrphaned()
+	if err != nil {
+		return err
+	}
+
+	var leases []*coordinationv1.Lease
+	var configMaps []*corev1.ConfigMap
+	for namespace, lister := range c.leaseListers {
+		namespaceLeases, err := lister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		leases = append(leases, namespaceLeases...)
+		namespaceConfigMaps, err := c.configMapLister.ConfigMaps(namespace).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		configMaps = append(configMaps, namespaceConfigMaps...)
+	}
+
+	now := c.now()
+	var errs []error
+	for _, lease := range leases {
+		l, ok := leaseLock(lease)
+		if !ok {
+			continue
+		}
+		orphaned := isOrphaned(l, now, c.staleAfter)
+		switch {
+		case orphaned && deleteOrphaned:
+			errs = append(errs, c.delete(ctx, syncCtx.Recorder(), l, c.kubeClient.CoordinationV1().Leases(l.namespace).Delete))
+		case orphaned != hasOrphanedAnnotation(lease):
+			updated := lease.DeepCopy()
+			setOrphanedAnnotation(updated, l, orphaned)
+			_, err := c.kubeClient.CoordinationV1().Leases(l.namespace).Update(ctx, updated, metav1.UpdateOptions{})
+			errs = append(errs, c.reported(syncCtx.Recorder(), l, orphaned, err))
+		}
+	}
+	for _, configMap := range configMaps {
+		l, ok := configMapLock(configMap)
+		if !ok {
+			continue
+		}
+		orphaned := isOrphaned(l, now, c.staleAfter)
+		switch {
+		case orphaned && deleteOrphaned:
+			errs = append(errs, c.delete(ctx, syncCtx.Recorder(), l, c.kubeClient.CoreV1().ConfigMaps(l.namespace).Delete))
+		case orphaned != hasOrphanedAnnotation(configMap):
+			updated := configMap.DeepCopy()
+			setOrphanedAnnotation(updated, l, orphaned)
+			_, err := c.kubeClient.CoreV1().ConfigMaps(l.namespace).Update(ctx, updated, metav1.UpdateOptions{})
+			errs = append(errs, c.reported(syncCtx.Recorder(), l, orphaned, err))
+		}
+	}
+	return v1helpers.NewMultiLineAggregate(errs)
+}
+
+func (c *OrphanedLockController) reported(recorder events.Recorder, l lock, orphaned bool, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", l, err)
+	}
+	if orphaned {
+		recorder.Warningf("OrphanedLeaderElectionLock", "%s was last renewed by %q at %s and belongs to no current component, it is safe to delete", l, l.holder, l.renewTime.UTC().Format(time.RFC3339))
+	} else {
+		klog.V(2).Infof("%s is renewed again, it is no longer reported as orphaned", l)
+	}
+	return nil
+}
+
+func (c *OrphanedLockController) delete(ctx context.Context, recorder events.Recorder, l lock, deleteFn func(context.Context, string, metav1.DeleteOptions) error) error {
+	if err := deleteFn(ctx, l.name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s: %w", l, err)
+	}
+	recorder.Eventf("OrphanedLeaderElectionLockDeleted", "Deleted %s, it was last renewed by %q at %s", l, l.holder, l.renewTime.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func (c *OrphanedLockController) deleteOrphaned() (bool, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return false, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return false, err
+	}
+	return overrides.Bool(unsupportedConfigOverrides, DeleteOrphanedOverrideField)
+}
+
+func isOrphaned(l lock, now time.Time, staleAfter time.Duration) bool {
+	if knownLocks[l.namespace].Has(l.name) {
+		return false
+	}
+	return now.Sub(l.renewTime) > staleAfter
+}
+
+// leaseLock returns the leader election record of a lease. Leases without a renew time are not used for leader
+// election.
+func leaseLock(lease *coordinationv1.Lease) (lock, bool) {
+	if lease.Spec.RenewTime == nil {
+		return lock{}, false
+	}
+	l := lock{kind: "lease", namespace: lease.Namespace, name: lease.Name, renewTime: lease.Spec.RenewTime.Time}
+	if lease.Spec.HolderIdentity != nil {
+		l.holder = *lease.Spec.HolderIdentity
+	}
+	return l, true
+}
+
+// configMapLock returns the leader election record of a configmap, which older components kept in an annotation.
+func configMapLock(configMap *corev1.ConfigMap) (lock, bool) {
+	value, ok := configMap.Annotations[resourcelock.LeaderElectionRecordAnnotationKey]
+	if !ok {
+		return lock{}, false
+	}
+	record := resourcelock.LeaderElectionRecord{}
+	if err := json.Unmarshal([]byte(value), &record); err != nil || record.RenewTime.IsZero() {
+		return lock{}, false
+	}
+	return lock{kind: "configmap", namespace: configMap.Namespace, name: configMap.Name, holder: record.HolderIdentity, renewTime: record.RenewTime.Time}, true
+}
+
+func hasOrphanedAnnotation(obj metav1.Object) bool {
+	_, ok := obj.GetAnnotations()[OrphanedAnnotation]
+	return ok
+}
+
+func setOrphanedAnnotation(obj metav1.Object, l lock, orphaned bool) {
+	annotations := obj.GetAnnotations()
+	if !orphaned {
+		delete(annotations, OrphanedAnnotation)
+		obj.SetAnnotations(annotations)
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[OrphanedAnnotation] = l.renewTime.UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,175 @@
+package orphanedlockcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	coordinationv1listers "k8s.io/client-go/listers/coordination/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func lease(namespace, name string, renewed time.Time) *coordinationv1.Lease {
+	holder := name + "-holder"
+	renewTime := metav1.NewMicroTime(renewed)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &renewTime},
+	}
+}
+
+func configMapWithLock(t *testing.T, namespace, name string, renewed time.Time) *corev1.ConfigMap {
+	record, err := json.Marshal(resourcelock.LeaderElectionRecord{HolderIdentity: name + "-holder", RenewTime: metav1.NewTime(renewed)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   namespace,
+		Name:        name,
+		Annotations: map[string]string{resourcelock.LeaderElectionRecordAnnotationKey: string(record)},
+	}}
+}
+
+func newTestController(t *testing.T, unsupportedConfigOverrides map[string]interface{}, objects ...runtime.Object) (*OrphanedLockController, *fake.Clientset) {
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedMap(operator.Object, unsupportedConfigOverrides, "spec", "unsupportedConfigOverrides"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	leaseIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := operatorIndexer.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range objects {
+		indexer := configMapIndexer
+		if _, ok := obj.(*coordinationv1.Lease); ok {
+			indexer = leaseIndexer
+		}
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := fake.NewSimpleClientset(objects...)
+	leaseLister := coordinationv1listers.NewLeaseLister(leaseIndexer)
+	c := &OrphanedLockController{
+		kubeClient:     client,
+		operatorLister: cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		leaseListers: map[string]coordinationv1listers.LeaseNamespaceLister{
+			operatorclient.OperatorNamespace: leaseLister.Leases(operatorclient.OperatorNamespace),
+			operatorclient.TargetNamespace:   leaseLister.Leases(operatorclient.TargetNamespace),
+		},
+		configMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+		staleAfter:      DefaultStaleAfter,
+		now:             func() time.Time { return now },
+	}
+	return c, client
+}
+
+func TestOrphanedLocksAreReported(t *testing.T) {
+	stale := now.Add(-45 * 24 * time.Hour)
+	fresh := now.Add(-10 * time.Second)
+	c, client := newTestController(t, nil,
+		// fresh lock of an unknown component
+		lease(operatorclient.TargetNamespace, "some-new-controller-lock", fresh),
+		// stale locks of known components, e.g. a cluster that was shut down for a while
+		lease(operatorclient.TargetNamespace, "cluster-policy-controller-lock", stale),
+		configMapWithLock(t, operatorclient.OperatorNamespace, "kube-controller-manager-operator-lock", stale),
+		// stale locks of removed components
+		lease(operatorclient.TargetNamespace, "removed-controller-lock", stale),
+		configMapWithLock(t, operatorclient.OperatorNamespace, "removed-operator-lock", stale),
+		// not a lock
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "config"}},
+	)
+	recorder := events.NewInMemoryRecorder("test")
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	orphaned := map[string]bool{}
+	leases, _ := client.CoordinationV1().Leases(operatorclient.TargetNamespace).List(context.TODO(), metav1.ListOptions{})
+	for _, l := range leases.Items {
+		if _, ok := l.Annotations[OrphanedAnnotation]; ok {
+			orphaned["lease/"+l.Name] = true
+		}
+	}
+	for _, namespace := range []string{operatorclient.OperatorNamespace, operatorclient.TargetNamespace} {
+		configMaps, _ := client.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+		for _, cm := range configMaps.Items {
+			if _, ok := cm.Annotations[OrphanedAnnotation]; ok {
+				orphaned["configmap/"+cm.Name] = true
+			}
+		}
+	}
+	expected := map[string]bool{"lease/removed-controller-lock": true, "configmap/removed-operator-lock": true}
+	if len(orphaned) != len(expected) || !orphaned["lease/removed-controller-lock"] || !orphaned["configmap/removed-operator-lock"] {
+		t.Errorf("expected %v to be annotated as orphaned, got %v", expected, orphaned)
+	}
+
+	var reported int
+	for _, event := range recorder.Events() {
+		if event.Reason == "OrphanedLeaderElectionLock" {
+			reported++
+		}
+	}
+	if reported != 2 {
+		t.Errorf("expected 2 OrphanedLeaderElectionLock events, got %d", reported)
+	}
+}
+
+func TestOrphanedLocksAreDeletedWhenOptedIn(t *testing.T) {
+	stale := now.Add(-45 * 24 * time.Hour)
+	c, client := newTestController(t, map[string]interface{}{DeleteOrphanedOverrideField: true},
+		lease(operatorclient.TargetNamespace, "cert-recovery-controller-lock", stale),
+		lease(operatorclient.TargetNamespace, "removed-controller-lock", stale),
+	)
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CoordinationV1().Leases(operatorclient.TargetNamespace).Get(context.TODO(), "removed-controller-lock", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the orphaned lock to be deleted, got %v", err)
+	}
+	if _, err := client.CoordinationV1().Leases(operatorclient.TargetNamespace).Get(context.TODO(), "cert-recovery-controller-lock", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the known lock to be kept, got %v", err)
+	}
+}
+
+func TestRenewedLockIsNoLongerReported(t *testing.T) {
+	renewed := lease(operatorclient.TargetNamespace, "removed-controller-lock", now.Add(-time.Minute))
+	renewed.Annotations = map[string]string{OrphanedAnnotation: "2024-01-01T00:00:00Z"}
+	c, client := newTestController(t, nil, renewed)
+	if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := client.CoordinationV1().Leases(operatorclient.TargetNamespace).Get(context.TODO(), "removed-controller-lock", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Annotations[OrphanedAnnotation]; ok {
+		t.Errorf("expected the orphaned annotation to be removed from a renewed lock")
+	}
+}
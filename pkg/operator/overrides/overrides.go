@@ -0,0 +1,138 @@
+// Package overrides reads the fields of the unsupportedConfigOverrides of kubecontrollermanager/cluster that the
+// operator interprets itself: the tunables of its controllers and of the observed config that the operator API has no
+// field for, e.g.
+//
+//	unsupportedConfigOverrides:
+//	  disableNetworkPolicyManagement: true
+//
+// These fields are not part of the kube-controller-manager config, they are pruned when the overrides are merged into
+// it. Every package registers the fields it reads from an init function, the target config controller reports every
+// other unknown field.
+package overrides
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+var (
+	lock       sync.Mutex
+	registered = map[string]bool{}
+)
+
+// Register adds fields to the known fields of the unsupportedConfigOverrides.
+func Register(fields ...string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, field := range fields {
+		registered[field] = true
+	}
+}
+
+// Registered returns the registered fields in order.
+func Registered() []string {
+	lock.Lock()
+	defer lock.Unlock()
+
+	fields := make([]string, 0, len(registered))
+	for field := range registered {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Path is the JSON path of field, e.g. spec.unsupportedConfigOverrides.disableNetworkPolicyManagement.
+func Path(field string) string {
+	return "spec.unsupportedConfigOverrides." + field
+}
+
+// SpecGetter returns the spec of the KubeControllerManager CR, e.g. its v1helpers.OperatorClient.
+type SpecGetter interface {
+	GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error)
+}
+
+// OfSpec returns the unsupportedConfigOverrides of the spec of operator.
+func OfSpec(operator SpecGetter) ([]byte, error) {
+	spec, _, _, err := operator.GetOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	return spec.UnsupportedConfigOverrides.Raw, nil
+}
+
+// Of returns the unsupportedConfigOverrides of the KubeControllerManager CR as the dynamic operator lister returns
+// it, nil without any.
+func Of(operator runtime.Object) ([]byte, error) {
+	object, ok := operator.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected operator type %T", operator)
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(object.Object, "spec", "unsupportedConfigOverrides")
+	if err != nil || !found || value == nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Raw returns the JSON of field, false when it is not set.
+func Raw(unsupportedConfigOverrides []byte, field string) (json.RawMessage, bool, error) {
+	if len(unsupportedConfigOverrides) == 0 {
+		return nil, false, nil
+	}
+	fields := map[string]json.RawMessage{}
+	if err := yaml.Unmarshal(unsupportedConfigOverrides, &fields); err != nil {
+		return nil, false, fmt.Errorf("spec.unsupportedConfigOverrides: %w", err)
+	}
+	raw, ok := fields[field]
+	if !ok || string(raw) == "null" {
+		return nil, false, nil
+	}
+	return raw, true, nil
+}
+
+// String returns field as a string, false when it is not set. Numbers and booleans are returned as they are written,
+// e.g. 1000 as "1000", for the validators to parse them like any other value.
+func String(unsupportedConfigOverrides []byte, field string) (string, bool, error) {
+	raw, ok, err := Raw(unsupportedConfigOverrides, field)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	switch raw[0] {
+	case '"':
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", false, err
+		}
+		return value, true, nil
+	case '{', '[':
+		return "", false, &validation.Error{Path: Path(field), Value: string(raw), Accepted: "a string, a number or a boolean"}
+	default:
+		return string(raw), true, nil
+	}
+}
+
+// Bool returns whether field is true, false when it is not set.
+func Bool(unsupportedConfigOverrides []byte, field string) (bool, error) {
+	value, ok, err := String(unsupportedConfigOverrides, field)
+	if err != nil || !ok {
+		return false, err
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &validation.Error{Path: Path(field), Value: value, Accepted: "true or false"}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,76 @@
+package overrides
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestString(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		overrides     string
+		expected      string
+		expectedOK    bool
+		expectedError string
+	}{
+		{name: "no overrides"},
+		{name: "not set", overrides: `{"extendedArguments":{}}`},
+		{name: "null", overrides: `{"field":null}`},
+		{name: "string", overrides: `{"field":"50s"}`, expected: "50s", expectedOK: true},
+		{name: "number", overrides: `{"field":1000}`, expected: "1000", expectedOK: true},
+		{name: "boolean", overrides: `{"field":true}`, expected: "true", expectedOK: true},
+		{name: "yaml", overrides: "field: 0.5\n", expected: "0.5", expectedOK: true},
+		{name: "object", overrides: `{"field":{"a":1}}`, expectedError: `spec.unsupportedConfigOverrides.field: "{\"a\":1}" must be a string, a number or a boolean`},
+		{name: "not an object", overrides: `[1]`, expectedError: "spec.unsupportedConfigOverrides: "},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok, err := String([]byte(test.overrides), "field")
+			if len(test.expectedError) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), test.expectedError) {
+					t.Fatalf("expected an error starting with %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expected || ok != test.expectedOK {
+				t.Errorf("expected %q (%v), got %q (%v)", test.expected, test.expectedOK, actual, ok)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	if enabled, err := Bool(nil, "field"); err != nil || enabled {
+		t.Errorf("expected an unset field to be false, got %v, %v", enabled, err)
+	}
+	if enabled, err := Bool([]byte(`{"field":true}`), "field"); err != nil || !enabled {
+		t.Errorf("expected true, got %v, %v", enabled, err)
+	}
+	if enabled, err := Bool([]byte(`{"field":"false"}`), "field"); err != nil || enabled {
+		t.Errorf("expected false, got %v, %v", enabled, err)
+	}
+	if _, err := Bool([]byte(`{"field":"yes please"}`), "field"); err == nil || err.Error() != `spec.unsupportedConfigOverrides.field: "yes please" must be true or false` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOf(t *testing.T) {
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if raw, err := Of(operator); err != nil || raw != nil {
+		t.Errorf("expected no overrides, got %s, %v", raw, err)
+	}
+	if err := unstructured.SetNestedField(operator.Object, map[string]interface{}{"field": int64(10)}, "spec", "unsupportedConfigOverrides"); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := Of(operator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := String(raw, "field"); value != "10" {
+		t.Errorf("expected 10, got %q", value)
+	}
+}
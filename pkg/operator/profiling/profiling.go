@@ -0,0 +1,82 @@
+// Package profiling gates the pprof endpoints of the secure port of the operator. The generic API server installs them
+// unconditionally, they are served only while the KubeControllerManager CR enables them.
+package profiling
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/server/routes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// Annotation on the KubeControllerManager CR serves the pprof endpoints while it is "true", e.g. to investigate the
+// memory of the operator. The annotation is read on every request, no restart is needed. Like every path but /metrics,
+// the endpoints require a user authorized to get the /debug/pprof non-resource URLs.
+const Annotation = "operator.openshift.io/enable-profiling"
+
+// paths are the paths routes.Profiling installs.
+var paths = []string{"/debug/pprof", "/debug/pprof/", "/debug/pprof/profile", "/debug/pprof/symbol", "/debug/pprof/trace"}
+
+// Install replaces the pprof endpoints of m by ones answering 404 unless Annotation enables them on the
+// KubeControllerManager CR read through operatorLister.
+func Install(m *mux.PathRecorderMux, operatorLister cache.GenericLister) {
+	for _, path := range paths {
+		m.Unregister(path)
+	}
+	profiling := mux.NewPathRecorderMux("profiling")
+	routes.Profiling{}.Install(profiling)
+	gated := &gate{operatorLister: operatorLister, next: profiling}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "/") {
+			m.UnlistedHandlePrefix(path, gated)
+			continue
+		}
+		m.UnlistedHandle(path, gated)
+	}
+}
+
+type gate struct {
+	operatorLister cache.GenericLister
+	next           http.Handler
+}
+
+func (g *gate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.enabled() {
+		http.NotFound(w, r)
+		return
+	}
+	g.next.ServeHTTP(w, r)
+}
+
+// enabled returns whether Annotation enables the endpoints. A malformed value disables them.
+func (g *gate) enabled() bool {
+	obj, err := g.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		klog.Warningf("Unable to read %s: %v", Annotation, err)
+		return false
+	}
+	operator, err := meta.Accessor(obj)
+	if err != nil {
+		klog.Warningf("Unable to read %s: %v", Annotation, err)
+		return false
+	}
+	value, ok := operator.GetAnnotations()[Annotation]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		klog.Warningf("Ignoring %s=%q, expected true or false", Annotation, value)
+		return false
+	}
+	return enabled
+}
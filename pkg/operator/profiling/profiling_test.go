@@ -0,0 +1,101 @@
+package profiling
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/server/routes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+type profilingTest struct {
+	t         *testing.T
+	operators cache.Indexer
+	handler   http.Handler
+}
+
+// newProfilingTest serves a mux with the pprof endpoints the generic API server installs, gated by Install, behind the
+// authorization of the generic API server allowing the user "admin" only.
+func newProfilingTest(t *testing.T) *profilingTest {
+	operators := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	m := mux.NewPathRecorderMux("test")
+	routes.Profiling{}.Install(m)
+	Install(m, cache.NewGenericLister(operators, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()))
+
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() == "admin" && !a.IsResourceRequest() && a.GetVerb() == "get" && strings.HasPrefix(a.GetPath(), "/debug/pprof") {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+	resolver := &request.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"), GrouplessAPIPrefixes: sets.NewString("api")}
+	return &profilingTest{
+		t:         t,
+		operators: operators,
+		handler:   filters.WithRequestInfo(filters.WithAuthorization(m, authz, scheme.Codecs), resolver),
+	}
+}
+
+func (p *profilingTest) setAnnotations(annotations map[string]string) {
+	p.t.Helper()
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	operator.SetAnnotations(annotations)
+	if err := p.operators.Update(operator); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *profilingTest) expectStatus(userName, path string, expected int) {
+	p.t.Helper()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r = r.WithContext(request.WithUser(r.Context(), &user.DefaultInfo{Name: userName}))
+	w := httptest.NewRecorder()
+	p.handler.ServeHTTP(w, r)
+	if w.Code != expected {
+		p.t.Errorf("%s by %s: expected %d, got %d: %s", path, userName, expected, w.Code, w.Body.String())
+	}
+}
+
+func TestProfilingIsDisabledByDefault(t *testing.T) {
+	p := newProfilingTest(t)
+	for _, path := range []string{"/debug/pprof", "/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/symbol"} {
+		p.expectStatus("admin", path, http.StatusNotFound)
+	}
+
+	p.setAnnotations(nil)
+	p.expectStatus("admin", "/debug/pprof/heap", http.StatusNotFound)
+	p.setAnnotations(map[string]string{Annotation: "yes"})
+	p.expectStatus("admin", "/debug/pprof/heap", http.StatusNotFound)
+}
+
+func TestProfilingIsEnabledLive(t *testing.T) {
+	p := newProfilingTest(t)
+	p.setAnnotations(map[string]string{Annotation: "true"})
+	p.expectStatus("admin", "/debug/pprof", http.StatusFound)
+	p.expectStatus("admin", "/debug/pprof/", http.StatusOK)
+	p.expectStatus("admin", "/debug/pprof/heap", http.StatusOK)
+
+	p.setAnnotations(map[string]string{Annotation: "false"})
+	p.expectStatus("admin", "/debug/pprof/heap", http.StatusNotFound)
+}
+
+func TestProfilingRequiresAuthorization(t *testing.T) {
+	p := newProfilingTest(t)
+	p.setAnnotations(map[string]string{Annotation: "true"})
+	p.expectStatus("someone", "/debug/pprof/heap", http.StatusForbidden)
+	p.expectStatus("admin", "/debug/pprof/heap", http.StatusOK)
+}
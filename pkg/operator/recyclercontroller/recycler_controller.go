@@ -0,0 +1,133 @@
+package recyclercontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/ptr"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+)
+
+const (
+	controllerName = "RecyclerServiceAccountController"
+	conditionType  = "RecyclerServiceAccountDegraded"
+
+	// ServiceAccountAsset is the service account the recycler pods of persistent volumes with the Recycle reclaim
+	// policy run as, see the recycler-config configmap.
+	ServiceAccountAsset = "assets/kube-controller-manager/recycler-sa.yaml"
+
+	// failuresBeforeDegraded is how many consecutive syncs have to fail before the controller goes Degraded, a
+	// regeneration usually succeeds on the next sync.
+	failuresBeforeDegraded = 3
+)
+
+// RecyclerServiceAccountController verifies the service account of the recycler pods. kube-controller-manager fails
+// to create recycler pods without it, which breaks persistent volumes with the Recycle reclaim policy without an
+// operator signal. The static resource controller applies the service account like the other static resources, this
+// controller checks on every sync that it exists and that a token can be issued for it. A missing service account is
+// recreated, a recreated one, by this or the static resource controller, is reported with an event. Failing
+// consecutive syncs set RecyclerServiceAccountDegraded.
+type RecyclerServiceAccountController struct {
+	operatorClient       v1helpers.StaticPodOperatorClient
+	serviceAccountClient corev1client.ServiceAccountsGetter
+
+	// uid is the UID of the service account on the previous sync, a different one means it was recreated
+	uid      types.UID
+	failures int
+}
+
+func NewRecyclerServiceAccountController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	serviceAccountClient corev1client.ServiceAccountsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RecyclerServiceAccountController{
+		operatorClient:       operatorClient,
+		serviceAccountClient: serviceAccountClient,
+	}
+	required := requiredServiceAccount()
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+	).WithFilteredEventsInformers(
+		factory.NamesFilter(required.Name),
+		kubeInformersForNamespaces.InformersFor(required.Namespace).Core().V1().ServiceAccounts().Informer(),
+	).ResyncEvery(5*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("recycler-service-account-controller"))
+}
+
+func (c *RecyclerServiceAccountController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	checkErr := c.check(ctx, syncCtx.Recorder())
+	if checkErr != nil {
+		c.failures++
+	} else {
+		c.failures = 0
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if c.failures >= failuresBeforeDegraded {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "ServiceAccountUnavailable"
+		condition.Message = fmt.Sprintf("Persistent volumes with the Recycle reclaim policy cannot be recycled, %d consecutive checks failed: %v", c.failures, checkErr)
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition)); err != nil {
+		return err
+	}
+	return checkErr
+}
+
+func requiredServiceAccount() *corev1.ServiceAccount {
+	return resourceread.ReadServiceAccountV1OrDie(bindata.MustAsset(ServiceAccountAsset))
+}
+
+// check recreates the service account when it is missing and verifies a token can be issued for it.
+func (c *RecyclerServiceAccountController) check(ctx context.Context, recorder events.Recorder) error {
+	required := requiredServiceAccount()
+	serviceAccounts := c.serviceAccountClient.ServiceAccounts(required.Namespace)
+
+	serviceAccount, err := serviceAccounts.Get(ctx, required.Name, metav1.GetOptions{})
+	recreated := false
+	switch {
+	case apierrors.IsNotFound(err):
+		recreated = true
+		serviceAccount, err = serviceAccounts.Create(ctx, required, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// the static resource controller was faster
+			serviceAccount, err = serviceAccounts.Get(ctx, required.Name, metav1.GetOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("unable to recreate serviceaccount %s/%s: %v", required.Namespace, required.Name, err)
+		}
+	case err != nil:
+		return err
+	}
+	if recreated || (len(c.uid) > 0 && serviceAccount.UID != c.uid) {
+		recorder.Warningf("RecyclerServiceAccountRegenerated", "serviceaccount %s/%s of the persistent volume recycler pods was missing and is recreated", required.Namespace, required.Name)
+	}
+	c.uid = serviceAccount.UID
+
+	if _, err := serviceAccounts.CreateToken(ctx, required.Name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: ptr.To[int64](600)},
+	}, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("no token can be issued for serviceaccount %s/%s: %v", required.Namespace, required.Name, err)
+	}
+	return nil
+}
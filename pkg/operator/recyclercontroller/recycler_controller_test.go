@@ -0,0 +1,137 @@
+package recyclercontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// newTestController returns a controller whose token requests fail while tokenErr is set.
+func newTestController(tokenErr *error) (*RecyclerServiceAccountController, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	uids := 0
+	client.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		create := action.(clienttesting.CreateAction)
+		if action.GetSubresource() == "token" {
+			if *tokenErr != nil {
+				return true, nil, *tokenErr
+			}
+			return true, &authenticationv1.TokenRequest{Status: authenticationv1.TokenRequestStatus{Token: "token"}}, nil
+		}
+		// the fake client does not set UIDs
+		uids++
+		create.GetObject().(metav1.Object).SetUID(types.UID(fmt.Sprintf("uid-%d", uids)))
+		return false, nil, nil
+	})
+	return &RecyclerServiceAccountController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		serviceAccountClient: client.CoreV1(),
+	}, client
+}
+
+func regeneratedEvents(recorder events.InMemoryRecorder) int {
+	count := 0
+	for _, event := range recorder.Events() {
+		if event.Reason == "RecyclerServiceAccountRegenerated" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDeletedServiceAccountIsRegenerated(t *testing.T) {
+	var tokenErr error
+	c, client := newTestController(&tokenErr)
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext(controllerName, recorder)
+
+	// created by the static resource controller
+	if _, err := client.CoreV1().ServiceAccounts("openshift-infra").Create(context.TODO(), requiredServiceAccount(), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if count := regeneratedEvents(recorder); count != 0 {
+		t.Errorf("expected no regeneration, got %d events", count)
+	}
+
+	// deleted by a cleanup script
+	if err := client.CoreV1().ServiceAccounts("openshift-infra").Delete(context.TODO(), "pv-recycler-controller", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().ServiceAccounts("openshift-infra").Get(context.TODO(), "pv-recycler-controller", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the service account to be regenerated, got %v", err)
+	}
+	if count := regeneratedEvents(recorder); count != 1 {
+		t.Errorf("expected a regeneration event, got %d", count)
+	}
+
+	// deleted and recreated by the static resource controller in between two syncs
+	if err := client.CoreV1().ServiceAccounts("openshift-infra").Delete(context.TODO(), "pv-recycler-controller", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().ServiceAccounts("openshift-infra").Create(context.TODO(), requiredServiceAccount(), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if count := regeneratedEvents(recorder); count != 2 {
+		t.Errorf("expected a second regeneration event, got %d", count)
+	}
+}
+
+func TestPersistentFailuresAreDegraded(t *testing.T) {
+	tokenErr := fmt.Errorf("serviceaccounts \"pv-recycler-controller\" not found")
+	c, _ := newTestController(&tokenErr)
+	syncCtx := factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))
+
+	degraded := func() *operatorv1.OperatorCondition {
+		_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+	}
+	for i := 1; i <= failuresBeforeDegraded; i++ {
+		if err := c.sync(context.TODO(), syncCtx); err == nil {
+			t.Fatalf("sync %d: expected the token request to fail", i)
+		}
+		if condition := degraded(); i < failuresBeforeDegraded && condition.Status != operatorv1.ConditionFalse {
+			t.Errorf("sync %d: expected a single failure not to degrade, got %#v", i, condition)
+		}
+	}
+	expectedMessage := `Persistent volumes with the Recycle reclaim policy cannot be recycled, 3 consecutive checks failed: no token can be issued for serviceaccount openshift-infra/pv-recycler-controller: serviceaccounts "pv-recycler-controller" not found`
+	if condition := degraded(); condition.Status != operatorv1.ConditionTrue || condition.Message != expectedMessage {
+		t.Errorf("expected the condition True %q, got %#v", expectedMessage, condition)
+	}
+
+	tokenErr = nil
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if condition := degraded(); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the condition to clear, got %#v", condition)
+	}
+}
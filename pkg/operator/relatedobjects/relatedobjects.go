@@ -0,0 +1,116 @@
+// Package relatedobjects collects the relatedObjects of the kube-controller-manager ClusterOperator, the objects
+// must-gather inspects for this operator. Every package of the operator registers the objects it writes or reads from
+// an init function, next to the code that manages them, so that a controller managing a new resource type adds it
+// where it is introduced.
+package relatedobjects
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var (
+	lock       sync.Mutex
+	registered = map[configv1.ObjectReference]bool{}
+)
+
+// Register adds references to the relatedObjects. A reference without a name is every object of the resource in the
+// namespace, or in the cluster for a reference without a namespace.
+func Register(references ...configv1.ObjectReference) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, reference := range references {
+		registered[reference] = true
+	}
+}
+
+// RegisterManifests adds the objects of the manifests. It panics on a manifest that does not parse, like the callers
+// of bindata.MustAsset do on a missing one.
+func RegisterManifests(manifests ...[]byte) {
+	for _, manifest := range manifests {
+		reference, err := ForManifest(manifest)
+		if err != nil {
+			panic(err)
+		}
+		Register(reference)
+	}
+}
+
+// ForManifest returns the reference of the object of a manifest.
+func ForManifest(manifest []byte) (configv1.ObjectReference, error) {
+	object := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"metadata"`
+	}{}
+	if err := yaml.Unmarshal(manifest, &object); err != nil {
+		return configv1.ObjectReference{}, err
+	}
+	groupVersion, err := schema.ParseGroupVersion(object.APIVersion)
+	if err != nil {
+		return configv1.ObjectReference{}, err
+	}
+	if len(object.Kind) == 0 || len(object.Metadata.Name) == 0 {
+		return configv1.ObjectReference{}, fmt.Errorf("manifest of %s %q has no kind or name", object.APIVersion, object.Kind)
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(groupVersion.WithKind(object.Kind))
+	return configv1.ObjectReference{
+		Group:     resource.Group,
+		Resource:  resource.Resource,
+		Namespace: object.Metadata.Namespace,
+		Name:      object.Metadata.Name,
+	}, nil
+}
+
+// List returns the registered references, ordered by group, resource, namespace and name.
+func List() []configv1.ObjectReference {
+	lock.Lock()
+	defer lock.Unlock()
+
+	references := make([]configv1.ObjectReference, 0, len(registered))
+	for reference := range registered {
+		references = append(references, reference)
+	}
+	sort.Slice(references, func(i, j int) bool {
+		a, b := references[i], references[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return references
+}
+
+// Includes returns whether a registered reference covers the object.
+func Includes(object configv1.ObjectReference) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, reference := range []configv1.ObjectReference{
+		object,
+		{Group: object.Group, Resource: object.Resource, Namespace: object.Namespace},
+		{Group: object.Group, Resource: object.Resource},
+	} {
+		if registered[reference] {
+			return true
+		}
+	}
+	return false
+}
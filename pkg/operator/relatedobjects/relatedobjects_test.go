@@ -0,0 +1,54 @@
+package relatedobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestForManifest(t *testing.T) {
+	reference, err := ForManifest([]byte(`
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  namespace: openshift-kube-controller-manager
+  name: default-deny
+`))
+	require.NoError(t, err)
+	assert.Equal(t, configv1.ObjectReference{Group: "networking.k8s.io", Resource: "networkpolicies", Namespace: "openshift-kube-controller-manager", Name: "default-deny"}, reference)
+
+	reference, err = ForManifest([]byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: openshift-infra
+`))
+	require.NoError(t, err)
+	assert.Equal(t, configv1.ObjectReference{Resource: "namespaces", Name: "openshift-infra"}, reference)
+
+	_, err = ForManifest([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.Error(t, err)
+}
+
+func TestIncludes(t *testing.T) {
+	Register(
+		configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "test-namespace"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: "test-namespace", Name: "test-configmap"},
+		configv1.ObjectReference{Group: "certificates.k8s.io", Resource: "certificatesigningrequests"},
+	)
+
+	assert.True(t, Includes(configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "test-namespace", Name: "any"}))
+	assert.True(t, Includes(configv1.ObjectReference{Resource: "configmaps", Namespace: "test-namespace", Name: "test-configmap"}))
+	assert.True(t, Includes(configv1.ObjectReference{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Name: "csr-1"}))
+	assert.False(t, Includes(configv1.ObjectReference{Resource: "configmaps", Namespace: "test-namespace", Name: "other"}))
+	assert.False(t, Includes(configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "other-namespace", Name: "any"}))
+
+	list := List()
+	assert.Contains(t, list, configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "test-namespace"})
+	for i := 1; i < len(list); i++ {
+		assert.LessOrEqual(t, list[i-1].Group, list[i].Group, "not ordered by group")
+	}
+}
@@ -0,0 +1,88 @@
+package operator
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/forceredeploymentcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/installerhistorycontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/upgradecheck"
+)
+
+// TestRelatedObjectsIncludeWrittenObjects checks that must-gather collects every object the controllers write: the
+// objects of the operand manifests, the revisioned and unrevisioned resources of the static pod and the objects
+// written by the controllers of this operator.
+func TestRelatedObjectsIncludeWrittenObjects(t *testing.T) {
+	var written []configv1.ObjectReference
+
+	root := "../../bindata/assets/kube-controller-manager"
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		reference, err := relatedobjects.ForManifest(content)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		written = append(written, reference)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, resource := range DeploymentConfigMaps {
+		written = append(written, configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.TargetNamespace, Name: resource.Name + "-1"})
+	}
+	for _, resource := range DeploymentSecrets {
+		written = append(written, configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.TargetNamespace, Name: resource.Name + "-1"})
+	}
+	for _, resource := range CertConfigMaps {
+		written = append(written, configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.TargetNamespace, Name: resource.Name})
+	}
+	for _, resource := range CertSecrets {
+		written = append(written, configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.TargetNamespace, Name: resource.Name})
+	}
+	written = append(written,
+		configv1.ObjectReference{Resource: "pods", Namespace: operatorclient.TargetNamespace, Name: "installer-1-master-0"},
+		configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: operatorclient.OperatorNamespace, Name: "kube-controller-manager-operator-lock"},
+		configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: operatorclient.TargetNamespace, Name: "cluster-policy-controller-lock"},
+		configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "kube-system", Name: "kube-controller-manager"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.TargetNamespace, Name: "cloud-config"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "csr-controller-ca"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "sa-token-signing-certs"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: forceredeploymentcontroller.HistoryConfigMapName},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: installerhistorycontroller.HistoryConfigMapName},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: upgradecheck.ReportConfigMapName},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.OperatorNamespace, Name: "csr-signer"},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.OperatorNamespace, Name: "next-service-account-private-key"},
+		configv1.ObjectReference{Group: "operator.openshift.io", Resource: "kubecontrollermanagers", Name: "cluster"},
+	)
+
+	// and the sources the observers and the target config controller read
+	written = append(written,
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: "cloud-provider-config"},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: targetconfigcontroller.AdditionalRootCAConfigMapName},
+		configv1.ObjectReference{Group: configv1.GroupName, Resource: "infrastructures", Name: "cluster"},
+		configv1.ObjectReference{Group: configv1.GroupName, Resource: "networks", Name: "cluster"},
+	)
+
+	for _, reference := range written {
+		if !relatedobjects.Includes(reference) {
+			t.Errorf("%s.%s %s -n %q is not in the relatedObjects, register it with relatedobjects.Register where it is managed", reference.Resource, reference.Group, reference.Name, reference.Namespace)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package release
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Minor turns a version into its MAJOR.MINOR release, e.g. a payload version like 4.16.0-0.nightly-2024-01-01-000000
+// into 4.16 or a kube-controller-manager version like v1.29.3+abcdef into 1.29. Versions without a numeric major and
+// minor, and development builds reporting 0.0.1-snapshot, have no release.
+func Minor(version string) (string, bool) {
+	major, rest, ok := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	if !ok {
+		return "", false
+	}
+	majorNumber, err := strconv.Atoi(major)
+	if err != nil || majorNumber == 0 {
+		return "", false
+	}
+	minor := rest
+	if i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minor = rest[:i]
+	}
+	minorNumber, err := strconv.Atoi(minor)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d.%d", majorNumber, minorNumber), true
+}
+
+// Compare compares two MAJOR.MINOR releases. It is negative when a is older than b, zero when both are the same
+// release and positive when a is newer.
+func Compare(a, b string) int {
+	aMajor, aMinor := split(a)
+	bMajor, bMinor := split(b)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
+
+func split(release string) (int, int) {
+	major, minor, _ := strings.Cut(release, ".")
+	majorNumber, _ := strconv.Atoi(major)
+	minorNumber, _ := strconv.Atoi(minor)
+	return majorNumber, minorNumber
+}
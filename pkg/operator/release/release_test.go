@@ -0,0 +1,49 @@
+package release
+
+import "testing"
+
+func TestMinor(t *testing.T) {
+	for _, test := range []struct {
+		version  string
+		expected string
+	}{
+		{version: "4.16.0-0.nightly-2024-01-01-000000", expected: "4.16"},
+		{version: "4.16.0-rc.1", expected: "4.16"},
+		{version: "4.16", expected: "4.16"},
+		{version: "1.29.3", expected: "1.29"},
+		{version: "v1.29.3+abcdef", expected: "1.29"},
+		{version: "v1.30-rc", expected: "1.30"},
+		{version: "0.0.1-snapshot"},
+		{version: "latest"},
+		{version: "4"},
+		{version: "4.x"},
+		{version: ""},
+	} {
+		actual, ok := Minor(test.version)
+		if actual != test.expected || ok != (len(test.expected) > 0) {
+			t.Errorf("%q: expected %q, got %q (%v)", test.version, test.expected, actual, ok)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.29", b: "1.29", expected: 0},
+		{a: "1.9", b: "1.29", expected: -1},
+		{a: "1.30", b: "1.29", expected: 1},
+		{a: "4.1", b: "1.30", expected: 1},
+	} {
+		actual := Compare(test.a, test.b)
+		if actual < 0 {
+			actual = -1
+		} else if actual > 0 {
+			actual = 1
+		}
+		if actual != test.expected {
+			t.Errorf("%s and %s: expected %d, got %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
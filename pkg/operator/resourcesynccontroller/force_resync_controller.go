@@ -0,0 +1,205 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	// ForceResyncAnnotation on the KubeControllerManager CR names a synced destination as <namespace>/<name>. The
+	// destination is rewritten from its source once and the annotation is removed again.
+	ForceResyncAnnotation = "operator.openshift.io/force-resync"
+
+	// forceResyncedAtAnnotation is stamped on the destination so that the write goes through even when the content
+	// already matches the source.
+	forceResyncedAtAnnotation = "operator.openshift.io/force-resynced-at"
+)
+
+// ForceResyncController rewrites a single synced configmap or secret on request. Deleting the destination to get it
+// resynced leaves mounting pods without the resource until the regular resource sync controller catches up.
+type ForceResyncController struct {
+	operatorLister         cache.GenericLister
+	kubeControllerManagers operatorv1client.KubeControllerManagersGetter
+	configMaps             corev1client.ConfigMapsGetter
+	secrets                corev1client.SecretsGetter
+
+	now func() time.Time
+}
+
+func NewForceResyncController(
+	operatorClient v1helpers.OperatorClient,
+	operatorLister cache.GenericLister,
+	kubeControllerManagers operatorv1client.KubeControllerManagersGetter,
+	configMaps corev1client.ConfigMapsGetter,
+	secrets corev1client.SecretsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &ForceResyncController{
+		operatorLister:         operatorLister,
+		kubeControllerManagers: kubeControllerManagers,
+		configMaps:             configMaps,
+		secrets:                secrets,
+		now:                    time.Now,
+	}
+	return factory.New().WithInformers(operatorClient.Informer()).WithSync(c.sync).ToController("ForceResyncController", eventRecorder.WithComponentSuffix("force-resync-controller"))
+}
+
+func (c *ForceResyncController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operator, err := c.operatorLister.Get("cluster")
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	operatorMeta, err := meta.Accessor(operator)
+	if err != nil {
+		return err
+	}
+	target, ok := operatorMeta.GetAnnotations()[ForceResyncAnnotation]
+	if !ok {
+		return nil
+	}
+
+	if err := c.resync(ctx, syncCtx.Recorder(), target); err != nil {
+		// keep the annotation, the resync is retried
+		return err
+	}
+	return c.clearAnnotation(ctx, operatorMeta.GetName())
+}
+
+func (c *ForceResyncController) resync(ctx context.Context, recorder events.Recorder, target string) error {
+	namespace, name, ok := strings.Cut(target, "/")
+	destination := resourcesynccontroller.ResourceLocation{Namespace: namespace, Name: name}
+	if ok {
+		for _, rule := range syncedConfigMaps {
+			if rule.destination == destination {
+				return c.resyncConfigMap(ctx, recorder, rule)
+			}
+		}
+		for _, rule := range syncedSecrets {
+			if rule.destination == destination {
+				return c.resyncSecret(ctx, recorder, rule)
+			}
+		}
+	}
+	recorder.Warningf("ForceResyncInvalidTarget", "Ignoring %s=%q: not a synced configmap or secret", ForceResyncAnnotation, target)
+	return nil
+}
+
+func (c *ForceResyncController) resyncConfigMap(ctx context.Context, recorder events.Recorder, rule syncRule) error {
+	source, err := c.configMaps.ConfigMaps(rule.source.Namespace).Get(ctx, rule.source.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ForceResyncInvalidTarget", "Not resyncing configmap/%s -n %s: source configmap/%s -n %s does not exist", rule.destination.Name, rule.destination.Namespace, rule.source.Name, rule.source.Namespace)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	destination, err := c.configMaps.ConfigMaps(rule.destination.Namespace).Get(ctx, rule.destination.Name, metav1.GetOptions{})
+	previousResourceVersion := ""
+	switch {
+	case errors.IsNotFound(err):
+		destination = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: rule.destination.Namespace, Name: rule.destination.Name}}
+	case err != nil:
+		return err
+	default:
+		previousResourceVersion = destination.ResourceVersion
+		destination = destination.DeepCopy()
+	}
+	destination.Data = source.Data
+	destination.BinaryData = source.BinaryData
+	c.stamp(&destination.ObjectMeta)
+
+	var written *corev1.ConfigMap
+	if len(previousResourceVersion) == 0 {
+		written, err = c.configMaps.ConfigMaps(rule.destination.Namespace).Create(ctx, destination, metav1.CreateOptions{})
+	} else {
+		written, err = c.configMaps.ConfigMaps(rule.destination.Namespace).Update(ctx, destination, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	recorder.Eventf("ForceResyncCompleted", "Rewrote configmap/%s -n %s (resourceVersion %q -> %q) from configmap/%s -n %s (resourceVersion %q)",
+		rule.destination.Name, rule.destination.Namespace, previousResourceVersion, written.ResourceVersion, rule.source.Name, rule.source.Namespace, source.ResourceVersion)
+	return nil
+}
+
+func (c *ForceResyncController) resyncSecret(ctx context.Context, recorder events.Recorder, rule syncRule) error {
+	source, err := c.secrets.Secrets(rule.source.Namespace).Get(ctx, rule.source.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		recorder.Warningf("ForceResyncInvalidTarget", "Not resyncing secret/%s -n %s: source secret/%s -n %s does not exist", rule.destination.Name, rule.destination.Namespace, rule.source.Name, rule.source.Namespace)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	destination, err := c.secrets.Secrets(rule.destination.Namespace).Get(ctx, rule.destination.Name, metav1.GetOptions{})
+	previousResourceVersion := ""
+	switch {
+	case errors.IsNotFound(err):
+		destination = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: rule.destination.Namespace, Name: rule.destination.Name}}
+	case err != nil:
+		return err
+	default:
+		previousResourceVersion = destination.ResourceVersion
+		destination = destination.DeepCopy()
+	}
+	destination.Type = source.Type
+	destination.Data = source.Data
+	c.stamp(&destination.ObjectMeta)
+
+	var written *corev1.Secret
+	if len(previousResourceVersion) == 0 {
+		written, err = c.secrets.Secrets(rule.destination.Namespace).Create(ctx, destination, metav1.CreateOptions{})
+	} else {
+		written, err = c.secrets.Secrets(rule.destination.Namespace).Update(ctx, destination, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	recorder.Eventf("ForceResyncCompleted", "Rewrote secret/%s -n %s (resourceVersion %q -> %q) from secret/%s -n %s (resourceVersion %q)",
+		rule.destination.Name, rule.destination.Namespace, previousResourceVersion, written.ResourceVersion, rule.source.Name, rule.source.Namespace, source.ResourceVersion)
+	return nil
+}
+
+func (c *ForceResyncController) stamp(objectMeta *metav1.ObjectMeta) {
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	objectMeta.Annotations[forceResyncedAtAnnotation] = c.now().UTC().Format(time.RFC3339Nano)
+}
+
+func (c *ForceResyncController) clearAnnotation(ctx context.Context, name string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{ForceResyncAnnotation: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.kubeControllerManagers.KubeControllerManagers().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to remove the %s annotation: %w", ForceResyncAnnotation, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+type fakeKubeControllerManagers struct {
+	operatorv1client.KubeControllerManagerInterface
+	patches []string
+}
+
+func (f *fakeKubeControllerManagers) KubeControllerManagers() operatorv1client.KubeControllerManagerInterface {
+	return f
+}
+
+func (f *fakeKubeControllerManagers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*operatorv1.KubeControllerManager, error) {
+	f.patches = append(f.patches, string(data))
+	return &operatorv1.KubeControllerManager{}, nil
+}
+
+func TestForceResyncController(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-client-ca", ResourceVersion: "10"},
+		Data:       map[string]string{"ca-bundle.crt": "new"},
+	}
+	sourceSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-controller-manager-client-cert-key", ResourceVersion: "20"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")},
+	}
+
+	tests := []struct {
+		name           string
+		annotation     *string
+		objects        []runtime.Object
+		expectedUpdate runtime.Object
+		expectedEvents []string
+		expectPatch    bool
+	}{
+		{
+			name:           "no annotation",
+			objects:        []runtime.Object{source},
+			expectedEvents: []string{},
+		},
+		{
+			name:       "stale configmap is rewritten",
+			annotation: ptr.To("openshift-kube-controller-manager/client-ca"),
+			objects: []runtime.Object{source, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "client-ca", ResourceVersion: "5"},
+				Data:       map[string]string{"ca-bundle.crt": "old"},
+			}},
+			expectedUpdate: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       operatorclient.TargetNamespace,
+					Name:            "client-ca",
+					ResourceVersion: "5",
+					Annotations:     map[string]string{forceResyncedAtAnnotation: "2024-01-02T03:04:05Z"},
+				},
+				Data: map[string]string{"ca-bundle.crt": "new"},
+			},
+			expectedEvents: []string{"ForceResyncCompleted"},
+			expectPatch:    true,
+		},
+		{
+			name:       "configmap already in sync is rewritten anyway",
+			annotation: ptr.To("openshift-kube-controller-manager/client-ca"),
+			objects: []runtime.Object{source, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "client-ca", ResourceVersion: "5"},
+				Data:       map[string]string{"ca-bundle.crt": "new"},
+			}},
+			expectedUpdate: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       operatorclient.TargetNamespace,
+					Name:            "client-ca",
+					ResourceVersion: "5",
+					Annotations:     map[string]string{forceResyncedAtAnnotation: "2024-01-02T03:04:05Z"},
+				},
+				Data: map[string]string{"ca-bundle.crt": "new"},
+			},
+			expectedEvents: []string{"ForceResyncCompleted"},
+			expectPatch:    true,
+		},
+		{
+			name:       "secret is rewritten",
+			annotation: ptr.To("openshift-kube-controller-manager/kube-controller-manager-client-cert-key"),
+			objects: []runtime.Object{sourceSecret, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "kube-controller-manager-client-cert-key", ResourceVersion: "7"},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": []byte("old"), "tls.key": []byte("old")},
+			}},
+			expectedUpdate: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       operatorclient.TargetNamespace,
+					Name:            "kube-controller-manager-client-cert-key",
+					ResourceVersion: "7",
+					Annotations:     map[string]string{forceResyncedAtAnnotation: "2024-01-02T03:04:05Z"},
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")},
+			},
+			expectedEvents: []string{"ForceResyncCompleted"},
+			expectPatch:    true,
+		},
+		{
+			name:           "resource that is not synced",
+			annotation:     ptr.To("openshift-kube-controller-manager/serving-cert"),
+			objects:        []runtime.Object{source},
+			expectedEvents: []string{"ForceResyncInvalidTarget"},
+			expectPatch:    true,
+		},
+		{
+			name:           "malformed target",
+			annotation:     ptr.To("client-ca"),
+			objects:        []runtime.Object{source},
+			expectedEvents: []string{"ForceResyncInvalidTarget"},
+			expectPatch:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operator := &unstructured.Unstructured{}
+			operator.SetAPIVersion("operator.openshift.io/v1")
+			operator.SetKind("KubeControllerManager")
+			operator.SetName("cluster")
+			if test.annotation != nil {
+				operator.SetAnnotations(map[string]string{ForceResyncAnnotation: *test.annotation})
+			}
+			operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := operatorIndexer.Add(operator); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeClient := fake.NewSimpleClientset(test.objects...)
+			kubeControllerManagers := &fakeKubeControllerManagers{}
+			c := &ForceResyncController{
+				operatorLister:         cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+				kubeControllerManagers: kubeControllerManagers,
+				configMaps:             kubeClient.CoreV1(),
+				secrets:                kubeClient.CoreV1(),
+				now:                    func() time.Time { return now },
+			}
+
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("ForceResyncController", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			var updates []runtime.Object
+			for _, action := range kubeClient.Actions() {
+				if update, ok := action.(clienttesting.UpdateAction); ok {
+					updates = append(updates, update.GetObject())
+				}
+			}
+			switch {
+			case test.expectedUpdate == nil && len(updates) > 0:
+				t.Errorf("expected no update, got %#v", updates)
+			case test.expectedUpdate != nil && (len(updates) != 1 || !reflect.DeepEqual(test.expectedUpdate, updates[0])):
+				t.Errorf("expected update\n%#v\ngot\n%#v", test.expectedUpdate, updates)
+			}
+
+			reasons := []string{}
+			for _, event := range recorder.Events() {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(test.expectedEvents, reasons) {
+				t.Errorf("expected events %v, got %v", test.expectedEvents, reasons)
+			}
+
+			expectedPatches := 0
+			if test.expectPatch {
+				expectedPatches = 1
+			}
+			if len(kubeControllerManagers.patches) != expectedPatches {
+				t.Fatalf("expected %d patches, got %v", expectedPatches, kubeControllerManagers.patches)
+			}
+			if test.expectPatch && kubeControllerManagers.patches[0] != `{"metadata":{"annotations":{"operator.openshift.io/force-resync":null}}}` {
+				t.Errorf("unexpected patch %s", kubeControllerManagers.patches[0])
+			}
+		})
+	}
+}
@@ -3,25 +3,74 @@ package resourcesynccontroller
 import (
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
 )
 
+// syncRule is a destination and the source it is kept in sync with.
+type syncRule struct {
+	destination resourcesynccontroller.ResourceLocation
+	source      resourcesynccontroller.ResourceLocation
+}
+
+var (
+	csrControllerCA = syncRule{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "csr-controller-ca"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-ca"},
+	}
+	clientCertKeySecret = syncRule{
+		destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kube-controller-manager-client-cert-key"},
+		source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-controller-manager-client-cert-key"},
+	}
+
+	syncedConfigMaps = []syncRule{
+		csrControllerCA,
+		{
+			destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "service-ca"},
+			source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "service-ca"},
+		},
+		// kcm is re-using the generic-apiserver, so if we set the client-ca and front-proxy-ca manually, it won't try to load them
+		// dynamically from the cluster and won't crash when API isn't available
+		{
+			destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "client-ca"},
+			source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-client-ca"},
+		},
+		{
+			destination: resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "aggregator-client-ca"},
+			source:      resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-aggregator-client-ca"},
+		},
+	}
+	syncedSecrets = []syncRule{
+		clientCertKeySecret,
+	}
+)
+
+func init() {
+	for _, rule := range syncedConfigMaps {
+		relatedobjects.Register(
+			configv1.ObjectReference{Resource: "configmaps", Namespace: rule.source.Namespace, Name: rule.source.Name},
+			configv1.ObjectReference{Resource: "configmaps", Namespace: rule.destination.Namespace, Name: rule.destination.Name},
+		)
+	}
+	for _, rule := range syncedSecrets {
+		relatedobjects.Register(
+			configv1.ObjectReference{Resource: "secrets", Namespace: rule.source.Namespace, Name: rule.source.Name},
+			configv1.ObjectReference{Resource: "secrets", Namespace: rule.destination.Namespace, Name: rule.destination.Name},
+		)
+	}
+}
+
 func AddSyncCSRControllerCA(resourceSyncController *resourcesynccontroller.ResourceSyncController) error {
-	return resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "csr-controller-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-ca"},
-	)
+	return resourceSyncController.SyncConfigMap(csrControllerCA.destination, csrControllerCA.source)
 }
 
 func AddSyncClientCertKeySecret(resourceSyncController *resourcesynccontroller.ResourceSyncController) error {
-	return resourceSyncController.SyncSecret(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "kube-controller-manager-client-cert-key"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-controller-manager-client-cert-key"},
-	)
+	return resourceSyncController.SyncSecret(clientCertKeySecret.destination, clientCertKeySecret.source)
 }
 
 func NewResourceSyncController(
@@ -38,32 +87,15 @@ func NewResourceSyncController(
 		v1helpers.CachedConfigMapGetter(configMapsGetter, kubeInformersForNamespaces),
 		eventRecorder,
 	)
-	if err := AddSyncCSRControllerCA(resourceSyncController); err != nil {
-		return nil, err
-	}
-	if err := AddSyncClientCertKeySecret(resourceSyncController); err != nil {
-		return nil, err
-	}
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "service-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "service-ca"},
-	); err != nil {
-		return nil, err
-	}
-
-	// kcm is re-using the generic-apiserver, so if we set the client-ca and front-proxy-ca manually, it won't try to load them
-	// dynamically from the cluster and won't crash when API isn't available
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "client-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-client-ca"},
-	); err != nil {
-		return nil, err
+	for _, rule := range syncedConfigMaps {
+		if err := resourceSyncController.SyncConfigMap(rule.destination, rule.source); err != nil {
+			return nil, err
+		}
 	}
-	if err := resourceSyncController.SyncConfigMap(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "aggregator-client-ca"},
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "kube-apiserver-aggregator-client-ca"},
-	); err != nil {
-		return nil, err
+	for _, rule := range syncedSecrets {
+		if err := resourceSyncController.SyncSecret(rule.destination, rule.source); err != nil {
+			return nil, err
+		}
 	}
 
 	return resourceSyncController, nil
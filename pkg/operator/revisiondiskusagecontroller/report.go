@@ -0,0 +1,77 @@
+package revisiondiskusagecontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DiskUsageReport is written by the pruner pod into its termination message once pruning finished.
+type DiskUsageReport struct {
+	// Revisions maps every revision directory retained on the node to its size in bytes.
+	Revisions map[string]int64 `json:"revisions"`
+	// TotalBytes is the sum over Revisions.
+	TotalBytes int64 `json:"totalBytes"`
+}
+
+// NewDiskUsageReport measures the <staticPodName>-<revision> directories found in resourceDir.
+func NewDiskUsageReport(resourceDir, staticPodName string) (*DiskUsageReport, error) {
+	entries, err := os.ReadDir(resourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiskUsageReport{Revisions: map[string]int64{}}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), staticPodName+"-") {
+			continue
+		}
+		revision := strings.TrimPrefix(entry.Name(), staticPodName+"-")
+		if _, err := strconv.Atoi(revision); err != nil {
+			// e.g. the unrevisioned <staticPodName>-certs directory
+			continue
+		}
+
+		var size int64
+		if err := filepath.WalkDir(filepath.Join(resourceDir, entry.Name()), func(_ string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		report.Revisions[revision] = size
+		report.TotalBytes += size
+	}
+	return report, nil
+}
+
+// ParseDiskUsageReport reads a report out of a pruner pod termination message.
+func ParseDiskUsageReport(message string) (*DiskUsageReport, error) {
+	report := &DiskUsageReport{}
+	if err := json.Unmarshal([]byte(message), report); err != nil {
+		return nil, fmt.Errorf("unable to parse revision disk usage report: %w", err)
+	}
+	return report, nil
+}
+
+// Write stores the report at path, usually the container termination message path.
+func (r *DiskUsageReport) Write(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
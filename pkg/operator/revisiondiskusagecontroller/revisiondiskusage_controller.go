@@ -0,0 +1,217 @@
+package revisiondiskusagecontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	controllerName = "RevisionDiskUsageController"
+
+	// DefaultDiskUsageThresholdBytes is the size of the retained revision directories on a single node above which
+	// an out-of-cycle prune is requested.
+	DefaultDiskUsageThresholdBytes = int64(256 * 1024 * 1024)
+
+	prunerPodPrefix         = "revision-pruner-"
+	pressurePrunerPodPrefix = "revision-pruner-pressure-"
+	protectedRevisionsFlag  = "--protected-revisions="
+)
+
+var revisionDiskUsageMetric = metrics.NewGaugeVec(&metrics.GaugeOpts{
+	Namespace:      "openshift",
+	Subsystem:      "kube_controller_manager",
+	Name:           "static_pod_revisions_disk_usage_bytes",
+	Help:           "Size of the kube-controller-manager revision directories retained on a node, as reported by the last pruner pod",
+	StabilityLevel: metrics.ALPHA,
+}, []string{"node"})
+
+func init() {
+	legacyregistry.MustRegister(revisionDiskUsageMetric)
+}
+
+// RevisionDiskUsageController collects the disk usage reports of the pruner pods, exposes them as a per-node metric and
+// prunes every revision of a node that is not in use when the report exceeds the threshold. The regular prune
+// controller keeps spec.succeededRevisionLimit/spec.failedRevisionLimit revisions around, which is more than small
+// root disks can afford.
+type RevisionDiskUsageController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	podLister      corev1listers.PodLister
+	podClient      corev1client.PodsGetter
+	thresholdBytes int64
+
+	lock sync.Mutex
+	// reportedNodes are the nodes the metric currently has a value for.
+	reportedNodes sets.Set[string]
+}
+
+func NewRevisionDiskUsageController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	podClient corev1client.PodsGetter,
+	thresholdBytes int64,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RevisionDiskUsageController{
+		operatorClient: operatorClient,
+		podLister:      kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Lister(),
+		podClient:      podClient,
+		thresholdBytes: thresholdBytes,
+		reportedNodes:  sets.New[string](),
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Informer(),
+	).ResyncEvery(10*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("revision-disk-usage-controller"))
+}
+
+func (c *RevisionDiskUsageController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	_, operatorStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+
+	prunerPods, err := c.podLister.Pods(operatorclient.TargetNamespace).List(labels.SelectorFromSet(labels.Set{"app": "pruner"}))
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var errs []error
+	nodes := sets.New[string]()
+	for _, nodeStatus := range operatorStatus.NodeStatuses {
+		nodes.Insert(nodeStatus.NodeName)
+
+		report, err := latestReport(prunerPods, nodeStatus.NodeName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if report == nil {
+			continue
+		}
+		revisionDiskUsageMetric.WithLabelValues(nodeStatus.NodeName).Set(float64(report.TotalBytes))
+		c.reportedNodes.Insert(nodeStatus.NodeName)
+
+		if report.TotalBytes <= c.thresholdBytes {
+			continue
+		}
+		if err := c.ensurePressurePrunerPod(ctx, syncCtx.Recorder(), prunerPods, operatorStatus.LatestAvailableRevision, nodeStatus, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, node := range sets.List(c.reportedNodes.Difference(nodes)) {
+		revisionDiskUsageMetric.DeleteLabelValues(node)
+		c.reportedNodes.Delete(node)
+	}
+
+	return v1helpers.NewMultiLineAggregate(errs)
+}
+
+// ensurePressurePrunerPod creates a copy of the node's pruner pod for the latest available revision that only protects the
+// revisions in use. The pod name is derived from the revision, so at most one out-of-cycle prune runs per node and
+// revision.
+func (c *RevisionDiskUsageController) ensurePressurePrunerPod(ctx context.Context, recorder events.Recorder, prunerPods []*corev1.Pod, latestAvailableRevision int32, nodeStatus operatorv1.NodeStatus, report *DiskUsageReport) error {
+	name := fmt.Sprintf("%s%d-%s", pressurePrunerPodPrefix, latestAvailableRevision, nodeStatus.NodeName)
+	var template *corev1.Pod
+	for _, pod := range prunerPods {
+		switch pod.Name {
+		case name:
+			// already requested
+			return nil
+		case fmt.Sprintf("%s%d-%s", prunerPodPrefix, latestAvailableRevision, nodeStatus.NodeName):
+			template = pod
+		}
+	}
+	if template == nil || template.Status.Phase != corev1.PodSucceeded {
+		// let the regular pruner finish first, it is the one we copy the command from
+		return nil
+	}
+
+	protected := sets.New[int32](latestAvailableRevision, nodeStatus.CurrentRevision, nodeStatus.TargetRevision).Delete(0)
+	protectedRevisions := make([]string, 0, protected.Len())
+	for _, revision := range sets.List(protected) {
+		protectedRevisions = append(protectedRevisions, strconv.Itoa(int(revision)))
+	}
+
+	template = template.DeepCopy()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       template.Namespace,
+			Labels:          template.Labels,
+			OwnerReferences: template.OwnerReferences,
+		},
+		Spec: template.Spec,
+	}
+	for i := range pod.Spec.Containers {
+		for j, arg := range pod.Spec.Containers[i].Args {
+			if strings.HasPrefix(arg, protectedRevisionsFlag) {
+				pod.Spec.Containers[i].Args[j] = protectedRevisionsFlag + strings.Join(protectedRevisions, ",")
+			}
+		}
+	}
+
+	if _, err := c.podClient.Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to request an out-of-cycle prune on node %s: %w", nodeStatus.NodeName, err)
+	}
+	klog.V(2).Infof("Revision directories on node %s use %d bytes, pruning all revisions but %s", nodeStatus.NodeName, report.TotalBytes, strings.Join(protectedRevisions, ","))
+	recorder.Eventf("RevisionPruneRequested", "Revision directories on node %s use %d bytes (threshold %d), pruning all revisions but %s", nodeStatus.NodeName, report.TotalBytes, c.thresholdBytes, strings.Join(protectedRevisions, ","))
+	return nil
+}
+
+// latestReport returns the disk usage report of the pruner pod that finished last on the given node.
+func latestReport(prunerPods []*corev1.Pod, nodeName string) (*DiskUsageReport, error) {
+	type finishedPod struct {
+		pod        *corev1.Pod
+		finishedAt metav1.Time
+		message    string
+	}
+	var finished []finishedPod
+	for _, pod := range prunerPods {
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if terminated := containerStatus.State.Terminated; terminated != nil && len(terminated.Message) > 0 {
+				finished = append(finished, finishedPod{pod: pod, finishedAt: terminated.FinishedAt, message: terminated.Message})
+			}
+		}
+	}
+	if len(finished) == 0 {
+		return nil, nil
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[j].finishedAt.Before(&finished[i].finishedAt) })
+
+	report, err := ParseDiskUsageReport(finished[0].message)
+	if err != nil {
+		return nil, fmt.Errorf("pod/%s: %w", finished[0].pod.Name, err)
+	}
+	return report, nil
+}
@@ -0,0 +1,225 @@
+package revisiondiskusagecontroller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func prunerPod(name, nodeName string, finishedAt time.Time, report *DiskUsageReport) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: operatorclient.TargetNamespace,
+			Labels:    map[string]string{"app": "pruner"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{{
+				Name:    "pruner",
+				Command: []string{"cluster-kube-controller-manager-operator", "prune"},
+				Args: []string{
+					"-v=4",
+					"--max-eligible-revision=7",
+					"--protected-revisions=3,4,5,6,7",
+					"--resource-dir=/etc/kubernetes/static-pod-resources",
+					"--cert-dir=kube-controller-manager-certs",
+					"--static-pod-name=kube-controller-manager-pod",
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	if report != nil {
+		message, _ := json.Marshal(report)
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "pruner",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				FinishedAt: metav1.NewTime(finishedAt),
+				Message:    string(message),
+			}},
+		}}
+	}
+	return pod
+}
+
+func TestRevisionDiskUsageController(t *testing.T) {
+	now := time.Now()
+	large := &DiskUsageReport{Revisions: map[string]int64{"3": 200, "4": 200, "5": 200, "6": 200, "7": 200}, TotalBytes: 1000}
+	small := &DiskUsageReport{Revisions: map[string]int64{"6": 200, "7": 200}, TotalBytes: 400}
+
+	tests := []struct {
+		name                string
+		pods                []*corev1.Pod
+		expectedMetric      float64
+		expectedPressurePod bool
+	}{
+		{
+			name:           "usage below threshold",
+			pods:           []*corev1.Pod{prunerPod("revision-pruner-7-master-0", "master-0", now, small)},
+			expectedMetric: 400,
+		},
+		{
+			name:                "usage above threshold triggers a prune of the unused revisions",
+			pods:                []*corev1.Pod{prunerPod("revision-pruner-7-master-0", "master-0", now, large)},
+			expectedMetric:      1000,
+			expectedPressurePod: true,
+		},
+		{
+			name: "the latest report wins",
+			pods: []*corev1.Pod{
+				prunerPod("revision-pruner-6-master-0", "master-0", now.Add(-time.Hour), large),
+				prunerPod("revision-pruner-7-master-0", "master-0", now, small),
+			},
+			expectedMetric: 400,
+		},
+		{
+			name: "a prune already requested for the revision is not requested again",
+			pods: []*corev1.Pod{
+				prunerPod("revision-pruner-7-master-0", "master-0", now.Add(-time.Minute), large),
+				prunerPod("revision-pruner-pressure-7-master-0", "master-0", now, large),
+			},
+			expectedMetric: 1000,
+		},
+		{
+			name: "the regular pruner for the latest revision has to finish first",
+			pods: []*corev1.Pod{
+				prunerPod("revision-pruner-6-master-0", "master-0", now, large),
+			},
+			expectedMetric: 1000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range test.pods {
+				podIndexer.Add(pod)
+			}
+			kubeClient := fake.NewSimpleClientset()
+
+			c := &RevisionDiskUsageController{
+				operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+					&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+					&operatorv1.StaticPodOperatorStatus{
+						LatestAvailableRevision: 7,
+						NodeStatuses: []operatorv1.NodeStatus{
+							{NodeName: "master-0", CurrentRevision: 6, TargetRevision: 7},
+						},
+					},
+					nil,
+					nil,
+				),
+				podLister:      corev1listers.NewPodLister(podIndexer),
+				podClient:      kubeClient.CoreV1(),
+				thresholdBytes: 500,
+				reportedNodes:  sets.New[string](),
+			}
+
+			if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := testutil.GetGaugeMetricValue(revisionDiskUsageMetric.WithLabelValues("master-0"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.expectedMetric {
+				t.Errorf("expected metric value %v, got %v", test.expectedMetric, value)
+			}
+
+			var created []*corev1.Pod
+			for _, action := range kubeClient.Actions() {
+				if createAction, ok := action.(clienttesting.CreateAction); ok {
+					created = append(created, createAction.GetObject().(*corev1.Pod))
+				}
+			}
+			if !test.expectedPressurePod {
+				if len(created) > 0 {
+					t.Fatalf("expected no out-of-cycle prune, got %v", created[0].Name)
+				}
+				return
+			}
+			if len(created) != 1 {
+				t.Fatalf("expected one out-of-cycle prune, got %d", len(created))
+			}
+			if created[0].Name != "revision-pruner-pressure-7-master-0" || created[0].Spec.NodeName != "master-0" {
+				t.Errorf("unexpected pruner pod %s on node %s", created[0].Name, created[0].Spec.NodeName)
+			}
+			expectedArgs := []string{
+				"-v=4",
+				"--max-eligible-revision=7",
+				"--protected-revisions=6,7",
+				"--resource-dir=/etc/kubernetes/static-pod-resources",
+				"--cert-dir=kube-controller-manager-certs",
+				"--static-pod-name=kube-controller-manager-pod",
+			}
+			if !reflect.DeepEqual(expectedArgs, created[0].Spec.Containers[0].Args) {
+				t.Errorf("expected args %v, got %v", expectedArgs, created[0].Spec.Containers[0].Args)
+			}
+		})
+	}
+}
+
+func TestNewDiskUsageReport(t *testing.T) {
+	resourceDir := t.TempDir()
+	for path, size := range map[string]int{
+		"kube-controller-manager-pod-3/configmaps/config/config.yaml":         100,
+		"kube-controller-manager-pod-3/secrets/service-account-private-key/k": 20,
+		"kube-controller-manager-pod-4/kube-controller-manager-pod.yaml":      50,
+		"kube-controller-manager-certs/secrets/csr-signer/tls.crt":            1000,
+		"kube-apiserver-pod-3/kube-apiserver-pod.yaml":                        1000,
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(resourceDir, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(resourceDir, path), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := NewDiskUsageReport(resourceDir, "kube-controller-manager-pod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DiskUsageReport{Revisions: map[string]int64{"3": 120, "4": 50}, TotalBytes: 170}
+	if !reflect.DeepEqual(expected, report) {
+		t.Errorf("expected %#v, got %#v", expected, report)
+	}
+
+	terminationMessage := filepath.Join(t.TempDir(), "termination-log")
+	if err := report.Write(terminationMessage); err != nil {
+		t.Fatal(err)
+	}
+	message, err := os.ReadFile(terminationMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseDiskUsageReport(string(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, parsed) {
+		t.Errorf("expected %#v after a round trip, got %#v", expected, parsed)
+	}
+}
@@ -0,0 +1,197 @@
+package revisionratecontroller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	controllerName = "RevisionRateController"
+	conditionType  = "RevisionRateDegraded"
+
+	// DefaultMaxRevisions is how many non-urgent revisions may be created within DefaultWindow.
+	DefaultMaxRevisions = 6
+	// DefaultWindow is the window revisions are counted in.
+	DefaultWindow = time.Hour
+
+	// MaxRevisionsOverrideField of the unsupportedConfigOverrides overrides DefaultMaxRevisions.
+	MaxRevisionsOverrideField = "maxRevisionsPerHour"
+	// AcknowledgeAnnotation on the KubeControllerManager CR acknowledges the revisions up to and including the
+	// revision it is set to, they are no longer counted.
+	AcknowledgeAnnotation = "operator.openshift.io/acknowledge-revision-thrash"
+
+	revisionStatusPrefix = "revision-status-"
+	// lastTriggers is how many trigger reasons the condition lists
+	lastTriggers = 3
+)
+
+func init() {
+	overrides.Register(MaxRevisionsOverrideField)
+}
+
+// revision is a revision as recorded in its revision-status configmap.
+type revision struct {
+	number    int
+	createdAt time.Time
+	reason    string
+}
+
+// RevisionRateController reports a Degraded condition when more revisions are created within the window than
+// allowed, for example an observer that flips a value on every sync. Every revision copies all revisioned
+// configmaps and secrets, a thrashing operator fills etcd long before the pruner catches up.
+//
+// Revisions triggered only by secrets, which is how certificate rotation reaches the operand, are urgent and not
+// counted.
+type RevisionRateController struct {
+	operatorClient  v1helpers.StaticPodOperatorClient
+	operatorLister  cache.GenericLister
+	configMapLister corev1listers.ConfigMapNamespaceLister
+
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewRevisionRateController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	operatorLister cache.GenericLister,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RevisionRateController{
+		operatorClient:  operatorClient,
+		operatorLister:  operatorLister,
+		configMapLister: kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+		window:          DefaultWindow,
+		now:             time.Now,
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Informer(),
+	).ResyncEvery(5*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("revision-rate-controller"))
+}
+
+func (c *RevisionRateController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	maxRevisions, acknowledged, err := c.limits()
+	if err != nil {
+		return err
+	}
+	configMaps, err := c.configMapLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	since := c.now().Add(-c.window)
+	var counted []revision
+	for _, configMap := range configMaps {
+		r, ok := revisionFor(configMap)
+		if !ok || r.number <= acknowledged || r.createdAt.Before(since) || urgent(r.reason) {
+			continue
+		}
+		counted = append(counted, r)
+	}
+	sort.Slice(counted, func(i, j int) bool { return counted[i].number > counted[j].number })
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+	}
+	if len(counted) > maxRevisions {
+		triggers := make([]string, 0, lastTriggers)
+		for _, r := range counted[:min(lastTriggers, len(counted))] {
+			triggers = append(triggers, fmt.Sprintf("revision %d: %s", r.number, r.reason))
+		}
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "RevisionThrash"
+		condition.Message = fmt.Sprintf("%d revisions were created within %s, more than the %d allowed. The last triggers were:\n%s\nFix what keeps changing, then set the %s annotation to %d to acknowledge.",
+			len(counted), c.window, maxRevisions, strings.Join(triggers, "\n"), AcknowledgeAnnotation, counted[0].number)
+	}
+
+	_, updated, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	if err != nil {
+		return err
+	}
+	if updated && condition.Status == operatorv1.ConditionTrue {
+		syncCtx.Recorder().Warningf("RevisionThrash", "%d revisions were created within %s, more than the %d allowed", len(counted), c.window, maxRevisions)
+	}
+	return nil
+}
+
+// limits returns the allowed number of revisions and the acknowledged revision set on the operator.
+func (c *RevisionRateController) limits() (int, int, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return 0, 0, err
+	}
+	operatorMeta, err := meta.Accessor(operator)
+	if err != nil {
+		return 0, 0, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	maxRevisions := DefaultMaxRevisions
+	value, ok, err := overrides.String(unsupportedConfigOverrides, MaxRevisionsOverrideField)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ok {
+		if maxRevisions, err = validation.IntBetween(overrides.Path(MaxRevisionsOverrideField), value, 1, math.MaxInt); err != nil {
+			return 0, 0, err
+		}
+	}
+	var acknowledged int
+	if value, ok := operatorMeta.GetAnnotations()[AcknowledgeAnnotation]; ok {
+		if acknowledged, err = validation.IntBetween(validation.AnnotationPath(AcknowledgeAnnotation), value, 0, math.MaxInt); err != nil {
+			return 0, 0, err
+		}
+	}
+	return maxRevisions, acknowledged, nil
+}
+
+func revisionFor(configMap *corev1.ConfigMap) (revision, bool) {
+	if !strings.HasPrefix(configMap.Name, revisionStatusPrefix) {
+		return revision{}, false
+	}
+	number, err := strconv.Atoi(configMap.Data["revision"])
+	if err != nil {
+		return revision{}, false
+	}
+	return revision{number: number, createdAt: configMap.CreationTimestamp.Time, reason: configMap.Data["reason"]}, true
+}
+
+// urgent returns whether a revision was triggered only by secrets. The revision controller joins the changes,
+// e.g. "required secret/serving-cert has changed,required configmap/config has changed".
+func urgent(reason string) bool {
+	if len(reason) == 0 {
+		return false
+	}
+	for _, change := range strings.Split(reason, ",") {
+		if !strings.Contains(change, " secret/") {
+			return false
+		}
+	}
+	return true
+}
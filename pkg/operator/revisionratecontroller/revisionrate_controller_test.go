@@ -0,0 +1,195 @@
+package revisionratecontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func revisionStatus(number int, createdAt time.Time, reason string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("revision-status-%d", number),
+			Namespace:         operatorclient.TargetNamespace,
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Data: map[string]string{"revision": fmt.Sprint(number), "reason": reason},
+	}
+}
+
+// thrash returns count revisions created a minute apart within the last hour, starting at revision first.
+func thrash(first, count int, reason string) []*corev1.ConfigMap {
+	var configMaps []*corev1.ConfigMap
+	for i := 0; i < count; i++ {
+		configMaps = append(configMaps, revisionStatus(first+i, now.Add(-time.Duration(count-i)*time.Minute), fmt.Sprintf("%s %d", reason, first+i)))
+	}
+	return configMaps
+}
+
+func newTestController(t *testing.T, annotations map[string]string, unsupportedConfigOverrides map[string]interface{}, configMaps ...*corev1.ConfigMap) *RevisionRateController {
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	operator.SetAnnotations(annotations)
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedMap(operator.Object, unsupportedConfigOverrides, "spec", "unsupportedConfigOverrides"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operatorIndexer.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, configMap := range configMaps {
+		if err := configMapIndexer.Add(configMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &RevisionRateController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		operatorLister:  cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		configMapLister: corev1listers.NewConfigMapLister(configMapIndexer).ConfigMaps(operatorclient.TargetNamespace),
+		window:          DefaultWindow,
+		now:             func() time.Time { return now },
+	}
+}
+
+func syncCondition(t *testing.T, c *RevisionRateController, recorder events.Recorder) *operatorv1.OperatorCondition {
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, recorder)); err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+	if condition == nil {
+		t.Fatalf("expected the %s condition to be set", conditionType)
+	}
+	return condition
+}
+
+func TestRevisionThrashIsDegraded(t *testing.T) {
+	recorder := events.NewInMemoryRecorder("test")
+	c := newTestController(t, nil, nil, thrash(3, 7, "required configmap/config has changed")...)
+
+	condition := syncCondition(t, c, recorder)
+	if condition.Status != operatorv1.ConditionTrue || condition.Reason != "RevisionThrash" {
+		t.Fatalf("expected the thrash to be degraded, got %v", condition)
+	}
+	for _, expected := range []string{
+		"7 revisions were created within 1h0m0s, more than the 6 allowed",
+		"revision 9: required configmap/config has changed 9\nrevision 8: required configmap/config has changed 8\nrevision 7: required configmap/config has changed 7\n",
+		AcknowledgeAnnotation + " annotation to 9",
+	} {
+		if !strings.Contains(condition.Message, expected) {
+			t.Errorf("expected the message to contain %q, got %q", expected, condition.Message)
+		}
+	}
+	if strings.Contains(condition.Message, "revision 6:") {
+		t.Errorf("expected only the last three triggers, got %q", condition.Message)
+	}
+
+	var warnings int
+	for _, event := range recorder.Events() {
+		if event.Reason == "RevisionThrash" {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("expected 1 RevisionThrash event, got %d", warnings)
+	}
+	// a condition that is already reported is not reported again
+	syncCondition(t, c, recorder)
+	if len(recorder.Events()) != warnings {
+		t.Errorf("expected no further events, got %v", recorder.Events())
+	}
+}
+
+func TestRevisionsWithinTheLimitAreNotDegraded(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		overrides   map[string]interface{}
+		configMaps  []*corev1.ConfigMap
+	}{
+		{
+			name:       "at the limit",
+			configMaps: thrash(3, 6, "required configmap/config has changed"),
+		},
+		{
+			name:       "urgent certificate rotation",
+			configMaps: thrash(3, 7, "required secret/serving-cert has changed,required secret/csr-signer has changed"),
+		},
+		{
+			name:       "outside of the window",
+			configMaps: append(thrash(3, 6, "required configmap/config has changed"), revisionStatus(2, now.Add(-61*time.Minute), "required configmap/config has changed")),
+		},
+		{
+			name:        "acknowledged",
+			annotations: map[string]string{AcknowledgeAnnotation: "9"},
+			configMaps:  thrash(3, 7, "required configmap/config has changed"),
+		},
+		{
+			name:       "raised limit",
+			overrides:  map[string]interface{}{MaxRevisionsOverrideField: int64(10)},
+			configMaps: thrash(3, 7, "required configmap/config has changed"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestController(t, test.annotations, test.overrides, test.configMaps...)
+			if condition := syncCondition(t, c, events.NewInMemoryRecorder("test")); condition.Status != operatorv1.ConditionFalse {
+				t.Errorf("expected no thrash, got %v", condition)
+			}
+		})
+	}
+}
+
+func TestRevisionThrashClearsAfterTheWindow(t *testing.T) {
+	c := newTestController(t, nil, nil, thrash(3, 7, "required secret/serving-cert has changed,required configmap/config has changed")...)
+	if condition := syncCondition(t, c, events.NewInMemoryRecorder("test")); condition.Status != operatorv1.ConditionTrue {
+		t.Fatalf("expected revisions that also change configmaps to count, got %v", condition)
+	}
+
+	c.now = func() time.Time { return now.Add(54 * time.Minute) }
+	if condition := syncCondition(t, c, events.NewInMemoryRecorder("test")); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the thrash to clear once the oldest revision left the window, got %v", condition)
+	}
+}
+
+func TestInvalidLimits(t *testing.T) {
+	c := newTestController(t, nil, map[string]interface{}{MaxRevisionsOverrideField: int64(0)})
+	expectedErr := `spec.unsupportedConfigOverrides.maxRevisionsPerHour: "0" must be an integer of at least 1`
+	if _, _, err := c.limits(); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected %q, got %v", expectedErr, err)
+	}
+
+	c = newTestController(t, map[string]string{AcknowledgeAnnotation: "latest"}, nil)
+	expectedErr = `metadata.annotations[operator.openshift.io/acknowledge-revision-thrash]: "latest" must be an integer of at least 0`
+	if _, _, err := c.limits(); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected %q, got %v", expectedErr, err)
+	}
+}
@@ -0,0 +1,203 @@
+package rolloutordercontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const controllerName = "RolloutOrderController"
+
+// zoneLabels are the node labels naming the failure domain of a node, the deprecated one is read for nodes that
+// were labelled before it was replaced.
+var zoneLabels = []string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone}
+
+// RolloutOrderController orders the node statuses of the operator so that consecutive revision installs touch
+// different failure domains. Once no node is progressing the installer controller starts a new revision with the
+// first one of the nodes at the oldest revision, which is the order of the node statuses. Rolling two nodes of the
+// same zone back-to-back would leave the kube-controller-manager leadership to that zone in between.
+//
+// The order is only changed between rollouts, while no node is progressing. Without zone labels on every node the
+// order is left alone.
+type RolloutOrderController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	nodeLister     corev1listers.NodeLister
+
+	// loggedRevision is the latest revision whose rollout order was logged
+	loggedRevision int32
+}
+
+func NewRolloutOrderController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &RolloutOrderController{
+		operatorClient: operatorClient,
+		nodeLister:     kubeInformersForNamespaces.InformersFor("").Core().V1().Nodes().Lister(),
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor("").Core().V1().Nodes().Informer(),
+	).ResyncEvery(10*time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("rollout-order-controller"))
+}
+
+func (c *RolloutOrderController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	_, originalStatus, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	for _, nodeStatus := range originalStatus.NodeStatuses {
+		if nodeStatus.TargetRevision != 0 {
+			return nil
+		}
+	}
+
+	zones, err := c.zonesOf(originalStatus.NodeStatuses)
+	if err != nil {
+		return err
+	}
+	order, reason := rolloutOrder(originalStatus.NodeStatuses, zones)
+	if c.loggedRevision != originalStatus.LatestAvailableRevision {
+		klog.Infof("Revisions after %d will be rolled out to nodes %s: %s", originalStatus.LatestAvailableRevision, strings.Join(order, ", "), reason)
+		c.loggedRevision = originalStatus.LatestAvailableRevision
+	}
+	if equalOrder(originalStatus.NodeStatuses, order) {
+		return nil
+	}
+
+	_, updated, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, func(status *operatorv1.StaticPodOperatorStatus) error {
+		for _, nodeStatus := range status.NodeStatuses {
+			// a rollout started meanwhile
+			if nodeStatus.TargetRevision != 0 {
+				return nil
+			}
+		}
+		status.NodeStatuses = reordered(status.NodeStatuses, order)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("RolloutOrderChanged", "Revisions are rolled out to nodes %s: %s", strings.Join(order, ", "), reason)
+	}
+	return nil
+}
+
+// zonesOf returns the zone of every node. A node without a zone label, or not found, has none.
+func (c *RolloutOrderController) zonesOf(nodeStatuses []operatorv1.NodeStatus) (map[string]string, error) {
+	zones := map[string]string{}
+	for _, nodeStatus := range nodeStatuses {
+		node, err := c.nodeLister.Get(nodeStatus.NodeName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range zoneLabels {
+			if zone := node.Labels[label]; len(zone) > 0 {
+				zones[nodeStatus.NodeName] = zone
+				break
+			}
+		}
+	}
+	return zones, nil
+}
+
+// rolloutOrder returns the node names in the order revisions should be rolled out and the reason for it. Every next
+// node is taken from the zone with the most nodes left that differs from the zone of the previous node, ties are
+// broken by the current order. Within a zone the current order is kept.
+func rolloutOrder(nodeStatuses []operatorv1.NodeStatus, zones map[string]string) ([]string, string) {
+	current := make([]string, 0, len(nodeStatuses))
+	for _, nodeStatus := range nodeStatuses {
+		current = append(current, nodeStatus.NodeName)
+	}
+	for _, name := range current {
+		if _, ok := zones[name]; !ok {
+			return current, fmt.Sprintf("node %s has no zone label, keeping the current order", name)
+		}
+	}
+
+	var zoneOrder []string
+	nodesOfZone := map[string][]string{}
+	for _, name := range current {
+		zone := zones[name]
+		if _, ok := nodesOfZone[zone]; !ok {
+			zoneOrder = append(zoneOrder, zone)
+		}
+		nodesOfZone[zone] = append(nodesOfZone[zone], name)
+	}
+	if len(zoneOrder) < 2 {
+		return current, "all nodes are in one zone, keeping the current order"
+	}
+
+	order := make([]string, 0, len(current))
+	var previous string
+	for len(order) < len(current) {
+		next := ""
+		for _, zone := range zoneOrder {
+			if len(nodesOfZone[zone]) == 0 || zone == previous {
+				continue
+			}
+			if len(next) == 0 || len(nodesOfZone[zone]) > len(nodesOfZone[next]) {
+				next = zone
+			}
+		}
+		// only the previous zone has nodes left
+		if len(next) == 0 {
+			next = previous
+		}
+		order = append(order, nodesOfZone[next][0])
+		nodesOfZone[next] = nodesOfZone[next][1:]
+		previous = next
+	}
+	return order, fmt.Sprintf("alternating zones %s", strings.Join(zoneOrder, ", "))
+}
+
+func equalOrder(nodeStatuses []operatorv1.NodeStatus, order []string) bool {
+	if len(nodeStatuses) != len(order) {
+		return false
+	}
+	for i := range nodeStatuses {
+		if nodeStatuses[i].NodeName != order[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reordered returns nodeStatuses in order. Nodes missing from order, added since it was computed, are kept at the
+// end.
+func reordered(nodeStatuses []operatorv1.NodeStatus, order []string) []operatorv1.NodeStatus {
+	byName := map[string]operatorv1.NodeStatus{}
+	for _, nodeStatus := range nodeStatuses {
+		byName[nodeStatus.NodeName] = nodeStatus
+	}
+	ret := make([]operatorv1.NodeStatus, 0, len(nodeStatuses))
+	for _, name := range order {
+		if nodeStatus, ok := byName[name]; ok {
+			ret = append(ret, nodeStatus)
+			delete(byName, name)
+		}
+	}
+	for _, nodeStatus := range nodeStatuses {
+		if _, ok := byName[nodeStatus.NodeName]; ok {
+			ret = append(ret, nodeStatus)
+		}
+	}
+	return ret
+}
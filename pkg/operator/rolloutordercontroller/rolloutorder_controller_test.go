@@ -0,0 +1,132 @@
+package rolloutordercontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+type testNode struct {
+	name           string
+	zone           string
+	targetRevision int32
+}
+
+func TestRolloutOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    []testNode
+		expected []string
+	}{
+		{
+			name: "three zones",
+			nodes: []testNode{
+				{name: "master-0", zone: "us-east-1a"},
+				{name: "master-1", zone: "us-east-1a"},
+				{name: "master-2", zone: "us-east-1b"},
+				{name: "master-3", zone: "us-east-1b"},
+				{name: "master-4", zone: "us-east-1c"},
+				{name: "master-5", zone: "us-east-1c"},
+			},
+			expected: []string{"master-0", "master-2", "master-4", "master-1", "master-3", "master-5"},
+		},
+		{
+			name: "stretched over two zones",
+			nodes: []testNode{
+				{name: "master-0", zone: "us-east-1a"},
+				{name: "master-1", zone: "us-east-1a"},
+				{name: "master-2", zone: "us-east-1b"},
+			},
+			expected: []string{"master-0", "master-2", "master-1"},
+		},
+		{
+			name: "unbalanced zones",
+			nodes: []testNode{
+				{name: "master-0", zone: "us-east-1b"},
+				{name: "master-1", zone: "us-east-1a"},
+				{name: "master-2", zone: "us-east-1a"},
+				{name: "master-3", zone: "us-east-1a"},
+			},
+			expected: []string{"master-1", "master-0", "master-2", "master-3"},
+		},
+		{
+			name: "unlabeled",
+			nodes: []testNode{
+				{name: "master-0"},
+				{name: "master-1"},
+				{name: "master-2"},
+			},
+			expected: []string{"master-0", "master-1", "master-2"},
+		},
+		{
+			name: "partially labeled",
+			nodes: []testNode{
+				{name: "master-0", zone: "us-east-1a"},
+				{name: "master-1", zone: "us-east-1a"},
+				{name: "master-2"},
+			},
+			expected: []string{"master-0", "master-1", "master-2"},
+		},
+		{
+			name: "rollout in progress",
+			nodes: []testNode{
+				{name: "master-0", zone: "us-east-1a"},
+				{name: "master-1", zone: "us-east-1a", targetRevision: 4},
+				{name: "master-2", zone: "us-east-1b"},
+			},
+			expected: []string{"master-0", "master-1", "master-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			var nodeStatuses []operatorv1.NodeStatus
+			for _, node := range test.nodes {
+				labels := map[string]string{}
+				if len(node.zone) > 0 {
+					labels[corev1.LabelTopologyZone] = node.zone
+				}
+				if err := nodeIndexer.Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: node.name, Labels: labels}}); err != nil {
+					t.Fatal(err)
+				}
+				nodeStatuses = append(nodeStatuses, operatorv1.NodeStatus{NodeName: node.name, CurrentRevision: 3, TargetRevision: node.targetRevision})
+			}
+
+			c := &RolloutOrderController{
+				operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+					&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+					&operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: 3, NodeStatuses: nodeStatuses},
+					nil,
+					nil,
+				),
+				nodeLister: corev1listers.NewNodeLister(nodeIndexer),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, nodeStatus := range status.NodeStatuses {
+				actual = append(actual, nodeStatus.NodeName)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected the order %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package signerexpirycontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/cert"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	controllerName = "SignerExpiryController"
+	conditionType  = "CSRSignerExpiryDegraded"
+
+	// WindowAnnotation on the KubeControllerManager CR sets how long before its expiry the csr-signer degrades the
+	// operator, e.g. "10m" in a test environment. Without it the window is 20% of the lifetime of the certificate,
+	// but at least three rotation days.
+	WindowAnnotation = "operator.openshift.io/csr-signer-expiry-window"
+
+	signerSecret = "csr-signer"
+
+	// defaultWindowRatio is the share of its lifetime the csr-signer has left when it degrades the operator. It is
+	// rotated at half of its lifetime, a csr-signer this close to its expiry is not rotated.
+	defaultWindowRatio = 0.2
+	// defaultMinWindowDays is the least number of rotation days of the default window.
+	defaultMinWindowDays = 3
+	defaultRotationDay   = 24 * time.Hour
+)
+
+// SignerExpiryController degrades the operator when the csr-signer comes close to its expiry without being rotated,
+// e.g. after the cluster was turned off for longer than the rotation allows for. It reads the certificate the secret
+// contains, a rotation that is stuck leaves the old one in place. An expired csr-signer stops the issuance of the
+// kubelet client and serving certificates, the nodes cannot join or renew their certificates anymore.
+type SignerExpiryController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	operatorLister cache.GenericLister
+	secretLister   corev1listers.SecretNamespaceLister
+
+	minWindow time.Duration
+	now       func() time.Time
+}
+
+// NewSignerExpiryController checks the csr-signer in the operator namespace. day is the base of the certificate
+// rotation, zero is the default of 24h.
+func NewSignerExpiryController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	operatorLister cache.GenericLister,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	day time.Duration,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	if day == 0 {
+		day = defaultRotationDay
+	}
+	c := &SignerExpiryController{
+		operatorClient: operatorClient,
+		operatorLister: operatorLister,
+		secretLister:   kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Lister().Secrets(operatorclient.OperatorNamespace),
+		minWindow:      defaultMinWindowDays * day,
+		now:            time.Now,
+	}
+
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.OperatorNamespace).Core().V1().Secrets().Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("signer-expiry-controller"))
+}
+
+func (c *SignerExpiryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	window, err := c.window()
+	if err != nil {
+		return err
+	}
+
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	secret, err := c.secretLister.Get(signerSecret)
+	switch {
+	case apierrors.IsNotFound(err):
+		// the cert rotation controller creates it and reports when it cannot
+		condition.Reason = "NoCertificate"
+	case err != nil:
+		return err
+	default:
+		certificates, err := cert.ParseCertsPEM(secret.Data["tls.crt"])
+		if err != nil {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = "InvalidCertificate"
+			condition.Message = fmt.Sprintf("The certificate of secret %s -n %s cannot be parsed: %v", signerSecret, operatorclient.OperatorNamespace, err)
+			break
+		}
+		signer := certificates[0]
+		if window == 0 {
+			window = max(time.Duration(defaultWindowRatio*float64(signer.NotAfter.Sub(signer.NotBefore))), c.minWindow)
+		}
+		if remaining := signer.NotAfter.Sub(c.now()); remaining < window {
+			condition.Status = operatorv1.ConditionTrue
+			condition.Reason = "CertificateExpiring"
+			condition.Message = fmt.Sprintf("The certificate of secret %s -n %s expires at %s, within %s, and was not rotated. The nodes cannot renew their certificates once it expired.",
+				signerSecret, operatorclient.OperatorNamespace, signer.NotAfter.UTC().Format(time.RFC3339), window.Round(time.Second))
+			if remaining <= 0 {
+				condition.Reason = "CertificateExpired"
+				condition.Message = fmt.Sprintf("The certificate of secret %s -n %s expired at %s and was not rotated. The nodes cannot renew their certificates.",
+					signerSecret, operatorclient.OperatorNamespace, signer.NotAfter.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+
+	_, updated, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	if err != nil {
+		return err
+	}
+	if updated && condition.Status == operatorv1.ConditionTrue {
+		syncCtx.Recorder().Warningf(condition.Reason, "%s", condition.Message)
+	}
+	return nil
+}
+
+// window returns the window set by WindowAnnotation, zero without it.
+func (c *SignerExpiryController) window() (time.Duration, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	operatorMeta, err := meta.Accessor(operator)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := operatorMeta.GetAnnotations()[WindowAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	return validation.DurationBetween(validation.AnnotationPath(WindowAnnotation), value, time.Second, 0)
+}
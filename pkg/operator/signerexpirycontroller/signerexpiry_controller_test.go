@@ -0,0 +1,166 @@
+package signerexpirycontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+type signerExpiryTest struct {
+	t          *testing.T
+	controller *SignerExpiryController
+	secrets    cache.Indexer
+	now        time.Time
+	recorder   events.InMemoryRecorder
+}
+
+func newSignerExpiryTest(t *testing.T, annotations map[string]string) *signerExpiryTest {
+	operator := &unstructured.Unstructured{}
+	operator.SetName("cluster")
+	operator.SetAnnotations(annotations)
+	operators := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operators.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	test := &signerExpiryTest{
+		t:        t,
+		secrets:  cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		recorder: events.NewInMemoryRecorder("test"),
+	}
+	test.controller = &SignerExpiryController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			&operatorv1.StaticPodOperatorStatus{},
+			nil,
+			nil,
+		),
+		operatorLister: cache.NewGenericLister(operators, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		secretLister:   corev1listers.NewSecretLister(test.secrets).Secrets(operatorclient.OperatorNamespace),
+		minWindow:      defaultMinWindowDays * defaultRotationDay,
+		now:            func() time.Time { return test.now },
+	}
+	return test
+}
+
+// setSigner stores a new csr-signer valid for lifetime and returns its NotAfter.
+func (s *signerExpiryTest) setSigner(lifetime time.Duration) time.Time {
+	s.t.Helper()
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration("kube-csr-signer", lifetime)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	certPEM, _, err := ca.GetPEMBytes()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.setSignerPEM(certPEM)
+	return ca.Certs[0].NotAfter
+}
+
+func (s *signerExpiryTest) setSignerPEM(certPEM []byte) {
+	s.t.Helper()
+	if err := s.secrets.Update(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "csr-signer"},
+		Data:       map[string][]byte{"tls.crt": certPEM},
+	}); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *signerExpiryTest) expectCondition(status operatorv1.ConditionStatus, reason, message string) {
+	s.t.Helper()
+	if err := s.controller.sync(context.TODO(), factory.NewSyncContext(controllerName, s.recorder)); err != nil {
+		s.t.Fatal(err)
+	}
+	_, operatorStatus, _, err := s.controller.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, conditionType)
+	if condition == nil || condition.Status != status || condition.Reason != reason || !strings.Contains(condition.Message, message) {
+		s.t.Errorf("expected %s to be %s with reason %s and a message containing %q, got %#v", conditionType, status, reason, message, condition)
+	}
+}
+
+func (s *signerExpiryTest) expectEvents(reasons ...string) {
+	s.t.Helper()
+	var got []string
+	for _, event := range s.recorder.Events() {
+		got = append(got, event.Reason)
+	}
+	if strings.Join(got, ",") != strings.Join(reasons, ",") {
+		s.t.Errorf("expected events %v, got %v", reasons, s.recorder.Events())
+	}
+}
+
+func TestSignerExpiry(t *testing.T) {
+	s := newSignerExpiryTest(t, nil)
+	notAfter := s.setSigner(30 * 24 * time.Hour)
+
+	// 20% of 30 days is 6 days, more than the three days of the minimum window
+	s.now = notAfter.Add(-7 * 24 * time.Hour)
+	s.expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+
+	s.now = notAfter.Add(-5 * 24 * time.Hour)
+	s.expectCondition(operatorv1.ConditionTrue, "CertificateExpiring", "The certificate of secret csr-signer -n openshift-kube-controller-manager-operator expires at "+notAfter.UTC().Format(time.RFC3339)+", within 144h0m0s")
+	// a condition that is already reported is not reported again
+	s.expectCondition(operatorv1.ConditionTrue, "CertificateExpiring", "")
+
+	s.now = notAfter.Add(time.Hour)
+	s.expectCondition(operatorv1.ConditionTrue, "CertificateExpired", "expired at "+notAfter.UTC().Format(time.RFC3339))
+
+	// the rotation clears it
+	s.setSigner(60 * 24 * time.Hour)
+	s.expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+	s.expectEvents("CertificateExpiring", "CertificateExpired")
+}
+
+func TestSignerExpiryMinimumWindow(t *testing.T) {
+	s := newSignerExpiryTest(t, nil)
+	// 20% of 5 days is a day, less than the three days of the minimum window
+	notAfter := s.setSigner(5 * 24 * time.Hour)
+
+	s.now = notAfter.Add(-4 * 24 * time.Hour)
+	s.expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+	s.now = notAfter.Add(-2 * 24 * time.Hour)
+	s.expectCondition(operatorv1.ConditionTrue, "CertificateExpiring", "within 72h0m0s")
+}
+
+func TestSignerExpiryWindowAnnotation(t *testing.T) {
+	s := newSignerExpiryTest(t, map[string]string{WindowAnnotation: "10m"})
+	notAfter := s.setSigner(30 * 24 * time.Hour)
+
+	s.now = notAfter.Add(-time.Hour)
+	s.expectCondition(operatorv1.ConditionFalse, "AsExpected", "")
+	s.now = notAfter.Add(-5 * time.Minute)
+	s.expectCondition(operatorv1.ConditionTrue, "CertificateExpiring", "within 10m0s")
+
+	s = newSignerExpiryTest(t, map[string]string{WindowAnnotation: "soon"})
+	s.setSigner(30 * 24 * time.Hour)
+	if err := s.controller.sync(context.TODO(), factory.NewSyncContext(controllerName, s.recorder)); err == nil || !strings.Contains(err.Error(), WindowAnnotation) {
+		t.Errorf("expected the invalid window to be rejected, got %v", err)
+	}
+}
+
+func TestSignerExpiryWithoutCertificate(t *testing.T) {
+	s := newSignerExpiryTest(t, nil)
+	s.expectCondition(operatorv1.ConditionFalse, "NoCertificate", "")
+
+	s.setSignerPEM([]byte("garbage"))
+	s.expectCondition(operatorv1.ConditionTrue, "InvalidCertificate", "The certificate of secret csr-signer -n openshift-kube-controller-manager-operator cannot be parsed")
+}
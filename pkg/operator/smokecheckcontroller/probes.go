@@ -0,0 +1,138 @@
+package smokecheckcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// scratchNamespaceLabel marks the namespaces of the service account probe, left over ones are deleted by the next
+	// probe
+	scratchNamespaceLabel  = "kube-controller-manager.openshift.io/smoke-check"
+	scratchNamespacePrefix = "openshift-kube-controller-manager-smoke-"
+
+	// nodeLeaseRenewal is how recently a node lease must have been renewed, the default node-monitor-grace-period
+	nodeLeaseRenewal = 40 * time.Second
+
+	pollInterval = 2 * time.Second
+)
+
+// DefaultProbes are the probes of the controllers a broken kube-controller-manager breaks first.
+func DefaultProbes(kubeClient kubernetes.Interface) []Probe {
+	return []Probe{
+		{Name: "ServiceAccount", Run: func(ctx context.Context) error { return probeServiceAccount(ctx, kubeClient) }},
+		{Name: "NodeLease", Run: func(ctx context.Context) error { return probeNodeLeases(ctx, kubeClient) }},
+	}
+}
+
+// probeServiceAccount creates a scratch namespace and waits for the service account controller to create its default
+// service account, the root CA publisher to create its CA bundle and the token controller to populate a service account
+// token secret. The namespace is deleted afterwards.
+func probeServiceAccount(ctx context.Context, kubeClient kubernetes.Interface) error {
+	if err := deleteScratchNamespaces(ctx, kubeClient); err != nil {
+		return err
+	}
+	namespace, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: scratchNamespacePrefix, Labels: map[string]string{scratchNamespaceLabel: "true"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create the scratch namespace: %v", err)
+	}
+	defer func() {
+		// the probe context may be expired already
+		deleteCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = kubeClient.CoreV1().Namespaces().Delete(deleteCtx, namespace.Name, metav1.DeleteOptions{})
+	}()
+
+	if err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		_, err := kubeClient.CoreV1().ServiceAccounts(namespace.Name).Get(ctx, "default", metav1.GetOptions{})
+		return err == nil, nil
+	}); err != nil {
+		return fmt.Errorf("serviceaccount/default was not created in namespace %s", namespace.Name)
+	}
+	if err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		configMap, err := kubeClient.CoreV1().ConfigMaps(namespace.Name).Get(ctx, "kube-root-ca.crt", metav1.GetOptions{})
+		return err == nil && len(configMap.Data["ca.crt"]) > 0, nil
+	}); err != nil {
+		return fmt.Errorf("configmap/kube-root-ca.crt was not published to namespace %s", namespace.Name)
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets(namespace.Name).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke-check-token", Annotations: map[string]string{corev1.ServiceAccountNameKey: "default"}},
+		Type:       corev1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create the token secret: %v", err)
+	}
+	if err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		secret, err := kubeClient.CoreV1().Secrets(namespace.Name).Get(ctx, secret.Name, metav1.GetOptions{})
+		return err == nil && len(secret.Data[corev1.ServiceAccountTokenKey]) > 0 && len(secret.Data[corev1.ServiceAccountRootCAKey]) > 0, nil
+	}); err != nil {
+		return fmt.Errorf("secret/%s in namespace %s was not populated with a token and CA", secret.Name, namespace.Name)
+	}
+	return nil
+}
+
+// deleteScratchNamespaces deletes the namespaces of probes that did not clean up, e.g. of an operator that was
+// restarted.
+func deleteScratchNamespaces(ctx context.Context, kubeClient kubernetes.Interface) error {
+	namespaces, err := kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: scratchNamespaceLabel})
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces.Items {
+		if namespace.DeletionTimestamp != nil {
+			continue
+		}
+		if err := kubeClient.CoreV1().Namespaces().Delete(ctx, namespace.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// probeNodeLeases waits for every node with a node lease renewed recently to not be marked unknown by the node
+// lifecycle controller, which marks nodes whose lease renewals it misses.
+func probeNodeLeases(ctx context.Context, kubeClient kubernetes.Interface) error {
+	var lastErr error
+	if err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		lastErr = nodeLeasesObserved(ctx, kubeClient)
+		return lastErr == nil, nil
+	}); err != nil {
+		return lastErr
+	}
+	return nil
+}
+
+func nodeLeasesObserved(ctx context.Context, kubeClient kubernetes.Interface) error {
+	leases, err := kubeClient.CoordinationV1().Leases(corev1.NamespaceNodeLease).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, lease := range leases.Items {
+		if lease.Spec.RenewTime == nil || time.Since(lease.Spec.RenewTime.Time) > nodeLeaseRenewal {
+			continue
+		}
+		node, err := kubeClient.CoreV1().Nodes().Get(ctx, lease.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionUnknown {
+				return fmt.Errorf("node %s renewed its lease %s ago but is %s: %s", node.Name, time.Since(lease.Spec.RenewTime.Time).Round(time.Second), condition.Reason, condition.Message)
+			}
+		}
+	}
+	return nil
+}
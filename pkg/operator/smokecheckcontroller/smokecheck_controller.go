@@ -0,0 +1,259 @@
+package smokecheckcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	controllerName = "SmokeCheckController"
+	conditionType  = "SmokeCheckDegraded"
+	// progressingConditionType is True while the probes run, the rollout of a revision waits for them
+	progressingConditionType = "SmokeCheckProgressing"
+
+	// SkipOverrideField of the unsupportedConfigOverrides turns the smoke checks off when it is true, the rollout of a
+	// revision is not gated by them anymore.
+	SkipOverrideField = "skipSmokeChecks"
+
+	// DefaultProbeTimeout is how long a probe may take before it fails.
+	DefaultProbeTimeout = 2 * time.Minute
+)
+
+func init() {
+	overrides.Register(SkipOverrideField)
+}
+
+// Probe is a functional check of the kube-controller-manager. It fails when the controllers it exercises do not do
+// their work within the deadline of ctx.
+type Probe struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// result is the outcome of the probes on a node at a revision, err is the first failed probe.
+type result struct {
+	revision int32
+	err      error
+}
+
+// SmokeCheckController runs the probes every time a node reports its kube-controller-manager ready at a new
+// revision, and holds back the installer pod of the next node until they pass. A pod going ready does not mean the
+// controllers work, for example a flag breaking the service account token controller is only noticed once users are
+// affected. SmokeCheckProgressing is set while the probes run. Failed probes set SmokeCheckDegraded naming the probe
+// and are retried on the next sync, the rollout of the revision continues once they pass.
+//
+// The results are kept in memory. A restarted operator runs the probes again for the nodes that reached the latest
+// revision in a rollout in progress, the other nodes are not checked before their next revision.
+type SmokeCheckController struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	operatorLister cache.GenericLister
+	probes         []Probe
+	timeout        time.Duration
+
+	lock sync.Mutex
+	// results are the outcomes per node, nil before the first sync
+	results map[string]result
+
+	controller factory.Controller
+}
+
+func NewSmokeCheckController(
+	operatorClient v1helpers.StaticPodOperatorClient,
+	operatorLister cache.GenericLister,
+	kubeClient kubernetes.Interface,
+	eventRecorder events.Recorder,
+) *SmokeCheckController {
+	c := &SmokeCheckController{
+		operatorClient: operatorClient,
+		operatorLister: operatorLister,
+		probes:         DefaultProbes(kubeClient),
+		timeout:        DefaultProbeTimeout,
+	}
+
+	c.controller = factory.New().WithInformers(
+		operatorClient.Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("smoke-check-controller"))
+	return c
+}
+
+func (c *SmokeCheckController) Run(ctx context.Context, workers int) {
+	c.controller.Run(ctx, workers)
+}
+
+func (c *SmokeCheckController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+
+	skipped, err := c.skipped()
+	if err != nil {
+		return err
+	}
+	if skipped {
+		condition.Reason = "SmokeChecksSkipped"
+		condition.Message = fmt.Sprintf("The smoke checks are skipped by %s", overrides.Path(SkipOverrideField))
+		progressing := operatorv1.OperatorCondition{Type: progressingConditionType, Status: operatorv1.ConditionFalse, Reason: "SmokeChecksSkipped"}
+		_, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition), v1helpers.UpdateStaticPodConditionFn(progressing))
+		return err
+	}
+
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	c.initialize(status)
+
+	progressing := operatorv1.OperatorCondition{
+		Type:   progressingConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	var failures []string
+	for _, nodeStatus := range status.NodeStatuses {
+		if nodeStatus.CurrentRevision == 0 {
+			continue
+		}
+		c.lock.Lock()
+		previous, checked := c.results[nodeStatus.NodeName]
+		c.lock.Unlock()
+		if checked && previous.revision == nodeStatus.CurrentRevision && previous.err == nil {
+			continue
+		}
+
+		running := operatorv1.OperatorCondition{
+			Type:    progressingConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "RunningSmokeChecks",
+			Message: fmt.Sprintf("The rollout of revision %d waits for the smoke checks of node %s", nodeStatus.CurrentRevision, nodeStatus.NodeName),
+		}
+		if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(running)); err != nil {
+			return err
+		}
+		// the installer controller sets the current revision once the operand is ready at it
+		probeErr := c.runProbes(ctx, nodeStatus.NodeName, nodeStatus.CurrentRevision)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.lock.Lock()
+		c.results[nodeStatus.NodeName] = result{revision: nodeStatus.CurrentRevision, err: probeErr}
+		c.lock.Unlock()
+		if probeErr != nil {
+			failures = append(failures, fmt.Sprintf("node %s at revision %d: %v", nodeStatus.NodeName, nodeStatus.CurrentRevision, probeErr))
+			continue
+		}
+		syncCtx.Recorder().Eventf("SmokeChecksPassed", "The smoke checks of node %s passed at revision %d", nodeStatus.NodeName, nodeStatus.CurrentRevision)
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "SmokeCheckFailed"
+		condition.Message = fmt.Sprintf("The rollout is halted until the smoke checks pass or %s is true: %s", overrides.Path(SkipOverrideField), strings.Join(failures, "\n"))
+	}
+	if _, _, err := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, v1helpers.UpdateStaticPodConditionFn(condition), v1helpers.UpdateStaticPodConditionFn(progressing)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// initialize records the nodes as checked at their current revision on the first sync, except for the nodes that
+// reached the latest revision in a rollout in progress. Probing the others would only repeat checks their revisions
+// passed before, or gate nothing.
+func (c *SmokeCheckController) initialize(status *operatorv1.StaticPodOperatorStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.results != nil {
+		return
+	}
+	rolloutInProgress := false
+	for _, nodeStatus := range status.NodeStatuses {
+		if nodeStatus.TargetRevision != 0 || nodeStatus.CurrentRevision != status.LatestAvailableRevision {
+			rolloutInProgress = true
+		}
+	}
+	c.results = map[string]result{}
+	for _, nodeStatus := range status.NodeStatuses {
+		if rolloutInProgress && nodeStatus.CurrentRevision == status.LatestAvailableRevision {
+			continue
+		}
+		c.results[nodeStatus.NodeName] = result{revision: nodeStatus.CurrentRevision}
+	}
+}
+
+// runProbes returns the error of the first failing probe.
+func (c *SmokeCheckController) runProbes(ctx context.Context, node string, revision int32) error {
+	for _, probe := range c.probes {
+		start := time.Now()
+		probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := probe.Run(probeCtx)
+		cancel()
+		if err != nil {
+			klog.Warningf("Smoke check %s of node %s at revision %d failed after %s: %v", probe.Name, node, revision, time.Since(start).Round(time.Second), err)
+			return fmt.Errorf("probe %s failed: %v", probe.Name, err)
+		}
+		klog.V(2).Infof("Smoke check %s of node %s at revision %d passed after %s", probe.Name, node, revision, time.Since(start).Round(time.Second))
+	}
+	return nil
+}
+
+// HoldInstallerPod is an installergate.HoldFunc. It holds the pod back while the probes of another node at the
+// revision of the pod did not pass.
+func (c *SmokeCheckController) HoldInstallerPod(pod *corev1.Pod, nodeName string, revision int32) (string, error) {
+	skipped, err := c.skipped()
+	if err != nil {
+		return "", err
+	}
+	if skipped {
+		return "", nil
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return "", err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, nodeStatus := range status.NodeStatuses {
+		if nodeStatus.NodeName == nodeName || nodeStatus.CurrentRevision != revision {
+			continue
+		}
+		r, ok := c.results[nodeStatus.NodeName]
+		switch {
+		case !ok || r.revision != revision:
+			return fmt.Sprintf("the smoke checks of node %s at revision %d did not run yet", nodeStatus.NodeName, revision), nil
+		case r.err != nil:
+			return fmt.Sprintf("the smoke checks of node %s at revision %d failed: %v", nodeStatus.NodeName, revision, r.err), nil
+		}
+	}
+	return "", nil
+}
+
+func (c *SmokeCheckController) skipped() (bool, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return false, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return false, err
+	}
+	return overrides.Bool(unsupportedConfigOverrides, SkipOverrideField)
+}
@@ -0,0 +1,335 @@
+package smokecheckcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/installer"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/installergate"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// fakeProbe fails with err, it counts its runs
+type fakeProbe struct {
+	err  error
+	runs int
+}
+
+func (p *fakeProbe) probe() Probe {
+	return Probe{Name: "Fake", Run: func(context.Context) error {
+		p.runs++
+		return p.err
+	}}
+}
+
+func newTestController(t *testing.T, unsupportedConfigOverrides map[string]interface{}, probe *fakeProbe, latest int32, currentRevisions ...int32) *SmokeCheckController {
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	if unsupportedConfigOverrides != nil {
+		if err := unstructured.SetNestedMap(operator.Object, unsupportedConfigOverrides, "spec", "unsupportedConfigOverrides"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operatorIndexer.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	status := &operatorv1.StaticPodOperatorStatus{LatestAvailableRevision: latest}
+	for i, revision := range currentRevisions {
+		status.NodeStatuses = append(status.NodeStatuses, operatorv1.NodeStatus{NodeName: fmt.Sprintf("master-%d", i), CurrentRevision: revision})
+	}
+	return &SmokeCheckController{
+		operatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+			&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+			status,
+			nil,
+			nil,
+		),
+		operatorLister: cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		probes:         []Probe{probe.probe()},
+		timeout:        time.Second,
+	}
+}
+
+func syncCondition(t *testing.T, c *SmokeCheckController) *operatorv1.OperatorCondition {
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v1helpers.FindOperatorCondition(status.Conditions, conditionType)
+}
+
+func installerPod(node string, revision int32) (*corev1.Pod, string) {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: fmt.Sprintf("installer-%d-%s", revision, node)}}, node
+}
+
+// hold returns why the installer pod for revision on node is held back.
+func hold(t *testing.T, c *SmokeCheckController, node string, revision int32) string {
+	t.Helper()
+	pod, nodeName := installerPod(node, revision)
+	reason, err := c.HoldInstallerPod(pod, nodeName, revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reason
+}
+
+func setCurrentRevision(t *testing.T, c *SmokeCheckController, node string, revision int32) {
+	if _, _, err := v1helpers.UpdateStaticPodStatus(context.TODO(), c.operatorClient, func(status *operatorv1.StaticPodOperatorStatus) error {
+		for i := range status.NodeStatuses {
+			if status.NodeStatuses[i].NodeName == node {
+				status.NodeStatuses[i].CurrentRevision = revision
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFailedProbesHaltTheRollout(t *testing.T) {
+	probe := &fakeProbe{err: fmt.Errorf("serviceaccount/default was not created")}
+	// master-0 is ready at the new revision
+	c := newTestController(t, nil, probe, 4, 4, 3, 3)
+
+	if reason := hold(t, c, "master-1", 4); !strings.Contains(reason, "did not run yet") {
+		t.Errorf("expected the installer pod to wait for the first smoke checks, got %q", reason)
+	}
+
+	condition := syncCondition(t, c)
+	if condition.Status != operatorv1.ConditionTrue || condition.Reason != "SmokeCheckFailed" || !strings.Contains(condition.Message, "node master-0 at revision 4: probe Fake failed: serviceaccount/default was not created") {
+		t.Errorf("expected the failed probe to degrade, got %#v", condition)
+	}
+	if reason := hold(t, c, "master-1", 4); reason != "the smoke checks of node master-0 at revision 4 failed: probe Fake failed: serviceaccount/default was not created" {
+		t.Errorf("expected the installer pod to be held, got %q", reason)
+	}
+	// the node that failed can be installed, e.g. a newer revision fixing it
+	if reason := hold(t, c, "master-0", 5); reason != "" {
+		t.Errorf("expected a newer revision to be installed, got %q", reason)
+	}
+
+	// failed probes are retried
+	probe.err = nil
+	if condition := syncCondition(t, c); condition.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the passed probe to clear the condition, got %#v", condition)
+	}
+	if reason := hold(t, c, "master-1", 4); reason != "" {
+		t.Errorf("expected the rollout to continue, got %q", reason)
+	}
+	if probe.runs != 2 {
+		t.Errorf("expected 2 probe runs, got %d", probe.runs)
+	}
+
+	// master-1 is ready at the new revision, master-2 waits for its probes
+	setCurrentRevision(t, c, "master-1", 4)
+	probe.err = fmt.Errorf("node master-2 renewed its lease 3s ago but is NodeStatusUnknown")
+	syncCondition(t, c)
+	if reason := hold(t, c, "master-2", 4); !strings.Contains(reason, "node master-1 at revision 4 failed") {
+		t.Errorf("expected the installer pod to be held, got %q", reason)
+	}
+	if probe.runs != 3 {
+		t.Errorf("expected the passed node not to be probed again, got %d probe runs", probe.runs)
+	}
+}
+
+func TestProbesRunOncePerRevision(t *testing.T) {
+	probe := &fakeProbe{}
+	c := newTestController(t, nil, probe, 4, 4, 4, 4)
+
+	// no rollout was in progress when the operator started
+	syncCondition(t, c)
+	if probe.runs != 0 {
+		t.Errorf("expected the nodes to be checked already, got %d probe runs", probe.runs)
+	}
+	if reason := hold(t, c, "master-1", 5); reason != "" {
+		t.Errorf("expected the first node of a rollout to be installed, got %q", reason)
+	}
+
+	setCurrentRevision(t, c, "master-0", 5)
+	if reason := hold(t, c, "master-1", 5); reason == "" {
+		t.Errorf("expected the installer pod to wait for the smoke checks")
+	}
+	syncCondition(t, c)
+	syncCondition(t, c)
+	if probe.runs != 1 {
+		t.Errorf("expected a single probe run, got %d", probe.runs)
+	}
+	if reason := hold(t, c, "master-1", 5); reason != "" {
+		t.Errorf("expected the rollout to continue, got %q", reason)
+	}
+}
+
+func TestSkippedProbes(t *testing.T) {
+	probe := &fakeProbe{err: fmt.Errorf("failed")}
+	c := newTestController(t, map[string]interface{}{SkipOverrideField: true}, probe, 4, 4, 3, 3)
+
+	if condition := syncCondition(t, c); condition.Status != operatorv1.ConditionFalse || condition.Reason != "SmokeChecksSkipped" {
+		t.Errorf("expected the skipped checks to be reported, got %#v", condition)
+	}
+	if reason := hold(t, c, "master-1", 4); reason != "" {
+		t.Errorf("expected the rollout not to be gated, got %q", reason)
+	}
+	if probe.runs != 0 {
+		t.Errorf("expected no probe runs, got %d", probe.runs)
+	}
+}
+
+func TestGatedRolloutIsNotDegraded(t *testing.T) {
+	ctx := context.TODO()
+	namespace := operatorclient.TargetNamespace
+	mirrorPod := func(node, revision string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "kube-controller-manager-" + node, Labels: map[string]string{"revision": revision}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "kube-controller-manager-pod-2"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "revision-status-2"}},
+		mirrorPod("master-0", "1"),
+		mirrorPod("master-1", "1"),
+	)
+	// revision 2 is rolled out to both nodes
+	c := newTestController(t, nil, &fakeProbe{}, 2, 1, 1)
+	var progressingDuringProbes *operatorv1.OperatorCondition
+	c.probes = []Probe{{Name: "Fake", Run: func(context.Context) error {
+		_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			return err
+		}
+		progressingDuringProbes = v1helpers.FindOperatorCondition(status.Conditions, progressingConditionType)
+		return nil
+	}}}
+	gate := installergate.NewGate(c.HoldInstallerPod)
+	recorder := events.NewInMemoryRecorder("test")
+	installerController := installer.NewInstallerController(
+		namespace,
+		"kube-controller-manager",
+		[]revision.RevisionResource{{Name: "kube-controller-manager-pod"}},
+		nil,
+		[]string{"installer"},
+		informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace)),
+		c.operatorClient,
+		client.CoreV1(),
+		client.CoreV1(),
+		gate.Client(client).CoreV1(),
+		recorder,
+	).WithInstallerPodMutationFn(gate.MutateInstallerPod)
+
+	status := func() *operatorv1.StaticPodOperatorStatus {
+		t.Helper()
+		_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+	expectNotDegraded := func() {
+		t.Helper()
+		for _, condition := range status().Conditions {
+			if strings.HasSuffix(condition.Type, "Degraded") && condition.Status == operatorv1.ConditionTrue {
+				t.Fatalf("expected the rollout not to degrade, got %#v", condition)
+			}
+		}
+	}
+	syncInstaller := func() {
+		t.Helper()
+		if err := installerController.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+			t.Fatal(err)
+		}
+		expectNotDegraded()
+	}
+	syncSmokeCheck := func() {
+		t.Helper()
+		syncCondition(t, c)
+		expectNotDegraded()
+	}
+	targeted := func() string {
+		t.Helper()
+		for _, node := range status().NodeStatuses {
+			if node.TargetRevision == 2 {
+				return node.NodeName
+			}
+		}
+		t.Fatalf("expected a node to be updated to revision 2, got %#v", status().NodeStatuses)
+		return ""
+	}
+	installerPodExists := func(node string) bool {
+		t.Helper()
+		_, err := client.CoreV1().Pods(namespace).Get(ctx, "installer-2-"+node, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	// the first node is not gated
+	syncSmokeCheck()
+	syncInstaller()
+	first := targeted()
+	syncInstaller()
+	if !installerPodExists(first) {
+		t.Fatalf("expected the installer pod of node %s", first)
+	}
+	installerPod, err := client.CoreV1().Pods(namespace).Get(ctx, "installer-2-"+first, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installerPod.Status.Phase = corev1.PodSucceeded
+	if _, err := client.CoreV1().Pods(namespace).Update(ctx, installerPod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Pods(namespace).Update(ctx, mirrorPod(first, "2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	syncInstaller()
+
+	// the second node waits for the smoke checks of the first one
+	syncInstaller()
+	second := targeted()
+	syncInstaller()
+	syncInstaller()
+	if installerPodExists(second) {
+		t.Fatalf("expected the installer pod of node %s to wait for the smoke checks", second)
+	}
+
+	syncSmokeCheck()
+	if progressingDuringProbes == nil || progressingDuringProbes.Status != operatorv1.ConditionTrue || progressingDuringProbes.Message != "The rollout of revision 2 waits for the smoke checks of node "+first {
+		t.Errorf("expected %s while the probes run, got %#v", progressingConditionType, progressingDuringProbes)
+	}
+	if progressing := v1helpers.FindOperatorCondition(status().Conditions, progressingConditionType); progressing == nil || progressing.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected %s to be False after the probes, got %#v", progressingConditionType, progressing)
+	}
+	syncInstaller()
+	if !installerPodExists(second) {
+		t.Errorf("expected the installer pod of node %s once the smoke checks passed", second)
+	}
+	for _, event := range recorder.Events() {
+		if event.Reason == "InstallerPodFailed" {
+			t.Errorf("expected no failed installer pods, got %q", event.Message)
+		}
+	}
+}
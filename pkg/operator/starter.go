@@ -11,14 +11,42 @@ import (
 	configv1client "github.com/openshift/client-go/config/clientset/versioned"
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
 	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/cacheintegritycontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/certrotationcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/clustercidrcontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/configobservercontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/node"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/controlplanenodes"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/degradeddamping"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/effectiveconfig"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/eventbudget"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/eventcontext"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/forceredeploymentcontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/gcwatchercontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/informerresync"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/inputgeneration"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/installerconcurrencycontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/installergate"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/installerhistorycontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/kubeconfigvalidationcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/networkpolicycontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/orphanedlockcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/profiling"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/recyclercontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/resourcesynccontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/revisiondiskusagecontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/revisionratecontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/rolloutordercontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/signerexpirycontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/smokecheckcontroller"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/targetconfigcontroller"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/upgradecheck"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/workqueuesaturationcontroller"
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/operator/certrotation"
 	"github.com/openshift/library-go/pkg/operator/configobserver/featuregates"
@@ -38,11 +66,32 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 )
 
+// RunOperator runs the operator with the default informer resync periods and event budget. It cannot restart itself,
+// a diverged informer cache is only reported.
 func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error {
+	return runOperator(ctx, cc, informerresync.DefaultPeriods(), eventbudget.DefaultBudget(), nil)
+}
+
+// NewRunOperator returns RunOperator with the informer resync periods and the event budget, which are read when the
+// operator starts so that they can be bound to flags. restart gracefully restarts the operator, it relists the informers when their
+// cache diverged from the API server.
+func NewRunOperator(resyncPeriods *informerresync.Periods, eventBudget *eventbudget.Budget, restart func()) controllercmd.StartFunc {
+	return func(ctx context.Context, cc *controllercmd.ControllerContext) error {
+		return runOperator(ctx, cc, *resyncPeriods, *eventBudget, restart)
+	}
+}
+
+func runOperator(ctx context.Context, cc *controllercmd.ControllerContext, resyncPeriods informerresync.Periods, eventBudget eventbudget.Budget, restart func()) error {
+	// the operator only runs while it holds the lease, ctx is cancelled when the lease is lost
+	leadership.Set(true)
+	defer leadership.Set(false)
+
 	// This kube client use protobuf, do not use it for CR
 	kubeClient, err := kubernetes.NewForConfig(cc.ProtoKubeConfig)
 	if err != nil {
@@ -52,9 +101,16 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 	if err != nil {
 		return err
 	}
+	if err := controlplanenodes.LogConvention(ctx, kubeClient.CoreV1()); err != nil {
+		klog.Warningf("Unable to determine the role label convention of the control plane nodes: %v", err)
+	}
+	operatorConfigClient, err := operatorv1client.NewForConfig(cc.KubeConfig)
+	if err != nil {
+		return err
+	}
 
-	configInformers := configinformers.NewSharedInformerFactory(configClient, 10*time.Minute)
-	kubeInformersForNamespaces := v1helpers.NewKubeInformersForNamespaces(kubeClient,
+	configInformers := configinformers.NewSharedInformerFactory(configClient, resyncPeriods.Config)
+	kubeInformersForNamespaces := informerresync.NewKubeInformersForNamespaces(kubeClient, resyncPeriods.Kube,
 		"",
 		operatorclient.GlobalUserSpecifiedConfigNamespace,
 		operatorclient.GlobalMachineSpecifiedConfigNamespace,
@@ -69,15 +125,25 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		return err
 	}
 	operatorLister := dynamicInformers.ForResource(operatorv1.GroupVersion.WithResource("kubecontrollermanagers")).Lister()
+	// every controller sets its conditions through this client, so flapping Degraded conditions are damped in one place
+	degradedDampingClient := degradeddamping.NewOperatorClient(operatorClient, operatorLister, degradeddamping.DefaultHoldDuration)
+	operatorClient = degradedDampingClient
 
 	desiredVersion := status.VersionForOperatorFromEnv()
+	// the operator only gets here as the leader, its pod is the lease holder
+	leader, err := leadership.Identity()
+	if err != nil {
+		return err
+	}
+	// every controller records through this recorder, so a crashlooping operand cannot flood the namespace with events
+	eventRecorder := eventbudget.NewRecorder(eventcontext.NewRecorder(cc.EventRecorder, operatorClient, desiredVersion, leader), eventBudget)
 	missingVersion := "0.0.1-snapshot"
 
 	// By default, this will exit(0) the process if the featuregates ever change to a different set of values.
 	featureGateAccessor := featuregates.NewFeatureGateAccess(
 		desiredVersion, missingVersion,
 		configInformers.Config().V1().ClusterVersions(), configInformers.Config().V1().FeatureGates(),
-		cc.EventRecorder,
+		eventRecorder,
 	)
 	go featureGateAccessor.Run(ctx)
 	go configInformers.Start(ctx.Done())
@@ -96,19 +162,43 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		kubeInformersForNamespaces,
 		v1helpers.CachedSecretGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
 		v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
-		cc.EventRecorder,
+		eventRecorder,
 	)
 	if err != nil {
 		return err
 	}
 
+	forceResyncController := resourcesynccontroller.NewForceResyncController(
+		operatorClient,
+		operatorLister,
+		operatorConfigClient,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		eventRecorder,
+	)
+
+	upgradeCheckController := upgradecheck.NewUpgradeCheckController(
+		operatorClient,
+		operatorLister,
+		upgradecheck.Clients{
+			KubeControllerManagers: operatorConfigClient,
+			Secrets:                kubeClient.CoreV1(),
+			Leases:                 kubeClient.CoordinationV1(),
+		},
+		kubeClient.CoreV1(),
+		eventRecorder,
+	)
+
 	configObserver, err := configobservercontroller.NewConfigObserver(
 		operatorClient,
 		configInformers,
 		kubeInformersForNamespaces,
 		resourceSyncController,
 		featureGateAccessor,
-		cc.EventRecorder,
+		desiredVersion,
+		status.ImageForOperandFromEnv(),
+		configobservercontroller.NewSourceVersions(kubeClient.Discovery(), operatorConfigClient),
+		eventRecorder,
 	)
 	if err != nil {
 		return err
@@ -117,38 +207,13 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 	staticResourceController := staticresourcecontroller.NewStaticResourceController(
 		"KubeControllerManagerStaticResources",
 		bindata.Asset,
-		[]string{
-			"assets/kube-controller-manager/ns.yaml",
-			"assets/kube-controller-manager/kubeconfig-cert-syncer.yaml",
-			"assets/kube-controller-manager/leader-election-rolebinding.yaml",
-			"assets/kube-controller-manager/leader-election-cluster-policy-controller-role.yaml",
-			"assets/kube-controller-manager/leader-election-cluster-policy-controller-rolebinding.yaml",
-			"assets/kube-controller-manager/namespace-security-allocation-controller-clusterrole.yaml",
-			"assets/kube-controller-manager/namespace-security-allocation-controller-clusterrolebinding.yaml",
-			"assets/kube-controller-manager/podsecurity-admission-label-syncer-controller-clusterrole.yaml",
-			"assets/kube-controller-manager/podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
-			"assets/kube-controller-manager/podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
-			"assets/kube-controller-manager/podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
-			"assets/kube-controller-manager/namespace-openshift-infra.yaml",
-			"assets/kube-controller-manager/svc.yaml",
-			"assets/kube-controller-manager/sa.yaml",
-			"assets/kube-controller-manager/recycler-sa.yaml",
-			"assets/kube-controller-manager/localhost-recovery-client-crb.yaml",
-			"assets/kube-controller-manager/localhost-recovery-sa.yaml",
-			"assets/kube-controller-manager/localhost-recovery-token.yaml",
-			"assets/kube-controller-manager/csr_approver_clusterrole.yaml",
-			"assets/kube-controller-manager/csr_approver_clusterrolebinding.yaml",
-		},
+		staticResourceAssets,
 		(&resourceapply.ClientHolder{}).WithKubernetes(kubeClient),
 		operatorClient,
-		cc.EventRecorder,
+		eventRecorder,
 	).WithConditionalResources(
 		bindata.Asset,
-		[]string{
-			"assets/kube-controller-manager/vsphere/legacy-cloud-provider-sa.yaml",
-			"assets/kube-controller-manager/vsphere/legacy-cloud-provider-role.yaml",
-			"assets/kube-controller-manager/vsphere/legacy-cloud-provider-binding.yaml",
-		},
+		vsphereAssets,
 		func() bool {
 			isVSphere, precheckSucceeded, err := newPlatformMatcherFn(configv1.VSpherePlatformType, configInformers.Config().V1().Infrastructures())()
 			if err != nil {
@@ -165,10 +230,18 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		nil,
 	).WithConditionalResources(
 		bindata.Asset,
-		[]string{
-			"assets/kube-controller-manager/gce/cloud-provider-role.yaml",
-			"assets/kube-controller-manager/gce/cloud-provider-binding.yaml",
+		clusterPolicyControllerAssets,
+		func() bool {
+			disabled, precheckSucceeded := clusterPolicyControllerDisabled(configInformers.Config().V1().Infrastructures())
+			return precheckSucceeded && !disabled
 		},
+		func() bool {
+			disabled, precheckSucceeded := clusterPolicyControllerDisabled(configInformers.Config().V1().Infrastructures())
+			return precheckSucceeded && disabled
+		},
+	).WithConditionalResources(
+		bindata.Asset,
+		gceAssets,
 		func() bool {
 			// We do not want to apply these resources, so must return false here
 			return false
@@ -190,7 +263,7 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		operatorLister,
 		kubeClient,
 		configInformers.Config().V1().Infrastructures(),
-		cc.EventRecorder,
+		eventRecorder,
 	)
 
 	// don't change any versions until we sync
@@ -204,11 +277,28 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 	}
 	versionRecorder.SetVersion("raw-internal", status.VersionForOperatorFromEnv())
 
-	staticPodControllers, err := staticpod.NewBuilder(operatorClient, kubeClient, kubeInformersForNamespaces, configInformers).
-		WithEvents(cc.EventRecorder).
-		WithInstaller([]string{"cluster-kube-controller-manager-operator", "installer"}).
+	installerConcurrencyController := installerconcurrencycontroller.NewInstallerConcurrencyController(operatorClient, kubeClient.CoreV1(), kubeInformersForNamespaces, eventRecorder)
+
+	smokeCheckController := smokecheckcontroller.NewSmokeCheckController(operatorClient, operatorLister, kubeClient, eventRecorder)
+
+	installerGate := installergate.NewGate(installerConcurrencyController.HoldInstallerPod, smokeCheckController.HoldInstallerPod)
+
+	// the revision controller reads through the cache, it must not recreate what the cache missed, and it must not
+	// create a revision from inputs that are half way through a change
+	revisionKubeClient := inputgeneration.NewFencedClient(
+		cacheintegritycontroller.NewLiveCreateClient(kubeClient, operatorclient.TargetNamespace),
+		operatorclient.TargetNamespace,
+		revisionResourceNames(DeploymentConfigMaps),
+		revisionResourceNames(DeploymentSecrets),
+		inputgeneration.DefaultTimeout,
+	)
+	// the installer controller waits for the installer pods the gate holds back like for pending ones
+	revisionKubeClient = installerGate.Client(revisionKubeClient)
+	staticPodControllers, err := staticpod.NewBuilder(operatorClient, revisionKubeClient, kubeInformersForNamespaces, configInformers).
+		WithEvents(eventRecorder).
+		WithCustomInstaller([]string{"cluster-kube-controller-manager-operator", "installer"}, installerGate.MutateInstallerPod).
 		WithPruning([]string{"cluster-kube-controller-manager-operator", "prune"}, "kube-controller-manager-pod").
-		WithRevisionedResources(operatorclient.TargetNamespace, "kube-controller-manager", deploymentConfigMaps, deploymentSecrets).
+		WithRevisionedResources(operatorclient.TargetNamespace, "kube-controller-manager", DeploymentConfigMaps, DeploymentSecrets).
 		WithUnrevisionedCerts("kube-controller-manager-certs", CertConfigMaps, CertSecrets).
 		WithVersioning("kube-controller-manager", versionRecorder).
 		WithPodDisruptionBudgetGuard(
@@ -231,37 +321,47 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 
 	clusterOperatorStatus := status.NewClusterOperatorStatusController(
 		"kube-controller-manager",
-		[]configv1.ObjectReference{
-			{Group: "operator.openshift.io", Resource: "kubecontrollermanagers", Name: "cluster"},
-			{Resource: "namespaces", Name: "openshift-config"},
-			{Resource: "namespaces", Name: "openshift-config-managed"},
-			{Resource: "namespaces", Name: operatorclient.TargetNamespace},
-			{Resource: "namespaces", Name: "openshift-kube-controller-manager-operator"},
-			{Resource: "namespaces", Name: "kube-system"},
-			// TODO move to a more appropriate operator. One that creates and approves these.
-			{Group: "certificates.k8s.io", Resource: "certificatesigningrequests"},
-			// TODO move to a more appropriate operator. One that creates and manages these.
-			{Resource: "nodes"},
-			{Group: "config.openshift.io", Resource: "nodes", Name: "cluster"},
-		},
+		// the objects the operator packages registered are set on every sync, see relatedobjects.List
+		nil,
 		configClient.ConfigV1(),
 		configInformers.Config().V1().ClusterOperators(),
 		operatorClient,
 		versionRecorder,
-		cc.EventRecorder,
+		eventRecorder,
 	)
+	clusterOperatorStatus.WithRelatedObjectsFunc(func() (bool, []configv1.ObjectReference) {
+		return true, relatedobjects.List()
+	})
 
 	certRotationScale, err := certrotation.GetCertRotationScale(ctx, kubeClient, operatorclient.GlobalUserSpecifiedConfigNamespace)
 	if err != nil {
 		return err
 	}
 
+	// a hosted control plane keeps the signing secrets here and publishes the verification configmaps to the guest
+	// cluster of OPERAND_KUBECONFIG
+	certRotationClusters := certrotationcontroller.StandaloneClusters(kubeClient, kubeInformersForNamespaces)
+	if operandKubeconfig := os.Getenv("OPERAND_KUBECONFIG"); len(operandKubeconfig) > 0 {
+		operandKubeConfig, err := clientcmd.BuildConfigFromFlags("", operandKubeconfig)
+		if err != nil {
+			return err
+		}
+		operandKubeClient, err := kubernetes.NewForConfig(operandKubeConfig)
+		if err != nil {
+			return err
+		}
+		certRotationClusters = certrotationcontroller.SplitClusters(kubeClient, kubeInformersForNamespaces, operandKubeClient)
+	}
+	if err := certRotationClusters.Validate(ctx); err != nil {
+		return err
+	}
+
 	certRotationController, err := certrotationcontroller.NewCertRotationController(
-		v1helpers.CachedSecretGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
-		v1helpers.CachedConfigMapGetter(kubeClient.CoreV1(), kubeInformersForNamespaces),
+		certRotationClusters,
 		operatorClient,
-		kubeInformersForNamespaces,
-		cc.EventRecorder,
+		operatorLister,
+		configInformers.Config().V1().ClusterVersions(),
+		eventRecorder,
 		// this is weird, but when we turn down rotation in CI, we go fast enough that kubelets and kas are racing to observe the new signer before the signer is used.
 		// we need to establish some kind of delay or back pressure to prevent the rollout.  This ensures we don't trigger kas restart
 		// during e2e tests for now.
@@ -270,7 +370,8 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 	if err != nil {
 		return err
 	}
-	saTokenController := certrotationcontroller.NewSATokenSignerController(operatorClient, kubeInformersForNamespaces, kubeClient, cc.EventRecorder)
+	saTokenController := certrotationcontroller.NewSATokenSignerController(operatorClient, operatorLister, certRotationClusters, eventRecorder)
+	signerExpiryController := signerexpirycontroller.NewSignerExpiryController(operatorClient, operatorLister, kubeInformersForNamespaces, certRotationScale*8, eventRecorder)
 
 	latencyProfileRejectionChecker, err := latencyprofilecontroller.NewInstallerProfileRejectionChecker(
 		kubeInformersForNamespaces.ConfigMapLister().ConfigMaps(operatorclient.TargetNamespace),
@@ -290,15 +391,52 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		),
 		configInformers.Config().V1().Nodes(),
 		kubeInformersForNamespaces,
-		cc.EventRecorder,
+		eventRecorder,
 	)
 
-	gcWatcherController := gcwatchercontroller.NewGarbageCollectorWatcherController(operatorClient, kubeInformersForNamespaces, configInformers, kubeClient, cc.EventRecorder, []string{
+	gcWatcherController := gcwatchercontroller.NewGarbageCollectorWatcherController(operatorClient, kubeInformersForNamespaces, configInformers, kubeClient, eventRecorder, []string{
 		"GarbageCollectorSyncFailed",
 	})
 
+	kubeconfigValidationController := kubeconfigvalidationcontroller.NewKubeconfigValidationController(operatorClient, kubeInformersForNamespaces, configInformers, eventRecorder)
+
+	revisionDiskUsageController := revisiondiskusagecontroller.NewRevisionDiskUsageController(operatorClient, kubeInformersForNamespaces, kubeClient.CoreV1(), revisiondiskusagecontroller.DefaultDiskUsageThresholdBytes, eventRecorder)
+
+	revisionRateController := revisionratecontroller.NewRevisionRateController(operatorClient, operatorLister, kubeInformersForNamespaces, eventRecorder)
+
+	rolloutOrderController := rolloutordercontroller.NewRolloutOrderController(operatorClient, kubeInformersForNamespaces, eventRecorder)
+
+	workqueueSaturationController := workqueuesaturationcontroller.NewWorkqueueSaturationController(operatorLister, operatorClient.Informer(), kubeInformersForNamespaces, eventRecorder)
+
+	orphanedLockController := orphanedlockcontroller.NewOrphanedLockController(kubeClient, operatorLister, kubeInformersForNamespaces, eventRecorder)
+
+	networkPolicyController := networkpolicycontroller.NewNetworkPolicyController(kubeClient, operatorLister, operatorClient.Informer(), configInformers.Config().V1().Infrastructures(), kubeInformersForNamespaces, eventRecorder)
+
+	forceRedeploymentHistoryController := forceredeploymentcontroller.NewForceRedeploymentHistoryController(kubeInformersForNamespaces, kubeClient.CoreV1(), eventRecorder)
+
+	clusterCIDRConsistencyController := clustercidrcontroller.NewClusterCIDRConsistencyController(operatorClient, kubeInformersForNamespaces, configInformers, eventRecorder)
+
+	recyclerServiceAccountController := recyclercontroller.NewRecyclerServiceAccountController(operatorClient, kubeInformersForNamespaces, kubeClient.CoreV1(), eventRecorder)
+
+	installerHistoryController := installerhistorycontroller.NewInstallerHistoryController(operatorClient, kubeInformersForNamespaces, kubeClient.CoreV1(), eventRecorder)
+
+	cacheIntegrityController := cacheintegritycontroller.NewCacheIntegrityController(kubeClient, kubeInformersForNamespaces, revisionResourceNames(DeploymentConfigMaps), revisionResourceNames(DeploymentSecrets), restart, eventRecorder)
+
+	if cc.Server != nil {
+		// the standby replicas answer without the leadership header, their server is not handed out before they lead
+		cc.Server.Handler.NonGoRestfulMux.Unregister("/metrics")
+		cc.Server.Handler.NonGoRestfulMux.Handle("/metrics", leadership.WithHeader(legacyregistry.HandlerWithReset()))
+		cc.Server.Handler.NonGoRestfulMux.Handle(effectiveconfig.Path, effectiveconfig.NewHandler(
+			operatorClient,
+			kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.TargetNamespace),
+		))
+		profiling.Install(cc.Server.Handler.NonGoRestfulMux, operatorLister)
+	}
+
 	configInformers.Start(ctx.Done())
 	kubeInformersForNamespaces.Start(ctx.Done())
+	// the same informers in a standalone cluster, starting them again is a no-op
+	certRotationClusters.Destination.KubeInformersForNamespaces.Start(ctx.Done())
 	dynamicInformers.Start(ctx.Done())
 
 	go staticPodControllers.Start(ctx)
@@ -307,22 +445,120 @@ func RunOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 	go configObserver.Run(ctx, 1)
 	go clusterOperatorStatus.Run(ctx, 1)
 	go resourceSyncController.Run(ctx, 1)
+	go forceResyncController.Run(ctx, 1)
+	go upgradeCheckController.Run(ctx, 1)
 	go certRotationController.Run(ctx, 1)
 	go saTokenController.Run(ctx, 1)
+	go signerExpiryController.Run(ctx, 1)
 	go latencyProfileController.Run(ctx, 1)
 	go gcWatcherController.Run(ctx, 1)
+	go kubeconfigValidationController.Run(ctx, 1)
+	go revisionDiskUsageController.Run(ctx, 1)
+	go revisionRateController.Run(ctx, 1)
+	go rolloutOrderController.Run(ctx, 1)
+	go workqueueSaturationController.Run(ctx, 1)
+	go orphanedLockController.Run(ctx, 1)
+	go networkPolicyController.Run(ctx, 1)
+	go forceRedeploymentHistoryController.Run(ctx, 1)
+	go clusterCIDRConsistencyController.Run(ctx, 1)
+	go installerConcurrencyController.Run(ctx, 1)
+	go recyclerServiceAccountController.Run(ctx, 1)
+	go installerHistoryController.Run(ctx, 1)
+	go smokeCheckController.Run(ctx, 1)
+	go cacheIntegrityController.Run(ctx, 1)
+	go degradedDampingClient.Run(ctx)
 
 	<-ctx.Done()
 	return nil
 }
 
-// deploymentConfigMaps is a list of configmaps that are directly copied for the current values.  A different actor/controller modifies these.
+func init() {
+	relatedobjects.Register(
+		configv1.ObjectReference{Group: "operator.openshift.io", Resource: "kubecontrollermanagers", Name: "cluster"},
+		configv1.ObjectReference{Resource: "namespaces", Name: operatorclient.GlobalUserSpecifiedConfigNamespace},
+		configv1.ObjectReference{Resource: "namespaces", Name: operatorclient.GlobalMachineSpecifiedConfigNamespace},
+		configv1.ObjectReference{Resource: "namespaces", Name: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "namespaces", Name: operatorclient.OperatorNamespace},
+		configv1.ObjectReference{Resource: "namespaces", Name: "kube-system"},
+		// TODO move to a more appropriate operator. One that creates and approves these.
+		configv1.ObjectReference{Group: "certificates.k8s.io", Resource: "certificatesigningrequests"},
+		// TODO move to a more appropriate operator. One that creates and manages these.
+		configv1.ObjectReference{Resource: "nodes"},
+		configv1.ObjectReference{Group: "config.openshift.io", Resource: "nodes", Name: "cluster"},
+
+		// the revisions of the static pod, the installer and pruner pods and the mirror pods of the operand
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "pods", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Group: "policy", Resource: "poddisruptionbudgets", Namespace: operatorclient.TargetNamespace},
+		// the lease of the leader election of kube-controller-manager
+		configv1.ObjectReference{Group: "coordination.k8s.io", Resource: "leases", Namespace: "kube-system", Name: "kube-controller-manager"},
+	)
+	for _, assets := range [][]string{staticResourceAssets, vsphereAssets, clusterPolicyControllerAssets, gceAssets} {
+		for _, asset := range assets {
+			relatedobjects.RegisterManifests(bindata.MustAsset(asset))
+		}
+	}
+}
+
+func revisionResourceNames(resources []revision.RevisionResource) []string {
+	names := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	return names
+}
+
+// staticResourceAssets are applied by the static resource controller.
+var staticResourceAssets = []string{
+	"assets/kube-controller-manager/ns.yaml",
+	"assets/kube-controller-manager/kubeconfig-cert-syncer.yaml",
+	"assets/kube-controller-manager/leader-election-rolebinding.yaml",
+	"assets/kube-controller-manager/namespace-security-allocation-controller-clusterrole.yaml",
+	"assets/kube-controller-manager/namespace-security-allocation-controller-clusterrolebinding.yaml",
+	"assets/kube-controller-manager/podsecurity-admission-label-syncer-controller-clusterrole.yaml",
+	"assets/kube-controller-manager/podsecurity-admission-label-syncer-controller-clusterrolebinding.yaml",
+	"assets/kube-controller-manager/podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrole.yaml",
+	"assets/kube-controller-manager/podsecurity-admission-label-privileged-namespaces-syncer-controller-clusterrolebinding.yaml",
+	"assets/kube-controller-manager/namespace-openshift-infra.yaml",
+	"assets/kube-controller-manager/svc.yaml",
+	"assets/kube-controller-manager/sa.yaml",
+	recyclercontroller.ServiceAccountAsset,
+	"assets/kube-controller-manager/localhost-recovery-client-crb.yaml",
+	"assets/kube-controller-manager/localhost-recovery-sa.yaml",
+	"assets/kube-controller-manager/localhost-recovery-token.yaml",
+	"assets/kube-controller-manager/csr_approver_clusterrole.yaml",
+	"assets/kube-controller-manager/csr_approver_clusterrolebinding.yaml",
+}
+
+// vsphereAssets are applied by the static resource controller on vSphere.
+var vsphereAssets = []string{
+	"assets/kube-controller-manager/vsphere/legacy-cloud-provider-sa.yaml",
+	"assets/kube-controller-manager/vsphere/legacy-cloud-provider-role.yaml",
+	"assets/kube-controller-manager/vsphere/legacy-cloud-provider-binding.yaml",
+}
+
+// clusterPolicyControllerAssets are applied by the static resource controller unless the cluster-policy-controller is
+// disabled, and deleted when it is.
+var clusterPolicyControllerAssets = []string{
+	"assets/kube-controller-manager/leader-election-cluster-policy-controller-role.yaml",
+	"assets/kube-controller-manager/leader-election-cluster-policy-controller-rolebinding.yaml",
+}
+
+// gceAssets are deleted by the static resource controller.
+var gceAssets = []string{
+	"assets/kube-controller-manager/gce/cloud-provider-role.yaml",
+	"assets/kube-controller-manager/gce/cloud-provider-binding.yaml",
+}
+
+// DeploymentConfigMaps is a list of configmaps that are directly copied for the current values.  A different actor/controller modifies these.
 // the first element should be the configmap that contains the static pod manifest
-var deploymentConfigMaps = []revision.RevisionResource{
+var DeploymentConfigMaps = []revision.RevisionResource{
 	{Name: "kube-controller-manager-pod"},
 
 	{Name: "config"},
-	{Name: "cluster-policy-controller-config"},
+	// not present when the cluster-policy-controller is disabled, see targetconfigcontroller.ClusterPolicyControllerDisabled
+	{Name: "cluster-policy-controller-config", Optional: true},
 	{Name: "controller-manager-kubeconfig"},
 	{Name: "cloud-config", Optional: true},
 	{Name: "kube-controller-cert-syncer-kubeconfig"},
@@ -331,8 +567,8 @@ var deploymentConfigMaps = []revision.RevisionResource{
 	{Name: "recycler-config"},
 }
 
-// deploymentSecrets is a list of secrets that are directly copied for the current values.  A different actor/controller modifies these.
-var deploymentSecrets = []revision.RevisionResource{
+// DeploymentSecrets is a list of secrets that are directly copied for the current values.  A different actor/controller modifies these.
+var DeploymentSecrets = []revision.RevisionResource{
 	{Name: "service-account-private-key"},
 
 	// this cert is created by the service-ca controller, which doesn't come up until after we are available. this piece of config must be optional.
@@ -375,3 +611,17 @@ func newPlatformMatcherFn(platform configv1.PlatformType, infraInformer configin
 		return infraData.Status.PlatformStatus.Type == platform, true, nil
 	}
 }
+
+// clusterPolicyControllerDisabled returns whether the cluster-policy-controller is disabled, precheckSucceeded is false
+// as long as that cannot be determined.
+func clusterPolicyControllerDisabled(infraInformer configinformersv1.InfrastructureInformer) (disabled, precheckSucceeded bool) {
+	if !infraInformer.Informer().HasSynced() {
+		return false, false
+	}
+	disabled, err := targetconfigcontroller.ClusterPolicyControllerDisabled(infraInformer.Lister())
+	if err != nil {
+		klog.Errorf("Unable to determine whether the cluster-policy-controller is disabled: %v", err)
+		return false, false
+	}
+	return disabled, true
+}
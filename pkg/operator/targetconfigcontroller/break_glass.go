@@ -0,0 +1,77 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// BreakGlassConfigAnnotation on the KubeControllerManager CR names a configmap in the operator namespace whose
+	// config.yaml is a complete KubeControllerManagerConfig. While it is set the kube-controller-manager runs with
+	// that config instead of the observed config, the defaults and the unsupportedConfigOverrides. It is meant for
+	// support to restore the control plane when the config observation is broken, removing it resumes the observation.
+	BreakGlassConfigAnnotation = "operator.openshift.io/break-glass-config"
+
+	breakGlassConfigKey           = "config.yaml"
+	breakGlassConfigConditionType = "BreakGlassConfigDegraded"
+)
+
+// breakGlassConfig returns the config as JSON and the configmap the annotation of kcmOperator points at, no config
+// without the annotation.
+func breakGlassConfig(lister corev1listers.ConfigMapLister, kcmOperator *operatorv1.KubeControllerManager) ([]byte, string, error) {
+	name, ok := kcmOperator.Annotations[BreakGlassConfigAnnotation]
+	if !ok {
+		return nil, "", nil
+	}
+	source := fmt.Sprintf("configmap/%s -n %s", name, operatorclient.OperatorNamespace)
+	if len(name) == 0 {
+		return nil, source, fmt.Errorf("%s must name a configmap in namespace %s", BreakGlassConfigAnnotation, operatorclient.OperatorNamespace)
+	}
+	configMap, err := lister.ConfigMaps(operatorclient.OperatorNamespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil, source, fmt.Errorf("%s of %s does not exist", source, BreakGlassConfigAnnotation)
+	}
+	if err != nil {
+		return nil, source, err
+	}
+	config, err := yaml.YAMLToJSON([]byte(configMap.Data[breakGlassConfigKey]))
+	if err != nil {
+		return nil, source, fmt.Errorf("%s: %s: %v", source, breakGlassConfigKey, err)
+	}
+	// the config replaces the observed config, it needs what the observed config is required to have
+	if err := isRequiredConfigPresent(config); err != nil {
+		return nil, source, fmt.Errorf("%s: %s: %v", source, breakGlassConfigKey, err)
+	}
+	return config, source, nil
+}
+
+// setBreakGlassConfigCondition reports an active break-glass config loudly, it bypasses every change of the cluster
+// config until it is removed.
+func setBreakGlassConfigCondition(ctx context.Context, operatorClient v1helpers.StaticPodOperatorClient, source string, breakGlassErr error) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   breakGlassConfigConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	switch {
+	case breakGlassErr != nil:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "BreakGlassConfigInvalid"
+		condition.Message = fmt.Sprintf("The break-glass config cannot be used, no revision is rolled out until it is fixed or %s is removed: %v", BreakGlassConfigAnnotation, breakGlassErr)
+	case len(source) > 0:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "BreakGlassConfigActive"
+		condition.Message = fmt.Sprintf("Config observation is bypassed, kube-controller-manager runs with the static config of %s. Remove %s from kubecontrollermanager/cluster to resume the observation.", source, BreakGlassConfigAnnotation)
+	}
+	_, _, err := v1helpers.UpdateStaticPodStatus(ctx, operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
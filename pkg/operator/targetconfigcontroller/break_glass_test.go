@@ -0,0 +1,105 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const knownGoodConfig = `apiVersion: kubecontrolplane.config.openshift.io/v1
+kind: KubeControllerManagerConfig
+extendedArguments:
+  cluster-name:
+  - known-good
+  feature-gates:
+  - RotateKubeletServerCertificate=true
+featureGates:
+- RotateKubeletServerCertificate=true
+`
+
+func TestBreakGlassConfig(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "known-good"},
+		Data:       map[string]string{"config.yaml": knownGoodConfig},
+	}))
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: "incomplete"},
+		Data:       map[string]string{"config.yaml": "extendedArguments:\n  cluster-name:\n  - known-good\n"},
+	}))
+	lister := corev1listers.NewConfigMapLister(indexer)
+	client := fake.NewSimpleClientset()
+	recorder := events.NewInMemoryRecorder("test")
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+	// replaced by the break-glass config while it is active
+	operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+		ObservedConfig:             runtime.RawExtension{Raw: []byte(`{"extendedArguments":{"cluster-name":["observed"]}}`)},
+		UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"extendedArguments":{"node-monitor-grace-period":["50s"]}}`)},
+	}}
+	kcmOperator := &operatorv1.KubeControllerManager{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+	// sync runs the steps of TargetConfigController.sync that depend on the break-glass config
+	sync := func() (map[string]interface{}, *operatorv1.OperatorCondition, error) {
+		config, source, err := breakGlassConfig(lister, kcmOperator)
+		require.NoError(t, setBreakGlassConfigCondition(context.TODO(), operatorClient, source, err))
+		_, status, _, statusErr := operatorClient.GetStaticPodOperatorState()
+		require.NoError(t, statusErr)
+		condition := v1helpers.FindOperatorCondition(status.Conditions, breakGlassConfigConditionType)
+		if err != nil {
+			return nil, condition, err
+		}
+		configMap, _, err := manageKubeControllerManagerConfig(context.TODO(), client.CoreV1(), recorder, operatorSpec, config)
+		require.NoError(t, err)
+		return extendedArgumentsOf(t, []byte(configMap.Data["config.yaml"])), condition, nil
+	}
+
+	extendedArguments, condition, err := sync()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"observed"}, extendedArguments["cluster-name"])
+	assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+
+	// activated
+	kcmOperator.Annotations = map[string]string{BreakGlassConfigAnnotation: "known-good"}
+	extendedArguments, condition, err = sync()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cluster-name":  []interface{}{"known-good"},
+		"feature-gates": []interface{}{"RotateKubeletServerCertificate=true"},
+	}, extendedArguments, "expected the static config without the defaults, observed config and overrides")
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Equal(t, "BreakGlassConfigActive", condition.Reason)
+	assert.Contains(t, condition.Message, "configmap/known-good -n openshift-kube-controller-manager-operator")
+
+	// a config missing required paths is not rolled out
+	kcmOperator.Annotations[BreakGlassConfigAnnotation] = "incomplete"
+	_, condition, err = sync()
+	assert.EqualError(t, err, "configmap/incomplete -n openshift-kube-controller-manager-operator: config.yaml: extendedArguments.feature-gates missing from config")
+	assert.Equal(t, operatorv1.ConditionTrue, condition.Status)
+	assert.Equal(t, "BreakGlassConfigInvalid", condition.Reason)
+
+	kcmOperator.Annotations[BreakGlassConfigAnnotation] = "missing"
+	_, _, err = sync()
+	assert.EqualError(t, err, "configmap/missing -n openshift-kube-controller-manager-operator of operator.openshift.io/break-glass-config does not exist")
+
+	// deactivated
+	delete(kcmOperator.Annotations, BreakGlassConfigAnnotation)
+	extendedArguments, condition, err = sync()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"observed"}, extendedArguments["cluster-name"])
+	assert.Equal(t, []interface{}{"50s"}, extendedArguments["node-monitor-grace-period"])
+	assert.Contains(t, extendedArguments, "leader-elect")
+	assert.Equal(t, operatorv1.ConditionFalse, condition.Status)
+}
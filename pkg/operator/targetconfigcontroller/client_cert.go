@@ -0,0 +1,127 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+const (
+	// ClientCertHashAnnotation of the operand pod is the hash of the client certificate kube-controller-manager
+	// authenticates to the kube-apiserver with. A rotation of the certificate changes the pod, which rolls out a new
+	// revision rather than leaving the old certificate in place until it expires.
+	ClientCertHashAnnotation = "kube-controller-manager.openshift.io/client-cert-hash"
+	// ClientCertNotBeforeAnnotation and ClientCertNotAfterAnnotation of the operand pod are the validity of the client
+	// certificate of the revision in RFC3339 format.
+	ClientCertNotBeforeAnnotation = "kube-controller-manager.openshift.io/client-cert-not-before"
+	ClientCertNotAfterAnnotation  = "kube-controller-manager.openshift.io/client-cert-not-after"
+
+	clientCertSecret        = "kube-controller-manager-client-cert-key"
+	clientCertConditionType = "ClientCertRevisionProgressing"
+
+	// clientCertExpiringRatio is the share of its lifetime the client certificate of a running revision has left when
+	// it is reported. The certificate is rotated at half of its lifetime.
+	clientCertExpiringRatio = 0.2
+)
+
+// clientCertAnnotations returns the annotations that pin the client certificate synced into the target namespace to
+// the operand pod, none until it is synced. Only the certificate is hashed, updates of the metadata alone keep them.
+func clientCertAnnotations(ctx context.Context, secretsGetter corev1client.SecretsGetter) (map[string]string, error) {
+	secret, err := secretsGetter.Secrets(operatorclient.TargetNamespace).Get(ctx, clientCertSecret, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	certPEM := secret.Data["tls.crt"]
+	if len(certPEM) == 0 {
+		return nil, nil
+	}
+	certificates, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("secret/%s -n %s: %v", clientCertSecret, operatorclient.TargetNamespace, err)
+	}
+	return map[string]string{
+		ClientCertHashAnnotation:      fmt.Sprintf("%x", sha256.Sum256(certPEM)),
+		ClientCertNotBeforeAnnotation: certificates[0].NotBefore.UTC().Format(time.RFC3339),
+		ClientCertNotAfterAnnotation:  certificates[0].NotAfter.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// setClientCertRevisionCondition reports the nodes that run a revision the client certificate of which is close to its
+// expiry. That is a revision with the rotated certificate that does not roll out, or a certificate that is not
+// rotated. Revisions older than ClientCertHashAnnotation are not checked.
+func setClientCertRevisionCondition(ctx context.Context, operatorClient v1helpers.StaticPodOperatorClient, configMapLister corev1listers.ConfigMapNamespaceLister, secretsGetter corev1client.SecretsGetter, now time.Time) error {
+	_, status, _, err := operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return err
+	}
+	live, err := clientCertAnnotations(ctx, secretsGetter)
+	if err != nil {
+		return err
+	}
+
+	var expiring []string
+	for _, nodeStatus := range status.NodeStatuses {
+		if nodeStatus.CurrentRevision == 0 {
+			continue
+		}
+		podConfigMap, err := configMapLister.Get(fmt.Sprintf("kube-controller-manager-pod-%d", nodeStatus.CurrentRevision))
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		pod, err := resourceread.ReadPodV1([]byte(podConfigMap.Data["pod.yaml"]))
+		if err != nil {
+			return fmt.Errorf("configmap/%s -n %s: %v", podConfigMap.Name, podConfigMap.Namespace, err)
+		}
+		notBefore, err := time.Parse(time.RFC3339, pod.Annotations[ClientCertNotBeforeAnnotation])
+		if err != nil {
+			continue
+		}
+		notAfter, err := time.Parse(time.RFC3339, pod.Annotations[ClientCertNotAfterAnnotation])
+		if err != nil {
+			continue
+		}
+		if remaining := notAfter.Sub(now); remaining >= time.Duration(clientCertExpiringRatio*float64(notAfter.Sub(notBefore))) {
+			continue
+		}
+		state := "it is not rotated yet"
+		if pod.Annotations[ClientCertHashAnnotation] != live[ClientCertHashAnnotation] {
+			state = "a revision with the rotated certificate is pending"
+		}
+		expiring = append(expiring, fmt.Sprintf("node %s runs revision %d, the client certificate of which expires at %s: %s", nodeStatus.NodeName, nodeStatus.CurrentRevision, notAfter.Format(time.RFC3339), state))
+	}
+	sort.Strings(expiring)
+
+	condition := operatorv1.OperatorCondition{
+		Type:   clientCertConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(expiring) > 0 {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "ClientCertExpiring"
+		condition.Message = fmt.Sprintf("The client certificate of kube-controller-manager is close to its expiry:\n%s", strings.Join(expiring, "\n"))
+	}
+	_, _, err = v1helpers.UpdateStaticPodStatus(ctx, operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
@@ -0,0 +1,175 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// clientCertSecretFor returns the client certificate secret with a new certificate valid for lifetime.
+func clientCertSecretFor(t *testing.T, lifetime time.Duration) (*corev1.Secret, *crypto.TLSCertificateConfig) {
+	t.Helper()
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration("system:kube-controller-manager", lifetime)
+	require.NoError(t, err)
+	certPEM, keyPEM, err := ca.GetPEMBytes()
+	require.NoError(t, err)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: clientCertSecret},
+		Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+	}, ca
+}
+
+func TestClientCertRotationRollsOutARevision(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	secret, _ := clientCertSecretFor(t, 30*24*time.Hour)
+	client := fake.NewSimpleClientset(secret)
+	informers := v1helpers.NewKubeInformersForNamespaces(client, operatorclient.TargetNamespace)
+	operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed, ObservedConfig: runtime.RawExtension{Raw: []byte(`{}`)}}},
+		&operatorv1.StaticPodOperatorStatus{},
+		nil,
+		nil,
+	)
+	revisions := revisioncontroller.NewRevisionController(
+		operatorclient.TargetNamespace,
+		[]revisioncontroller.RevisionResource{{Name: "kube-controller-manager-pod"}},
+		nil,
+		informers.InformersFor(operatorclient.TargetNamespace),
+		revisioncontroller.StaticPodLatestRevisionClient{StaticPodOperatorClient: operatorClient},
+		client.CoreV1(),
+		client.CoreV1(),
+		events.NewInMemoryRecorder("test"),
+	)
+	informers.Start(ctx.Done())
+	operatorSpec, _, _, err := operatorClient.GetStaticPodOperatorState()
+	require.NoError(t, err)
+
+	// rollOut renders the pod and syncs the revision controller until it created the revision
+	rollOut := func(revision int32) *corev1.Pod {
+		t.Helper()
+		podConfigMap, _, err := managePod(ctx, client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, "kcm-image", "operator-image", "cpc-image", false, true, false)
+		require.NoError(t, err)
+		require.NoError(t, wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			if err := revisions.Sync(ctx, factory.NewSyncContext("RevisionController", events.NewInMemoryRecorder("test"))); err != nil {
+				return false, nil
+			}
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			return err == nil && status.LatestAvailableRevision >= revision, err
+		}))
+		_, status, _, err := operatorClient.GetStaticPodOperatorState()
+		require.NoError(t, err)
+		assert.Equal(t, revision, status.LatestAvailableRevision)
+		return resourceread.ReadPodV1OrDie([]byte(podConfigMap.Data["pod.yaml"]))
+	}
+
+	pod := rollOut(1)
+	oldHash := pod.Annotations[ClientCertHashAnnotation]
+	require.NotEmpty(t, oldHash)
+
+	// the rotated certificate changes the revisioned pod
+	rotated, rotatedCert := clientCertSecretFor(t, 30*24*time.Hour)
+	_, err = client.CoreV1().Secrets(operatorclient.TargetNamespace).Update(ctx, rotated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	pod = rollOut(2)
+	assert.NotEqual(t, oldHash, pod.Annotations[ClientCertHashAnnotation])
+	assert.Equal(t, rotatedCert.Certs[0].NotAfter.UTC().Format(time.RFC3339), pod.Annotations[ClientCertNotAfterAnnotation])
+
+	revision2, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(ctx, "kube-controller-manager-pod-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, pod.Annotations[ClientCertHashAnnotation], resourceread.ReadPodV1OrDie([]byte(revision2.Data["pod.yaml"])).Annotations[ClientCertHashAnnotation])
+}
+
+func TestClientCertRevisionCondition(t *testing.T) {
+	secret, certificate := clientCertSecretFor(t, 30*24*time.Hour)
+	client := fake.NewSimpleClientset(secret)
+	live, err := clientCertAnnotations(context.TODO(), client.CoreV1())
+	require.NoError(t, err)
+	notAfter := certificate.Certs[0].NotAfter
+
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	addRevision := func(revision int, annotations map[string]string) {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager", Annotations: annotations}}
+		require.NoError(t, configMaps.Add(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "kube-controller-manager-pod-" + fmt.Sprint(revision)},
+			Data:       map[string]string{"pod.yaml": resourceread.WritePodV1OrDie(pod)},
+		}))
+	}
+	// revision 1 predates the annotations, revision 2 has the current certificate, revision 3 a previous one
+	addRevision(1, nil)
+	addRevision(2, live)
+	addRevision(3, map[string]string{
+		ClientCertHashAnnotation:      "previous",
+		ClientCertNotBeforeAnnotation: notAfter.Add(-30 * 24 * time.Hour).Format(time.RFC3339),
+		ClientCertNotAfterAnnotation:  notAfter.Format(time.RFC3339),
+	})
+
+	tests := []struct {
+		name            string
+		revisions       []int32
+		now             time.Time
+		expectedStatus  operatorv1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name:           "fresh certificate",
+			revisions:      []int32{2, 3},
+			now:            notAfter.Add(-10 * 24 * time.Hour),
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:            "rotated certificate not rolled out",
+			revisions:       []int32{2, 3},
+			now:             notAfter.Add(-2 * 24 * time.Hour),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedMessage: "The client certificate of kube-controller-manager is close to its expiry:\nnode master-0 runs revision 2, the client certificate of which expires at " + notAfter.UTC().Format(time.RFC3339) + ": it is not rotated yet\nnode master-1 runs revision 3, the client certificate of which expires at " + notAfter.UTC().Format(time.RFC3339) + ": a revision with the rotated certificate is pending",
+		},
+		{
+			name:           "revisions without the annotations",
+			revisions:      []int32{1, 1},
+			now:            notAfter.Add(time.Hour),
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}},
+				&operatorv1.StaticPodOperatorStatus{NodeStatuses: []operatorv1.NodeStatus{
+					{NodeName: "master-0", CurrentRevision: test.revisions[0]},
+					{NodeName: "master-1", CurrentRevision: test.revisions[1]},
+				}},
+				nil,
+				nil,
+			)
+			require.NoError(t, setClientCertRevisionCondition(context.TODO(), operatorClient, corev1listers.NewConfigMapLister(configMaps).ConfigMaps(operatorclient.TargetNamespace), client.CoreV1(), test.now))
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			require.NoError(t, err)
+			condition := v1helpers.FindOperatorCondition(status.Conditions, clientCertConditionType)
+			require.NotNil(t, condition)
+			assert.Equal(t, test.expectedStatus, condition.Status)
+			assert.Equal(t, test.expectedMessage, condition.Message)
+		})
+	}
+}
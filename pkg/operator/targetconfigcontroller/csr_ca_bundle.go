@@ -0,0 +1,148 @@
+package targetconfigcontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// csrCABundleExpiryGracePeriod is how long past their expiry certificates stay in the csr-controller-ca bundle, clients
+// with a clock running behind must still be able to verify them.
+const csrCABundleExpiryGracePeriod = 10 * time.Minute
+
+// csrCABundleInputs are the bundles csr-controller-ca combines: the csr-signer certificates the CSRs are signed with and
+// the csr-signer-signer CAs the csr-signer certificates are signed with.
+var csrCABundleInputs = []string{"csr-signer-ca", "csr-controller-signer-ca"}
+
+func ManageCSRCABundle(ctx context.Context, lister corev1listers.ConfigMapLister, client corev1client.ConfigMapsGetter, recorder events.Recorder) (*corev1.ConfigMap, bool, error) {
+	return manageCSRCABundle(ctx, lister, client, recorder, time.Now())
+}
+
+// manageCSRCABundle combines the csr-controller-ca bundle from csrCABundleInputs. Certificates that expired more than
+// csrCABundleExpiryGracePeriod before now are pruned, except for the two newest of every input: the current signer and
+// its predecessor always remain trusted. The bundle is only written when the certificates it contains change, a new
+// order of the same certificates would roll out new revisions of its consumers for nothing.
+func manageCSRCABundle(ctx context.Context, lister corev1listers.ConfigMapLister, client corev1client.ConfigMapsGetter, recorder events.Recorder, now time.Time) (*corev1.ConfigMap, bool, error) {
+	var certificates []*x509.Certificate
+	for _, name := range csrCABundleInputs {
+		input, err := lister.ConfigMaps(operatorclient.OperatorNamespace).Get(name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if len(input.Data["ca-bundle.crt"]) == 0 {
+			continue
+		}
+		inputCertificates, err := cert.ParseCertsPEM([]byte(input.Data["ca-bundle.crt"]))
+		if err != nil {
+			return nil, false, fmt.Errorf("configmap/%s in %q is malformed: %v", name, operatorclient.OperatorNamespace, err)
+		}
+		certificates = append(certificates, pruneExpiredCertificates(inputCertificates, now)...)
+	}
+	certificates = uniqueCertificates(certificates)
+
+	caBytes, err := crypto.EncodeCertificates(certificates...)
+	if err != nil {
+		return nil, false, err
+	}
+	required := &corev1.ConfigMap{
+		ObjectMeta: certrotation.NewTLSArtifactObjectMeta("csr-controller-ca", operatorclient.OperatorNamespace, "kube-controller-manager", ""),
+		Data:       map[string]string{"ca-bundle.crt": string(caBytes)},
+	}
+
+	existing, err := lister.ConfigMaps(operatorclient.OperatorNamespace).Get("csr-controller-ca")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+	if err == nil {
+		// a bundle that cannot be parsed is replaced
+		existingCertificates, _ := cert.ParseCertsPEM([]byte(existing.Data["ca-bundle.crt"]))
+		if sameCertificates(existingCertificates, certificates) {
+			required.Data["ca-bundle.crt"] = existing.Data["ca-bundle.crt"]
+		} else if pruned := missingCertificates(existingCertificates, certificates); len(pruned) > 0 {
+			recorder.Eventf("CSRCABundlePruned", "Removed expired certificates from configmap/csr-controller-ca -n %s: %s", operatorclient.OperatorNamespace, strings.Join(subjects(pruned), ", "))
+		}
+	}
+	return resourceapply.ApplyConfigMap(ctx, client, recorder, required)
+}
+
+// pruneExpiredCertificates returns the certificates of a bundle that did not expire csrCABundleExpiryGracePeriod before
+// now and the two newest ones, whether they expired or not.
+func pruneExpiredCertificates(certificates []*x509.Certificate, now time.Time) []*x509.Certificate {
+	newest := make([]*x509.Certificate, len(certificates))
+	copy(newest, certificates)
+	sort.SliceStable(newest, func(i, j int) bool { return newest[i].NotBefore.After(newest[j].NotBefore) })
+	if len(newest) > 2 {
+		newest = newest[:2]
+	}
+
+	var retained []*x509.Certificate
+	for _, certificate := range certificates {
+		if certificate.NotAfter.Add(csrCABundleExpiryGracePeriod).After(now) || containsCertificate(newest, certificate) {
+			retained = append(retained, certificate)
+		}
+	}
+	return retained
+}
+
+func uniqueCertificates(certificates []*x509.Certificate) []*x509.Certificate {
+	var unique []*x509.Certificate
+	for _, certificate := range certificates {
+		if !containsCertificate(unique, certificate) {
+			unique = append(unique, certificate)
+		}
+	}
+	return unique
+}
+
+func containsCertificate(certificates []*x509.Certificate, certificate *x509.Certificate) bool {
+	for _, c := range certificates {
+		if bytes.Equal(c.Raw, certificate.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingCertificates returns the certificates of from that are not in to.
+func missingCertificates(from, to []*x509.Certificate) []*x509.Certificate {
+	var missing []*x509.Certificate
+	for _, certificate := range from {
+		if !containsCertificate(to, certificate) {
+			missing = append(missing, certificate)
+		}
+	}
+	return missing
+}
+
+// sameCertificates returns whether a and b contain the same certificates in any order.
+func sameCertificates(a, b []*x509.Certificate) bool {
+	return len(missingCertificates(a, b)) == 0 && len(missingCertificates(b, a)) == 0
+}
+
+func subjects(certificates []*x509.Certificate) []string {
+	var names []string
+	for _, certificate := range certificates {
+		names = append(names, certificate.Subject.String())
+	}
+	return names
+}
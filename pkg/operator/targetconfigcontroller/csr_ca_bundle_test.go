@@ -0,0 +1,187 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/certrotation"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// caCertificate returns a self-signed CA certificate named name valid from notBefore to notAfter.
+func caCertificate(t *testing.T, name string, notBefore, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func TestManageCSRCABundle(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	// csr-signer certificates, oldest first
+	signerExpired := caCertificate(t, "signer-expired", now.Add(-90*day), now.Add(-60*day))
+	signerInGrace := caCertificate(t, "signer-in-grace", now.Add(-60*day), now.Add(-time.Minute))
+	signerPrevious := caCertificate(t, "signer-previous", now.Add(-30*day), now.Add(day))
+	signerCurrent := caCertificate(t, "signer-current", now.Add(-day), now.Add(29*day))
+	// csr-signer-signer CAs, oldest first
+	caExpired := caCertificate(t, "ca-expired", now.Add(-300*day), now.Add(-240*day))
+	caPrevious := caCertificate(t, "ca-previous", now.Add(-240*day), now.Add(-120*day))
+	caCurrent := caCertificate(t, "ca-current", now.Add(-120*day), now.Add(-time.Hour))
+
+	bundle := func(certificates ...*x509.Certificate) string {
+		t.Helper()
+		pem, err := crypto.EncodeCertificates(certificates...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem)
+	}
+	configMap := func(name string, certificates ...*x509.Certificate) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: name},
+			Data:       map[string]string{"ca-bundle.crt": bundle(certificates...)},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		signers          []*x509.Certificate
+		cas              []*x509.Certificate
+		existing         []*x509.Certificate
+		expected         []*x509.Certificate
+		expectedModified bool
+		expectedPruned   string
+	}{
+		{
+			name:             "mixed expired and valid certificates",
+			signers:          []*x509.Certificate{signerExpired, signerInGrace, signerPrevious, signerCurrent},
+			cas:              []*x509.Certificate{caCurrent, caExpired, caPrevious},
+			existing:         []*x509.Certificate{signerExpired, signerInGrace, signerPrevious, signerCurrent, caCurrent, caExpired, caPrevious},
+			expected:         []*x509.Certificate{signerInGrace, signerPrevious, signerCurrent, caCurrent, caPrevious},
+			expectedModified: true,
+			expectedPruned:   "CN=signer-expired, CN=ca-expired",
+		},
+		{
+			name:             "new bundle",
+			signers:          []*x509.Certificate{signerExpired, signerCurrent},
+			cas:              []*x509.Certificate{caCurrent},
+			expected:         []*x509.Certificate{signerExpired, signerCurrent, caCurrent},
+			expectedModified: true,
+		},
+		{
+			name:             "added certificate",
+			signers:          []*x509.Certificate{signerPrevious, signerCurrent},
+			cas:              []*x509.Certificate{caCurrent},
+			existing:         []*x509.Certificate{signerPrevious, caCurrent},
+			expected:         []*x509.Certificate{signerPrevious, signerCurrent, caCurrent},
+			expectedModified: true,
+		},
+		{
+			name:     "same certificates in another order",
+			signers:  []*x509.Certificate{signerPrevious, signerCurrent},
+			cas:      []*x509.Certificate{caPrevious, caCurrent},
+			existing: []*x509.Certificate{caCurrent, signerCurrent, caPrevious, signerPrevious},
+			expected: []*x509.Certificate{caCurrent, signerCurrent, caPrevious, signerPrevious},
+		},
+		{
+			name:     "nothing to prune",
+			signers:  []*x509.Certificate{signerPrevious, signerCurrent},
+			existing: []*x509.Certificate{signerPrevious, signerCurrent},
+			expected: []*x509.Certificate{signerPrevious, signerCurrent},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			client := fake.NewSimpleClientset()
+			objects := []*corev1.ConfigMap{configMap("csr-signer-ca", test.signers...)}
+			if len(test.cas) > 0 {
+				objects = append(objects, configMap("csr-controller-signer-ca", test.cas...))
+			}
+			if test.existing != nil {
+				existing := configMap("csr-controller-ca", test.existing...)
+				existing.ObjectMeta = certrotation.NewTLSArtifactObjectMeta("csr-controller-ca", operatorclient.OperatorNamespace, "kube-controller-manager", "")
+				objects = append(objects, existing)
+			}
+			for _, object := range objects {
+				if err := indexer.Add(object); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := client.CoreV1().ConfigMaps(object.Namespace).Create(context.TODO(), object, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			recorder := events.NewInMemoryRecorder("test")
+
+			_, modified, err := manageCSRCABundle(context.TODO(), corev1listers.NewConfigMapLister(indexer), client.CoreV1(), recorder, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			actual, err := client.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), "csr-controller-ca", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualCertificates, err := cert.ParseCertsPEM([]byte(actual.Data["ca-bundle.crt"]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actualSubjects, expectedSubjects := strings.Join(subjects(actualCertificates), ", "), strings.Join(subjects(test.expected), ", "); actualSubjects != expectedSubjects {
+				t.Errorf("expected the bundle %s, got %s", expectedSubjects, actualSubjects)
+			}
+
+			var pruned []string
+			for _, event := range recorder.Events() {
+				if event.Reason == "CSRCABundlePruned" {
+					pruned = append(pruned, event.Message)
+				}
+			}
+			switch {
+			case len(test.expectedPruned) == 0 && len(pruned) > 0:
+				t.Errorf("expected no certificates to be pruned, got %v", pruned)
+			case len(test.expectedPruned) > 0 && (len(pruned) != 1 || !strings.HasSuffix(pruned[0], ": "+test.expectedPruned)):
+				t.Errorf("expected %s to be pruned, got %v", test.expectedPruned, pruned)
+			}
+		})
+	}
+}
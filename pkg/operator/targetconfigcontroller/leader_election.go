@@ -0,0 +1,54 @@
+package targetconfigcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+)
+
+// operandLeaderElectionDefaults are the leader election timings of kube-controller-manager without a flag.
+var operandLeaderElectionDefaults = map[string]time.Duration{
+	"leader-elect-lease-duration": 15 * time.Second,
+	"leader-elect-renew-deadline": 10 * time.Second,
+	"leader-elect-retry-period":   2 * time.Second,
+}
+
+// validateLeaderElection checks the leader election timings of the rendered kube-controller-manager config, e.g.
+// after unsupportedConfigOverrides changed them. The elector of kube-controller-manager refuses to start with
+// timings it cannot renew the lease with, the config must not be rolled out.
+func validateLeaderElection(configMap *corev1.ConfigMap) error {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(configMap.Data["config.yaml"]), &config); err != nil {
+		return fmt.Errorf("failed to unmarshal the kube-controller-manager config: %v", err)
+	}
+	extendedArguments, _ := config["extendedArguments"].(map[string]interface{})
+	if values, _ := extendedArguments["leader-elect"].([]interface{}); len(values) == 1 && values[0] == "false" {
+		return nil
+	}
+
+	timings := map[string]time.Duration{}
+	for name, defaultDuration := range operandLeaderElectionDefaults {
+		values, _ := extendedArguments[name].([]interface{})
+		if len(values) == 0 {
+			timings[name] = defaultDuration
+			continue
+		}
+		value, _ := values[len(values)-1].(string)
+		duration, err := validation.DurationBetween("extendedArguments."+name, value, time.Second, 0)
+		if err != nil {
+			return err
+		}
+		timings[name] = duration
+	}
+	return validation.LeaderElection(
+		"extendedArguments.leader-elect-renew-deadline",
+		"extendedArguments.leader-elect-retry-period",
+		timings["leader-elect-lease-duration"],
+		timings["leader-elect-renew-deadline"],
+		timings["leader-elect-retry-period"],
+	)
+}
@@ -0,0 +1,68 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestOperandLeaderElectionOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		overrides   string
+		expectedErr string
+	}{
+		{
+			name:      "slow etcd",
+			overrides: `{"extendedArguments":{"leader-elect-lease-duration":["60s"],"leader-elect-renew-deadline":["40s"],"leader-elect-retry-period":["10s"]}}`,
+		},
+		{
+			// the default lease duration of kube-controller-manager is 15s
+			name:        "renew deadline of the default lease duration",
+			overrides:   `{"extendedArguments":{"leader-elect-renew-deadline":["15s"]}}`,
+			expectedErr: `extendedArguments.leader-elect-renew-deadline: "15s" must be shorter than the lease duration 15s`,
+		},
+		{
+			name:        "retry period longer than the renew deadline",
+			overrides:   `{"extendedArguments":{"leader-elect-lease-duration":["60s"],"leader-elect-renew-deadline":["40s"],"leader-elect-retry-period":["45s"]}}`,
+			expectedErr: `extendedArguments.leader-elect-retry-period: "45s" must be shorter than the renew deadline 40s divided by 1.2`,
+		},
+		{
+			name:        "no duration",
+			overrides:   `{"extendedArguments":{"leader-elect-lease-duration":["0s"]}}`,
+			expectedErr: `extendedArguments.leader-elect-lease-duration: "0s" must be a duration of at least 1s`,
+		},
+		{
+			name:      "leader election disabled",
+			overrides: `{"extendedArguments":{"leader-elect":["false"],"leader-elect-renew-deadline":["15s"]}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+				UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(test.overrides)},
+			}}
+
+			_, _, err := manageKubeControllerManagerConfig(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, nil)
+			if len(test.expectedErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.expectedErr)
+			// the config is not rolled out
+			_, err = client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(context.TODO(), "config", metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err), "expected no config, got %v", err)
+		})
+	}
+}
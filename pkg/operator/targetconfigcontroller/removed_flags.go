@@ -0,0 +1,109 @@
+package targetconfigcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// bundledRelease is the kube-controller-manager minor release shipped with this operator.
+const bundledRelease = "1.29"
+
+// removedFlag is a kube-controller-manager flag that was removed upstream.
+type removedFlag struct {
+	// removedIn is the first kube-controller-manager release rejecting the flag at startup
+	removedIn string
+	// migration tells the admin what replaces the flag, it is part of the event reporting its removal
+	migration string
+}
+
+// removedFlags are the flags that lingering unsupportedConfigOverrides or old observed configs still carry, but the
+// bundled kube-controller-manager no longer accepts. They are dropped from the rendered config instead of failing
+// the operand at startup. Add every flag removed upstream when bumping bundledRelease,
+// TestRemovedUpstreamFlagsHaveAnEntry enforces that against the flags of the bundled release.
+var removedFlags = map[string]removedFlag{
+	"deleting-pods-burst": {
+		removedIn: "1.22",
+		migration: "it had no effect, remove it",
+	},
+	"deleting-pods-qps": {
+		removedIn: "1.22",
+		migration: "it had no effect, remove it",
+	},
+	"horizontal-pod-autoscaler-downscale-delay": {
+		removedIn: "1.22",
+		migration: "use --horizontal-pod-autoscaler-downscale-stabilization",
+	},
+	"horizontal-pod-autoscaler-upscale-delay": {
+		removedIn: "1.22",
+		migration: "scaling up is no longer delayed, configure behavior.scaleUp of the HorizontalPodAutoscalers instead",
+	},
+	"horizontal-pod-autoscaler-use-rest-clients": {
+		removedIn: "1.22",
+		migration: "the autoscaler always uses the metrics APIs, remove it",
+	},
+	"register-retry-count": {
+		removedIn: "1.22",
+		migration: "it had no effect, remove it",
+	},
+	"address": {
+		removedIn: "1.24",
+		migration: "the insecure port was removed, metrics and health checks are served on --secure-port bound to --bind-address",
+	},
+	"port": {
+		removedIn: "1.24",
+		migration: "the insecure port was removed, metrics and health checks are served on --secure-port",
+	},
+	"experimental-cluster-signing-duration": {
+		removedIn: "1.25",
+		migration: "use --cluster-signing-duration",
+	},
+	"enable-taint-manager": {
+		removedIn: "1.27",
+		migration: "the taint manager is always enabled, remove it",
+	},
+	"pod-eviction-timeout": {
+		removedIn: "1.27",
+		migration: "pods are evicted by the taint manager, set tolerationSeconds for the node.kubernetes.io/not-ready and node.kubernetes.io/unreachable taints instead",
+	},
+}
+
+// removedFlagsOf returns the names of the flags of extendedArguments kubeRelease no longer accepts.
+func removedFlagsOf(extendedArguments map[string]interface{}, kubeRelease string) []string {
+	var removed []string
+	for name := range extendedArguments {
+		if flag, ok := removedFlags[name]; ok && release.Compare(flag.removedIn, kubeRelease) <= 0 {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// pruneRemovedFlags drops the flags the bundled kube-controller-manager no longer accepts from the config.yaml of
+// configMap. It returns the names of the dropped flags.
+func pruneRemovedFlags(configMap *corev1.ConfigMap) ([]string, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(configMap.Data["config.yaml"]), &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the kube-controller-manager config: %v", err)
+	}
+	extendedArguments, _ := config["extendedArguments"].(map[string]interface{})
+	removed := removedFlagsOf(extendedArguments, bundledRelease)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	for _, name := range removed {
+		delete(extendedArguments, name)
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	configMap.Data["config.yaml"] = string(configBytes)
+	return removed, nil
+}
@@ -0,0 +1,132 @@
+package targetconfigcontroller
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/release"
+)
+
+// readTestdata returns the lines of a testdata file, without comments and empty lines.
+func readTestdata(t *testing.T, name string) []string {
+	file, err := os.Open("testdata/" + name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func extendedArgumentsOf(t *testing.T, config []byte) map[string]interface{} {
+	var parsed map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(config, &parsed))
+	extendedArguments, _ := parsed["extendedArguments"].(map[string]interface{})
+	return extendedArguments
+}
+
+func TestRemovedUpstreamFlagsHaveAnEntry(t *testing.T) {
+	allowlist := sets.New(readTestdata(t, "kube-controller-manager-flags.txt")...)
+
+	removedUpstream := sets.New[string]()
+	for _, line := range readTestdata(t, "removed-upstream-flags.txt") {
+		name, removedIn, _ := strings.Cut(line, " ")
+		removedUpstream.Insert(name)
+		flag, ok := removedFlags[name]
+		if !assert.True(t, ok, "--%s was removed in %s and needs an entry in removedFlags", name, removedIn) {
+			continue
+		}
+		assert.Equal(t, removedIn, flag.removedIn, "--%s", name)
+		assert.NotEmpty(t, flag.migration, "--%s needs a migration note", name)
+	}
+
+	for name, flag := range removedFlags {
+		if release.Compare(flag.removedIn, bundledRelease) > 0 {
+			continue
+		}
+		assert.True(t, removedUpstream.Has(name), "--%s is not listed as removed upstream", name)
+		assert.False(t, allowlist.Has(name), "--%s is still accepted by kube-controller-manager %s", name, bundledRelease)
+	}
+}
+
+func TestDefaultConfigFlagsAreAccepted(t *testing.T) {
+	allowlist := sets.New(readTestdata(t, "kube-controller-manager-flags.txt")...)
+	for name := range extendedArgumentsOf(t, bindata.MustAsset("assets/config/defaultconfig.yaml")) {
+		assert.True(t, allowlist.Has(name), "--%s is not accepted by kube-controller-manager %s", name, bundledRelease)
+	}
+}
+
+func TestRemovedFlagsOfRelease(t *testing.T) {
+	extendedArguments := map[string]interface{}{
+		"pod-eviction-timeout":                  []interface{}{"5m"},
+		"experimental-cluster-signing-duration": []interface{}{"720h"},
+		"cluster-signing-duration":              []interface{}{"720h"},
+	}
+	assert.Equal(t, []string{"experimental-cluster-signing-duration"}, removedFlagsOf(extendedArguments, "1.26"))
+	assert.Equal(t, []string{"experimental-cluster-signing-duration", "pod-eviction-timeout"}, removedFlagsOf(extendedArguments, "1.27"))
+	assert.Empty(t, removedFlagsOf(extendedArguments, "1.24"))
+}
+
+func TestStaleFlagsArePruned(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := events.NewInMemoryRecorder("test")
+	operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+		ObservedConfig:             runtime.RawExtension{Raw: []byte(`{"extendedArguments":{"cluster-name":["test"],"enable-taint-manager":["true"]}}`)},
+		UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"extendedArguments":{"pod-eviction-timeout":["5m"],"port":["0"],"node-monitor-grace-period":["50s"]}}`)},
+	}}
+
+	configMap, modified, err := manageKubeControllerManagerConfig(context.TODO(), client.CoreV1(), recorder, operatorSpec, nil)
+	require.NoError(t, err)
+	require.True(t, modified)
+
+	extendedArguments := extendedArgumentsOf(t, []byte(configMap.Data["config.yaml"]))
+	for _, name := range []string{"enable-taint-manager", "pod-eviction-timeout", "port"} {
+		assert.NotContains(t, extendedArguments, name)
+	}
+	assert.Equal(t, []interface{}{"test"}, extendedArguments["cluster-name"])
+	assert.Equal(t, []interface{}{"50s"}, extendedArguments["node-monitor-grace-period"])
+
+	allowlist := sets.New(readTestdata(t, "kube-controller-manager-flags.txt")...)
+	for _, arg := range GetKubeControllerManagerArgs(map[string]interface{}{"extendedArguments": extendedArguments}) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		assert.True(t, allowlist.Has(name), "rendered %s is not accepted by kube-controller-manager %s", arg, bundledRelease)
+	}
+
+	var pruned []string
+	for _, event := range recorder.Events() {
+		if event.Reason == "RemovedFlagPruned" {
+			pruned = append(pruned, event.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		"--enable-taint-manager was removed in kube-controller-manager 1.27 and is not passed on: " + removedFlags["enable-taint-manager"].migration,
+		"--pod-eviction-timeout was removed in kube-controller-manager 1.27 and is not passed on: " + removedFlags["pod-eviction-timeout"].migration,
+		"--port was removed in kube-controller-manager 1.24 and is not passed on: " + removedFlags["port"].migration,
+	}, pruned)
+
+	// the events explain a change of the rendered config, they are not repeated on every sync
+	eventCount := len(recorder.Events())
+	_, modified, err = manageKubeControllerManagerConfig(context.TODO(), client.CoreV1(), recorder, operatorSpec, nil)
+	require.NoError(t, err)
+	assert.False(t, modified)
+	assert.Len(t, recorder.Events(), eventCount)
+}
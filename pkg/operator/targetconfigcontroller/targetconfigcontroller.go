@@ -3,9 +3,12 @@ package targetconfigcontroller
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
 	"strings"
@@ -26,6 +29,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/api/annotations"
+	configv1 "github.com/openshift/api/config/v1"
 	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
 	openshiftcontrolplanev1 "github.com/openshift/api/openshiftcontrolplane/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -43,14 +47,58 @@ import (
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/cluster-kube-controller-manager-operator/bindata"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/inputgeneration"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/version"
 )
 
 const (
 	ServingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+
+	// ServiceCAHashAnnotation of the operand pod is the hash of the service CA bundle of the revision. A rotation of the
+	// service CA changes the pod, so the new bundle is rolled out rather than left to the next unrelated revision.
+	ServiceCAHashAnnotation = "kube-controller-manager.openshift.io/service-ca-hash"
+
+	// AdditionalRootCAConfigMapName is the configmap in openshift-config whose ca-bundle.crt is appended to the
+	// --root-ca-file of kube-controller-manager, which is published as ca.crt in the kube-root-ca.crt configmaps.
+	AdditionalRootCAConfigMapName = "kube-controller-manager-additional-root-ca"
+
+	// maxAdditionalRootCABytes limits the additional bundle, it is copied into every namespace.
+	maxAdditionalRootCABytes = 256 * 1024
 )
 
+// RevisionConfigMaps are the revision inputs of the target namespace this controller writes. A sync changing one of
+// them stamps all of them and RevisionSecrets with one input generation, see inputgeneration.
+var RevisionConfigMaps = []string{
+	"config",
+	"cluster-policy-controller-config",
+	"controller-manager-kubeconfig",
+	"serviceaccount-ca",
+	"recycler-config",
+	"kube-controller-manager-pod",
+}
+
+// RevisionSecrets are the revisioned certs and keys of the target namespace. The SA token signer controller stamps
+// service-account-private-key and RevisionConfigMaps when it promotes a key. serving-cert is written by the service-ca
+// operator, the pod referencing it is written and stamped by this controller.
+var RevisionSecrets = []string{
+	"service-account-private-key",
+	"serving-cert",
+}
+
+func init() {
+	relatedobjects.Register(
+		// the rendered configs, kubeconfigs and bundles, the static pod manifest and the recovery client token
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "secrets", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "serviceaccounts", Namespace: operatorclient.TargetNamespace},
+		configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: AdditionalRootCAConfigMapName},
+		configv1.ObjectReference{Group: configv1.GroupName, Resource: "infrastructures", Name: "cluster"},
+	)
+}
+
 type TargetConfigController struct {
 	targetImagePullSpec             string
 	operatorImagePullSpec           string
@@ -126,12 +174,6 @@ func (c TargetConfigController) sync(ctx context.Context, syncCtx factory.SyncCo
 		return nil
 	}
 
-	// block until config is observed and specific paths are present
-	if err := isRequiredConfigPresent(operatorSpec.ObservedConfig.Raw); err != nil {
-		syncCtx.Recorder().Warning("ConfigMissing", err.Error())
-		return err
-	}
-
 	// TODO this entire block should become a configobserver, but that requires changes to the observedconfig format.
 	//  I would do that in 4.9, not 4.8.
 	// we need to get at the content of the kcm operator resource itself.  The operatorClient should be improved to return this
@@ -149,7 +191,22 @@ func (c TargetConfigController) sync(ctx context.Context, syncCtx factory.SyncCo
 	// in the case of a new cluster, the first instance ever created will be "good", so there is no possibility to accidentally create a "bad" set of flags.
 	useSecureServiceCA := kcmOperator.Spec.UseMoreSecureServiceCA
 
-	requeue, err := createTargetConfigController(ctx, syncCtx, c, operatorSpec, useSecureServiceCA)
+	breakGlass, breakGlassSource, err := breakGlassConfig(c.configMapLister, kcmOperator)
+	if conditionErr := setBreakGlassConfigCondition(ctx, c.operatorClient, breakGlassSource, err); conditionErr != nil {
+		return conditionErr
+	}
+	if err != nil {
+		return err
+	}
+	if breakGlass != nil {
+		klog.Warningf("Config observation is bypassed by %s, kube-controller-manager runs with the static config of %s", BreakGlassConfigAnnotation, breakGlassSource)
+	} else if err := isRequiredConfigPresent(operatorSpec.ObservedConfig.Raw); err != nil {
+		// block until config is observed and specific paths are present
+		syncCtx.Recorder().Warning("ConfigMissing", err.Error())
+		return err
+	}
+
+	requeue, err := createTargetConfigController(ctx, syncCtx, c, operatorSpec, useSecureServiceCA, breakGlass)
 	if err != nil {
 		return err
 	}
@@ -200,45 +257,63 @@ func isRequiredConfigPresent(config []byte) error {
 }
 
 // createTargetConfigController takes care of synchronizing (not upgrading) the thing we're managing.
-func createTargetConfigController(ctx context.Context, syncCtx factory.SyncContext, c TargetConfigController, operatorSpec *operatorv1.StaticPodOperatorSpec, useSecureServiceCA bool) (bool, error) {
+func createTargetConfigController(ctx context.Context, syncCtx factory.SyncContext, c TargetConfigController, operatorSpec *operatorv1.StaticPodOperatorSpec, useSecureServiceCA bool, breakGlassConfig []byte) (bool, error) {
 	errors := []error{}
+	// the revision controller does not create a revision until every input carries the generation of this sync
+	client := inputgeneration.NewStamper(c.kubeClient.CoreV1(), operatorclient.TargetNamespace, inputgeneration.NewGeneration(), RevisionConfigMaps, RevisionSecrets)
 
-	_, _, err := manageKubeControllerManagerConfig(ctx, c.kubeClient.CoreV1(), syncCtx.Recorder(), operatorSpec)
+	_, _, err := manageKubeControllerManagerConfig(ctx, client, syncCtx.Recorder(), operatorSpec, breakGlassConfig)
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap", err))
 	}
-	_, _, err = manageClusterPolicyControllerConfig(ctx, c.kubeClient.CoreV1(), syncCtx.Recorder(), operatorSpec)
-	if err != nil {
+	// the operator reads the override itself before it leads and ignores it when invalid
+	if _, err := leadership.ConfigOverride(operatorSpec.UnsupportedConfigOverrides.Raw); err != nil {
+		errors = append(errors, err)
+	}
+	if err := setUnsupportedConfigOverridesCondition(ctx, c.operatorClient, operatorSpec.UnsupportedConfigOverrides.Raw); err != nil {
+		errors = append(errors, err)
+	}
+	// without the topology the cluster-policy-controller config and the pod are left as they are
+	clusterPolicyControllerDisabled, topologyErr := ClusterPolicyControllerDisabled(c.infrastuctureLister)
+	switch {
+	case topologyErr != nil:
+		errors = append(errors, fmt.Errorf("%q: unable to determine the control plane topology: %v", "infrastructures.config.openshift.io/cluster", topologyErr))
+	case clusterPolicyControllerDisabled:
+		_, _, err = resourceapply.DeleteConfigMap(ctx, client, syncCtx.Recorder(), resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-controller-manager/cluster-policy-controller-cm.yaml")))
+	default:
+		_, _, err = manageClusterPolicyControllerConfig(ctx, client, syncCtx.Recorder(), operatorSpec)
+	}
+	if topologyErr == nil && err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/cluster-policy-controller-config", err))
 	}
-	_, _, err = manageRecycler(ctx, c.kubeClient.CoreV1(), syncCtx.Recorder(), c.toolsImagePullSpec)
+	_, _, err = manageRecycler(ctx, client, syncCtx.Recorder(), c.toolsImagePullSpec)
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/recycler-config", err))
 	}
-	_, _, err = ManageCSRIntermediateCABundle(ctx, c.secretLister, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	_, _, err = ManageCSRIntermediateCABundle(ctx, c.secretLister, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/csr-intermediate-ca", err))
 	}
-	_, _, err = ManageCSRCABundle(ctx, c.configMapLister, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	_, _, err = ManageCSRCABundle(ctx, c.configMapLister, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/csr-controller-ca", err))
 	}
-	_, requeueDelay, _, err := ManageCSRSigner(ctx, c.secretLister, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	_, requeueDelay, _, err := ManageCSRSigner(ctx, c.secretLister, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "secrets/csr-signer", err))
 	}
 	if requeueDelay > 0 {
 		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), requeueDelay)
 	}
-	_, _, err = manageServiceAccountCABundle(ctx, c.configMapLister, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	_, _, err = manageServiceAccountCABundle(ctx, c.configMapLister, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/serviceaccount-ca", err))
 	}
-	err = ensureLocalhostRecoverySAToken(ctx, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	err = ensureLocalhostRecoverySAToken(ctx, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "serviceaccount/localhost-recovery-client", err))
 	}
-	_, _, err = manageControllerManagerKubeconfig(ctx, c.kubeClient.CoreV1(), c.infrastuctureLister, syncCtx.Recorder())
+	_, _, err = manageControllerManagerKubeconfig(ctx, client, c.infrastuctureLister, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/controller-manager-kubeconfig", err))
 	}
@@ -261,15 +336,24 @@ func createTargetConfigController(ctx context.Context, syncCtx factory.SyncConte
 		}
 	}
 
-	_, _, err = managePod(ctx, c.kubeClient.CoreV1(), c.kubeClient.CoreV1(), syncCtx.Recorder(), operatorSpec, c.targetImagePullSpec, c.operatorImagePullSpec, c.clusterPolicyControllerPullSpec, addServingServiceCAToTokenSecrets, useSecureServiceCA)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("%q: %v", "configmap/kube-controller-manager-pod", err))
+	if topologyErr == nil {
+		_, _, err = managePod(ctx, client, client, syncCtx.Recorder(), operatorSpec, c.targetImagePullSpec, c.operatorImagePullSpec, c.clusterPolicyControllerPullSpec, addServingServiceCAToTokenSecrets, useSecureServiceCA, clusterPolicyControllerDisabled)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%q: %v", "configmap/kube-controller-manager-pod", err))
+		}
 	}
 
-	err = ensureKubeControllerManagerTrustedCA(ctx, c.kubeClient.CoreV1(), syncCtx.Recorder())
+	if err := setClientCertRevisionCondition(ctx, c.operatorClient, c.configMapLister.ConfigMaps(operatorclient.TargetNamespace), client, time.Now()); err != nil {
+		errors = append(errors, fmt.Errorf("%q: %v", "secrets/"+clientCertSecret, err))
+	}
+
+	err = ensureKubeControllerManagerTrustedCA(ctx, client, syncCtx.Recorder())
 	if err != nil {
 		errors = append(errors, fmt.Errorf("%q: %v", "configmap/trusted-ca-bundle", err))
 	}
+	if err := client.Finish(ctx); err != nil {
+		errors = append(errors, fmt.Errorf("unable to stamp the input generation: %v", err))
+	}
 
 	// The operator is not upgradeable if serving service CA addition to token secrets is enabled
 	// with the UnsupportedConfigOverride field
@@ -277,7 +361,15 @@ func createTargetConfigController(ctx context.Context, syncCtx factory.SyncConte
 	//
 	// This should be removed in 4.6.
 	var upgradeableCondition operatorv1.OperatorCondition
-	if addServingServiceCAToTokenSecrets {
+	if breakGlassConfig != nil {
+		// the static config would be carried into the next release unchanged
+		upgradeableCondition = operatorv1.OperatorCondition{
+			Type:    operatorv1.OperatorStatusTypeUpgradeable,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "BreakGlassConfigActive",
+			Message: fmt.Sprintf("Remove %s from kubecontrollermanager/cluster to resume the config observation before upgrading", BreakGlassConfigAnnotation),
+		}
+	} else if addServingServiceCAToTokenSecrets {
 		upgradeableCondition = operatorv1.OperatorCondition{
 			Type:    operatorv1.OperatorStatusTypeUpgradeable,
 			Status:  operatorv1.ConditionFalse,
@@ -384,21 +476,53 @@ func isConfiguredToOwnCloudController(cm *corev1.ConfigMap) (bool, error) {
 	return len(cloudProvider) != 1 || (cloudProvider[0] != "external" && cloudProvider[0] != ""), nil
 }
 
-func manageKubeControllerManagerConfig(ctx context.Context, client corev1client.ConfigMapsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec) (*corev1.ConfigMap, bool, error) {
+// manageKubeControllerManagerConfig renders the defaults, the observed config and the unsupportedConfigOverrides, or
+// only breakGlassConfig when it is set.
+func manageKubeControllerManagerConfig(ctx context.Context, client corev1client.ConfigMapsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec, breakGlassConfig []byte) (*corev1.ConfigMap, bool, error) {
 	configMap := resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-controller-manager/cm.yaml"))
-	defaultConfig := bindata.MustAsset("assets/config/defaultconfig.yaml")
+	configs := [][]byte{
+		bindata.MustAsset("assets/config/defaultconfig.yaml"),
+		operatorSpec.ObservedConfig.Raw,
+		operatorSpec.UnsupportedConfigOverrides.Raw,
+	}
+	if breakGlassConfig != nil {
+		configs = [][]byte{breakGlassConfig}
+	}
 	requiredConfigMap, _, err := resourcemerge.MergePrunedConfigMap(
 		&kubecontrolplanev1.KubeControllerManagerConfig{},
 		configMap,
 		"config.yaml",
 		nil,
-		defaultConfig,
-		operatorSpec.ObservedConfig.Raw,
-		operatorSpec.UnsupportedConfigOverrides.Raw)
+		configs...)
 	if err != nil {
 		return nil, false, err
 	}
-	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
+	removed, err := pruneRemovedFlags(requiredConfigMap)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := validateLeaderElection(requiredConfigMap); err != nil {
+		return nil, false, err
+	}
+	configMap, modified, err := resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
+	if err != nil || !modified {
+		return configMap, modified, err
+	}
+	for _, name := range removed {
+		flag := removedFlags[name]
+		recorder.Warningf("RemovedFlagPruned", "--%s was removed in kube-controller-manager %s and is not passed on: %s", name, flag.removedIn, flag.migration)
+	}
+	return configMap, modified, nil
+}
+
+// ClusterPolicyControllerDisabled returns true when the cluster-policy-controller must not run in the kube-controller-manager
+// static pod. With an external control plane topology its function is provided by the hosting control plane.
+func ClusterPolicyControllerDisabled(infrastructureLister configv1listers.InfrastructureLister) (bool, error) {
+	infrastructure, err := infrastructureLister.Get("cluster")
+	if err != nil {
+		return false, err
+	}
+	return infrastructure.Status.ControlPlaneTopology == configv1.ExternalTopologyMode, nil
 }
 
 func manageClusterPolicyControllerConfig(ctx context.Context, client corev1client.CoreV1Interface, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec) (*corev1.ConfigMap, bool, error) {
@@ -532,8 +656,17 @@ func manageRecycler(ctx context.Context, configMapsGetter corev1client.ConfigMap
 	return resourceapply.ApplyConfigMap(ctx, configMapsGetter, recorder, requiredCM)
 }
 
-func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGetter, secretsGetter corev1client.SecretsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec, imagePullSpec, operatorImagePullSpec, clusterPolicyControllerPullSpec string, addServingServiceCAToTokenSecrets, useSecureServiceCA bool) (*corev1.ConfigMap, bool, error) {
+func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGetter, secretsGetter corev1client.SecretsGetter, recorder events.Recorder, operatorSpec *operatorv1.StaticPodOperatorSpec, imagePullSpec, operatorImagePullSpec, clusterPolicyControllerPullSpec string, addServingServiceCAToTokenSecrets, useSecureServiceCA, clusterPolicyControllerDisabled bool) (*corev1.ConfigMap, bool, error) {
 	required := resourceread.ReadPodV1OrDie(bindata.MustAsset("assets/kube-controller-manager/pod.yaml"))
+	if clusterPolicyControllerDisabled {
+		containers := required.Spec.Containers[:0]
+		for _, container := range required.Spec.Containers {
+			if container.Name != "cluster-policy-controller" {
+				containers = append(containers, container)
+			}
+		}
+		required.Spec.Containers = containers
+	}
 	// TODO: If the image pull spec is not specified, the "${IMAGE}" will be used as value and the pod will fail to start.
 	images := map[string]string{
 		"${IMAGE}":                           imagePullSpec,
@@ -574,7 +707,7 @@ func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGett
 		logLevel = 2
 	}
 	// containers[0] = kube-controller-manager
-	// containers[1] = cluster-policy-controller
+	// containers[1] = cluster-policy-controller, unless it is disabled
 	// containers[2] = kube-controller-manager-cert-syncer
 	// containers[3] = kube-controller-manager-recovery-controller
 	containerNames := sets.NewString("kube-controller-manager", "cluster-policy-controller", "kube-controller-manager-recovery-controller")
@@ -644,13 +777,34 @@ func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGett
 
 	kcmContainerArgsWithLoglevel[0] = strings.TrimSpace(kcmContainerArgsWithLoglevel[0])
 
+	// a secure port bound to loopback is only reachable by the probes through the same address
+	bindAddress, _, err := unstructured.NestedStringSlice(observedConfig, "extendedArguments", "bind-address")
+	if err != nil {
+		return nil, false, fmt.Errorf("couldn't get the extendedArguments.bind-address config from observedConfig: %v", err)
+	}
+	if len(bindAddress) == 1 {
+		if ip := net.ParseIP(bindAddress[0]); ip != nil && ip.IsLoopback() {
+			kcmContainer := &required.Spec.Containers[0]
+			for _, probe := range []*corev1.Probe{kcmContainer.StartupProbe, kcmContainer.LivenessProbe, kcmContainer.ReadinessProbe} {
+				if probe != nil && probe.HTTPGet != nil {
+					probe.HTTPGet.Host = bindAddress[0]
+				}
+			}
+		}
+	}
+
 	proxyConfig, _, err := unstructured.NestedStringMap(observedConfig, "targetconfigcontroller", "proxy")
 	if err != nil {
 		return nil, false, fmt.Errorf("couldn't get the proxy config from observedConfig: %v", err)
 	}
 
+	// only the kube-controller-manager and the cluster-policy-controller reach out of the cluster, e.g. to cloud and
+	// registry APIs. The cert syncer and the recovery controller talk to the local kube-apiserver only.
 	proxyEnvVars := proxyMapToEnvVars(proxyConfig)
 	for i, container := range required.Spec.Containers {
+		if container.Name != "kube-controller-manager" && container.Name != "cluster-policy-controller" {
+			continue
+		}
 		required.Spec.Containers[i].Env = append(container.Env, proxyEnvVars...)
 	}
 
@@ -680,6 +834,21 @@ func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGett
 		}
 	}
 
+	serviceCAHash, err := serviceCABundleHash(ctx, configMapsGetter)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(serviceCAHash) > 0 {
+		required.Annotations[ServiceCAHashAnnotation] = serviceCAHash
+	}
+	clientCert, err := clientCertAnnotations(ctx, secretsGetter)
+	if err != nil {
+		return nil, false, err
+	}
+	for name, value := range clientCert {
+		required.Annotations[name] = value
+	}
+
 	configMap := resourceread.ReadConfigMapV1OrDie(bindata.MustAsset("assets/kube-controller-manager/pod-cm.yaml"))
 	configMap.Data["pod.yaml"] = resourceread.WritePodV1OrDie(required)
 	configMap.Data["forceRedeploymentReason"] = operatorSpec.ForceRedeploymentReason
@@ -687,6 +856,23 @@ func managePod(ctx context.Context, configMapsGetter corev1client.ConfigMapsGett
 	return resourceapply.ApplyConfigMap(ctx, configMapsGetter, recorder, configMap)
 }
 
+// serviceCABundleHash returns the hash of the bundle of the service-ca configmap synced into the target namespace, empty
+// until it is synced. Only the bundle is hashed, updates of the metadata alone keep the hash.
+func serviceCABundleHash(ctx context.Context, configMapsGetter corev1client.ConfigMapsGetter) (string, error) {
+	serviceCA, err := configMapsGetter.ConfigMaps(operatorclient.TargetNamespace).Get(ctx, "service-ca", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	bundle := serviceCA.Data["ca-bundle.crt"]
+	if len(bundle) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(bundle))), nil
+}
+
 func GetKubeControllerManagerArgs(config map[string]interface{}) []string {
 	extendedArguments, ok := config["extendedArguments"]
 	if !ok || extendedArguments == nil {
@@ -705,6 +891,10 @@ func GetKubeControllerManagerArgs(config map[string]interface{}) []string {
 }
 
 func manageServiceAccountCABundle(ctx context.Context, lister corev1listers.ConfigMapLister, client corev1client.ConfigMapsGetter, recorder events.Recorder) (*corev1.ConfigMap, bool, error) {
+	// an invalid bundle leaves the current serviceaccount-ca in place
+	if err := validateAdditionalRootCA(lister); err != nil {
+		return nil, false, err
+	}
 	requiredConfigMap, err := resourcesynccontroller.CombineCABundleConfigMaps(
 		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.TargetNamespace, Name: "serviceaccount-ca"},
 		lister,
@@ -715,6 +905,8 @@ func manageServiceAccountCABundle(ctx context.Context, lister corev1listers.Conf
 		// include the ca bundle needed to recognize default
 		// certificates generated by cluster-ingress-operator
 		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace, Name: "default-ingress-cert"},
+		// include the ca bundle provided by the admin, appended after the defaults
+		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace, Name: AdditionalRootCAConfigMapName},
 	)
 	if err != nil {
 		return nil, false, err
@@ -722,21 +914,42 @@ func manageServiceAccountCABundle(ctx context.Context, lister corev1listers.Conf
 	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
 }
 
-func ManageCSRCABundle(ctx context.Context, lister corev1listers.ConfigMapLister, client corev1client.ConfigMapsGetter, recorder events.Recorder) (*corev1.ConfigMap, bool, error) {
-	requiredConfigMap, err := resourcesynccontroller.CombineCABundleConfigMaps(
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-ca"},
-		lister,
-		"kube-controller-manager",
-		"",
-		// include the CA we use to sign CSRs
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.OperatorNamespace, Name: "csr-signer-ca"},
-		// include the CA we use to sign the cert key pairs from from csr-signer
-		resourcesynccontroller.ResourceLocation{Namespace: operatorclient.OperatorNamespace, Name: "csr-controller-signer-ca"},
-	)
+// validateAdditionalRootCA checks the admin provided ca bundle before it ends up in the --root-ca-file and from
+// there in the kube-root-ca.crt configmap of every namespace. A missing configmap is valid and means only the
+// default CAs are used.
+func validateAdditionalRootCA(lister corev1listers.ConfigMapLister) error {
+	configMap, err := lister.ConfigMaps(operatorclient.GlobalUserSpecifiedConfigNamespace).Get(AdditionalRootCAConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	if err != nil {
-		return nil, false, err
+		return err
+	}
+
+	bundle, ok := configMap.Data["ca-bundle.crt"]
+	if !ok || len(strings.TrimSpace(bundle)) == 0 {
+		return fmt.Errorf("configmap/%s -n %s: missing ca-bundle.crt", configMap.Name, configMap.Namespace)
+	}
+	if len(bundle) > maxAdditionalRootCABytes {
+		return fmt.Errorf("configmap/%s -n %s: ca-bundle.crt is %d bytes, at most %d bytes are allowed", configMap.Name, configMap.Namespace, len(bundle), maxAdditionalRootCABytes)
+	}
+	rest := []byte(bundle)
+	for i := 0; ; i++ {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			if len(bytes.TrimSpace(rest)) > 0 {
+				return fmt.Errorf("configmap/%s -n %s: ca-bundle.crt contains data that is not PEM encoded after %d certificates", configMap.Name, configMap.Namespace, i)
+			}
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("configmap/%s -n %s: ca-bundle.crt block %d is a %q, only CERTIFICATE blocks are allowed", configMap.Name, configMap.Namespace, i, block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("configmap/%s -n %s: ca-bundle.crt certificate %d is malformed: %v", configMap.Name, configMap.Namespace, i, err)
+		}
 	}
-	return resourceapply.ApplyConfigMap(ctx, client, recorder, requiredConfigMap)
 }
 
 func ManageCSRSigner(ctx context.Context, lister corev1listers.SecretLister, client corev1client.SecretsGetter, recorder events.Recorder) (*corev1.Secret, time.Duration, bool, error) {
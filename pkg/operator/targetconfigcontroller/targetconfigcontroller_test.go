@@ -14,13 +14,18 @@ import (
 	"testing"
 	"time"
 
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
 	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
@@ -327,6 +332,116 @@ func TestManageCSRSigner(t *testing.T) {
 
 }
 
+func TestManageServiceAccountCABundle(t *testing.T) {
+	serverCA := string(makeCerts(t, time.Now().Add(-time.Hour), 24*time.Hour)["tls.crt"])
+	ingressCA := string(makeCerts(t, time.Now().Add(-time.Hour), 24*time.Hour)["tls.crt"])
+	corporateCA := string(makeCerts(t, time.Now().Add(-time.Hour), 24*time.Hour)["tls.crt"])
+	corporateKey := string(makeCerts(t, time.Now().Add(-time.Hour), 24*time.Hour)["tls.key"])
+
+	additional := func(bundle string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: AdditionalRootCAConfigMapName, Namespace: operatorclient.GlobalUserSpecifiedConfigNamespace},
+			Data:       map[string]string{"ca-bundle.crt": bundle},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		additional     *corev1.ConfigMap
+		existingBundle string
+		expectedBundle string
+		expectedError  string
+	}{
+		{
+			name:           "default root CA",
+			expectedBundle: serverCA + ingressCA,
+		},
+		{
+			name:           "additional bundle is appended after the default root CA",
+			additional:     additional(corporateCA),
+			expectedBundle: serverCA + ingressCA + corporateCA,
+		},
+		{
+			name:           "removing the additional bundle restores the default root CA",
+			existingBundle: serverCA + ingressCA + corporateCA,
+			expectedBundle: serverCA + ingressCA,
+		},
+		{
+			name:           "invalid PEM",
+			additional:     additional("not a certificate"),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  "ca-bundle.crt contains data that is not PEM encoded after 0 certificates",
+		},
+		{
+			name:           "trailing garbage",
+			additional:     additional(corporateCA + "garbage"),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  "ca-bundle.crt contains data that is not PEM encoded after 1 certificates",
+		},
+		{
+			name:           "private key",
+			additional:     additional(corporateCA + corporateKey),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  `ca-bundle.crt block 1 is a "RSA PRIVATE KEY", only CERTIFICATE blocks are allowed`,
+		},
+		{
+			name:           "malformed certificate",
+			additional:     additional("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  "ca-bundle.crt certificate 0 is malformed",
+		},
+		{
+			name:           "empty bundle",
+			additional:     additional(""),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  "missing ca-bundle.crt",
+		},
+		{
+			name:           "bundle exceeding the size limit",
+			additional:     additional(strings.Repeat(corporateCA, maxAdditionalRootCABytes/len(corporateCA)+1)),
+			existingBundle: serverCA + ingressCA,
+			expectedError:  "at most 262144 bytes are allowed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, configMap := range []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver-server-ca", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace}, Data: map[string]string{"ca-bundle.crt": serverCA}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "default-ingress-cert", Namespace: operatorclient.GlobalMachineSpecifiedConfigNamespace}, Data: map[string]string{"ca-bundle.crt": ingressCA}},
+			} {
+				require.NoError(t, indexer.Add(configMap))
+			}
+			if test.additional != nil {
+				require.NoError(t, indexer.Add(test.additional))
+			}
+			client := fake.NewSimpleClientset()
+			if len(test.existingBundle) > 0 {
+				client = fake.NewSimpleClientset(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "serviceaccount-ca", Namespace: operatorclient.TargetNamespace},
+					Data:       map[string]string{"ca-bundle.crt": test.existingBundle},
+				})
+			}
+
+			_, _, err := manageServiceAccountCABundle(context.Background(), corev1listers.NewConfigMapLister(indexer), client.CoreV1(), events.NewInMemoryRecorder("target-config-controller"))
+			if len(test.expectedError) > 0 {
+				require.ErrorContains(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			actual, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Get(context.Background(), "serviceaccount-ca", metav1.GetOptions{})
+			require.NoError(t, err)
+			expectedBundle := test.expectedBundle
+			if len(expectedBundle) == 0 {
+				// rejected bundles leave the current root CA in place
+				expectedBundle = test.existingBundle
+			}
+			assert.Equal(t, expectedBundle, actual.Data["ca-bundle.crt"])
+		})
+	}
+}
+
 func makeCerts(t *testing.T, notAfter time.Time, duration time.Duration) map[string][]byte {
 	// below code is copied from vendor/github.com/openshift/library-go/pkg/crypto/crypto.go
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -483,3 +598,164 @@ func TestConfiguredToOwnCloudController(t *testing.T) {
 		})
 	}
 }
+
+func TestManagePodClusterPolicyControllerDisabled(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		topology           configv1.TopologyMode
+		expectedContainers []string
+	}{
+		{
+			name:               "highly available topology",
+			topology:           configv1.HighlyAvailableTopologyMode,
+			expectedContainers: []string{"kube-controller-manager", "cluster-policy-controller", "kube-controller-manager-cert-syncer", "kube-controller-manager-recovery-controller"},
+		},
+		{
+			name:               "external topology",
+			topology:           configv1.ExternalTopologyMode,
+			expectedContainers: []string{"kube-controller-manager", "kube-controller-manager-cert-syncer", "kube-controller-manager-recovery-controller"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, indexer.Add(&configv1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.InfrastructureStatus{ControlPlaneTopology: tc.topology},
+			}))
+			disabled, err := ClusterPolicyControllerDisabled(configv1listers.NewInfrastructureLister(indexer))
+			require.NoError(t, err)
+
+			client := fake.NewSimpleClientset()
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(`{}`)}}}
+			podConfigMap, _, err := managePod(context.TODO(), client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, "kcm-image", "operator-image", "cpc-image", false, true, disabled)
+			require.NoError(t, err)
+
+			pod := resourceread.ReadPodV1OrDie([]byte(podConfigMap.Data["pod.yaml"]))
+			var containers []string
+			for _, container := range pod.Spec.Containers {
+				containers = append(containers, container.Name)
+			}
+			assert.Equal(t, tc.expectedContainers, containers)
+		})
+	}
+}
+
+func TestManagePodProbesFollowBindAddress(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		bindAddress  string
+		expectedHost string
+	}{
+		{name: "default", expectedHost: ""},
+		{name: "all ipv4", bindAddress: "0.0.0.0", expectedHost: ""},
+		{name: "all ipv6", bindAddress: "::", expectedHost: ""},
+		{name: "node local ipv4", bindAddress: "127.0.0.1", expectedHost: "127.0.0.1"},
+		{name: "node local ipv6", bindAddress: "::1", expectedHost: "::1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			observedConfig := `{}`
+			if len(tc.bindAddress) > 0 {
+				observedConfig = fmt.Sprintf(`{"extendedArguments":{"bind-address":[%q]}}`, tc.bindAddress)
+			}
+			client := fake.NewSimpleClientset()
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(observedConfig)}}}
+			podConfigMap, _, err := managePod(context.TODO(), client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, "kcm-image", "operator-image", "cpc-image", false, true, false)
+			require.NoError(t, err)
+
+			pod := resourceread.ReadPodV1OrDie([]byte(podConfigMap.Data["pod.yaml"]))
+			kcm := pod.Spec.Containers[0]
+			for _, probe := range []*corev1.Probe{kcm.StartupProbe, kcm.LivenessProbe, kcm.ReadinessProbe} {
+				assert.Equal(t, tc.expectedHost, probe.HTTPGet.Host)
+			}
+			// the other containers serve on their own ports
+			assert.Equal(t, "", pod.Spec.Containers[1].ReadinessProbe.HTTPGet.Host)
+		})
+	}
+}
+
+func TestManagePodProxyEnv(t *testing.T) {
+	proxyEnv := []corev1.EnvVar{
+		{Name: "HTTPS_PROXY", Value: "https://proxy.example.com:3129"},
+		{Name: "HTTP_PROXY", Value: "http://proxy.example.com:3128"},
+		{Name: "NO_PROXY", Value: ".example.com,10.128.0.0/14,172.30.0.0/16,api-int.cluster.example.com"},
+	}
+	for _, tc := range []struct {
+		name           string
+		observedConfig string
+		expectedEnv    []corev1.EnvVar
+	}{
+		{
+			name:           "no proxy",
+			observedConfig: `{}`,
+		},
+		{
+			name:           "proxy",
+			observedConfig: `{"targetconfigcontroller":{"proxy":{"HTTP_PROXY":"http://proxy.example.com:3128","HTTPS_PROXY":"https://proxy.example.com:3129","NO_PROXY":".example.com,10.128.0.0/14,172.30.0.0/16,api-int.cluster.example.com"}}}`,
+			expectedEnv:    proxyEnv,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(tc.observedConfig)}}}
+			podConfigMap, _, err := managePod(context.TODO(), client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, "kcm-image", "operator-image", "cpc-image", false, true, false)
+			require.NoError(t, err)
+			unproxiedPodConfigMap, _, err := managePod(context.TODO(), client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(`{}`)}}}, "kcm-image", "operator-image", "cpc-image", false, true, false)
+			require.NoError(t, err)
+
+			pod := resourceread.ReadPodV1OrDie([]byte(podConfigMap.Data["pod.yaml"]))
+			unproxiedPod := resourceread.ReadPodV1OrDie([]byte(unproxiedPodConfigMap.Data["pod.yaml"]))
+			for i, container := range pod.Spec.Containers {
+				expectedEnv := unproxiedPod.Spec.Containers[i].Env
+				if container.Name == "kube-controller-manager" || container.Name == "cluster-policy-controller" {
+					expectedEnv = append(expectedEnv, tc.expectedEnv...)
+				}
+				assert.Equal(t, expectedEnv, container.Env, container.Name)
+			}
+			for _, container := range unproxiedPod.Spec.Containers {
+				for _, env := range container.Env {
+					assert.NotContains(t, []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}, env.Name, container.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestManagePodServiceCARotation(t *testing.T) {
+	serviceCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "service-ca"},
+		Data:       map[string]string{"ca-bundle.crt": "old-ca"},
+	}
+	client := fake.NewSimpleClientset(serviceCA)
+	operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(`{}`)}}}
+	applyPod := func() (*corev1.Pod, bool) {
+		podConfigMap, modified, err := managePod(context.TODO(), client.CoreV1(), client.CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, "kcm-image", "operator-image", "cpc-image", false, true, false)
+		require.NoError(t, err)
+		return resourceread.ReadPodV1OrDie([]byte(podConfigMap.Data["pod.yaml"])), modified
+	}
+	updateServiceCA := func(update func(*corev1.ConfigMap)) {
+		update(serviceCA)
+		_, err := client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Update(context.TODO(), serviceCA, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	pod, _ := applyPod()
+	oldHash := pod.Annotations[ServiceCAHashAnnotation]
+	require.NotEmpty(t, oldHash)
+
+	// metadata only updates of the service CA, e.g. by the resource sync, must not roll out a revision
+	updateServiceCA(func(cm *corev1.ConfigMap) { cm.Annotations = map[string]string{"example.com/touched": "true"} })
+	pod, modified := applyPod()
+	assert.False(t, modified, "a metadata update of the service CA must not change the pod")
+	assert.Equal(t, oldHash, pod.Annotations[ServiceCAHashAnnotation])
+
+	// a rotation changes the revisioned pod, the revision controller requests a new revision for it
+	updateServiceCA(func(cm *corev1.ConfigMap) { cm.Data["ca-bundle.crt"] = "new-ca" })
+	pod, modified = applyPod()
+	assert.True(t, modified, "a rotation of the service CA must change the pod")
+	assert.NotEqual(t, oldHash, pod.Annotations[ServiceCAHashAnnotation])
+
+	// without the synced bundle the pod does not pin one
+	require.NoError(t, client.CoreV1().ConfigMaps(operatorclient.TargetNamespace).Delete(context.TODO(), "service-ca", metav1.DeleteOptions{}))
+	pod, _ = applyPod()
+	assert.NotContains(t, pod.Annotations, ServiceCAHashAnnotation)
+}
@@ -0,0 +1,77 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+
+	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
+	openshiftcontrolplanev1 "github.com/openshift/api/openshiftcontrolplane/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/configobservation/validation"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const unsupportedConfigOverridesConditionType = "UnsupportedConfigOverridesDegraded"
+
+// overrideFields are the fields of the unsupportedConfigOverrides that have an effect: the ones of the configs of
+// kube-controller-manager and cluster-policy-controller, and the ones the operator reads itself. It is built on first
+// use, after the packages registered their fields.
+var overrideFields = sync.OnceValue(func() validation.Fields {
+	fields := validation.FieldsOf(
+		kubecontrolplanev1.KubeControllerManagerConfig{},
+		openshiftcontrolplanev1.OpenShiftControllerManagerConfig{},
+	)
+	fields[leadership.OverrideField] = nil
+	for _, field := range overrides.Registered() {
+		fields[field] = nil
+	}
+	fields["enableDeprecatedAndRemovedServiceCAKeyUntilNextRelease_ThisMakesClusterImpossibleToUpgrade"] = nil
+	return fields
+})
+
+// unknownOverrideFields returns the fields of the unsupportedConfigOverrides that have no effect, they are pruned when
+// the overrides are merged. Overrides that are not an object cannot be merged at all and are an error.
+func unknownOverrideFields(unsupportedConfigOverrides []byte) ([]*validation.UnknownFieldError, error) {
+	if len(unsupportedConfigOverrides) == 0 {
+		return nil, nil
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(unsupportedConfigOverrides, &values); err != nil {
+		return nil, fmt.Errorf("must be an object: %v", err)
+	}
+	return validation.UnknownFields(values, overrideFields()), nil
+}
+
+// setUnsupportedConfigOverridesCondition reports every field of the unsupportedConfigOverrides that has no effect,
+// typically a mistyped key. The known fields are applied regardless, the condition clears once the overrides are fixed.
+func setUnsupportedConfigOverridesCondition(ctx context.Context, operatorClient v1helpers.StaticPodOperatorClient, unsupportedConfigOverrides []byte) error {
+	condition := operatorv1.OperatorCondition{
+		Type:   unsupportedConfigOverridesConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	unknown, err := unknownOverrideFields(unsupportedConfigOverrides)
+	switch {
+	case err != nil:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "InvalidOverrides"
+		condition.Message = fmt.Sprintf("spec.unsupportedConfigOverrides %v", err)
+	case len(unknown) > 0:
+		lines := make([]string, 0, len(unknown))
+		for _, field := range unknown {
+			lines = append(lines, fmt.Sprintf("spec.unsupportedConfigOverrides.%v", field))
+		}
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "UnknownFields"
+		condition.Message = "The unknown fields of the overrides are ignored:\n" + strings.Join(lines, "\n")
+	}
+	_, _, err = v1helpers.UpdateStaticPodStatus(ctx, operatorClient, v1helpers.UpdateStaticPodConditionFn(condition))
+	return err
+}
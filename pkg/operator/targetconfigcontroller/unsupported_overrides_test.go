@@ -0,0 +1,121 @@
+package targetconfigcontroller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestUnknownOverrideFields(t *testing.T) {
+	overrides := []byte(`
+extendedArguments:
+  kube-api-qps: ["300"]
+extendedArgument:
+  kube-api-burst: ["600"]
+operatorLeaderElection:
+  leaseDuration: 137s
+enableDeprecatedAndRemovedServiceCAKeyUntilNextRelease_ThisMakesClusterImpossibleToUpgrade: false
+leaderElection:
+  renewDeadlin: 107s
+`)
+	unknown, err := unknownOverrideFields(overrides)
+	require.NoError(t, err)
+	var actual []string
+	for _, field := range unknown {
+		actual = append(actual, field.Error())
+	}
+	assert.Equal(t, []string{
+		"extendedArgument: is not a known field, did you mean extendedArguments?",
+		"leaderElection.renewDeadlin: is not a known field, did you mean renewDeadline?",
+	}, actual)
+
+	unknown, err = unknownOverrideFields(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+	_, err = unknownOverrideFields([]byte("not: [a map"))
+	assert.Error(t, err)
+}
+
+func TestUnsupportedConfigOverridesCondition(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		overrides                 string
+		expectedStatus            operatorv1.ConditionStatus
+		expectedReason            string
+		expectedMessage           string
+		expectedExtendedArguments map[string]interface{}
+	}{
+		{
+			name:           "no overrides",
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: "AsExpected",
+		},
+		{
+			name:                      "valid",
+			overrides:                 `{"extendedArguments":{"kube-api-qps":["300"]}}`,
+			expectedStatus:            operatorv1.ConditionFalse,
+			expectedReason:            "AsExpected",
+			expectedExtendedArguments: map[string]interface{}{"kube-api-qps": []interface{}{"300"}},
+		},
+		{
+			name:           "partially invalid",
+			overrides:      `{"extendedArguments":{"kube-api-qps":["300"]},"extendedArgument":{"kube-api-burst":["600"]},"servingInfo":{"bindAdress":"0.0.0.0:10257"}}`,
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: "UnknownFields",
+			expectedMessage: "The unknown fields of the overrides are ignored:\n" +
+				"spec.unsupportedConfigOverrides.extendedArgument: is not a known field, did you mean extendedArguments?\n" +
+				"spec.unsupportedConfigOverrides.servingInfo.bindAdress: is not a known field, did you mean bindAddress?",
+			// the known fields are applied regardless
+			expectedExtendedArguments: map[string]interface{}{"kube-api-qps": []interface{}{"300"}},
+		},
+		{
+			name:           "not an object",
+			overrides:      `["extendedArguments"]`,
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: "InvalidOverrides",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil)
+			require.NoError(t, setUnsupportedConfigOverridesCondition(context.TODO(), operatorClient, []byte(tc.overrides)))
+			_, status, _, err := operatorClient.GetStaticPodOperatorState()
+			require.NoError(t, err)
+			condition := v1helpers.FindOperatorCondition(status.Conditions, unsupportedConfigOverridesConditionType)
+			require.NotNil(t, condition)
+			assert.Equal(t, tc.expectedStatus, condition.Status)
+			assert.Equal(t, tc.expectedReason, condition.Reason)
+			if tc.expectedReason == "InvalidOverrides" {
+				assert.Contains(t, condition.Message, "spec.unsupportedConfigOverrides must be an object")
+				return
+			}
+			assert.Equal(t, tc.expectedMessage, condition.Message)
+
+			// fixing the overrides clears the condition
+			require.NoError(t, setUnsupportedConfigOverridesCondition(context.TODO(), operatorClient, nil))
+			_, status, _, err = operatorClient.GetStaticPodOperatorState()
+			require.NoError(t, err)
+			assert.Equal(t, operatorv1.ConditionFalse, v1helpers.FindOperatorCondition(status.Conditions, unsupportedConfigOverridesConditionType).Status)
+
+			operatorSpec := &operatorv1.StaticPodOperatorSpec{OperatorSpec: operatorv1.OperatorSpec{
+				ObservedConfig:             runtime.RawExtension{Raw: []byte(`{}`)},
+				UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+			}}
+			configMap, _, err := manageKubeControllerManagerConfig(context.TODO(), fake.NewSimpleClientset().CoreV1(), events.NewInMemoryRecorder("test"), operatorSpec, nil)
+			require.NoError(t, err)
+			extendedArguments := extendedArgumentsOf(t, []byte(configMap.Data["config.yaml"]))
+			for name, value := range tc.expectedExtendedArguments {
+				assert.Equal(t, value, extendedArguments[name], name)
+			}
+			assert.NotContains(t, configMap.Data["config.yaml"], "extendedArgument:")
+			assert.NotContains(t, configMap.Data["config.yaml"], "bindAdress")
+		})
+	}
+}
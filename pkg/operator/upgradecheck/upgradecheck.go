@@ -0,0 +1,284 @@
+// Package upgradecheck evaluates whether kube-controller-manager is in a good state to upgrade the cluster. Every
+// criterion is reported with the evidence it was decided on and a remediation hint when it fails.
+package upgradecheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/cert"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// DefaultWindow is the default duration of the upgrade no certificate may expire within.
+const DefaultWindow = 24 * time.Hour
+
+// leases are the leader election locks of the operator and of kube-controller-manager.
+var leases = []leaseLocation{
+	{namespace: operatorclient.OperatorNamespace, name: leadership.LockName},
+	{namespace: "kube-system", name: "kube-controller-manager"},
+}
+
+type leaseLocation struct {
+	namespace, name string
+}
+
+// State is what the criteria are evaluated on.
+type State struct {
+	Operator *operatorv1.KubeControllerManager
+	// Secrets are the TLS secrets of the operator and the target namespace
+	Secrets []corev1.Secret
+	// Leases are the leases found of the operator and of kube-controller-manager
+	Leases []coordinationv1.Lease
+}
+
+// Clients read the State from the cluster.
+type Clients struct {
+	KubeControllerManagers operatorv1client.KubeControllerManagersGetter
+	Secrets                corev1client.SecretsGetter
+	Leases                 coordinationv1client.LeasesGetter
+}
+
+// Gather reads the State from the cluster. A missing lease is left out, it fails the leader election criterion.
+func Gather(ctx context.Context, clients Clients) (*State, error) {
+	operator, err := clients.KubeControllerManagers.KubeControllerManagers().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	state := &State{Operator: operator}
+	for _, namespace := range []string{operatorclient.OperatorNamespace, operatorclient.TargetNamespace} {
+		secrets, err := clients.Secrets.Secrets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets.Items {
+			if secret.Type == corev1.SecretTypeTLS {
+				state.Secrets = append(state.Secrets, secret)
+			}
+		}
+	}
+	for _, lease := range leases {
+		found, err := clients.Leases.Leases(lease.namespace).Get(ctx, lease.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		state.Leases = append(state.Leases, *found)
+	}
+	return state, nil
+}
+
+// Result is the outcome of one criterion.
+type Result struct {
+	Name   string
+	Passed bool
+	// Evidence are the observations the outcome was decided on
+	Evidence []string
+	// Remediation is how to fix a failed criterion
+	Remediation string
+}
+
+// Report are the results of all criteria.
+type Report struct {
+	Results []Result
+}
+
+// Passed returns whether every criterion passed.
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Write prints the report, one criterion per line followed by its evidence and the remediation of a failure.
+func (r *Report) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, result := range r.Results {
+		outcome := "PASS"
+		if !result.Passed {
+			outcome = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s %s\n", outcome, result.Name)
+		for _, evidence := range result.Evidence {
+			fmt.Fprintf(&b, "     %s\n", evidence)
+		}
+		if !result.Passed && len(result.Remediation) > 0 {
+			fmt.Fprintf(&b, "     remediation: %s\n", result.Remediation)
+		}
+	}
+	if r.Passed() {
+		b.WriteString("kube-controller-manager is ready to upgrade\n")
+	} else {
+		b.WriteString("kube-controller-manager is not ready to upgrade\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Check evaluates the criteria on state at now. No certificate may expire within window.
+func Check(state *State, now time.Time, window time.Duration) *Report {
+	return &Report{Results: []Result{
+		nodesAtLatestRevision(state.Operator),
+		certificatesNotExpiring(state.Secrets, now, window),
+		noUnsupportedConfigOverrides(state.Operator),
+		noConfigObservationErrors(state.Operator),
+		leaderElectionStable(state.Leases, now),
+	}}
+}
+
+func nodesAtLatestRevision(operator *operatorv1.KubeControllerManager) Result {
+	result := Result{
+		Name:        "nodes-at-latest-revision",
+		Passed:      true,
+		Remediation: "wait for the rollout to finish, a stuck rollout is reported by the NodeInstallerProgressing and NodeInstallerDegraded conditions",
+	}
+	latest := operator.Status.LatestAvailableRevision
+	if len(operator.Status.NodeStatuses) == 0 {
+		result.Passed = false
+		result.Evidence = append(result.Evidence, "no node statuses")
+	}
+	for _, node := range operator.Status.NodeStatuses {
+		switch {
+		case node.TargetRevision != 0:
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("node %s is progressing from revision %d to %d, the latest is %d", node.NodeName, node.CurrentRevision, node.TargetRevision, latest))
+		case node.CurrentRevision != latest:
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("node %s is at revision %d, the latest is %d", node.NodeName, node.CurrentRevision, latest))
+		default:
+			result.Evidence = append(result.Evidence, fmt.Sprintf("node %s is at the latest revision %d", node.NodeName, latest))
+		}
+	}
+	return result
+}
+
+func certificatesNotExpiring(secrets []corev1.Secret, now time.Time, window time.Duration) Result {
+	result := Result{
+		Name:        "certificates-not-expiring",
+		Passed:      true,
+		Remediation: "let the certificates be rotated before upgrading, a stuck rotation is reported by the CertRotation conditions",
+	}
+	secrets = append([]corev1.Secret(nil), secrets...)
+	sort.Slice(secrets, func(i, j int) bool {
+		if secrets[i].Namespace != secrets[j].Namespace {
+			return secrets[i].Namespace < secrets[j].Namespace
+		}
+		return secrets[i].Name < secrets[j].Name
+	})
+	var checked int
+	for _, secret := range secrets {
+		certificates, err := cert.ParseCertsPEM(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("secret/%s -n %s: %v", secret.Name, secret.Namespace, err))
+			continue
+		}
+		checked++
+		for _, certificate := range certificates {
+			if certificate.NotAfter.Before(now.Add(window)) {
+				result.Passed = false
+				result.Evidence = append(result.Evidence, fmt.Sprintf("secret/%s -n %s: %q expires at %s, within %s", secret.Name, secret.Namespace, certificate.Subject.CommonName, certificate.NotAfter.UTC().Format(time.RFC3339), window))
+			}
+		}
+	}
+	if result.Passed {
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%d certificate secrets are valid for at least %s", checked, window))
+	}
+	return result
+}
+
+func noUnsupportedConfigOverrides(operator *operatorv1.KubeControllerManager) Result {
+	result := Result{
+		Name:        "no-unsupported-config-overrides",
+		Passed:      true,
+		Remediation: "remove spec.unsupportedConfigOverrides of kubecontrollermanager/cluster, an unsupported configuration may not survive the upgrade",
+	}
+	raw := strings.TrimSpace(string(operator.Spec.UnsupportedConfigOverrides.Raw))
+	if len(raw) == 0 || raw == "{}" || raw == "null" {
+		result.Evidence = append(result.Evidence, "spec.unsupportedConfigOverrides is empty")
+		return result
+	}
+	result.Passed = false
+	result.Evidence = append(result.Evidence, fmt.Sprintf("spec.unsupportedConfigOverrides is %s", raw))
+	return result
+}
+
+func noConfigObservationErrors(operator *operatorv1.KubeControllerManager) Result {
+	result := Result{
+		Name:        "no-config-observation-errors",
+		Passed:      true,
+		Remediation: "fix the cluster configuration the ConfigObservationDegraded condition names",
+	}
+	for _, condition := range operator.Status.Conditions {
+		if condition.Type != "ConfigObservationDegraded" {
+			continue
+		}
+		if condition.Status == operatorv1.ConditionTrue {
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("ConfigObservationDegraded is True: %s", condition.Message))
+		} else {
+			result.Evidence = append(result.Evidence, fmt.Sprintf("ConfigObservationDegraded is %s", condition.Status))
+		}
+		return result
+	}
+	result.Evidence = append(result.Evidence, "no ConfigObservationDegraded condition")
+	return result
+}
+
+func leaderElectionStable(found []coordinationv1.Lease, now time.Time) Result {
+	result := Result{
+		Name:        "leader-election-stable",
+		Passed:      true,
+		Remediation: "check the pods of the lease holders, a lease that is not renewed in time is lost and acquired again",
+	}
+	for _, lease := range leases {
+		var current *coordinationv1.Lease
+		for i := range found {
+			if found[i].Namespace == lease.namespace && found[i].Name == lease.name {
+				current = &found[i]
+			}
+		}
+		description := fmt.Sprintf("lease/%s -n %s", lease.name, lease.namespace)
+		switch {
+		case current == nil:
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s does not exist", description))
+		case current.Spec.HolderIdentity == nil || len(*current.Spec.HolderIdentity) == 0:
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s is not held", description))
+		case current.Spec.RenewTime == nil || current.Spec.LeaseDurationSeconds == nil:
+			result.Passed = false
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s held by %s was never renewed", description, *current.Spec.HolderIdentity))
+		default:
+			age := now.Sub(current.Spec.RenewTime.Time).Round(time.Second)
+			leaseDuration := time.Duration(*current.Spec.LeaseDurationSeconds) * time.Second
+			if age > leaseDuration {
+				result.Passed = false
+				result.Evidence = append(result.Evidence, fmt.Sprintf("%s held by %s was last renewed %s ago, longer than its lease duration %s", description, *current.Spec.HolderIdentity, age, leaseDuration))
+				continue
+			}
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s held by %s was renewed %s ago", description, *current.Spec.HolderIdentity, age))
+		}
+	}
+	return result
+}
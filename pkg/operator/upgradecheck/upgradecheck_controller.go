@@ -0,0 +1,147 @@
+package upgradecheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/relatedobjects"
+)
+
+const (
+	// Annotation on the KubeControllerManager CR requests an upgrade check. Its value is the window no certificate
+	// may expire within, e.g. "48h", empty for DefaultWindow. The report is written to ReportConfigMapName and the
+	// annotation is removed again.
+	Annotation = "operator.openshift.io/upgrade-check"
+
+	// ReportConfigMapName in the operator namespace holds the report of the last requested upgrade check.
+	ReportConfigMapName = "upgrade-check-report"
+)
+
+func init() {
+	relatedobjects.Register(configv1.ObjectReference{Resource: "configmaps", Namespace: operatorclient.OperatorNamespace, Name: ReportConfigMapName})
+}
+
+// UpgradeCheckController runs the upgrade check on request, for clusters the upgrade-check command cannot be run
+// against, e.g. from a pipeline with access to the KubeControllerManager CR only.
+type UpgradeCheckController struct {
+	operatorLister cache.GenericLister
+	clients        Clients
+	configMaps     corev1client.ConfigMapsGetter
+
+	now func() time.Time
+}
+
+func NewUpgradeCheckController(
+	operatorClient v1helpers.OperatorClient,
+	operatorLister cache.GenericLister,
+	clients Clients,
+	configMaps corev1client.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &UpgradeCheckController{
+		operatorLister: operatorLister,
+		clients:        clients,
+		configMaps:     configMaps,
+		now:            time.Now,
+	}
+	return factory.New().WithInformers(operatorClient.Informer()).WithSync(c.sync).ToController("UpgradeCheckController", eventRecorder.WithComponentSuffix("upgrade-check-controller"))
+}
+
+func (c *UpgradeCheckController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operator, err := c.operatorLister.Get("cluster")
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	operatorMeta, err := meta.Accessor(operator)
+	if err != nil {
+		return err
+	}
+	value, ok := operatorMeta.GetAnnotations()[Annotation]
+	if !ok {
+		return nil
+	}
+
+	window := DefaultWindow
+	if len(value) > 0 {
+		if window, err = time.ParseDuration(value); err != nil || window < 0 {
+			syncCtx.Recorder().Warningf("UpgradeCheckInvalidWindow", "Ignoring %s=%q: not a duration", Annotation, value)
+			return c.clearAnnotation(ctx, operatorMeta.GetName())
+		}
+	}
+
+	state, err := Gather(ctx, c.clients)
+	if err != nil {
+		// keep the annotation, the check is retried
+		return err
+	}
+	report := Check(state, c.now(), window)
+	var b strings.Builder
+	if err := report.Write(&b); err != nil {
+		return err
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.configMaps, syncCtx.Recorder(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.OperatorNamespace, Name: ReportConfigMapName},
+		Data: map[string]string{
+			"report":    b.String(),
+			"passed":    strconv.FormatBool(report.Passed()),
+			"checkedAt": c.now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if report.Passed() {
+		syncCtx.Recorder().Eventf("UpgradeCheckPassed", "Upgrade check passed, see configmap/%s -n %s", ReportConfigMapName, operatorclient.OperatorNamespace)
+	} else {
+		syncCtx.Recorder().Warningf("UpgradeCheckFailed", "Upgrade check failed %s, see configmap/%s -n %s", strings.Join(failed(report), ", "), ReportConfigMapName, operatorclient.OperatorNamespace)
+	}
+	return c.clearAnnotation(ctx, operatorMeta.GetName())
+}
+
+// failed returns the names of the failed criteria.
+func failed(report *Report) []string {
+	var names []string
+	for _, result := range report.Results {
+		if !result.Passed {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+func (c *UpgradeCheckController) clearAnnotation(ctx context.Context, name string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{Annotation: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.clients.KubeControllerManagers.KubeControllerManagers().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to remove the %s annotation: %w", Annotation, err)
+	}
+	return nil
+}
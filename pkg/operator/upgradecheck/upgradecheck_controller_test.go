@@ -0,0 +1,108 @@
+package upgradecheck
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+func TestUpgradeCheckController(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		annotation     *string
+		overrides      string
+		expectedPassed string
+		expectedEvents []string
+		expectPatch    bool
+	}{
+		{name: "not requested"},
+		{name: "passed", annotation: ptr.To("1h"), expectedPassed: "true", expectedEvents: []string{"ConfigMapCreated", "UpgradeCheckPassed"}, expectPatch: true},
+		{name: "failed with the default window", annotation: ptr.To(""), overrides: `{"a":"b"}`, expectedPassed: "false", expectedEvents: []string{"ConfigMapCreated", "UpgradeCheckFailed"}, expectPatch: true},
+		{name: "invalid window", annotation: ptr.To("soon"), expectedEvents: []string{"UpgradeCheckInvalidWindow"}, expectPatch: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operator := &unstructured.Unstructured{}
+			operator.SetAPIVersion("operator.openshift.io/v1")
+			operator.SetKind("KubeControllerManager")
+			operator.SetName("cluster")
+			if test.annotation != nil {
+				operator.SetAnnotations(map[string]string{Annotation: *test.annotation})
+			}
+			operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := operatorIndexer.Add(operator); err != nil {
+				t.Fatal(err)
+			}
+
+			typedOperator, objects := healthy(t, now)
+			typedOperator.Spec.UnsupportedConfigOverrides.Raw = []byte(test.overrides)
+			kubeClient := fake.NewSimpleClientset(objects...)
+			kubeControllerManagers := &fakeKubeControllerManagers{operator: typedOperator}
+			c := &UpgradeCheckController{
+				operatorLister: cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+				clients: Clients{
+					KubeControllerManagers: kubeControllerManagers,
+					Secrets:                kubeClient.CoreV1(),
+					Leases:                 kubeClient.CoordinationV1(),
+				},
+				configMaps: kubeClient.CoreV1(),
+				now:        func() time.Time { return now },
+			}
+
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("UpgradeCheckController", recorder)); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := kubeClient.CoreV1().ConfigMaps(operatorclient.OperatorNamespace).Get(context.TODO(), ReportConfigMapName, metav1.GetOptions{})
+			switch {
+			case len(test.expectedPassed) == 0 && err == nil:
+				t.Errorf("expected no report, got %v", report.Data)
+			case len(test.expectedPassed) > 0 && err != nil:
+				t.Fatal(err)
+			case len(test.expectedPassed) > 0:
+				if report.Data["passed"] != test.expectedPassed {
+					t.Errorf("expected passed %s, got %s", test.expectedPassed, report.Data["passed"])
+				}
+				if !strings.Contains(report.Data["report"], "nodes-at-latest-revision") {
+					t.Errorf("expected the criteria in the report, got %s", report.Data["report"])
+				}
+			}
+
+			var reasons []string
+			for _, event := range recorder.Events() {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(test.expectedEvents, reasons) {
+				t.Errorf("expected events %v, got %v", test.expectedEvents, reasons)
+			}
+
+			expectedPatches := 0
+			if test.expectPatch {
+				expectedPatches = 1
+			}
+			if len(kubeControllerManagers.patches) != expectedPatches {
+				t.Fatalf("expected %d patches, got %v", expectedPatches, kubeControllerManagers.patches)
+			}
+			if test.expectPatch && kubeControllerManagers.patches[0] != `{"metadata":{"annotations":{"operator.openshift.io/upgrade-check":null}}}` {
+				t.Errorf("unexpected patch %s", kubeControllerManagers.patches[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package upgradecheck
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorv1client "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/leadership"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+type fakeKubeControllerManagers struct {
+	operatorv1client.KubeControllerManagerInterface
+	operator *operatorv1.KubeControllerManager
+	patches  []string
+}
+
+func (f *fakeKubeControllerManagers) KubeControllerManagers() operatorv1client.KubeControllerManagerInterface {
+	return f
+}
+
+func (f *fakeKubeControllerManagers) Get(ctx context.Context, name string, opts metav1.GetOptions) (*operatorv1.KubeControllerManager, error) {
+	return f.operator, nil
+}
+
+func (f *fakeKubeControllerManagers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*operatorv1.KubeControllerManager, error) {
+	f.patches = append(f.patches, string(data))
+	return f.operator, nil
+}
+
+// tlsSecret returns a TLS secret with a certificate valid for a day.
+func tlsSecret(t *testing.T, namespace, name string) *corev1.Secret {
+	ca, err := crypto.MakeSelfSignedCAConfig(name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := ca.GetPEMBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+	}
+}
+
+func lease(namespace, name string, renewed time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("master-0_1234"),
+			LeaseDurationSeconds: ptr.To[int32](137),
+			RenewTime:            &metav1.MicroTime{Time: renewed},
+		},
+	}
+}
+
+// healthy returns the objects of a cluster passing every criterion at now.
+func healthy(t *testing.T, now time.Time) (*operatorv1.KubeControllerManager, []runtime.Object) {
+	operator := &operatorv1.KubeControllerManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: operatorv1.KubeControllerManagerStatus{StaticPodOperatorStatus: operatorv1.StaticPodOperatorStatus{
+			OperatorStatus: operatorv1.OperatorStatus{Conditions: []operatorv1.OperatorCondition{
+				{Type: "ConfigObservationDegraded", Status: operatorv1.ConditionFalse},
+			}},
+			LatestAvailableRevision: 7,
+			NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "master-0", CurrentRevision: 7},
+				{NodeName: "master-1", CurrentRevision: 7},
+			},
+		}},
+	}
+	return operator, []runtime.Object{
+		tlsSecret(t, operatorclient.TargetNamespace, "serving-cert"),
+		tlsSecret(t, operatorclient.OperatorNamespace, "csr-signer"),
+		// not a TLS secret
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorclient.TargetNamespace, Name: "service-account-private-key"}},
+		lease(operatorclient.OperatorNamespace, leadership.LockName, now.Add(-10*time.Second)),
+		lease("kube-system", "kube-controller-manager", now.Add(-5*time.Second)),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		mutate func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object
+		// expectedFailed are the failed criteria with the evidence of the failure
+		expectedFailed map[string]string
+	}{
+		{name: "all pass", window: time.Hour},
+		{
+			name:   "node behind",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				operator.Status.NodeStatuses[1].CurrentRevision = 6
+				return objects
+			},
+			expectedFailed: map[string]string{"nodes-at-latest-revision": "node master-1 is at revision 6, the latest is 7"},
+		},
+		{
+			name:   "node progressing",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				operator.Status.LatestAvailableRevision = 8
+				operator.Status.NodeStatuses[0].TargetRevision = 8
+				operator.Status.NodeStatuses[1].CurrentRevision = 8
+				return objects
+			},
+			expectedFailed: map[string]string{"nodes-at-latest-revision": "node master-0 is progressing from revision 7 to 8, the latest is 8"},
+		},
+		{
+			name:           "certificate expiring within the window",
+			window:         48 * time.Hour,
+			expectedFailed: map[string]string{"certificates-not-expiring": "secret/serving-cert -n openshift-kube-controller-manager: \"serving-cert\" expires at"},
+		},
+		{
+			name:   "unsupported config overrides",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				operator.Spec.UnsupportedConfigOverrides.Raw = []byte(`{"extendedArguments":{"kube-api-qps":["300"]}}`)
+				return objects
+			},
+			expectedFailed: map[string]string{"no-unsupported-config-overrides": `spec.unsupportedConfigOverrides is {"extendedArguments":{"kube-api-qps":["300"]}}`},
+		},
+		{
+			name:   "config observation errors",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				operator.Status.Conditions[0].Status = operatorv1.ConditionTrue
+				operator.Status.Conditions[0].Message = "observer cloud-provider: infrastructures.config.openshift.io \"cluster\" not found"
+				return objects
+			},
+			expectedFailed: map[string]string{"no-config-observation-errors": "ConfigObservationDegraded is True: observer cloud-provider"},
+		},
+		{
+			name:   "operand lease expired",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				objects[4] = lease("kube-system", "kube-controller-manager", now.Add(-5*time.Minute))
+				return objects
+			},
+			expectedFailed: map[string]string{"leader-election-stable": "lease/kube-controller-manager -n kube-system held by master-0_1234 was last renewed 5m0s ago, longer than its lease duration 2m17s"},
+		},
+		{
+			name:   "operator lease missing",
+			window: time.Hour,
+			mutate: func(operator *operatorv1.KubeControllerManager, objects []runtime.Object) []runtime.Object {
+				return append(objects[:3], objects[4])
+			},
+			expectedFailed: map[string]string{"leader-election-stable": "lease/kube-controller-manager-operator-lock -n openshift-kube-controller-manager-operator does not exist"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operator, objects := healthy(t, now)
+			if test.mutate != nil {
+				objects = test.mutate(operator, objects)
+			}
+			state, err := Gather(context.Background(), Clients{
+				KubeControllerManagers: &fakeKubeControllerManagers{operator: operator},
+				Secrets:                fake.NewSimpleClientset(objects...).CoreV1(),
+				Leases:                 fake.NewSimpleClientset(objects...).CoordinationV1(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := Check(state, now, test.window)
+
+			var names []string
+			for _, result := range report.Results {
+				names = append(names, result.Name)
+				expected, shouldFail := test.expectedFailed[result.Name]
+				if result.Passed == shouldFail {
+					t.Errorf("%s: expected passed %v, got %v: %v", result.Name, !shouldFail, result.Passed, result.Evidence)
+					continue
+				}
+				if shouldFail && !strings.Contains(strings.Join(result.Evidence, "\n"), expected) {
+					t.Errorf("%s: expected the evidence %q, got %q", result.Name, expected, result.Evidence)
+				}
+				if len(result.Evidence) == 0 {
+					t.Errorf("%s: no evidence", result.Name)
+				}
+			}
+			expectedNames := []string{"nodes-at-latest-revision", "certificates-not-expiring", "no-unsupported-config-overrides", "no-config-observation-errors", "leader-election-stable"}
+			if !reflect.DeepEqual(names, expectedNames) {
+				t.Errorf("expected the criteria %v, got %v", expectedNames, names)
+			}
+			if report.Passed() != (len(test.expectedFailed) == 0) {
+				t.Errorf("expected passed %v, got %v", len(test.expectedFailed) == 0, report.Passed())
+			}
+		})
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	report := &Report{Results: []Result{
+		{Name: "nodes-at-latest-revision", Passed: true, Evidence: []string{"node master-0 is at the latest revision 7"}, Remediation: "wait"},
+		{Name: "no-unsupported-config-overrides", Evidence: []string{"spec.unsupportedConfigOverrides is {}"}, Remediation: "remove them"},
+	}}
+	var b strings.Builder
+	if err := report.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `PASS nodes-at-latest-revision
+     node master-0 is at the latest revision 7
+FAIL no-unsupported-config-overrides
+     spec.unsupportedConfigOverrides is {}
+     remediation: remove them
+kube-controller-manager is not ready to upgrade
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
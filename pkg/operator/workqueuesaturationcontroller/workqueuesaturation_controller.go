@@ -0,0 +1,310 @@
+package workqueuesaturationcontroller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/transport"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/compat"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/overrides"
+)
+
+const (
+	controllerName = "WorkqueueSaturationController"
+
+	// EnableOverrideField of the unsupportedConfigOverrides enables scraping the workqueue metrics of the operand when
+	// it is true.
+	EnableOverrideField = "scrapeOperandWorkqueues"
+
+	// DefaultDepthThreshold is the depth of a workqueue above which its controller is considered saturated.
+	DefaultDepthThreshold = 100
+	// DefaultSaturationDuration is how long the depth has to stay above the threshold before it is reported.
+	DefaultSaturationDuration = 10 * time.Minute
+
+	// servingName is the name in the serving certificate of kube-controller-manager, see the service.
+	servingName = "kube-controller-manager.openshift-kube-controller-manager.svc"
+	metricsPort = "10257"
+)
+
+// workqueues are the workqueues of kube-controller-manager whose saturation is an early warning: nodes not being
+// marked unhealthy, pods of deleted owners piling up, rollouts and endpoints lagging behind.
+var workqueues = sets.New(
+	"node_lifecycle_controller",
+	"node_lifecycle_controller_pods",
+	"garbage_collector_attempt_to_delete",
+	"garbage_collector_attempt_to_orphan",
+	"namespace",
+	"resource_quota_primary",
+	"deployment",
+	"replicaset",
+	"daemonset",
+	"statefulset",
+	"job",
+	"endpoint",
+	"endpoint_slice",
+)
+
+var (
+	workqueueDepthMetric = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      "openshift",
+		Subsystem:      "kube_controller_manager",
+		Name:           "operand_workqueue_depth",
+		Help:           "Depth of a kube-controller-manager workqueue, as scraped from the operand on a node",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"node", "name"})
+	workqueueUnfinishedWorkMetric = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      "openshift",
+		Subsystem:      "kube_controller_manager",
+		Name:           "operand_workqueue_unfinished_work_seconds",
+		Help:           "Seconds of work in progress of a kube-controller-manager workqueue, as scraped from the operand on a node",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"node", "name"})
+)
+
+func init() {
+	legacyregistry.MustRegister(workqueueDepthMetric, workqueueUnfinishedWorkMetric)
+	overrides.Register(EnableOverrideField)
+}
+
+// workqueue is a workqueue of the operand on a node.
+type workqueue struct {
+	node string
+	name string
+}
+
+// WorkqueueSaturationController scrapes the workqueue metrics of the kube-controller-manager of every node,
+// republishes those of the curated workqueues with the node they were scraped from, and emits a warning event when
+// the depth of a workqueue stays above the threshold. Scraping is best effort, failures are logged and never affect
+// the conditions of the operator.
+type WorkqueueSaturationController struct {
+	operatorLister  cache.GenericLister
+	podLister       corev1listers.PodNamespaceLister
+	configMapLister corev1listers.ConfigMapNamespaceLister
+
+	depthThreshold     float64
+	saturationDuration time.Duration
+	now                func() time.Time
+	// newClient returns the client authenticating to the metrics endpoint of the operand
+	newClient func(ctx context.Context) (*http.Client, error)
+	// address returns the host and port of the metrics endpoint of an operand pod
+	address func(pod *corev1.Pod) string
+
+	// saturatedSince is when the depth of a workqueue exceeded the threshold
+	saturatedSince map[workqueue]time.Time
+	// reported are the saturated workqueues a warning was emitted for
+	reported sets.Set[workqueue]
+	// published are the workqueues the metrics currently have a value for
+	published sets.Set[workqueue]
+}
+
+func NewWorkqueueSaturationController(
+	operatorLister cache.GenericLister,
+	operatorInformer cache.SharedIndexInformer,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &WorkqueueSaturationController{
+		operatorLister:     operatorLister,
+		podLister:          kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Lister().Pods(operatorclient.TargetNamespace),
+		configMapLister:    kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().ConfigMaps().Lister().ConfigMaps(operatorclient.GlobalMachineSpecifiedConfigNamespace),
+		depthThreshold:     DefaultDepthThreshold,
+		saturationDuration: DefaultSaturationDuration,
+		now:                time.Now,
+		address: func(pod *corev1.Pod) string {
+			// the operand runs in the host network
+			return net.JoinHostPort(pod.Status.PodIP, metricsPort)
+		},
+		saturatedSince: map[workqueue]time.Time{},
+		reported:       sets.New[workqueue](),
+		published:      sets.New[workqueue](),
+	}
+	c.newClient = c.serviceCAClient
+
+	// the metrics are scraped on resync only, the informers are just for the listers
+	return factory.New().WithBareInformers(
+		operatorInformer,
+		kubeInformersForNamespaces.InformersFor(operatorclient.TargetNamespace).Core().V1().Pods().Informer(),
+		kubeInformersForNamespaces.InformersFor(operatorclient.GlobalMachineSpecifiedConfigNamespace).Core().V1().ConfigMaps().Informer(),
+	).ResyncEvery(time.Minute).WithSync(c.sync).ToController(controllerName, eventRecorder.WithComponentSuffix("workqueue-saturation-controller"))
+}
+
+func (c *WorkqueueSaturationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	enabled, err := c.enabled()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		c.unpublish(sets.New[string]())
+		return nil
+	}
+
+	pods, err := c.podLister.List(labels.SelectorFromSet(labels.Set{"app": "kube-controller-manager"}))
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(ctx)
+	if err != nil {
+		klog.Warningf("Not scraping the kube-controller-manager workqueues: %v", err)
+		c.unpublish(sets.New[string]())
+		return nil
+	}
+
+	scraped := sets.New[string]()
+	for _, pod := range pods {
+		if len(pod.Spec.NodeName) == 0 || len(pod.Status.PodIP) == 0 || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		depths, unfinishedWork, err := c.scrape(ctx, client, c.address(pod))
+		if err != nil {
+			klog.V(2).Infof("Failed to scrape the kube-controller-manager workqueues on node %s: %v", pod.Spec.NodeName, err)
+			continue
+		}
+		scraped.Insert(pod.Spec.NodeName)
+		for name, depth := range depths {
+			queue := workqueue{node: pod.Spec.NodeName, name: name}
+			workqueueDepthMetric.WithLabelValues(queue.node, queue.name).Set(depth)
+			if value, ok := unfinishedWork[name]; ok {
+				workqueueUnfinishedWorkMetric.WithLabelValues(queue.node, queue.name).Set(value)
+			}
+			c.published.Insert(queue)
+			c.track(syncCtx.Recorder(), queue, depth)
+		}
+	}
+	c.unpublish(scraped)
+	return nil
+}
+
+// track emits a warning when the depth of queue stayed above the threshold for the saturation duration, once per
+// saturation.
+func (c *WorkqueueSaturationController) track(recorder events.Recorder, queue workqueue, depth float64) {
+	if depth <= c.depthThreshold {
+		delete(c.saturatedSince, queue)
+		c.reported.Delete(queue)
+		return
+	}
+	since, ok := c.saturatedSince[queue]
+	if !ok {
+		c.saturatedSince[queue] = c.now()
+		return
+	}
+	if c.reported.Has(queue) || c.now().Sub(since) < c.saturationDuration {
+		return
+	}
+	recorder.Warningf("WorkqueueSaturated", "The %s workqueue of kube-controller-manager on node %s has been deeper than %v since %s, it is %v deep", queue.name, queue.node, c.depthThreshold, since.UTC().Format(time.RFC3339), depth)
+	c.reported.Insert(queue)
+}
+
+// unpublish removes the metrics and the saturation of the workqueues of the nodes that were not scraped.
+func (c *WorkqueueSaturationController) unpublish(scraped sets.Set[string]) {
+	for queue := range c.published {
+		if scraped.Has(queue.node) {
+			continue
+		}
+		workqueueDepthMetric.DeleteLabelValues(queue.node, queue.name)
+		workqueueUnfinishedWorkMetric.DeleteLabelValues(queue.node, queue.name)
+		c.published.Delete(queue)
+		delete(c.saturatedSince, queue)
+		c.reported.Delete(queue)
+	}
+}
+
+// scrape returns the depth and the unfinished work of the curated workqueues served at address.
+func (c *WorkqueueSaturationController) scrape(ctx context.Context, client *http.Client, address string) (map[string]float64, map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+address+"/metrics", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.FmtText))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s: %s", req.URL, resp.Status)
+	}
+
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return workqueueGauges(families["workqueue_depth"]), workqueueGauges(families["workqueue_unfinished_work_seconds"]), nil
+}
+
+// workqueueGauges returns the values of a workqueue gauge by the name of the curated workqueues.
+func workqueueGauges(family *dto.MetricFamily) map[string]float64 {
+	values := map[string]float64{}
+	if family == nil || family.GetType() != dto.MetricType_GAUGE {
+		return values
+	}
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "name" && workqueues.Has(label.GetValue()) {
+				values[label.GetValue()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+// serviceCAClient returns a client trusting the service CA, which signs the serving certificate of the operand, and
+// authenticating with the token of the operator. The token is read on every call, it is rotated.
+func (c *WorkqueueSaturationController) serviceCAClient(ctx context.Context) (*http.Client, error) {
+	token, err := compat.ServiceAccountToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading service account token: %w", err)
+	}
+	serviceCA, err := c.configMapLister.Get("service-ca")
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(serviceCA.Data["ca-bundle.crt"])) {
+		return nil, fmt.Errorf("configmap/service-ca -n %s has no certificates", operatorclient.GlobalMachineSpecifiedConfigNamespace)
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: transport.NewBearerAuthRoundTripper(string(token), &http.Transport{
+			TLSHandshakeTimeout: 5 * time.Second,
+			TLSClientConfig: &tls.Config{
+				RootCAs:    roots,
+				ServerName: servingName,
+			},
+		}),
+	}, nil
+}
+
+func (c *WorkqueueSaturationController) enabled() (bool, error) {
+	operator, err := c.operatorLister.Get("cluster")
+	if err != nil {
+		return false, err
+	}
+	unsupportedConfigOverrides, err := overrides.Of(operator)
+	if err != nil {
+		return false, err
+	}
+	return overrides.Bool(unsupportedConfigOverrides, EnableOverrideField)
+}
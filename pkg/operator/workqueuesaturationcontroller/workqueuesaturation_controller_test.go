@@ -0,0 +1,218 @@
+package workqueuesaturationcontroller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/transport"
+	"k8s.io/component-base/metrics/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"github.com/openshift/cluster-kube-controller-manager-operator/pkg/operator/operatorclient"
+)
+
+// fakeOperand serves workqueue metrics to the operator token only.
+type fakeOperand struct {
+	lock    sync.Mutex
+	depth   int
+	status  int
+	scrapes int
+}
+
+func (o *fakeOperand) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.scrapes++
+	if r.URL.Path != "/metrics" || r.Header.Get("Authorization") != "Bearer operator-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if o.status != 0 {
+		w.WriteHeader(o.status)
+		return
+	}
+	fmt.Fprintf(w, `# HELP workqueue_depth [ALPHA] Current depth of workqueue
+# TYPE workqueue_depth gauge
+workqueue_depth{name="node_lifecycle_controller"} %d
+workqueue_depth{name="DynamicServingCertificateController"} 500
+# HELP workqueue_unfinished_work_seconds [ALPHA] How many seconds of work has done that is in progress and hasn't been observed by work_duration.
+# TYPE workqueue_unfinished_work_seconds gauge
+workqueue_unfinished_work_seconds{name="node_lifecycle_controller"} 42.5
+# HELP workqueue_adds_total [ALPHA] Total number of adds handled by workqueue
+# TYPE workqueue_adds_total counter
+workqueue_adds_total{name="node_lifecycle_controller"} 1234
+`, o.depth)
+}
+
+func (o *fakeOperand) set(depth, status int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.depth, o.status = depth, status
+}
+
+func newTestController(t *testing.T, enabled bool, operand *fakeOperand) (*WorkqueueSaturationController, *time.Time) {
+	server := httptest.NewTLSServer(operand)
+	t.Cleanup(server.Close)
+
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	operator.SetName("cluster")
+	if enabled {
+		if err := unstructured.SetNestedField(operator.Object, true, "spec", "unsupportedConfigOverrides", EnableOverrideField); err != nil {
+			t.Fatal(err)
+		}
+	}
+	operatorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := operatorIndexer.Add(operator); err != nil {
+		t.Fatal(err)
+	}
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := podIndexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager-master-0", Namespace: operatorclient.TargetNamespace, Labels: map[string]string{"app": "kube-controller-manager"}},
+		Spec:       corev1.PodSpec{NodeName: "master-0"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &WorkqueueSaturationController{
+		operatorLister:     cache.NewGenericLister(operatorIndexer, operatorv1.GroupVersion.WithResource("kubecontrollermanagers").GroupResource()),
+		podLister:          corev1listers.NewPodLister(podIndexer).Pods(operatorclient.TargetNamespace),
+		depthThreshold:     DefaultDepthThreshold,
+		saturationDuration: DefaultSaturationDuration,
+		now:                func() time.Time { return now },
+		newClient: func(context.Context) (*http.Client, error) {
+			client := server.Client()
+			client.Transport = transport.NewBearerAuthRoundTripper("operator-token", client.Transport)
+			return client, nil
+		},
+		address:        func(*corev1.Pod) string { return strings.TrimPrefix(server.URL, "https://") },
+		saturatedSince: map[workqueue]time.Time{},
+		reported:       sets.New[workqueue](),
+		published:      sets.New[workqueue](),
+	}
+	t.Cleanup(func() { c.unpublish(sets.New[string]()) })
+	return c, &now
+}
+
+func saturationWarnings(recorder events.InMemoryRecorder) int {
+	var warnings int
+	for _, event := range recorder.Events() {
+		if event.Reason == "WorkqueueSaturated" {
+			warnings++
+		}
+	}
+	return warnings
+}
+
+func TestWorkqueueMetricsAreRepublished(t *testing.T) {
+	operand := &fakeOperand{depth: 7}
+	c, _ := newTestController(t, true, operand)
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	for metric, expected := range map[string]float64{"depth": 7, "unfinished work": 42.5} {
+		gauge := workqueueDepthMetric
+		if metric == "unfinished work" {
+			gauge = workqueueUnfinishedWorkMetric
+		}
+		value, err := testutil.GetGaugeMetricValue(gauge.WithLabelValues("master-0", "node_lifecycle_controller"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Errorf("expected the %s to be %v, got %v", metric, expected, value)
+		}
+	}
+	if !c.published.Equal(sets.New(workqueue{node: "master-0", name: "node_lifecycle_controller"})) {
+		t.Errorf("expected only the curated workqueues to be published, got %v", c.published.UnsortedList())
+	}
+}
+
+func TestSaturatedWorkqueueIsReported(t *testing.T) {
+	operand := &fakeOperand{depth: 150}
+	c, now := newTestController(t, true, operand)
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext(controllerName, recorder)
+
+	for _, step := range []struct {
+		after            time.Duration
+		depth            int
+		expectedWarnings int
+	}{
+		{after: 0, depth: 150},
+		{after: 5 * time.Minute, depth: 150},
+		{after: 5 * time.Minute, depth: 150, expectedWarnings: 1},
+		{after: time.Minute, depth: 200, expectedWarnings: 1},
+		// a new saturation is reported again
+		{after: time.Minute, depth: 20, expectedWarnings: 1},
+		{after: time.Minute, depth: 150, expectedWarnings: 1},
+		{after: 10 * time.Minute, depth: 150, expectedWarnings: 2},
+	} {
+		*now = now.Add(step.after)
+		operand.set(step.depth, 0)
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		if warnings := saturationWarnings(recorder); warnings != step.expectedWarnings {
+			t.Fatalf("at %s with depth %d: expected %d warnings, got %d", now.Format(time.RFC3339), step.depth, step.expectedWarnings, warnings)
+		}
+	}
+	if message := recorder.Events()[0].Message; !strings.Contains(message, "The node_lifecycle_controller workqueue of kube-controller-manager on node master-0 has been deeper than 100 since 2024-03-01T12:00:00Z") {
+		t.Errorf("unexpected warning %q", message)
+	}
+}
+
+func TestScrapeFailuresAreIgnored(t *testing.T) {
+	operand := &fakeOperand{depth: 150}
+	c, now := newTestController(t, true, operand)
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext(controllerName, recorder)
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	operand.set(150, http.StatusServiceUnavailable)
+	*now = now.Add(DefaultSaturationDuration)
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("expected scrape failures to be ignored, got %v", err)
+	}
+	if c.published.Len() != 0 {
+		t.Errorf("expected the metrics of the node to be removed, got %v", c.published.UnsortedList())
+	}
+	if warnings := saturationWarnings(recorder); warnings != 0 {
+		t.Errorf("expected no warning without samples, got %d", warnings)
+	}
+
+	c.newClient = func(context.Context) (*http.Client, error) { return nil, fmt.Errorf("no service CA") }
+	if err := c.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("expected a missing client to be ignored, got %v", err)
+	}
+}
+
+func TestScrapingIsOptional(t *testing.T) {
+	operand := &fakeOperand{depth: 150}
+	c, _ := newTestController(t, false, operand)
+	if err := c.sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+	if operand.scrapes != 0 {
+		t.Errorf("expected no scrapes, got %d", operand.scrapes)
+	}
+}